@@ -3,17 +3,54 @@ package api
 import "time"
 
 type Track struct {
-	ID        string        `json:"id"`
-	Title     string        `json:"title"`
-	Artist    string        `json:"artist"`
-	Album     string        `json:"album"`
-	Duration  time.Duration `json:"duration"`
-	FilePath  string        `json:"file_path"`
-	Genre     string        `json:"genre"`
-	Year      int           `json:"year"`
-	TrackNum  int           `json:"track_number"`
-	CoverArt  []byte        `json:"-"`
-	CreatedAt time.Time     `json:"created_at"`
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Artist      string        `json:"artist"`
+	Album       string        `json:"album"`
+	AlbumArtist string        `json:"album_artist,omitempty"` // From the album artist tag; empty if untagged
+	Duration    time.Duration `json:"duration"`
+	FilePath    string        `json:"file_path"`
+	URL         string        `json:"url,omitempty"` // HTTP source streamed instead of a file; FilePath holds it too
+	Genre       string        `json:"genre"`
+	Year        int           `json:"year"`
+	TrackNum    int           `json:"track_number"`
+	DiscNum     int           `json:"disc_number"`
+	BPM         float64       `json:"bpm,omitempty"`
+	BPMDetected bool          `json:"bpm_detected,omitempty"` // BPM was detected from the audio, not tagged
+	Size        int64         `json:"size,omitempty"`         // File size in bytes; 0 if unknown
+	TrimStart   time.Duration `json:"-"`                      // Playback start point, from the sidecar
+	TrimEnd     time.Duration `json:"-"`                      // Playback end point; zero means the natural end
+	Rating      int           `json:"-"`                      // Stars, 1 to MaxRating, from the sidecar; 0 is unrated
+	Gain        float64       `json:"-"`                      // Manual level offset in dB, from the sidecar, on top of ReplayGain
+
+	// ReplayGain adjustments in dB and peak sample levels (1.0 is full
+	// scale), from the file's tags; zero when untagged
+	TrackGain float64 `json:"track_gain,omitempty"`
+	TrackPeak float64 `json:"track_peak,omitempty"`
+	AlbumGain float64 `json:"album_gain,omitempty"`
+	AlbumPeak float64 `json:"album_peak,omitempty"`
+
+	// Chapters embedded in the file, in start order; nil when it has none
+	Chapters []Chapter `json:"chapters,omitempty"`
+
+	CoverArt  []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsStream reports whether the track is streamed from a URL rather than
+// read from a file on disk
+func (t *Track) IsStream() bool {
+	return t.URL != ""
+}
+
+// MaxRating is the most stars a track can be rated
+const MaxRating = 5
+
+// Chapter is a titled section of a track, such as an audiobook chapter or
+// a song within a mix
+type Chapter struct {
+	Title string        `json:"title"`
+	Start time.Duration `json:"start"`
 }
 
 type Playlist struct {
@@ -50,10 +87,37 @@ type PlaybackState struct {
 	Volume       float64       `json:"volume"` // 0.0 to 1.0
 	Repeat       RepeatMode    `json:"repeat"`
 	Shuffle      bool          `json:"shuffle"`
+	SkipSilence  bool          `json:"skip_silence"`
+	PreviewTrack *Track        `json:"preview_track,omitempty"` // Track being auditioned, if any
+	Preview      PreviewState  `json:"preview"`                 // How far the audition has got, while PreviewTrack is set
+	Loop         ABLoop        `json:"loop"`                    // A-B repeat of part of the current track
 	Queue        []*Track      `json:"queue"`
 	QueueIndex   int           `json:"queue_index"`
 }
 
+// PreviewState is the progress of a preview: how far into the snippet it
+// is, how long the snippet is, and whether it is paused
+type PreviewState struct {
+	Position time.Duration `json:"position"`
+	Length   time.Duration `json:"length"`
+	Paused   bool          `json:"paused"`
+}
+
+// ABLoop is an A-B repeat: once both points are set, playback reaching B
+// goes back to A. Either point may be set alone, so A at 0 is kept apart
+// from no A.
+type ABLoop struct {
+	A    time.Duration `json:"a"`
+	B    time.Duration `json:"b"`
+	HasA bool          `json:"has_a"`
+	HasB bool          `json:"has_b"`
+}
+
+// Active reports whether both points are set, so playback loops
+func (l ABLoop) Active() bool {
+	return l.HasA && l.HasB && l.B > l.A
+}
+
 // CommandType enumerates audio commands
 type CommandType int
 
@@ -66,6 +130,10 @@ const (
 	CmdVolume
 	CmdNext
 	CmdPrevious
+	CmdPreview
+	CmdStopPreview
+	CmdTogglePreview
+	CmdGain
 )
 
 // AudioCommand represents commands sent to the audio engine
@@ -83,6 +151,8 @@ const (
 	EventPositionUpdate
 	EventError
 	EventStateChange
+	EventTrackAdvanced // The engine moved on to the prepared next track by itself; Payload is the new *Track
+	EventStreamInfo    // A stream named its station or what it's playing; Payload is an audio.StreamInfo
 )
 
 // AudioEvent represents events emitted by the audio engine
@@ -91,13 +161,20 @@ type AudioEvent struct {
 	Payload interface{}
 }
 
-// Player defines the core playback interface
+// Player defines the core playback interface. Toggle pauses what is
+// playing and resumes what is paused. Position and Duration are those of
+// the current track, zero with none. Events delivers position updates,
+// the end of each track, errors and state changes as they happen.
 type Player interface {
 	Play(track *Track) error
 	Pause() error
 	Resume() error
+	Toggle() error
 	Stop() error
 	Seek(position time.Duration) error
 	SetVolume(level float64) error
+	Position() time.Duration
+	Duration() time.Duration
 	GetState() *PlaybackState
+	Events() <-chan AudioEvent
 }
@@ -1,18 +1,33 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/jscyril/golang_music_player/api"
 	"github.com/jscyril/golang_music_player/internal/audio"
 	"github.com/jscyril/golang_music_player/internal/config"
 	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/mpris"
 	"github.com/jscyril/golang_music_player/internal/playlist"
+	"github.com/jscyril/golang_music_player/internal/scrobble"
 	"github.com/jscyril/golang_music_player/internal/ui"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+	"github.com/jscyril/golang_music_player/pkg/timecode"
 )
 
 func main() {
@@ -23,12 +38,23 @@ func main() {
 }
 
 func run() error {
+	exportPath := flag.String("export", "", "write the library to a .csv or .json file and exit")
+	queueStdin := flag.Bool("queue-stdin", false, "read newline-separated file paths or URLs from stdin as the initial queue")
+	playArg := flag.String("play", "", "start playing an audio file or URL, an M3U/PLS playlist file, or a playlist (by name or ID)")
+	atArg := flag.String("at", "", "with -play, start at this position, e.g. 01:30")
+	flag.Parse()
+
 	// Load configuration
 	configPath := config.GetConfigPath()
 	cfg, err := config.LoadOrCreate(configPath)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", configPath, problem)
+		}
+	}
 
 	// Create data directory
 	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
@@ -49,7 +75,20 @@ func run() error {
 
 	// Initialize audio engine
 	audioEngine := audio.NewAudioEngine()
+	audioEngine.SetSilenceThreshold(cfg.SilenceThresholdDB)
+	audioEngine.SetTransition(cfg.Gapless, time.Duration(cfg.CrossfadeSeconds*float64(time.Second)))
+	audioEngine.SetFade(time.Duration(cfg.FadeSeconds * float64(time.Second)))
+	audioEngine.Equalizer().SetGains(cfg.EQGains)
+	audioEngine.Equalizer().SetBypass(cfg.EQBypass)
+	if mode, ok := audio.ParseReplayGainMode(cfg.ReplayGain); ok {
+		audioEngine.SetReplayGain(mode)
+	} else if cfg.ReplayGain != "" {
+		fmt.Fprintf(os.Stderr, "Warning: unknown replay_gain %q, using off\n", cfg.ReplayGain)
+	}
 	audioEngine.Start(ctx)
+	if err := audioEngine.SetVolume(cfg.DefaultVolume); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: default_volume: %v\n", err)
+	}
 
 	// Load persisted library (or create empty)
 	libraryPath := filepath.Join(cfg.DataDir, "library.json")
@@ -58,14 +97,63 @@ func run() error {
 		return fmt.Errorf("load library: %w", err)
 	}
 	fmt.Printf("Loaded %d tracks from library\n", lib.TotalTracks)
+	scanOpts := library.ScanOptions{
+		Recursive:      cfg.ScanRecursive,
+		MaxDepth:       cfg.ScanMaxDepth,
+		FollowSymlinks: cfg.FollowSymlinks,
+		IncludeHidden:  cfg.ScanHidden,
+		Ignore:         cfg.ScanIgnore,
+	}
+	if err := library.CheckIgnorePatterns(scanOpts.Ignore); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: scan_ignore: %v\n", err)
+	}
+	lib.SetScanOptions(scanOpts)
 
-	// Scan only if library is empty and directories are configured
-	if lib.TotalTracks == 0 && len(cfg.MusicDirectories) > 0 {
+	// Reuse tags parsed on earlier runs for files that haven't changed
+	if cfg.EnableCache && cfg.CachePath != "" {
+		cachePath := cfg.CachePath
+		if !filepath.IsAbs(cachePath) {
+			cachePath = filepath.Join(filepath.Dir(configPath), cachePath)
+		}
+		cache, err := library.LoadMetadataCache(filepath.Join(cachePath, "metadata.json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			lib.SetMetadataCache(cache)
+			logger.Info("Loaded cached metadata for %d files", cache.Len())
+			defer func() {
+				if err := cache.Save(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: save metadata cache: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	// Scan only if the library is empty, or its first scan was cut short,
+	// and directories are configured. The UI scans in the background,
+	// showing tracks as they're found, unless they're needed before it
+	// starts.
+	needScan := (lib.TotalTracks == 0 || lib.ScanUnfinished()) && len(cfg.MusicDirectories) > 0
+	backgroundScan := needScan && *exportPath == "" && *playArg == "" && !*queueStdin
+	if needScan && !backgroundScan {
 		fmt.Println("Library empty, scanning music directories...")
-		if err := lib.Scan(ctx, cfg.MusicDirectories); err != nil {
+		skipped, err := lib.Scan(ctx, cfg.MusicDirectories)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: scan error: %v\n", err)
 		}
-		fmt.Printf("Found %d tracks\n", lib.TotalTracks)
+		for _, s := range skipped {
+			logger.Warn("Skipped during scan: %v", s)
+		}
+		if len(skipped) > 0 {
+			fmt.Printf("Found %d tracks, %d skipped (see log)\n", lib.TotalTracks, len(skipped))
+		} else {
+			fmt.Printf("Found %d tracks\n", lib.TotalTracks)
+		}
+	}
+
+	dedup, ok := library.ParseDedupMode(cfg.Dedup)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: unknown dedup %q, using off\n", cfg.Dedup)
 	}
 
 	// Save library on exit
@@ -75,6 +163,64 @@ func run() error {
 		}
 	}()
 
+	// Load per-file sidecar data (analysis cache) kept outside the tags
+	sidecar, err := library.LoadSidecar(filepath.Join(cfg.DataDir, "sidecar.json"))
+	if err != nil {
+		return fmt.Errorf("load sidecar: %w", err)
+	}
+	lib.SetSidecar(sidecar)
+	if cfg.ITunesLibrary != "" {
+		trims, err := library.LoadITunesTrims(cfg.ITunesLibrary)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			lib.SetITunesTrims(trims)
+			logger.Info("Loaded %d iTunes start/stop times", trims.Len())
+		}
+	}
+	defer func() {
+		if err := sidecar.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: save sidecar: %v\n", err)
+		}
+	}()
+
+	// Load play counts for the Recently Played and Most Played views
+	playStats, err := library.LoadPlayStats(filepath.Join(cfg.DataDir, "play_stats.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if *exportPath != "" {
+		return exportLibrary(lib, playStats, *exportPath)
+	}
+
+	// Load where tracks were last left off, dropping stale positions
+	var positions *library.Positions
+	if cfg.RememberPositions {
+		positions, err = library.LoadPositions(filepath.Join(cfg.DataDir, "positions.json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			if cfg.ResumeMaxAgeDays > 0 {
+				maxAge := time.Duration(cfg.ResumeMaxAgeDays) * 24 * time.Hour
+				if n := positions.Prune(maxAge, time.Now()); n > 0 {
+					logger.Info("Pruned %d saved positions older than %d days", n, cfg.ResumeMaxAgeDays)
+				}
+			}
+			defer func() {
+				if err := positions.Save(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: save positions: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	// Load bookmarks within tracks; without them the rest still works
+	bookmarks, err := library.LoadBookmarks(filepath.Join(cfg.DataDir, "bookmarks.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	// Initialize playlist manager
 	playlistPath := filepath.Join(cfg.DataDir, "playlists")
 	plManager := playlist.NewManager(playlistPath)
@@ -82,10 +228,376 @@ func run() error {
 		fmt.Fprintf(os.Stderr, "Warning: load playlists: %v\n", err)
 	}
 
+	// Load smart playlist rules; definitions that don't parse are skipped
+	smartPlaylists, err := playlist.LoadSmartPlaylists(filepath.Join(cfg.DataDir, "smart_playlists.json"))
+	if err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Warning: smart_playlists: %s\n", problem)
+		}
+	}
+
 	// Run UI
-	if err := ui.Run(audioEngine, lib, plManager); err != nil {
+	opts := ui.Options{
+		TrackDelay:      time.Duration(cfg.TrackDelaySeconds * float64(time.Second)),
+		OrganizeRoot:    cfg.OrganizeRoot,
+		OrganizePattern: cfg.OrganizePattern,
+	}
+	if opts.OrganizeRoot == "" && len(cfg.MusicDirectories) > 0 {
+		opts.OrganizeRoot = cfg.MusicDirectories[0]
+	}
+	if tint, ok := views.ParseRowTint(cfg.RowTint); ok {
+		opts.RowTint = tint
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown row_tint %q, using none\n", cfg.RowTint)
+	}
+	if cfg.LibraryColumns != "" {
+		if columns, err := components.ParseColumns(cfg.LibraryColumns); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: library_columns: %v\n", err)
+		} else {
+			opts.LibraryColumns = columns
+		}
+	}
+	if action, ok := ui.ParseEnterAction(cfg.EnterAction); ok {
+		opts.EnterAction = action
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown enter_action %q, using replace_queue\n", cfg.EnterAction)
+	}
+	opts.ConfirmReplaceQueue = cfg.ConfirmReplaceQueue
+	keys, err := ui.NewKeymap(cfg.KeyBindings.Bindings())
+	if err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Warning: key_bindings: %s\n", problem)
+		}
+	}
+	opts.Keys = keys
+	if field, ok := views.ParseSortField(cfg.DefaultSort); ok {
+		opts.DefaultSort = field
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown default_sort %q, using none\n", cfg.DefaultSort)
+	}
+	if mode, ok := ui.ParseRepeatMode(cfg.DefaultRepeat); ok {
+		opts.Repeat = mode
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown default_repeat %q, using off\n", cfg.DefaultRepeat)
+	}
+	opts.Shuffle = cfg.DefaultShuffle
+	opts.Volume = cfg.DefaultVolume
+	opts.Positions = positions
+	if backgroundScan {
+		opts.ScanDirectories = cfg.MusicDirectories
+	}
+	opts.Dedup = dedup
+	opts.Bookmarks = bookmarks
+	opts.PlayStats = playStats
+	opts.SmartPlaylists = smartPlaylists
+	opts.SleepQuit = cfg.SleepQuit
+	opts.PlaylistExportDir = cfg.PlaylistExportDir
+	if opts.PlaylistExportDir == "" {
+		opts.PlaylistExportDir = filepath.Join(cfg.DataDir, "m3u")
+	}
+	opts.ShuffleSpread = cfg.ShuffleSpread
+	opts.PreviewOffset = cfg.PreviewOffset
+	opts.PreviewLength = time.Duration(cfg.PreviewSeconds * float64(time.Second))
+	opts.Prelisten = cfg.Prelisten
+	opts.PrelistenDelay = time.Duration(cfg.PrelistenDelay * float64(time.Second))
+	opts.PrelistenVolume = cfg.PrelistenVolume
+	if opts.PreviewLength <= 0 {
+		opts.PreviewLength = 10 * time.Second
+	}
+	if mode, ok := components.ParseTimeMode(cfg.TimeMode); ok {
+		opts.TimeMode = mode
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown time_mode %q, using elapsed\n", cfg.TimeMode)
+	}
+	opts.SnapSeek = cfg.SnapSeek
+	opts.ExactAccents = cfg.SearchExactAccents
+	opts.SeekStep = time.Duration(cfg.SeekStepSeconds * float64(time.Second))
+	opts.SeekStepLong = time.Duration(cfg.SeekLongStepSeconds * float64(time.Second))
+	if cfg.AlbumArt == "" || cfg.AlbumArt == "auto" {
+		opts.AlbumArt = components.DetectImageProtocol(os.Getenv)
+	} else if protocol, ok := components.ParseImageProtocol(cfg.AlbumArt); ok {
+		opts.AlbumArt = protocol
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown album_art %q, using off\n", cfg.AlbumArt)
+	}
+	opts.OnTimeModeChange = func(mode components.TimeMode) {
+		cfg.TimeMode = mode.String()
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			logger.Warn("Failed to save time mode: %v", err)
+		}
+	}
+	opts.OnEqualizerChange = func(gains []float64, bypass bool) {
+		cfg.EQGains, cfg.EQBypass = gains, bypass
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			logger.Warn("Failed to save equalizer settings: %v", err)
+		}
+	}
+	historyPath := filepath.Join(cfg.DataDir, "search_history.json")
+	if history, err := loadSearchHistory(historyPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		opts.SearchHistory = history
+	}
+	opts.OnSearchHistoryChange = func(history []string) {
+		if err := saveSearchHistory(historyPath, history); err != nil {
+			logger.Warn("Failed to save search history: %v", err)
+		}
+	}
+
+	// Pick up where the last session left off; a session that can't be
+	// read is started afresh
+	sessionPath := filepath.Join(filepath.Dir(configPath), "session.json")
+	if session, err := ui.LoadSession(sessionPath); err != nil {
+		logger.Warn("Ignoring saved session: %v", err)
+	} else {
+		opts.Session = session
+	}
+	var sessionMu sync.Mutex
+	opts.OnSessionSave = func(session ui.Session) {
+		sessionMu.Lock()
+		defer sessionMu.Unlock()
+		if err := session.Save(sessionPath); err != nil {
+			logger.Warn("Failed to save session: %v", err)
+		}
+	}
+	if theme, ok := components.ThemeByName(cfg.Theme); ok {
+		opts.Theme = theme
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unknown theme %q, using Default\n", cfg.Theme)
+	}
+	opts.OnThemeChange = func(theme components.Theme) {
+		cfg.Theme = theme.Name
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			logger.Warn("Failed to save theme: %v", err)
+		}
+	}
+	opts.OnReplayGainChange = func(mode audio.ReplayGainMode) {
+		cfg.ReplayGain = mode.String()
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			logger.Warn("Failed to save ReplayGain mode: %v", err)
+		}
+	}
+	opts.OnLibraryFolderChange = func(dir string) {
+		cfg.MusicDirectories = []string{dir}
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			logger.Warn("Failed to save music folder: %v", err)
+		}
+	}
+	if cfg.WatchLibrary && len(cfg.MusicDirectories) > 0 {
+		watcher := library.NewWatcher(cfg.MusicDirectories, time.Duration(cfg.WatchIntervalSeconds*float64(time.Second)))
+		watcher.SetScanOptions(scanOpts)
+		watcher.Start(ctx)
+		defer watcher.Stop()
+		opts.Watcher = watcher
+	}
+	if cfg.MPRIS {
+		// Without a session bus, e.g. over SSH or on a console, play on without it
+		if server, err := mpris.Connect(); err != nil {
+			logger.Info("MPRIS disabled: %v", err)
+		} else {
+			defer server.Close()
+			opts.MPRIS = server
+		}
+	}
+	opts.Notifications = cfg.Notifications
+	opts.NotifyCover = filepath.Join(cfg.DataDir, "notify_cover")
+	if scrobbler := newScrobbler(cfg); scrobbler != nil {
+		scrobbler.Start(ctx)
+		defer scrobbler.Stop()
+		opts.Scrobbler = scrobbler
+		opts.OnScrobbleLogin = func(service, sessionKey string) {
+			if service != "lastfm" {
+				return
+			}
+			cfg.LastFMSessionKey, cfg.LastFMPassword = sessionKey, ""
+			if err := config.SaveConfig(cfg, configPath); err != nil {
+				logger.Warn("Failed to save last.fm session key: %v", err)
+			}
+		}
+	}
+	if *queueStdin {
+		tracks, err := readQueue(lib, os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read queue from stdin: %w", err)
+		}
+		opts.InitialQueue = tracks
+		opts.InputTTY = true
+	}
+	if *playArg != "" {
+		tracks, err := resolvePlay(lib, plManager, *playArg)
+		if err != nil {
+			// Start normally rather than failing a window-manager shortcut
+			logger.Warn("Cannot play %s: %v", *playArg, err)
+			fmt.Fprintf(os.Stderr, "Warning: cannot play %s: %v\n", *playArg, err)
+		} else {
+			opts.InitialQueue = tracks
+			opts.AutoPlay = true
+		}
+	}
+	if *atArg != "" && opts.AutoPlay {
+		at, err := timecode.Parse(*atArg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring -at: %v\n", err)
+		} else {
+			opts.StartAt = at
+		}
+	}
+
+	if err := ui.Run(audioEngine, lib, plManager, opts); err != nil {
 		return fmt.Errorf("run ui: %w", err)
 	}
 
 	return nil
 }
+
+// newScrobbler sets up scrobbling to the services with credentials in
+// cfg, or returns nil if there are none. A Last.fm username and password
+// are exchanged for a session key in the background; the UI saves it in
+// their place through OnScrobbleLogin.
+func newScrobbler(cfg *config.Config) *scrobble.Scrobbler {
+	var services []scrobble.Service
+	if cfg.LastFMAPIKey != "" && cfg.LastFMAPISecret != "" {
+		lastfm := scrobble.NewLastFM(cfg.LastFMAPIKey, cfg.LastFMAPISecret, cfg.LastFMSessionKey)
+		lastfm.Username, lastfm.Password = cfg.LastFMUsername, cfg.LastFMPassword
+		if lastfm.SessionKey != "" || lastfm.Username != "" && lastfm.Password != "" {
+			services = append(services, lastfm)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: last.fm scrobbling needs lastfm_session_key, or lastfm_username and lastfm_password\n")
+		}
+	}
+	if cfg.ListenBrainzToken != "" {
+		services = append(services, scrobble.NewListenBrainz(cfg.ListenBrainzToken))
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	scrobbler, err := scrobble.NewScrobbler(filepath.Join(cfg.DataDir, "scrobbles.json"), services...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	return scrobbler
+}
+
+// loadSearchHistory reads the saved search queries, most recent first; a
+// missing file is an empty history
+func loadSearchHistory(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read search history: %w", err)
+	}
+	var history []string
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("unmarshal search history: %w", err)
+	}
+	return history, nil
+}
+
+// saveSearchHistory writes the search queries to path, creating its folder
+// if needed and replacing the file in one go
+func saveSearchHistory(path string, history []string) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal search history: %w", err)
+	}
+	if err := atomicfile.WriteData(path, data); err != nil {
+		return fmt.Errorf("write search history: %w", err)
+	}
+	return nil
+}
+
+// exportLibrary writes every library track to path with its play count from
+// playStats, when they loaded, choosing CSV or JSON from the file extension
+func exportLibrary(lib *library.Library, playStats *library.PlayStats, path string) error {
+	format, err := library.FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	defer file.Close()
+
+	tracks := lib.GetAllTracks()
+	var stats library.ListeningStats
+	if playStats != nil {
+		stats = playStats.ListeningStats(tracks)
+	}
+	if err := library.ExportLibrary(file, tracks, stats, format); err != nil {
+		return fmt.Errorf("export library: %w", err)
+	}
+	fmt.Printf("Exported %d tracks to %s\n", len(tracks), path)
+	return file.Close()
+}
+
+// readQueue reads newline-separated file paths and builds tracks for them.
+// Unusable paths are reported and skipped.
+func readQueue(lib *library.Library, r io.Reader) ([]*api.Track, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimSpace(scanner.Text()); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	tracks, errs := lib.ReadTracks(paths)
+	for _, err := range errs {
+		logger.Warn("Skipping queued path: %v", err)
+		fmt.Fprintf(os.Stderr, "Warning: skipping %v\n", err)
+	}
+	fmt.Printf("Queued %d of %d paths from stdin\n", len(tracks), len(paths))
+	return tracks, nil
+}
+
+// resolvePlay builds the queue for -play: an audio file on disk or an http
+// URL to stream, or else a playlist matched by ID or case-insensitive name
+func resolvePlay(lib *library.Library, plManager *playlist.Manager, arg string) ([]*api.Track, error) {
+	if library.IsStreamURL(arg) {
+		return []*api.Track{library.NewStreamTrack(arg)}, nil
+	}
+	if _, err := os.Stat(arg); err == nil && library.IsPlaylistFile(arg) {
+		tracks, errs, err := lib.ReadPlaylist(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, err := range errs {
+			logger.Warn("Skipping playlist entry: %v", err)
+		}
+		if len(tracks) == 0 {
+			return nil, fmt.Errorf("no playable tracks in %s", arg)
+		}
+		return tracks, nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		tracks, errs := lib.ReadTracks([]string{arg})
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+		return tracks, nil
+	}
+
+	for _, pl := range plManager.GetAll() {
+		if pl.ID != arg && !strings.EqualFold(pl.Name, arg) {
+			continue
+		}
+		if len(pl.Tracks) == 0 {
+			return nil, fmt.Errorf("playlist %q is empty", pl.Name)
+		}
+		tracks := make([]*api.Track, len(pl.Tracks))
+		for i := range pl.Tracks {
+			tracks[i] = &pl.Tracks[i]
+		}
+		return tracks, nil
+	}
+	return nil, fmt.Errorf("no such file or playlist")
+}
@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// analyzerWindow is how many of the latest samples a spectrum is worked
+// out from, a power of two for the FFT: about 23ms at 44.1kHz
+const analyzerWindow = 1024
+
+// Spectrum band edges in Hz, and the level range bands are scaled over
+const (
+	spectrumLowHz  = 40.0
+	spectrumHighHz = 16000.0
+	spectrumFloor  = -60.0 // dB shown as an empty band
+)
+
+// Analyzer keeps the latest samples played, mixed to mono, for Spectrum
+// to show what is playing. Samples are only copied as they stream past;
+// the spectrum is worked out when it is asked for.
+type Analyzer struct {
+	mu   sync.Mutex
+	buf  [analyzerWindow]float64
+	pos  int // Where the next sample goes in buf
+	rate beep.SampleRate
+}
+
+// NewAnalyzer returns an analyzer holding silence
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{rate: 44100}
+}
+
+// SetSampleRate sets the rate of the samples streamed through the analyzer
+func (a *Analyzer) SetSampleRate(rate beep.SampleRate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rate = rate
+}
+
+// Wrap returns s with the samples it streams copied into the analyzer
+func (a *Analyzer) Wrap(s beep.Streamer) beep.Streamer {
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		n, ok := s.Stream(samples)
+		a.mu.Lock()
+		for _, sample := range samples[:n] {
+			a.buf[a.pos] = (sample[0] + sample[1]) / 2
+			a.pos = (a.pos + 1) % analyzerWindow
+		}
+		a.mu.Unlock()
+		return n, ok
+	})
+}
+
+// Reset forgets the samples held, as when playback stops
+func (a *Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.buf = [analyzerWindow]float64{}
+	a.pos = 0
+}
+
+// Spectrum returns the level of bands frequency bands of the latest
+// samples, from 0 for silence to 1 for a full-scale tone. The bands are
+// spaced evenly in pitch from 40Hz to 16kHz.
+func (a *Analyzer) Spectrum(bands int) []float64 {
+	if bands <= 0 {
+		return nil
+	}
+	x := make([]complex128, analyzerWindow)
+	a.mu.Lock()
+	rate := float64(a.rate)
+	for i := range x {
+		// Hann window, oldest sample first
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(analyzerWindow-1))
+		x[i] = complex(a.buf[(a.pos+i)%analyzerWindow]*w, 0)
+	}
+	a.mu.Unlock()
+	fft(x)
+
+	levels := make([]float64, bands)
+	binHz := rate / analyzerWindow
+	ratio := math.Pow(spectrumHighHz/spectrumLowHz, 1/float64(bands))
+	for b := range levels {
+		lo := int(spectrumLowHz * math.Pow(ratio, float64(b)) / binHz)
+		hi := int(spectrumLowHz * math.Pow(ratio, float64(b+1)) / binHz)
+		hi = min(max(hi, lo+1), analyzerWindow/2)
+		peak := 0.0
+		for k := lo; k < hi; k++ {
+			peak = max(peak, cmplx.Abs(x[k]))
+		}
+		// A Hann window sums to half the window, so a full-scale sine's
+		// bin comes to a quarter of it
+		db := 20 * math.Log10(peak/(analyzerWindow/4)+1e-12)
+		levels[b] = min(max((db-spectrumFloor)/-spectrumFloor, 0), 1)
+	}
+	return levels
+}
+
+// fft transforms x in place, whose length must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, -2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+}
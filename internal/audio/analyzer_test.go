@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+func TestAnalyzer_SpectrumFindsTone(t *testing.T) {
+	a := NewAnalyzer()
+	if levels := a.Spectrum(16); levels[8] != 0 {
+		t.Fatalf("silence gave %v", levels)
+	}
+
+	// A full-scale 1 kHz tone, streamed through in small chunks
+	i := 0
+	tone := beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for j := range samples {
+			v := math.Sin(2 * math.Pi * 1000 * float64(i) / 44100)
+			samples[j] = [2]float64{v, v}
+			i++
+		}
+		return len(samples), true
+	})
+	s := a.Wrap(tone)
+	buf := make([][2]float64, 256)
+	for range 8 {
+		s.Stream(buf)
+	}
+
+	levels := a.Spectrum(16)
+	loudest := 0
+	for b, level := range levels {
+		if level > levels[loudest] {
+			loudest = b
+		}
+	}
+	// 1 kHz is band 8 of 16 spaced from 40 Hz to 16 kHz
+	if loudest != 8 || levels[8] < 0.9 {
+		t.Errorf("loudest band %d at %.2f, want band 8 near 1: %v", loudest, levels[loudest], levels)
+	}
+	if levels[0] > 0.3 || levels[15] > 0.3 {
+		t.Errorf("far bands not quiet: %v", levels)
+	}
+
+	a.Reset()
+	if levels := a.Spectrum(16); levels[8] != 0 {
+		t.Errorf("reset gave %v", levels)
+	}
+}
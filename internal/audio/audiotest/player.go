@@ -0,0 +1,287 @@
+// Package audiotest provides a stand-in for the audio engine, for testing
+// code that drives playback without opening files or a sound device.
+package audiotest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// Player plays nothing but keeps the state the engine would, sending its
+// events as it changes. Time stands still until Advance moves it on, and
+// a track ends only when Finish says so. It is safe for concurrent use.
+type Player struct {
+	mu         sync.Mutex
+	state      api.PlaybackState
+	events     chan api.AudioEvent
+	next       *api.Track
+	replayGain audio.ReplayGainMode
+	eq         *audio.Equalizer
+
+	// Played is every track Play was given, in order
+	Played []*api.Track
+}
+
+// NewPlayer returns a stopped player at full volume
+func NewPlayer() *Player {
+	return &Player{
+		state:  api.PlaybackState{Status: api.StatusStopped, Volume: 1},
+		events: make(chan api.AudioEvent, 100),
+		eq:     audio.NewEqualizer(),
+	}
+}
+
+// send queues an event, dropping it if nobody is reading them
+func (p *Player) send(t api.EventType, payload any) {
+	select {
+	case p.events <- api.AudioEvent{Type: t, Payload: payload}:
+	default:
+	}
+}
+
+func (p *Player) Play(track *api.Track) error {
+	if track == nil {
+		return playerrors.ErrTrackNotFound
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Played = append(p.Played, track)
+	p.state.CurrentTrack = track
+	p.state.Status = api.StatusPlaying
+	p.state.Position = track.TrimStart
+	p.state.Loop = api.ABLoop{}
+	p.send(api.EventTrackStarted, track)
+	return nil
+}
+
+func (p *Player) Pause() error {
+	return p.setStatus(api.StatusPlaying, api.StatusPaused)
+}
+
+func (p *Player) Resume() error {
+	return p.setStatus(api.StatusPaused, api.StatusPlaying)
+}
+
+// setStatus moves from one status to another, doing nothing from any other
+func (p *Player) setStatus(from, to api.PlayerStatus) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Status == from {
+		p.state.Status = to
+		p.send(api.EventStateChange, nil)
+	}
+	return nil
+}
+
+// Toggle pauses while playing and resumes while paused. Stopped, it plays
+// the track last loaded from the start, if there is one
+func (p *Player) Toggle() error {
+	state := p.GetState()
+	switch {
+	case state.Status == api.StatusPlaying:
+		return p.Pause()
+	case state.Status == api.StatusPaused:
+		return p.Resume()
+	case state.CurrentTrack != nil:
+		return p.Play(state.CurrentTrack)
+	}
+	return playerrors.ErrTrackNotFound
+}
+
+func (p *Player) FadeOutAndPause(time.Duration) error {
+	return p.Pause()
+}
+
+func (p *Player) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Status = api.StatusStopped
+	p.state.Position = 0
+	p.state.Loop = api.ABLoop{}
+	p.send(api.EventStateChange, nil)
+	return nil
+}
+
+// Seek moves within the current track, clamped to its length
+func (p *Player) Seek(position time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.CurrentTrack == nil {
+		return nil
+	}
+	p.state.Position = p.clamp(position)
+	p.send(api.EventPositionUpdate, p.state.Position)
+	return nil
+}
+
+// clamp keeps pos within the current track
+func (p *Player) clamp(pos time.Duration) time.Duration {
+	pos = max(pos, 0)
+	if d := p.state.CurrentTrack.Duration; d > 0 {
+		pos = min(pos, d)
+	}
+	return pos
+}
+
+func (p *Player) SetVolume(level float64) error {
+	if level < 0 || level > 1 {
+		return playerrors.ErrInvalidVolume
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Volume = level
+	return nil
+}
+
+func (p *Player) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state.Position
+}
+
+func (p *Player) Duration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.CurrentTrack == nil {
+		return 0
+	}
+	return p.state.CurrentTrack.Duration
+}
+
+func (p *Player) GetState() *api.PlaybackState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := p.state
+	return &state
+}
+
+func (p *Player) Events() <-chan api.AudioEvent {
+	return p.events
+}
+
+// Advance moves playback d further into the current track while playing
+func (p *Player) Advance(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Status != api.StatusPlaying || p.state.CurrentTrack == nil {
+		return
+	}
+	p.state.Position = p.clamp(p.state.Position + d)
+	p.send(api.EventPositionUpdate, p.state.Position)
+}
+
+// Finish ends the current track as if it had played out. A track set with
+// SetNext carries on in its place, as the engine does gaplessly.
+func (p *Player) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ended := p.state.CurrentTrack
+	if ended == nil {
+		return
+	}
+	if next := p.next; next != nil {
+		p.next = nil
+		p.Played = append(p.Played, next)
+		p.state.CurrentTrack, p.state.Position = next, next.TrimStart
+		p.send(api.EventTrackAdvanced, next)
+		return
+	}
+	p.state.Status = api.StatusStopped
+	p.state.Position = 0
+	p.send(api.EventTrackEnded, ended)
+}
+
+func (p *Player) Preview(track *api.Track, offset float64, length time.Duration, volume float64) error {
+	if track == nil {
+		return playerrors.ErrTrackNotFound
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.PreviewTrack = track
+	p.state.Preview = api.PreviewState{Length: length}
+	p.send(api.EventStateChange, nil)
+	return nil
+}
+
+func (p *Player) StopPreview() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.PreviewTrack = nil
+	p.state.Preview = api.PreviewState{}
+	p.send(api.EventStateChange, nil)
+	return nil
+}
+
+func (p *Player) TogglePreview() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.PreviewTrack != nil {
+		p.state.Preview.Paused = !p.state.Preview.Paused
+		p.send(api.EventStateChange, nil)
+	}
+	return nil
+}
+
+// SetNext sets the track Finish carries on with; join is ignored
+func (p *Player) SetNext(track *api.Track, join bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next = track
+}
+
+func (p *Player) SetLoopA(pos time.Duration) {
+	p.setLoop(func(loop *api.ABLoop) { loop.A, loop.HasA = pos, true })
+}
+
+func (p *Player) SetLoopB(pos time.Duration) {
+	p.setLoop(func(loop *api.ABLoop) { loop.B, loop.HasB = pos, true })
+}
+
+func (p *Player) ClearLoop() {
+	p.setLoop(func(loop *api.ABLoop) { *loop = api.ABLoop{} })
+}
+
+// setLoop changes the loop points, keeping A before B as the engine does
+func (p *Player) setLoop(change func(*api.ABLoop)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	loop := &p.state.Loop
+	change(loop)
+	if loop.HasA && loop.HasB && loop.B < loop.A {
+		loop.A, loop.B = loop.B, loop.A
+	}
+}
+
+func (p *Player) SetSkipSilence(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.SkipSilence = enabled
+}
+
+func (p *Player) SetReplayGain(mode audio.ReplayGainMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replayGain = mode
+}
+
+func (p *Player) ReplayGain() audio.ReplayGainMode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.replayGain
+}
+
+// SetGain does nothing, as nothing is heard
+func (p *Player) SetGain(filePath string, db float64) {}
+
+func (p *Player) Equalizer() *audio.Equalizer {
+	return p.eq
+}
+
+// Spectrum returns silence in every band
+func (p *Player) Spectrum(bands int) []float64 {
+	return make([]float64, max(bands, 0))
+}
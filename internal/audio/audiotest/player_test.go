@@ -0,0 +1,65 @@
+package audiotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestPlayer_PlaysThroughATrack(t *testing.T) {
+	p := NewPlayer()
+	track := &api.Track{ID: "a", Title: "A", Duration: time.Minute}
+	if err := p.Toggle(); err == nil {
+		t.Error("Toggle with nothing loaded gave no error")
+	}
+	if err := p.Play(track); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-p.Events(); ev.Type != api.EventTrackStarted {
+		t.Errorf("first event = %v, want track started", ev.Type)
+	}
+
+	p.Advance(20 * time.Second)
+	if p.Position() != 20*time.Second || p.Duration() != time.Minute {
+		t.Errorf("at %v of %v, want 20s of 1m", p.Position(), p.Duration())
+	}
+	p.Toggle()
+	p.Advance(time.Hour)
+	if s := p.GetState(); s.Status != api.StatusPaused || s.Position != 20*time.Second {
+		t.Errorf("after Toggle: %v at %v, want paused at 20s", s.Status, s.Position)
+	}
+	p.Toggle()
+	p.Seek(2 * time.Minute)
+	if p.Position() != time.Minute {
+		t.Errorf("seek past the end left position at %v", p.Position())
+	}
+
+	p.Finish()
+	var ended bool
+	for len(p.Events()) > 0 {
+		if ev := <-p.Events(); ev.Type == api.EventTrackEnded && ev.Payload == track {
+			ended = true
+		}
+	}
+	if !ended || p.GetState().Status != api.StatusStopped {
+		t.Errorf("Finish: ended = %v, status %v", ended, p.GetState().Status)
+	}
+}
+
+func TestPlayer_FinishCarriesOnWithNext(t *testing.T) {
+	p := NewPlayer()
+	a, b := &api.Track{ID: "a"}, &api.Track{ID: "b"}
+	p.Play(a)
+	p.SetNext(b, false)
+	p.Finish()
+	if s := p.GetState(); s.CurrentTrack != b || s.Status != api.StatusPlaying {
+		t.Errorf("after Finish: %v %v, want b playing", s.CurrentTrack, s.Status)
+	}
+	if len(p.Played) != 2 {
+		t.Errorf("Played = %v, want a then b", p.Played)
+	}
+	if err := p.SetVolume(2); err == nil {
+		t.Error("volume out of range accepted")
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"github.com/faiface/beep"
 	"github.com/faiface/beep/flac"
 	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
 	"github.com/faiface/beep/wav"
 	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
 )
 
 // SupportedFormats returns list of supported audio formats
 func SupportedFormats() []string {
-	return []string{".mp3", ".wav", ".flac"}
+	return []string{".mp3", ".wav", ".flac", ".ogg"}
 }
 
 // IsSupported checks if a file format is supported
@@ -29,8 +30,9 @@ func IsSupported(filePath string) bool {
 	return false
 }
 
-// DecodeAudio decodes an audio file based on its extension
-func DecodeAudio(r io.ReadSeekCloser, filePath string) (beep.StreamSeekCloser, beep.Format, error) {
+// DecodeAudio decodes an audio file based on its extension. Only readers
+// that can seek, such as files, give decoders that can seek.
+func DecodeAudio(r io.ReadCloser, filePath string) (beep.StreamSeekCloser, beep.Format, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
@@ -40,6 +42,8 @@ func DecodeAudio(r io.ReadSeekCloser, filePath string) (beep.StreamSeekCloser, b
 		return wav.Decode(r)
 	case ".flac":
 		return flac.Decode(r)
+	case ".ogg":
+		return vorbis.Decode(r)
 	default:
 		return nil, beep.Format{}, fmt.Errorf("%w: %s", playerrors.ErrInvalidFormat, ext)
 	}
@@ -3,7 +3,6 @@ package audio
 import (
 	"context"
 	"fmt"
-	"os"
 	"sync"
 	"time"
 
@@ -17,18 +16,68 @@ import (
 
 var _ api.Player = (*AudioEngine)(nil)
 
+// PlaybackError is the payload of EventError: a track that couldn't be
+// opened or decoded to play or preview
+type PlaybackError struct {
+	Track   *api.Track
+	Preview bool // The track was to be previewed, not played
+	Err     error
+}
+
+func (e *PlaybackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PlaybackError) Unwrap() error {
+	return e.Err
+}
+
 type AudioEngine struct {
-	state      *api.PlaybackState
-	commands   chan api.AudioCommand
-	events     chan api.AudioEvent
-	mu         sync.RWMutex
-	streamer   beep.StreamSeekCloser
-	ctrl       *beep.Ctrl
-	volume     *effects.Volume
-	format     beep.Format
-	done       chan struct{}
-	sampleRate beep.SampleRate // speaker sample rate (fixed at init)
-	trackRate  beep.SampleRate // current track's native sample rate
+	state       *api.PlaybackState
+	commands    chan api.AudioCommand
+	events      chan api.AudioEvent
+	streamInfos chan StreamInfo    // From the decoding stream, for run to send on
+	stopped     chan *fadeStreamer // Fader a stop has faded out, for run to clear
+	mu          sync.RWMutex
+	streamer    beep.StreamSeekCloser
+	ctrl        *beep.Ctrl
+	volume      *effects.Volume
+	format      beep.Format
+	done        chan struct{}
+	sampleRate  beep.SampleRate // speaker sample rate (fixed at init)
+	trackRate   beep.SampleRate // current track's native sample rate
+
+	silenceThresholdDB float64 // level below which audio counts as silent
+	replayGain         ReplayGainMode
+	gains              map[string]float64 // Manual offsets in dB that SetGain gave, by file path
+	eq                 *Equalizer
+	analyzer           *Analyzer
+
+	// Transitions: with gapless or a crossfade set, nextTrack is opened
+	// shortly before the current track ends and started by transition
+	// without a gap. Otherwise, or when it isn't to be joined on, it is
+	// opened into preload all the same, for Play to start without delay.
+	transition *transitionStreamer
+	nextTrack  *api.Track
+	nextJoin   bool       // Join nextTrack onto the current one
+	nextFailed *api.Track // nextTrack couldn't be opened; don't retry it
+	preload    *preloadedTrack
+	gapless    bool
+	crossfade  time.Duration
+
+	// fader fades playback in when it starts or resumes and out when it
+	// pauses or stops, over fade; with fade 0 it passes audio through
+	fader *fadeStreamer
+	fade  time.Duration
+
+	// Preview stream, played while main playback is paused
+	previewStreamer    beep.StreamSeekCloser
+	previewCtrl        *beep.Ctrl
+	previewVolume      *effects.Volume
+	previewStart       int             // Sample the snippet starts at
+	previewRate        beep.SampleRate // The previewed file's sample rate
+	previewGen         int
+	resumeAfterPreview bool // Main playback was playing when the preview started
 }
 
 func NewAudioEngine() *AudioEngine {
@@ -38,9 +87,15 @@ func NewAudioEngine() *AudioEngine {
 			Volume: 0.5,
 			Repeat: api.RepeatNone,
 		},
-		commands: make(chan api.AudioCommand, 10),
-		events:   make(chan api.AudioEvent, 20),
-		done:     make(chan struct{}),
+		commands:           make(chan api.AudioCommand, 10),
+		events:             make(chan api.AudioEvent, 20),
+		streamInfos:        make(chan StreamInfo, 8),
+		stopped:            make(chan *fadeStreamer, 8),
+		done:               make(chan struct{}),
+		silenceThresholdDB: DefaultSilenceThresholdDB,
+		gains:              make(map[string]float64),
+		eq:                 NewEqualizer(),
+		analyzer:           NewAnalyzer(),
 	}
 }
 
@@ -48,6 +103,8 @@ func (e *AudioEngine) Start(ctx context.Context) error {
 	// Initialize the speaker ONCE with a standard sample rate.
 	// Calling speaker.Init() more than once causes the oto backend to panic.
 	e.sampleRate = beep.SampleRate(44100)
+	e.eq.SetSampleRate(e.sampleRate)
+	e.analyzer.SetSampleRate(e.sampleRate)
 	if err := speaker.Init(e.sampleRate, e.sampleRate.N(time.Second/10)); err != nil {
 		logger.Error("Speaker init failed: %v", err)
 		return fmt.Errorf("speaker init: %w", err)
@@ -69,6 +126,18 @@ func (e *AudioEngine) run(ctx context.Context) {
 			e.cleanup()
 			return
 
+		case info := <-e.streamInfos:
+			e.events <- api.AudioEvent{Type: api.EventStreamInfo, Payload: info}
+
+		case fader := <-e.stopped:
+			// Unless another track has started since
+			e.mu.RLock()
+			current := e.fader == fader
+			e.mu.RUnlock()
+			if current {
+				e.stopPlayback()
+			}
+
 		case cmd := <-e.commands:
 			switch cmd.Type {
 			case api.CmdPlay:
@@ -76,17 +145,30 @@ func (e *AudioEngine) run(ctx context.Context) {
 				logger.Info("Play command received: %q by %s (%s)", track.Title, track.Artist, track.FilePath)
 				if err := e.playTrack(track); err != nil {
 					logger.Error("Failed to play track %q: %v", track.Title, err)
-					e.events <- api.AudioEvent{Type: api.EventError, Payload: err}
+					// Playback stopped for the track that failed; it
+					// never started, so none is current
+					e.mu.Lock()
+					e.state.CurrentTrack = nil
+					e.mu.Unlock()
+					e.events <- api.AudioEvent{Type: api.EventError, Payload: &PlaybackError{Track: track, Err: err}}
 				}
 
 			case api.CmdPause:
 				logger.Debug("Pause command received")
 				speaker.Lock()
 				e.mu.Lock()
-				if e.ctrl != nil {
-					e.ctrl.Paused = true
+				fade := e.fade
+				if d, ok := cmd.Payload.(time.Duration); ok {
+					fade = max(d, 0)
+				}
+				if e.ctrl != nil && e.state.Status != api.StatusStopped {
+					// The status changes now; the stream pauses once faded.
+					// A track fading out to stop is left to stop.
+					ctrl := e.ctrl
+					e.fader.fadeTo(0, e.sampleRate.N(fade), func() { ctrl.Paused = true })
 					e.state.Status = api.StatusPaused
 				}
+				e.resumeAfterPreview = false
 				e.mu.Unlock()
 				speaker.Unlock()
 				e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
@@ -94,16 +176,21 @@ func (e *AudioEngine) run(ctx context.Context) {
 			case api.CmdResume:
 				speaker.Lock()
 				e.mu.Lock()
-				if e.ctrl != nil {
+				if e.ctrl != nil && e.state.Status != api.StatusStopped {
+					// Also cancels the pause if it's still fading out
 					e.ctrl.Paused = false
+					e.fader.fadeTo(1, e.sampleRate.N(e.fade), nil)
 					e.state.Status = api.StatusPlaying
 				}
+				e.resumeAfterPreview = false
 				e.mu.Unlock()
 				speaker.Unlock()
 				e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
 
 			case api.CmdStop:
-				e.stopPlayback()
+				if !e.fadeOut() {
+					e.stopPlayback()
+				}
 				e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
 
 			case api.CmdVolume:
@@ -113,6 +200,11 @@ func (e *AudioEngine) run(ctx context.Context) {
 				if e.volume != nil {
 					// Convert 0-1 range to decibel-like scale
 					e.volume.Volume = level*2 - 1 // -1 to 1 range
+					e.volume.Silent = level <= 0
+				}
+				if e.previewVolume != nil {
+					e.previewVolume.Volume = level*2 - 1
+					e.previewVolume.Silent = level <= 0
 				}
 				e.state.Volume = level
 				e.mu.Unlock()
@@ -121,6 +213,28 @@ func (e *AudioEngine) run(ctx context.Context) {
 			case api.CmdSeek:
 				pos := cmd.Payload.(time.Duration)
 				e.seekTo(pos)
+
+			case api.CmdPreview:
+				req := cmd.Payload.(previewRequest)
+				if err := e.startPreview(req); err != nil {
+					logger.Error("Failed to preview %q: %v", req.track.Title, err)
+					e.events <- api.AudioEvent{Type: api.EventError, Payload: &PlaybackError{Track: req.track, Preview: true, Err: err}}
+				}
+
+			case api.CmdStopPreview:
+				e.stopPreview(cmd.Payload)
+
+			case api.CmdTogglePreview:
+				e.togglePreview()
+
+			case api.CmdGain:
+				req := cmd.Payload.(gainRequest)
+				speaker.Lock()
+				e.mu.Lock()
+				e.gains[req.filePath] = req.db
+				e.applyGain()
+				e.mu.Unlock()
+				speaker.Unlock()
 			}
 		}
 	}
@@ -136,17 +250,23 @@ func (e *AudioEngine) trackPosition(ctx context.Context) {
 			return
 		case <-ticker.C:
 			speaker.Lock()
-			e.mu.RLock()
+			e.mu.Lock()
 			if e.state.Status == api.StatusPlaying && e.streamer != nil {
 				pos := e.streamer.Position()
 				e.state.Position = e.trackRate.D(pos)
 			}
-			e.mu.RUnlock()
+			previewing := e.previewStreamer != nil && !e.state.Preview.Paused
+			if previewing {
+				e.state.Preview.Position = e.previewRate.D(e.previewStreamer.Position() - e.previewStart)
+			}
+			e.mu.Unlock()
 			speaker.Unlock()
 
+			e.prepareNext()
+
 			// Send event outside of locks to avoid blocking
 			e.mu.RLock()
-			if e.state.Status == api.StatusPlaying {
+			if e.state.Status == api.StatusPlaying || previewing {
 				e.events <- api.AudioEvent{
 					Type:    api.EventPositionUpdate,
 					Payload: e.state.Position,
@@ -161,58 +281,167 @@ func (e *AudioEngine) playTrack(track *api.Track) error {
 	logger.Debug("Stopping previous playback before starting new track")
 	e.stopPlayback()
 
-	file, err := os.Open(track.FilePath)
+	ts, startPos := e.takePreload(track)
+	if ts == nil {
+		var err error
+		if ts, startPos, err = e.openTrack(track); err != nil {
+			return err
+		}
+	}
+	format := ts.format
+
+	e.mu.Lock()
+	e.streamer = ts.decoder
+	e.format = format
+	e.trackRate = format.SampleRate
+	e.transition = &transitionStreamer{
+		cur:       ts,
+		rate:      e.sampleRate,
+		fade:      e.sampleRate.N(e.crossfade),
+		onAdvance: e.advanced,
+	}
+	e.ctrl = &beep.Ctrl{Streamer: e.analyzer.Wrap(e.eq.Wrap(e.transition)), Paused: false}
+	e.fader = newFadeStreamer(e.ctrl)
+	if e.fade > 0 {
+		e.fader.level = 0
+		e.fader.fadeTo(1, e.sampleRate.N(e.fade), nil)
+	}
+	e.volume = &effects.Volume{
+		Streamer: e.fader,
+		Base:     2,
+		Volume:   e.state.Volume*2 - 1,
+		Silent:   e.state.Volume <= 0,
+	}
+	e.state.CurrentTrack = track
+	e.state.Status = api.StatusPlaying
+	e.state.Position = format.SampleRate.D(startPos)
+	e.state.Loop = api.ABLoop{}
+	e.mu.Unlock()
+
+	speaker.Play(beep.Seq(e.volume, beep.Callback(func() {
+		// Only reached when no next track took over
+		e.mu.RLock()
+		ended, stopping, fader := e.state.CurrentTrack, e.state.Status == api.StatusStopped, e.fader
+		e.mu.RUnlock()
+		if stopping {
+			// Ended while fading out to stop
+			e.clearStopped(fader)
+			return
+		}
+		logger.Info("Track ended: %q", ended.Title)
+		e.events <- api.AudioEvent{Type: api.EventTrackEnded, Payload: ended}
+	})))
+
+	logger.Info("Track started: %q by %s", track.Title, track.Artist)
+	e.events <- api.AudioEvent{Type: api.EventTrackStarted, Payload: track}
+	return nil
+}
+
+// openTrack opens and decodes track, ready to play at the speaker's rate,
+// and returns the decoder position it starts from
+func (e *AudioEngine) openTrack(track *api.Track) (*trackStream, int, error) {
+	file, name, err := e.openSource(track)
 	if err != nil {
-		logger.Error("Failed to open file %s: %v", track.FilePath, err)
-		return playerrors.NewPlayerError("open", track.ID, err)
+		logger.Error("Failed to open %s: %v", track.FilePath, err)
+		return nil, 0, playerrors.NewPlayerError("open", track.ID, err)
 	}
 
-	streamer, format, err := DecodeAudio(file, track.FilePath)
+	streamer, format, err := DecodeAudio(file, name)
 	if err != nil {
 		file.Close()
 		logger.Error("Failed to decode %s: %v", track.FilePath, err)
-		return playerrors.NewPlayerError("decode", track.ID, err)
+		return nil, 0, playerrors.NewPlayerError("decode", track.ID, err)
 	}
 
 	logger.Debug("Decoded track: sample_rate=%d, channels=%d", format.SampleRate, format.NumChannels)
 
+	e.mu.RLock()
+	skipSilence, thresholdDB, gainMode := e.state.SkipSilence, e.silenceThresholdDB, e.replayGain
+	offset := e.gainOffset(track)
+	e.mu.RUnlock()
+
+	// Work out the playable region: the track's trim points, optionally
+	// narrowed to skip leading and trailing silence. The decoder itself is
+	// seeked, so positions stay true file time. Streams play as they come,
+	// to an end that is 0 when unknown.
+	startPos, endPos := 0, streamer.Len()
+	trimStart, trimEnd := track.TrimStart, track.TrimEnd
+	if track.IsStream() {
+		trimStart, trimEnd, skipSilence = 0, 0, false
+	}
+	if trimStart > 0 {
+		startPos = format.SampleRate.N(trimStart)
+	}
+	if trimEnd > 0 {
+		endPos = min(endPos, format.SampleRate.N(trimEnd))
+	}
+	if skipSilence {
+		start, end, err := findSilenceBounds(track.FilePath, thresholdDB)
+		if err != nil {
+			logger.Warn("Silence detection failed for %s: %v", track.FilePath, err)
+		} else {
+			if start > startPos {
+				logger.Debug("Skipping %v of leading silence", format.SampleRate.D(start-startPos))
+			}
+			startPos, endPos = max(startPos, start), min(endPos, end)
+		}
+	}
+	if startPos >= endPos {
+		startPos, endPos = 0, streamer.Len()
+	}
+	if startPos > 0 {
+		if err := streamer.Seek(startPos); err != nil {
+			logger.Warn("Failed to seek to start point of %s: %v", track.FilePath, err)
+			startPos = 0
+		}
+	}
+
+	var src beep.Streamer = streamer
+	if endPos < streamer.Len() {
+		src = &untilStreamer{s: streamer, end: endPos}
+	}
+	loop := &loopStreamer{s: src, dec: streamer}
+	src = loop
+
 	// If the track's sample rate differs from the speaker's initialized rate,
 	// wrap it in a resampler so we never need to call speaker.Init() again.
-	var src beep.Streamer = streamer
 	if format.SampleRate != e.sampleRate {
 		logger.Info("Resampling track from %d to %d Hz", format.SampleRate, e.sampleRate)
-		src = beep.Resample(4, format.SampleRate, e.sampleRate, streamer)
+		src = beep.Resample(4, format.SampleRate, e.sampleRate, src)
 	}
+	gain := &gainStreamer{s: src}
+	gain.gain, gain.limit = replayGainFactor(track, gainMode, offset)
 
-	e.mu.Lock()
-	e.streamer = streamer
-	e.format = format
-	e.trackRate = format.SampleRate
-	e.ctrl = &beep.Ctrl{Streamer: src, Paused: false}
-	e.volume = &effects.Volume{
-		Streamer: e.ctrl,
-		Base:     2,
-		Volume:   e.state.Volume*2 - 1,
-		Silent:   false,
-	}
-	e.state.CurrentTrack = track
 	// Backfill duration from the decoded stream if the track was scanned
 	// before duration computation was added (e.g. loaded from a cached library).
+	e.mu.Lock()
 	if track.Duration == 0 && format.SampleRate > 0 && streamer.Len() > 0 {
 		track.Duration = format.SampleRate.D(streamer.Len())
 	}
-	e.state.Status = api.StatusPlaying
-	e.state.Position = 0
 	e.mu.Unlock()
 
-	speaker.Play(beep.Seq(e.volume, beep.Callback(func() {
-		logger.Info("Track ended: %q", track.Title)
-		e.events <- api.AudioEvent{Type: api.EventTrackEnded, Payload: track}
-	})))
+	return &trackStream{track: track, decoder: streamer, format: format, src: gain, gain: gain, loop: loop, end: endPos}, startPos, nil
+}
 
-	logger.Info("Track started: %q by %s", track.Title, track.Artist)
-	e.events <- api.AudioEvent{Type: api.EventTrackStarted, Payload: track}
-	return nil
+// advanced is called on the speaker goroutine, with the speaker locked,
+// when the prepared next track has taken over from the current one
+func (e *AudioEngine) advanced(from, to *trackStream) {
+	e.mu.Lock()
+	e.streamer = to.decoder
+	e.format = to.format
+	e.trackRate = to.format.SampleRate
+	e.state.CurrentTrack = to.track
+	e.state.Position = to.format.SampleRate.D(to.decoder.Position())
+	e.state.Loop = api.ABLoop{}
+	e.nextTrack = nil
+	e.mu.Unlock()
+
+	logger.Info("Track advanced: %q by %s", to.track.Title, to.track.Artist)
+	// Don't block the speaker
+	go func() {
+		from.decoder.Close()
+		e.events <- api.AudioEvent{Type: api.EventTrackAdvanced, Payload: to.track}
+	}()
 }
 
 func (e *AudioEngine) stopPlayback() {
@@ -224,15 +453,30 @@ func (e *AudioEngine) stopPlayback() {
 	streamer := e.streamer
 	e.streamer = nil
 	e.ctrl = nil
+	e.fader = nil
 	e.volume = nil
+	var next *trackStream
+	if e.transition != nil {
+		next = e.transition.next
+	}
+	e.transition = nil
 	e.state.Status = api.StatusStopped
 	e.state.Position = 0
+	e.state.Loop = api.ABLoop{}
+	e.resumeAfterPreview = false
 	e.mu.Unlock()
 
-	// Close streamer outside of locks
+	e.analyzer.Reset()
+
+	// Close streamers outside of locks
 	if streamer != nil {
 		streamer.Close()
 	}
+	if next != nil {
+		next.decoder.Close()
+	}
+	// speaker.Clear also ended any preview
+	e.closePreview()
 }
 
 func (e *AudioEngine) seekTo(pos time.Duration) {
@@ -241,23 +485,53 @@ func (e *AudioEngine) seekTo(pos time.Duration) {
 	defer e.mu.Unlock()
 	defer speaker.Unlock()
 
-	if e.streamer != nil {
-		newPos := e.trackRate.N(pos)
-		if newPos < 0 {
-			newPos = 0
+	// Seeking during a crossfade cancels it; the next track is opened
+	// again as the end nears
+	if e.transition != nil {
+		if next := e.transition.cancelFade(); next != nil {
+			logger.Debug("Seek cancelled crossfade into %q", next.track.Title)
+			go next.decoder.Close()
 		}
-		if length := e.streamer.Len(); newPos >= length {
-			newPos = length - 1
+	}
+
+	if e.state.CurrentTrack != nil && e.state.CurrentTrack.IsStream() {
+		logger.Debug("Ignoring seek in stream %s", e.state.CurrentTrack.URL)
+		return
+	}
+	if e.streamer != nil {
+		end := e.streamer.Len()
+		if e.transition != nil && e.transition.cur.decoder == e.streamer {
+			end = e.transition.cur.end
 		}
+		newPos, atEnd := seekTarget(e.trackRate.N(pos), end)
 		if err := e.streamer.Seek(newPos); err == nil {
-			e.state.Position = pos
+			e.state.Position = e.trackRate.D(newPos)
+		}
+		// Seeking to the very end pauses there rather than ending the
+		// track, so it isn't treated as finished; resuming plays it out
+		if atEnd && e.ctrl != nil {
+			e.ctrl.Paused = true
+			e.state.Status = api.StatusPaused
 		}
 	}
 }
 
+// seekTarget clamps a seek to pos, in samples, to a track whose playable
+// region ends at end. A seek at or past the end lands on its last sample
+// and reports atEnd.
+func seekTarget(pos, end int) (target int, atEnd bool) {
+	if pos >= end-1 {
+		return max(end-1, 0), true
+	}
+	return max(pos, 0), false
+}
+
 func (e *AudioEngine) cleanup() {
 	logger.Info("Audio engine shutting down")
 	e.stopPlayback()
+	e.mu.Lock()
+	e.dropPreload()
+	e.mu.Unlock()
 	close(e.events)
 }
 
@@ -273,11 +547,52 @@ func (e *AudioEngine) Pause() error {
 	e.commands <- api.AudioCommand{Type: api.CmdPause}
 	return nil
 }
+
+// FadeOutAndPause pauses playback after fading it out over d, however
+// long the fade set by SetFade is. The status is paused straight away;
+// resuming during the fade turns it back up.
+func (e *AudioEngine) FadeOutAndPause(d time.Duration) error {
+	e.commands <- api.AudioCommand{Type: api.CmdPause, Payload: d}
+	return nil
+}
+
 func (e *AudioEngine) Resume() error {
 	e.commands <- api.AudioCommand{Type: api.CmdResume}
 	return nil
 }
 
+// Toggle pauses while playing and resumes while paused. Stopped, it plays
+// the track last loaded from the start, if there is one.
+func (e *AudioEngine) Toggle() error {
+	state := e.GetState()
+	switch {
+	case state.Status == api.StatusPlaying:
+		return e.Pause()
+	case state.Status == api.StatusPaused:
+		return e.Resume()
+	case state.CurrentTrack != nil:
+		return e.Play(state.CurrentTrack)
+	}
+	return playerrors.ErrTrackNotFound
+}
+
+// Position returns how far into the current track playback is
+func (e *AudioEngine) Position() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.state.Position
+}
+
+// Duration returns the current track's length, or zero with none
+func (e *AudioEngine) Duration() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.state.CurrentTrack == nil {
+		return 0
+	}
+	return e.state.CurrentTrack.Duration
+}
+
 func (e *AudioEngine) Stop() error {
 	e.commands <- api.AudioCommand{Type: api.CmdStop}
 	return nil
@@ -296,6 +611,237 @@ func (e *AudioEngine) SetVolume(level float64) error {
 	return nil
 }
 
+// SetSkipSilence toggles skipping leading and trailing silence. It takes
+// effect from the next track that starts; a preloaded one is opened again.
+func (e *AudioEngine) SetSkipSilence(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state.SkipSilence = enabled
+	e.dropPreload()
+}
+
+// SetTransition sets how consecutive tracks join: with a crossfade they
+// overlap for that long (capped at MaxCrossfade), the first fading out as
+// the second fades in; otherwise with gapless the second starts on the
+// sample after the first ends. With neither, the engine stops at the end of
+// each track and the caller starts the next. The next track is the one
+// given to SetNext. It takes effect from the next track that starts.
+func (e *AudioEngine) SetTransition(gapless bool, crossfade time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gapless = gapless
+	e.crossfade = min(max(crossfade, 0), MaxCrossfade)
+}
+
+// SetFade sets how long playback fades in when it starts or resumes and
+// out when it pauses or stops, capped at MaxFade; 0 switches fading off.
+// Seeking isn't faded, nor is a track following on through SetTransition.
+// It takes effect from the next play, pause, resume or stop.
+func (e *AudioEngine) SetFade(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fade = min(max(d, 0), MaxFade)
+}
+
+// fadeOut starts fading out playing audio to stop it, so stopping doesn't
+// cut it off mid-note, and reports whether it did. The status is stopped
+// straight away; playback is cleared by run once the fade, or the track,
+// has ended. With fading off or nothing playing it does nothing.
+func (e *AudioEngine) fadeOut() bool {
+	speaker.Lock()
+	defer speaker.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.fade <= 0 || e.fader == nil || e.state.Status != api.StatusPlaying {
+		return false
+	}
+	fader, ctrl := e.fader, e.ctrl
+	fader.fadeTo(0, e.sampleRate.N(e.fade), func() {
+		ctrl.Paused = true
+		e.clearStopped(fader)
+	})
+	e.state.Status = api.StatusStopped
+	e.state.Position = 0
+	e.state.Loop = api.ABLoop{}
+	e.resumeAfterPreview = false
+	return true
+}
+
+// clearStopped hands run the fader of playback that has stopped, to clear
+// it. It is called on the speaker goroutine, so it mustn't block.
+func (e *AudioEngine) clearStopped(fader *fadeStreamer) {
+	select {
+	case e.stopped <- fader:
+	default:
+	}
+}
+
+// SetReplayGain selects the ReplayGain adjustment. It applies straight away
+// to the playing track and to one prepared or preloaded to follow it.
+func (e *AudioEngine) SetReplayGain(mode ReplayGainMode) {
+	speaker.Lock()
+	e.mu.Lock()
+	e.replayGain = mode
+	e.applyGain()
+	e.mu.Unlock()
+	speaker.Unlock()
+}
+
+// gainRequest is the payload of CmdGain
+type gainRequest struct {
+	filePath string
+	db       float64
+}
+
+// SetGain sets the manual level offset, in dB, of the file at filePath.
+// It applies straight away to the playing track and to one prepared or
+// preloaded to follow it, and to the file's later plays. It is applied on
+// the run loop, which from then on uses it instead of the track's Gain.
+func (e *AudioEngine) SetGain(filePath string, db float64) {
+	e.commands <- api.AudioCommand{Type: api.CmdGain, Payload: gainRequest{filePath, db}}
+}
+
+// gainOffset returns the manual level offset track plays at. Callers must
+// hold e.mu.
+func (e *AudioEngine) gainOffset(track *api.Track) float64 {
+	if db, ok := e.gains[track.FilePath]; ok {
+		return db
+	}
+	return track.Gain
+}
+
+// applyGain sets the gain of the playing track and of one prepared or
+// preloaded to follow it. Callers must hold the speaker lock and e.mu.
+func (e *AudioEngine) applyGain() {
+	var streams []*trackStream
+	if x := e.transition; x != nil {
+		streams = append(streams, x.cur, x.next)
+	}
+	if e.preload != nil {
+		streams = append(streams, e.preload.ts)
+	}
+	for _, ts := range streams {
+		if ts != nil && ts.gain != nil {
+			ts.gain.gain, ts.gain.limit = replayGainFactor(ts.track, e.replayGain, e.gainOffset(ts.track))
+		}
+	}
+}
+
+// Equalizer returns the equalizer applied to playback. Changes to it take
+// effect immediately.
+func (e *AudioEngine) Equalizer() *Equalizer {
+	return e.eq
+}
+
+// Spectrum returns the levels of bands frequency bands of what is
+// playing, as Analyzer.Spectrum gives them. The levels are taken before
+// the volume, so they don't drop as it is turned down; paused, they hold.
+func (e *AudioEngine) Spectrum(bands int) []float64 {
+	return e.analyzer.Spectrum(bands)
+}
+
+// ReplayGain returns the ReplayGain mode in use
+func (e *AudioEngine) ReplayGain() ReplayGainMode {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.replayGain
+}
+
+// SetNext sets the track to move on to when the current one ends, when
+// gapless playback or a crossfade is on and join is set; nil ends playback
+// with the current track. Once the engine has moved on it sends
+// EventTrackAdvanced and forgets the next track, so the caller sets the
+// following one.
+//
+// Without join, or with neither transition on, playback ends with the
+// current track, but the next one is still opened shortly before, so that
+// playing it then starts at once.
+func (e *AudioEngine) SetNext(track *api.Track, join bool) {
+	speaker.Lock()
+	e.mu.Lock()
+	var stale []*trackStream
+	if x := e.transition; x != nil && x.next != nil && !x.fading && (x.next.track != track || !join) {
+		// The wrong track was prepared; it is reopened once it is needed
+		stale, x.next = append(stale, x.next), nil
+	}
+	if p := e.preload; p != nil && p.ts.track != track {
+		stale, e.preload = append(stale, p.ts), nil
+	}
+	if e.nextTrack != track {
+		e.nextFailed = nil
+	}
+	e.nextTrack = track
+	e.nextJoin = join
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	for _, ts := range stale {
+		ts.decoder.Close()
+	}
+}
+
+// prepareNext opens the next track once the current one is close enough
+// to its end: into the transition, so it can start the track without a
+// gap, or into preload for Play to pick up
+func (e *AudioEngine) prepareNext() {
+	speaker.Lock()
+	e.mu.RLock()
+	x, want := e.transition, e.nextTrack
+	join := e.nextJoin && (e.gapless || e.crossfade > 0)
+	ready := want == nil || want == e.nextFailed || x == nil || x.ended ||
+		(join && x.next != nil) || (!join && e.preload != nil)
+	if !ready {
+		lead := preloadAhead
+		if join {
+			lead += e.crossfade
+		}
+		ready = x.cur.remaining(e.sampleRate) > e.sampleRate.N(lead)
+	}
+	e.mu.RUnlock()
+	speaker.Unlock()
+	if ready {
+		return
+	}
+
+	ts, start, err := e.openTrack(want)
+	speaker.Lock()
+	e.mu.Lock()
+	current := e.transition == x && e.nextTrack == want && !x.ended
+	if err != nil {
+		if current {
+			e.nextFailed = want
+		}
+	} else if current && join && e.nextJoin && x.next == nil {
+		x.next = ts
+		x.fade = e.sampleRate.N(e.crossfade)
+		ts = nil
+	} else if current && !join && e.preload == nil {
+		e.preload = &preloadedTrack{ts: ts, start: start}
+		ts = nil
+	}
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	if err != nil {
+		logger.Warn("Cannot prepare next track %q: %v", want.Title, err)
+	} else if ts != nil {
+		// Playback moved on while the track was being opened
+		ts.decoder.Close()
+	}
+}
+
+// SetSilenceThreshold sets the level in dB below which audio counts as
+// silent. Non-negative values are ignored.
+func (e *AudioEngine) SetSilenceThreshold(db float64) {
+	if db >= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.silenceThresholdDB = db
+}
+
 func (e *AudioEngine) GetState() *api.PlaybackState {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -1,7 +1,12 @@
 package audio
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jscyril/golang_music_player/api"
 )
@@ -88,6 +93,17 @@ func TestPlay_NilTrack(t *testing.T) {
 	}
 }
 
+func TestToggle_NothingLoaded(t *testing.T) {
+	engine := NewAudioEngine()
+
+	if err := engine.Toggle(); err == nil {
+		t.Error("Toggle with no track should return an error")
+	}
+	if engine.Position() != 0 || engine.Duration() != 0 {
+		t.Errorf("Position, Duration = %v, %v with no track; want 0, 0", engine.Position(), engine.Duration())
+	}
+}
+
 func TestIsSupported(t *testing.T) {
 	tests := []struct {
 		path     string
@@ -97,7 +113,7 @@ func TestIsSupported(t *testing.T) {
 		{"/music/song.MP3", true},
 		{"/music/song.wav", true},
 		{"/music/song.flac", true},
-		{"/music/song.ogg", false},
+		{"/music/song.ogg", true},
 		{"/music/song.aac", false},
 		{"/music/song.txt", false},
 	}
@@ -119,10 +135,59 @@ func TestSupportedFormats(t *testing.T) {
 		t.Error("SupportedFormats should return at least one format")
 	}
 
-	expected := map[string]bool{".mp3": true, ".wav": true, ".flac": true}
+	expected := map[string]bool{".mp3": true, ".wav": true, ".flac": true, ".ogg": true}
 	for _, f := range formats {
 		if !expected[f] {
 			t.Errorf("Unexpected format: %s", f)
 		}
 	}
 }
+
+func TestSeekTarget(t *testing.T) {
+	tests := []struct {
+		pos, end int
+		target   int
+		atEnd    bool
+	}{
+		{pos: -50, end: 1000, target: 0},
+		{pos: 500, end: 1000, target: 500},
+		{pos: 998, end: 1000, target: 998},
+		{pos: 999, end: 1000, target: 999, atEnd: true},
+		{pos: 5000, end: 1000, target: 999, atEnd: true},
+		{pos: 10, end: 0, target: 0, atEnd: true},
+	}
+	for _, tt := range tests {
+		target, atEnd := seekTarget(tt.pos, tt.end)
+		if target != tt.target || atEnd != tt.atEnd {
+			t.Errorf("seekTarget(%d, %d) = %d, %v; want %d, %v", tt.pos, tt.end, target, atEnd, tt.target, tt.atEnd)
+		}
+	}
+}
+
+func TestPlay_UnopenableTrackReportsError(t *testing.T) {
+	engine := NewAudioEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.run(ctx)
+
+	track := &api.Track{ID: "gone", Title: "Gone", FilePath: filepath.Join(t.TempDir(), "gone.mp3")}
+	if err := engine.Play(track); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	select {
+	case event := <-engine.Events():
+		pe, ok := event.Payload.(*PlaybackError)
+		if event.Type != api.EventError || !ok {
+			t.Fatalf("event = %v %#v, want EventError with a *PlaybackError", event.Type, event.Payload)
+		}
+		if pe.Track != track || pe.Preview || !errors.Is(pe, os.ErrNotExist) {
+			t.Errorf("error = %+v, want the track's not-found error", pe)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event for the failed track")
+	}
+
+	if state := engine.GetState(); state.CurrentTrack != nil || state.Status != api.StatusStopped {
+		t.Errorf("state after failure = %v with %v, want stopped with no track", state.Status, state.CurrentTrack)
+	}
+}
@@ -0,0 +1,255 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// EQBandFrequencies are the centre frequencies of the equalizer's bands, in Hz
+var EQBandFrequencies = [...]float64{31, 62, 125, 250, 500, 1000, 2000, 4000, 8000, 16000}
+
+// EQBands is the number of equalizer bands
+const EQBands = len(EQBandFrequencies)
+
+// Band gains are clamped to ±MaxEQGain dB
+const MaxEQGain = 12.0
+
+// eqQ is the bandwidth of each band, about an octave
+const eqQ = 1.41
+
+// EQPreset is a named set of band gains in dB
+type EQPreset struct {
+	Name  string
+	Gains [EQBands]float64
+}
+
+// EQPresets are the built-in presets, in the order they are cycled through
+var EQPresets = []EQPreset{
+	{Name: "Flat"},
+	{Name: "Bass Boost", Gains: [EQBands]float64{6, 5, 4, 2, 0, 0, 0, 0, 0, 0}},
+	{Name: "Vocal", Gains: [EQBands]float64{-2, -2, -1, 0, 2, 4, 4, 2, 0, -1}},
+	{Name: "Treble", Gains: [EQBands]float64{0, 0, 0, 0, 0, 0, 2, 4, 5, 6}},
+}
+
+// biquad is one second-order filter section with per-channel state
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     [2]float64
+}
+
+// peaking sets the filter to boost or cut gainDB around freq, after the
+// RBJ audio EQ cookbook
+func (f *biquad) peaking(freq, gainDB float64, rate beep.SampleRate) {
+	a := math.Pow(10, gainDB/40)
+	w0 := 2 * math.Pi * freq / float64(rate)
+	alpha := math.Sin(w0) / (2 * eqQ)
+	cos := math.Cos(w0)
+	a0 := 1 + alpha/a
+	f.b0 = (1 + alpha*a) / a0
+	f.b1 = -2 * cos / a0
+	f.b2 = (1 - alpha*a) / a0
+	f.a1 = -2 * cos / a0
+	f.a2 = (1 - alpha/a) / a0
+}
+
+func (f *biquad) process(c int, x float64) float64 {
+	y := f.b0*x + f.b1*f.x1[c] + f.b2*f.x2[c] - f.a1*f.y1[c] - f.a2*f.y2[c]
+	f.x2[c], f.x1[c] = f.x1[c], x
+	f.y2[c], f.y1[c] = f.y1[c], y
+	return y
+}
+
+// Equalizer is a graphic equalizer with one peaking filter per band. It
+// holds only the settings: each stream it wraps gets filters of its own,
+// so the playing track and a preview never share filter state. It is safe
+// to change from any goroutine while audio streams through it. Bands at
+// 0 dB are skipped, so a flat or bypassed equalizer costs nothing.
+//
+// Boosting a band can push loud passages past full scale, so the whole
+// signal is first lowered by the largest boost: with every band cut or
+// flat it is left alone.
+type Equalizer struct {
+	mu      sync.Mutex
+	rate    beep.SampleRate
+	gains   [EQBands]float64
+	bypass  bool
+	version int // Bumped on every change, for streams to pick it up
+}
+
+// NewEqualizer creates a flat equalizer for audio at 44.1 kHz
+func NewEqualizer() *Equalizer {
+	return &Equalizer{rate: 44100}
+}
+
+// SetSampleRate sets the rate of the audio being filtered
+func (eq *Equalizer) SetSampleRate(rate beep.SampleRate) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.rate = rate
+	eq.version++
+}
+
+// SetBand sets band i's gain in dB, clamped to ±MaxEQGain. Out of range
+// bands are ignored.
+func (eq *Equalizer) SetBand(i int, gainDB float64) {
+	if i < 0 || i >= EQBands {
+		return
+	}
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.gains[i] = clampEQGain(gainDB)
+	eq.version++
+}
+
+// SetGains sets every band at once, e.g. from saved settings. Missing
+// bands are set flat and extra values ignored.
+func (eq *Equalizer) SetGains(gains []float64) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	for i := range eq.gains {
+		eq.gains[i] = 0
+		if i < len(gains) {
+			eq.gains[i] = clampEQGain(gains[i])
+		}
+	}
+	eq.version++
+}
+
+// ApplyPreset sets the bands to the preset called name, ignoring case,
+// and reports whether there is one
+func (eq *Equalizer) ApplyPreset(name string) bool {
+	for _, preset := range EQPresets {
+		if strings.EqualFold(preset.Name, name) {
+			eq.SetGains(preset.Gains[:])
+			return true
+		}
+	}
+	return false
+}
+
+// Preset returns the name of the preset the bands match, or "Custom"
+func (eq *Equalizer) Preset() string {
+	gains := eq.Gains()
+	for _, preset := range EQPresets {
+		if preset.Gains == gains {
+			return preset.Name
+		}
+	}
+	return "Custom"
+}
+
+// Gains returns the band gains in dB
+func (eq *Equalizer) Gains() [EQBands]float64 {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return eq.gains
+}
+
+// Preamp returns the level change in dB applied before the bands: minus
+// the largest boost, or 0 when no band is boosted or the equalizer is
+// bypassed
+func (eq *Equalizer) Preamp() float64 {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	if eq.bypass {
+		return 0
+	}
+	return preamp(eq.gains)
+}
+
+// SetBypass turns the equalizer off, passing audio through untouched,
+// while keeping its settings
+func (eq *Equalizer) SetBypass(bypass bool) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.bypass = bypass
+	eq.version++
+}
+
+// Bypassed reports whether the equalizer is bypassed
+func (eq *Equalizer) Bypassed() bool {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return eq.bypass
+}
+
+// Wrap returns s filtered through the equalizer, with filters of its own
+func (eq *Equalizer) Wrap(s beep.Streamer) beep.Streamer {
+	return &eqStreamer{s: s, eq: eq, version: -1}
+}
+
+func preamp(gains [EQBands]float64) float64 {
+	boost := 0.0
+	for _, g := range gains {
+		boost = max(boost, g)
+	}
+	return -boost
+}
+
+func clampEQGain(gainDB float64) float64 {
+	if math.IsNaN(gainDB) {
+		return 0
+	}
+	return min(max(gainDB, -MaxEQGain), MaxEQGain)
+}
+
+// eqStreamer runs a stream through an equalizer's settings with its own
+// filter state, recomputing the filters when the settings change. Their
+// state carries over, so a change doesn't click.
+type eqStreamer struct {
+	s  beep.Streamer
+	eq *Equalizer
+
+	version int
+	gains   [EQBands]float64
+	bypass  bool
+	preamp  float64 // Linear factor
+	filters [EQBands]biquad
+}
+
+func (e *eqStreamer) Stream(samples [][2]float64) (int, bool) {
+	n, ok := e.s.Stream(samples)
+	e.sync()
+	if e.bypass {
+		return n, ok
+	}
+	samples = samples[:n]
+	if e.preamp != 1 {
+		for i := range samples {
+			samples[i][0] *= e.preamp
+			samples[i][1] *= e.preamp
+		}
+	}
+	for b := range e.filters {
+		if e.gains[b] == 0 {
+			continue
+		}
+		f := &e.filters[b]
+		for i := range samples {
+			samples[i][0] = f.process(0, samples[i][0])
+			samples[i][1] = f.process(1, samples[i][1])
+		}
+	}
+	return n, ok
+}
+
+// sync picks up changed settings
+func (e *eqStreamer) sync() {
+	e.eq.mu.Lock()
+	defer e.eq.mu.Unlock()
+	if e.version == e.eq.version {
+		return
+	}
+	e.version, e.gains, e.bypass = e.eq.version, e.eq.gains, e.eq.bypass
+	e.preamp = math.Pow(10, preamp(e.gains)/20)
+	for i := range e.filters {
+		e.filters[i].peaking(EQBandFrequencies[i], e.gains[i], e.eq.rate)
+	}
+}
+
+func (e *eqStreamer) Err() error {
+	return e.s.Err()
+}
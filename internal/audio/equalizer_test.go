@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// toneLevel runs a sine at freq through eq and returns its peak level once
+// the filters have settled
+func toneLevel(eq *Equalizer, freq float64) float64 {
+	samples := make([][2]float64, 44100/5)
+	for i := range samples {
+		v := 0.25 * math.Sin(2*math.Pi*freq*float64(i)/44100)
+		samples[i] = [2]float64{v, v}
+	}
+	eq.Wrap(beep.StreamerFunc(func(s [][2]float64) (int, bool) { return len(s), true })).Stream(samples)
+	peak := 0.0
+	for _, s := range samples[len(samples)/2:] {
+		peak = max(peak, math.Abs(s[0]))
+	}
+	return peak
+}
+
+func TestEqualizer_BoostsBand(t *testing.T) {
+	flat := toneLevel(NewEqualizer(), 1000)
+
+	// The boost is taken back off the whole signal, so the boosted band
+	// keeps its level and the rest is lowered
+	eq := NewEqualizer()
+	eq.SetBand(5, 6) // 1 kHz
+	if eq.Preamp() != -6 {
+		t.Errorf("Preamp = %v, want -6", eq.Preamp())
+	}
+	if gainDB := 20 * math.Log10(toneLevel(eq, 1000)/flat); math.Abs(gainDB) > 0.5 {
+		t.Errorf("1 kHz changed by %.2f dB, want about 0", gainDB)
+	}
+	if far := toneLevel(eq, 60); math.Abs(20*math.Log10(far/0.25)+6) > 0.5 {
+		t.Errorf("60 Hz changed by %.2f dB, want about -6", 20*math.Log10(far/0.25))
+	}
+
+	// Cuts need no preamp
+	eq = NewEqualizer()
+	eq.SetBand(5, -6)
+	if eq.Preamp() != 0 {
+		t.Errorf("Preamp with only a cut = %v, want 0", eq.Preamp())
+	}
+	if far := toneLevel(eq, 60); math.Abs(20*math.Log10(far/0.25)) > 0.5 {
+		t.Errorf("60 Hz changed by %.2f dB, want about 0", 20*math.Log10(far/0.25))
+	}
+
+	eq = NewEqualizer()
+	eq.SetBand(5, 6)
+	eq.SetBypass(true)
+	if level := toneLevel(eq, 1000); math.Abs(level-flat) > 1e-9 {
+		t.Errorf("bypassed level = %v, want %v", level, flat)
+	}
+}
+
+func TestEqualizer_ClampsAndPresets(t *testing.T) {
+	eq := NewEqualizer()
+	eq.SetBand(0, 40)
+	eq.SetBand(1, -40)
+	eq.SetBand(EQBands, 3) // Ignored
+	if gains := eq.Gains(); gains[0] != MaxEQGain || gains[1] != -MaxEQGain {
+		t.Errorf("gains = %v, want clamped to ±%v", gains, MaxEQGain)
+	}
+	if eq.Preset() != "Custom" {
+		t.Errorf("Preset = %q, want Custom", eq.Preset())
+	}
+
+	if !eq.ApplyPreset("bass boost") || eq.Preset() != "Bass Boost" {
+		t.Errorf("after ApplyPreset: Preset = %q, want Bass Boost", eq.Preset())
+	}
+	if eq.ApplyPreset("nope") {
+		t.Error("ApplyPreset accepted an unknown preset")
+	}
+
+	eq.SetGains([]float64{1, 2})
+	if gains := eq.Gains(); gains[0] != 1 || gains[1] != 2 || gains[2] != 0 {
+		t.Errorf("SetGains with two values: %v", gains)
+	}
+}
+
+func TestEqualizer_StreamsKeepTheirOwnFilters(t *testing.T) {
+	eq := NewEqualizer()
+	eq.SetBand(5, 6)
+	alone := toneLevel(eq, 1000)
+
+	// Another stream filtered meanwhile, as a preview is, leaves this
+	// one's filters alone
+	tone := make([][2]float64, 512)
+	for i := range tone {
+		tone[i] = [2]float64{1, -1}
+	}
+	s := eq.Wrap(beep.StreamerFunc(func(s [][2]float64) (int, bool) { return copy(s, tone), true }))
+	other := eq.Wrap(beep.StreamerFunc(func(s [][2]float64) (int, bool) { return copy(s, tone), true }))
+	a, b := make([][2]float64, 512), make([][2]float64, 512)
+	s.Stream(a)
+	other.Stream(make([][2]float64, 512))
+	other.Stream(b)
+	s.Stream(b)
+	again := eq.Wrap(beep.StreamerFunc(func(s [][2]float64) (int, bool) { return copy(s, tone), true }))
+	c := make([][2]float64, 512)
+	again.Stream(c)
+	again.Stream(c)
+	for i := range b {
+		if b[i] != c[i] {
+			t.Fatalf("sample %d = %v after another stream, want %v", i, b[i], c[i])
+		}
+	}
+	if level := toneLevel(eq, 1000); level != alone {
+		t.Errorf("level = %v, want %v", level, alone)
+	}
+}
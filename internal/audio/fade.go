@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// MaxFade caps how long fading in on play and out on pause or stop takes
+const MaxFade = 5 * time.Second
+
+// fadeStreamer ramps the level of s linearly toward a target, for fading
+// in when playback starts or resumes and out when it pauses or stops. A
+// new fade starts from wherever the last one had got to, so pausing and
+// quickly resuming turns the level around smoothly instead of jumping.
+//
+// It runs on the speaker goroutine; fadeTo is called under the speaker
+// lock.
+type fadeStreamer struct {
+	s      beep.Streamer
+	level  float64 // Current gain, 0-1
+	target float64
+	step   float64 // Gain change per sample
+
+	// onDone, if set, is called on the speaker goroutine once the level
+	// reaches the target, before any later sample is pulled from s
+	onDone func()
+}
+
+// newFadeStreamer wraps s at full level
+func newFadeStreamer(s beep.Streamer) *fadeStreamer {
+	return &fadeStreamer{s: s, level: 1, target: 1}
+}
+
+// fadeTo ramps toward target at a rate that would cover the whole range
+// in samples, so finishing a partly done fade takes proportionally less.
+// With samples <= 0 the level jumps there. onDone replaces any pending
+// callback, which is then never called.
+func (f *fadeStreamer) fadeTo(target float64, samples int, onDone func()) {
+	f.target, f.onDone = target, onDone
+	if samples <= 0 {
+		f.level = target
+	}
+	f.step = 1 / float64(max(samples, 1))
+	if f.level == f.target {
+		f.finish()
+	}
+}
+
+// finish calls and clears the pending callback
+func (f *fadeStreamer) finish() {
+	if done := f.onDone; done != nil {
+		f.onDone = nil
+		done()
+	}
+}
+
+func (f *fadeStreamer) Stream(samples [][2]float64) (int, bool) {
+	if f.level == f.target && f.level == 1 {
+		return f.s.Stream(samples)
+	}
+
+	// Pull no more than what's left of the ramp, so whatever onDone does,
+	// such as pausing, takes effect on the very next sample
+	want := len(samples)
+	if f.onDone != nil && f.level != f.target {
+		want = min(want, int(math.Ceil(math.Abs(f.target-f.level)/f.step)))
+	}
+	n, ok := f.s.Stream(samples[:want])
+	for i := range samples[:n] {
+		switch {
+		case math.Abs(f.target-f.level) <= f.step*1.000001:
+			// Land exactly, whatever rounding the steps picked up
+			f.level = f.target
+		case f.level < f.target:
+			f.level += f.step
+		default:
+			f.level -= f.step
+		}
+		samples[i][0] *= f.level
+		samples[i][1] *= f.level
+	}
+	if f.level != f.target || n < want {
+		return n, ok
+	}
+	f.finish()
+	if want == len(samples) {
+		return n, ok
+	}
+	m, ok := f.Stream(samples[want:])
+	return n + m, ok || n > 0
+}
+
+func (f *fadeStreamer) Err() error {
+	return f.s.Err()
+}
@@ -0,0 +1,95 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// streamLevels streams n samples from s and returns their left channel
+func streamLevels(s beep.Streamer, n int) []float64 {
+	buf := make([][2]float64, n)
+	got, _ := s.Stream(buf)
+	levels := make([]float64, got)
+	for i := range levels {
+		levels[i] = buf[i][0]
+	}
+	return levels
+}
+
+func TestFadeStreamer_Ramp(t *testing.T) {
+	f := newFadeStreamer(&constDecoder{level: 1, n: 100})
+	f.level = 0
+	f.fadeTo(1, 10, nil)
+
+	levels := streamLevels(f, 20)
+	if len(levels) != 20 {
+		t.Fatalf("streamed %d samples, want 20", len(levels))
+	}
+	for i, l := range levels {
+		want := math.Min(float64(i+1)/10, 1)
+		if math.Abs(l-want) > 1e-9 {
+			t.Errorf("sample %d = %v, want %v", i, l, want)
+		}
+	}
+}
+
+func TestFadeStreamer_PauseAfterFade(t *testing.T) {
+	ctrl := &beep.Ctrl{Streamer: &constDecoder{level: 1, n: 100}}
+	f := newFadeStreamer(ctrl)
+	f.fadeTo(0, 10, func() { ctrl.Paused = true })
+
+	levels := streamLevels(f, 20)
+	if len(levels) != 20 {
+		t.Fatalf("streamed %d samples, want 20", len(levels))
+	}
+	if !ctrl.Paused {
+		t.Fatal("not paused after fading out")
+	}
+	if levels[8] <= 0 || levels[9] != 0 {
+		t.Errorf("fade ends at %v, %v; want silence from sample 9", levels[8], levels[9])
+	}
+	// Only the fade was pulled from the track; the rest is the pause
+	if pos := ctrl.Streamer.(*constDecoder).pos; pos != 10 {
+		t.Errorf("track advanced %d samples, want 10", pos)
+	}
+}
+
+func TestFadeStreamer_ResumeDuringPause(t *testing.T) {
+	ctrl := &beep.Ctrl{Streamer: &constDecoder{level: 1, n: 100}}
+	f := newFadeStreamer(ctrl)
+	paused := false
+	f.fadeTo(0, 10, func() { paused = true })
+	levels := streamLevels(f, 4)
+
+	// Resuming turns the level around from where the fade-out got to
+	f.fadeTo(1, 10, nil)
+	levels = append(levels, streamLevels(f, 10)...)
+	if paused {
+		t.Error("paused after resuming mid-fade")
+	}
+	for i := 1; i < len(levels); i++ {
+		if d := math.Abs(levels[i] - levels[i-1]); d > 0.1+1e-9 {
+			t.Errorf("level jumps by %v at sample %d", d, i)
+		}
+	}
+	if last := levels[len(levels)-1]; last != 1 {
+		t.Errorf("level = %v after fading back in, want 1", last)
+	}
+}
+
+func TestFadeStreamer_Off(t *testing.T) {
+	ctrl := &beep.Ctrl{Streamer: &constDecoder{level: 1, n: 100}}
+	f := newFadeStreamer(ctrl)
+	f.fadeTo(0, 0, func() { ctrl.Paused = true })
+	if !ctrl.Paused {
+		t.Error("fading off didn't pause straight away")
+	}
+
+	ctrl.Paused = false
+	f.fadeTo(1, 0, nil)
+	if levels := streamLevels(f, 5); levels[0] != 1 {
+		t.Errorf("first sample after resuming = %v, want 1", levels[0])
+	}
+}
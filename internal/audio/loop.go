@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// loopStreamer plays s, seeking dec back to a whenever it reaches b, for an
+// A-B repeat. dec is the decoder at the start of s, whose positions a and b
+// are in. With b at or before a it passes s through untouched. a and b are
+// changed with the speaker locked.
+type loopStreamer struct {
+	s    beep.Streamer
+	dec  beep.StreamSeeker
+	a, b int
+}
+
+// active reports whether the stream is looping
+func (l *loopStreamer) active() bool {
+	return l.b > l.a
+}
+
+func (l *loopStreamer) Stream(samples [][2]float64) (int, bool) {
+	if !l.active() {
+		return l.s.Stream(samples)
+	}
+	n := 0
+	for n < len(samples) {
+		pos := l.dec.Position()
+		if pos >= l.b {
+			if err := l.dec.Seek(l.a); err != nil {
+				logger.Warn("A-B loop: seek back failed: %v", err)
+				l.a, l.b = 0, 0
+				m, ok := l.s.Stream(samples[n:])
+				return n + m, ok || n > 0
+			}
+			pos = l.a
+		}
+		chunk := samples[n:]
+		if len(chunk) > l.b-pos {
+			chunk = chunk[:l.b-pos]
+		}
+		m, ok := l.s.Stream(chunk)
+		n += m
+		if !ok || m < len(chunk) {
+			return n, ok || n > 0
+		}
+	}
+	return n, true
+}
+
+func (l *loopStreamer) Err() error {
+	return l.s.Err()
+}
+
+// SetLoopA sets the point an A-B loop of the playing track goes back to.
+// Set after B, the two are swapped.
+func (e *AudioEngine) SetLoopA(pos time.Duration) {
+	e.setLoop(func(loop *api.ABLoop) {
+		loop.A, loop.HasA = pos, true
+	})
+}
+
+// SetLoopB sets the point at which an A-B loop of the playing track goes
+// back to A. Set before A, the two are swapped.
+func (e *AudioEngine) SetLoopB(pos time.Duration) {
+	e.setLoop(func(loop *api.ABLoop) {
+		loop.B, loop.HasB = pos, true
+	})
+}
+
+// ClearLoop stops looping. Playback carries on from where it is.
+func (e *AudioEngine) ClearLoop() {
+	e.setLoop(func(loop *api.ABLoop) {
+		*loop = api.ABLoop{}
+	})
+}
+
+// setLoop changes the playing track's loop points and applies them to its
+// stream. Streams of unknown length can't loop. The loop is reset whenever
+// another track starts.
+func (e *AudioEngine) setLoop(change func(*api.ABLoop)) {
+	speaker.Lock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer speaker.Unlock()
+
+	if e.transition == nil || e.transition.cur.loop == nil || e.state.CurrentTrack.IsStream() {
+		return
+	}
+	loop := e.state.Loop
+	change(&loop)
+	loop.A, loop.B = max(loop.A, 0), max(loop.B, 0)
+	if loop.HasA && loop.HasB && loop.B < loop.A {
+		loop.A, loop.B = loop.B, loop.A
+	}
+	e.state.Loop = loop
+
+	cur := e.transition.cur
+	cur.loop.a, cur.loop.b = 0, 0
+	if loop.Active() {
+		// A crossfade already under way is cancelled, as by a seek: the
+		// track no longer ends
+		if next := e.transition.cancelFade(); next != nil {
+			go next.decoder.Close()
+		}
+		rate := cur.format.SampleRate
+		cur.loop.a, cur.loop.b = rate.N(loop.A), rate.N(loop.B)
+		if cur.end > 0 {
+			cur.loop.b = min(cur.loop.b, cur.end)
+		}
+		logger.Debug("A-B loop %v to %v", loop.A, loop.B)
+	}
+}
@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// rampDecoder is a decoder of n samples, each at the level of its position
+type rampDecoder struct {
+	constDecoder
+}
+
+func (d *rampDecoder) Stream(samples [][2]float64) (int, bool) {
+	k := min(len(samples), d.n-d.pos)
+	for i := 0; i < k; i++ {
+		samples[i] = [2]float64{float64(d.pos + i), float64(d.pos + i)}
+	}
+	d.pos += k
+	return k, k > 0
+}
+
+// stream reads n samples from l in chunks of size, returning the left
+// channel
+func stream(l *loopStreamer, n, size int) []int {
+	var out []int
+	buf := make([][2]float64, size)
+	for len(out) < n {
+		k, ok := l.Stream(buf[:min(size, n-len(out))])
+		for _, s := range buf[:k] {
+			out = append(out, int(s[0]))
+		}
+		if !ok {
+			break
+		}
+	}
+	return out
+}
+
+func TestLoopStreamer(t *testing.T) {
+	dec := &rampDecoder{constDecoder{n: 100}}
+	l := &loopStreamer{s: dec, dec: dec, a: 10, b: 20}
+
+	got := stream(l, 35, 7)
+	for i, pos := range got {
+		want := i
+		if i >= 20 {
+			want = 10 + (i-20)%10
+		}
+		if pos != want {
+			t.Fatalf("sample %d came from %d, want %d (got %v)", i, pos, want, got)
+		}
+	}
+
+	// Cleared mid-loop, playback carries on from where it was to the end
+	l.a, l.b = 0, 0
+	rest := stream(l, 1000, 7)
+	if len(rest) != 85 || rest[0] != 15 || rest[len(rest)-1] != 99 {
+		t.Errorf("after clearing got %d samples from %d to %d, want 85 from 15 to 99", len(rest), rest[0], rest[len(rest)-1])
+	}
+}
+
+func TestLoopStreamer_SeekedPastB(t *testing.T) {
+	dec := &rampDecoder{constDecoder{n: 100}}
+	l := &loopStreamer{s: dec, dec: dec, a: 10, b: 20}
+	dec.pos = 50
+
+	if got := stream(l, 3, 3); got[0] != 10 {
+		t.Errorf("playing past B should go back to A, got %v", got)
+	}
+}
+
+func TestAudioEngine_SetLoop(t *testing.T) {
+	e := NewAudioEngine()
+	ts := constTrack("a", 0, 5000) // 5s at testRate
+	e.transition = &transitionStreamer{cur: ts, rate: testRate}
+	e.state.CurrentTrack = ts.track
+
+	e.SetLoopA(3 * time.Second)
+	if ts.loop != nil {
+		t.Fatal("constTrack has no loop stage")
+	}
+
+	ts.loop = &loopStreamer{s: ts.src, dec: ts.decoder}
+	e.SetLoopA(3 * time.Second)
+	if ts.loop.active() {
+		t.Error("A alone shouldn't loop")
+	}
+	e.SetLoopB(1 * time.Second)
+	want := api.ABLoop{A: time.Second, B: 3 * time.Second, HasA: true, HasB: true}
+	if got := e.GetState().Loop; got != want {
+		t.Errorf("B before A: loop = %+v, want them swapped to %+v", got, want)
+	}
+	if ts.loop.a != 1000 || ts.loop.b != 3000 {
+		t.Errorf("loop stage = %d..%d, want 1000..3000", ts.loop.a, ts.loop.b)
+	}
+	if ts.remaining(testRate) < ts.end {
+		t.Error("a looping track should never be close to its end")
+	}
+
+	e.SetLoopB(10 * time.Second)
+	if ts.loop.b != 5000 {
+		t.Errorf("B past the end = %d, want clamped to 5000", ts.loop.b)
+	}
+
+	e.ClearLoop()
+	if e.GetState().Loop != (api.ABLoop{}) || ts.loop.active() {
+		t.Error("ClearLoop should clear both points")
+	}
+}
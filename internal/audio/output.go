@@ -0,0 +1,208 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// The speaker can only be opened once per process and always opens the
+// system's default output, so outputs are switched through the sound
+// server instead: the player's stream is moved to another sink of
+// PulseAudio, or PipeWire's PulseAudio service, while it keeps playing.
+
+// ErrNoOutputControl means there is no sound server to list or switch
+// outputs with
+var ErrNoOutputControl = errors.New("switching outputs needs PulseAudio or PipeWire (pactl not found)")
+
+// OutputDevice is an audio output the player can play through
+type OutputDevice struct {
+	Name        string // Sound server's identifier
+	Description string // Human-readable name, e.g. "Built-in Audio Analog Stereo"
+	Default     bool   // New streams play here
+	Current     bool   // The player is playing here
+}
+
+// Label returns the description, or the name when there is none
+func (d OutputDevice) Label() string {
+	if d.Description != "" {
+		return d.Description
+	}
+	return d.Name
+}
+
+// runPactl runs pactl with untranslated output, so it can be parsed
+func runPactl(args ...string) (string, error) {
+	path, err := exec.LookPath("pactl")
+	if err != nil {
+		return "", ErrNoOutputControl
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("pactl %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("pactl %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// OutputDevices lists the outputs available to switch to
+func OutputDevices() ([]OutputDevice, error) {
+	sinks, err := runPactl("list", "sinks")
+	if err != nil {
+		return nil, err
+	}
+	info, err := runPactl("info")
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := runPactl("list", "sink-inputs")
+	if err != nil {
+		return nil, err
+	}
+
+	devices, indices := parseSinks(sinks)
+	def := parseDefaultSink(info)
+	current := map[string]bool{}
+	for _, in := range parseSinkInputs(inputs, os.Getpid()) {
+		current[indices[in.sink]] = true
+	}
+	for i := range devices {
+		devices[i].Default = devices[i].Name == def
+		devices[i].Current = current[devices[i].Name]
+	}
+	return devices, nil
+}
+
+// SetOutputDevice moves the player's playback to the output called name,
+// carrying on where it was. The system's default output, which other
+// programs play through, is left alone. If the output can't be used, such
+// as headphones that have just switched off, the error is returned and
+// playback stays where it was; the sound server itself moves it to the
+// default output should its device go away.
+func SetOutputDevice(name string) error {
+	return moveStreams(name)
+}
+
+// moveStreams moves the player's streams to the sink called name
+func moveStreams(name string) error {
+	out, err := runPactl("list", "sink-inputs")
+	if err != nil {
+		return err
+	}
+	for _, in := range parseSinkInputs(out, os.Getpid()) {
+		if _, err := runPactl("move-sink-input", strconv.Itoa(in.index), name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pactlBlock is one object of "pactl list" output: a header such as
+// "Sink #3" and the property lines under it, trimmed
+type pactlBlock struct {
+	header string
+	lines  []string
+}
+
+// pactlBlocks splits "pactl list" output into its objects
+func pactlBlocks(out string) []pactlBlock {
+	var blocks []pactlBlock
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+		case !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t"):
+			blocks = append(blocks, pactlBlock{header: line})
+		case len(blocks) > 0:
+			b := &blocks[len(blocks)-1]
+			b.lines = append(b.lines, strings.TrimSpace(line))
+		}
+	}
+	return blocks
+}
+
+// blockIndex returns the number after "#" in a block header
+func blockIndex(header string) (int, bool) {
+	_, num, ok := strings.Cut(header, "#")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(num))
+	return n, err == nil
+}
+
+// parseSinks reads "pactl list sinks", returning the outputs and their
+// names by index
+func parseSinks(out string) ([]OutputDevice, map[int]string) {
+	var devices []OutputDevice
+	names := make(map[int]string)
+	for _, b := range pactlBlocks(out) {
+		index, ok := blockIndex(b.header)
+		if !ok || !strings.HasPrefix(b.header, "Sink ") {
+			continue
+		}
+		var d OutputDevice
+		for _, line := range b.lines {
+			if v, ok := strings.CutPrefix(line, "Name: "); ok {
+				d.Name = v
+			} else if v, ok := strings.CutPrefix(line, "Description: "); ok {
+				d.Description = v
+			}
+		}
+		if d.Name != "" {
+			devices = append(devices, d)
+			names[index] = d.Name
+		}
+	}
+	return devices, names
+}
+
+// parseDefaultSink reads the default output's name from "pactl info"
+func parseDefaultSink(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "Default Sink: "); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// sinkInput is a playing stream and the index of the sink it plays on
+type sinkInput struct {
+	index, sink int
+}
+
+// parseSinkInputs reads "pactl list sink-inputs", returning the streams
+// opened by process pid
+func parseSinkInputs(out string, pid int) []sinkInput {
+	var inputs []sinkInput
+	want := fmt.Sprintf("application.process.id = %q", strconv.Itoa(pid))
+	for _, b := range pactlBlocks(out) {
+		index, ok := blockIndex(b.header)
+		if !ok || !strings.HasPrefix(b.header, "Sink Input ") {
+			continue
+		}
+		in, mine := sinkInput{index: index, sink: -1}, false
+		for _, line := range b.lines {
+			if v, ok := strings.CutPrefix(line, "Sink: "); ok {
+				in.sink, _ = strconv.Atoi(v)
+			} else if line == want {
+				mine = true
+			}
+		}
+		if mine {
+			inputs = append(inputs, in)
+		}
+	}
+	return inputs
+}
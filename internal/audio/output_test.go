@@ -0,0 +1,60 @@
+package audio
+
+import "testing"
+
+const pactlSinks = `Sink #0
+	State: SUSPENDED
+	Name: alsa_output.pci-0000_00_1f.3.analog-stereo
+	Description: Built-in Audio Analog Stereo
+	Driver: module-alsa-card.c
+
+Sink #7
+	State: RUNNING
+	Name: bluez_output.00_1B_66_AA_BB_CC.1
+	Description: WH-1000XM4
+	Properties:
+		device.description = "WH-1000XM4"
+`
+
+const pactlSinkInputs = `Sink Input #31
+	Driver: protocol-native.c
+	Sink: 7
+	Properties:
+		application.name = "ALSA plug-in [musicplayer]"
+		application.process.id = "4242"
+
+Sink Input #32
+	Sink: 0
+	Properties:
+		application.process.id = "99"
+`
+
+func TestParseSinks(t *testing.T) {
+	devices, names := parseSinks(pactlSinks)
+	if len(devices) != 2 {
+		t.Fatalf("parsed %d sinks, want 2", len(devices))
+	}
+	if d := devices[1]; d.Name != "bluez_output.00_1B_66_AA_BB_CC.1" || d.Label() != "WH-1000XM4" {
+		t.Errorf("second sink = %+v", d)
+	}
+	if names[7] != devices[1].Name || names[0] != devices[0].Name {
+		t.Errorf("sink names by index = %v", names)
+	}
+}
+
+func TestParseSinkInputs(t *testing.T) {
+	inputs := parseSinkInputs(pactlSinkInputs, 4242)
+	if len(inputs) != 1 || inputs[0] != (sinkInput{index: 31, sink: 7}) {
+		t.Errorf("streams of pid 4242 = %+v, want only #31 on sink 7", inputs)
+	}
+	if inputs := parseSinkInputs(pactlSinkInputs, 1); len(inputs) != 0 {
+		t.Errorf("streams of pid 1 = %+v, want none", inputs)
+	}
+}
+
+func TestParseDefaultSink(t *testing.T) {
+	info := "Server Name: PulseAudio (on PipeWire 1.0.5)\nDefault Sink: alsa_output.usb-DAC\nDefault Source: mic\n"
+	if got := parseDefaultSink(info); got != "alsa_output.usb-DAC" {
+		t.Errorf("default sink = %q", got)
+	}
+}
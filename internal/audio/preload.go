@@ -0,0 +1,40 @@
+package audio
+
+import (
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// preloadedTrack is the next track opened ahead of time for Play, when it
+// isn't joined onto the current one. Only the decoder is held open; it
+// reads the file as it plays, so memory use doesn't grow with its length.
+type preloadedTrack struct {
+	ts    *trackStream
+	start int // Decoder position the track starts from
+}
+
+// takePreload returns track's stream if it was preloaded, and otherwise
+// discards whatever was. It returns a nil stream if track must be opened.
+func (e *AudioEngine) takePreload(track *api.Track) (*trackStream, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p := e.preload
+	if p == nil {
+		return nil, 0
+	}
+	e.preload = nil
+	if p.ts.track != track {
+		p.ts.decoder.Close()
+		return nil, 0
+	}
+	logger.Debug("Playing preloaded track %q", track.Title)
+	return p.ts, p.start
+}
+
+// dropPreload closes the preloaded track, if any. The caller holds e.mu.
+func (e *AudioEngine) dropPreload() {
+	if e.preload != nil {
+		e.preload.ts.decoder.Close()
+		e.preload = nil
+	}
+}
@@ -0,0 +1,37 @@
+package audio
+
+import "testing"
+
+func TestPreload_TakenByPlay(t *testing.T) {
+	e := NewAudioEngine()
+	ts := constTrack("next", 1, 100)
+	e.preload = &preloadedTrack{ts: ts, start: 10}
+
+	// Setting the same next track again, even to be joined, keeps it
+	e.SetNext(ts.track, true)
+	got, start := e.takePreload(ts.track)
+	if got != ts || start != 10 {
+		t.Fatalf("takePreload = %v, %d; want the preloaded stream from 10", got, start)
+	}
+	if e.preload != nil || ts.decoder.(*constDecoder).closed {
+		t.Error("preload should be handed over open")
+	}
+}
+
+func TestPreload_DiscardedWhenNextChanges(t *testing.T) {
+	e := NewAudioEngine()
+	ts := constTrack("next", 1, 100)
+	e.preload = &preloadedTrack{ts: ts}
+	other := constTrack("other", 1, 100).track
+
+	e.SetNext(other, false)
+	if e.preload != nil || !ts.decoder.(*constDecoder).closed {
+		t.Error("SetNext with another track should close the preload")
+	}
+
+	ts = constTrack("next", 1, 100)
+	e.preload = &preloadedTrack{ts: ts}
+	if got, _ := e.takePreload(other); got != nil || !ts.decoder.(*constDecoder).closed {
+		t.Error("playing another track should close the preload")
+	}
+}
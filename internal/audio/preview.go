@@ -0,0 +1,189 @@
+package audio
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/speaker"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// previewRequest is the payload of CmdPreview
+type previewRequest struct {
+	track  *api.Track
+	offset float64 // Fraction of the track to start at
+	length time.Duration
+	volume float64 // Fraction of the playback volume to play at
+}
+
+// previewEnded is the payload of CmdStopPreview when a snippet finishes on
+// its own; gen identifies which preview it was
+type previewEnded struct {
+	gen int
+}
+
+// Preview plays a short snippet of track, starting offset (0-1) of the way
+// in, on a separate stream at volume (0-1) times the playback volume,
+// levelled by the ReplayGain mode as the track would be played. Main
+// playback is paused meanwhile and resumed once the snippet ends or
+// StopPreview is called; its queue, position and track are left alone,
+// and no track started/ended events are sent. Previewing another track
+// replaces the current preview.
+func (e *AudioEngine) Preview(track *api.Track, offset float64, length time.Duration, volume float64) error {
+	if track == nil {
+		return playerrors.ErrTrackNotFound
+	}
+	e.commands <- api.AudioCommand{Type: api.CmdPreview, Payload: previewRequest{track, offset, length, volume}}
+	return nil
+}
+
+// StopPreview ends the current preview and restores main playback
+func (e *AudioEngine) StopPreview() error {
+	e.commands <- api.AudioCommand{Type: api.CmdStopPreview}
+	return nil
+}
+
+// TogglePreview pauses the current preview, or resumes it if paused.
+// Main playback stays paused either way.
+func (e *AudioEngine) TogglePreview() error {
+	e.commands <- api.AudioCommand{Type: api.CmdTogglePreview}
+	return nil
+}
+
+func (e *AudioEngine) startPreview(req previewRequest) error {
+	track := req.track
+	if track.IsStream() {
+		return playerrors.NewPlayerError("preview", track.ID, errors.New("streams can't be previewed"))
+	}
+	file, err := os.Open(track.FilePath)
+	if err != nil {
+		return playerrors.NewPlayerError("open", track.ID, err)
+	}
+	streamer, format, err := DecodeAudio(file, track.FilePath)
+	if err != nil {
+		file.Close()
+		return playerrors.NewPlayerError("decode", track.ID, err)
+	}
+
+	offset := min(max(req.offset, 0), 1)
+	start := int(float64(streamer.Len()) * offset)
+	if err := streamer.Seek(start); err != nil {
+		logger.Warn("Failed to seek preview of %s: %v", track.FilePath, err)
+	}
+
+	length := min(format.SampleRate.N(req.length), max(streamer.Len()-start, 0))
+	var src beep.Streamer = beep.Take(length, streamer)
+	if format.SampleRate != e.sampleRate {
+		src = beep.Resample(4, format.SampleRate, e.sampleRate, src)
+	}
+
+	// Replace any running preview but keep its note of whether to resume
+	e.closePreview()
+
+	speaker.Lock()
+	e.mu.Lock()
+	if e.ctrl != nil && !e.ctrl.Paused && e.state.Status == api.StatusPlaying {
+		e.ctrl.Paused = true
+		e.state.Status = api.StatusPaused
+		e.resumeAfterPreview = true
+	}
+	e.previewGen++
+	gen := e.previewGen
+	e.previewStreamer = streamer
+	e.previewStart, e.previewRate = start, format.SampleRate
+	gain := &gainStreamer{s: src}
+	gain.gain, gain.limit = replayGainFactor(track, e.replayGain, e.gainOffset(track))
+	gain.gain *= min(max(req.volume, 0), 1)
+	gain.limit = gain.limit && gain.gain > 1
+	e.previewCtrl = &beep.Ctrl{Streamer: e.eq.Wrap(gain)}
+	e.previewVolume = &effects.Volume{
+		Streamer: e.previewCtrl,
+		Base:     2,
+		Volume:   e.state.Volume*2 - 1,
+		Silent:   e.state.Volume <= 0,
+	}
+	e.state.PreviewTrack = track
+	e.state.Preview = api.PreviewState{Length: format.SampleRate.D(length)}
+	volume := e.previewVolume
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	speaker.Play(beep.Seq(volume, beep.Callback(func() {
+		// The callback runs on the speaker goroutine; don't block it
+		go func() {
+			e.commands <- api.AudioCommand{Type: api.CmdStopPreview, Payload: previewEnded{gen}}
+		}()
+	})))
+
+	logger.Info("Previewing %q from %.0f%%", track.Title, offset*100)
+	e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
+	return nil
+}
+
+// stopPreview ends the preview and resumes main playback if the preview
+// paused it. A previewEnded payload from an already replaced preview is
+// ignored.
+func (e *AudioEngine) stopPreview(payload interface{}) {
+	e.mu.RLock()
+	gen := e.previewGen
+	e.mu.RUnlock()
+	if ended, ok := payload.(previewEnded); ok && ended.gen != gen {
+		return
+	}
+
+	e.closePreview()
+
+	speaker.Lock()
+	e.mu.Lock()
+	if e.resumeAfterPreview && e.ctrl != nil {
+		e.ctrl.Paused = false
+		e.fader.fadeTo(1, e.sampleRate.N(e.fade), nil)
+		e.state.Status = api.StatusPlaying
+	}
+	e.resumeAfterPreview = false
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
+}
+
+// togglePreview pauses or resumes the preview stream, if one is playing
+func (e *AudioEngine) togglePreview() {
+	speaker.Lock()
+	e.mu.Lock()
+	if e.previewCtrl != nil {
+		e.previewCtrl.Paused = !e.previewCtrl.Paused
+		e.state.Preview.Paused = e.previewCtrl.Paused
+	}
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	e.events <- api.AudioEvent{Type: api.EventStateChange, Payload: e.state}
+}
+
+// closePreview silences and releases the preview stream, if any
+func (e *AudioEngine) closePreview() {
+	speaker.Lock()
+	e.mu.Lock()
+	streamer := e.previewStreamer
+	if e.previewCtrl != nil {
+		// A nil streamer ends the Ctrl, so the mixer drops it
+		e.previewCtrl.Streamer = nil
+	}
+	e.previewStreamer = nil
+	e.previewCtrl = nil
+	e.previewVolume = nil
+	e.state.PreviewTrack = nil
+	e.state.Preview = api.PreviewState{}
+	e.mu.Unlock()
+	speaker.Unlock()
+
+	if streamer != nil {
+		streamer.Close()
+	}
+}
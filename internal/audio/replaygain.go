@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// ReplayGainMode selects which ReplayGain adjustment playback applies
+type ReplayGainMode int
+
+const (
+	ReplayGainOff   ReplayGainMode = iota // Play at the file's own level
+	ReplayGainTrack                       // Level each track to the reference loudness
+	ReplayGainAlbum                       // Level whole albums, keeping loudness differences within them
+)
+
+var replayGainModeNames = [...]string{"off", "track", "album"}
+
+func (m ReplayGainMode) String() string {
+	if int(m) < len(replayGainModeNames) {
+		return replayGainModeNames[m]
+	}
+	return "unknown"
+}
+
+// ParseReplayGainMode parses a mode name as written by String
+func ParseReplayGainMode(s string) (ReplayGainMode, bool) {
+	for i, name := range replayGainModeNames {
+		if s == name {
+			return ReplayGainMode(i), true
+		}
+	}
+	return ReplayGainOff, false
+}
+
+// Next returns the mode after m, wrapping around
+func (m ReplayGainMode) Next() ReplayGainMode {
+	return (m + 1) % ReplayGainMode(len(replayGainModeNames))
+}
+
+// replayGainFactor returns the linear gain to apply to track under mode,
+// with offset, the track's manual offset in dB, added to it. Album mode
+// falls back to the track's gain when there is no album gain, and the
+// other way round. A known peak caps the gain so the loudest sample stays
+// at full scale; untagged tracks play at unity gain plus their offset.
+// limit reports a boost that no peak caps, to be limited as it plays.
+func replayGainFactor(track *api.Track, mode ReplayGainMode, offset float64) (factor float64, limit bool) {
+	gain, peak := replayGainTags(track, mode)
+	factor = math.Pow(10, (gain+offset)/20)
+	if peak > 0 {
+		return min(factor, 1/peak), false
+	}
+	return factor, factor > 1
+}
+
+// replayGainTags returns the tagged gain in dB and peak that mode plays
+// track at, or zeros when it's off or the track is untagged
+func replayGainTags(track *api.Track, mode ReplayGainMode) (gain, peak float64) {
+	if mode == ReplayGainOff {
+		return 0, 0
+	}
+	hasTrack := track.TrackGain != 0 || track.TrackPeak != 0
+	hasAlbum := track.AlbumGain != 0 || track.AlbumPeak != 0
+	switch {
+	case hasAlbum && (mode == ReplayGainAlbum || !hasTrack):
+		return track.AlbumGain, track.AlbumPeak
+	case hasTrack:
+		return track.TrackGain, track.TrackPeak
+	}
+	return 0, 0
+}
+
+// limitKnee is the level above which a limited boost eases samples into
+// full scale instead of clipping them
+const limitKnee = 0.8
+
+// softLimit bends x above limitKnee so it approaches full scale without
+// reaching it
+func softLimit(x float64) float64 {
+	a := math.Abs(x)
+	if a <= limitKnee {
+		return x
+	}
+	return math.Copysign(limitKnee+(1-limitKnee)*math.Tanh((a-limitKnee)/(1-limitKnee)), x)
+}
+
+// gainStreamer scales its source by gain, keeping samples within full
+// scale so they can't wrap around: with limit set, as for a boost without
+// a known peak, softly, otherwise by clipping them. Its gain is changed
+// under the speaker lock.
+type gainStreamer struct {
+	s     beep.Streamer
+	gain  float64
+	limit bool
+}
+
+func (g *gainStreamer) Stream(samples [][2]float64) (int, bool) {
+	n, ok := g.s.Stream(samples)
+	switch {
+	case g.limit:
+		for i := range samples[:n] {
+			samples[i][0] = softLimit(samples[i][0] * g.gain)
+			samples[i][1] = softLimit(samples[i][1] * g.gain)
+		}
+	case g.gain != 1:
+		for i := range samples[:n] {
+			samples[i][0] = min(max(samples[i][0]*g.gain, -1), 1)
+			samples[i][1] = min(max(samples[i][1]*g.gain, -1), 1)
+		}
+	}
+	return n, ok
+}
+
+func (g *gainStreamer) Err() error {
+	return g.s.Err()
+}
@@ -0,0 +1,86 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestReplayGainFactor(t *testing.T) {
+	tagged := &api.Track{TrackGain: -6, TrackPeak: 0.5, AlbumGain: 3, AlbumPeak: 0.9}
+	tests := []struct {
+		name  string
+		track *api.Track
+		mode  ReplayGainMode
+		want  float64
+		limit bool
+	}{
+		{"off", tagged, ReplayGainOff, 1, false},
+		{"track", tagged, ReplayGainTrack, math.Pow(10, -6.0/20), false},
+		{"album, capped by peak", tagged, ReplayGainAlbum, 1 / 0.9, false},
+		{"untagged", &api.Track{}, ReplayGainAlbum, 1, false},
+		{"album falls back to track", &api.Track{TrackGain: -3}, ReplayGainAlbum, math.Pow(10, -3.0/20), false},
+		{"track falls back to album", &api.Track{AlbumGain: -3}, ReplayGainTrack, math.Pow(10, -3.0/20), false},
+		{"peak caps a boost", &api.Track{TrackGain: 12, TrackPeak: 0.8}, ReplayGainTrack, 1.25, false},
+		{"offset on top", &api.Track{TrackGain: -6, Gain: 2}, ReplayGainTrack, math.Pow(10, -4.0/20), false},
+		{"offset with ReplayGain off", &api.Track{TrackGain: -6, Gain: -3}, ReplayGainOff, math.Pow(10, -3.0/20), false},
+		{"peak caps a boosting offset", &api.Track{TrackGain: -1, TrackPeak: 0.8, Gain: 6}, ReplayGainTrack, 1.25, false},
+		{"boost without a peak is limited", &api.Track{Gain: 6}, ReplayGainTrack, math.Pow(10, 6.0/20), true},
+	}
+	for _, tt := range tests {
+		got, limit := replayGainFactor(tt.track, tt.mode, tt.track.Gain)
+		if math.Abs(got-tt.want) > 1e-9 || limit != tt.limit {
+			t.Errorf("%s: factor = %v, limit %v; want %v, %v", tt.name, got, limit, tt.want, tt.limit)
+		}
+	}
+}
+
+func TestGainStreamer_Limits(t *testing.T) {
+	src := beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{0.9, 0.2}
+		}
+		return len(samples), true
+	})
+	g := &gainStreamer{s: src, gain: 2, limit: true}
+	samples := make([][2]float64, 4)
+	g.Stream(samples)
+	for _, s := range samples {
+		if s[0] <= limitKnee || s[0] >= 1 || math.Abs(s[1]-0.4) > 1e-9 {
+			t.Fatalf("sample = %v, want the first eased below 1 and the second 0.4", s)
+		}
+	}
+	if got := softLimit(-5); got >= -limitKnee || got < -1 {
+		t.Errorf("softLimit(-5) = %v, want within -1 and -%v", got, limitKnee)
+	}
+}
+
+func TestGainStreamer_Clamps(t *testing.T) {
+	src := beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{0.6, -0.2}
+		}
+		return len(samples), true
+	})
+	g := &gainStreamer{s: src, gain: 2}
+	samples := make([][2]float64, 4)
+	g.Stream(samples)
+	for _, s := range samples {
+		if s[0] != 1 || math.Abs(s[1]+0.4) > 1e-9 {
+			t.Fatalf("sample = %v, want [1 -0.4]", s)
+		}
+	}
+}
+
+func TestReplayGainMode_Parse(t *testing.T) {
+	for mode := ReplayGainOff; mode <= ReplayGainAlbum; mode++ {
+		if got, ok := ParseReplayGainMode(mode.String()); !ok || got != mode {
+			t.Errorf("ParseReplayGainMode(%q) = %v, %v", mode.String(), got, ok)
+		}
+	}
+	if ReplayGainAlbum.Next() != ReplayGainOff {
+		t.Error("Next should wrap from album to off")
+	}
+}
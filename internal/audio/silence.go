@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+const (
+	// DefaultSilenceThresholdDB is the level below which audio counts as silent
+	DefaultSilenceThresholdDB = -50.0
+
+	// silenceSearchWindow bounds how far into the head and tail of a track
+	// we look for silence, so long files don't have to be fully decoded
+	silenceSearchWindow = 30 * time.Second
+)
+
+// findSilenceBounds decodes the file separately from playback and returns the
+// sample positions of the first and last non-silent samples. end is the
+// position just past the last audible sample. If no audible sample is found
+// in a window the corresponding bound is left at the start or end of the file.
+func findSilenceBounds(filePath string, thresholdDB float64) (start, end int, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	streamer, format, err := DecodeAudio(file, filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer streamer.Close()
+
+	threshold := math.Pow(10, thresholdDB/20)
+	window := format.SampleRate.N(silenceSearchWindow)
+	length := streamer.Len()
+	end = length
+
+	// Leading silence: scan forward until the first loud sample
+	if pos, ok := firstAudible(streamer, window, threshold); ok {
+		start = pos
+	}
+
+	// Trailing silence: scan the tail and remember the last loud sample
+	tailStart := length - window
+	if tailStart < start {
+		tailStart = start
+	}
+	if err := streamer.Seek(tailStart); err != nil {
+		return start, end, nil
+	}
+	if pos, ok := lastAudible(streamer, threshold); ok {
+		end = pos + 1
+	}
+
+	return start, end, nil
+}
+
+// firstAudible returns the position of the first sample above threshold
+// within the next limit samples
+func firstAudible(s beep.StreamSeeker, limit int, threshold float64) (int, bool) {
+	buf := make([][2]float64, 1024)
+	base := s.Position()
+	read := 0
+	for read < limit {
+		n, ok := s.Stream(buf)
+		for i := 0; i < n; i++ {
+			if isAudible(buf[i], threshold) {
+				return base + read + i, true
+			}
+		}
+		read += n
+		if !ok {
+			break
+		}
+	}
+	return 0, false
+}
+
+// lastAudible streams to the end and returns the position of the last
+// sample above threshold
+func lastAudible(s beep.StreamSeeker, threshold float64) (int, bool) {
+	buf := make([][2]float64, 1024)
+	pos := s.Position()
+	last, found := 0, false
+	for {
+		n, ok := s.Stream(buf)
+		for i := 0; i < n; i++ {
+			if isAudible(buf[i], threshold) {
+				last, found = pos+i, true
+			}
+		}
+		pos += n
+		if !ok {
+			break
+		}
+	}
+	return last, found
+}
+
+func isAudible(sample [2]float64, threshold float64) bool {
+	return math.Abs(sample[0]) > threshold || math.Abs(sample[1]) > threshold
+}
+
+// untilStreamer ends the wrapped stream once it reaches the end position,
+// used to trim trailing silence so the next track starts sooner
+type untilStreamer struct {
+	s   beep.StreamSeeker
+	end int
+}
+
+func (u *untilStreamer) Stream(samples [][2]float64) (int, bool) {
+	remaining := u.end - u.s.Position()
+	if remaining <= 0 {
+		return 0, false
+	}
+	if len(samples) > remaining {
+		samples = samples[:remaining]
+	}
+	return u.s.Stream(samples)
+}
+
+func (u *untilStreamer) Err() error {
+	return u.s.Err()
+}
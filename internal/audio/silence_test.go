@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// paddedTone returns a seekable stream of lead silent samples, a full-scale
+// tone of length tone, then tail silent samples
+func paddedTone(lead, tone, tail int) beep.StreamSeeker {
+	format := beep.Format{SampleRate: 44100, NumChannels: 2, Precision: 2}
+	buf := beep.NewBuffer(format)
+	buf.Append(beep.Take(lead, beep.Silence(-1)))
+	pos := 0
+	buf.Append(beep.Take(tone, beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			v := 0.5 * math.Sin(float64(pos)*0.1)
+			if v == 0 {
+				v = 0.01
+			}
+			samples[i] = [2]float64{v, v}
+			pos++
+		}
+		return len(samples), true
+	})))
+	buf.Append(beep.Take(tail, beep.Silence(-1)))
+	return buf.Streamer(0, buf.Len())
+}
+
+func TestSilenceBounds(t *testing.T) {
+	threshold := math.Pow(10, DefaultSilenceThresholdDB/20)
+	s := paddedTone(1000, 5000, 2000)
+
+	start, ok := firstAudible(s, s.Len(), threshold)
+	if !ok || start != 1000 {
+		t.Errorf("firstAudible = %d, %v; want 1000, true", start, ok)
+	}
+
+	if err := s.Seek(start); err != nil {
+		t.Fatal(err)
+	}
+	last, ok := lastAudible(s, threshold)
+	if !ok || last != 5999 {
+		t.Errorf("lastAudible = %d, %v; want 5999, true", last, ok)
+	}
+}
+
+func TestSilenceBounds_AllSilent(t *testing.T) {
+	threshold := math.Pow(10, DefaultSilenceThresholdDB/20)
+	s := paddedTone(3000, 0, 0)
+
+	if _, ok := firstAudible(s, s.Len(), threshold); ok {
+		t.Error("firstAudible found audio in silence")
+	}
+}
+
+func TestUntilStreamer(t *testing.T) {
+	s := paddedTone(0, 4000, 0)
+	u := &untilStreamer{s: s, end: 2500}
+
+	total := 0
+	buf := make([][2]float64, 1024)
+	for {
+		n, ok := u.Stream(buf)
+		total += n
+		if !ok {
+			break
+		}
+	}
+	if total != 2500 {
+		t.Errorf("untilStreamer streamed %d samples, want 2500", total)
+	}
+}
@@ -0,0 +1,242 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// streamClient fetches streamed tracks. A stream may play for hours, so
+// only waiting for the server to answer is limited, not the whole request.
+var streamClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 15 * time.Second
+	return &http.Client{Transport: transport}
+}()
+
+// streamBuffer is how much of a stream is read ahead of playback, in
+// chunks of streamChunk bytes: about 30 seconds of a 128 kbit/s stream,
+// so a slow network rarely makes the speaker wait
+const (
+	streamChunk  = 16 << 10
+	streamBuffer = 32
+)
+
+// StreamInfo is the payload of EventStreamInfo: what a radio stream says
+// about itself. Empty fields are unchanged.
+type StreamInfo struct {
+	Track     *api.Track
+	Connected bool   // A new connection: what earlier ones said no longer holds
+	Station   string // The icy-name header
+	Title     string // The latest StreamTitle, often "Artist - Title"
+}
+
+// openSource opens the audio of track, from disk or streamed over HTTP,
+// and returns it with a name whose extension tells the decoder the format
+func (e *AudioEngine) openSource(track *api.Track) (io.ReadCloser, string, error) {
+	if !track.IsStream() {
+		file, err := os.Open(track.FilePath)
+		return file, track.FilePath, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, track.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	// Ask radio servers to interleave the titles of what they're playing
+	req.Header.Set("Icy-MetaData", "1")
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("%s: %s", track.URL, resp.Status)
+	}
+
+	name := "stream" + streamFormat(track.URL, resp.Header.Get("Content-Type"))
+	logger.Info("Streaming %s as %s", track.URL, name)
+	e.streamInfo(StreamInfo{Track: track, Connected: true, Station: strings.TrimSpace(resp.Header.Get("icy-name"))})
+	body := newPrefetchReader(resp.Body, streamChunk, streamBuffer)
+	interval, err := strconv.Atoi(resp.Header.Get("icy-metaint"))
+	if err != nil || interval <= 0 {
+		return body, name, nil
+	}
+	return &icyReader{
+		r:        body,
+		interval: interval,
+		left:     interval,
+		onTitle: func(title string) {
+			e.streamInfo(StreamInfo{Track: track, Title: title})
+		},
+	}, name, nil
+}
+
+// streamInfo hands info to the run loop to send as EventStreamInfo. It is
+// called while audio is being decoded, so it never blocks: should the run
+// loop be that far behind, the info is dropped.
+func (e *AudioEngine) streamInfo(info StreamInfo) {
+	select {
+	case e.streamInfos <- info:
+	default:
+		logger.Debug("Stream info dropped: %+v", info)
+	}
+}
+
+// prefetchReader reads a network stream ahead on its own goroutine, so the
+// decoder reading from the speaker's callback takes what has arrived
+// rather than waiting on the network itself
+type prefetchReader struct {
+	r      io.ReadCloser
+	chunks chan []byte
+	stop   chan struct{}
+	once   sync.Once
+
+	buf []byte // What's left of the chunk being read
+	err error  // Set before chunks is closed
+}
+
+// newPrefetchReader starts reading r in chunks of size bytes, up to ahead
+// of them ahead of the reader
+func newPrefetchReader(r io.ReadCloser, size, ahead int) *prefetchReader {
+	p := &prefetchReader{r: r, chunks: make(chan []byte, ahead), stop: make(chan struct{})}
+	go p.fill(size)
+	return p
+}
+
+func (p *prefetchReader) fill(size int) {
+	defer close(p.chunks)
+	for {
+		chunk := make([]byte, size)
+		n, err := io.ReadAtLeast(p.r, chunk, 1)
+		if n > 0 {
+			select {
+			case p.chunks <- chunk[:n]:
+			case <-p.stop:
+				return
+			}
+		}
+		if err != nil {
+			p.err = err
+			return
+		}
+	}
+}
+
+func (p *prefetchReader) Read(b []byte) (int, error) {
+	if len(p.buf) == 0 {
+		chunk, ok := <-p.chunks
+		if !ok {
+			return 0, p.err
+		}
+		p.buf = chunk
+	}
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
+
+// Close stops reading ahead and closes the stream, which also ends a read
+// waiting on the network
+func (p *prefetchReader) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	return p.r.Close()
+}
+
+// streamFormat picks the extension to decode a stream as: the URL's when
+// it names a supported format, otherwise the one its content type implies.
+// Most radio streams are MP3, so that's assumed when neither says.
+func streamFormat(rawURL, contentType string) string {
+	if ext := strings.ToLower(path.Ext(strings.SplitN(rawURL, "?", 2)[0])); IsSupported("x" + ext) {
+		return ext
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "audio/flac", "audio/x-flac":
+		return ".flac"
+	case "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return ".wav"
+	case "audio/ogg", "application/ogg", "audio/vorbis":
+		return ".ogg"
+	}
+	return ".mp3"
+}
+
+// icyReader strips the metadata blocks a radio server interleaves with the
+// audio once asked with Icy-MetaData, passing titles on as they change.
+// Every interval bytes of audio are followed by a length byte, in units of
+// 16 bytes, and that much metadata such as "StreamTitle='...';".
+type icyReader struct {
+	r        io.ReadCloser
+	interval int // Audio bytes between metadata blocks
+	left     int // Audio bytes until the next block
+	title    string
+	onTitle  func(string)
+}
+
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		if err := r.readMetadata(); err != nil {
+			return 0, err
+		}
+		r.left = r.interval
+	}
+	if len(p) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.r.Read(p)
+	r.left -= n
+	return n, err
+}
+
+func (r *icyReader) Close() error {
+	return r.r.Close()
+}
+
+// readMetadata reads one metadata block and reports a new title
+func (r *icyReader) readMetadata() error {
+	var length [1]byte
+	if _, err := io.ReadFull(r.r, length[:]); err != nil {
+		return err
+	}
+	if length[0] == 0 {
+		return nil
+	}
+	block := make([]byte, int(length[0])*16)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return err
+	}
+	title, ok := parseStreamTitle(string(block))
+	if ok && title != r.title {
+		r.title = title
+		if r.onTitle != nil {
+			r.onTitle(title)
+		}
+	}
+	return nil
+}
+
+// parseStreamTitle finds the StreamTitle in an ICY metadata block, which is
+// padded with NULs to a multiple of 16 bytes
+func parseStreamTitle(block string) (string, bool) {
+	_, rest, ok := strings.Cut(block, "StreamTitle='")
+	if !ok {
+		return "", false
+	}
+	title, _, ok := strings.Cut(rest, "';")
+	if !ok {
+		title = strings.TrimRight(rest, "\x00")
+		title = strings.TrimSuffix(title, "'")
+	}
+	return strings.TrimSpace(title), true
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// icyStream interleaves audio with metadata blocks every interval bytes
+func icyStream(interval int, audio string, titles ...string) []byte {
+	var buf bytes.Buffer
+	for i := 0; len(audio) > 0; i++ {
+		n := min(interval, len(audio))
+		buf.WriteString(audio[:n])
+		audio = audio[n:]
+		if n < interval {
+			break
+		}
+		var meta string
+		if i < len(titles) && titles[i] != "" {
+			meta = "StreamTitle='" + titles[i] + "';"
+		}
+		blocks := (len(meta) + 15) / 16
+		buf.WriteByte(byte(blocks))
+		buf.WriteString(meta + strings.Repeat("\x00", blocks*16-len(meta)))
+	}
+	return buf.Bytes()
+}
+
+func TestICYReader(t *testing.T) {
+	var got []string
+	r := &icyReader{
+		r:        io.NopCloser(bytes.NewReader(icyStream(4, "aaaabbbbccccdd", "Band - Song", "", "Band - Song", "Other - Tune"))),
+		interval: 4,
+		left:     4,
+		onTitle:  func(title string) { got = append(got, title) },
+	}
+	audio, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(audio) != "aaaabbbbccccdd" {
+		t.Errorf("audio = %q", audio)
+	}
+	// Repeats of the same title aren't reported again
+	if len(got) != 1 || got[0] != "Band - Song" {
+		t.Errorf("titles = %q", got)
+	}
+}
+
+func TestPrefetchReader(t *testing.T) {
+	data := strings.Repeat("0123456789", 100)
+	p := newPrefetchReader(io.NopCloser(strings.NewReader(data)), 64, 4)
+	got, err := io.ReadAll(p)
+	if err != nil || string(got) != data {
+		t.Errorf("ReadAll = %d bytes, %v", len(got), err)
+	}
+
+	// Closing ends a read waiting on a stream that sends nothing
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	p = newPrefetchReader(pr, 64, 4)
+	done := make(chan error)
+	go func() {
+		_, err := p.Read(make([]byte, 8))
+		done <- err
+	}()
+	p.Close()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("read after Close succeeded")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close didn't end the read")
+	}
+}
+
+func TestStreamInfo_NeverBlocks(t *testing.T) {
+	e := NewAudioEngine()
+	track := &api.Track{URL: "http://radio.example/live"}
+	for i := 0; i < cap(e.streamInfos)+5; i++ {
+		e.streamInfo(StreamInfo{Track: track, Title: "Song"})
+	}
+	if len(e.streamInfos) != cap(e.streamInfos) {
+		t.Errorf("%d infos waiting, want %d", len(e.streamInfos), cap(e.streamInfos))
+	}
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	tests := []struct {
+		block, want string
+		ok          bool
+	}{
+		{"StreamTitle='Band - Song';StreamUrl='';\x00\x00", "Band - Song", true},
+		{"StreamTitle='Don't Stop';", "Don't Stop", true},
+		{"StreamTitle='Cut off\x00\x00", "Cut off", true},
+		{"StreamUrl='x';", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseStreamTitle(tt.block); got != tt.want || ok != tt.ok {
+			t.Errorf("parseStreamTitle(%q) = %q, %v; want %q, %v", tt.block, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStreamFormat(t *testing.T) {
+	tests := []struct {
+		url, contentType, want string
+	}{
+		{"http://host/song.flac?token=1", "application/octet-stream", ".flac"},
+		{"http://host/stream", "audio/x-wav", ".wav"},
+		{"http://host/live", "audio/mpeg", ".mp3"},
+		{"http://host/live", "application/ogg", ".ogg"},
+		{"http://host/live.aac", "", ".mp3"},
+	}
+	for _, tt := range tests {
+		if got := streamFormat(tt.url, tt.contentType); got != tt.want {
+			t.Errorf("streamFormat(%q, %q) = %q, want %q", tt.url, tt.contentType, got, tt.want)
+		}
+	}
+}
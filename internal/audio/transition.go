@@ -0,0 +1,182 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// MaxCrossfade caps how long consecutive tracks overlap
+const MaxCrossfade = 10 * time.Second
+
+// preloadAhead is how long before the end of the fade, or of the track when
+// gapless, the next track is opened so it is ready in time
+const preloadAhead = 5 * time.Second
+
+// trackStream is an opened track: its decoder and the trimmed, resampled
+// stream that is played
+type trackStream struct {
+	track   *api.Track
+	decoder beep.StreamSeekCloser
+	format  beep.Format
+	src     beep.Streamer
+	gain    *gainStreamer // ReplayGain stage at the end of src
+	loop    *loopStreamer // A-B repeat stage of src
+	end     int           // End of the playable region, in decoder samples
+}
+
+// remaining returns how much of the playable region is left, in samples at
+// rate. A stream of unknown length, or one looping, is never close to its
+// end.
+func (t *trackStream) remaining(rate beep.SampleRate) int {
+	if t.end <= 0 || (t.loop != nil && t.loop.active()) {
+		return math.MaxInt
+	}
+	left := max(t.end-t.decoder.Position(), 0)
+	return rate.N(t.format.SampleRate.D(left))
+}
+
+// transitionStreamer plays the current track and moves on to the next one,
+// once one is prepared, without returning to the speaker in between. With
+// a fade length the tracks overlap for that long, the current one fading
+// out as the next fades in; without, the next starts on the sample after
+// the current one ends. With no next track it ends with the current one.
+//
+// It runs on the speaker goroutine; its fields are changed under the
+// speaker lock.
+type transitionStreamer struct {
+	cur, next *trackStream
+	rate      beep.SampleRate // Output rate, for measuring what's left
+	fade      int             // Crossfade length in output samples; 0 is gapless
+
+	fading           bool
+	fadeLen, fadePos int
+	ended            bool
+
+	// onAdvance is called on the speaker goroutine once the next track has
+	// taken over
+	onAdvance func(from, to *trackStream)
+
+	buf [][2]float64
+}
+
+func (x *transitionStreamer) Stream(samples [][2]float64) (int, bool) {
+	filled := 0
+	for filled < len(samples) && !x.ended {
+		out := samples[filled:]
+
+		if x.fading || x.startFade() {
+			filled += x.mix(out)
+			continue
+		}
+
+		// Stop short of where the fade is due to start
+		if x.next != nil && x.fade > 0 {
+			if room := x.cur.remaining(x.rate) - x.fade; room < len(out) {
+				out = out[:max(room, 1)]
+			}
+		}
+		n, ok := x.cur.src.Stream(out)
+		filled += n
+		if n < len(out) || !ok {
+			if x.next == nil {
+				x.ended = true
+				break
+			}
+			x.advance()
+		}
+	}
+	return filled, filled > 0
+}
+
+func (x *transitionStreamer) Err() error {
+	if x.cur == nil {
+		return nil
+	}
+	return x.cur.src.Err()
+}
+
+// startFade begins the crossfade once the current track is within the fade
+// length of its end. Tracks shorter than the fade get a shorter one, so it
+// never outlasts either of them.
+func (x *transitionStreamer) startFade() bool {
+	if x.next == nil || x.fade <= 0 {
+		return false
+	}
+	left := x.cur.remaining(x.rate)
+	if left > x.fade {
+		return false
+	}
+	x.fading = true
+	x.fadeLen = max(min(left, x.fade, x.next.remaining(x.rate)), 1)
+	x.fadePos = 0
+	return true
+}
+
+// mix fills out with the two tracks overlapping, using an equal-power
+// curve so the loudness holds steady through the fade, and returns how
+// many samples it wrote. Once the fade completes or the current track runs
+// out, the next track takes over.
+func (x *transitionStreamer) mix(out [][2]float64) int {
+	k := min(len(out), x.fadeLen-x.fadePos)
+	out = out[:k]
+	if cap(x.buf) < k {
+		x.buf = make([][2]float64, k)
+	}
+	in := x.buf[:k]
+
+	n, _ := x.cur.src.Stream(out)
+	m, _ := x.next.src.Stream(in)
+	for i := range out {
+		var a, b [2]float64
+		if i < n {
+			a = out[i]
+		}
+		if i < m {
+			b = in[i]
+		}
+		if i >= n {
+			// The current track ended early: the next one is at full level
+			out[i] = b
+			continue
+		}
+		t := float64(x.fadePos+i) / float64(x.fadeLen)
+		fadeOut, fadeIn := math.Cos(t*math.Pi/2), math.Sin(t*math.Pi/2)
+		out[i] = [2]float64{a[0]*fadeOut + b[0]*fadeIn, a[1]*fadeOut + b[1]*fadeIn}
+	}
+	x.fadePos += k
+
+	written := k
+	if m < k && n < k {
+		// Both ran out; whatever follows is handled after the switch
+		written = max(n, m)
+	}
+	if x.fadePos >= x.fadeLen || n < k {
+		x.advance()
+	}
+	return written
+}
+
+// advance makes the next track current
+func (x *transitionStreamer) advance() {
+	from := x.cur
+	x.cur, x.next = x.next, nil
+	x.fading = false
+	if x.onAdvance != nil {
+		x.onAdvance(from, x.cur)
+	}
+}
+
+// cancelFade stops a crossfade in progress, leaving the current track
+// playing at full level, and returns the next track, which has been partly
+// played and should be closed. It returns nil if no fade was running.
+func (x *transitionStreamer) cancelFade() *trackStream {
+	if !x.fading {
+		return nil
+	}
+	next := x.next
+	x.next, x.fading = nil, false
+	return next
+}
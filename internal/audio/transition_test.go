@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// constDecoder is a decoder of n samples all at level
+type constDecoder struct {
+	level  float64
+	pos, n int
+	closed bool
+}
+
+func (d *constDecoder) Stream(samples [][2]float64) (int, bool) {
+	k := min(len(samples), d.n-d.pos)
+	for i := 0; i < k; i++ {
+		samples[i] = [2]float64{d.level, d.level}
+	}
+	d.pos += k
+	return k, k > 0
+}
+func (d *constDecoder) Err() error       { return nil }
+func (d *constDecoder) Len() int         { return d.n }
+func (d *constDecoder) Position() int    { return d.pos }
+func (d *constDecoder) Seek(p int) error { d.pos = p; return nil }
+func (d *constDecoder) Close() error     { d.closed = true; return nil }
+
+const testRate = beep.SampleRate(1000)
+
+func constTrack(title string, level float64, n int) *trackStream {
+	dec := &constDecoder{level: level, n: n}
+	return &trackStream{
+		track:   &api.Track{Title: title},
+		decoder: dec,
+		format:  beep.Format{SampleRate: testRate, NumChannels: 2, Precision: 2},
+		src:     dec,
+		end:     n,
+	}
+}
+
+// drain streams x to the end in chunks of size, returning the left channel
+func drain(x *transitionStreamer, size int) []float64 {
+	var out []float64
+	buf := make([][2]float64, size)
+	for {
+		n, ok := x.Stream(buf)
+		for _, s := range buf[:n] {
+			out = append(out, s[0])
+		}
+		if !ok {
+			return out
+		}
+	}
+}
+
+func TestTransition_Gapless(t *testing.T) {
+	a, b := constTrack("a", 1, 10), constTrack("b", 0.5, 7)
+	var advanced []string
+	x := &transitionStreamer{cur: a, next: b, rate: testRate, onAdvance: func(from, to *trackStream) {
+		advanced = append(advanced, from.track.Title+">"+to.track.Title)
+	}}
+
+	out := drain(x, 4)
+	if len(out) != 17 {
+		t.Fatalf("played %d samples, want 17 with no gap", len(out))
+	}
+	for i, v := range out {
+		want := 1.0
+		if i >= 10 {
+			want = 0.5
+		}
+		if v != want {
+			t.Fatalf("sample %d = %v, want %v", i, v, want)
+		}
+	}
+	if len(advanced) != 1 || advanced[0] != "a>b" {
+		t.Errorf("advanced = %v, want [a>b]", advanced)
+	}
+}
+
+func TestTransition_Crossfade(t *testing.T) {
+	a, b := constTrack("a", 1, 20), constTrack("b", 1, 20)
+	x := &transitionStreamer{cur: a, next: b, rate: testRate, fade: 8}
+
+	out := drain(x, 5)
+	if len(out) != 32 {
+		t.Fatalf("played %d samples, want 20+20-8 overlapping", len(out))
+	}
+	// Equal power: the sum of the gains never dips below the level of
+	// either track, and the fade starts from the first track alone
+	if out[12] != 1 {
+		t.Errorf("fade start = %v, want 1", out[12])
+	}
+	for i := 12; i < 20; i++ {
+		if out[i] < 1-1e-9 || out[i] > math.Sqrt2+1e-9 {
+			t.Errorf("sample %d in fade = %v, want between 1 and √2", i, out[i])
+		}
+	}
+	if x.cur != b || x.next != nil {
+		t.Error("the second track should be current after the fade")
+	}
+}
+
+func TestTransition_ShortTracksShortenTheFade(t *testing.T) {
+	a, b := constTrack("a", 1, 3), constTrack("b", 1, 4)
+	x := &transitionStreamer{cur: a, next: b, rate: testRate, fade: 1000}
+
+	out := drain(x, 64)
+	// The fade is cut to the shorter track, so the tracks overlap for 3
+	if len(out) != 4 {
+		t.Fatalf("played %d samples, want 4", len(out))
+	}
+	if x.cur != b {
+		t.Error("the second track should have taken over")
+	}
+}
+
+func TestTransition_CancelFade(t *testing.T) {
+	a, b := constTrack("a", 1, 20), constTrack("b", 0.25, 20)
+	x := &transitionStreamer{cur: a, next: b, rate: testRate, fade: 10}
+
+	buf := make([][2]float64, 12)
+	x.Stream(buf)
+	if !x.fading {
+		t.Fatal("fade should have started")
+	}
+	if next := x.cancelFade(); next != b {
+		t.Fatalf("cancelFade returned %v, want the next track", next)
+	}
+
+	// The first track plays out at full level and playback ends with it
+	out := drain(x, 4)
+	if len(out) != 8 {
+		t.Fatalf("played %d more samples, want the 8 left", len(out))
+	}
+	for i, v := range out {
+		if v != 1 {
+			t.Fatalf("sample %d after cancel = %v, want 1", i, v)
+		}
+	}
+	if x.cancelFade() != nil {
+		t.Error("cancelFade without a fade should return nil")
+	}
+}
+
+func TestTransition_EndsWithoutNext(t *testing.T) {
+	x := &transitionStreamer{cur: constTrack("a", 1, 5), rate: testRate, fade: 3}
+	if out := drain(x, 4); len(out) != 5 {
+		t.Errorf("played %d samples, want 5", len(out))
+	}
+	if n, ok := x.Stream(make([][2]float64, 4)); n != 0 || ok {
+		t.Errorf("after the end Stream = %d, %v; want 0, false", n, ok)
+	}
+}
@@ -1,66 +1,283 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
 )
 
 // Config holds application configuration
 type Config struct {
-	MusicDirectories []string `json:"music_directories"`
-	DefaultVolume    float64  `json:"default_volume"`
-	Theme            string   `json:"theme"`
-	KeyBindings      KeyMap   `json:"key_bindings"`
-	EnableCache      bool     `json:"enable_cache"`
-	CachePath        string   `json:"cache_path"`
-	DataDir          string   `json:"data_dir"`
+	MusicDirectories []string `json:"music_directories" toml:"music_directories"`
+	DefaultVolume    float64  `json:"default_volume" toml:"default_volume"`
+	Theme            string   `json:"theme" toml:"theme"`
+	KeyBindings      KeyMap   `json:"key_bindings" toml:"key_bindings"`
+	EnableCache      bool     `json:"enable_cache" toml:"enable_cache"`
+	CachePath        string   `json:"cache_path" toml:"cache_path"`
+	DataDir          string   `json:"data_dir" toml:"data_dir"`
+
+	// DefaultSort is the library's sort order at startup: "none", "title",
+	// "artist", "album", "bpm" or "size". DefaultShuffle and DefaultRepeat
+	// ("off", "one" or "all") are the queue's modes at startup.
+	DefaultSort    string `json:"default_sort" toml:"default_sort"`
+	DefaultShuffle bool   `json:"default_shuffle" toml:"default_shuffle"`
+	DefaultRepeat  string `json:"default_repeat" toml:"default_repeat"`
+
+	// SilenceThresholdDB is the level below which audio counts as silence
+	// when skipping silence at track boundaries
+	SilenceThresholdDB float64 `json:"silence_threshold_db" toml:"silence_threshold_db"`
+
+	// TrackDelaySeconds is a pause inserted before auto-advancing to the
+	// next queued track; 0 advances immediately
+	TrackDelaySeconds float64 `json:"track_delay_seconds" toml:"track_delay_seconds"`
+
+	// OrganizeRoot is where organized files are moved to; empty means the
+	// first music directory. OrganizePattern lays out paths below it.
+	OrganizeRoot    string `json:"organize_root" toml:"organize_root"`
+	OrganizePattern string `json:"organize_pattern" toml:"organize_pattern"`
+
+	// RowTint colors library rows by an attribute: "none" or "format"
+	RowTint string `json:"row_tint" toml:"row_tint"`
+
+	// LibraryColumns lays library rows out in columns, e.g.
+	// "title,artist:20,album:20,duration"; empty shows "artist - title"
+	LibraryColumns string `json:"library_columns" toml:"library_columns"`
+
+	// SearchExactAccents makes the library search match accents as typed
+	// instead of ignoring them
+	SearchExactAccents bool `json:"search_exact_accents" toml:"search_exact_accents"`
+
+	// TimeMode is the progress bar time display: "elapsed", "remaining"
+	// or "percent". It is updated when toggled in the UI.
+	TimeMode string `json:"time_mode" toml:"time_mode"`
+
+	// SnapSeek rounds seek targets to whole seconds
+	SnapSeek bool `json:"snap_seek" toml:"snap_seek"`
+
+	// SeekStepSeconds and SeekLongStepSeconds are how far the seek keys
+	// and their shifted versions move
+	SeekStepSeconds     float64 `json:"seek_step_seconds" toml:"seek_step_seconds"`
+	SeekLongStepSeconds float64 `json:"seek_long_step_seconds" toml:"seek_long_step_seconds"`
+
+	// EnterAction is what Enter does with the selected track:
+	// "replace_queue" queues the list it was chosen from, "play_track"
+	// plays just that track and keeps the queue, "play_album" plays it with
+	// the rest of its album queued after it. ConfirmReplaceQueue asks
+	// before a non-empty queue is replaced.
+	EnterAction         string `json:"enter_action" toml:"enter_action"`
+	ConfirmReplaceQueue bool   `json:"confirm_replace_queue" toml:"confirm_replace_queue"`
+
+	// PreviewSeconds is how long a preview plays, starting PreviewOffset
+	// (0-1) of the way into the track
+	PreviewSeconds float64 `json:"preview_seconds" toml:"preview_seconds"`
+	PreviewOffset  float64 `json:"preview_offset" toml:"preview_offset"`
+
+	// Prelisten previews the library's selected track once the selection
+	// has rested on it for PrelistenDelay seconds, at PrelistenVolume
+	// (0-1) of the playback volume
+	Prelisten       bool    `json:"prelisten" toml:"prelisten"`
+	PrelistenDelay  float64 `json:"prelisten_delay" toml:"prelisten_delay"`
+	PrelistenVolume float64 `json:"prelisten_volume" toml:"prelisten_volume"`
+
+	// ShuffleSpread enables smart shuffle: tracks by the same artist or
+	// from the same album are kept at least this many tracks apart where
+	// possible. 0 is plain shuffle.
+	ShuffleSpread int `json:"shuffle_spread" toml:"shuffle_spread"`
+
+	// ITunesLibrary is the path to an exported "iTunes Library.xml" whose
+	// custom start/stop times are used as trims; empty disables it
+	ITunesLibrary string `json:"itunes_library" toml:"itunes_library"`
+
+	// RememberPositions saves where each track was left off so it can be
+	// resumed. Positions older than ResumeMaxAgeDays are dropped at
+	// startup; 0 keeps them indefinitely.
+	RememberPositions bool `json:"remember_positions" toml:"remember_positions"`
+	ResumeMaxAgeDays  int  `json:"resume_max_age_days" toml:"resume_max_age_days"`
+
+	// PlaylistExportDir is where the library's listed tracks are saved as
+	// M3U playlists; empty means an "m3u" folder in DataDir
+	PlaylistExportDir string `json:"playlist_export_dir" toml:"playlist_export_dir"`
+
+	// AlbumArt is how the playing track's artwork is shown: "auto" detects
+	// the terminal's image support, or one of "kitty", "iterm2", "sixel",
+	// "placeholder" or "off"
+	AlbumArt string `json:"album_art" toml:"album_art"`
+
+	// ScanRecursive scans the subfolders of the music directories, down to
+	// ScanMaxDepth levels (0 for no limit); FollowSymlinks descends into
+	// symlinked folders as well
+	ScanRecursive  bool `json:"scan_recursive" toml:"scan_recursive"`
+	ScanMaxDepth   int  `json:"scan_max_depth" toml:"scan_max_depth"`
+	FollowSymlinks bool `json:"follow_symlinks" toml:"follow_symlinks"`
+
+	// ScanHidden scans dotfiles and dot-folders; ScanIgnore lists glob
+	// patterns of further file and folder names to skip
+	ScanHidden bool     `json:"scan_hidden" toml:"scan_hidden"`
+	ScanIgnore []string `json:"scan_ignore" toml:"scan_ignore"`
+
+	// Dedup hides duplicate tracks from the library at startup: "off",
+	// "hash" for identical files, "tags" for the same title, artist, album
+	// and length, or "both"
+	Dedup string `json:"dedup" toml:"dedup"`
+
+	// WatchLibrary rescans the music directories every WatchIntervalSeconds
+	// and adds or removes tracks as files come and go
+	WatchLibrary         bool    `json:"watch_library" toml:"watch_library"`
+	WatchIntervalSeconds float64 `json:"watch_interval_seconds" toml:"watch_interval_seconds"`
+
+	// CrossfadeSeconds overlaps consecutive tracks for this long; without
+	// it, Gapless starts the next track the moment the current one ends
+	CrossfadeSeconds float64 `json:"crossfade_seconds" toml:"crossfade_seconds"`
+	Gapless          bool    `json:"gapless" toml:"gapless"`
+
+	// SleepQuit quits the player once the sleep timer has paused it
+	SleepQuit bool `json:"sleep_quit" toml:"sleep_quit"`
+
+	// FadeSeconds fades playback in on play and resume and out on pause
+	// and stop; 0 switches it off
+	FadeSeconds float64 `json:"fade_seconds" toml:"fade_seconds"`
+
+	// ReplayGain levels playback with the files' ReplayGain tags: "off",
+	// "track" or "album". It is updated when switched in the UI.
+	ReplayGain string `json:"replay_gain" toml:"replay_gain"`
+
+	// EQGains are the equalizer's band gains in dB, lowest band first;
+	// EQBypass turns it off while keeping them. Both are updated when
+	// changed in the UI.
+	EQGains  []float64 `json:"eq_gains" toml:"eq_gains"`
+	EQBypass bool      `json:"eq_bypass" toml:"eq_bypass"`
+
+	// MPRIS registers the player on the D-Bus session bus so media keys
+	// and desktop panels can see and control playback
+	MPRIS bool `json:"mpris" toml:"mpris"`
+
+	// Notifications shows a desktop notification with the artist, title
+	// and artwork as each track starts
+	Notifications bool `json:"notifications" toml:"notifications"`
+
+	// Scrobbling to Last.fm needs an API account's key and secret and a
+	// session key; LastFMUsername and LastFMPassword, if set instead of
+	// the session key, are exchanged for one at startup and the password
+	// is cleared. ListenBrainzToken enables ListenBrainz.
+	LastFMAPIKey      string `json:"lastfm_api_key" toml:"lastfm_api_key"`
+	LastFMAPISecret   string `json:"lastfm_api_secret" toml:"lastfm_api_secret"`
+	LastFMSessionKey  string `json:"lastfm_session_key" toml:"lastfm_session_key"`
+	LastFMUsername    string `json:"lastfm_username" toml:"lastfm_username"`
+	LastFMPassword    string `json:"lastfm_password" toml:"lastfm_password"`
+	ListenBrainzToken string `json:"listenbrainz_token" toml:"listenbrainz_token"`
 }
 
-// KeyMap defines keyboard shortcuts
+// KeyMap defines keyboard shortcuts. Each binding is one or more
+// space-separated keys, e.g. "n ctrl+n"; "space" is the space bar. An empty
+// binding keeps the action's default keys. Actions binds the other actions
+// by name, e.g. "shuffle" or "sleep_timer".
 type KeyMap struct {
-	PlayPause   string `json:"play_pause"`
-	Stop        string `json:"stop"`
-	Next        string `json:"next"`
-	Previous    string `json:"previous"`
-	VolumeUp    string `json:"volume_up"`
-	VolumeDown  string `json:"volume_down"`
-	SeekForward string `json:"seek_forward"`
-	SeekBack    string `json:"seek_back"`
-	Quit        string `json:"quit"`
-	Search      string `json:"search"`
-	Library     string `json:"library"`
-	Playlist    string `json:"playlist"`
+	PlayPause   string `json:"play_pause" toml:"play_pause"`
+	Stop        string `json:"stop" toml:"stop"`
+	Next        string `json:"next" toml:"next"`
+	Previous    string `json:"previous" toml:"previous"`
+	VolumeUp    string `json:"volume_up" toml:"volume_up"`
+	VolumeDown  string `json:"volume_down" toml:"volume_down"`
+	SeekForward string `json:"seek_forward" toml:"seek_forward"`
+	SeekBack    string `json:"seek_back" toml:"seek_back"`
+	Quit        string `json:"quit" toml:"quit"`
+	Search      string `json:"search" toml:"search"`
+	Library     string `json:"library" toml:"library"`
+	Playlist    string `json:"playlist" toml:"playlist"`
+
+	Actions map[string]string `json:"actions,omitempty" toml:"actions,omitempty"`
+}
+
+// Bindings returns the configured bindings by action name, leaving out
+// those that are empty
+func (k KeyMap) Bindings() map[string]string {
+	bindings := map[string]string{
+		"play_pause":    k.PlayPause,
+		"stop":          k.Stop,
+		"next":          k.Next,
+		"previous":      k.Previous,
+		"volume_up":     k.VolumeUp,
+		"volume_down":   k.VolumeDown,
+		"seek_forward":  k.SeekForward,
+		"seek_back":     k.SeekBack,
+		"quit":          k.Quit,
+		"search":        k.Search,
+		"view_library":  k.Library,
+		"view_playlist": k.Playlist,
+	}
+	for action, keys := range k.Actions {
+		bindings[action] = keys
+	}
+	for action, keys := range bindings {
+		if keys == "" {
+			delete(bindings, action)
+		}
+	}
+	return bindings
 }
 
 // GetDefaultConfig returns default configuration
 func GetDefaultConfig() *Config {
 	return &Config{
-		MusicDirectories: []string{},
-		DefaultVolume:    0.5,
-		Theme:            "dark",
-		EnableCache:      true,
-		CachePath:        ".cache/musicplayer",
-		DataDir:          "./data",
+		MusicDirectories:     []string{},
+		DefaultVolume:        0.5,
+		Theme:                "dark",
+		EnableCache:          true,
+		CachePath:            ".cache/musicplayer",
+		DataDir:              "./data",
+		DefaultSort:          "none",
+		DefaultRepeat:        "off",
+		Dedup:                "off",
+		SilenceThresholdDB:   -50,
+		OrganizePattern:      "{artist}/{album}/{track} - {title}",
+		RowTint:              "none",
+		TimeMode:             "elapsed",
+		SeekStepSeconds:      5,
+		SeekLongStepSeconds:  30,
+		EnterAction:          "replace_queue",
+		PreviewSeconds:       10,
+		PreviewOffset:        0.3,
+		PrelistenDelay:       1,
+		PrelistenVolume:      0.5,
+		RememberPositions:    true,
+		ResumeMaxAgeDays:     30,
+		AlbumArt:             "auto",
+		ScanRecursive:        true,
+		FollowSymlinks:       true,
+		WatchIntervalSeconds: 5,
+		ReplayGain:           "off",
+		MPRIS:                true,
 		KeyBindings: KeyMap{
 			PlayPause:   " ",
 			Stop:        "s",
 			Next:        "n",
 			Previous:    "p",
-			VolumeUp:    "+",
+			VolumeUp:    "+ =",
 			VolumeDown:  "-",
 			SeekForward: "right",
 			SeekBack:    "left",
 			Quit:        "q",
 			Search:      "/",
-			Library:     "l",
-			Playlist:    "P",
+			Library:     "2",
+			Playlist:    "3",
 		},
 	}
 }
 
-// LoadConfig reads and unmarshals configuration from file
+// isTOML reports whether the config file at path is TOML rather than JSON,
+// going by its extension
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// LoadConfig reads and unmarshals configuration from file, as TOML if it
+// ends in .toml and as JSON otherwise
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -70,12 +287,96 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	// Start from defaults so fields missing from older config files keep
+	// sensible values
+	config := GetDefaultConfig()
+	if isTOML(path) {
+		err = toml.Unmarshal(data, config)
+	} else {
+		err = json.Unmarshal(data, config)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	migrateKeyBindings(&config.KeyBindings)
+
+	return config, nil
+}
+
+// migrateKeyBindings replaces key bindings that config files were written
+// with before keys could be remapped, which never matched the keys the
+// player actually used; keeping them would take those keys away
+func migrateKeyBindings(k *KeyMap) {
+	if k.Library == "l" && k.Playlist == "P" {
+		k.Library, k.Playlist = "2", "3"
+	}
+}
+
+// Validate checks the settings that have a limited range, resetting each
+// that is out of range to its default, and points out key bindings older
+// versions wrote that now leave a default key unbound. The error lists
+// every problem found, one per line.
+func (c *Config) Validate() error {
+	defaults := GetDefaultConfig()
+	var errs []error
+	check := func(ok bool, reset func(), format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+			reset()
+		}
+	}
+
+	check(c.DefaultVolume >= 0 && c.DefaultVolume <= 1, func() { c.DefaultVolume = defaults.DefaultVolume },
+		"default_volume %v is not between 0 and 1, using %v", c.DefaultVolume, defaults.DefaultVolume)
+	check(c.PreviewOffset >= 0 && c.PreviewOffset <= 1, func() { c.PreviewOffset = defaults.PreviewOffset },
+		"preview_offset %v is not between 0 and 1, using %v", c.PreviewOffset, defaults.PreviewOffset)
+	check(c.PreviewSeconds > 0, func() { c.PreviewSeconds = defaults.PreviewSeconds },
+		"preview_seconds %v is not positive, using %v", c.PreviewSeconds, defaults.PreviewSeconds)
+	check(c.PrelistenDelay > 0, func() { c.PrelistenDelay = defaults.PrelistenDelay },
+		"prelisten_delay %v is not positive, using %v", c.PrelistenDelay, defaults.PrelistenDelay)
+	check(c.PrelistenVolume > 0 && c.PrelistenVolume <= 1, func() { c.PrelistenVolume = defaults.PrelistenVolume },
+		"prelisten_volume %v is not above 0 and at most 1, using %v", c.PrelistenVolume, defaults.PrelistenVolume)
+	check(c.SeekStepSeconds > 0, func() { c.SeekStepSeconds = defaults.SeekStepSeconds },
+		"seek_step_seconds %v is not positive, using %v", c.SeekStepSeconds, defaults.SeekStepSeconds)
+	check(c.SeekLongStepSeconds > 0, func() { c.SeekLongStepSeconds = defaults.SeekLongStepSeconds },
+		"seek_long_step_seconds %v is not positive, using %v", c.SeekLongStepSeconds, defaults.SeekLongStepSeconds)
+	check(c.WatchIntervalSeconds > 0, func() { c.WatchIntervalSeconds = defaults.WatchIntervalSeconds },
+		"watch_interval_seconds %v is not positive, using %v", c.WatchIntervalSeconds, defaults.WatchIntervalSeconds)
+
+	durations := []struct {
+		name  string
+		value *float64
+	}{
+		{"track_delay_seconds", &c.TrackDelaySeconds},
+		{"crossfade_seconds", &c.CrossfadeSeconds},
+		{"fade_seconds", &c.FadeSeconds},
+	}
+	for _, d := range durations {
+		check(*d.value >= 0, func() { *d.value = 0 }, "%s %v is negative, using 0", d.name, *d.value)
+	}
+	check(c.ScanMaxDepth >= 0, func() { c.ScanMaxDepth = 0 },
+		"scan_max_depth %d is negative, using 0 (no limit)", c.ScanMaxDepth)
+	check(c.ShuffleSpread >= 0, func() { c.ShuffleSpread = 0 },
+		"shuffle_spread %d is negative, using 0", c.ShuffleSpread)
+	check(c.ResumeMaxAgeDays >= 0, func() { c.ResumeMaxAgeDays = 0 },
+		"resume_max_age_days %d is negative, using 0 (keep indefinitely)", c.ResumeMaxAgeDays)
+
+	// Written when volume_up was "+" alone, before "=" was added so it
+	// works without shift
+	if c.KeyBindings.VolumeUp == "+" {
+		errs = append(errs, errors.New(`key_bindings: volume_up is "+", so "=" no longer turns the volume up; set it to "+ =" for both`))
+	}
 
-	return &config, nil
+	for _, dir := range c.MusicDirectories {
+		if strings.TrimSpace(dir) == "" {
+			errs = append(errs, errors.New("music_directories has an empty entry"))
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			errs = append(errs, fmt.Errorf("music_directories: %s is not a folder", dir))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // SaveConfig marshals and saves configuration to file
@@ -86,7 +387,15 @@ func SaveConfig(config *Config, path string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	var data []byte
+	var err error
+	if isTOML(path) {
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(config)
+		data = buf.Bytes()
+	} else {
+		data, err = json.MarshalIndent(config, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -115,7 +424,9 @@ func LoadOrCreate(path string) (*Config, error) {
 	return config, nil
 }
 
-// GetConfigPath returns the default config file path
+// GetConfigPath returns the default config file path: config.toml in the
+// user's config folder, or config.json there if that is what an older
+// version left
 func GetConfigPath() string {
 	// Check environment variable first
 	if path := os.Getenv("MUSIC_PLAYER_CONFIG"); path != "" {
@@ -123,15 +434,30 @@ func GetConfigPath() string {
 	}
 
 	// Use XDG config directory if available
-	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-		return filepath.Join(xdgConfig, "musicplayer", "config.json")
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		// Fall back to home directory
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "./config.toml"
+		}
+		dir = filepath.Join(home, ".config")
 	}
+	return configFile(filepath.Join(dir, "musicplayer"))
+}
 
-	// Fall back to home directory
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "./config.json"
+// configFile returns the config file in dir, preferring config.toml to a
+// config.json kept from older versions
+func configFile(dir string) string {
+	path, old := filepath.Join(dir, "config.toml"), filepath.Join(dir, "config.json")
+	if !fileExists(path) && fileExists(old) {
+		return old
 	}
+	return path
+}
 
-	return filepath.Join(home, ".config", "musicplayer", "config.json")
+// fileExists reports whether there is a file at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -210,3 +211,108 @@ func TestGetDefaultConfig(t *testing.T) {
 		t.Errorf("Expected default quit 'q', got %s", config.KeyBindings.Quit)
 	}
 }
+
+// TestValidate tests out-of-range settings are reported and reset
+func TestValidate(t *testing.T) {
+	config := GetDefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Defaults should be valid: %v", err)
+	}
+
+	config.DefaultVolume = 1.5
+	config.FadeSeconds = -2
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected errors for out-of-range settings")
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 problems, got %q", lines)
+	}
+	if config.DefaultVolume != 0.5 || config.FadeSeconds != 0 {
+		t.Errorf("Expected reset to defaults, got volume %v fade %v", config.DefaultVolume, config.FadeSeconds)
+	}
+}
+
+// TestLoadConfigMigratesKeyBindings tests old never-used key defaults are replaced
+func TestLoadConfigMigratesKeyBindings(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{"key_bindings": {"volume_up": "+", "library": "l", "playlist": "P", "next": "N"}}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	bindings := config.KeyBindings.Bindings()
+	if bindings["view_library"] != "2" || bindings["view_playlist"] != "3" {
+		t.Errorf("Old defaults not migrated: %v", bindings)
+	}
+	if bindings["next"] != "N" {
+		t.Errorf("Expected next 'N', got %q", bindings["next"])
+	}
+
+	// The user's own "+" is kept, but pointed out
+	if bindings["volume_up"] != "+" {
+		t.Errorf("volume_up rewritten to %q", bindings["volume_up"])
+	}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "volume_up") {
+		t.Errorf("volume_up \"+\" not reported: %v", err)
+	}
+}
+
+// TestSaveLoadConfigTOML tests a .toml config is written and read as TOML
+func TestSaveLoadConfigTOML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	original := GetDefaultConfig()
+	original.MusicDirectories = []string{"/test/music"}
+	original.KeyBindings.Actions = map[string]string{"queue.remove": "x", "shuffle": "z"}
+	original.EQGains = []float64{1.5, -2}
+	if err := SaveConfig(original, configPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `music_directories = ["/test/music"]`) {
+		t.Errorf("Not saved as TOML:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.KeyBindings.Actions["queue.remove"] != "x" || len(loaded.EQGains) != 2 || loaded.MusicDirectories[0] != "/test/music" {
+		t.Errorf("Loaded %+v", loaded)
+	}
+
+	if err := os.WriteFile(configPath, []byte("default_volume = 0.3\n[key_bindings]\nnext = \"N\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.DefaultVolume != 0.3 || loaded.KeyBindings.Next != "N" || loaded.KeyBindings.Quit != "q" || loaded.Theme != "dark" {
+		t.Errorf("Expected settings over defaults, got %+v", loaded)
+	}
+}
+
+// TestGetConfigPath tests an older config.json is kept in use
+func TestGetConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MUSIC_PLAYER_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if got := GetConfigPath(); got != filepath.Join(dir, "musicplayer", "config.toml") {
+		t.Errorf("GetConfigPath() = %s, want config.toml", got)
+	}
+	old := filepath.Join(dir, "musicplayer", "config.json")
+	if err := SaveConfig(GetDefaultConfig(), old); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != old {
+		t.Errorf("GetConfigPath() = %s, want %s", got, old)
+	}
+}
@@ -0,0 +1,108 @@
+// Package desktop hands files to the rest of the desktop: showing them in
+// the system file manager, copying text to the clipboard and showing
+// notifications, through whichever helper program the platform provides.
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrNoClipboard means no clipboard program was found to copy with
+var ErrNoClipboard = errors.New("no clipboard program found (install wl-clipboard, xclip or xsel)")
+
+// launchWait is how long Reveal waits for the file manager's launcher to
+// fail before taking it as started. Some launchers stay running until the
+// window they open is closed.
+const launchWait = 2 * time.Second
+
+// command is a program to run and its arguments
+type command struct {
+	name string
+	args []string
+}
+
+// revealCommand returns how to show path in the file manager on goos:
+// selected in its folder where the file manager can do that, otherwise
+// its folder opened
+func revealCommand(goos, path string) command {
+	switch goos {
+	case "darwin":
+		return command{"open", []string{"-R", path}}
+	case "windows":
+		return command{"explorer", []string{"/select," + path}}
+	}
+	return command{"xdg-open", []string{filepath.Dir(path)}}
+}
+
+// Reveal shows the file at path in the system file manager. It returns once
+// the file manager has been started, or with the error that stopped it.
+func Reveal(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("reveal: %w", err)
+	}
+	c := revealCommand(runtime.GOOS, path)
+	// Standard streams are left unset so nothing reaches the terminal
+	cmd := exec.Command(c.name, c.args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("reveal: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		// Explorer exits with 1 even when it opens the folder
+		if err != nil && runtime.GOOS != "windows" {
+			return fmt.Errorf("reveal: %s: %w", c.name, err)
+		}
+	case <-time.After(launchWait):
+	}
+	return nil
+}
+
+// clipboardCommands returns the programs that can copy to the clipboard on
+// goos, in the order to try them. Wayland's comes first when a Wayland
+// display is set, as through XWayland xclip copies only for X clients.
+func clipboardCommands(goos string, getenv func(string) string) []command {
+	switch goos {
+	case "darwin":
+		return []command{{"pbcopy", nil}}
+	case "windows":
+		return []command{{"clip", nil}}
+	}
+	cmds := []command{
+		{"xclip", []string{"-selection", "clipboard"}},
+		{"xsel", []string{"--clipboard", "--input"}},
+	}
+	if getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append([]command{{"wl-copy", nil}}, cmds...)
+	}
+	return cmds
+}
+
+// CopyToClipboard puts text on the system clipboard, with the first
+// clipboard program found. It returns ErrNoClipboard when there is none.
+func CopyToClipboard(text string) error {
+	for _, c := range clipboardCommands(runtime.GOOS, os.Getenv) {
+		path, err := exec.LookPath(c.name)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, c.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("copy: %s: %s", c.name, msg)
+			}
+			return fmt.Errorf("copy: %s: %w", c.name, err)
+		}
+		return nil
+	}
+	return ErrNoClipboard
+}
@@ -0,0 +1,70 @@
+package desktop
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRevealCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		want command
+	}{
+		{"linux", command{"xdg-open", []string{"/music/album"}}},
+		{"freebsd", command{"xdg-open", []string{"/music/album"}}},
+		{"darwin", command{"open", []string{"-R", "/music/album/song.mp3"}}},
+		{"windows", command{"explorer", []string{"/select,/music/album/song.mp3"}}},
+	}
+	for _, tt := range tests {
+		got := revealCommand(tt.goos, "/music/album/song.mp3")
+		if got.name != tt.want.name || !slices.Equal(got.args, tt.want.args) {
+			t.Errorf("%s: got %v, want %v", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestClipboardCommands(t *testing.T) {
+	names := func(cmds []command) []string {
+		var out []string
+		for _, c := range cmds {
+			out = append(out, c.name)
+		}
+		return out
+	}
+	x11 := func(string) string { return "" }
+	wayland := func(key string) string {
+		if key == "WAYLAND_DISPLAY" {
+			return "wayland-0"
+		}
+		return ""
+	}
+
+	if got := names(clipboardCommands("linux", x11)); !slices.Equal(got, []string{"xclip", "xsel"}) {
+		t.Errorf("X11: %v", got)
+	}
+	if got := names(clipboardCommands("linux", wayland)); !slices.Equal(got, []string{"wl-copy", "xclip", "xsel"}) {
+		t.Errorf("Wayland: %v", got)
+	}
+	if got := names(clipboardCommands("darwin", wayland)); !slices.Equal(got, []string{"pbcopy"}) {
+		t.Errorf("macOS: %v", got)
+	}
+}
+
+func TestNotifyCommand(t *testing.T) {
+	linux, ok := notifyCommand("linux", "-Artist - Title", "Album", "/tmp/cover")
+	want := []string{"--app-name=golang_music_player", "--icon=/tmp/cover", "--", "-Artist - Title", "Album"}
+	if !ok || linux.name != "notify-send" || !slices.Equal(linux.args, want) {
+		t.Errorf("linux: %v, %v", linux, ok)
+	}
+	if c, _ := notifyCommand("freebsd", "T", "B", ""); slices.Contains(c.args, "--icon=") || len(c.args) != 4 {
+		t.Errorf("without an icon: %v", c.args)
+	}
+
+	mac, ok := notifyCommand("darwin", `Say "Hi"`, `a\b`, "/tmp/cover")
+	if !ok || mac.name != "osascript" || mac.args[1] != `display notification "a\\b" with title "Say \"Hi\""` {
+		t.Errorf("darwin: %v, %v", mac, ok)
+	}
+	if _, ok := notifyCommand("windows", "T", "B", ""); ok {
+		t.Error("windows has a notification command")
+	}
+}
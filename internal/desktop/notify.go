@@ -0,0 +1,60 @@
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoNotifier means the desktop has no way found to show notifications
+var ErrNoNotifier = errors.New("no notification program found (install libnotify's notify-send)")
+
+// appName is who notifications are from
+const appName = "golang_music_player"
+
+// notifyCommand returns how to show a notification on goos, or false when
+// there's no program for it there. icon, the path of an image file, is
+// shown where the platform can; empty leaves it out.
+func notifyCommand(goos, title, body, icon string) (command, bool) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return command{"osascript", []string{"-e", script}}, true
+	case "windows":
+		return command{}, false
+	}
+	args := []string{"--app-name=" + appName}
+	if icon != "" {
+		args = append(args, "--icon="+icon)
+	}
+	// "--" keeps a title starting with "-" from reading as an option
+	return command{"notify-send", append(args, "--", title, body)}, true
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Notify shows a desktop notification with title and body, and the image
+// file at icon if the platform shows one. It returns ErrNoNotifier when the
+// platform's notification program isn't there.
+func Notify(title, body, icon string) error {
+	c, ok := notifyCommand(runtime.GOOS, title, body, icon)
+	if !ok {
+		return ErrNoNotifier
+	}
+	path, err := exec.LookPath(c.name)
+	if err != nil {
+		return ErrNoNotifier
+	}
+	if out, err := exec.Command(path, c.args...).CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("notify: %s: %s", c.name, msg)
+		}
+		return fmt.Errorf("notify: %s: %w", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Availability describes whether a track's file can currently be read
+type Availability int
+
+const (
+	Available Availability = iota
+	Offline                // The volume or library root holding the file is not mounted
+	Missing                // The root is there but the file is gone, e.g. deleted
+)
+
+func (a Availability) String() string {
+	switch a {
+	case Available:
+		return "available"
+	case Offline:
+		return "offline"
+	case Missing:
+		return "missing"
+	}
+	return "unknown"
+}
+
+// CheckAvailability reports, by track ID, the tracks whose files aren't
+// available. Each library root is checked once, ahead of its files: the
+// tracks under a root that is offline are all offline without their files
+// being touched, so an unplugged drive or a dead network mount costs one
+// check. Only the files under roots that are there are stat'ed, to find
+// the ones that have gone missing.
+func (l *Library) CheckAvailability() map[string]Availability {
+	l.mu.RLock()
+	roots := append([]string(nil), l.ScanPaths...)
+	paths := make(map[string]string, len(l.Tracks))
+	for id, track := range l.Tracks {
+		paths[id] = track.FilePath
+	}
+	l.mu.RUnlock()
+
+	rootOnline := make(map[string]bool)
+	online := func(dir string) bool {
+		if ok, seen := rootOnline[dir]; seen {
+			return ok
+		}
+		ok := dirOnline(dir)
+		rootOnline[dir] = ok
+		return ok
+	}
+
+	result := make(map[string]Availability)
+	for id, path := range paths {
+		root, inRoot := containingRoot(roots, path)
+		if inRoot && !online(root) {
+			result[id] = Offline
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		// Files added from elsewhere are judged by their own directory
+		if inRoot || online(filepath.Dir(path)) {
+			result[id] = Missing
+		} else {
+			result[id] = Offline
+		}
+	}
+	return result
+}
+
+// containingRoot returns the library root that path lies under
+func containingRoot(roots []string, path string) (string, bool) {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// dirOnline reports whether dir exists and has content. An empty directory
+// is treated as an unmounted mount point.
+func dirOnline(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(1)
+	return err == nil && len(names) > 0
+}
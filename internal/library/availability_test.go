@@ -0,0 +1,54 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestCheckAvailability(t *testing.T) {
+	base := t.TempDir()
+	mounted := filepath.Join(base, "music")
+	unplugged := filepath.Join(base, "external") // Mount point left empty
+	writeFile(t, filepath.Join(mounted, "here.mp3"), "x")
+	if err := os.MkdirAll(unplugged, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lib := NewLibrary()
+	lib.ScanPaths = []string{mounted, unplugged}
+	lib.AddTrack(&api.Track{ID: "here", FilePath: filepath.Join(mounted, "here.mp3")})
+	lib.AddTrack(&api.Track{ID: "deleted", FilePath: filepath.Join(mounted, "gone.mp3")})
+	lib.AddTrack(&api.Track{ID: "offline", FilePath: filepath.Join(unplugged, "Album", "a.flac")})
+
+	got := lib.CheckAvailability()
+
+	if _, ok := got["here"]; ok {
+		t.Error("existing file reported unavailable")
+	}
+	if got["deleted"] != Missing {
+		t.Errorf("deleted file = %v, want missing", got["deleted"])
+	}
+	if got["offline"] != Offline {
+		t.Errorf("file on unmounted root = %v, want offline", got["offline"])
+	}
+}
+
+func TestCheckAvailability_OutsideTheRoots(t *testing.T) {
+	dir := t.TempDir()
+	lib := NewLibrary()
+	lib.ScanPaths = []string{filepath.Join(dir, "music")}
+	lib.AddTrack(&api.Track{ID: "added", FilePath: filepath.Join(dir, "gone.mp3")})
+	lib.AddTrack(&api.Track{ID: "elsewhere", FilePath: filepath.Join(dir, "usb", "a.mp3")})
+	writeFile(t, filepath.Join(dir, "other.mp3"), "x")
+
+	got := lib.CheckAvailability()
+	if got["added"] != Missing {
+		t.Errorf("file added from a folder that is there = %v, want missing", got["added"])
+	}
+	if got["elsewhere"] != Offline {
+		t.Errorf("file added from a folder that is gone = %v, want offline", got["elsewhere"])
+	}
+}
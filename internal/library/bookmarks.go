@@ -0,0 +1,145 @@
+package library
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+const (
+	// bookmarkMergeWindow is how close a new bookmark may be to an
+	// existing one before it replaces it rather than adding another
+	bookmarkMergeWindow = time.Second
+
+	// bookmarkNextSlack keeps "next" from landing on the bookmark just
+	// jumped to when playback reports a position a little short of it
+	bookmarkNextSlack = time.Second
+
+	// bookmarkPrevSlack is how far past a bookmark playback must be for
+	// "previous" to return to it rather than go to the one before, as
+	// with restarting a track versus going to the previous one
+	bookmarkPrevSlack = 2 * time.Second
+)
+
+// Bookmark is a named position within a track
+type Bookmark struct {
+	Label    string        `json:"label"`
+	Position time.Duration `json:"position"`
+}
+
+// Bookmarks is a JSON-backed store of bookmarks keyed by file path, each
+// file's kept in position order
+type Bookmarks struct {
+	jsonStore[[]Bookmark]
+}
+
+// NewBookmarks creates an empty store that saves to path
+func NewBookmarks(path string) *Bookmarks {
+	b := &Bookmarks{}
+	b.init(path)
+	return b
+}
+
+// LoadBookmarks loads bookmarks from a JSON file (or returns empty if not exists)
+func LoadBookmarks(path string) (*Bookmarks, error) {
+	b := NewBookmarks(path)
+	if err := b.load(b, "bookmarks"); err != nil {
+		return nil, err
+	}
+	for _, marks := range b.Entries {
+		sortBookmarks(marks)
+	}
+	return b, nil
+}
+
+// Save persists the bookmarks to their JSON file
+func (b *Bookmarks) Save() error {
+	return b.save(b, "bookmarks")
+}
+
+// Get returns the bookmarks of filePath in position order
+func (b *Bookmarks) Get(filePath string) []Bookmark {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Clone(b.Entries[filePath])
+}
+
+// Add bookmarks filePath at position. A bookmark already within a second
+// of it is relabelled instead, so repeated presses don't pile up.
+func (b *Bookmarks) Add(filePath, label string, position time.Duration) Bookmark {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	mark := Bookmark{Label: label, Position: max(position, 0)}
+	marks := b.Entries[filePath]
+	for i, m := range marks {
+		if (m.Position - mark.Position).Abs() < bookmarkMergeWindow {
+			marks[i].Label = label
+			return marks[i]
+		}
+	}
+	marks = append(marks, mark)
+	sortBookmarks(marks)
+	b.Entries[filePath] = marks
+	return mark
+}
+
+// Remove deletes the bookmark of filePath nearest position and returns it.
+// ok is false if the file has none.
+func (b *Bookmarks) Remove(filePath string, position time.Duration) (removed Bookmark, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	marks := b.Entries[filePath]
+	if len(marks) == 0 {
+		return Bookmark{}, false
+	}
+	nearest := 0
+	for i, m := range marks {
+		if (m.Position - position).Abs() < (marks[nearest].Position - position).Abs() {
+			nearest = i
+		}
+	}
+	removed = marks[nearest]
+	if marks = slices.Delete(marks, nearest, nearest+1); len(marks) == 0 {
+		delete(b.Entries, filePath)
+	} else {
+		b.Entries[filePath] = marks
+	}
+	return removed, true
+}
+
+// Next returns the first bookmark of filePath after position
+func (b *Bookmarks) Next(filePath string, position time.Duration) (Bookmark, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, m := range b.Entries[filePath] {
+		if m.Position > position+bookmarkNextSlack {
+			return m, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// Prev returns the last bookmark of filePath before position. Just past a
+// bookmark, that's the one before it, so pressing again steps back.
+func (b *Bookmarks) Prev(filePath string, position time.Duration) (Bookmark, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	marks := b.Entries[filePath]
+	for i := len(marks) - 1; i >= 0; i-- {
+		if marks[i].Position < position-bookmarkPrevSlack {
+			return marks[i], true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// sortBookmarks puts marks in position order
+func sortBookmarks(marks []Bookmark) {
+	slices.SortStableFunc(marks, func(a, b Bookmark) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+}
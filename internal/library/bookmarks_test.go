@@ -0,0 +1,84 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBookmarks_AddAndStep(t *testing.T) {
+	b := NewBookmarks("")
+	b.Add("/mix.mp3", "Drop", 20*time.Minute)
+	b.Add("/mix.mp3", "Intro", 30*time.Second)
+	b.Add("/mix.mp3", "Encore", 50*time.Minute)
+	b.Add("/mix.mp3", "Intro (cut)", 30*time.Second+500*time.Millisecond) // Relabels
+
+	marks := b.Get("/mix.mp3")
+	if len(marks) != 3 || marks[0].Label != "Intro (cut)" || marks[1].Label != "Drop" || marks[2].Label != "Encore" {
+		t.Fatalf("Get = %+v, want Intro (cut), Drop, Encore in order", marks)
+	}
+
+	tests := []struct {
+		name     string
+		step     func(string, time.Duration) (Bookmark, bool)
+		position time.Duration
+		want     string // "" for none
+	}{
+		{"next from start", b.Next, 0, "Intro (cut)"},
+		{"next just short of a bookmark", b.Next, 20*time.Minute - 200*time.Millisecond, "Encore"},
+		{"next after the last", b.Next, 55 * time.Minute, ""},
+		{"prev well past a bookmark", b.Prev, 25 * time.Minute, "Drop"},
+		{"prev just past a bookmark", b.Prev, 20*time.Minute + time.Second, "Intro (cut)"},
+		{"prev before the first", b.Prev, 10 * time.Second, ""},
+	}
+	for _, tt := range tests {
+		got, ok := tt.step("/mix.mp3", tt.position)
+		if ok != (tt.want != "") || got.Label != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got.Label, ok, tt.want)
+		}
+	}
+	if _, ok := b.Next("/other.mp3", 0); ok {
+		t.Error("a file without bookmarks has a next one")
+	}
+}
+
+func TestBookmarks_Remove(t *testing.T) {
+	b := NewBookmarks("")
+	b.Add("/a.mp3", "One", time.Minute)
+	b.Add("/a.mp3", "Two", 3*time.Minute)
+
+	if removed, ok := b.Remove("/a.mp3", 2*time.Minute+40*time.Second); !ok || removed.Label != "Two" {
+		t.Errorf("Remove = %+v, %v; want the nearest, Two", removed, ok)
+	}
+	b.Remove("/a.mp3", 0)
+	if _, ok := b.Remove("/a.mp3", 0); ok {
+		t.Error("removed from a file with no bookmarks left")
+	}
+	if _, ok := b.Entries["/a.mp3"]; ok {
+		t.Error("empty bookmark list kept")
+	}
+}
+
+func TestBookmarks_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	b := NewBookmarks(path)
+	b.Add("/set.flac", "Second song", 4*time.Minute)
+	b.Add("/set.flac", "First song", 0)
+	if err := b.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatalf("LoadBookmarks: %v", err)
+	}
+	marks := loaded.Get("/set.flac")
+	if len(marks) != 2 || marks[0] != (Bookmark{"First song", 0}) || marks[1] != (Bookmark{"Second song", 4 * time.Minute}) {
+		t.Errorf("loaded %+v", marks)
+	}
+
+	missing, err := LoadBookmarks(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || len(missing.Get("/set.flac")) != 0 {
+		t.Errorf("missing file: %v, %v", missing, err)
+	}
+}
@@ -0,0 +1,155 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+const (
+	bpmAnalysisLength = 60 * time.Second // Only the first minute is analyzed
+	bpmHopSize        = 256              // Samples per energy frame
+	bpmMin            = 60.0
+	bpmMax            = 180.0
+)
+
+// errNoBeat is returned when the audio has no detectable pulse (e.g. silence)
+var errNoBeat = errors.New("no beat detected")
+
+// DetectBPM estimates the tempo of an audio file by decoding its first minute
+func DetectBPM(filePath string) (float64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	streamer, format, err := decodeStream(filePath, file)
+	if err != nil {
+		return 0, fmt.Errorf("decode: %w", err)
+	}
+	defer streamer.Close()
+
+	return detectTempo(streamer, format.SampleRate)
+}
+
+// detectTempo estimates BPM from an onset envelope: per-frame energy is
+// differentiated and half-wave rectified, then autocorrelated over the lags
+// that correspond to bpmMin..bpmMax. The strongest lag wins.
+func detectTempo(s beep.Streamer, rate beep.SampleRate) (float64, error) {
+	if rate <= 0 {
+		return 0, errNoBeat
+	}
+
+	remaining := rate.N(bpmAnalysisLength)
+	buf := make([][2]float64, bpmHopSize)
+	var energies []float64
+
+	for remaining > 0 {
+		n, ok := s.Stream(buf)
+		if n > 0 {
+			var e float64
+			for _, sample := range buf[:n] {
+				mono := (sample[0] + sample[1]) / 2
+				e += mono * mono
+			}
+			energies = append(energies, e)
+			remaining -= n
+		}
+		if !ok {
+			break
+		}
+	}
+
+	if len(energies) < 2 {
+		return 0, errNoBeat
+	}
+
+	onsets := make([]float64, len(energies))
+	for i := 1; i < len(energies); i++ {
+		if d := energies[i] - energies[i-1]; d > 0 {
+			onsets[i] = d
+		}
+	}
+
+	frameRate := float64(rate) / bpmHopSize
+	minLag := int(frameRate * 60 / bpmMax)
+	maxLag := int(math.Ceil(frameRate * 60 / bpmMin))
+	if minLag < 1 {
+		minLag = 1
+	}
+	if maxLag >= len(onsets) {
+		return 0, errNoBeat
+	}
+
+	corr := make([]float64, maxLag+2)
+	bestLag, best := 0, 0.0
+	for lag := minLag; lag <= maxLag+1 && lag < len(onsets); lag++ {
+		var sum float64
+		for i := 0; i+lag < len(onsets); i++ {
+			sum += onsets[i] * onsets[i+lag]
+		}
+		corr[lag] = sum
+		if lag <= maxLag && sum > best {
+			best, bestLag = sum, lag
+		}
+	}
+	if bestLag == 0 || best == 0 {
+		return 0, errNoBeat
+	}
+
+	// Parabolic interpolation around the peak for sub-frame precision
+	lag := float64(bestLag)
+	if bestLag > minLag {
+		prev, next := corr[bestLag-1], corr[bestLag+1]
+		if denom := prev - 2*best + next; denom != 0 {
+			lag += 0.5 * (prev - next) / denom
+		}
+	}
+
+	bpm := 60 * frameRate / lag
+	return math.Round(bpm*10) / 10, nil
+}
+
+// maxConcurrentAnalysis caps how many files are decoded for analysis at
+// once, so bulk analysis leaves CPU for playback
+const maxConcurrentAnalysis = 2
+
+// analysisSlots is a semaphore shared by all analysis jobs
+var analysisSlots = make(chan struct{}, maxConcurrentAnalysis)
+
+// AnalyzeBPM returns the tempo of a track, preferring the cached sidecar
+// value for unchanged files unless force, and falling back to DetectBPM.
+// The result is cached, replacing what was, but the track itself is not
+// modified, so callers on the UI goroutine can assign it safely. Detection
+// blocks while maxConcurrentAnalysis other detections are running.
+func (l *Library) AnalyzeBPM(track *api.Track, force bool) (float64, error) {
+	if l.sidecar != nil && !force {
+		if bpm, ok := l.sidecar.CachedBPM(track.FilePath); ok {
+			return bpm, nil
+		}
+	}
+
+	analysisSlots <- struct{}{}
+	defer func() { <-analysisSlots }()
+
+	info, err := os.Stat(track.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("stat file: %w", err)
+	}
+
+	bpm, err := DetectBPM(track.FilePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if l.sidecar != nil {
+		l.sidecar.SetBPM(track.FilePath, bpm, info.ModTime())
+	}
+	return bpm, nil
+}
@@ -0,0 +1,86 @@
+package library
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// clickTrack generates short decaying clicks at the given tempo
+func clickTrack(rate beep.SampleRate, bpm float64, seconds int) beep.Streamer {
+	period := int(float64(rate) * 60 / bpm)
+	clickLen := rate.N(10 * time.Millisecond)
+	total := int(rate) * seconds
+	pos := 0
+
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		if pos >= total {
+			return 0, false
+		}
+		n := 0
+		for i := range samples {
+			if pos >= total {
+				break
+			}
+			var v float64
+			if offset := pos % period; offset < clickLen {
+				v = math.Sin(float64(offset)*0.3) * (1 - float64(offset)/float64(clickLen))
+			}
+			samples[i] = [2]float64{v, v}
+			pos++
+			n++
+		}
+		return n, true
+	})
+}
+
+func TestDetectTempo(t *testing.T) {
+	rate := beep.SampleRate(44100)
+
+	for _, want := range []float64{90, 120, 128, 150} {
+		got, err := detectTempo(clickTrack(rate, want, 20), rate)
+		if err != nil {
+			t.Fatalf("detectTempo(%v bpm) error: %v", want, err)
+		}
+		if math.Abs(got-want) > 1.5 {
+			t.Errorf("detectTempo(%v bpm) = %v", want, got)
+		}
+	}
+}
+
+func TestDetectTempo_Silence(t *testing.T) {
+	rate := beep.SampleRate(44100)
+	silence := beep.Take(rate.N(5*time.Second), beep.Silence(-1))
+
+	if _, err := detectTempo(silence, rate); err == nil {
+		t.Error("detectTempo on silence should return an error")
+	}
+}
+
+func TestAnalyzeBPM_ForceSkipsTheCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(path, []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(filepath.Join(dir, "sidecar.json")))
+	lib.Sidecar().SetBPM(path, 120, info.ModTime())
+	track := &api.Track{ID: "a", FilePath: path}
+
+	if bpm, err := lib.AnalyzeBPM(track, false); err != nil || bpm != 120 {
+		t.Errorf("AnalyzeBPM = %v, %v; want the cached 120", bpm, err)
+	}
+	if bpm, err := lib.AnalyzeBPM(track, true); err == nil {
+		t.Errorf("forced AnalyzeBPM = %v from the cache, want a detection", bpm)
+	}
+}
@@ -0,0 +1,121 @@
+package library
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/dhowden/tag"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/timecode"
+)
+
+// readChapters reads the chapters embedded in a file's tags: ID3v2 CHAP
+// frames, or Vorbis comments in the CHAPTER001=00:00:00.000 and
+// CHAPTER001NAME=Title convention. They're returned in start order; nil
+// when the file has none.
+func readChapters(raw map[string]interface{}, format tag.Format) []api.Chapter {
+	var chapters []api.Chapter
+	for key, value := range raw {
+		if key == "CHAP" || strings.HasPrefix(key, "CHAP_") {
+			if data, ok := value.([]byte); ok {
+				if chapter, ok := parseCHAP(data, format); ok {
+					chapters = append(chapters, chapter)
+				}
+			}
+			continue
+		}
+
+		// Vorbis comment keys come lowercased
+		num, ok := strings.CutPrefix(key, "chapter")
+		if !ok || num == "" || strings.HasSuffix(num, "name") {
+			continue
+		}
+		s, ok := rawTagString(value)
+		if !ok {
+			continue
+		}
+		start, err := timecode.Parse(s)
+		if err != nil {
+			continue
+		}
+		title, _ := rawTagString(raw[key+"name"])
+		chapters = append(chapters, api.Chapter{Title: title, Start: start})
+	}
+	if len(chapters) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(chapters, func(a, b api.Chapter) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return chapters
+}
+
+// parseCHAP decodes an ID3v2 CHAP frame: a null-terminated element ID, the
+// start and end times and byte offsets, then embedded frames, of which the
+// TIT2 title is used
+func parseCHAP(data []byte, format tag.Format) (api.Chapter, bool) {
+	id, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(id) == 0 || len(rest) < 16 {
+		return api.Chapter{}, false
+	}
+	start := time.Duration(binary.BigEndian.Uint32(rest)) * time.Millisecond
+	chapter := api.Chapter{Start: start}
+
+	for sub := rest[16:]; len(sub) >= 10; {
+		name := string(sub[:4])
+		size := int(binary.BigEndian.Uint32(sub[4:8]))
+		if format == tag.ID3v2_4 {
+			// Synchsafe: seven bits per byte
+			size = int(sub[4])<<21 | int(sub[5])<<14 | int(sub[6])<<7 | int(sub[7])
+		}
+		sub = sub[10:]
+		if size > len(sub) {
+			break
+		}
+		if name == "TIT2" {
+			if title := decodeID3Text(sub[:size]); title != "" {
+				chapter.Title = title
+			}
+		}
+		sub = sub[size:]
+	}
+	return chapter, true
+}
+
+// decodeID3Text decodes an ID3v2 text frame body: an encoding byte
+// (ISO-8859-1, UTF-16 with BOM, UTF-16BE or UTF-8) followed by the text
+func decodeID3Text(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	enc, text := b[0], b[1:]
+	switch enc {
+	case 1, 2:
+		order := binary.ByteOrder(binary.BigEndian)
+		if enc == 1 && len(text) >= 2 {
+			if text[0] == 0xff && text[1] == 0xfe {
+				order = binary.LittleEndian
+			}
+			if (text[0] == 0xff && text[1] == 0xfe) || (text[0] == 0xfe && text[1] == 0xff) {
+				text = text[2:]
+			}
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			units = append(units, order.Uint16(text[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(text), "\x00")
+	}
+	runes := make([]rune, 0, len(text))
+	for _, c := range text {
+		runes = append(runes, rune(c))
+	}
+	return strings.TrimRight(string(runes), "\x00")
+}
@@ -0,0 +1,85 @@
+package library
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// chapFrame builds an ID3v2.3 CHAP frame body, with a TIT2 subframe when
+// title is set
+func chapFrame(id string, start time.Duration, title string) []byte {
+	b := append([]byte(id), 0)
+	b = binary.BigEndian.AppendUint32(b, uint32(start.Milliseconds()))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, 0xffffffff)
+	b = binary.BigEndian.AppendUint32(b, 0xffffffff)
+	if title != "" {
+		text := append([]byte{3}, title...) // UTF-8
+		b = append(b, "TIT2"...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(text)))
+		b = append(b, 0, 0)
+		b = append(b, text...)
+	}
+	return b
+}
+
+func TestReadChapters_ID3(t *testing.T) {
+	raw := map[string]interface{}{
+		"TIT2":   "Audiobook",
+		"CHAP":   chapFrame("ch1", 90*time.Second, "二章"),
+		"CHAP_0": chapFrame("ch0", 0, "Opening"),
+		"CHAP_1": chapFrame("ch2", 10*time.Minute, ""),
+		"CHAP_2": []byte{1, 2, 3}, // truncated
+	}
+	got := readChapters(raw, tag.ID3v2_3)
+	want := []api.Chapter{{Title: "Opening"}, {Title: "二章", Start: 90 * time.Second}, {Start: 10 * time.Minute}}
+	if len(got) != len(want) {
+		t.Fatalf("chapters = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadChapters_Vorbis(t *testing.T) {
+	raw := map[string]interface{}{
+		"title":          "Live set",
+		"chapter002":     "00:12:30.500",
+		"chapter002name": "Second",
+		"chapter001":     "00:00:00.000",
+		"chapter001name": "First",
+		"chapter003":     "not a time",
+	}
+	got := readChapters(raw, tag.VORBIS)
+	if len(got) != 2 || got[0] != (api.Chapter{Title: "First"}) || got[1] != (api.Chapter{Title: "Second", Start: 12*time.Minute + 30500*time.Millisecond}) {
+		t.Errorf("chapters = %+v", got)
+	}
+	if got := readChapters(map[string]interface{}{"title": "Plain"}, tag.VORBIS); got != nil {
+		t.Errorf("untagged chapters = %+v, want nil", got)
+	}
+}
+
+func TestDecodeID3Text(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		want string
+	}{
+		{[]byte{0, 'C', 'a', 'f', 0xe9, 0}, "Café"},
+		{[]byte{1, 0xff, 0xfe, 'H', 0, 'i', 0}, "Hi"},
+		{[]byte{1, 0xfe, 0xff, 0, 'H', 0, 'i'}, "Hi"},
+		{[]byte{2, 0x5b, 0x87}, "宇"},
+		{append([]byte{3}, "ヒカル"...), "ヒカル"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := decodeID3Text(tt.b); got != tt.want {
+			t.Errorf("decodeID3Text(%v) = %q, want %q", tt.b, got, tt.want)
+		}
+	}
+}
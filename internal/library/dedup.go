@@ -0,0 +1,212 @@
+package library
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// DedupMode chooses how Deduplicate recognises duplicate tracks. The modes
+// combine: DedupHash|DedupTags collapses tracks matching either way.
+type DedupMode int
+
+const (
+	// DedupHash matches files with identical contents. Only files of the
+	// same size are read, a block at a time.
+	DedupHash DedupMode = 1 << iota
+
+	// DedupTags matches tracks with the same title, artist and album,
+	// ignoring case, and durations within dedupDurationSlack of each other.
+	// No files are read.
+	DedupTags
+
+	DedupOff DedupMode = 0
+)
+
+// dedupDurationSlack is how far apart the durations of tag-matched
+// duplicates may be, allowing for encoders padding differently
+const dedupDurationSlack = 2 * time.Second
+
+// ParseDedupMode parses a dedup mode by name: "off", "hash", "tags" or
+// "both"
+func ParseDedupMode(s string) (DedupMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off", "none":
+		return DedupOff, true
+	case "hash":
+		return DedupHash, true
+	case "tags":
+		return DedupTags, true
+	case "both":
+		return DedupHash | DedupTags, true
+	}
+	return DedupOff, false
+}
+
+// DuplicateGroup is a track kept by Deduplicate and the duplicates of it
+// that were left out
+type DuplicateGroup struct {
+	Kept       *api.Track
+	Duplicates []*api.Track
+}
+
+// Deduplicate returns tracks without their duplicates, keeping the first of
+// each set in the order given, and the groups it collapsed. Files that
+// can't be read for hashing are kept as they are.
+func Deduplicate(tracks []*api.Track, mode DedupMode) ([]*api.Track, []DuplicateGroup) {
+	// keptAs maps each left-out track to the index in tracks of the
+	// track kept in its place
+	keptAs := make(map[int]int)
+	if mode&DedupHash != 0 {
+		matchByHash(tracks, keptAs)
+	}
+	if mode&DedupTags != 0 {
+		matchByTags(tracks, keptAs)
+	}
+
+	kept := make([]*api.Track, 0, len(tracks)-len(keptAs))
+	groupOf := make(map[int]int) // Index of a kept track to its group
+	var groups []DuplicateGroup
+	for i, track := range tracks {
+		first, duplicate := keptAs[i]
+		if !duplicate {
+			kept = append(kept, track)
+			continue
+		}
+		g, ok := groupOf[first]
+		if !ok {
+			g = len(groups)
+			groupOf[first] = g
+			groups = append(groups, DuplicateGroup{Kept: tracks[first]})
+		}
+		groups[g].Duplicates = append(groups[g].Duplicates, track)
+	}
+	return kept, groups
+}
+
+// matchByHash records tracks whose file contents match an earlier track's.
+// Sizes are compared first so only files that could match are read.
+func matchByHash(tracks []*api.Track, keptAs map[int]int) {
+	bySize := make(map[int64][]int)
+	for i, track := range tracks {
+		if _, done := keptAs[i]; done {
+			continue
+		}
+		size := track.Size
+		if info, err := os.Stat(track.FilePath); err == nil {
+			size = info.Size()
+		} else if size == 0 {
+			continue
+		}
+		bySize[size] = append(bySize[size], i)
+	}
+
+	for _, indices := range bySize {
+		if len(indices) < 2 {
+			continue
+		}
+		byHash := make(map[string]int)
+		for _, i := range indices {
+			sum, err := hashFile(tracks[i].FilePath)
+			if err != nil {
+				logger.Warn("Dedup: %v", err)
+				continue
+			}
+			if first, ok := byHash[sum]; ok {
+				keptAs[i] = resolveKept(keptAs, first)
+				continue
+			}
+			byHash[sum] = i
+		}
+	}
+}
+
+// matchByTags records tracks whose title, artist, album and duration match
+// an earlier track's. Untitled tracks are never matched.
+func matchByTags(tracks []*api.Track, keptAs map[int]int) {
+	byTags := make(map[string][]int)
+	for i, track := range tracks {
+		if strings.TrimSpace(track.Title) == "" {
+			continue
+		}
+		key := strings.Join([]string{
+			strings.ToLower(strings.TrimSpace(track.Title)),
+			strings.ToLower(strings.TrimSpace(track.Artist)),
+			strings.ToLower(strings.TrimSpace(track.Album)),
+		}, "\x00")
+		byTags[key] = append(byTags[key], i)
+	}
+
+	for _, indices := range byTags {
+		for n, i := range indices {
+			if _, done := keptAs[i]; done {
+				continue
+			}
+			for _, j := range indices[n+1:] {
+				if _, done := keptAs[j]; done {
+					continue
+				}
+				if diff := tracks[i].Duration - tracks[j].Duration; diff <= dedupDurationSlack && diff >= -dedupDurationSlack {
+					keptAs[j] = i
+				}
+			}
+		}
+	}
+}
+
+// resolveKept follows i to the track ultimately kept in its place
+func resolveKept(keptAs map[int]int, i int) int {
+	for {
+		first, ok := keptAs[i]
+		if !ok {
+			return i
+		}
+		i = first
+	}
+}
+
+// hashFile returns the SHA-256 of a file's contents, reading it a block at
+// a time
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// HideDuplicates hides the library's duplicate tracks, found as
+// Deduplicate finds them, and returns the groups collapsed. Of each set
+// the track kept is the first in GetAllTracks order. Hidden tracks stay in
+// the library, so GetTrack still finds them, and calling it again with
+// DedupOff shows them again. With the hash mode it reads files, so it is
+// best called off the UI goroutine.
+func (l *Library) HideDuplicates(mode DedupMode) []DuplicateGroup {
+	var groups []DuplicateGroup
+	if mode != DedupOff {
+		_, groups = Deduplicate(l.sortedTracks(true), mode)
+	}
+
+	hidden := make(map[string]bool)
+	for _, g := range groups {
+		for _, track := range g.Duplicates {
+			hidden[track.ID] = true
+		}
+	}
+	l.mu.Lock()
+	l.hidden = hidden
+	l.mu.Unlock()
+	return groups
+}
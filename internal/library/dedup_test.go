@@ -0,0 +1,100 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestDeduplicate_ByHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) *api.Track {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return &api.Track{ID: name, FilePath: path, Title: name}
+	}
+	a := write("a.mp3", "same audio")
+	b := write("b.mp3", "other aud!") // Same size, different contents
+	c := write("c.mp3", "same audio")
+	d := write("d.mp3", "same audio")
+	missing := &api.Track{ID: "gone", FilePath: filepath.Join(dir, "gone.mp3"), Size: 10}
+
+	kept, groups := Deduplicate([]*api.Track{a, b, missing, c, d}, DedupHash)
+	if len(kept) != 3 || kept[0] != a || kept[1] != b || kept[2] != missing {
+		t.Errorf("kept = %v", kept)
+	}
+	if len(groups) != 1 || groups[0].Kept != a || len(groups[0].Duplicates) != 2 ||
+		groups[0].Duplicates[0] != c || groups[0].Duplicates[1] != d {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestDeduplicate_ByTags(t *testing.T) {
+	a := &api.Track{ID: "a", Title: "Song", Artist: "Band", Album: "LP", Duration: 200 * time.Second}
+	b := &api.Track{ID: "b", Title: "song ", Artist: "BAND", Album: "lp", Duration: 201 * time.Second}
+	live := &api.Track{ID: "live", Title: "Song", Artist: "Band", Album: "LP", Duration: 260 * time.Second}
+	other := &api.Track{ID: "other", Title: "Song", Artist: "Band", Album: "Live", Duration: 200 * time.Second}
+	untitled1 := &api.Track{ID: "u1", Duration: time.Minute}
+	untitled2 := &api.Track{ID: "u2", Duration: time.Minute}
+
+	kept, groups := Deduplicate([]*api.Track{a, b, live, other, untitled1, untitled2}, DedupTags)
+	if len(kept) != 5 || kept[1] != live {
+		t.Errorf("kept = %v", kept)
+	}
+	if len(groups) != 1 || groups[0].Kept != a || len(groups[0].Duplicates) != 1 || groups[0].Duplicates[0] != b {
+		t.Errorf("groups = %+v", groups)
+	}
+
+	if kept, groups := Deduplicate([]*api.Track{a, b}, DedupOff); len(kept) != 2 || groups != nil {
+		t.Errorf("off: kept %v, groups %v", kept, groups)
+	}
+}
+
+func TestLibrary_HideDuplicates(t *testing.T) {
+	lib := NewLibrary()
+	a := &api.Track{ID: "a", FilePath: "/m/a.mp3", Title: "Song", Artist: "Band", Album: "LP", Duration: 200 * time.Second}
+	b := &api.Track{ID: "b", FilePath: "/m/b.mp3", Title: "Song", Artist: "Band", Album: "LP", Duration: 200 * time.Second}
+	lib.AddTrack(a)
+	lib.AddTrack(b)
+
+	groups := lib.HideDuplicates(DedupTags)
+	if len(groups) != 1 || groups[0].Kept != a {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if all := lib.GetAllTracks(); len(all) != 1 || all[0] != a {
+		t.Errorf("GetAllTracks = %v", all)
+	}
+	if got := lib.GetTracksByAlbum("LP"); len(got) != 1 {
+		t.Errorf("GetTracksByAlbum = %v", got)
+	}
+	if got := lib.Search("song"); len(got) != 1 {
+		t.Errorf("Search = %v", got)
+	}
+	if got, err := lib.GetTrack("b"); err != nil || got != b {
+		t.Errorf("GetTrack(hidden) = %v, %v", got, err)
+	}
+
+	// Hiding again finds the same set, and off shows them all
+	if groups := lib.HideDuplicates(DedupTags); len(groups) != 1 {
+		t.Errorf("second pass groups = %+v", groups)
+	}
+	if groups := lib.HideDuplicates(DedupOff); groups != nil || len(lib.GetAllTracks()) != 2 {
+		t.Errorf("off: groups %v, tracks %v", groups, lib.GetAllTracks())
+	}
+}
+
+func TestParseDedupMode(t *testing.T) {
+	for in, want := range map[string]DedupMode{"": DedupOff, "off": DedupOff, "Hash": DedupHash, "tags": DedupTags, "both": DedupHash | DedupTags} {
+		if got, ok := ParseDedupMode(in); !ok || got != want {
+			t.Errorf("ParseDedupMode(%q) = %v, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParseDedupMode("fuzzy"); ok {
+		t.Error("ParseDedupMode(fuzzy) ok")
+	}
+}
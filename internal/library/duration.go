@@ -0,0 +1,294 @@
+package library
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// mp3ScanWindow is how far past the ID3v2 tag the first MPEG frame is
+// looked for
+const mp3ScanWindow = 8 << 10
+
+// mp3SampleRates are the sample rates by MPEG version (MPEG-1, MPEG-2,
+// MPEG-2.5) and header index
+var mp3SampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+// mp3Bitrates are the bitrates in kbit/s by MPEG-1 layer (I, II, III),
+// then MPEG-2 and 2.5 Layer I and Layers II and III, and header index
+var mp3Bitrates = [5][15]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// mp3Header is what an MPEG audio frame header says about its frame
+type mp3Header struct {
+	sampleRate int
+	samples    int // Samples per frame
+	sideInfo   int // Size of the Layer III side information before a Xing header
+	bitrate    int // Bits per second
+	size       int // Bytes in the frame, header included
+}
+
+// headerDuration returns a file's duration as its headers give it, without
+// decoding the audio. WAV and FLAC headers hold their length; an MP3 has
+// one when its first frame carries a Xing, Info or VBRI header, or failing
+// that when it is tagged with TLEN, or else when its first two frames have
+// the same bitrate, which a constant bitrate file's size divides by.
+// Otherwise it returns 0, and NeedsDuration reports the track for
+// ScanDuration to fill in later. metadata may be nil when the file has no
+// tags.
+func headerDuration(filePath string, file *os.File, metadata tag.Metadata) time.Duration {
+	if strings.ToLower(filepath.Ext(filePath)) != ".mp3" {
+		file.Seek(0, io.SeekStart)
+		return computeAudioDuration(filePath, file)
+	}
+	if d, ok := mp3HeaderDuration(file); ok {
+		return d
+	}
+	if metadata != nil {
+		if value, ok := rawTag(metadata, "TLEN", "TLE"); ok {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+				return time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if info, err := file.Stat(); err == nil {
+		if d, ok := mp3CBRDuration(file, info.Size()); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// mp3HeaderDuration reads the frame count from the Xing, Info or VBRI
+// header in an MP3's first frame, which encoders write for VBR files and
+// LAME writes for CBR ones too
+func mp3HeaderDuration(r io.ReadSeeker) (time.Duration, bool) {
+	_, frame, header, ok := findMP3Frame(r)
+	if !ok {
+		return 0, false
+	}
+	frames, ok := xingFrames(frame, 4+header.sideInfo)
+	if !ok {
+		frames, ok = vbriFrames(frame)
+	}
+	if !ok || frames == 0 {
+		return 0, false
+	}
+	return time.Duration(int64(frames) * int64(header.samples) * int64(time.Second) / int64(header.sampleRate)), true
+}
+
+// mp3CBRDuration estimates the duration of a constant bitrate MP3 of size
+// bytes from its bitrate and the bytes of audio after its ID3v2 tag and
+// before an ID3v1 one. It is only trusted when the frame after the first
+// has the same bitrate, so VBR files without a header are left to decode.
+func mp3CBRDuration(r io.ReadSeeker, size int64) (time.Duration, bool) {
+	start, frame, header, ok := findMP3Frame(r)
+	if !ok {
+		return 0, false
+	}
+	if header.size+4 <= len(frame) {
+		next, ok := parseMP3Header(frame[header.size:])
+		if !ok || next.bitrate != header.bitrate {
+			return 0, false
+		}
+	}
+
+	end := size
+	var id3v1 [3]byte
+	if _, err := r.Seek(size-128, io.SeekStart); err == nil {
+		if _, err := io.ReadFull(r, id3v1[:]); err == nil && string(id3v1[:]) == "TAG" {
+			end -= 128
+		}
+	}
+	if end <= start {
+		return 0, false
+	}
+	return time.Duration((end - start) * 8 * int64(time.Second) / int64(header.bitrate)), true
+}
+
+// findMP3Frame finds an MP3's first MPEG frame past its ID3v2 tag,
+// returning where it starts, the bytes read from there and its header
+func findMP3Frame(r io.ReadSeeker) (start int64, frame []byte, header mp3Header, ok bool) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, nil, mp3Header{}, false
+	}
+	var id3 [10]byte
+	if _, err := io.ReadFull(r, id3[:]); err != nil {
+		return 0, nil, mp3Header{}, false
+	}
+	if bytes.Equal(id3[:3], []byte("ID3")) {
+		start = 10 + (int64(id3[6]&0x7f)<<21 | int64(id3[7]&0x7f)<<14 | int64(id3[8]&0x7f)<<7 | int64(id3[9]&0x7f))
+		if id3[5]&0x10 != 0 {
+			start += 10 // Footer
+		}
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, nil, mp3Header{}, false
+	}
+	buf := make([]byte, mp3ScanWindow)
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if header, ok := parseMP3Header(buf[i:]); ok {
+			return start + int64(i), buf[i:], header, true
+		}
+	}
+	return 0, nil, mp3Header{}, false
+}
+
+// parseMP3Header parses an MPEG audio frame header
+func parseMP3Header(b []byte) (h mp3Header, ok bool) {
+	if b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return mp3Header{}, false
+	}
+	version := (b[1] >> 3) & 3 // 3 MPEG-1, 2 MPEG-2, 0 MPEG-2.5
+	layer := (b[1] >> 1) & 3   // 1 Layer III, 2 Layer II, 3 Layer I
+	bitrate := b[2] >> 4
+	rate := (b[2] >> 2) & 3
+	if version == 1 || layer == 0 || bitrate == 0 || bitrate == 15 || rate == 3 {
+		return mp3Header{}, false
+	}
+
+	mpeg1 := version == 3
+	row := 0
+	switch version {
+	case 2:
+		row = 1
+	case 0:
+		row = 2
+	}
+	h.sampleRate = mp3SampleRates[row][rate]
+
+	switch {
+	case layer == 3:
+		h.samples = 384
+	case layer == 2 || mpeg1:
+		h.samples = 1152
+	default:
+		h.samples = 576
+	}
+
+	table := 4
+	switch {
+	case mpeg1:
+		table = int(3 - layer)
+	case layer == 3:
+		table = 3
+	}
+	h.bitrate = mp3Bitrates[table][bitrate] * 1000
+
+	// Padding adds a slot: four bytes in Layer I, one in the others
+	padding := int(b[2]>>1) & 1
+	if layer == 3 {
+		h.size = (12*h.bitrate/h.sampleRate + padding) * 4
+	} else {
+		h.size = h.samples/8*h.bitrate/h.sampleRate + padding
+	}
+
+	mono := b[3]>>6 == 3
+	switch {
+	case mpeg1 && mono:
+		h.sideInfo = 17
+	case mpeg1:
+		h.sideInfo = 32
+	case mono:
+		h.sideInfo = 9
+	default:
+		h.sideInfo = 17
+	}
+	return h, true
+}
+
+// xingFrames reads the frame count of a Xing or Info header at offset
+func xingFrames(frame []byte, offset int) (uint32, bool) {
+	if len(frame) < offset+12 {
+		return 0, false
+	}
+	id := string(frame[offset : offset+4])
+	if id != "Xing" && id != "Info" {
+		return 0, false
+	}
+	flags := binary.BigEndian.Uint32(frame[offset+4:])
+	if flags&1 == 0 {
+		return 0, false // No frame count
+	}
+	return binary.BigEndian.Uint32(frame[offset+8:]), true
+}
+
+// vbriFrames reads the frame count of a Fraunhofer VBRI header, which
+// always sits 32 bytes past the frame header
+func vbriFrames(frame []byte) (uint32, bool) {
+	const offset = 4 + 32
+	if len(frame) < offset+18 || string(frame[offset:offset+4]) != "VBRI" {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(frame[offset+14:]), true
+}
+
+// NeedsDuration reports whether a track's duration is still unknown and
+// ScanDuration could find it. Streams have none to find.
+func NeedsDuration(track *api.Track) bool {
+	return track.Duration <= 0 && !track.IsStream() && track.FilePath != ""
+}
+
+// SetDuration fills in a track's duration, as ScanDuration found it, under
+// the library's lock, unless the track has one by now. The library's own
+// copy of the track is changed too, should track be a copy of it.
+func (l *Library) SetDuration(track *api.Track, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if track.Duration <= 0 {
+		track.Duration = d
+	}
+	if own, ok := l.Tracks[track.ID]; ok && own.Duration <= 0 {
+		own.Duration = d
+	}
+}
+
+// ScanDuration decodes a track's whole file to find its duration, for the
+// files whose headers don't give it. The result is stored in the metadata
+// cache but the track itself is not modified; SetDuration fills it in.
+func (l *Library) ScanDuration(track *api.Track) (time.Duration, error) {
+	file, err := os.Open(track.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat file: %w", err)
+	}
+
+	d := computeAudioDuration(track.FilePath, file)
+	if d <= 0 {
+		return 0, fmt.Errorf("decode %s: no duration found", filepath.Base(track.FilePath))
+	}
+
+	l.mu.RLock()
+	cache := l.cache
+	l.mu.RUnlock()
+	if cache != nil {
+		cache.SetDuration(track.FilePath, info, d)
+	}
+	return d, nil
+}
@@ -0,0 +1,149 @@
+package library
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// mp3Frame returns an MPEG-1 Layer III stereo frame at 44.1kHz whose side
+// information is followed by extra
+func mp3Frame(extra []byte) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	copy(frame[4+32:], extra)
+	return frame
+}
+
+// xingHeader returns a Xing or Info header giving a frame count
+func xingHeader(id string, frames uint32) []byte {
+	b := []byte(id)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	return binary.BigEndian.AppendUint32(b, frames)
+}
+
+func TestMP3HeaderDuration(t *testing.T) {
+	// 1000 frames of 1152 samples at 44.1kHz
+	want := time.Duration(1000 * 1152 * int64(time.Second) / 44100)
+
+	vbri := append([]byte("VBRI"), make([]byte, 10)...)
+	vbri = binary.BigEndian.AppendUint32(vbri, 1000)
+
+	tagged := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20}
+	tagged = append(tagged, make([]byte, 20)...)
+	tagged = append(tagged, mp3Frame(xingHeader("Info", 1000))...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want time.Duration
+		ok   bool
+	}{
+		{"xing", mp3Frame(xingHeader("Xing", 1000)), want, true},
+		{"vbri", mp3Frame(vbri), want, true},
+		{"after id3v2 tag", tagged, want, true},
+		{"no frame count", mp3Frame(nil), 0, false},
+		{"not mp3", []byte("RIFF....WAVEfmt of some length"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mp3HeaderDuration(bytes.NewReader(tt.data))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("mp3HeaderDuration() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMP3CBRDuration(t *testing.T) {
+	// Two 417-byte frames at 128kbit/s
+	cbr := append(mp3Frame(nil), mp3Frame(nil)...)
+	want := time.Duration(2 * 417 * 8 * int64(time.Second) / 128000)
+
+	vbr := mp3Frame(nil)
+	vbr = append(vbr, 0xff, 0xfb, 0xa0, 0x00) // 160kbit/s
+	vbr = append(vbr, make([]byte, 500)...)
+
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want time.Duration
+		ok   bool
+	}{
+		{"constant bitrate", cbr, want, true},
+		{"before an id3v1 tag", append(slices.Clone(cbr), id3v1...), want, true},
+		{"bitrate changes", vbr, 0, false},
+		{"not mp3", []byte("RIFF....WAVEfmt of some length"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mp3CBRDuration(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("mp3CBRDuration() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNeedsDuration(t *testing.T) {
+	tests := []struct {
+		track *api.Track
+		want  bool
+	}{
+		{&api.Track{FilePath: "/music/a.mp3"}, true},
+		{&api.Track{FilePath: "/music/a.mp3", Duration: time.Minute}, false},
+		{NewStreamTrack("http://radio.example/live"), false},
+		{&api.Track{}, false},
+	}
+	for _, tt := range tests {
+		if got := NeedsDuration(tt.track); got != tt.want {
+			t.Errorf("NeedsDuration(%q) = %v, want %v", tt.track.FilePath, got, tt.want)
+		}
+	}
+}
+
+func TestLibraryScanDuration_UpdatesCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.wav")
+	writeSilentWAV(t, path, 500*time.Millisecond)
+
+	lib := NewLibrary()
+	cache := NewMetadataCache(filepath.Join(t.TempDir(), "cache.json"))
+	lib.SetMetadataCache(cache)
+	track, err := lib.scanner.metaReader.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As for an MP3 whose headers didn't give a duration
+	track.Duration = 0
+	for _, entry := range cache.Entries {
+		entry.Track.Duration = 0
+	}
+
+	d, err := lib.ScanDuration(track)
+	if err != nil {
+		t.Fatalf("ScanDuration: %v", err)
+	}
+	if d < 400*time.Millisecond {
+		t.Errorf("ScanDuration = %v, want about 500ms", d)
+	}
+	if track.Duration != 0 {
+		t.Error("ScanDuration should leave the track for the caller to update")
+	}
+	if got := cache.Entries[path].Track.Duration; got != d {
+		t.Errorf("cached duration = %v, want %v", got, d)
+	}
+
+	lib.AddTrack(track)
+	lib.SetDuration(track, d)
+	if own, _ := lib.GetTrack(track.ID); track.Duration != d || own.Duration != d {
+		t.Errorf("SetDuration left %v, %v; want %v", track.Duration, own.Duration, d)
+	}
+}
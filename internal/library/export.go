@@ -0,0 +1,149 @@
+package library
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// Format is a library export file format
+type Format int
+
+const (
+	FormatCSV Format = iota
+	FormatJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSON:
+		return "json"
+	}
+	return "unknown"
+}
+
+// FormatFromPath picks the export format from a file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return 0, fmt.Errorf("unsupported export format %q", filepath.Ext(path))
+}
+
+// exportRecord is the flat, tool-friendly shape of an exported track.
+// Durations are written in seconds rather than Go's nanosecond encoding.
+type exportRecord struct {
+	Path            string  `json:"path"`
+	Title           string  `json:"title,omitempty"`
+	Artist          string  `json:"artist,omitempty"`
+	Album           string  `json:"album,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Genre           string  `json:"genre,omitempty"`
+	Year            int     `json:"year,omitempty"`
+	TrackNum        int     `json:"track_number,omitempty"`
+	DiscNum         int     `json:"disc_number,omitempty"`
+	BPM             float64 `json:"bpm,omitempty"`
+	SizeBytes       int64   `json:"size_bytes,omitempty"`
+	Rating          int     `json:"rating,omitempty"`
+	Plays           int     `json:"plays,omitempty"`
+}
+
+var csvHeader = []string{
+	"path", "title", "artist", "album", "duration_seconds",
+	"genre", "year", "track_number", "disc_number", "bpm",
+	"size_bytes", "rating", "plays",
+}
+
+func newExportRecord(t *api.Track, plays int) exportRecord {
+	return exportRecord{
+		Path:            t.FilePath,
+		Title:           t.Title,
+		Artist:          t.Artist,
+		Album:           t.Album,
+		DurationSeconds: t.Duration.Seconds(),
+		Genre:           t.Genre,
+		Year:            t.Year,
+		TrackNum:        t.TrackNum,
+		DiscNum:         t.DiscNum,
+		BPM:             t.BPM,
+		SizeBytes:       t.Size,
+		Rating:          t.Rating,
+		Plays:           plays,
+	}
+}
+
+// csvRow renders a record as CSV fields, leaving unknown numbers empty
+func (r exportRecord) csvRow() []string {
+	return []string{
+		r.Path, r.Title, r.Artist, r.Album, formatFloat(r.DurationSeconds),
+		r.Genre, formatInt(r.Year), formatInt(r.TrackNum), formatInt(r.DiscNum),
+		formatFloat(r.BPM), formatInt64(r.SizeBytes), formatInt(r.Rating), formatInt(r.Plays),
+	}
+}
+
+// ExportLibrary writes tracks to w in the given format, with each track's
+// play count taken from stats. CSV output starts with a header row; JSON
+// output is an array of objects.
+func ExportLibrary(w io.Writer, tracks []*api.Track, stats ListeningStats, format Format) error {
+	plays := make(map[string]int, len(stats.Tracks))
+	for _, row := range stats.Tracks {
+		plays[row.Path] = row.Plays
+	}
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, t := range tracks {
+			if err := cw.Write(newExportRecord(t, plays[t.FilePath]).csvRow()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case FormatJSON:
+		records := make([]exportRecord, 0, len(tracks))
+		for _, t := range tracks {
+			records = append(records, newExportRecord(t, plays[t.FilePath]))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	return fmt.Errorf("unsupported export format %v", format)
+}
+
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func formatInt64(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
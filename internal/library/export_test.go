@@ -0,0 +1,86 @@
+package library
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func exportTracks() []*api.Track {
+	return []*api.Track{
+		{
+			FilePath: "/music/a.mp3",
+			Title:    "Hello, World",
+			Artist:   "Line\nBreak",
+			Album:    `Say "Hi"`,
+			Duration: 90 * time.Second,
+			Year:     1999,
+			TrackNum: 3,
+			Size:     4_000_000,
+			Rating:   4,
+		},
+		{FilePath: "/music/b.flac"},
+	}
+}
+
+// exportStats has plays of the first export track only
+func exportStats() ListeningStats {
+	return ListeningStats{Tracks: []TrackStats{{Path: "/music/a.mp3", Plays: 7}}}
+}
+
+func TestExportLibrary_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLibrary(&buf, exportTracks(), exportStats(), FormatCSV); err != nil {
+		t.Fatalf("ExportLibrary: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2", len(rows))
+	}
+
+	row := rows[1]
+	if row[1] != "Hello, World" || row[2] != "Line\nBreak" || row[3] != `Say "Hi"` {
+		t.Errorf("quoted fields did not round-trip: %q", row[1:4])
+	}
+	if row[4] != "90" || row[6] != "1999" || row[7] != "3" {
+		t.Errorf("numeric fields = %q", row[4:8])
+	}
+	if row[10] != "4000000" || row[11] != "4" || row[12] != "7" {
+		t.Errorf("size, rating and plays = %q", row[10:13])
+	}
+	if rows[2][6] != "" || rows[2][12] != "" {
+		t.Errorf("unknown year and plays should be empty, got %q", rows[2])
+	}
+}
+
+func TestExportLibrary_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLibrary(&buf, exportTracks(), exportStats(), FormatJSON); err != nil {
+		t.Fatalf("ExportLibrary: %v", err)
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("exported JSON does not parse: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0]["duration_seconds"] != 90.0 {
+		t.Errorf("duration_seconds = %v", records[0]["duration_seconds"])
+	}
+	if records[0]["rating"] != 4.0 || records[0]["plays"] != 7.0 || records[0]["size_bytes"] != 4e6 {
+		t.Errorf("record = %v", records[0])
+	}
+	if _, ok := records[1]["year"]; ok {
+		t.Error("unpopulated year should be omitted")
+	}
+}
@@ -0,0 +1,245 @@
+package library
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ITunesTrim is a custom start/stop time set on a track in iTunes. A zero
+// End means the track plays to its natural end.
+type ITunesTrim struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ITunesTrims holds the start/stop times from an iTunes library, keyed by
+// file path
+type ITunesTrims struct {
+	byPath map[string]ITunesTrim
+	byTail map[string]ITunesTrim // keyed by artist/album/file for moved libraries, if unique
+}
+
+// LoadITunesTrims reads custom start/stop times from an exported iTunes
+// library ("iTunes Library.xml"). iTunes keeps these in its library rather
+// than in the audio files, so this is the only place they can be read from.
+func LoadITunesTrims(path string) (*ITunesTrims, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open itunes library: %w", err)
+	}
+	defer file.Close()
+
+	trims, err := parseITunesTrims(file)
+	if err != nil {
+		return nil, fmt.Errorf("parse itunes library: %w", err)
+	}
+	return trims, nil
+}
+
+// parseITunesTrims reads the Tracks dictionary of an iTunes plist
+func parseITunesTrims(r io.Reader) (*ITunesTrims, error) {
+	dec := xml.NewDecoder(r)
+	root, err := decodePlist(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	trims := &ITunesTrims{
+		byPath: make(map[string]ITunesTrim),
+		byTail: make(map[string]ITunesTrim),
+	}
+	// Tails are counted over every track, trimmed or not, so a file whose
+	// tail another track shares is only ever matched by its full path
+	tails := make(map[string]int)
+	rootDict, _ := root.(map[string]any)
+	tracks, _ := rootDict["Tracks"].(map[string]any)
+	for _, v := range tracks {
+		entry, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		location, _ := entry["Location"].(string)
+		filePath, ok := locationPath(location)
+		if !ok {
+			continue
+		}
+		tail := pathTail(filePath)
+		tails[tail]++
+
+		start, _ := entry["Start Time"].(int64)
+		stop, _ := entry["Stop Time"].(int64)
+		if start <= 0 && stop <= 0 {
+			continue
+		}
+		trim := ITunesTrim{Start: time.Duration(start) * time.Millisecond}
+		if stop > start {
+			trim.End = time.Duration(stop) * time.Millisecond
+		}
+		trims.byPath[filePath] = trim
+		trims.byTail[tail] = trim
+	}
+	for tail := range trims.byTail {
+		if tails[tail] > 1 {
+			delete(trims.byTail, tail)
+		}
+	}
+	return trims, nil
+}
+
+// Lookup returns the trim for a file. Files are matched by full path, or
+// failing that by their last three path elements (artist/album/file in the
+// iTunes media layout) so a library copied to a new location still matches.
+// The fallback only matches when no other iTunes track has the same three
+// elements.
+func (t *ITunesTrims) Lookup(filePath string) (ITunesTrim, bool) {
+	if t == nil {
+		return ITunesTrim{}, false
+	}
+	if trim, ok := t.byPath[filepath.Clean(filePath)]; ok {
+		return trim, true
+	}
+	trim, ok := t.byTail[pathTail(filepath.Clean(filePath))]
+	return trim, ok
+}
+
+// Len returns the number of tracks with trims
+func (t *ITunesTrims) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.byPath)
+}
+
+// locationPath converts an iTunes "file://" Location to a local path.
+// Windows iTunes writes file://localhost/C:/Music/..., whose path keeps a
+// slash ahead of the drive letter; a host other than localhost is a
+// network share, \\host\share on Windows.
+func locationPath(location string) (string, bool) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	p := u.Path
+	if isDrivePath(p) {
+		p = p[1:]
+	} else if u.Host != "" && u.Host != "localhost" {
+		p = "//" + u.Host + p
+	}
+	return filepath.Clean(filepath.FromSlash(p)), true
+}
+
+// isDrivePath reports whether a URL path starts with a Windows drive
+// letter, as in /C:/Music
+func isDrivePath(p string) bool {
+	if len(p) < 3 || p[0] != '/' || p[2] != ':' {
+		return false
+	}
+	c := p[1] | 0x20 // Lower case
+	return c >= 'a' && c <= 'z'
+}
+
+// pathTail returns the last three elements of a path
+func pathTail(p string) string {
+	parts := strings.Split(filepath.ToSlash(p), "/")
+	if len(parts) > 3 {
+		parts = parts[len(parts)-3:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// decodePlist decodes the first plist value in the stream into Go values:
+// dict becomes map[string]any, array []any, integer int64, real float64,
+// true/false bool, and string/date/data string.
+func decodePlist(dec *xml.Decoder) (any, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(dec, start)
+		}
+	}
+}
+
+func decodePlistValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				if tok.Name.Local == "key" {
+					var k string
+					if err := dec.DecodeElement(&k, &tok); err != nil {
+						return nil, err
+					}
+					key = k
+					continue
+				}
+				v, err := decodePlistValue(dec, tok)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var arr []any
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				v, err := decodePlistValue(dec, tok)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			case xml.EndElement:
+				return arr, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "integer":
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", text)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid real %q", text)
+		}
+		return f, nil
+	}
+	return text, nil
+}
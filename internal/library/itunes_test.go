@@ -0,0 +1,126 @@
+package library
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+const itunesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Major Version</key><integer>1</integer>
+	<key>Tracks</key>
+	<dict>
+		<key>101</key>
+		<dict>
+			<key>Track ID</key><integer>101</integer>
+			<key>Name</key><string>Intro Heavy</string>
+			<key>Start Time</key><integer>12500</integer>
+			<key>Stop Time</key><integer>200000</integer>
+			<key>Compilation</key><true/>
+			<key>Location</key><string>file:///Users/me/Music/iTunes/iTunes%20Media/Music/Artist/Album/01%20Intro%20Heavy.mp3</string>
+		</dict>
+		<key>102</key>
+		<dict>
+			<key>Track ID</key><integer>102</integer>
+			<key>Name</key><string>Untrimmed</string>
+			<key>Location</key><string>file:///Users/me/Music/Artist/Album/02%20Untrimmed.mp3</string>
+		</dict>
+	</dict>
+	<key>Playlists</key><array><dict><key>Name</key><string>Library</string></dict></array>
+</dict>
+</plist>`
+
+func TestParseITunesTrims(t *testing.T) {
+	trims, err := parseITunesTrims(strings.NewReader(itunesXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trims.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", trims.Len())
+	}
+
+	want := ITunesTrim{Start: 12500 * time.Millisecond, End: 200 * time.Second}
+	if got, ok := trims.Lookup("/Users/me/Music/iTunes/iTunes Media/Music/Artist/Album/01 Intro Heavy.mp3"); !ok || got != want {
+		t.Errorf("Lookup by path = %v, %v; want %v", got, ok, want)
+	}
+	if got, ok := trims.Lookup("/home/me/music/Artist/Album/01 Intro Heavy.mp3"); !ok || got != want {
+		t.Errorf("Lookup by tail = %v, %v; want %v", got, ok, want)
+	}
+	if _, ok := trims.Lookup("/Users/me/Music/Artist/Album/02 Untrimmed.mp3"); ok {
+		t.Error("track without start/stop times should not have a trim")
+	}
+}
+
+func TestITunesTrims_AppTrimWins(t *testing.T) {
+	trims, err := parseITunesTrims(strings.NewReader(itunesXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(t.TempDir() + "/sidecar.json"))
+	lib.SetITunesTrims(trims)
+
+	track := &api.Track{ID: "1", FilePath: "/music/Artist/Album/01 Intro Heavy.mp3"}
+	lib.AddTrack(track)
+	if track.TrimStart != 12500*time.Millisecond {
+		t.Errorf("imported TrimStart = %v, want 12.5s", track.TrimStart)
+	}
+
+	// Clearing the trim in the app overrides the iTunes times
+	if err := lib.SetTrim(track, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	lib.SetITunesTrims(trims)
+	if track.TrimStart != 0 || track.TrimEnd != 0 {
+		t.Errorf("trim after clearing = %v..%v, want none", track.TrimStart, track.TrimEnd)
+	}
+}
+
+func TestLocationPath(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+		ok       bool
+	}{
+		{"file:///Users/me/Music/a%20b.mp3", "/Users/me/Music/a b.mp3", true},
+		{"file://localhost/C:/Users/me/Music/a.mp3", filepath.Clean("C:/Users/me/Music/a.mp3"), true},
+		{"file://localhost/Users/me/a.mp3", "/Users/me/a.mp3", true},
+		{"file://nas/music/a.mp3", filepath.Clean("//nas/music/a.mp3"), true},
+		{"http://example.com/a.mp3", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := locationPath(tt.location)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("locationPath(%q) = %q, %v; want %q, %v", tt.location, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestITunesTrims_TailMustBeUnique(t *testing.T) {
+	const xml = `<plist version="1.0"><dict><key>Tracks</key><dict>
+		<key>1</key><dict>
+			<key>Start Time</key><integer>5000</integer>
+			<key>Location</key><string>file:///old/one/Artist/Album/01.mp3</string>
+		</dict>
+		<key>2</key><dict>
+			<key>Location</key><string>file:///old/two/Artist/Album/01.mp3</string>
+		</dict>
+	</dict></dict></plist>`
+	trims, err := parseITunesTrims(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := trims.Lookup("/new/Artist/Album/01.mp3"); ok {
+		t.Error("matched a tail two iTunes tracks share")
+	}
+	if _, ok := trims.Lookup("/old/one/Artist/Album/01.mp3"); !ok {
+		t.Error("full path no longer matches")
+	}
+}
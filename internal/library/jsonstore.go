@@ -0,0 +1,78 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+)
+
+// jsonStore is what the small JSON files kept per track have in common:
+// entries keyed by file path, the file they're saved to and the lock
+// guarding them. Each store embeds one, so the entries are saved as its
+// "entries" field.
+type jsonStore[E any] struct {
+	Entries map[string]E `json:"entries"`
+
+	path   string
+	mu     sync.RWMutex
+	saving sync.Mutex // Held through a whole save, so saves land in order
+}
+
+// init empties the store, to be saved to path
+func (s *jsonStore[E]) init(path string) {
+	s.Entries = make(map[string]E)
+	s.path = path
+}
+
+// load reads the store's file into v, the struct embedding the store. A
+// missing file leaves it empty; what names the store in errors.
+func (s *jsonStore[E]) load(v any, what string) error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s file: %w", what, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", what, err)
+	}
+	if s.Entries == nil {
+		s.Entries = make(map[string]E)
+	}
+	return nil
+}
+
+// save writes v, the struct embedding the store, to the store's file.
+// It is safe to call off the goroutine changing the store: the entries
+// are read under the lock, and a save started later never lands first.
+func (s *jsonStore[E]) save(v any, what string) error {
+	s.saving.Lock()
+	defer s.saving.Unlock()
+
+	s.mu.RLock()
+	data, err := json.MarshalIndent(v, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", what, err)
+	}
+	if err := atomicfile.WriteData(s.path, data); err != nil {
+		return fmt.Errorf("write %s file: %w", what, err)
+	}
+	return nil
+}
+
+// Rename moves what is kept for oldPath to newPath after the file has
+// been moved, replacing anything kept for newPath
+func (s *jsonStore[E]) Rename(oldPath, newPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.Entries[oldPath]; ok {
+		delete(s.Entries, oldPath)
+		s.Entries[newPath] = entry
+	}
+}
@@ -0,0 +1,56 @@
+package library
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONStore_SavesEntriesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data", "plays.json")
+	s := NewPlayStats(path)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		s.Record("/music/a.mp3", at)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Entries map[string]PlayStat `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := raw.Entries["/music/a.mp3"].Count; got != 8 {
+		t.Errorf("saved count = %d, want 8", got)
+	}
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("left %d files beside the store, want only it", len(files)-1)
+	}
+
+	s.Rename("/music/a.mp3", "/music/b.mp3")
+	if s.Get("/music/a.mp3").Count != 0 || s.Get("/music/b.mp3").Count != 8 {
+		t.Error("Rename didn't move the entry")
+	}
+}
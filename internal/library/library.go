@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -27,8 +28,15 @@ type Library struct {
 	albumIndex  map[string][]string
 	genreIndex  map[string][]string
 
+	// hidden holds the IDs of duplicates HideDuplicates has hidden. They
+	// stay in Tracks, and are saved, but aren't listed or searched.
+	hidden map[string]bool
+
 	mu      sync.RWMutex
 	scanner *Scanner
+	sidecar *Sidecar
+	itunes  *ITunesTrims
+	cache   *MetadataCache
 }
 
 // NewLibrary creates a new empty library
@@ -42,11 +50,166 @@ func NewLibrary() *Library {
 	}
 }
 
+// SetSidecar attaches the per-file sidecar store and applies its cached
+// analysis results to tracks already in the library
+func (l *Library) SetSidecar(sc *Sidecar) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sidecar = sc
+	for _, track := range l.Tracks {
+		l.applySidecar(track)
+	}
+}
+
+// SetMetadataCache makes scans reuse the tags cached for files that haven't
+// changed, and store the tags of files they have to read
+func (l *Library) SetMetadataCache(c *MetadataCache) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache = c
+	l.scanner.metaReader.cache = c
+}
+
+// SetScanOptions sets how later scans walk the music directories
+func (l *Library) SetScanOptions(opts ScanOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.scanner.SetOptions(opts)
+}
+
+// Sidecar returns the attached sidecar store, or nil if none
+func (l *Library) Sidecar() *Sidecar {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sidecar
+}
+
+// SetITunesTrims attaches start/stop times imported from iTunes. They act
+// as trims for tracks that don't have one set in the app.
+func (l *Library) SetITunesTrims(trims *ITunesTrims) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.itunes = trims
+	for _, track := range l.Tracks {
+		l.applySidecar(track)
+	}
+}
+
+// applySidecar fills in fields the tags didn't provide from the sidecar
+// cache, and trims from the sidecar or else from imported iTunes times
+func (l *Library) applySidecar(track *api.Track) {
+	if l.sidecar != nil && track.BPM == 0 {
+		if bpm, ok := l.sidecar.CachedBPM(track.FilePath); ok {
+			track.BPM, track.BPMDetected = bpm, true
+		}
+	}
+	if l.sidecar != nil {
+		track.Rating = l.sidecar.Rating(track.FilePath)
+		track.Gain = l.sidecar.Gain(track.FilePath)
+	}
+
+	if l.sidecar != nil {
+		if start, end, ok := l.sidecar.Trim(track.FilePath); ok {
+			track.TrimStart, track.TrimEnd = start, end
+			return
+		}
+	}
+	if trim, ok := l.itunes.Lookup(track.FilePath); ok {
+		track.TrimStart, track.TrimEnd = trim.Start, trim.End
+		return
+	}
+	track.TrimStart, track.TrimEnd = 0, 0
+}
+
+// SetRating rates a track from 1 to api.MaxRating stars, or clears its rating
+// with 0, and stores it in the sidecar. The library's own copy of the
+// track is rated too, should track be a copy of it.
+func (l *Library) SetRating(track *api.Track, rating int) error {
+	if rating < 0 || rating > api.MaxRating {
+		return playerrors.ErrInvalidRating
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sidecar != nil {
+		l.sidecar.SetRating(track.FilePath, rating)
+	}
+	track.Rating = rating
+	if own, ok := l.Tracks[track.ID]; ok {
+		own.Rating = rating
+	}
+	return nil
+}
+
+// MaxGain is the furthest, in dB either way, a track's level can be
+// offset by hand
+const MaxGain = 12
+
+// SetGain offsets a track's level by db, within MaxGain either way, and
+// stores it in the sidecar; 0 plays it at its usual level. The library's
+// own copy of the track is changed too, should track be a copy of it.
+func (l *Library) SetGain(track *api.Track, db float64) error {
+	if db < -MaxGain || db > MaxGain {
+		return playerrors.ErrInvalidGain
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sidecar != nil {
+		l.sidecar.SetGain(track.FilePath, db)
+	}
+	track.Gain = db
+	if own, ok := l.Tracks[track.ID]; ok {
+		own.Gain = db
+	}
+	return nil
+}
+
+// SetTrim sets the in and out points for a track and stores them in the
+// sidecar. A zero end means the natural end of the track; passing zero for
+// both clears the trim. The library's own copy of the track is trimmed
+// too, should track be a copy of it.
+func (l *Library) SetTrim(track *api.Track, start, end time.Duration) error {
+	if start < 0 || end < 0 || (end > 0 && start >= end) {
+		return playerrors.ErrInvalidTrim
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sidecar != nil {
+		l.sidecar.SetTrim(track.FilePath, start, end)
+	}
+	track.TrimStart, track.TrimEnd = start, end
+	if own, ok := l.Tracks[track.ID]; ok {
+		own.TrimStart, own.TrimEnd = start, end
+	}
+	return nil
+}
+
 // AddTrack adds a track to the library and updates indices
 func (l *Library) AddTrack(track *api.Track) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.addTrack(track)
+}
 
+// addTrack adds a track with l.mu held
+func (l *Library) addTrack(track *api.Track) {
+	l.applySidecar(track)
+
+	// A track scanned again replaces the old entry, indices and all
+	if old, ok := l.Tracks[track.ID]; ok {
+		l.removeFromIndex(l.artistIndex, old.Artist, old.ID)
+		l.removeFromIndex(l.albumIndex, old.Album, old.ID)
+		l.removeFromIndex(l.genreIndex, old.Genre, old.ID)
+	}
 	l.Tracks[track.ID] = track
 	l.TotalTracks = len(l.Tracks)
 
@@ -74,17 +237,26 @@ func (l *Library) GetTrack(id string) (*api.Track, error) {
 	return track, nil
 }
 
-// GetAllTracks returns all tracks as a slice
+// GetAllTracks returns all tracks as a slice, without hidden duplicates
 func (l *Library) GetAllTracks() []*api.Track {
+	return l.sortedTracks(false)
+}
+
+// sortedTracks returns the library's tracks in artist, album and track
+// order, including hidden duplicates if withHidden is set
+func (l *Library) sortedTracks(withHidden bool) []*api.Track {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
 	tracks := make([]*api.Track, 0, len(l.Tracks))
-	for _, track := range l.Tracks {
-		tracks = append(tracks, track)
+	for id, track := range l.Tracks {
+		if withHidden || !l.hidden[id] {
+			tracks = append(tracks, track)
+		}
 	}
 
-	// Sort by artist, then album, then track number
+	// Sort by artist, then album, then track number; the path breaks ties
+	// so the order is the same on every launch
 	sort.Slice(tracks, func(i, j int) bool {
 		if tracks[i].Artist != tracks[j].Artist {
 			return tracks[i].Artist < tracks[j].Artist
@@ -92,7 +264,10 @@ func (l *Library) GetAllTracks() []*api.Track {
 		if tracks[i].Album != tracks[j].Album {
 			return tracks[i].Album < tracks[j].Album
 		}
-		return tracks[i].TrackNum < tracks[j].TrackNum
+		if tracks[i].TrackNum != tracks[j].TrackNum {
+			return tracks[i].TrackNum < tracks[j].TrackNum
+		}
+		return tracks[i].FilePath < tracks[j].FilePath
 	})
 
 	return tracks
@@ -110,7 +285,7 @@ func (l *Library) GetTracksByArtist(artist string) []*api.Track {
 
 	tracks := make([]*api.Track, 0, len(trackIDs))
 	for _, id := range trackIDs {
-		if track, ok := l.Tracks[id]; ok {
+		if track, ok := l.Tracks[id]; ok && !l.hidden[id] {
 			tracks = append(tracks, track)
 		}
 	}
@@ -129,7 +304,7 @@ func (l *Library) GetTracksByAlbum(album string) []*api.Track {
 
 	tracks := make([]*api.Track, 0, len(trackIDs))
 	for _, id := range trackIDs {
-		if track, ok := l.Tracks[id]; ok {
+		if track, ok := l.Tracks[id]; ok && !l.hidden[id] {
 			tracks = append(tracks, track)
 		}
 	}
@@ -170,7 +345,10 @@ func (l *Library) Search(query string) []*api.Track {
 	query = strings.ToLower(query)
 	results := make([]*api.Track, 0, 10)
 
-	for _, track := range l.Tracks {
+	for id, track := range l.Tracks {
+		if l.hidden[id] {
+			continue
+		}
 		titleMatch := strings.Contains(strings.ToLower(track.Title), query)
 		artistMatch := strings.Contains(strings.ToLower(track.Artist), query)
 		albumMatch := strings.Contains(strings.ToLower(track.Album), query)
@@ -207,6 +385,9 @@ func (l *Library) RemoveTrack(id string) error {
 
 	delete(l.Tracks, id)
 	l.TotalTracks = len(l.Tracks)
+	if l.cache != nil {
+		l.cache.Remove(track.FilePath)
+	}
 	return nil
 }
 
@@ -230,29 +411,155 @@ func (l *Library) removeFromIndex(index map[string][]string, key, trackID string
 	}
 }
 
-// Scan scans the configured paths and adds tracks to the library
-func (l *Library) Scan(ctx context.Context, paths []string) error {
+// Scan scans the configured paths and adds tracks to the library. Files
+// and folders that can't be read are skipped and returned alongside, so one
+// unreadable folder doesn't stop the scan. Roots that don't exist are also
+// reported as skipped; an error is returned only if none of them do.
+// LastScanned is set once a scan finishes; one cancelled part way leaves
+// it as it was.
+func (l *Library) Scan(ctx context.Context, paths []string) ([]*playerrors.ScanError, error) {
+	return l.ScanEach(ctx, paths, nil)
+}
+
+// ScanEach scans like Scan, also handing each track to added, if set, as
+// soon as it is in the library. added is called from the scanning
+// goroutine.
+func (l *Library) ScanEach(ctx context.Context, paths []string, added func(*api.Track)) ([]*playerrors.ScanError, error) {
+	l.mu.Lock()
 	l.ScanPaths = paths
-	tracks, errors := l.scanner.Scan(ctx, paths)
+	l.mu.Unlock()
 
-	// Collect errors
-	var scanErrors []error
-	go func() {
-		for err := range errors {
-			scanErrors = append(scanErrors, err)
+	var skipped []*playerrors.ScanError
+	var roots []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			skipped = append(skipped, &playerrors.ScanError{Path: path, Err: err})
+			continue
 		}
-	}()
+		roots = append(roots, path)
+	}
+	if len(roots) == 0 && len(paths) > 0 {
+		return skipped, skipped[0]
+	}
 
-	// Add tracks to library
-	for track := range tracks {
+	skipped = append(skipped, l.scanInto(ctx, l.scanner, roots, func(track *api.Track) {
 		l.AddTrack(track)
+		if added != nil {
+			added(track)
+		}
+	})...)
+	if ctx.Err() != nil {
+		return skipped, ctx.Err()
 	}
 
 	l.mu.Lock()
 	l.LastScanned = time.Now()
 	l.mu.Unlock()
 
-	return nil
+	return skipped, nil
+}
+
+// Rescan scans paths afresh and, once the scan has finished, replaces
+// the library's tracks with the ones it found, e.g. to move the library to
+// another folder. Files and folders that can't be read are skipped and
+// returned, as for Scan. If none of the roots exist or the scan is
+// cancelled the library is left as it was and an error says why.
+func (l *Library) Rescan(ctx context.Context, paths []string) ([]*playerrors.ScanError, error) {
+	return l.rescan(ctx, paths, l.scanner)
+}
+
+// Reread scans the library's folders afresh, as Rescan does, but reads
+// every file's tags again rather than taking them from the metadata cache,
+// so the tracks pick up tags the library didn't read when they were first
+// scanned, such as ReplayGain and album artist tags.
+func (l *Library) Reread(ctx context.Context) ([]*playerrors.ScanError, error) {
+	l.mu.RLock()
+	scanner := *l.scanner
+	l.mu.RUnlock()
+	scanner.metaReader = &MetadataReader{cache: scanner.metaReader.cache, refresh: true}
+	return l.rescan(ctx, l.ScanRoots(), &scanner)
+}
+
+// rescan is Rescan, scanning with scanner
+func (l *Library) rescan(ctx context.Context, paths []string, scanner *Scanner) ([]*playerrors.ScanError, error) {
+	var skipped []*playerrors.ScanError
+	var roots []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			skipped = append(skipped, &playerrors.ScanError{Path: path, Err: err})
+			continue
+		}
+		roots = append(roots, path)
+	}
+	if len(roots) == 0 {
+		if len(skipped) == 0 {
+			return nil, playerrors.ErrNoMusicFolder
+		}
+		return skipped, skipped[0]
+	}
+
+	var found []*api.Track
+	skipped = append(skipped, l.scanInto(ctx, scanner, roots, func(track *api.Track) {
+		found = append(found, track)
+	})...)
+	if err := ctx.Err(); err != nil {
+		return skipped, err
+	}
+
+	// Swap the tracks in one go, so the library is never seen half full
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Tracks = make(map[string]*api.Track)
+	l.artistIndex = make(map[string][]string)
+	l.albumIndex = make(map[string][]string)
+	l.genreIndex = make(map[string][]string)
+	l.hidden = nil
+	for _, track := range found {
+		l.addTrack(track)
+	}
+	l.ScanPaths = paths
+	l.LastScanned = time.Now()
+	return skipped, nil
+}
+
+// ScanRoots returns the folders the library was last scanned from
+func (l *Library) ScanRoots() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Clone(l.ScanPaths)
+}
+
+// ScanUnfinished reports whether the last scan was cut short, so the
+// library may be missing tracks from its folders
+func (l *Library) ScanUnfinished() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.ScanPaths) > 0 && l.LastScanned.IsZero()
+}
+
+// scanInto runs scanner over roots, handing each track to add, and
+// returns the problems it ran into
+func (l *Library) scanInto(ctx context.Context, scanner *Scanner, roots []string, add func(*api.Track)) []*playerrors.ScanError {
+	tracks, errs := scanner.Scan(ctx, roots)
+
+	var skipped []*playerrors.ScanError
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range errs {
+			scanErr, ok := err.(*playerrors.ScanError)
+			if !ok {
+				scanErr = &playerrors.ScanError{Err: err}
+			}
+			skipped = append(skipped, scanErr)
+		}
+	}()
+
+	for track := range tracks {
+		add(track)
+	}
+	<-done
+	return skipped
 }
 
 // Clear removes all tracks from the library
@@ -264,6 +571,7 @@ func (l *Library) Clear() {
 	l.artistIndex = make(map[string][]string)
 	l.albumIndex = make(map[string][]string)
 	l.genreIndex = make(map[string][]string)
+	l.hidden = nil
 	l.TotalTracks = 0
 }
 
@@ -333,6 +641,95 @@ func (l *Library) rebuildIndices() {
 	l.TotalTracks = len(l.Tracks)
 }
 
+// ListDir lists the subfolders and supported files directly in path, with
+// the library's scan options, without scanning any of them
+func (l *Library) ListDir(path string) (subdirs []string, tracks []string, err error) {
+	return l.scanner.ListDir(path)
+}
+
+// ScanFolder recursively scans a single directory, adds any new tracks to the
+// library and returns every track found beneath it sorted by disc, track
+// number and path. Tracks already in the library are returned as-is. Files
+// and folders that can't be read are skipped and returned; the error is
+// only set if dir itself can't be read or the scan was cancelled.
+func (l *Library) ScanFolder(ctx context.Context, dir string) ([]*api.Track, []*playerrors.ScanError, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil, &playerrors.ScanError{Path: dir, Err: err}
+	}
+
+	var found []*api.Track
+	skipped := l.scanInto(ctx, l.scanner, []string{dir}, func(track *api.Track) {
+		if existing, err := l.GetTrack(track.ID); err == nil {
+			found = append(found, existing)
+			return
+		}
+		l.AddTrack(track)
+		found = append(found, track)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, skipped, err
+	}
+
+	SortByDiscTrackPath(found)
+	return found, skipped, nil
+}
+
+// SortByDiscTrackPath sorts tracks in album order: disc number, then track
+// number, then file path as a tie-breaker for untagged files.
+func SortByDiscTrackPath(tracks []*api.Track) {
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if tracks[i].DiscNum != tracks[j].DiscNum {
+			return tracks[i].DiscNum < tracks[j].DiscNum
+		}
+		if tracks[i].TrackNum != tracks[j].TrackNum {
+			return tracks[i].TrackNum < tracks[j].TrackNum
+		}
+		return tracks[i].FilePath < tracks[j].FilePath
+	})
+}
+
+// ReadTracks builds tracks for a list of file paths without adding them to the
+// library. Paths already in the library reuse their track, and http and
+// https URLs become tracks that stream them. Paths that are missing, not
+// regular files or not a supported format are skipped and reported as
+// *ScanError values.
+func (l *Library) ReadTracks(paths []string) ([]*api.Track, []error) {
+	var tracks []*api.Track
+	var errs []error
+	for _, path := range paths {
+		if IsStreamURL(path) {
+			tracks = append(tracks, NewStreamTrack(path))
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		if track, err := l.GetTrack(generateTrackID(path)); err == nil {
+			tracks = append(tracks, track)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err == nil && !info.Mode().IsRegular() {
+			err = fmt.Errorf("not a regular file")
+		}
+		if err != nil {
+			errs = append(errs, &playerrors.ScanError{Path: path, Err: err})
+			continue
+		}
+
+		track, err := l.scanner.ScanFile(path)
+		if err != nil {
+			errs = append(errs, &playerrors.ScanError{Path: path, Err: err})
+			continue
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, errs
+}
+
 // AddFile adds a single file from any location to the library
 func (l *Library) AddFile(filePath string) (*api.Track, error) {
 	track, err := l.scanner.ScanFile(filePath)
@@ -0,0 +1,73 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/wav"
+	"github.com/jscyril/golang_music_player/api"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// writeSilentWAV writes a short silent WAV file to path
+func writeSilentWAV(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	format := beep.Format{SampleRate: 8000, NumChannels: 1, Precision: 2}
+	if err := wav.Encode(f, beep.Take(format.SampleRate.N(d), beep.Silence(-1)), format); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadTracks_SkipsInvalidPaths(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.wav")
+	writeSilentWAV(t, good, time.Second)
+	text := filepath.Join(dir, "notes.txt")
+	writeFile(t, text, "not audio")
+
+	lib := NewLibrary()
+	tracks, errs := lib.ReadTracks([]string{good, filepath.Join(dir, "missing.mp3"), text, dir})
+
+	if len(tracks) != 1 || tracks[0].FilePath != good {
+		t.Fatalf("tracks = %v, want just %s", tracks, good)
+	}
+	if tracks[0].Duration != time.Second {
+		t.Errorf("duration = %v, want 1s", tracks[0].Duration)
+	}
+	if lib.TotalTracks != 0 {
+		t.Error("ReadTracks should not add tracks to the library")
+	}
+
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), errs)
+	}
+	var scanErr *playerrors.ScanError
+	for _, err := range errs {
+		if !errors.As(err, &scanErr) {
+			t.Errorf("error %v is not a ScanError", err)
+		}
+	}
+}
+
+func TestLibrary_AddTrackAgainReplacesIndexEntries(t *testing.T) {
+	lib := NewLibrary()
+	lib.AddTrack(&api.Track{ID: "a", FilePath: "/a.mp3", Artist: "Band", Album: "Old"})
+	lib.AddTrack(&api.Track{ID: "a", FilePath: "/a.mp3", Artist: "Band", Album: "New"})
+
+	if got := lib.GetTracksByArtist("Band"); len(got) != 1 {
+		t.Errorf("artist lists %d tracks, want 1", len(got))
+	}
+	if got := lib.GetAlbums(); len(got) != 1 || got[0] != "New" {
+		t.Errorf("albums = %v, want just New", got)
+	}
+}
@@ -0,0 +1,291 @@
+package library
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// PlaylistEntry is one track listed in a playlist file. Title, Artist and
+// Duration come from #EXTINF (M3U) or TitleN/LengthN (PLS) when present.
+type PlaylistEntry struct {
+	Path     string
+	Title    string
+	Artist   string
+	Duration time.Duration
+}
+
+// IsPlaylistFile reports whether path has a playlist file extension
+func IsPlaylistFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8", ".pls":
+		return true
+	}
+	return false
+}
+
+// LoadPlaylist reads an M3U, M3U8 or PLS playlist. Entries keep the order
+// they are listed in, and relative paths are resolved against the
+// playlist's directory; http and https URLs are kept as they are, to be
+// streamed. Entries whose file doesn't exist are left out and reported as
+// skipped; only failing to read the playlist itself is an error.
+func LoadPlaylist(path string) ([]PlaylistEntry, []*playerrors.ScanError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open playlist: %w", err)
+	}
+	defer file.Close()
+
+	parse := parseM3U
+	if strings.EqualFold(filepath.Ext(path), ".pls") {
+		parse = parsePLS
+	}
+	entries, err := parse(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse playlist %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	var found []PlaylistEntry
+	var skipped []*playerrors.ScanError
+	for _, entry := range entries {
+		if IsStreamURL(entry.Path) {
+			found = append(found, entry)
+			continue
+		}
+		entry.Path = resolveEntryPath(dir, entry.Path)
+		if _, err := os.Stat(entry.Path); err != nil {
+			skipped = append(skipped, &playerrors.ScanError{Path: entry.Path, Err: err})
+			continue
+		}
+		found = append(found, entry)
+	}
+	return found, skipped, nil
+}
+
+// parseM3U reads plain or extended M3U. Comments are skipped; an #EXTINF
+// line describes the entry that follows it.
+func parseM3U(r io.Reader) ([]PlaylistEntry, error) {
+	var entries []PlaylistEntry
+	var pending PlaylistEntry
+
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF") // UTF-8 BOM, common in .m3u8
+			first = false
+		}
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			pending = parseExtinf(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			pending.Path = line
+			entries = append(entries, pending)
+			pending = PlaylistEntry{}
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// parseExtinf parses "<seconds>[ attrs],<artist> - <title>". A negative or
+// invalid length is left unknown, and text without " - " is all title.
+func parseExtinf(info string) PlaylistEntry {
+	var entry PlaylistEntry
+	length, display, _ := strings.Cut(info, ",")
+	if fields := strings.Fields(length); len(fields) > 0 {
+		if secs, err := strconv.ParseFloat(fields[0], 64); err == nil && secs > 0 {
+			entry.Duration = time.Duration(secs * float64(time.Second))
+		}
+	}
+	display = strings.TrimSpace(display)
+	if artist, title, ok := strings.Cut(display, " - "); ok {
+		entry.Artist, entry.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+	} else {
+		entry.Title = display
+	}
+	return entry
+}
+
+// parsePLS reads a PLS playlist, ordering entries by their FileN number
+func parsePLS(r io.Reader) ([]PlaylistEntry, error) {
+	byNum := make(map[int]*PlaylistEntry)
+	var order []int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue // Section header, comment or blank line
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var field string
+		for _, prefix := range []string{"file", "title", "length"} {
+			if strings.HasPrefix(key, prefix) {
+				field = prefix
+				break
+			}
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(key, field))
+		if field == "" || err != nil {
+			continue // NumberOfEntries, Version and the like
+		}
+		entry, ok := byNum[n]
+		if !ok {
+			entry = &PlaylistEntry{}
+			byNum[n] = entry
+			order = append(order, n)
+		}
+		value = strings.TrimSpace(value)
+		switch field {
+		case "file":
+			entry.Path = value
+		case "title":
+			entry.Title = value
+		case "length":
+			if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+				entry.Duration = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Ints(order)
+	var entries []PlaylistEntry
+	for _, n := range order {
+		if entry := byNum[n]; entry.Path != "" {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// resolveEntryPath turns a playlist entry into a file path: file:// URLs
+// are decoded and relative paths are taken from the playlist's directory
+func resolveEntryPath(dir, entry string) string {
+	if strings.HasPrefix(entry, "file://") {
+		if u, err := url.Parse(entry); err == nil {
+			entry = u.Path
+		}
+	}
+	entry = filepath.FromSlash(strings.ReplaceAll(entry, `\`, "/"))
+	if !filepath.IsAbs(entry) {
+		entry = filepath.Join(dir, entry)
+	}
+	return filepath.Clean(entry)
+}
+
+// SavePlaylist writes tracks to an extended M3U file at path, creating its
+// directory if needed. Tracks under the playlist's directory are written
+// as relative paths so the folder can be moved as a whole; others are
+// absolute. The file is replaced atomically, so an existing playlist is
+// never left half written.
+func SavePlaylist(path string, tracks []*api.Track) error {
+	err := atomicfile.WriteFile(path, func(w io.Writer) error {
+		return writeM3U(w, filepath.Dir(path), tracks)
+	})
+	if err != nil {
+		return fmt.Errorf("write playlist: %w", err)
+	}
+	return nil
+}
+
+// writeM3U writes tracks as extended M3U with paths relative to dir where
+// they are inside it. Unknown durations are written as -1.
+func writeM3U(w io.Writer, dir string, tracks []*api.Track) error {
+	if _, err := fmt.Fprintln(w, "#EXTM3U"); err != nil {
+		return err
+	}
+	for _, t := range tracks {
+		secs := -1
+		if t.Duration > 0 {
+			secs = int(t.Duration.Round(time.Second) / time.Second)
+		}
+		display := t.Title
+		if t.Artist != "" {
+			display = t.Artist + " - " + t.Title
+		}
+		if _, err := fmt.Fprintf(w, "#EXTINF:%d,%s\n%s\n", secs, display, entryPath(dir, t.FilePath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryPath returns filePath relative to dir when it's inside dir, with
+// forward slashes so the playlist works on other systems too
+func entryPath(dir, filePath string) string {
+	if rel, err := filepath.Rel(dir, filePath); err == nil && filepath.IsLocal(rel) {
+		return filepath.ToSlash(rel)
+	}
+	return filePath
+}
+
+// ReadPlaylist loads a playlist file and reads its tracks in order, from
+// the library when known. Playlist metadata fills in a title or duration
+// the file's own tags don't provide, and names streamed URLs. Missing and
+// unreadable files are returned as errors alongside the tracks that could
+// be read.
+func (l *Library) ReadPlaylist(path string) ([]*api.Track, []error, error) {
+	entries, skipped, err := LoadPlaylist(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs []error
+	for _, s := range skipped {
+		errs = append(errs, s)
+	}
+	var tracks []*api.Track
+	for _, entry := range entries {
+		if IsStreamURL(entry.Path) {
+			tracks = append(tracks, withStreamInfo(NewStreamTrack(entry.Path), entry))
+			continue
+		}
+		read, readErrs := l.ReadTracks([]string{entry.Path})
+		errs = append(errs, readErrs...)
+		for _, track := range read {
+			tracks = append(tracks, withPlaylistInfo(track, entry))
+		}
+	}
+	return tracks, errs, nil
+}
+
+// withPlaylistInfo returns track with gaps filled from the playlist entry.
+// The track is copied when changed so library tracks aren't modified.
+func withPlaylistInfo(track *api.Track, entry PlaylistEntry) *api.Track {
+	needTitle := entry.Title != "" && track.Title == titleFromPath(track.FilePath)
+	needArtist := entry.Artist != "" && (track.Artist == "" || track.Artist == "Unknown Artist")
+	needDuration := entry.Duration > 0 && track.Duration == 0
+	if !needTitle && !needArtist && !needDuration {
+		return track
+	}
+
+	filled := *track
+	if needTitle {
+		filled.Title = entry.Title
+	}
+	if needArtist {
+		filled.Artist = entry.Artist
+	}
+	if needDuration {
+		filled.Duration = entry.Duration
+	}
+	return &filled
+}
@@ -0,0 +1,149 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestLoadPlaylist_M3U(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "music")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(dir, "abs.mp3")
+	for _, p := range []string{filepath.Join(sub, "b.mp3"), filepath.Join(sub, "a.mp3"), abs} {
+		writeFile(t, p, "x")
+	}
+
+	playlist := filepath.Join(dir, "mix.m3u8")
+	writeFile(t, playlist, "\uFEFF#EXTM3U\n"+
+		"#EXTINF:215,Queen - Bohemian Rhapsody\n"+
+		"music/b.mp3\n"+
+		"\n"+
+		"# a comment\n"+
+		"music/missing.mp3\n"+
+		"#EXTINF:-1,Untitled Jam\n"+
+		"music/a.mp3\n"+
+		"file://"+filepath.ToSlash(abs)+"\n")
+
+	entries, skipped, err := LoadPlaylist(playlist)
+	if err != nil {
+		t.Fatalf("LoadPlaylist: %v", err)
+	}
+
+	want := []PlaylistEntry{
+		{Path: filepath.Join(sub, "b.mp3"), Title: "Bohemian Rhapsody", Artist: "Queen", Duration: 215 * time.Second},
+		{Path: filepath.Join(sub, "a.mp3"), Title: "Untitled Jam"},
+		{Path: abs},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+	if len(skipped) != 1 || !strings.HasSuffix(skipped[0].Path, "missing.mp3") {
+		t.Errorf("skipped = %v, want music/missing.mp3", skipped)
+	}
+}
+
+func TestLoadPlaylist_PLS(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "one.mp3"), "x")
+	writeFile(t, filepath.Join(dir, "two.mp3"), "x")
+
+	playlist := filepath.Join(dir, "radio.pls")
+	writeFile(t, playlist, "[playlist]\n"+
+		"File2=two.mp3\n"+
+		"Title2=Second\n"+
+		"File1=one.mp3\n"+
+		"Length1=61\n"+
+		"NumberOfEntries=2\n"+
+		"Version=2\n")
+
+	entries, skipped, err := LoadPlaylist(playlist)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("LoadPlaylist: %v, skipped %v", err, skipped)
+	}
+	if len(entries) != 2 ||
+		entries[0].Path != filepath.Join(dir, "one.mp3") || entries[0].Duration != 61*time.Second ||
+		entries[1].Path != filepath.Join(dir, "two.mp3") || entries[1].Title != "Second" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestReadPlaylist_FillsMissingTags(t *testing.T) {
+	dir := t.TempDir()
+	writeSilentWAV(t, filepath.Join(dir, "take1.wav"), time.Second)
+	playlist := filepath.Join(dir, "set.m3u")
+	writeFile(t, playlist, "#EXTM3U\n#EXTINF:1,Band - Live Take\ntake1.wav\nnope.wav\n")
+
+	lib := NewLibrary()
+	tracks, errs, err := lib.ReadPlaylist(playlist)
+	if err != nil {
+		t.Fatalf("ReadPlaylist: %v", err)
+	}
+	if len(tracks) != 1 || tracks[0].Title != "Live Take" || tracks[0].Artist != "Band" {
+		t.Errorf("tracks = %+v, want the playlist's title and artist", tracks)
+	}
+	if len(errs) != 1 {
+		t.Errorf("errs = %v, want the missing file", errs)
+	}
+
+	if _, _, err := lib.ReadPlaylist(filepath.Join(dir, "absent.m3u")); err == nil {
+		t.Error("a missing playlist file should be an error")
+	}
+}
+
+func TestSavePlaylist_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	inside := filepath.Join(dir, "lists", "music", "a.mp3")
+	outside := filepath.Join(dir, "elsewhere", "b.mp3")
+	writeFile(t, inside, "x")
+	writeFile(t, outside, "x")
+
+	path := filepath.Join(dir, "lists", "new", "..", "mix.m3u8")
+	tracks := []*api.Track{
+		{Title: "Song A", Artist: "Band", Duration: 185 * time.Second, FilePath: inside},
+		{Title: "Song B", FilePath: outside},
+	}
+	if err := SavePlaylist(path, tracks); err != nil {
+		t.Fatalf("SavePlaylist: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXTM3U\n" +
+		"#EXTINF:185,Band - Song A\nmusic/a.mp3\n" +
+		"#EXTINF:-1,Song B\n" + outside + "\n"
+	if string(data) != want {
+		t.Errorf("playlist =\n%s\nwant\n%s", data, want)
+	}
+
+	entries, skipped, err := LoadPlaylist(path)
+	if err != nil || len(skipped) != 0 || len(entries) != 2 ||
+		entries[0].Path != inside || entries[1].Path != outside {
+		t.Errorf("reloaded %+v, skipped %v, err %v", entries, skipped, err)
+	}
+
+	// Overwriting leaves no temp files behind
+	if err := SavePlaylist(path, tracks[:1]); err != nil {
+		t.Fatalf("SavePlaylist again: %v", err)
+	}
+	files, _ := os.ReadDir(filepath.Dir(path))
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", f.Name())
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+)
+
+// metadataCacheVersion is bumped whenever the cached fields change meaning;
+// caches written with another version are discarded on load
+const metadataCacheVersion = 3
+
+// cachedMetadata is a track as read from a file with the file's
+// modification time and size at the time, which must both still match for
+// the entry to be used
+type cachedMetadata struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Track   api.Track `json:"track"`
+}
+
+// MetadataCache is a JSON-backed store of parsed tags keyed by file path,
+// so files unchanged since they were last read skip tag parsing and
+// duration decoding. It is only a cache: a missing, corrupt or outdated
+// file just starts it empty.
+type MetadataCache struct {
+	Version int                        `json:"version"`
+	Entries map[string]*cachedMetadata `json:"entries"`
+
+	path  string
+	mu    sync.Mutex
+	dirty bool
+}
+
+// NewMetadataCache creates an empty cache that saves to path
+func NewMetadataCache(path string) *MetadataCache {
+	return &MetadataCache{
+		Version: metadataCacheVersion,
+		Entries: make(map[string]*cachedMetadata),
+		path:    path,
+	}
+}
+
+// LoadMetadataCache loads the cache saved at path. A missing file, or one
+// written in another format version, gives an empty cache; only a file
+// that exists but can't be read is an error.
+func LoadMetadataCache(path string) (*MetadataCache, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewMetadataCache(path), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read metadata cache: %w", err)
+	}
+
+	c := NewMetadataCache(path)
+	if err := json.Unmarshal(data, c); err != nil || c.Version != metadataCacheVersion || c.Entries == nil {
+		// Rebuilt as files are read again
+		return NewMetadataCache(path), nil
+	}
+	return c, nil
+}
+
+// Save writes the cache to its file if anything changed since it was
+// loaded, first dropping the entries of files that no longer exist
+func (c *MetadataCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.Entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.Entries, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	err := atomicfile.WriteFile(c.path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(c)
+	})
+	if err != nil {
+		return fmt.Errorf("write metadata cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// Lookup returns a copy of the track cached for filePath if info, the
+// file's current details, still matches the cached modification time and
+// size
+func (c *MetadataCache) Lookup(filePath string, info os.FileInfo) (*api.Track, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[filePath]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	track := entry.Track
+	return &track, true
+}
+
+// Store caches the track read from filePath against info, the file's
+// details when it was read
+func (c *MetadataCache) Store(filePath string, info os.FileInfo, track *api.Track) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cachedMetadata{ModTime: info.ModTime(), Size: info.Size(), Track: *track}
+	entry.Track.CoverArt = nil
+	c.Entries[filePath] = entry
+	c.dirty = true
+}
+
+// SetDuration fills in the duration of the track cached for filePath, if
+// the entry still matches info
+func (c *MetadataCache) SetDuration(filePath string, info os.FileInfo, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[filePath]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return
+	}
+	entry.Track.Duration = d
+	c.dirty = true
+}
+
+// Remove forgets filePath, e.g. once its file has been deleted
+func (c *MetadataCache) Remove(filePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Entries[filePath]; ok {
+		delete(c.Entries, filePath)
+		c.dirty = true
+	}
+}
+
+// Rename moves the entry of oldPath to newPath after the file has been
+// moved, which keeps its modification time and size
+func (c *MetadataCache) Rename(oldPath, newPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.Entries[oldPath]; ok {
+		delete(c.Entries, oldPath)
+		c.Entries[newPath] = entry
+		c.dirty = true
+	}
+}
+
+// Len returns the number of cached files
+func (c *MetadataCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Entries)
+}
@@ -0,0 +1,155 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache_SkipsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "song.wav")
+	writeSilentWAV(t, song, 100*time.Millisecond)
+
+	cachePath := filepath.Join(dir, "cache", "metadata.json")
+	cache := NewMetadataCache(cachePath)
+	r := &MetadataReader{cache: cache}
+	first, err := r.Read(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadMetadataCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 1 {
+		t.Fatalf("loaded %d entries, want 1", loaded.Len())
+	}
+	// Tamper with the entry to tell a cache hit from a re-parse
+	loaded.Entries[song].Track.Title = "From cache"
+	r = &MetadataReader{cache: loaded}
+	track, err := r.Read(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Title != "From cache" || track.ID != first.ID || track.Duration != first.Duration {
+		t.Errorf("unchanged file: got %+v, want the cached track", track)
+	}
+
+	// A changed file is parsed again
+	writeSilentWAV(t, song, 300*time.Millisecond)
+	track, err = r.Read(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Title == "From cache" {
+		t.Error("changed file should be re-parsed")
+	}
+}
+
+func TestLibrary_RereadIgnoresTheCache(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "song.wav")
+	writeSilentWAV(t, song, 100*time.Millisecond)
+
+	lib := NewLibrary()
+	cache := NewMetadataCache(filepath.Join(dir, "metadata.json"))
+	lib.SetMetadataCache(cache)
+	if _, err := lib.Scan(context.Background(), []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	cache.Entries[song].Track.Title = "From cache"
+
+	if _, err := lib.Rescan(context.Background(), []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if tracks := lib.GetAllTracks(); len(tracks) != 1 || tracks[0].Title != "From cache" {
+		t.Fatalf("Rescan read %v, want the cached track", tracks)
+	}
+	if _, err := lib.Reread(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if tracks := lib.GetAllTracks(); len(tracks) != 1 || tracks[0].Title == "From cache" {
+		t.Errorf("Reread read %v, want the file parsed again", tracks)
+	}
+	if cache.Entries[song].Track.Title == "From cache" {
+		t.Error("Reread left the stale entry in the cache")
+	}
+}
+
+func TestMetadataCache_DropsDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	keep, gone := filepath.Join(dir, "keep.wav"), filepath.Join(dir, "gone.wav")
+	cache := NewMetadataCache(filepath.Join(dir, "metadata.json"))
+	r := &MetadataReader{cache: cache}
+	for _, path := range []string{keep, gone} {
+		writeSilentWAV(t, path, 100*time.Millisecond)
+		if _, err := r.Read(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Entries[gone]; ok || cache.Len() != 1 {
+		t.Errorf("entries after save = %v, want only %s", cache.Entries, keep)
+	}
+}
+
+func TestLoadMetadataCache_DiscardsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"old.json":     `{"version": 0, "entries": {"a.mp3": {"size": "not a number"}}}`,
+		"corrupt.json": `{"version": 1, "entr`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cache, err := LoadMetadataCache(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if cache.Len() != 0 || cache.Version != metadataCacheVersion {
+			t.Errorf("%s: got %d entries at version %d, want an empty current cache", name, cache.Len(), cache.Version)
+		}
+	}
+
+	cache, err := LoadMetadataCache(filepath.Join(dir, "missing.json"))
+	if err != nil || cache.Len() != 0 {
+		t.Errorf("missing file: %v, %v", cache, err)
+	}
+}
+
+func TestLibrary_RemoveTrackForgetsCachedMetadata(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "song.wav")
+	writeSilentWAV(t, song, 100*time.Millisecond)
+
+	lib := NewLibrary()
+	cache := NewMetadataCache(filepath.Join(dir, "metadata.json"))
+	lib.SetMetadataCache(cache)
+	track, err := lib.AddFile(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("cache has %d entries after AddFile, want 1", cache.Len())
+	}
+	if err := lib.RemoveTrack(track.ID); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cache has %d entries after RemoveTrack, want 0", cache.Len())
+	}
+}
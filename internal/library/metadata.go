@@ -1,31 +1,66 @@
 package library
 
 import (
+	"context"
 	"crypto/md5"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dhowden/tag"
 	"github.com/faiface/beep"
 	"github.com/faiface/beep/flac"
 	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
 	"github.com/faiface/beep/wav"
 	"github.com/jscyril/golang_music_player/api"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
 )
 
 // MetadataReader extracts metadata from audio files
-type MetadataReader struct{}
+type MetadataReader struct {
+	cache   *MetadataCache // Optional; consulted before parsing a file
+	refresh bool           // Parse every file, replacing what the cache held for it
+}
 
 // NewMetadataReader creates a new metadata reader
 func NewMetadataReader() *MetadataReader {
 	return &MetadataReader{}
 }
 
-// Read extracts metadata from an audio file and returns a Track
+// Read extracts metadata from an audio file and returns a Track. With a
+// cache set, files unchanged since they were cached aren't parsed again.
+// An http URL isn't fetched: its track is named from the URL, as
+// NewStreamTrack names it.
 func (r *MetadataReader) Read(filePath string) (*api.Track, error) {
+	if IsStreamURL(filePath) {
+		return NewStreamTrack(filePath), nil
+	}
+	if r.cache == nil {
+		return r.parse(filePath)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	if track, ok := r.cache.Lookup(filePath, info); ok && !r.refresh {
+		return track, nil
+	}
+	track, err := r.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Store(filePath, info, track)
+	return track, nil
+}
+
+// parse reads a file's tags and, where its headers give it, its duration
+func (r *MetadataReader) parse(filePath string) (*api.Track, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("open file: %w", err)
@@ -38,44 +73,113 @@ func (r *MetadataReader) Read(filePath string) (*api.Track, error) {
 	// Try to read metadata tags
 	metadata, err := tag.ReadFrom(file)
 	if err != nil {
-		// If no tags, compute duration from the audio stream and return basic track info.
-		file.Seek(0, 0)
-		duration := computeAudioDuration(filePath, file)
+		// If no tags, take the duration from the headers and return basic track info.
+		duration := headerDuration(filePath, file, nil)
 		return &api.Track{
 			ID:        id,
-			Title:     filepath.Base(filePath),
+			Title:     titleFromPath(filePath),
 			Duration:  duration,
 			FilePath:  filePath,
 			CreatedAt: time.Now(),
 		}, nil
 	}
 
-	// Compute duration by decoding the audio stream.
-	// Seek back to the start first (tag.ReadFrom may have advanced the cursor).
-	file.Seek(0, 0)
-	duration := computeAudioDuration(filePath, file)
+	// Take the duration from the headers where they hold it. MP3s without
+	// a frame count are left at 0 for ScanDuration, rather than decoding
+	// the whole file during the scan.
+	duration := headerDuration(filePath, file, metadata)
 
 	track := &api.Track{
-		ID:        id,
-		Title:     getOrDefault(metadata.Title(), filepath.Base(filePath)),
-		Artist:    getOrDefault(metadata.Artist(), "Unknown Artist"),
-		Album:     getOrDefault(metadata.Album(), "Unknown Album"),
-		Genre:     getOrDefault(metadata.Genre(), ""),
-		Year:      metadata.Year(),
-		Duration:  duration,
-		FilePath:  filePath,
-		CreatedAt: time.Now(),
+		ID:          id,
+		Title:       getOrDefault(metadata.Title(), titleFromPath(filePath)),
+		Artist:      getOrDefault(metadata.Artist(), "Unknown Artist"),
+		Album:       getOrDefault(metadata.Album(), "Unknown Album"),
+		AlbumArtist: metadata.AlbumArtist(),
+		Genre:       getOrDefault(metadata.Genre(), ""),
+		Year:        metadata.Year(),
+		Duration:    duration,
+		FilePath:    filePath,
+		CreatedAt:   time.Now(),
 	}
 
 	// Get track number
 	trackNum, _ := metadata.Track()
 	track.TrackNum = trackNum
+	discNum, _ := metadata.Disc()
+	track.DiscNum = discNum
+	track.BPM = readBPMTag(metadata)
+	readReplayGain(metadata, track)
+	track.Chapters = readChapters(metadata.Raw(), metadata.Format())
 
 	return track, nil
 }
 
-// ReadCoverArt extracts cover art from an audio file
+// LoadTrackMetadata reads a file's ID3v2, Vorbis comment or MP4 tags into a
+// Track. Files without readable tags still load, titled after the file name.
+func LoadTrackMetadata(filePath string) (*api.Track, error) {
+	track, err := NewMetadataReader().Read(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		track.Size = info.Size()
+	}
+	return track, nil
+}
+
+// LoadTrackMetadataBatch runs LoadTrackMetadata over paths on up to workers
+// goroutines. Tracks come back in the order of paths; files that can't be
+// read are left out and reported.
+func LoadTrackMetadataBatch(ctx context.Context, paths []string, workers int) ([]*api.Track, []*playerrors.ScanError) {
+	results := make([]*api.Track, len(paths))
+	errs := make([]error, len(paths))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = LoadTrackMetadata(paths[i])
+			}
+		}()
+	}
+feed:
+	for i := range paths {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	var tracks []*api.Track
+	var skipped []*playerrors.ScanError
+	for i, track := range results {
+		switch {
+		case errs[i] != nil:
+			skipped = append(skipped, &playerrors.ScanError{Path: paths[i], Err: errs[i]})
+		case track != nil:
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks, skipped
+}
+
+// titleFromPath is the fallback title: the file name without its extension
+func titleFromPath(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ReadCoverArt extracts cover art from an audio file. Streams have none.
 func (r *MetadataReader) ReadCoverArt(filePath string) ([]byte, error) {
+	if IsStreamURL(filePath) {
+		return nil, nil
+	}
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("open file: %w", err)
@@ -108,29 +212,78 @@ func getOrDefault(value, defaultValue string) string {
 	return value
 }
 
-// computeAudioDuration decodes the audio file to determine its total duration.
-// r must be seeked to position 0 before calling. Returns 0 on any error.
-func computeAudioDuration(filePath string, r interface {
+// rawTag looks up a raw tag frame by any of the given names, ignoring case.
+// ID3v2 user-defined TXXX frames are matched by their description as well.
+func rawTag(metadata tag.Metadata, names ...string) (string, bool) {
+	raw := metadata.Raw()
+	for _, name := range names {
+		for key, value := range raw {
+			if strings.EqualFold(key, name) {
+				if s, ok := rawTagString(value); ok {
+					return s, true
+				}
+			}
+			if comm, ok := value.(*tag.Comm); ok && strings.EqualFold(comm.Description, name) {
+				return strings.TrimSpace(comm.Text), true
+			}
+		}
+	}
+	return "", false
+}
+
+// rawTagString converts a raw tag value to a trimmed string
+func rawTagString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), v != ""
+	case int:
+		return strconv.Itoa(v), true
+	}
+	return "", false
+}
+
+// readBPMTag reads the tempo from TBPM (ID3v2), BPM (Vorbis) or tmpo (MP4).
+// Returns 0 when the tag is missing or unparseable.
+func readBPMTag(metadata tag.Metadata) float64 {
+	value, ok := rawTag(metadata, "TBPM", "TBP", "BPM", "tmpo")
+	if !ok {
+		return 0
+	}
+	bpm, err := strconv.ParseFloat(value, 64)
+	if err != nil || bpm <= 0 {
+		return 0
+	}
+	return bpm
+}
+
+// decodeStream opens a decoder for the file based on its extension
+func decodeStream(filePath string, r interface {
 	Read([]byte) (int, error)
 	Seek(int64, int) (int64, error)
 	Close() error
-}) time.Duration {
-	ext := strings.ToLower(filepath.Ext(filePath))
-
-	var streamer beep.StreamSeekCloser
-	var format beep.Format
-	var err error
-
-	switch ext {
+}) (beep.StreamSeekCloser, beep.Format, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
 	case ".mp3":
-		streamer, format, err = mp3.Decode(r)
+		return mp3.Decode(r)
 	case ".wav":
-		streamer, format, err = wav.Decode(r)
+		return wav.Decode(r)
 	case ".flac":
-		streamer, format, err = flac.Decode(r)
+		return flac.Decode(r)
+	case ".ogg":
+		return vorbis.Decode(r)
 	default:
-		return 0
+		return nil, beep.Format{}, playerrors.ErrInvalidFormat
 	}
+}
+
+// computeAudioDuration decodes the audio file to determine its total duration.
+// r must be seeked to position 0 before calling. Returns 0 on any error.
+func computeAudioDuration(filePath string, r interface {
+	Read([]byte) (int, error)
+	Seek(int64, int) (int64, error)
+	Close() error
+}) time.Duration {
+	streamer, format, err := decodeStream(filePath, r)
 	if err != nil {
 		return 0
 	}
@@ -141,3 +294,27 @@ func computeAudioDuration(filePath string, r interface {
 	}
 	return format.SampleRate.D(streamer.Len())
 }
+
+// readReplayGain reads the REPLAYGAIN_* gain and peak tags, as written to
+// Vorbis comments, ID3v2 TXXX frames and MP4 freeform atoms, into track.
+// Missing or unparseable values are left at zero.
+func readReplayGain(metadata tag.Metadata, track *api.Track) {
+	track.TrackGain = readGainTag(metadata, "REPLAYGAIN_TRACK_GAIN", "replaygain_track_gain")
+	track.TrackPeak = readGainTag(metadata, "REPLAYGAIN_TRACK_PEAK", "replaygain_track_peak")
+	track.AlbumGain = readGainTag(metadata, "REPLAYGAIN_ALBUM_GAIN", "replaygain_album_gain")
+	track.AlbumPeak = readGainTag(metadata, "REPLAYGAIN_ALBUM_PEAK", "replaygain_album_peak")
+}
+
+// readGainTag parses a ReplayGain value such as "-6.48 dB" or "0.988547"
+func readGainTag(metadata tag.Metadata, names ...string) float64 {
+	value, ok := rawTag(metadata, names...)
+	if !ok {
+		return 0
+	}
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "dB"))
+	f, err := strconv.ParseFloat(strings.TrimPrefix(value, "+"), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return f
+}
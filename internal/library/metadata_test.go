@@ -0,0 +1,87 @@
+package library
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dhowden/tag"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestLoadTrackMetadata_UntaggedFallsBackToFileName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "01 Field Recording.wav")
+	writeSilentWAV(t, path, 500*time.Millisecond)
+
+	track, err := LoadTrackMetadata(path)
+	if err != nil {
+		t.Fatalf("untagged file should still load: %v", err)
+	}
+	if track.Title != "01 Field Recording" {
+		t.Errorf("Title = %q, want file name without extension", track.Title)
+	}
+	if track.Size == 0 {
+		t.Error("Size should be set")
+	}
+	if track.Duration < 400*time.Millisecond {
+		t.Errorf("Duration = %v, want about 500ms", track.Duration)
+	}
+}
+
+func TestLoadTrackMetadataBatch_KeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"c.wav", "a.wav", "missing.wav", "b.wav"} {
+		path := filepath.Join(dir, name)
+		if name != "missing.wav" {
+			writeSilentWAV(t, path, 100*time.Millisecond)
+		}
+		paths = append(paths, path)
+	}
+
+	tracks, skipped := LoadTrackMetadataBatch(context.Background(), paths, 3)
+	if len(skipped) != 1 || skipped[0].Path != paths[2] {
+		t.Errorf("skipped = %v, want only the missing file", skipped)
+	}
+	var titles []string
+	for _, track := range tracks {
+		titles = append(titles, track.Title)
+	}
+	if len(titles) != 3 || titles[0] != "c" || titles[1] != "a" || titles[2] != "b" {
+		t.Errorf("titles = %v, want [c a b]", titles)
+	}
+}
+
+// rawMetadata is tag.Metadata with only its raw frames filled in
+type rawMetadata struct {
+	tag.Metadata
+	raw map[string]interface{}
+}
+
+func (m rawMetadata) Raw() map[string]interface{} { return m.raw }
+
+func TestReadReplayGain(t *testing.T) {
+	metadata := rawMetadata{raw: map[string]interface{}{
+		"replaygain_track_gain": "-6.48 dB",
+		"REPLAYGAIN_TRACK_PEAK": "0.988547",
+		"TXXX":                  &tag.Comm{Description: "REPLAYGAIN_ALBUM_GAIN", Text: "+2.10 dB"},
+		"REPLAYGAIN_ALBUM_PEAK": "not a number",
+	}}
+
+	var track api.Track
+	readReplayGain(metadata, &track)
+	if track.TrackGain != -6.48 || track.TrackPeak != 0.988547 || track.AlbumGain != 2.1 {
+		t.Errorf("gains = %v/%v track, %v album; want -6.48/0.988547, 2.1",
+			track.TrackGain, track.TrackPeak, track.AlbumGain)
+	}
+	if track.AlbumPeak != 0 {
+		t.Errorf("AlbumPeak = %v, want 0 for an unparseable value", track.AlbumPeak)
+	}
+
+	var untagged api.Track
+	readReplayGain(rawMetadata{raw: map[string]interface{}{}}, &untagged)
+	if untagged.TrackGain != 0 || untagged.TrackPeak != 0 || untagged.AlbumGain != 0 || untagged.AlbumPeak != 0 {
+		t.Errorf("untagged track = %+v, want no gains", untagged)
+	}
+}
@@ -0,0 +1,224 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// DefaultOrganizePattern lays files out as Artist/Album/NN - Title.ext
+const DefaultOrganizePattern = "{artist}/{album}/{track} - {title}"
+
+// ErrTargetExists is reported for a move whose destination is already taken,
+// either on disk or by another track in the same batch
+var ErrTargetExists = errors.New("target path already exists")
+
+// Move is one planned rename. Err is set when planning or applying the move
+// failed; such moves leave the file where it is.
+type Move struct {
+	Track *api.Track
+	From  string
+	To    string
+	Err   error
+}
+
+// Unchanged reports whether the file is already at its target path
+func (m Move) Unchanged() bool {
+	return m.From == m.To
+}
+
+// PlanOrganize computes target paths under root for tracks using pattern,
+// without touching the filesystem. Placeholders are {artist}, {album},
+// {title}, {track}, {disc}, {year} and {genre}; the original extension is
+// kept. Moves onto an existing file or onto the same target as an earlier
+// track get ErrTargetExists.
+func PlanOrganize(tracks []*api.Track, root, pattern string) []Move {
+	if pattern == "" {
+		pattern = DefaultOrganizePattern
+	}
+
+	moves := make([]Move, 0, len(tracks))
+	claimed := make(map[string]bool)
+	for _, t := range tracks {
+		to := filepath.Join(root, expandPattern(pattern, t)) + strings.ToLower(filepath.Ext(t.FilePath))
+		move := Move{Track: t, From: t.FilePath, To: to}
+
+		if !move.Unchanged() {
+			key := strings.ToLower(to)
+			if claimed[key] {
+				move.Err = ErrTargetExists
+			} else if _, err := os.Lstat(to); err == nil {
+				move.Err = ErrTargetExists
+			}
+			claimed[key] = true
+		}
+		moves = append(moves, move)
+	}
+	return moves
+}
+
+// expandPattern fills in pattern placeholders from the track's tags. Each
+// path component is sanitized separately so tags can't add directories.
+func expandPattern(pattern string, t *api.Track) string {
+	values := map[string]string{
+		"{artist}": orUnknown(t.Artist, "Unknown Artist"),
+		"{album}":  orUnknown(t.Album, "Unknown Album"),
+		"{title}":  orUnknown(t.Title, titleFromPath(t.FilePath)),
+		"{track}":  fmt.Sprintf("%02d", t.TrackNum),
+		"{disc}":   strconv.Itoa(max(t.DiscNum, 1)),
+		"{year}":   orUnknown(formatYear(t.Year), "0000"),
+		"{genre}":  orUnknown(t.Genre, "Unknown Genre"),
+	}
+
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		for placeholder, value := range values {
+			part = strings.ReplaceAll(part, placeholder, sanitizeFilename(value))
+		}
+		parts[i] = sanitizeFilename(part)
+	}
+	return filepath.Join(parts...)
+}
+
+// sanitizeFilename replaces characters that are illegal in file names on
+// common filesystems and trims leading/trailing spaces and dots
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return -1
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+func orUnknown(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+func formatYear(year int) string {
+	if year <= 0 {
+		return ""
+	}
+	return strconv.Itoa(year)
+}
+
+// ApplyMoves moves the files of planned moves, and only the files: it can
+// run off the goroutine that owns the tracks, which are left as they are
+// until Relocate is called for each move that succeeded. A failing move
+// records its error and the batch carries on; a file is never moved onto
+// an existing one.
+func ApplyMoves(moves []Move) []Move {
+	for i := range moves {
+		m := &moves[i]
+		if m.Err != nil || m.Unchanged() {
+			continue
+		}
+		m.Err = moveFile(m.From, m.To)
+	}
+	return moves
+}
+
+// Relocate points track at newPath once its file has moved there. It gets
+// the ID of its new path, under which the library now keeps and indexes
+// it, and its sidecar entry and cached tags follow it. Call it from the
+// goroutine that owns the tracks, as it changes track in place.
+func (l *Library) Relocate(track *api.Track, newPath string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldPath, oldID := track.FilePath, track.ID
+	_, kept := l.Tracks[oldID]
+	if kept {
+		l.removeFromIndex(l.artistIndex, track.Artist, oldID)
+		l.removeFromIndex(l.albumIndex, track.Album, oldID)
+		l.removeFromIndex(l.genreIndex, track.Genre, oldID)
+		delete(l.Tracks, oldID)
+	}
+	track.FilePath, track.ID = newPath, generateTrackID(newPath)
+	if l.sidecar != nil {
+		l.sidecar.Rename(oldPath, newPath)
+	}
+	if l.cache != nil {
+		l.cache.Rename(oldPath, newPath)
+	}
+	if kept {
+		l.addTrack(track)
+	}
+}
+
+// moveFile moves from to to, creating parent directories, without ever
+// replacing a file at to, even one that appeared since the move was
+// planned: the file is hard linked at to, which fails if to exists, and
+// only then unlinked at from. Where a hard link can't be made, as across
+// filesystems, it is copied to a file created exclusively instead.
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	err := os.Link(from, to)
+	if errors.Is(err, fs.ErrExist) {
+		return ErrTargetExists
+	}
+	if err != nil {
+		if err := copyFileExclusive(from, to); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(from); err != nil {
+		os.Remove(to) // The original stays where it was
+		return fmt.Errorf("remove original: %w", err)
+	}
+	return nil
+}
+
+// copyFileExclusive copies from to a new file at to, keeping its mode and
+// modification time; a file already at to is left alone
+func copyFileExclusive(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if errors.Is(err, fs.ErrExist) {
+		return ErrTargetExists
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(to)
+		return fmt.Errorf("copy: %w", err)
+	}
+	return os.Chtimes(to, info.ModTime(), info.ModTime())
+}
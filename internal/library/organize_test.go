@@ -0,0 +1,131 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandPattern_Sanitizes(t *testing.T) {
+	track := &api.Track{Artist: "AC/DC", Album: "What? Now.", Title: `Say "Hi"`, TrackNum: 3, FilePath: "/x/a.mp3"}
+
+	got := expandPattern(DefaultOrganizePattern, track)
+	want := filepath.Join("AC_DC", "What_ Now", `03 - Say _Hi_`)
+	if got != want {
+		t.Errorf("expandPattern = %q, want %q", got, want)
+	}
+}
+
+func TestOrganize_CollisionsKeepFiles(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "in", "a.mp3")
+	b := filepath.Join(root, "in", "b.mp3")
+	c := filepath.Join(root, "in", "c.flac")
+	writeFile(t, a, "a")
+	writeFile(t, b, "b")
+	writeFile(t, c, "c")
+
+	// a and b map to the same target; c's target already exists
+	existing := filepath.Join(root, "Art", "Alb", "02 - Other.flac")
+	writeFile(t, existing, "existing")
+
+	tracks := []*api.Track{
+		{Artist: "Art", Album: "Alb", Title: "Song", TrackNum: 1, FilePath: a},
+		{Artist: "Art", Album: "Alb", Title: "Song", TrackNum: 1, FilePath: b},
+		{Artist: "Art", Album: "Alb", Title: "Other", TrackNum: 2, FilePath: c},
+	}
+
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(filepath.Join(root, "sidecar.json")))
+	lib.Sidecar().SetBPM(a, 120, time.Time{})
+
+	for _, track := range tracks {
+		track.ID = generateTrackID(track.FilePath)
+		lib.AddTrack(track)
+	}
+	oldID := tracks[0].ID
+
+	moves := ApplyMoves(PlanOrganize(tracks, root, ""))
+
+	if moves[0].Err != nil {
+		t.Fatalf("first move failed: %v", moves[0].Err)
+	}
+	if !errors.Is(moves[1].Err, ErrTargetExists) || !errors.Is(moves[2].Err, ErrTargetExists) {
+		t.Errorf("collisions not detected: %v, %v", moves[1].Err, moves[2].Err)
+	}
+
+	if tracks[0].FilePath != a {
+		t.Fatal("ApplyMoves changed the track; only Relocate should")
+	}
+	target := filepath.Join(root, "Art", "Alb", "01 - Song.mp3")
+	lib.Relocate(tracks[0], moves[0].To)
+	if tracks[0].FilePath != target {
+		t.Errorf("track path = %q, want %q", tracks[0].FilePath, target)
+	}
+	if tracks[0].ID == oldID || tracks[0].ID != generateTrackID(target) {
+		t.Errorf("track ID = %q, want the new path's", tracks[0].ID)
+	}
+	if _, err := lib.GetTrack(oldID); err == nil {
+		t.Error("the old ID still finds the track")
+	}
+	if got, err := lib.GetTrack(tracks[0].ID); err != nil || got != tracks[0] {
+		t.Errorf("GetTrack(new ID) = %v, %v", got, err)
+	}
+	if n := len(lib.GetTracksByArtist("Art")); n != 3 {
+		t.Errorf("artist index lists %d tracks, want 3", n)
+	}
+	if _, ok := lib.Sidecar().Entries[target]; !ok {
+		t.Error("sidecar entry did not follow the moved file")
+	}
+	for _, path := range []string{b, c, existing} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be untouched: %v", path, err)
+		}
+	}
+}
+
+func TestMoveFile_NeverReplaces(t *testing.T) {
+	root := t.TempDir()
+	from := filepath.Join(root, "a.mp3")
+	to := filepath.Join(root, "out", "b.mp3")
+	writeFile(t, from, "a")
+	writeFile(t, to, "b")
+
+	if err := moveFile(from, to); !errors.Is(err, ErrTargetExists) {
+		t.Errorf("moveFile onto a file = %v, want ErrTargetExists", err)
+	}
+	if data, _ := os.ReadFile(to); string(data) != "b" {
+		t.Errorf("target now holds %q", data)
+	}
+	if _, err := os.Stat(from); err != nil {
+		t.Errorf("original gone: %v", err)
+	}
+
+	if err := copyFileExclusive(from, to); !errors.Is(err, ErrTargetExists) {
+		t.Errorf("copy onto a file = %v, want ErrTargetExists", err)
+	}
+	fresh := filepath.Join(root, "out", "c.mp3")
+	if err := moveFile(from, fresh); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(fresh); string(data) != "a" {
+		t.Errorf("moved file holds %q", data)
+	}
+	if _, err := os.Stat(from); !os.IsNotExist(err) {
+		t.Errorf("original left behind: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package library
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// PlayStat is how often a track has been played and when it last was
+type PlayStat struct {
+	Count      int       `json:"count"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// PlayStats is a JSON-backed store of play counts keyed by file path
+type PlayStats struct {
+	jsonStore[PlayStat]
+}
+
+// NewPlayStats creates an empty store that saves to path
+func NewPlayStats(path string) *PlayStats {
+	s := &PlayStats{}
+	s.init(path)
+	return s
+}
+
+// LoadPlayStats loads play stats from a JSON file (or returns empty if not exists)
+func LoadPlayStats(path string) (*PlayStats, error) {
+	s := NewPlayStats(path)
+	if err := s.load(s, "play stats"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save persists the play stats to their JSON file
+func (s *PlayStats) Save() error {
+	return s.save(s, "play stats")
+}
+
+// Record counts a play of filePath made at
+func (s *PlayStats) Record(filePath string, at time.Time) PlayStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat := s.Entries[filePath]
+	stat.Count++
+	stat.LastPlayed = at
+	s.Entries[filePath] = stat
+	return stat
+}
+
+// Get returns the stats of filePath, zero if it was never played
+func (s *PlayStats) Get(filePath string) PlayStat {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Entries[filePath]
+}
+
+// Recent returns up to n played file paths, most recently played first;
+// n <= 0 returns them all
+func (s *PlayStats) Recent(n int) []string {
+	return s.ranked(n, func(a, b PlayStat) int {
+		return b.LastPlayed.Compare(a.LastPlayed)
+	})
+}
+
+// MostPlayed returns up to n played file paths, most played first and
+// ties most recent first; n <= 0 returns them all
+func (s *PlayStats) MostPlayed(n int) []string {
+	return s.ranked(n, func(a, b PlayStat) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), b.LastPlayed.Compare(a.LastPlayed))
+	})
+}
+
+// ranked returns the first n paths in the order of compare, breaking ties
+// by path so the order is stable
+func (s *PlayStats) ranked(n int, compare func(a, b PlayStat) int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	paths := make([]string, 0, len(s.Entries))
+	for path := range s.Entries {
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		return cmp.Or(compare(s.Entries[a], s.Entries[b]), cmp.Compare(a, b))
+	})
+	if n > 0 && len(paths) > n {
+		paths = paths[:n]
+	}
+	return paths
+}
+
+// Clear forgets every play
+func (s *PlayStats) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Entries = make(map[string]PlayStat)
+}
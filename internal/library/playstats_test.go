@@ -0,0 +1,55 @@
+package library
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlayStats_Rankings(t *testing.T) {
+	s := NewPlayStats("")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.Record("/a.mp3", start)
+	s.Record("/b.mp3", start.Add(time.Minute))
+	s.Record("/a.mp3", start.Add(2*time.Minute))
+	s.Record("/c.mp3", start.Add(3*time.Minute))
+	s.Record("/c.mp3", start.Add(4*time.Minute))
+
+	if got := s.Get("/a.mp3"); got.Count != 2 || !got.LastPlayed.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Get(a) = %+v", got)
+	}
+	if got, want := s.Recent(0), []string{"/c.mp3", "/a.mp3", "/b.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("Recent = %v, want %v", got, want)
+	}
+	// a and c tie on plays; c was played last
+	if got, want := s.MostPlayed(2), []string{"/c.mp3", "/a.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("MostPlayed(2) = %v, want %v", got, want)
+	}
+
+	s.Clear()
+	if got := s.Recent(0); len(got) != 0 {
+		t.Errorf("Recent after Clear = %v", got)
+	}
+}
+
+func TestPlayStats_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "play_stats.json")
+	s, err := LoadPlayStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.Record("/a.mp3", at)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPlayStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Get("/a.mp3"); got.Count != 1 || !got.LastPlayed.Equal(at) {
+		t.Errorf("loaded = %+v", got)
+	}
+}
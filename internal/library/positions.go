@@ -0,0 +1,99 @@
+package library
+
+import (
+	"time"
+)
+
+const (
+	// minResumePosition is how far into a track playback must get before
+	// its position is worth remembering
+	minResumePosition = 10 * time.Second
+
+	// resumeEndMargin is how close to the end counts as finished; finished
+	// tracks are forgotten so they start over next time
+	resumeEndMargin = 15 * time.Second
+)
+
+// PositionEntry is the last playback position of one file
+type PositionEntry struct {
+	Position time.Duration `json:"position"`
+	SavedAt  time.Time     `json:"saved_at"`
+}
+
+// Positions is a JSON-backed store of where each file was last left off,
+// keyed by file path, so playback can resume there
+type Positions struct {
+	jsonStore[*PositionEntry]
+}
+
+// NewPositions creates an empty store that saves to path
+func NewPositions(path string) *Positions {
+	p := &Positions{}
+	p.init(path)
+	return p
+}
+
+// LoadPositions loads positions from a JSON file (or returns empty if not exists)
+func LoadPositions(path string) (*Positions, error) {
+	p := NewPositions(path)
+	if err := p.load(p, "positions"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Save persists the positions to their JSON file
+func (p *Positions) Save() error {
+	return p.save(p, "positions")
+}
+
+// Get returns where filePath was last left off
+func (p *Positions) Get(filePath string) (time.Duration, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if entry, ok := p.Entries[filePath]; ok {
+		return entry.Position, true
+	}
+	return 0, false
+}
+
+// Set records the playback position of filePath at now. A position near
+// the end of duration forgets the file instead, and one too early to be
+// worth resuming is ignored, keeping any earlier position.
+func (p *Positions) Set(filePath string, position, duration time.Duration, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if duration > 0 && position >= duration-resumeEndMargin {
+		delete(p.Entries, filePath)
+		return
+	}
+	if position < minResumePosition {
+		return
+	}
+	p.Entries[filePath] = &PositionEntry{Position: position, SavedAt: now}
+}
+
+// Forget removes the position stored for filePath
+func (p *Positions) Forget(filePath string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.Entries, filePath)
+}
+
+// Prune removes positions saved more than maxAge before now and returns how
+// many were removed
+func (p *Positions) Prune(maxAge time.Duration, now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	removed := 0
+	for path, entry := range p.Entries {
+		if now.Sub(entry.SavedAt) > maxAge {
+			delete(p.Entries, path)
+			removed++
+		}
+	}
+	return removed
+}
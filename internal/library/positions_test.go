@@ -0,0 +1,79 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPositions_SetRules(t *testing.T) {
+	now := time.Now()
+	p := NewPositions("")
+
+	p.Set("/a.mp3", 90*time.Second, 4*time.Minute, now)
+	if pos, ok := p.Get("/a.mp3"); !ok || pos != 90*time.Second {
+		t.Fatalf("Get = %v, %v, want 1m30s", pos, ok)
+	}
+
+	// Restarting the track doesn't wipe the position straight away
+	p.Set("/a.mp3", 2*time.Second, 4*time.Minute, now)
+	if pos, _ := p.Get("/a.mp3"); pos != 90*time.Second {
+		t.Errorf("early position replaced the saved one: %v", pos)
+	}
+
+	// Near the end the track counts as finished
+	p.Set("/a.mp3", 4*time.Minute-3*time.Second, 4*time.Minute, now)
+	if _, ok := p.Get("/a.mp3"); ok {
+		t.Error("a nearly finished track should be forgotten")
+	}
+
+	// Unknown durations still remember positions
+	p.Set("/b.mp3", time.Minute, 0, now)
+	if _, ok := p.Get("/b.mp3"); !ok {
+		t.Error("position with unknown duration should be kept")
+	}
+}
+
+func TestPositions_Prune(t *testing.T) {
+	now := time.Now()
+	p := NewPositions("")
+	p.Set("/old.mp3", time.Minute, 0, now.Add(-40*24*time.Hour))
+	p.Set("/new.mp3", time.Minute, 0, now.Add(-time.Hour))
+
+	if removed := p.Prune(30*24*time.Hour, now); removed != 1 {
+		t.Errorf("Prune removed %d, want 1", removed)
+	}
+	if _, ok := p.Get("/old.mp3"); ok {
+		t.Error("old entry should be pruned")
+	}
+	if _, ok := p.Get("/new.mp3"); !ok {
+		t.Error("recent entry should be kept")
+	}
+}
+
+func TestPositions_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "positions.json")
+	saved := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	p := NewPositions(path)
+	p.Set("/a.mp3", 75*time.Second, 5*time.Minute, saved)
+	if err := p.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadPositions(path)
+	if err != nil {
+		t.Fatalf("LoadPositions: %v", err)
+	}
+	if pos, ok := loaded.Get("/a.mp3"); !ok || pos != 75*time.Second {
+		t.Errorf("loaded position = %v, %v", pos, ok)
+	}
+	if !loaded.Entries["/a.mp3"].SavedAt.Equal(saved) {
+		t.Errorf("saved at = %v, want %v", loaded.Entries["/a.mp3"].SavedAt, saved)
+	}
+
+	missing, err := LoadPositions(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || len(missing.Entries) != 0 {
+		t.Errorf("missing file: %v, %d entries", err, len(missing.Entries))
+	}
+}
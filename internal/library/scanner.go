@@ -2,20 +2,26 @@ package library
 
 import (
 	"context"
-	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jscyril/golang_music_player/api"
 	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
 )
 
+// SupportedExtensions lists the file extensions the scanner picks up,
+// lowercase with the leading dot. Callers may append their own; matching
+// is case-insensitive.
+var SupportedExtensions = []string{".mp3", ".wav", ".flac", ".ogg"}
+
 // Scanner scans directories concurrently using a worker pool
 type Scanner struct {
 	workers    int
-	formats    []string
 	metaReader *MetadataReader
+	opts       ScanOptions
 }
 
 // NewScanner creates a new file scanner
@@ -25,76 +31,138 @@ func NewScanner(workers int) *Scanner {
 	}
 	return &Scanner{
 		workers:    workers,
-		formats:    []string{".mp3", ".wav", ".flac"},
 		metaReader: NewMetadataReader(),
+		opts:       DefaultScanOptions(),
 	}
 }
 
+// SetOptions sets how later scans walk their folders
+func (s *Scanner) SetOptions(opts ScanOptions) {
+	s.opts = opts
+}
+
 // SupportedFormats returns list of supported audio formats
 func (s *Scanner) SupportedFormats() []string {
-	return s.formats
+	return SupportedExtensions
 }
 
 // isSupported checks if a file format is supported
 func (s *Scanner) isSupported(filePath string) bool {
 	ext := strings.ToLower(filepath.Ext(filePath))
-	for _, format := range s.formats {
-		if ext == format {
+	for _, format := range SupportedExtensions {
+		if ext == strings.ToLower(format) {
 			return true
 		}
 	}
 	return false
 }
 
-// Scan scans directories concurrently and returns channels for results and errors
+// progressInterval is the least time between a scan's Progress calls
+const progressInterval = 200 * time.Millisecond
+
+// scanProgress counts a scan's files for its Progress callback. Files are
+// found by the walk and scanned by the workers concurrently.
+type scanProgress struct {
+	report    func(scanned, total int)
+	reporting sync.Mutex // Held through a report, so reports never overlap
+	mu        sync.Mutex
+	found     int
+	scanned   int
+	walked    bool // The walk has finished, so found is the total
+	last      time.Time
+}
+
+// foundFile counts a file the walk has found
+func (p *scanProgress) foundFile() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.found++
+}
+
+// walkDone marks every file as found
+func (p *scanProgress) walkDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.walked = true
+}
+
+// scannedFile counts a file read, tags or not, reporting if one is due
+func (p *scanProgress) scannedFile() {
+	p.mu.Lock()
+	p.scanned++
+	now := time.Now()
+	if now.Sub(p.last) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.last = now
+	p.send()
+}
+
+// finish reports the final count
+func (p *scanProgress) finish() {
+	p.mu.Lock()
+	p.send()
+}
+
+// send reports the counts, called with p.mu held and unlocking it. The
+// counts are copied under it and reported after, so the workers keep
+// counting meanwhile; taking reporting before letting go of p.mu keeps
+// the reports in order.
+func (p *scanProgress) send() {
+	scanned, total := p.scanned, -1
+	if p.walked {
+		total = p.found
+	}
+	p.reporting.Lock()
+	defer p.reporting.Unlock()
+	p.mu.Unlock()
+	p.report(scanned, total)
+}
+
+// discoveredFile is a supported file found while walking, with the size
+// reported by the directory entry (0 if it couldn't be read)
+type discoveredFile struct {
+	path string
+	size int64
+}
+
+// Scan scans directories concurrently and returns channels for results and
+// errors. Unreadable files and folders are reported on the error channel and
+// skipped. Both channels must be drained until closed. The options'
+// Progress, if set, hears how far the scan has got.
 func (s *Scanner) Scan(ctx context.Context, paths []string) (<-chan *api.Track, <-chan error) {
 	tracks := make(chan *api.Track, 100)
 	errors := make(chan error, 10)
-	files := make(chan string, 100)
+	files := make(chan discoveredFile, 100)
 
 	var wg sync.WaitGroup
+	var progress *scanProgress
+	if s.opts.Progress != nil {
+		progress = &scanProgress{report: s.opts.Progress}
+	}
 
-	// Start file discovery goroutine
+	// Start file discovery; directories are read concurrently
 	go func() {
 		defer close(files)
-		for _, path := range paths {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-
-			err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
-				if err != nil {
-					select {
-					case errors <- &playerrors.ScanError{Path: p, Err: err}:
-					default:
-					}
-					return nil
+		s.walkConcurrent(ctx, paths, s.workers,
+			func(file discoveredFile) {
+				if progress != nil {
+					progress.foundFile()
 				}
-
 				select {
+				case files <- file:
 				case <-ctx.Done():
-					return ctx.Err()
-				default:
 				}
-
-				if !d.IsDir() && s.isSupported(p) {
-					select {
-					case files <- p:
-					case <-ctx.Done():
-						return ctx.Err()
-					}
-				}
-				return nil
-			})
-
-			if err != nil && err != context.Canceled {
+			},
+			func(err *playerrors.ScanError) {
 				select {
-				case errors <- &playerrors.ScanError{Path: path, Err: err}:
-				default:
+				case errors <- err:
+				case <-ctx.Done():
 				}
-			}
+			})
+		if progress != nil {
+			progress.walkDone()
 		}
 	}()
 
@@ -103,21 +171,25 @@ func (s *Scanner) Scan(ctx context.Context, paths []string) (<-chan *api.Track,
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for filePath := range files {
+			for file := range files {
 				select {
 				case <-ctx.Done():
 					return
 				default:
 				}
 
-				track, err := s.metaReader.Read(filePath)
+				track, err := s.metaReader.Read(file.path)
+				if progress != nil {
+					progress.scannedFile()
+				}
 				if err != nil {
 					select {
-					case errors <- &playerrors.ScanError{Path: filePath, Err: err}:
-					default:
+					case errors <- &playerrors.ScanError{Path: file.path, Err: err}:
+					case <-ctx.Done():
 					}
 					continue
 				}
+				track.Size = file.size
 
 				select {
 				case tracks <- track:
@@ -131,6 +203,9 @@ func (s *Scanner) Scan(ctx context.Context, paths []string) (<-chan *api.Track,
 	// Close channels when done
 	go func() {
 		wg.Wait()
+		if progress != nil && ctx.Err() == nil {
+			progress.finish()
+		}
 		close(tracks)
 		close(errors)
 	}()
@@ -143,5 +218,12 @@ func (s *Scanner) ScanFile(filePath string) (*api.Track, error) {
 	if !s.isSupported(filePath) {
 		return nil, playerrors.ErrInvalidFormat
 	}
-	return s.metaReader.Read(filePath)
+	track, err := s.metaReader.Read(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		track.Size = info.Size()
+	}
+	return track, nil
 }
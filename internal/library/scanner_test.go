@@ -0,0 +1,474 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+func TestScanner_IsSupported(t *testing.T) {
+	s := NewScanner(1)
+	for path, want := range map[string]bool{
+		"a.mp3":   true,
+		"b.FLAC":  true,
+		"c.Ogg":   true,
+		"d.m4a":   false,
+		"e.opus":  false,
+		"f.aac":   false,
+		"g.txt":   false,
+		"noext":   false,
+		"h.mp3.x": false,
+	} {
+		if got := s.isSupported(path); got != want {
+			t.Errorf("isSupported(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestScanner_FollowsSymlinksWithoutLoops(t *testing.T) {
+	root := t.TempDir()
+	music := filepath.Join(root, "music")
+	mount := filepath.Join(root, "mount")
+	for _, dir := range []string{music, mount} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSilentWAV(t, filepath.Join(music, "local.wav"), 100*time.Millisecond)
+	writeSilentWAV(t, filepath.Join(mount, "remote.wav"), 100*time.Millisecond)
+
+	// A symlinked mount, plus a link back up the tree that would loop
+	if err := os.Symlink(mount, filepath.Join(music, "mounted")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(music, filepath.Join(mount, "back")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tracks, errs := NewScanner(2).Scan(ctx, []string{music})
+	go func() {
+		for range errs {
+		}
+	}()
+
+	var paths []string
+	for track := range tracks {
+		rel, _ := filepath.Rel(music, track.FilePath)
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	want := []string{"local.wav", filepath.Join("mounted", "remote.wav")}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("scanned %v, want %v", paths, want)
+	}
+}
+
+func TestLibraryScan_SkipsUnreadableFolders(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.Mkdir(locked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeSilentWAV(t, filepath.Join(root, "ok.wav"), 100*time.Millisecond)
+	writeSilentWAV(t, filepath.Join(locked, "hidden.wav"), 100*time.Millisecond)
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	lib := NewLibrary()
+	skipped, err := lib.Scan(context.Background(), []string{root})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if lib.TotalTracks != 1 {
+		t.Errorf("TotalTracks = %d, want 1", lib.TotalTracks)
+	}
+	if len(skipped) != 1 || skipped[0].Path != locked {
+		t.Errorf("skipped = %v, want %s", skipped, locked)
+	}
+}
+
+func TestLibraryScan_MissingRoots(t *testing.T) {
+	root := t.TempDir()
+	missing := filepath.Join(root, "missing")
+	writeSilentWAV(t, filepath.Join(root, "ok.wav"), 100*time.Millisecond)
+
+	lib := NewLibrary()
+	skipped, err := lib.Scan(context.Background(), []string{missing, root})
+	if err != nil {
+		t.Fatalf("one missing root should not fail the scan: %v", err)
+	}
+	if lib.TotalTracks != 1 || len(skipped) != 1 || skipped[0].Path != missing {
+		t.Errorf("TotalTracks = %d, skipped = %v", lib.TotalTracks, skipped)
+	}
+
+	if _, err := NewLibrary().Scan(context.Background(), []string{missing}); err == nil {
+		t.Error("Scan with no existing roots should return an error")
+	}
+	if _, _, err := lib.ScanFolder(context.Background(), missing); err == nil {
+		t.Error("ScanFolder on a missing folder should return an error")
+	}
+}
+
+func TestLibraryScanEach_HandsOverTracks(t *testing.T) {
+	root := t.TempDir()
+	writeSilentWAV(t, filepath.Join(root, "a.wav"), 100*time.Millisecond)
+	writeSilentWAV(t, filepath.Join(root, "b.wav"), 100*time.Millisecond)
+
+	lib := NewLibrary()
+	var added []*api.Track
+	if _, err := lib.ScanEach(context.Background(), []string{root}, func(track *api.Track) {
+		if _, err := lib.GetTrack(track.ID); err != nil {
+			t.Errorf("%s handed over before it was in the library", track.FilePath)
+		}
+		added = append(added, track)
+	}); err != nil {
+		t.Fatalf("ScanEach error: %v", err)
+	}
+	if len(added) != 2 || lib.TotalTracks != 2 {
+		t.Errorf("handed over %d tracks, library has %d; want 2", len(added), lib.TotalTracks)
+	}
+	if lib.ScanUnfinished() {
+		t.Error("finished scan reported unfinished")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cut := NewLibrary()
+	if _, err := cut.Scan(ctx, []string{root}); err == nil {
+		t.Error("cancelled scan returned no error")
+	}
+	if !cut.ScanUnfinished() || !cut.LastScanned.IsZero() {
+		t.Errorf("cancelled scan: unfinished = %v, LastScanned = %v", cut.ScanUnfinished(), cut.LastScanned)
+	}
+}
+
+func TestLibraryRescan_ReplacesTracks(t *testing.T) {
+	old, moved := t.TempDir(), t.TempDir()
+	writeSilentWAV(t, filepath.Join(old, "a.wav"), 100*time.Millisecond)
+	writeSilentWAV(t, filepath.Join(moved, "b.wav"), 100*time.Millisecond)
+	writeSilentWAV(t, filepath.Join(moved, "c.wav"), 100*time.Millisecond)
+
+	lib := NewLibrary()
+	if _, err := lib.Scan(context.Background(), []string{old}); err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if _, err := lib.Rescan(context.Background(), []string{filepath.Join(old, "missing")}); err == nil {
+		t.Error("rescanning a missing folder returned no error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := lib.Rescan(ctx, []string{moved}); err == nil {
+		t.Error("cancelled rescan returned no error")
+	}
+	if lib.TotalTracks != 1 || lib.ScanPaths[0] != old {
+		t.Fatalf("failed rescans left %d tracks from %v, want the 1 from %s", lib.TotalTracks, lib.ScanPaths, old)
+	}
+
+	if _, err := lib.Rescan(context.Background(), []string{moved}); err != nil {
+		t.Fatalf("Rescan error: %v", err)
+	}
+	if lib.TotalTracks != 2 || len(lib.GetAllTracks()) != 2 || lib.ScanPaths[0] != moved {
+		t.Errorf("after rescanning: %d tracks from %v, want 2 from %s", lib.TotalTracks, lib.ScanPaths, moved)
+	}
+	for _, track := range lib.GetAllTracks() {
+		if filepath.Dir(track.FilePath) != moved {
+			t.Errorf("%s kept after moving the library", track.FilePath)
+		}
+	}
+}
+
+func TestLibraryScan_ReportsProgress(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, 20, 5)
+
+	type call struct{ scanned, total int }
+	var calls []call
+	lib := NewLibrary()
+	opts := DefaultScanOptions()
+	opts.Progress = func(scanned, total int) {
+		calls = append(calls, call{scanned, total})
+	}
+	lib.SetScanOptions(opts)
+	if _, err := lib.Scan(context.Background(), []string{root}); err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	if len(calls) == 0 || len(calls) > 10 {
+		t.Fatalf("Progress called %d times for 100 files, want a few", len(calls))
+	}
+	if last := calls[len(calls)-1]; last != (call{100, 100}) {
+		t.Errorf("last progress = %+v, want 100 of 100", last)
+	}
+	for _, c := range calls {
+		if c.total != -1 && c.total != 100 || c.scanned > 100 {
+			t.Errorf("progress %+v out of range", c)
+		}
+	}
+}
+
+func TestScanProgress_CountsWhileReporting(t *testing.T) {
+	reporting, release := make(chan struct{}), make(chan struct{})
+	p := &scanProgress{report: func(scanned, total int) {
+		if scanned == 1 {
+			close(reporting)
+			<-release
+		}
+	}}
+	go p.scannedFile()
+	<-reporting
+
+	counted := make(chan struct{})
+	go func() {
+		p.foundFile()
+		p.scannedFile()
+		close(counted)
+	}()
+	select {
+	case <-counted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("counting waited for a slow report")
+	}
+	close(release)
+}
+
+// makeTree creates dirs folders of files empty .mp3 files each under root
+func makeTree(tb testing.TB, root string, dirs, files int) {
+	tb.Helper()
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("artist%03d", d/10), fmt.Sprintf("album%03d", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%02d.mp3", f)), nil, 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestFindFiles_Sorted(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, 30, 5)
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, skipped := NewScanner(8).FindFiles(context.Background(), []string{root})
+	if len(skipped) != 0 {
+		t.Errorf("skipped = %v", skipped)
+	}
+	if len(files) != 150 {
+		t.Fatalf("found %d files, want 150", len(files))
+	}
+	if !sort.StringsAreSorted(files) {
+		t.Error("files are not sorted")
+	}
+}
+
+func TestFindFiles_ScanOptions(t *testing.T) {
+	root := t.TempDir()
+	linked := t.TempDir()
+	for _, path := range []string{
+		"top.mp3",
+		filepath.Join("a", "one.mp3"),
+		filepath.Join("a", "b", "two.mp3"),
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(linked, "linked.mp3"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(linked, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts ScanOptions
+		want []string
+	}{
+		{"default", DefaultScanOptions(), []string{"a/b/two.mp3", "a/one.mp3", "link/linked.mp3", "top.mp3"}},
+		{"flat", ScanOptions{FollowSymlinks: true}, []string{"top.mp3"}},
+		{"depth 1", ScanOptions{Recursive: true, MaxDepth: 1, FollowSymlinks: true}, []string{"a/one.mp3", "link/linked.mp3", "top.mp3"}},
+		{"no symlinks", ScanOptions{Recursive: true}, []string{"a/b/two.mp3", "a/one.mp3", "top.mp3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(2)
+			s.SetOptions(tt.opts)
+			files, skipped := s.FindFiles(context.Background(), []string{root})
+			if len(skipped) != 0 {
+				t.Errorf("skipped = %v", skipped)
+			}
+			var got []string
+			for _, f := range files {
+				rel, _ := filepath.Rel(root, f)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindFiles_SkipsHiddenAndIgnored(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"song.mp3",
+		".hidden.mp3",
+		filepath.Join(".trash", "deleted.mp3"),
+		filepath.Join("album", "@eaDir", "thumb.mp3"),
+		filepath.Join("album", "part.tmp.mp3"),
+		filepath.Join("album", "track.mp3"),
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	find := func(opts ScanOptions) string {
+		s := NewScanner(2)
+		s.SetOptions(opts)
+		files, _ := s.FindFiles(context.Background(), []string{root})
+		var got []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(root, f)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return strings.Join(got, ",")
+	}
+
+	opts := DefaultScanOptions()
+	opts.Ignore = []string{"@eaDir", "*.tmp.*"}
+	if got, want := find(opts), "album/track.mp3,song.mp3"; got != want {
+		t.Errorf("found %s, want %s", got, want)
+	}
+
+	opts.IncludeHidden = true
+	if got, want := find(opts), ".hidden.mp3,.trash/deleted.mp3,album/track.mp3,song.mp3"; got != want {
+		t.Errorf("with hidden files found %s, want %s", got, want)
+	}
+
+	if err := CheckIgnorePatterns([]string{"*.tmp", "[a-"}); err == nil {
+		t.Error("malformed pattern not reported")
+	}
+}
+
+func TestFindFiles_CancelDoesNotLeak(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, 50, 2)
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewScanner(8).FindFiles(ctx, []string{root})
+
+	// Give any stragglers a moment to exit before counting
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before, %d after cancelled walk", before, after)
+	}
+}
+
+func BenchmarkFindFiles(b *testing.B) {
+	root := b.TempDir()
+	makeTree(b, root, 2000, 40) // 80,000 files
+	ctx := context.Background()
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s := NewScanner(workers)
+			for i := 0; i < b.N; i++ {
+				s.FindFiles(ctx, []string{root})
+			}
+		})
+	}
+}
+
+func TestListDir_OneLevel(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"b.mp3",
+		"a.flac",
+		"notes.txt",
+		".hidden.mp3",
+		filepath.Join("Album", "track.mp3"),
+		filepath.Join("Album", "Disc 2", "track.mp3"),
+		filepath.Join("Another", "track.mp3"),
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "broken.mp3")); err != nil {
+		t.Fatal(err)
+	}
+
+	rel := func(paths []string) string {
+		var got []string
+		for _, p := range paths {
+			r, _ := filepath.Rel(root, p)
+			got = append(got, filepath.ToSlash(r))
+		}
+		return strings.Join(got, ",")
+	}
+	subdirs, tracks, err := NewScanner(1).ListDir(root)
+	if err != nil {
+		t.Fatalf("ListDir error: %v", err)
+	}
+	if got, want := rel(subdirs), "Album,Another"; got != want {
+		t.Errorf("subdirs = %s, want %s", got, want)
+	}
+	if got, want := rel(tracks), "a.flac,b.mp3"; got != want {
+		t.Errorf("tracks = %s, want %s", got, want)
+	}
+
+	subdirs, tracks, err = NewScanner(1).ListDir(filepath.Join(root, "Album"))
+	if err != nil || rel(subdirs) != "Album/Disc 2" || rel(tracks) != "Album/track.mp3" {
+		t.Errorf("Album lists %s and %s, %v", rel(subdirs), rel(tracks), err)
+	}
+
+	missing := filepath.Join(root, "gone")
+	_, _, err = NewScanner(1).ListDir(missing)
+	var scanErr *playerrors.ScanError
+	if !errors.As(err, &scanErr) || scanErr.Path != missing {
+		t.Errorf("ListDir(%s) error = %v, want a ScanError for it", missing, err)
+	}
+}
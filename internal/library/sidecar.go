@@ -0,0 +1,152 @@
+package library
+
+import (
+	"os"
+	"time"
+)
+
+// SidecarEntry holds per-file data that isn't stored in the file's own tags.
+// Analysis results are only trusted while ModTime matches the file on disk;
+// user settings such as trim points are kept regardless.
+type SidecarEntry struct {
+	ModTime   time.Time     `json:"mod_time"`
+	BPM       float64       `json:"bpm,omitempty"`
+	TrimStart time.Duration `json:"trim_start,omitempty"`
+	TrimEnd   time.Duration `json:"trim_end,omitempty"`
+	TrimSet   bool          `json:"trim_set,omitempty"` // Trim was set in the app, even if cleared
+	Rating    int           `json:"rating,omitempty"`   // Stars, 1-5
+	Gain      float64       `json:"gain,omitempty"`     // Manual level offset in dB
+}
+
+// Sidecar is a JSON-backed store of per-file data keyed by file path
+type Sidecar struct {
+	jsonStore[*SidecarEntry]
+}
+
+// NewSidecar creates an empty sidecar that saves to path
+func NewSidecar(path string) *Sidecar {
+	s := &Sidecar{}
+	s.init(path)
+	return s
+}
+
+// LoadSidecar loads a sidecar from a JSON file (or returns empty if not exists)
+func LoadSidecar(path string) (*Sidecar, error) {
+	sc := NewSidecar(path)
+	if err := sc.load(sc, "sidecar"); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Save persists the sidecar to its JSON file
+func (s *Sidecar) Save() error {
+	return s.save(s, "sidecar")
+}
+
+// CachedBPM returns the analyzed BPM for filePath if the cached entry is
+// still valid for the file's current modification time
+func (s *Sidecar) CachedBPM(filePath string) (float64, bool) {
+	s.mu.RLock()
+	entry, ok := s.Entries[filePath]
+	var bpm float64
+	var modTime time.Time
+	if ok {
+		bpm, modTime = entry.BPM, entry.ModTime
+	}
+	s.mu.RUnlock()
+
+	if !ok || bpm <= 0 {
+		return 0, false
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil || !modTime.Equal(info.ModTime()) {
+		return 0, false
+	}
+	return bpm, true
+}
+
+// SetBPM records an analyzed BPM for filePath against its modification time
+func (s *Sidecar) SetBPM(filePath string, bpm float64, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(filePath)
+	entry.BPM = bpm
+	entry.ModTime = modTime
+}
+
+// Trim returns the stored trim points for filePath. A zero end means the
+// track plays to its natural end. ok reports whether a trim was ever set
+// in the app, so a cleared trim can still override imported ones.
+func (s *Sidecar) Trim(filePath string) (start, end time.Duration, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if entry, found := s.Entries[filePath]; found {
+		set := entry.TrimSet || entry.TrimStart > 0 || entry.TrimEnd > 0
+		return entry.TrimStart, entry.TrimEnd, set
+	}
+	return 0, 0, false
+}
+
+// SetTrim stores trim points for filePath. Zero values clear them.
+func (s *Sidecar) SetTrim(filePath string, start, end time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(filePath)
+	entry.TrimStart = start
+	entry.TrimEnd = end
+	entry.TrimSet = true
+}
+
+// Rating returns the stars filePath was rated, or 0 if unrated
+func (s *Sidecar) Rating(filePath string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if entry, found := s.Entries[filePath]; found {
+		return entry.Rating
+	}
+	return 0
+}
+
+// SetRating stores the stars filePath is rated. Zero clears the rating.
+func (s *Sidecar) SetRating(filePath string, rating int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entry(filePath).Rating = rating
+}
+
+// Gain returns the level offset set for filePath in dB, or 0 if none
+func (s *Sidecar) Gain(filePath string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if entry, found := s.Entries[filePath]; found {
+		return entry.Gain
+	}
+	return 0
+}
+
+// SetGain stores the level offset for filePath in dB. Zero clears it.
+func (s *Sidecar) SetGain(filePath string, db float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entry(filePath).Gain = db
+}
+
+// entry returns the entry for filePath, creating it if needed.
+// Callers must hold the write lock.
+func (s *Sidecar) entry(filePath string) *SidecarEntry {
+	entry, ok := s.Entries[filePath]
+	if !ok {
+		entry = &SidecarEntry{}
+		s.Entries[filePath] = entry
+	}
+	return entry
+}
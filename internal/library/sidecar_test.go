@@ -0,0 +1,110 @@
+package library
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+func TestSetTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sidecar.json")
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(path))
+	track := &api.Track{ID: "1", FilePath: "/music/a.mp3"}
+	lib.AddTrack(track)
+
+	if err := lib.SetTrim(track, 10*time.Second, 5*time.Second); !errors.Is(err, playerrors.ErrInvalidTrim) {
+		t.Errorf("start after end: err = %v, want ErrInvalidTrim", err)
+	}
+
+	copied := *track
+	if err := lib.SetTrim(&copied, 10*time.Second, 0); err != nil {
+		t.Fatalf("start only: %v", err)
+	}
+	if track.TrimStart != 10*time.Second || track.TrimEnd != 0 {
+		t.Errorf("library's track trimmed %v..%v through a copy, want 10s..0", track.TrimStart, track.TrimEnd)
+	}
+	if err := lib.Sidecar().Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, end, ok := loaded.Trim(track.FilePath); !ok || start != 10*time.Second || end != 0 {
+		t.Errorf("reloaded trim = %v..%v, want 10s..0", start, end)
+	}
+}
+
+func TestSetRating(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sidecar.json")
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(path))
+	own := &api.Track{ID: "1", FilePath: "/music/a.mp3"}
+	lib.AddTrack(own)
+
+	if err := lib.SetRating(own, 6); !errors.Is(err, playerrors.ErrInvalidRating) {
+		t.Errorf("6 stars: err = %v, want ErrInvalidRating", err)
+	}
+	copied := *own
+	if err := lib.SetRating(&copied, 4); err != nil {
+		t.Fatal(err)
+	}
+	if own.Rating != 4 {
+		t.Errorf("library's track rated %d through a copy, want 4", own.Rating)
+	}
+	if err := lib.Sidecar().Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := NewLibrary()
+	fresh.SetSidecar(loaded)
+	track := &api.Track{ID: "1", FilePath: "/music/a.mp3"}
+	fresh.AddTrack(track)
+	if track.Rating != 4 {
+		t.Errorf("reloaded rating = %d, want 4", track.Rating)
+	}
+}
+
+func TestSetGain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sidecar.json")
+	lib := NewLibrary()
+	lib.SetSidecar(NewSidecar(path))
+	own := &api.Track{ID: "1", FilePath: "/music/a.mp3"}
+	lib.AddTrack(own)
+
+	if err := lib.SetGain(own, MaxGain+1); !errors.Is(err, playerrors.ErrInvalidGain) {
+		t.Errorf("+13 dB: err = %v, want ErrInvalidGain", err)
+	}
+	copied := *own
+	if err := lib.SetGain(&copied, -2.5); err != nil {
+		t.Fatal(err)
+	}
+	if own.Gain != -2.5 {
+		t.Errorf("library's track offset %v dB through a copy, want -2.5", own.Gain)
+	}
+	if err := lib.Sidecar().Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := NewLibrary()
+	fresh.SetSidecar(loaded)
+	track := &api.Track{ID: "1", FilePath: "/music/a.mp3"}
+	fresh.AddTrack(track)
+	if track.Gain != -2.5 {
+		t.Errorf("reloaded offset = %v dB, want -2.5", track.Gain)
+	}
+}
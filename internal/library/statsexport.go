@@ -0,0 +1,222 @@
+package library
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+)
+
+// ListeningStats sums up the plays in a PlayStats store: every played
+// track, each artist and album with what was played of them, and the
+// totals. Listening time counts each play as the whole track, the store
+// keeping no more than that; tracks no longer in the library are listed by
+// path, with no time. Every list is sorted most played first, ties broken
+// by listening time and then by name, so exports of the same plays match.
+type ListeningStats struct {
+	Totals  ListeningTotals `json:"totals"`
+	Artists []GroupStats    `json:"artists"`
+	Albums  []GroupStats    `json:"albums"`
+	Tracks  []TrackStats    `json:"tracks"`
+}
+
+// ListeningTotals are the sums over every play
+type ListeningTotals struct {
+	Plays           int     `json:"plays"`
+	Tracks          int     `json:"tracks"`
+	Artists         int     `json:"artists"`
+	Albums          int     `json:"albums"`
+	ListenedSeconds float64 `json:"listened_seconds"`
+}
+
+// GroupStats are the plays of one artist's tracks, or of one album's
+type GroupStats struct {
+	Artist          string    `json:"artist"`
+	Album           string    `json:"album,omitempty"`
+	Plays           int       `json:"plays"`
+	Tracks          int       `json:"tracks"` // Different tracks played
+	ListenedSeconds float64   `json:"listened_seconds"`
+	LastPlayed      time.Time `json:"last_played"`
+}
+
+// TrackStats are the plays of one track
+type TrackStats struct {
+	Path            string    `json:"path"`
+	Title           string    `json:"title,omitempty"`
+	Artist          string    `json:"artist,omitempty"`
+	Album           string    `json:"album,omitempty"`
+	Plays           int       `json:"plays"`
+	ListenedSeconds float64   `json:"listened_seconds"`
+	LastPlayed      time.Time `json:"last_played"`
+}
+
+// ListeningStats sums up the plays of the store, taking each track's
+// artist, album and length from tracks, matched by file path
+func (s *PlayStats) ListeningStats(tracks []*api.Track) ListeningStats {
+	byPath := make(map[string]*api.Track, len(tracks))
+	for _, t := range tracks {
+		byPath[t.FilePath] = t
+	}
+
+	s.mu.RLock()
+	var st ListeningStats
+	artists := make(map[string]*GroupStats)
+	albums := make(map[[2]string]*GroupStats)
+	for path, stat := range s.Entries {
+		if stat.Count <= 0 {
+			continue
+		}
+		row := TrackStats{Path: path, Plays: stat.Count, LastPlayed: stat.LastPlayed.UTC()}
+		if t := byPath[path]; t != nil {
+			row.Title, row.Artist, row.Album = t.Title, t.Artist, t.Album
+			row.ListenedSeconds = t.Duration.Seconds() * float64(stat.Count)
+		}
+		st.Tracks = append(st.Tracks, row)
+		st.Totals.Plays += row.Plays
+		st.Totals.ListenedSeconds += row.ListenedSeconds
+
+		if row.Artist != "" {
+			addPlays(artists, row.Artist, GroupStats{Artist: row.Artist}, row)
+		}
+		if row.Album != "" {
+			addPlays(albums, [2]string{row.Artist, row.Album}, GroupStats{Artist: row.Artist, Album: row.Album}, row)
+		}
+	}
+	s.mu.RUnlock()
+
+	st.Artists = sortedGroups(artists)
+	st.Albums = sortedGroups(albums)
+	slices.SortFunc(st.Tracks, func(a, b TrackStats) int {
+		return cmp.Or(cmp.Compare(b.Plays, a.Plays), cmp.Compare(b.ListenedSeconds, a.ListenedSeconds), cmp.Compare(a.Path, b.Path))
+	})
+	st.Totals.Tracks = len(st.Tracks)
+	st.Totals.Artists = len(st.Artists)
+	st.Totals.Albums = len(st.Albums)
+	return st
+}
+
+// addPlays adds a track's plays to its group in groups, starting the group
+// as empty if the track is its first
+func addPlays[K comparable](groups map[K]*GroupStats, key K, empty GroupStats, row TrackStats) {
+	g := groups[key]
+	if g == nil {
+		g = &empty
+		groups[key] = g
+	}
+	g.Plays += row.Plays
+	g.Tracks++
+	g.ListenedSeconds += row.ListenedSeconds
+	if row.LastPlayed.After(g.LastPlayed) {
+		g.LastPlayed = row.LastPlayed
+	}
+}
+
+// sortedGroups lists groups most played first
+func sortedGroups[K comparable](groups map[K]*GroupStats) []GroupStats {
+	list := make([]GroupStats, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, *g)
+	}
+	slices.SortFunc(list, func(a, b GroupStats) int {
+		return cmp.Or(cmp.Compare(b.Plays, a.Plays), cmp.Compare(b.ListenedSeconds, a.ListenedSeconds),
+			cmp.Compare(strings.ToLower(a.Artist), strings.ToLower(b.Artist)), cmp.Compare(a.Artist, b.Artist),
+			cmp.Compare(strings.ToLower(a.Album), strings.ToLower(b.Album)), cmp.Compare(a.Album, b.Album))
+	})
+	return list
+}
+
+var statsCSVHeader = []string{
+	"kind", "artist", "album", "title", "path", "plays", "tracks", "listened_seconds", "last_played",
+}
+
+// ExportStats writes the stats to w in the given format. JSON output is
+// one object with the totals and the artist, album and track lists. CSV
+// output is a single table after a header row, its kind column saying
+// what each row counts: "total", then each "artist", "album" and "track".
+func (st ListeningStats) ExportStats(w io.Writer, format Format) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		rows := [][]string{statsCSVHeader, {
+			"total", "", "", "", "", strconv.Itoa(st.Totals.Plays), strconv.Itoa(st.Totals.Tracks),
+			formatSeconds(st.Totals.ListenedSeconds), "",
+		}}
+		for _, g := range st.Artists {
+			rows = append(rows, g.csvRow("artist"))
+		}
+		for _, g := range st.Albums {
+			rows = append(rows, g.csvRow("album"))
+		}
+		for _, t := range st.Tracks {
+			rows = append(rows, []string{
+				"track", t.Artist, t.Album, t.Title, t.Path, strconv.Itoa(t.Plays), "1",
+				formatSeconds(t.ListenedSeconds), formatTime(t.LastPlayed),
+			})
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+
+	case FormatJSON:
+		// Empty lists are written as [] rather than null
+		if st.Artists == nil {
+			st.Artists = []GroupStats{}
+		}
+		if st.Albums == nil {
+			st.Albums = []GroupStats{}
+		}
+		if st.Tracks == nil {
+			st.Tracks = []TrackStats{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+	return fmt.Errorf("unsupported export format %v", format)
+}
+
+// csvRow renders a group as a CSV row of the given kind
+func (g GroupStats) csvRow(kind string) []string {
+	return []string{
+		kind, g.Artist, g.Album, "", "", strconv.Itoa(g.Plays), strconv.Itoa(g.Tracks),
+		formatSeconds(g.ListenedSeconds), formatTime(g.LastPlayed),
+	}
+}
+
+// formatSeconds writes a listening time in whole seconds
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
+
+// formatTime writes a time as RFC 3339, or nothing if it is unset
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SaveStats writes the stats to path, in the format its extension names,
+// creating its directory if needed. The file is replaced atomically.
+func (st ListeningStats) SaveStats(path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	err = atomicfile.WriteFile(path, func(w io.Writer) error {
+		return st.ExportStats(w, format)
+	})
+	if err != nil {
+		return fmt.Errorf("write stats: %w", err)
+	}
+	return nil
+}
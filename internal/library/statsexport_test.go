@@ -0,0 +1,107 @@
+package library
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func statsFixture(t *testing.T) (*PlayStats, []*api.Track) {
+	t.Helper()
+	tracks := []*api.Track{
+		{FilePath: "/music/a.mp3", Title: "Hello, World", Artist: "Band", Album: "First", Duration: 3 * time.Minute},
+		{FilePath: "/music/b.mp3", Title: "B", Artist: "Band", Album: "Second", Duration: 2 * time.Minute},
+		{FilePath: "/music/c.mp3", Title: "C", Artist: "Solo, Artist", Album: "Alone", Duration: time.Minute},
+	}
+	s := NewPlayStats(filepath.Join(t.TempDir(), "plays.json"))
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		s.Record("/music/a.mp3", at.Add(time.Duration(i)*time.Hour))
+	}
+	s.Record("/music/b.mp3", at)
+	s.Record("/music/c.mp3", at)
+	s.Record("/music/c.mp3", at.Add(time.Minute))
+	s.Record("/gone/d.mp3", at)
+	return s, tracks
+}
+
+func TestListeningStats_Totals(t *testing.T) {
+	s, tracks := statsFixture(t)
+	st := s.ListeningStats(tracks)
+
+	want := ListeningTotals{Plays: 7, Tracks: 4, Artists: 2, Albums: 3, ListenedSeconds: 3*180 + 120 + 2*60}
+	if st.Totals != want {
+		t.Errorf("totals = %+v, want %+v", st.Totals, want)
+	}
+	if len(st.Artists) != 2 || st.Artists[0].Artist != "Band" || st.Artists[0].Plays != 4 || st.Artists[0].Tracks != 2 {
+		t.Errorf("top artist = %+v, want Band with 4 plays of 2 tracks", st.Artists)
+	}
+	if st.Artists[0].ListenedSeconds != 660 || !st.Artists[0].LastPlayed.Equal(time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("Band listened %vs, last %v", st.Artists[0].ListenedSeconds, st.Artists[0].LastPlayed)
+	}
+	if got := st.Tracks[0]; got.Path != "/music/a.mp3" || got.Plays != 3 {
+		t.Errorf("top track = %+v", got)
+	}
+	if got := st.Tracks[len(st.Tracks)-1]; got.Path != "/gone/d.mp3" || got.ListenedSeconds != 0 || got.Artist != "" {
+		t.Errorf("track gone from the library = %+v, want it last with no time", got)
+	}
+}
+
+func TestListeningStats_ExportCSV(t *testing.T) {
+	s, tracks := statsFixture(t)
+	var buf bytes.Buffer
+	if err := s.ListeningStats(tracks).ExportStats(&buf, FormatCSV); err != nil {
+		t.Fatalf("ExportStats: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v", err)
+	}
+	// Header, total, 2 artists, 3 albums, 4 tracks
+	if len(rows) != 11 {
+		t.Fatalf("got %d rows, want 11", len(rows))
+	}
+	if total := rows[1]; total[0] != "total" || total[5] != "7" || total[7] != "780" {
+		t.Errorf("total row = %q", total)
+	}
+	if artist := rows[3]; artist[0] != "artist" || artist[1] != "Solo, Artist" {
+		t.Errorf("second artist row = %q, want the comma kept in one field", artist)
+	}
+	if track := rows[7]; track[0] != "track" || track[3] != "Hello, World" || track[8] != "2026-03-01T14:00:00Z" {
+		t.Errorf("first track row = %q", track)
+	}
+}
+
+func TestListeningStats_ExportJSONIsStable(t *testing.T) {
+	s, tracks := statsFixture(t)
+	var first, second bytes.Buffer
+	if err := s.ListeningStats(tracks).ExportStats(&first, FormatJSON); err != nil {
+		t.Fatalf("ExportStats: %v", err)
+	}
+	if err := s.ListeningStats(tracks).ExportStats(&second, FormatJSON); err != nil {
+		t.Fatalf("ExportStats: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Error("exporting the same plays twice differs")
+	}
+	var got ListeningStats
+	if err := json.Unmarshal(first.Bytes(), &got); err != nil {
+		t.Fatalf("exported JSON does not parse: %v", err)
+	}
+	if got.Totals.Plays != 7 || len(got.Albums) != 3 || got.Albums[0].Album != "First" {
+		t.Errorf("parsed back %+v", got)
+	}
+
+	var empty bytes.Buffer
+	if err := NewPlayStats("").ListeningStats(nil).ExportStats(&empty, FormatJSON); err != nil {
+		t.Fatalf("ExportStats: %v", err)
+	}
+	if !bytes.Contains(empty.Bytes(), []byte(`"tracks": []`)) {
+		t.Errorf("no plays exported as %s, want empty lists", empty.String())
+	}
+}
@@ -0,0 +1,62 @@
+package library
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// IsStreamURL reports whether s is an http or https URL, played by
+// streaming it rather than read from disk
+func IsStreamURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")
+}
+
+// NewStreamTrack returns a track streaming rawURL. No request is made: the
+// title is taken from the URL's last path element, or its host for a bare
+// address such as a radio station's, and the artist is the host. Radio
+// streams update the title as they play.
+func NewStreamTrack(rawURL string) *api.Track {
+	rawURL = strings.TrimSpace(rawURL)
+	track := &api.Track{
+		ID:        generateTrackID(rawURL),
+		FilePath:  rawURL,
+		URL:       rawURL,
+		Title:     rawURL,
+		CreatedAt: time.Now(),
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return track
+	}
+	track.Artist = u.Hostname()
+	track.Title = u.Hostname()
+	if base := path.Base(u.Path); base != "/" && base != "." {
+		if name, err := url.PathUnescape(strings.TrimSuffix(base, path.Ext(base))); err == nil && name != "" {
+			track.Title = name
+		}
+	}
+	return track
+}
+
+// withStreamInfo returns a stream track with the title, artist and length
+// a playlist lists for it
+func withStreamInfo(track *api.Track, entry PlaylistEntry) *api.Track {
+	if entry.Title != "" {
+		track.Title = entry.Title
+	}
+	if entry.Artist != "" {
+		track.Artist = entry.Artist
+	}
+	if entry.Duration > 0 {
+		track.Duration = entry.Duration
+	}
+	return track
+}
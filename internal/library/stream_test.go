@@ -0,0 +1,53 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewStreamTrack(t *testing.T) {
+	tests := []struct {
+		url, title, artist string
+	}{
+		{"https://music.example.com/files/My%20Song.mp3", "My Song", "music.example.com"},
+		{"http://radio.example.com:8000/", "radio.example.com", "radio.example.com"},
+	}
+	for _, tt := range tests {
+		if !IsStreamURL(tt.url) {
+			t.Errorf("IsStreamURL(%q) = false", tt.url)
+		}
+		track := NewStreamTrack(tt.url)
+		if !track.IsStream() || track.FilePath != tt.url || track.Title != tt.title || track.Artist != tt.artist {
+			t.Errorf("NewStreamTrack(%q) = %+v", tt.url, track)
+		}
+	}
+	for _, s := range []string{"/music/a.mp3", "file:///music/a.mp3", "http:/no-host", "ftp://host/a.mp3"} {
+		if IsStreamURL(s) {
+			t.Errorf("IsStreamURL(%q) = true", s)
+		}
+	}
+}
+
+func TestReadPlaylist_StreamEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "radio.m3u")
+	content := "#EXTM3U\n#EXTINF:-1,Jazz FM - Live\nhttp://radio.example.com/jazz\nhttps://example.com/a.mp3\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks, errs, err := NewLibrary().ReadPlaylist(path)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("ReadPlaylist: %v, %v", err, errs)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(tracks))
+	}
+	if got := tracks[0]; got.URL != "http://radio.example.com/jazz" || got.Artist != "Jazz FM" || got.Title != "Live" {
+		t.Errorf("first track = %+v", got)
+	}
+	if got := tracks[1]; got.URL != "https://example.com/a.mp3" || got.Title != "a" {
+		t.Errorf("second track = %+v", got)
+	}
+}
@@ -0,0 +1,311 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// ScanOptions controls how far a scan walks below the folders it's given
+type ScanOptions struct {
+	// Recursive descends into subfolders; without it only the files
+	// directly in each folder are scanned
+	Recursive bool
+
+	// MaxDepth limits how many levels of subfolders a recursive scan
+	// descends, 1 being the folders directly inside; 0 means no limit
+	MaxDepth int
+
+	// FollowSymlinks descends into symlinked folders. Each real folder is
+	// still read only once, so links can't loop. Symlinked files are
+	// scanned either way, as are roots that are themselves links.
+	FollowSymlinks bool
+
+	// IncludeHidden scans files and folders whose names start with a dot,
+	// which are skipped otherwise
+	IncludeHidden bool
+
+	// Ignore lists glob patterns, such as "*.tmp" or "@eaDir", for files
+	// and folders to skip. Each is matched against every name below the
+	// roots, in the syntax of filepath.Match.
+	Ignore []string
+
+	// Progress, if set, is told how a scan is getting on: how many files
+	// have been read so far, and how many there are to read, -1 until the
+	// walk has found them all. It is called at most every
+	// progressInterval from the scanning goroutines, one call at a time,
+	// and once more with both counts equal when a scan finishes.
+	Progress func(scanned, total int)
+}
+
+// DefaultScanOptions walks the whole tree, following symlinks and skipping
+// hidden files
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{Recursive: true, FollowSymlinks: true}
+}
+
+// CheckIgnorePatterns returns an error naming the first malformed pattern
+func CheckIgnorePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("ignore pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// skip reports whether a file or folder called name is left out of scans
+func (o ScanOptions) skip(name string) bool {
+	if !o.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, pattern := range o.Ignore {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// descend reports whether the subfolders of a folder depth levels below a
+// root are walked
+func (o ScanOptions) descend(depth int) bool {
+	return o.Recursive && (o.MaxDepth <= 0 || depth < o.MaxDepth)
+}
+
+// dirJob is a directory waiting to be read. path is how it was reached
+// (possibly through a symlink) and real is its resolved location; depth
+// counts the levels below its root.
+type dirJob struct {
+	path, real string
+	depth      int
+}
+
+// dirQueue is the shared work list for walkConcurrent. pending counts jobs
+// queued or being read; the walk is done when it drops to zero.
+type dirQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    []dirJob
+	pending int
+	closed  bool
+	visited map[string]bool // real paths already queued
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{visited: make(map[string]bool)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a directory unless its real path was already seen
+func (q *dirQueue) push(job dirJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.visited[job.real] {
+		return
+	}
+	q.visited[job.real] = true
+	q.jobs = append(q.jobs, job)
+	q.pending++
+	q.cond.Signal()
+}
+
+// pop waits for a job. ok is false once the walk is finished or closed.
+func (q *dirQueue) pop() (job dirJob, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 && q.pending > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed || len(q.jobs) == 0 {
+		return dirJob{}, false
+	}
+	job = q.jobs[len(q.jobs)-1]
+	q.jobs = q.jobs[:len(q.jobs)-1]
+	return job, true
+}
+
+// done marks a popped job as finished
+func (q *dirQueue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+// close stops the walk, waking every waiting worker
+func (q *dirQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// walkConcurrent reads the directory trees under roots with up to workers
+// directories read at once, calling visit for each supported file and
+// report for each file or folder that can't be read. Both callbacks may be
+// called from several goroutines at once. How deep it goes and whether it
+// follows symlinked directories is set by the scanner's options; each real
+// directory is read once, so links can't loop.
+// It returns once the walk is finished or ctx is cancelled, with no
+// goroutines left running.
+func (s *Scanner) walkConcurrent(ctx context.Context, roots []string, workers int, visit func(discoveredFile), report func(*playerrors.ScanError)) {
+	q := newDirQueue()
+	for _, root := range roots {
+		real, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			report(&playerrors.ScanError{Path: root, Err: err})
+			continue
+		}
+		info, err := os.Stat(real)
+		if err != nil {
+			report(&playerrors.ScanError{Path: root, Err: err})
+			continue
+		}
+		if !info.IsDir() {
+			if s.isSupported(root) {
+				visit(discoveredFile{path: root, size: info.Size()})
+			}
+			continue
+		}
+		q.push(dirJob{path: root, real: real})
+	}
+
+	stop := context.AfterFunc(ctx, q.close)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, ok := q.pop()
+				if !ok {
+					return
+				}
+				s.readDir(job, q, visit, report)
+				q.done()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// readDir lists one directory, queueing its subdirectories if the options
+// descend that far
+func (s *Scanner) readDir(job dirJob, q *dirQueue, visit func(discoveredFile), report func(*playerrors.ScanError)) {
+	entries, err := os.ReadDir(job.path)
+	if err != nil {
+		report(&playerrors.ScanError{Path: job.path, Err: err})
+	}
+	descend := s.opts.descend(job.depth)
+	for _, entry := range entries {
+		if s.opts.skip(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(job.path, entry.Name())
+		switch {
+		case entry.Type()&os.ModeSymlink != 0:
+			info, err := os.Stat(path)
+			if err != nil {
+				report(&playerrors.ScanError{Path: path, Err: err})
+				continue
+			}
+			if info.IsDir() {
+				if !descend || !s.opts.FollowSymlinks {
+					continue
+				}
+				real, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					report(&playerrors.ScanError{Path: path, Err: err})
+					continue
+				}
+				q.push(dirJob{path: path, real: real, depth: job.depth + 1})
+			} else if s.isSupported(path) {
+				visit(discoveredFile{path: path, size: info.Size()})
+			}
+		case entry.IsDir():
+			if descend {
+				q.push(dirJob{path: path, real: filepath.Join(job.real, entry.Name()), depth: job.depth + 1})
+			}
+		case s.isSupported(path):
+			file := discoveredFile{path: path}
+			if info, err := entry.Info(); err == nil {
+				file.size = info.Size()
+			}
+			visit(file)
+		}
+	}
+}
+
+// FindFiles lists the supported audio files under roots, sorted by path,
+// reading directories on the scanner's workers concurrently. Files and
+// folders that can't be read are skipped and returned.
+func (s *Scanner) FindFiles(ctx context.Context, roots []string) ([]string, []*playerrors.ScanError) {
+	var mu sync.Mutex
+	var files []string
+	var skipped []*playerrors.ScanError
+	s.walkConcurrent(ctx, roots, s.workers,
+		func(f discoveredFile) {
+			mu.Lock()
+			files = append(files, f.path)
+			mu.Unlock()
+		},
+		func(err *playerrors.ScanError) {
+			mu.Lock()
+			skipped = append(skipped, err)
+			mu.Unlock()
+		})
+	sort.Strings(files)
+	return files, skipped
+}
+
+// ListDir lists one level of a folder, for browsing a library folder by
+// folder instead of walking it all up front: the subfolders directly in
+// path and the supported files directly in it, each sorted. Names the
+// options skip are left out, and symlinked folders are listed only if the
+// options follow them. Entries that can't be read, such as broken links,
+// are left out too. err is a *ScanError if the folder itself can't be
+// read, along with whatever was listed before that happened.
+func (s *Scanner) ListDir(path string) (subdirs []string, tracks []string, err error) {
+	entries, readErr := os.ReadDir(path)
+	for _, entry := range entries {
+		if s.opts.skip(entry.Name()) {
+			continue
+		}
+		full := filepath.Join(path, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			info, err := os.Stat(full)
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+			if isDir && !s.opts.FollowSymlinks {
+				continue
+			}
+		}
+		switch {
+		case isDir:
+			subdirs = append(subdirs, full)
+		case s.isSupported(full):
+			tracks = append(tracks, full)
+		}
+	}
+	sort.Strings(subdirs)
+	sort.Strings(tracks)
+	if readErr != nil {
+		return subdirs, tracks, &playerrors.ScanError{Path: path, Err: readErr}
+	}
+	return subdirs, tracks, nil
+}
@@ -0,0 +1,412 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// DefaultWatchInterval is how often a Watcher rescans when none is given
+const DefaultWatchInterval = 5 * time.Second
+
+// WatchEvent lists the audio files that appeared under the watched roots
+// and those that disappeared since the previous event. A rename shows up as
+// a removal of the old path and an addition of the new one.
+type WatchEvent struct {
+	Added   []string
+	Removed []string
+}
+
+// notifyRescanEvery is how many intervals go by between full polls while
+// change notifications are coming in, to catch changes on network mounts,
+// which send none
+const notifyRescanEvery = 12
+
+// Watcher reports supported audio files being added to or removed from
+// directory trees. It listens for the OS's change notifications on the
+// folders holding audio, walking the trees again once something changes,
+// and falls back to polling them every interval when notifications can't
+// be had. A full poll also runs every notifyRescanEvery intervals, so it
+// still sees into network mounts, and symlinked folders, which the scan
+// follows too.
+//
+// Bursts of changes, such as an album being copied in, are collected into
+// one event: an event is only sent once a walk finds the trees unchanged
+// since the one before, so files still being written (whose size keeps
+// changing) are held back until they are complete.
+type Watcher struct {
+	mu       sync.Mutex
+	roots    []string
+	rootsGen int // Bumped by SetRoots, so a poll can tell the roots moved
+	interval time.Duration
+	scanner  *Scanner
+	events   chan WatchEvent
+	ready    chan struct{} // Closed once the first snapshot is taken
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewWatcher creates a watcher for roots that polls every interval, or
+// every DefaultWatchInterval if interval is not positive
+func NewWatcher(roots []string, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		roots:    roots,
+		interval: interval,
+		scanner:  NewScanner(4),
+		events:   make(chan WatchEvent),
+		ready:    make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+}
+
+// SetScanOptions sets how the watcher walks its roots, which should match
+// how they were scanned. Call it before Start.
+func (w *Watcher) SetScanOptions(opts ScanOptions) {
+	w.scanner.SetOptions(opts)
+}
+
+// SetRoots moves the watcher onto other roots from its next poll. Files
+// already under them are taken as they are, so only later changes are
+// reported, as after Start.
+func (w *Watcher) SetRoots(roots []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roots = roots
+	w.rootsGen++
+}
+
+// Events returns the channel changes are sent on. It is closed when the
+// watcher stops.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Start watches in the background until ctx is cancelled or Stop is
+// called. It returns straight away; the trees are first walked in the
+// background too, so only changes after that walk are reported.
+func (w *Watcher) Start(ctx context.Context) {
+	w.done = make(chan struct{})
+	go w.run(ctx)
+}
+
+// Stop stops polling and waits for the background goroutine to exit. It
+// is safe to call more than once, and before Start.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	if w.done != nil {
+		<-w.done
+	}
+}
+
+// run takes the first snapshot, then watches until stopped
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+
+	notify := newDirNotifier()
+	defer notify.close()
+
+	baseline := w.snapshot(ctx)
+	notify.watch(baseline)
+	close(w.ready)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	last := baseline
+	// With notifications, the trees are only walked once one says
+	// something changed, until the walks find them settled again
+	changed, ticks := false, 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-notify.changed():
+			changed = true
+			continue
+		case err := <-notify.errors():
+			// Such as events dropped on overflow: walk to be sure
+			logger.Warn("Watch: %v", err)
+			changed = true
+			continue
+		case <-ticker.C:
+		}
+		ticks++
+		if notify.active() && !changed && !w.rootsMoved(baseline.gen) && ticks%notifyRescanEvery != 0 {
+			continue
+		}
+
+		current := w.snapshot(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		notify.watch(current)
+		// New roots: start over from what's under them
+		if current.gen != baseline.gen {
+			baseline, last = current, current
+			continue
+		}
+		// Still changing: wait for a quiet poll
+		if !sameSnapshot(current, last) {
+			last = current
+			continue
+		}
+		changed = false
+		event := diffSnapshots(baseline, current)
+		if len(event.Added) == 0 && len(event.Removed) == 0 {
+			continue
+		}
+		select {
+		case w.events <- event:
+			baseline = current
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// rootsMoved reports whether SetRoots has been called since the snapshot
+// of generation gen
+func (w *Watcher) rootsMoved(gen int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rootsGen != gen
+}
+
+// dirNotifier listens for changes to the folders holding a snapshot's
+// files. Without OS notifications, or once it can't add a folder, it is
+// inactive and the watcher polls.
+type dirNotifier struct {
+	w       *fsnotify.Watcher
+	watched map[string]bool
+	gen     int // The roots the watched folders are under
+}
+
+func newDirNotifier() *dirNotifier {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warn("Watch: no change notifications, polling instead: %v", err)
+		return &dirNotifier{}
+	}
+	return &dirNotifier{w: w, watched: make(map[string]bool)}
+}
+
+// active reports whether notifications are coming in
+func (n *dirNotifier) active() bool {
+	return n.w != nil
+}
+
+// changed delivers a value for each change seen in a watched folder; it
+// never does while inactive
+func (n *dirNotifier) changed() <-chan fsnotify.Event {
+	if n.w == nil {
+		return nil
+	}
+	return n.w.Events
+}
+
+// errors delivers the errors notifications report; it never does while
+// inactive
+func (n *dirNotifier) errors() <-chan error {
+	if n.w == nil {
+		return nil
+	}
+	return n.w.Errors
+}
+
+// watch adds the roots and the folders holding snap's files, and those
+// between them, that aren't watched yet. Folders under roots that are no
+// longer watched are dropped. Should one fail, as when the OS limit on
+// watches is reached, it gives up on notifications.
+func (n *dirNotifier) watch(snap treeSnapshot) {
+	if n.w == nil {
+		return
+	}
+	if snap.gen != n.gen {
+		for dir := range n.watched {
+			n.w.Remove(dir)
+		}
+		clear(n.watched)
+		n.gen = snap.gen
+	}
+	for _, dir := range snap.dirs() {
+		if n.watched[dir] {
+			continue
+		}
+		if err := n.w.Add(dir); err != nil {
+			logger.Warn("Watch: can't watch %s, polling instead: %v", dir, err)
+			n.close()
+			return
+		}
+		n.watched[dir] = true
+	}
+}
+
+func (n *dirNotifier) close() {
+	if n.w != nil {
+		n.w.Close()
+		n.w = nil
+	}
+}
+
+// treeSnapshot is the state of the watched trees at one poll: every
+// supported file with its size, and the paths that couldn't be read
+type treeSnapshot struct {
+	roots  []string
+	files  map[string]int64
+	failed []string
+	gen    int // The roots walked, as of that SetRoots call
+}
+
+// dirs lists the roots that are folders and every folder from them down
+// to each file
+func (s treeSnapshot) dirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) bool {
+		if seen[dir] {
+			return false
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+		return true
+	}
+	for _, root := range s.roots {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			add(filepath.Clean(root))
+		}
+	}
+	for path := range s.files {
+		for dir := filepath.Dir(path); add(dir); dir = filepath.Dir(dir) {
+			if s.isRoot(dir) || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// isRoot reports whether dir is one of the roots
+func (s treeSnapshot) isRoot(dir string) bool {
+	for _, root := range s.roots {
+		if filepath.Clean(root) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot walks the roots
+func (w *Watcher) snapshot(ctx context.Context) treeSnapshot {
+	w.mu.Lock()
+	roots, gen := w.roots, w.rootsGen
+	w.mu.Unlock()
+
+	var mu sync.Mutex
+	snap := treeSnapshot{roots: roots, files: make(map[string]int64), gen: gen}
+	w.scanner.walkConcurrent(ctx, roots, w.scanner.workers,
+		func(f discoveredFile) {
+			mu.Lock()
+			snap.files[f.path] = f.size
+			mu.Unlock()
+		},
+		func(err *playerrors.ScanError) {
+			mu.Lock()
+			snap.failed = append(snap.failed, err.Path)
+			mu.Unlock()
+		})
+	return snap
+}
+
+// unreadable reports whether path is, or is inside, a path that couldn't
+// be read
+func (s treeSnapshot) unreadable(path string) bool {
+	for _, failed := range s.failed {
+		if path == failed || strings.HasPrefix(path, failed+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSnapshot reports whether two snapshots list the same files at the
+// same sizes
+func sameSnapshot(a, b treeSnapshot) bool {
+	if len(a.files) != len(b.files) {
+		return false
+	}
+	for path, size := range a.files {
+		if other, ok := b.files[path]; !ok || other != size {
+			return false
+		}
+	}
+	return true
+}
+
+// diffSnapshots lists the files in to but not from, and the reverse.
+// Files under folders to couldn't read aren't counted as removed, so an
+// unplugged drive doesn't empty the library.
+func diffSnapshots(from, to treeSnapshot) WatchEvent {
+	var event WatchEvent
+	for path := range to.files {
+		if _, ok := from.files[path]; !ok {
+			event.Added = append(event.Added, path)
+		}
+	}
+	for path := range from.files {
+		if _, ok := to.files[path]; !ok && !to.unreadable(path) {
+			event.Removed = append(event.Removed, path)
+		}
+	}
+	sort.Strings(event.Added)
+	sort.Strings(event.Removed)
+	return event
+}
+
+// ApplyWatchEvent brings the library in line with a watcher event, adding
+// tracks for the new files and removing those whose files are gone. Files
+// that can't be read are skipped and returned as *ScanError values.
+func (l *Library) ApplyWatchEvent(event WatchEvent) (added, removed []*api.Track, errs []error) {
+	for _, path := range event.Removed {
+		id := generateTrackID(path)
+		track, err := l.GetTrack(id)
+		if err != nil {
+			continue
+		}
+		if l.RemoveTrack(id) == nil {
+			removed = append(removed, track)
+		}
+	}
+	for _, path := range event.Added {
+		if _, err := l.GetTrack(generateTrackID(path)); err == nil {
+			continue
+		}
+		track, err := l.scanner.ScanFile(path)
+		if err != nil {
+			errs = append(errs, &playerrors.ScanError{Path: path, Err: err})
+			continue
+		}
+		l.AddTrack(track)
+		added = append(added, track)
+	}
+	return added, removed, errs
+}
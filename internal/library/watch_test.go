@@ -0,0 +1,161 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// nextEvent waits for a watcher event, failing the test after a timeout
+func nextEvent(t *testing.T, w *Watcher) WatchEvent {
+	t.Helper()
+	select {
+	case event, ok := <-w.Events():
+		if !ok {
+			t.Fatal("watcher stopped")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch event")
+	}
+	return WatchEvent{}
+}
+
+func TestWatcher_ReportsAddedAndRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.wav")
+	writeSilentWAV(t, old, 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher([]string{dir}, 10*time.Millisecond)
+	w.Start(ctx)
+	<-w.ready
+	defer w.Stop()
+
+	// A burst of files arrives as one event
+	album := filepath.Join(dir, "album")
+	if err := os.Mkdir(album, 0755); err != nil {
+		t.Fatal(err)
+	}
+	a, b := filepath.Join(album, "01.wav"), filepath.Join(album, "02.wav")
+	writeSilentWAV(t, a, 100*time.Millisecond)
+	writeSilentWAV(t, b, 100*time.Millisecond)
+	if err := os.WriteFile(filepath.Join(album, "cover.jpg"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	event := nextEvent(t, w)
+	for len(event.Added) < 2 {
+		// The poll may have landed between the two writes
+		more := nextEvent(t, w)
+		event.Added = append(event.Added, more.Added...)
+	}
+	if want := []string{a, b}; !reflect.DeepEqual(event.Added, want) || len(event.Removed) != 0 {
+		t.Fatalf("event = %+v, want %v added", event, want)
+	}
+
+	if err := os.Remove(old); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, w); !reflect.DeepEqual(event.Removed, []string{old}) || len(event.Added) != 0 {
+		t.Errorf("event = %+v, want %s removed", event, old)
+	}
+}
+
+func TestWatcher_SetRoots(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeSilentWAV(t, filepath.Join(second, "there.wav"), 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher([]string{first}, 10*time.Millisecond)
+	w.Start(ctx)
+	<-w.ready
+	defer w.Stop()
+
+	// What's already in the new root isn't news; what comes after is
+	w.SetRoots([]string{second})
+	time.Sleep(50 * time.Millisecond)
+	added := filepath.Join(second, "new.wav")
+	writeSilentWAV(t, added, 100*time.Millisecond)
+	if event := nextEvent(t, w); !reflect.DeepEqual(event.Added, []string{added}) || len(event.Removed) != 0 {
+		t.Errorf("event = %+v, want just %s added", event, added)
+	}
+}
+
+func TestWatcher_StopClosesEvents(t *testing.T) {
+	w := NewWatcher([]string{t.TempDir()}, 10*time.Millisecond)
+	w.Stop() // Before Start is a no-op
+
+	w = NewWatcher([]string{t.TempDir()}, 10*time.Millisecond)
+	w.Start(context.Background())
+	w.Stop()
+	w.Stop()
+	if _, ok := <-w.Events(); ok {
+		t.Error("events channel should be closed after Stop")
+	}
+}
+
+func TestDiffSnapshots_KeepsUnreadableFolders(t *testing.T) {
+	drive := filepath.Join("mnt", "usb")
+	from := treeSnapshot{files: map[string]int64{
+		filepath.Join(drive, "a.mp3"):   1,
+		filepath.Join("music", "b.mp3"): 1,
+	}}
+	to := treeSnapshot{files: map[string]int64{}, failed: []string{drive}}
+
+	event := diffSnapshots(from, to)
+	if want := []string{filepath.Join("music", "b.mp3")}; !reflect.DeepEqual(event.Removed, want) {
+		t.Errorf("Removed = %v, want %v", event.Removed, want)
+	}
+}
+
+func TestTreeSnapshot_Dirs(t *testing.T) {
+	root := t.TempDir()
+	snap := treeSnapshot{roots: []string{root}, files: map[string]int64{
+		filepath.Join(root, "Artist", "Album", "01.mp3"): 1,
+		filepath.Join(root, "Artist", "Album", "02.mp3"): 1,
+		filepath.Join(root, "single.mp3"):                1,
+	}}
+	want := []string{root, filepath.Join(root, "Artist"), filepath.Join(root, "Artist", "Album")}
+	if got := snap.dirs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("dirs = %v, want %v", got, want)
+	}
+}
+
+func TestLibrary_ApplyWatchEvent(t *testing.T) {
+	dir := t.TempDir()
+	keep, gone, added := filepath.Join(dir, "keep.wav"), filepath.Join(dir, "gone.wav"), filepath.Join(dir, "new.wav")
+	for _, path := range []string{keep, gone, added} {
+		writeSilentWAV(t, path, 100*time.Millisecond)
+	}
+	lib := NewLibrary()
+	if _, err := lib.Scan(context.Background(), []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	lib.RemoveTrack(generateTrackID(added))
+
+	newTracks, removed, errs := lib.ApplyWatchEvent(WatchEvent{
+		Added:   []string{added, keep, filepath.Join(dir, "missing.wav")},
+		Removed: []string{gone},
+	})
+	if len(newTracks) != 1 || newTracks[0].FilePath != added {
+		t.Errorf("added = %v, want only %s", newTracks, added)
+	}
+	if len(removed) != 1 || removed[0].FilePath != gone {
+		t.Errorf("removed = %v, want %s", removed, gone)
+	}
+	if len(errs) != 1 {
+		t.Errorf("errs = %v, want one for the missing file", errs)
+	}
+	if lib.TotalTracks != 2 {
+		t.Errorf("TotalTracks = %d, want 2", lib.TotalTracks)
+	}
+}
@@ -0,0 +1,448 @@
+// Package mpris exposes the player on the D-Bus session bus as an MPRIS
+// media player, so desktop media keys, panels and tools like playerctl
+// can show what's playing and control playback.
+package mpris
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// ErrNoBus is returned by Connect when there is no session bus to connect to
+var ErrNoBus = errors.New("no D-Bus session bus")
+
+// BusName is the name the player is registered under
+const BusName = "org.mpris.MediaPlayer2.golang_music_player"
+
+// Identity is the player name shown by MPRIS clients
+const Identity = "Golang Music Player"
+
+const (
+	objectPath  dbus.ObjectPath = "/org/mpris/MediaPlayer2"
+	noTrackPath dbus.ObjectPath = "/org/mpris/MediaPlayer2/TrackList/NoTrack"
+	trackPrefix                 = "/org/golang_music_player/track/"
+
+	ifaceRoot   = "org.mpris.MediaPlayer2"
+	ifacePlayer = "org.mpris.MediaPlayer2.Player"
+)
+
+// seekThreshold is how far the position may drift from where steady
+// playback would have taken it before clients are told it jumped
+const seekThreshold = 1500 * time.Millisecond
+
+// maxPending is how many commands may wait for the player before further
+// ones are dropped. Repeated seeks and volume changes are merged, so only
+// a client hammering different buttons while the UI is stuck fills it.
+const maxPending = 32
+
+// CommandKind is a playback action requested by an MPRIS client
+type CommandKind int
+
+const (
+	CommandPlay        CommandKind = iota
+	CommandPause                   // Pause; does nothing when stopped
+	CommandPlayPause               // Toggle between playing and paused
+	CommandStop                    // Stop playback
+	CommandNext                    // Skip to the next track
+	CommandPrevious                // Go back to the previous track
+	CommandSeek                    // Move the position by Position
+	CommandSetPosition             // Move to Position in the current track
+	CommandVolume                  // Set the volume to Volume
+	CommandQuit                    // Quit the player
+)
+
+// Command is one request from an MPRIS client
+type Command struct {
+	Kind     CommandKind
+	Position time.Duration // Offset for CommandSeek, target for CommandSetPosition
+	Volume   float64       // 0-1, for CommandVolume
+}
+
+// Status is what the player reports to MPRIS clients
+type Status struct {
+	Track       *api.Track // nil when nothing is loaded
+	Playback    api.PlayerStatus
+	Position    time.Duration
+	Length      time.Duration
+	Volume      float64 // 0-1
+	CanNext     bool
+	CanPrevious bool
+}
+
+// Server is the player's MPRIS object on the session bus. Commands from
+// clients arrive on Commands; Update keeps what clients see current.
+type Server struct {
+	conn     *dbus.Conn
+	props    *prop.Properties
+	commands chan Command
+
+	// Client commands wait in pending until the player takes them, so a
+	// method call is answered at once however busy the player is
+	queueMu sync.Mutex
+	pending []Command
+	wake    chan struct{}
+
+	mu         sync.Mutex
+	status     Status
+	lastUpdate time.Time
+}
+
+// Connect connects to the session bus and registers the player. It
+// returns an error wrapping ErrNoBus when there is no session bus, as
+// on a headless machine, in which case the player runs without MPRIS.
+func Connect() (*Server, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoBus, err)
+	}
+	s, err := newServer(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// A second instance takes a unique name, as the spec suggests
+	for _, name := range []string{BusName, fmt.Sprintf("%s.instance%d", BusName, os.Getpid())} {
+		reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("request name %s: %w", name, err)
+		}
+		if reply == dbus.RequestNameReplyPrimaryOwner {
+			return s, nil
+		}
+	}
+	s.Close()
+	return nil, fmt.Errorf("request name %s: already taken", BusName)
+}
+
+// newServer exports the player's object on conn
+func newServer(conn *dbus.Conn) (*Server, error) {
+	s := &Server{conn: conn, commands: make(chan Command), wake: make(chan struct{}, 1)}
+
+	if err := conn.Export(root{s}, objectPath, ifaceRoot); err != nil {
+		return nil, fmt.Errorf("export %s: %w", ifaceRoot, err)
+	}
+	if err := conn.ExportWithMap(player{s}, playerMethods, objectPath, ifacePlayer); err != nil {
+		return nil, fmt.Errorf("export %s: %w", ifacePlayer, err)
+	}
+	props, err := prop.Export(conn, objectPath, s.propMap())
+	if err != nil {
+		return nil, fmt.Errorf("export properties: %w", err)
+	}
+	s.props = props
+
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       ifaceRoot,
+				Methods:    introspect.Methods(root{}),
+				Properties: props.Introspection(ifaceRoot),
+			},
+			{
+				Name:       ifacePlayer,
+				Methods:    renameMethods(introspect.Methods(player{}), playerMethods),
+				Properties: props.Introspection(ifacePlayer),
+				Signals: []introspect.Signal{
+					{Name: "Seeked", Args: []introspect.Arg{{Name: "Position", Type: "x"}}},
+				},
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, fmt.Errorf("export introspection: %w", err)
+	}
+
+	go s.deliver()
+	return s, nil
+}
+
+// Commands returns the channel client requests arrive on. It is closed
+// when the connection to the bus ends.
+func (s *Server) Commands() <-chan Command {
+	return s.commands
+}
+
+// Close disconnects from the bus
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+// Update sets the reported status, telling clients about properties that
+// changed and about position jumps
+func (s *Server) Update(status Status) {
+	s.mu.Lock()
+	old, elapsed := s.status, time.Since(s.lastUpdate)
+	s.status, s.lastUpdate = status, time.Now()
+	s.mu.Unlock()
+
+	before, after := playerProperties(old), playerProperties(status)
+	for name, v := range after {
+		if !reflect.DeepEqual(before[name], v) {
+			s.props.SetMust(ifacePlayer, name, v)
+		}
+	}
+
+	if status.Track == nil || old.Track == nil || status.Track.ID != old.Track.ID {
+		return
+	}
+	expected := old.Position
+	if old.Playback == api.StatusPlaying {
+		expected += elapsed
+	}
+	if drift := status.Position - expected; drift > seekThreshold || drift < -seekThreshold {
+		s.conn.Emit(objectPath, ifacePlayer+".Seeked", status.Position.Microseconds())
+	}
+}
+
+// send queues a command for the player without waiting for it. A seek or
+// volume change following one the player hasn't taken yet is merged into
+// it; past maxPending waiting commands, new ones are dropped and logged.
+func (s *Server) send(cmd Command) {
+	s.queueMu.Lock()
+	if n := len(s.pending); n > 0 && s.pending[n-1].Kind == cmd.Kind {
+		last := &s.pending[n-1]
+		switch cmd.Kind {
+		case CommandSeek:
+			last.Position += cmd.Position
+			s.queueMu.Unlock()
+			return
+		case CommandSetPosition, CommandVolume:
+			*last = cmd
+			s.queueMu.Unlock()
+			return
+		}
+	}
+	if len(s.pending) >= maxPending {
+		s.queueMu.Unlock()
+		logger.Warn("MPRIS command %d dropped: player not keeping up", cmd.Kind)
+		return
+	}
+	s.pending = append(s.pending, cmd)
+	s.queueMu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // Already woken
+	}
+}
+
+// next takes the oldest waiting command
+func (s *Server) next() (Command, bool) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	if len(s.pending) == 0 {
+		return Command{}, false
+	}
+	cmd := s.pending[0]
+	s.pending = s.pending[1:]
+	return cmd, true
+}
+
+// deliver hands waiting commands to the player one at a time until the
+// connection ends, then closes Commands
+func (s *Server) deliver() {
+	defer close(s.commands)
+	done := s.conn.Context().Done()
+	for {
+		select {
+		case <-s.wake:
+		case <-done:
+			return
+		}
+		for cmd, ok := s.next(); ok; cmd, ok = s.next() {
+			select {
+			case s.commands <- cmd:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// root serves the org.mpris.MediaPlayer2 methods
+type root struct{ s *Server }
+
+func (root) Raise() *dbus.Error { return nil }
+
+func (r root) Quit() *dbus.Error {
+	r.s.send(Command{Kind: CommandQuit})
+	return nil
+}
+
+// player serves the org.mpris.MediaPlayer2.Player methods
+type player struct{ s *Server }
+
+func (p player) Next() *dbus.Error      { return p.command(CommandNext) }
+func (p player) Previous() *dbus.Error  { return p.command(CommandPrevious) }
+func (p player) Pause() *dbus.Error     { return p.command(CommandPause) }
+func (p player) PlayPause() *dbus.Error { return p.command(CommandPlayPause) }
+func (p player) Stop() *dbus.Error      { return p.command(CommandStop) }
+func (p player) Play() *dbus.Error      { return p.command(CommandPlay) }
+
+// playerMethods maps the player's Go method names to D-Bus ones where
+// they differ: a Go method named Seek would be taken for io.Seeker's
+var playerMethods = map[string]string{"SeekBy": "Seek"}
+
+// renameMethods applies names to introspected methods
+func renameMethods(methods []introspect.Method, names map[string]string) []introspect.Method {
+	for i, m := range methods {
+		if name, ok := names[m.Name]; ok {
+			methods[i].Name = name
+		}
+	}
+	return methods
+}
+
+func (p player) SeekBy(offset int64) *dbus.Error {
+	p.s.send(Command{Kind: CommandSeek, Position: time.Duration(offset) * time.Microsecond})
+	return nil
+}
+
+func (p player) SetPosition(trackID dbus.ObjectPath, position int64) *dbus.Error {
+	// Requests for a track that has since changed are ignored
+	p.s.mu.Lock()
+	current := trackPath(p.s.status.Track)
+	p.s.mu.Unlock()
+	if trackID == current {
+		p.s.send(Command{Kind: CommandSetPosition, Position: time.Duration(position) * time.Microsecond})
+	}
+	return nil
+}
+
+func (player) OpenUri(uri string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.NotSupported", []any{"opening URIs is not supported"})
+}
+
+func (p player) command(kind CommandKind) *dbus.Error {
+	p.s.send(Command{Kind: kind})
+	return nil
+}
+
+// propMap describes the properties of both interfaces for prop.Export.
+// Volume is the only writable one; setting it only asks the player, and
+// the value clients see changes when the player reports it.
+func (s *Server) propMap() prop.Map {
+	rootProps := make(map[string]*prop.Prop, len(rootProperties))
+	for name, v := range rootProperties {
+		rootProps[name] = &prop.Prop{Value: v, Emit: prop.EmitConst}
+	}
+	playerProps := make(map[string]*prop.Prop)
+	for name, v := range playerProperties(Status{}) {
+		p := &prop.Prop{Value: v, Emit: prop.EmitTrue}
+		switch name {
+		case "Position":
+			p.Emit = prop.EmitFalse // Spec: tracked through Seeked instead
+		case "Volume":
+			p.Writable = true
+			p.Callback = func(c *prop.Change) *dbus.Error {
+				// Called with the properties locked: send must not block
+				s.send(Command{Kind: CommandVolume, Volume: min(max(c.Value.(float64), 0), 1)})
+				return nil
+			}
+		}
+		playerProps[name] = p
+	}
+	return prop.Map{ifaceRoot: rootProps, ifacePlayer: playerProps}
+}
+
+// rootProperties are the org.mpris.MediaPlayer2 properties, which never change
+var rootProperties = map[string]any{
+	"CanQuit":             true,
+	"CanRaise":            false,
+	"HasTrackList":        false,
+	"Identity":            Identity,
+	"SupportedUriSchemes": []string{},
+	"SupportedMimeTypes":  []string{},
+}
+
+// playerProperties returns the org.mpris.MediaPlayer2.Player properties
+// for status
+func playerProperties(status Status) map[string]any {
+	loaded := status.Track != nil
+	return map[string]any{
+		"PlaybackStatus": playbackStatus(status.Playback),
+		"Rate":           1.0,
+		"MinimumRate":    1.0,
+		"MaximumRate":    1.0,
+		"Metadata":       metadata(status),
+		"Volume":         status.Volume,
+		"Position":       status.Position.Microseconds(),
+		"CanGoNext":      status.CanNext,
+		"CanGoPrevious":  status.CanPrevious,
+		"CanPlay":        loaded,
+		"CanPause":       loaded,
+		"CanSeek":        loaded && status.Length > 0,
+		"CanControl":     true,
+	}
+}
+
+func playbackStatus(status api.PlayerStatus) string {
+	switch status {
+	case api.StatusPlaying:
+		return "Playing"
+	case api.StatusPaused:
+		return "Paused"
+	}
+	return "Stopped"
+}
+
+// metadata describes the current track in xesam terms
+func metadata(status Status) map[string]dbus.Variant {
+	track := status.Track
+	meta := map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(trackPath(track))}
+	if track == nil {
+		return meta
+	}
+	if status.Length > 0 {
+		meta["mpris:length"] = dbus.MakeVariant(status.Length.Microseconds())
+	}
+	if track.Title != "" {
+		meta["xesam:title"] = dbus.MakeVariant(track.Title)
+	}
+	if track.Artist != "" {
+		meta["xesam:artist"] = dbus.MakeVariant([]string{track.Artist})
+	}
+	if track.Album != "" {
+		meta["xesam:album"] = dbus.MakeVariant(track.Album)
+	}
+	if track.FilePath != "" {
+		u := url.URL{Scheme: "file", Path: track.FilePath}
+		meta["xesam:url"] = dbus.MakeVariant(u.String())
+	}
+	return meta
+}
+
+// trackPath returns the object path identifying track. Object path
+// elements may only hold ASCII letters, digits and underscores, so any
+// other byte of the ID is written as _XX in hex.
+func trackPath(track *api.Track) dbus.ObjectPath {
+	if track == nil || track.ID == "" {
+		return noTrackPath
+	}
+	var sb strings.Builder
+	sb.WriteString(trackPrefix)
+	for i := 0; i < len(track.ID); i++ {
+		c := track.ID[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "_%02x", c)
+		}
+	}
+	return dbus.ObjectPath(sb.String())
+}
@@ -0,0 +1,318 @@
+package mpris
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// busConfig is a session bus that lets anyone own and call anything
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>`
+
+// testBus is a private dbus-daemon with a server on it and a client
+// connection to reach it from
+type testBus struct {
+	t       *testing.T
+	server  *Server
+	client  *dbus.Conn
+	player  dbus.BusObject
+	signals chan *dbus.Signal
+}
+
+func newTestBus(t *testing.T) *testBus {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	socket := filepath.Join(dir, "bus")
+	if err := os.WriteFile(config, []byte(strings.Replace(busConfig, "%s", socket, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read bus address: %v", err)
+	}
+	address = strings.TrimSpace(address)
+
+	serverConn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("connect server: %v", err)
+	}
+	s, err := newServer(serverConn)
+	if err != nil {
+		t.Fatalf("newServer: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	client, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("connect client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.AddMatchSignal(dbus.WithMatchSender(serverConn.Names()[0])); err != nil {
+		t.Fatalf("match signals: %v", err)
+	}
+	signals := make(chan *dbus.Signal, 32)
+	client.Signal(signals)
+
+	return &testBus{
+		t:       t,
+		server:  s,
+		client:  client,
+		player:  client.Object(serverConn.Names()[0], objectPath),
+		signals: signals,
+	}
+}
+
+// call calls a method on the server's object
+func (b *testBus) call(method string, args ...any) error {
+	return b.player.Call(method, 0, args...).Err
+}
+
+// get returns a property of the server's object
+func (b *testBus) get(iface, name string) any {
+	b.t.Helper()
+	v, err := b.player.GetProperty(iface + "." + name)
+	if err != nil {
+		b.t.Fatalf("get %s: %v", name, err)
+	}
+	return v.Value()
+}
+
+// signal returns the next signal the server sent
+func (b *testBus) signal() *dbus.Signal {
+	b.t.Helper()
+	for {
+		select {
+		case sig := <-b.signals:
+			if sig.Path == objectPath {
+				return sig
+			}
+		case <-time.After(2 * time.Second):
+			b.t.Fatal("timed out waiting for a signal")
+			return nil
+		}
+	}
+}
+
+func nextCommand(t *testing.T, s *Server) Command {
+	t.Helper()
+	select {
+	case cmd := <-s.Commands():
+		return cmd
+	case <-time.After(2 * time.Second):
+		t.Fatal("no command received")
+	}
+	return Command{}
+}
+
+func TestServer_SendMergesWaitingCommands(t *testing.T) {
+	s := &Server{wake: make(chan struct{}, 1)}
+	s.send(Command{Kind: CommandSeek, Position: time.Second})
+	s.send(Command{Kind: CommandSeek, Position: 2 * time.Second})
+	s.send(Command{Kind: CommandVolume, Volume: 0.2})
+	s.send(Command{Kind: CommandVolume, Volume: 0.7})
+	s.send(Command{Kind: CommandNext})
+	s.send(Command{Kind: CommandNext})
+
+	want := []Command{
+		{Kind: CommandSeek, Position: 3 * time.Second},
+		{Kind: CommandVolume, Volume: 0.7},
+		{Kind: CommandNext},
+		{Kind: CommandNext},
+	}
+	for i, w := range want {
+		got, ok := s.next()
+		if !ok || got != w {
+			t.Fatalf("command %d = %+v, %v; want %+v", i, got, ok, w)
+		}
+	}
+	if _, ok := s.next(); ok {
+		t.Error("commands left over")
+	}
+
+	for i := 0; i < maxPending+5; i++ {
+		s.send(Command{Kind: CommandKind(i % 2)})
+	}
+	if len(s.pending) != maxPending {
+		t.Errorf("%d commands waiting, want %d", len(s.pending), maxPending)
+	}
+}
+
+func TestTrackPath(t *testing.T) {
+	if got := trackPath(nil); got != noTrackPath {
+		t.Errorf("trackPath(nil) = %s", got)
+	}
+	if got := trackPath(&api.Track{ID: "ab-1"}); got != trackPrefix+"ab_2d1" || !got.IsValid() {
+		t.Errorf("trackPath = %s", got)
+	}
+}
+
+func TestServer_Commands(t *testing.T) {
+	bus := newTestBus(t)
+
+	tests := []struct {
+		method string
+		args   []any
+		want   Command
+	}{
+		{ifacePlayer + ".PlayPause", nil, Command{Kind: CommandPlayPause}},
+		{ifacePlayer + ".Next", nil, Command{Kind: CommandNext}},
+		{ifacePlayer + ".Seek", []any{int64(-5_000_000)}, Command{Kind: CommandSeek, Position: -5 * time.Second}},
+		{ifaceRoot + ".Quit", nil, Command{Kind: CommandQuit}},
+	}
+	for _, tt := range tests {
+		if err := bus.call(tt.method, tt.args...); err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		if got := nextCommand(t, bus.server); got != tt.want {
+			t.Errorf("%s: command = %+v, want %+v", tt.method, got, tt.want)
+		}
+	}
+
+	if err := bus.player.SetProperty(ifacePlayer+".Volume", dbus.MakeVariant(1.5)); err != nil {
+		t.Fatalf("set Volume: %v", err)
+	}
+	if got := nextCommand(t, bus.server); got != (Command{Kind: CommandVolume, Volume: 1}) {
+		t.Errorf("Set Volume: command = %+v", got)
+	}
+	if err := bus.player.SetProperty(ifacePlayer+".Rate", dbus.MakeVariant(2.0)); err == nil {
+		t.Error("set Rate succeeded")
+	}
+	if err := bus.call(ifacePlayer+".OpenUri", "file:///a.mp3"); err == nil {
+		t.Error("OpenUri succeeded")
+	}
+	if err := bus.call(ifacePlayer + ".Bogus"); err == nil {
+		t.Error("unknown method succeeded")
+	}
+}
+
+func TestServer_Metadata(t *testing.T) {
+	bus := newTestBus(t)
+	track := &api.Track{ID: "ab-1", Title: "Song", Artist: "Band", Album: "Record", FilePath: "/music/a song.mp3"}
+	bus.server.Update(Status{Track: track, Playback: api.StatusPlaying, Length: 3 * time.Minute, Volume: 0.5, CanNext: true})
+
+	if got := bus.get(ifacePlayer, "PlaybackStatus"); got != "Playing" {
+		t.Errorf("PlaybackStatus = %#v", got)
+	}
+	for i := 0; i < 10; i++ {
+		sig := bus.signal()
+		if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" {
+			t.Fatalf("signal = %+v", sig)
+		}
+		if _, ok := sig.Body[1].(map[string]dbus.Variant)["Position"]; ok {
+			t.Error("Position included in PropertiesChanged")
+		}
+		if len(bus.signals) == 0 {
+			break
+		}
+	}
+
+	meta := bus.get(ifacePlayer, "Metadata").(map[string]dbus.Variant)
+	if meta["xesam:title"].Value() != "Song" || meta["xesam:album"].Value() != "Record" {
+		t.Errorf("metadata = %#v", meta)
+	}
+	if artists := meta["xesam:artist"].Value().([]string); len(artists) != 1 || artists[0] != "Band" {
+		t.Errorf("artists = %#v", artists)
+	}
+	if meta["mpris:length"].Value() != int64(180_000_000) {
+		t.Errorf("length = %#v", meta["mpris:length"])
+	}
+	if meta["mpris:trackid"].Value() != trackPath(track) {
+		t.Errorf("trackid = %#v", meta["mpris:trackid"])
+	}
+	if meta["xesam:url"].Value() != "file:///music/a%20song.mp3" {
+		t.Errorf("url = %#v", meta["xesam:url"])
+	}
+
+	// SetPosition only applies to the current track
+	bus.call(ifacePlayer+".SetPosition", dbus.ObjectPath(trackPrefix+"other"), int64(1_000_000))
+	bus.call(ifacePlayer+".SetPosition", trackPath(track), int64(2_000_000))
+	if got := nextCommand(t, bus.server); got != (Command{Kind: CommandSetPosition, Position: 2 * time.Second}) {
+		t.Errorf("SetPosition: command = %+v", got)
+	}
+}
+
+func TestServer_Seeked(t *testing.T) {
+	bus := newTestBus(t)
+	track := &api.Track{ID: "t"}
+	bus.server.Update(Status{Track: track, Playback: api.StatusPaused, Position: 10 * time.Second, Length: time.Minute})
+	bus.server.Update(Status{Track: track, Playback: api.StatusPaused, Position: 10 * time.Second, Length: time.Minute})
+	bus.server.Update(Status{Track: track, Playback: api.StatusPaused, Position: 40 * time.Second, Length: time.Minute})
+
+	for {
+		sig := bus.signal()
+		if sig.Name == ifacePlayer+".Seeked" {
+			if sig.Body[0] != int64(40_000_000) {
+				t.Errorf("Seeked to %v", sig.Body[0])
+			}
+			return
+		}
+	}
+}
+
+func TestServer_RootAndIntrospection(t *testing.T) {
+	bus := newTestBus(t)
+	if got := bus.get(ifaceRoot, "Identity"); got != Identity {
+		t.Errorf("Identity = %#v", got)
+	}
+	if got := bus.get(ifaceRoot, "CanQuit"); got != true {
+		t.Errorf("CanQuit = %#v", got)
+	}
+
+	var xml string
+	if err := bus.player.Call("org.freedesktop.DBus.Introspectable.Introspect", 0).Store(&xml); err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	for _, want := range []string{`name="` + ifacePlayer + `"`, `name="SetPosition"`, `name="Seeked"`, `name="Volume" type="d" access="readwrite"`} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("introspection lacks %s", want)
+		}
+	}
+}
+
+func TestServer_CommandsClosedWithConnection(t *testing.T) {
+	bus := newTestBus(t)
+	bus.server.Close()
+	select {
+	case _, ok := <-bus.server.Commands():
+		if ok {
+			t.Error("command received after Close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Commands not closed")
+	}
+}
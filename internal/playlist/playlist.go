@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -157,6 +158,86 @@ func (m *Manager) RemoveTrack(playlistID, trackID string) error {
 	return m.savePlaylist(playlist)
 }
 
+// RemoveTrackAt removes the track at index from a playlist
+func (m *Manager) RemoveTrackAt(playlistID string, index int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	playlist, exists := m.playlists[playlistID]
+	if !exists {
+		return playerrors.ErrPlaylistNotFound
+	}
+	if index < 0 || index >= len(playlist.Tracks) {
+		return playerrors.ErrTrackNotFound
+	}
+
+	playlist.Tracks = slices.Delete(playlist.Tracks, index, index+1)
+	playlist.UpdatedAt = time.Now()
+	return m.savePlaylist(playlist)
+}
+
+// MoveTrack moves the track at from in a playlist to position to
+func (m *Manager) MoveTrack(playlistID string, from, to int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	playlist, exists := m.playlists[playlistID]
+	if !exists {
+		return playerrors.ErrPlaylistNotFound
+	}
+	if from < 0 || from >= len(playlist.Tracks) || to < 0 || to >= len(playlist.Tracks) {
+		return playerrors.ErrTrackNotFound
+	}
+
+	track := playlist.Tracks[from]
+	playlist.Tracks = slices.Insert(slices.Delete(playlist.Tracks, from, from+1), to, track)
+	playlist.UpdatedAt = time.Now()
+	return m.savePlaylist(playlist)
+}
+
+// SetTracks replaces a playlist's tracks, e.g. to put back the ones it
+// had before an edit
+func (m *Manager) SetTracks(playlistID string, tracks []api.Track) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	playlist, exists := m.playlists[playlistID]
+	if !exists {
+		return playerrors.ErrPlaylistNotFound
+	}
+
+	playlist.Tracks = slices.Clone(tracks)
+	playlist.UpdatedAt = time.Now()
+	return m.savePlaylist(playlist)
+}
+
+// UpdateTrackPath points every playlist entry for oldID at track's new
+// file path and ID, e.g. after the file was moved, and saves the affected
+// playlists
+func (m *Manager) UpdateTrackPath(oldID string, track *api.Track) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, playlist := range m.playlists {
+		changed := false
+		for i := range playlist.Tracks {
+			if playlist.Tracks[i].ID == oldID {
+				playlist.Tracks[i].ID = track.ID
+				playlist.Tracks[i].FilePath = track.FilePath
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		playlist.UpdatedAt = time.Now()
+		if err := m.savePlaylist(playlist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // savePlaylist saves a playlist to disk
 func (m *Manager) savePlaylist(playlist *api.Playlist) error {
 	if err := os.MkdirAll(m.basePath, 0755); err != nil {
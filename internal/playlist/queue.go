@@ -3,6 +3,7 @@ package playlist
 import (
 	"errors"
 	"math/rand"
+	"slices"
 	"sync"
 
 	"github.com/jscyril/golang_music_player/api"
@@ -15,6 +16,10 @@ type Queue struct {
 	repeatMode api.RepeatMode
 	shuffle    bool
 	original   []*api.Track // Original order before shuffle
+	nextCount  int          // Tracks inserted via InsertNext since the index last moved
+	pinned     *api.Track   // Kept right after the current track until it plays
+	spread     int          // Smart shuffle: keep an artist or album out of the next spread tracks; 0 is plain shuffle
+	rng        *rand.Rand   // Shuffle source; nil uses the global source
 	mu         sync.RWMutex
 }
 
@@ -35,7 +40,133 @@ func (q *Queue) Add(tracks ...*api.Track) {
 	q.tracks = append(q.tracks, tracks...)
 }
 
-// Set replaces the entire queue with new tracks
+// InsertNext places a track right after the current one, leaving the rest of
+// the queue intact. Repeated calls stack in call order, each after the
+// previously inserted track, until the current index moves.
+func (q *Queue) InsertNext(track *api.Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tracks) == 0 {
+		q.tracks = append(q.tracks, track)
+		q.index = 0
+		return
+	}
+
+	// Stack after the previously inserted tracks, but never ahead of a pin
+	pos := q.index + 1 + q.nextCount
+	if q.pinned != nil {
+		pos++
+	}
+	if pos > len(q.tracks) {
+		pos = len(q.tracks)
+	}
+	q.tracks = insertTrack(q.tracks, pos, track)
+	q.nextCount++
+
+	// Keep the pre-shuffle order in sync so unshuffling doesn't drop the track
+	if q.original != nil {
+		q.original = append(q.original, track)
+	}
+}
+
+// PlayNow inserts a track right after the current one and makes it current,
+// keeping the rest of the queue in order
+func (q *Queue) PlayNow(track *api.Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tracks) == 0 {
+		q.tracks = append(q.tracks, track)
+		q.index = 0
+		return
+	}
+
+	q.tracks = insertTrack(q.tracks, q.index+1, track)
+	q.index++
+	q.nextCount = 0
+	if q.original != nil {
+		q.original = append(q.original, track)
+	}
+	q.keepPinned()
+}
+
+// Pin inserts a track right after the current one and keeps it there,
+// whatever else is queued, moved or shuffled, until it plays or is unpinned.
+// Only one track is pinned at a time; pinning another leaves the previous
+// one where it is.
+func (q *Queue) Pin(track *api.Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tracks) == 0 {
+		// Nothing is playing ahead of it, so it simply becomes current
+		q.tracks = append(q.tracks, track)
+		q.index = 0
+		return
+	}
+
+	q.tracks = insertTrack(q.tracks, q.index+1, track)
+	q.pinned = track
+	if q.original != nil {
+		q.original = append(q.original, track)
+	}
+}
+
+// Unpin releases the pinned track, leaving it at its current position
+func (q *Queue) Unpin() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pinned = nil
+}
+
+// Pinned returns the pinned track, or nil if none
+func (q *Queue) Pinned() *api.Track {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.pinned
+}
+
+// keepPinned moves the pinned track back to right after the current one
+// after the order or index changed, and releases it once it is current.
+// Callers must hold the write lock.
+func (q *Queue) keepPinned() {
+	if q.pinned == nil {
+		return
+	}
+
+	pos := -1
+	for i, t := range q.tracks {
+		if t == q.pinned {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 || pos == q.index {
+		q.pinned = nil
+		return
+	}
+	if pos == q.index+1 {
+		return
+	}
+
+	q.tracks = append(q.tracks[:pos], q.tracks[pos+1:]...)
+	if pos < q.index {
+		q.index--
+	}
+	q.tracks = insertTrack(q.tracks, q.index+1, q.pinned)
+}
+
+// insertTrack inserts track into tracks at pos
+func insertTrack(tracks []*api.Track, pos int, track *api.Track) []*api.Track {
+	tracks = append(tracks, nil)
+	copy(tracks[pos+1:], tracks[pos:])
+	tracks[pos] = track
+	return tracks
+}
+
+// Set replaces the entire queue with new tracks, in order. The new queue is
+// not shuffled; call Shuffle to shuffle it.
 func (q *Queue) Set(tracks []*api.Track) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -43,7 +174,10 @@ func (q *Queue) Set(tracks []*api.Track) {
 	q.tracks = make([]*api.Track, len(tracks))
 	copy(q.tracks, tracks)
 	q.original = nil
+	q.shuffle = false
 	q.index = 0
+	q.nextCount = 0
+	q.pinned = nil
 }
 
 // Clear removes all tracks from the queue
@@ -53,7 +187,10 @@ func (q *Queue) Clear() {
 
 	q.tracks = make([]*api.Track, 0)
 	q.original = nil
+	q.shuffle = false
 	q.index = 0
+	q.nextCount = 0
+	q.pinned = nil
 }
 
 // Current returns the current track
@@ -67,58 +204,99 @@ func (q *Queue) Current() *api.Track {
 	return q.tracks[q.index]
 }
 
-// Next moves to the next track and returns it
+// Next moves to the next track and returns it, as when a track finishes:
+// RepeatOne stays on the current track and RepeatAll wraps to the start
 func (q *Queue) Next() *api.Track {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return q.next(q.repeatMode)
+}
+
+// Skip moves to the next track on request and returns it. Unlike Next it
+// always advances: under RepeatOne it moves on, wrapping like RepeatAll.
+func (q *Queue) Skip() *api.Track {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.next(manualRepeat(q.repeatMode))
+}
 
+// next advances under mode. Callers must hold the lock.
+func (q *Queue) next(mode api.RepeatMode) *api.Track {
 	if len(q.tracks) == 0 {
 		return nil
 	}
 
-	switch q.repeatMode {
+	switch mode {
 	case api.RepeatOne:
 		// Stay on current track
 		return q.tracks[q.index]
 	case api.RepeatAll:
+		q.nextCount = 0
 		q.index = (q.index + 1) % len(q.tracks)
 	default: // RepeatNone
 		if q.index < len(q.tracks)-1 {
+			q.nextCount = 0
 			q.index++
 		} else {
 			return nil // End of queue
 		}
 	}
 
+	q.keepPinned()
 	return q.tracks[q.index]
 }
 
-// Previous moves to the previous track and returns it
+// Previous moves to the previous track and returns it. RepeatOne stays on
+// the current track.
 func (q *Queue) Previous() *api.Track {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return q.previous(q.repeatMode)
+}
 
+// SkipBack moves to the previous track on request and returns it. Under
+// RepeatOne it moves back, wrapping like RepeatAll.
+func (q *Queue) SkipBack() *api.Track {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.previous(manualRepeat(q.repeatMode))
+}
+
+// previous steps back under mode. Callers must hold the lock.
+func (q *Queue) previous(mode api.RepeatMode) *api.Track {
 	if len(q.tracks) == 0 {
 		return nil
 	}
 
-	switch q.repeatMode {
+	switch mode {
 	case api.RepeatOne:
 		return q.tracks[q.index]
 	case api.RepeatAll:
+		q.nextCount = 0
 		q.index--
 		if q.index < 0 {
 			q.index = len(q.tracks) - 1
 		}
 	default:
 		if q.index > 0 {
+			q.nextCount = 0
 			q.index--
 		}
 	}
 
+	q.keepPinned()
 	return q.tracks[q.index]
 }
 
+// manualRepeat is the mode a user's skip moves under: repeating one track
+// only applies when it finishes, so skipping still moves through the queue
+func manualRepeat(mode api.RepeatMode) api.RepeatMode {
+	if mode == api.RepeatOne {
+		return api.RepeatAll
+	}
+	return mode
+}
+
 // JumpTo jumps to a specific index
 func (q *Queue) JumpTo(index int) error {
 	q.mu.Lock()
@@ -128,7 +306,11 @@ func (q *Queue) JumpTo(index int) error {
 		return errors.New("index out of bounds")
 	}
 
+	if index != q.index {
+		q.nextCount = 0
+	}
 	q.index = index
+	q.keepPinned()
 	return nil
 }
 
@@ -143,6 +325,11 @@ func (q *Queue) Remove(index int) error {
 
 	q.tracks = append(q.tracks[:index], q.tracks[index+1:]...)
 
+	// Removing one of the stacked play-next tracks shrinks the stack
+	if index > q.index && index <= q.index+q.nextCount {
+		q.nextCount--
+	}
+
 	// Adjust current index if needed
 	if q.index > index {
 		q.index--
@@ -150,15 +337,54 @@ func (q *Queue) Remove(index int) error {
 		q.index = len(q.tracks) - 1
 	}
 
+	q.keepPinned()
+	return nil
+}
+
+// Move moves the track at from to position to, shifting the tracks in
+// between. The current track keeps playing: the index follows it to its
+// new position. Moving the pinned track releases the pin so it stays where
+// it was put, and any tracks stacked by InsertNext are no longer treated
+// as a stack.
+func (q *Queue) Move(from, to int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if from < 0 || from >= len(q.tracks) || to < 0 || to >= len(q.tracks) {
+		return errors.New("index out of bounds")
+	}
+	if from == to {
+		return nil
+	}
+
+	track := q.tracks[from]
+	q.tracks = append(q.tracks[:from], q.tracks[from+1:]...)
+	q.tracks = insertTrack(q.tracks, to, track)
+
+	switch {
+	case q.index == from:
+		q.index = to
+	case from < q.index && to >= q.index:
+		q.index--
+	case from > q.index && to <= q.index:
+		q.index++
+	}
+	q.nextCount = 0
+	if track == q.pinned {
+		q.pinned = nil
+	}
+	q.keepPinned()
 	return nil
 }
 
-// Shuffle shuffles the queue (Fisher-Yates algorithm)
+// Shuffle shuffles the queue (Fisher-Yates algorithm). A queue too short
+// to shuffle is just put in shuffle mode.
 func (q *Queue) Shuffle() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	if len(q.tracks) <= 1 {
+		q.shuffle = true
 		return
 	}
 
@@ -174,7 +400,7 @@ func (q *Queue) Shuffle() {
 	// Shuffle all tracks
 	n := len(q.tracks)
 	for i := n - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := q.intn(i + 1)
 		q.tracks[i], q.tracks[j] = q.tracks[j], q.tracks[i]
 	}
 
@@ -185,8 +411,38 @@ func (q *Queue) Shuffle() {
 			break
 		}
 	}
+	if q.spread > 0 {
+		spreadTracks(q.tracks, q.spread)
+	}
 	q.index = 0
+	q.nextCount = 0
 	q.shuffle = true
+	q.keepPinned()
+}
+
+// SetSmartShuffle makes Shuffle keep tracks by the same artist or from the
+// same album at least spread tracks apart where possible. 0 restores plain
+// shuffle. It applies from the next Shuffle.
+func (q *Queue) SetSmartShuffle(spread int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.spread = max(spread, 0)
+}
+
+// SetShuffleSeed makes shuffles reproducible from seed
+func (q *Queue) SetShuffleSeed(seed int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rng = rand.New(rand.NewSource(seed))
+}
+
+// intn returns a random int in [0, n) from the queue's source.
+// Callers must hold the lock.
+func (q *Queue) intn(n int) int {
+	if q.rng != nil {
+		return q.rng.Intn(n)
+	}
+	return rand.Intn(n)
 }
 
 // Unshuffle restores original order
@@ -195,6 +451,7 @@ func (q *Queue) Unshuffle() {
 	defer q.mu.Unlock()
 
 	if q.original == nil {
+		q.shuffle = false
 		return
 	}
 
@@ -203,6 +460,7 @@ func (q *Queue) Unshuffle() {
 	q.tracks = q.original
 	q.original = nil
 	q.shuffle = false
+	q.nextCount = 0
 
 	// Find new index of current track
 	for i, track := range q.tracks {
@@ -211,6 +469,7 @@ func (q *Queue) Unshuffle() {
 			break
 		}
 	}
+	q.keepPinned()
 }
 
 // SetRepeatMode sets the repeat mode
@@ -234,6 +493,85 @@ func (q *Queue) IsShuffled() bool {
 	return q.shuffle
 }
 
+// QueueState is a queue's tracks and place in them as Snapshot saw them,
+// for Restore to put back
+type QueueState struct {
+	tracks    []*api.Track
+	index     int
+	shuffle   bool
+	original  []*api.Track
+	nextCount int
+	pinned    *api.Track
+}
+
+// Snapshot captures the queue's tracks, order, current track, shuffle and
+// pin. The repeat mode and shuffle settings are left out.
+func (q *Queue) Snapshot() QueueState {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return QueueState{
+		tracks:    slices.Clone(q.tracks),
+		index:     q.index,
+		shuffle:   q.shuffle,
+		original:  slices.Clone(q.original),
+		nextCount: q.nextCount,
+		pinned:    q.pinned,
+	}
+}
+
+// Restore puts the queue back as it was when s was taken
+func (q *Queue) Restore(s QueueState) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tracks = slices.Clone(s.tracks)
+	if q.tracks == nil {
+		q.tracks = make([]*api.Track, 0)
+	}
+	q.index = s.index
+	q.shuffle = s.shuffle
+	q.original = slices.Clone(s.original)
+	q.nextCount = s.nextCount
+	q.pinned = s.pinned
+}
+
+// Tracks returns the tracks in play order, shuffled if the queue was
+func (s QueueState) Tracks() []*api.Track {
+	return s.tracks
+}
+
+// Index returns the current track's position in Tracks
+func (s QueueState) Index() int {
+	return s.index
+}
+
+// Shuffled reports whether the queue was shuffled
+func (s QueueState) Shuffled() bool {
+	return s.shuffle
+}
+
+// Original returns the order a shuffled queue unshuffles back to, or nil
+func (s QueueState) Original() []*api.Track {
+	return s.original
+}
+
+// SetShuffled replaces the queue with a shuffled one that plays tracks in
+// order from index and unshuffles back to original, e.g. to restore a
+// saved queue without shuffling it again
+func (q *Queue) SetShuffled(tracks, original []*api.Track, index int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tracks = slices.Clone(tracks)
+	if q.tracks == nil {
+		q.tracks = make([]*api.Track, 0)
+	}
+	q.original = slices.Clone(original)
+	q.shuffle = true
+	q.index = max(min(index, len(q.tracks)-1), 0)
+	q.nextCount = 0
+	q.pinned = nil
+}
+
 // GetAll returns a copy of all tracks in the queue
 func (q *Queue) GetAll() []*api.Track {
 	q.mu.RLock()
@@ -258,6 +596,27 @@ func (q *Queue) Index() int {
 	return q.index
 }
 
+// PeekNext returns the track Next would advance to without moving the index
+func (q *Queue) PeekNext() *api.Track {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if len(q.tracks) == 0 {
+		return nil
+	}
+
+	switch q.repeatMode {
+	case api.RepeatOne:
+		return q.tracks[q.index]
+	case api.RepeatAll:
+		return q.tracks[(q.index+1)%len(q.tracks)]
+	}
+	if q.index < len(q.tracks)-1 {
+		return q.tracks[q.index+1]
+	}
+	return nil
+}
+
 // HasNext returns true if there's a next track
 func (q *Queue) HasNext() bool {
 	q.mu.RLock()
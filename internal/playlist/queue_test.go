@@ -0,0 +1,424 @@
+package playlist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func queueTracks(ids ...string) []*api.Track {
+	tracks := make([]*api.Track, len(ids))
+	for i, id := range ids {
+		tracks[i] = &api.Track{ID: id, Title: id}
+	}
+	return tracks
+}
+
+func queueIDs(q *Queue) []string {
+	var ids []string
+	for _, t := range q.GetAll() {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueue_InsertNextStacks(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c"))
+	q.JumpTo(1)
+
+	for _, tr := range queueTracks("x", "y") {
+		q.InsertNext(tr)
+	}
+
+	if want := []string{"a", "b", "x", "y", "c"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+	if q.Index() != 1 || q.Current().ID != "b" {
+		t.Errorf("current = %d (%s), want 1 (b)", q.Index(), q.Current().ID)
+	}
+	if next := q.PeekNext(); next == nil || next.ID != "x" {
+		t.Errorf("PeekNext = %v, want x", next)
+	}
+}
+
+func TestQueue_InsertNextResetsAfterAdvance(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b"))
+	q.InsertNext(&api.Track{ID: "x"})
+
+	if next := q.Next(); next.ID != "x" {
+		t.Fatalf("Next = %s, want x", next.ID)
+	}
+
+	// The stack starts over after the current track
+	q.InsertNext(&api.Track{ID: "y"})
+	if want := []string{"a", "x", "y", "b"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+}
+
+func TestQueue_InsertNextEmpty(t *testing.T) {
+	q := NewQueue()
+	q.InsertNext(&api.Track{ID: "x"})
+
+	if cur := q.Current(); cur == nil || cur.ID != "x" {
+		t.Errorf("Current = %v, want x", cur)
+	}
+}
+
+func TestQueue_RemoveStackedTrack(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b"))
+	q.InsertNext(&api.Track{ID: "x"})
+	q.InsertNext(&api.Track{ID: "y"})
+	q.Remove(1) // x
+
+	q.InsertNext(&api.Track{ID: "z"})
+	if want := []string{"a", "y", "z", "b"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+}
+
+func TestQueue_PlayNow(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c"))
+
+	q.PlayNow(&api.Track{ID: "x"})
+
+	if want := []string{"a", "x", "b", "c"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+	if cur := q.Current(); cur == nil || cur.ID != "x" {
+		t.Errorf("current = %v, want x", cur)
+	}
+
+	empty := NewQueue()
+	empty.PlayNow(&api.Track{ID: "y"})
+	if cur := empty.Current(); cur == nil || cur.ID != "y" {
+		t.Errorf("current on empty queue = %v, want y", cur)
+	}
+}
+
+func TestQueue_MoveKeepsCurrent(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     []string
+		current  int
+	}{
+		{from: 3, to: 1, want: []string{"a", "d", "b", "c", "e"}, current: 2}, // ahead of current
+		{from: 0, to: 4, want: []string{"b", "c", "d", "e", "a"}, current: 0}, // from before to after
+		{from: 1, to: 3, want: []string{"a", "c", "d", "b", "e"}, current: 3}, // the current track
+		{from: 2, to: 4, want: []string{"a", "b", "d", "e", "c"}, current: 1}, // after current
+	}
+	for _, tt := range tests {
+		q := NewQueue()
+		q.Set(queueTracks("a", "b", "c", "d", "e"))
+		q.JumpTo(1)
+
+		if err := q.Move(tt.from, tt.to); err != nil {
+			t.Fatalf("Move(%d, %d): %v", tt.from, tt.to, err)
+		}
+		if !equalIDs(queueIDs(q), tt.want) {
+			t.Errorf("Move(%d, %d): queue = %v, want %v", tt.from, tt.to, queueIDs(q), tt.want)
+		}
+		if q.Index() != tt.current || q.Current().ID != "b" {
+			t.Errorf("Move(%d, %d): current = %d (%s), want %d (b)", tt.from, tt.to, q.Index(), q.Current().ID, tt.current)
+		}
+	}
+
+	q := NewQueue()
+	q.Set(queueTracks("a", "b"))
+	if err := q.Move(0, 2); err == nil {
+		t.Error("Move out of bounds: expected an error")
+	}
+}
+
+func TestQueue_MovePinned(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c"))
+	q.Pin(&api.Track{ID: "p"})
+
+	// Moving another track ahead of the pin leaves the pin next
+	q.Move(3, 1)
+	if want := []string{"a", "p", "c", "b"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+
+	// Moving the pinned track itself releases it
+	q.Move(1, 3)
+	if want := []string{"a", "c", "b", "p"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+	if q.Pinned() != nil {
+		t.Errorf("Pinned = %v, want nil after moving it", q.Pinned())
+	}
+}
+
+func TestQueue_PinStaysNext(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c", "d"))
+	pin := &api.Track{ID: "p"}
+	q.Pin(pin)
+
+	q.InsertNext(&api.Track{ID: "x"})
+	q.Add(&api.Track{ID: "e"})
+	if want := []string{"a", "p", "x", "b", "c", "d", "e"}; !equalIDs(queueIDs(q), want) {
+		t.Errorf("queue = %v, want %v", queueIDs(q), want)
+	}
+
+	q.Shuffle()
+	if next := q.PeekNext(); next != pin {
+		t.Errorf("after shuffle PeekNext = %v, want pinned track", next.ID)
+	}
+	q.Unshuffle()
+	if next := q.PeekNext(); next != pin {
+		t.Errorf("after unshuffle PeekNext = %v, want pinned track", next.ID)
+	}
+
+	q.JumpTo(q.Len() - 1)
+	if next := q.PeekNext(); next != nil && next != pin {
+		t.Errorf("after jump PeekNext = %v, want pinned track", next.ID)
+	}
+	if q.Pinned() != pin {
+		t.Fatal("pin lost after jump")
+	}
+
+	if cur := q.Next(); cur != pin {
+		t.Fatalf("Next = %v, want pinned track", cur.ID)
+	}
+	if q.Pinned() != nil {
+		t.Error("pin should be released once the track plays")
+	}
+}
+
+func TestQueue_SmartShuffleSpreadsArtists(t *testing.T) {
+	var tracks []*api.Track
+	for i, artist := range []string{"a", "a", "a", "b", "b", "b", "c", "c", "c", "d", "d", "d"} {
+		tracks = append(tracks, &api.Track{ID: fmt.Sprint(i), Artist: artist})
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		q := NewQueue()
+		q.Set(tracks)
+		q.SetSmartShuffle(2)
+		q.SetShuffleSeed(seed)
+		q.Shuffle()
+
+		got := q.GetAll()
+		for i := 1; i < len(got); i++ {
+			for gap := 1; gap <= 2 && gap <= i; gap++ {
+				if got[i].Artist == got[i-gap].Artist {
+					t.Fatalf("seed %d: %s at %d and %d", seed, got[i].Artist, i-gap, i)
+				}
+			}
+		}
+	}
+}
+
+func TestQueue_SmartShuffleSeeded(t *testing.T) {
+	order := func() []string {
+		q := NewQueue()
+		q.Set(queueTracks("a", "b", "c", "d", "e", "f", "g"))
+		q.SetSmartShuffle(3)
+		q.SetShuffleSeed(42)
+		q.Shuffle()
+		return queueIDs(q)
+	}
+	if first, second := order(), order(); !equalIDs(first, second) {
+		t.Errorf("same seed gave %v and %v", first, second)
+	}
+}
+
+func TestQueue_SmartShuffleSingleArtist(t *testing.T) {
+	tracks := queueTracks("a", "b", "c", "d", "e")
+	for _, tr := range tracks {
+		tr.Artist = "solo"
+	}
+
+	q := NewQueue()
+	q.Set(tracks)
+	q.SetSmartShuffle(2)
+	q.Shuffle()
+
+	if q.Len() != len(tracks) {
+		t.Fatalf("Len = %d, want %d", q.Len(), len(tracks))
+	}
+	seen := make(map[string]bool)
+	for _, id := range queueIDs(q) {
+		seen[id] = true
+	}
+	if len(seen) != len(tracks) {
+		t.Errorf("shuffle lost tracks: %v", queueIDs(q))
+	}
+}
+
+func TestQueue_ShuffleVisitsEachTrackOnce(t *testing.T) {
+	q := NewQueue()
+	q.SetShuffleSeed(7)
+	q.Set(queueTracks("a", "b", "c", "d", "e", "f"))
+	q.JumpTo(2)
+	q.Shuffle()
+
+	seen := map[string]bool{q.Current().ID: true}
+	if q.Current().ID != "c" {
+		t.Fatalf("current after shuffle = %s, want c", q.Current().ID)
+	}
+	for next := q.Next(); next != nil; next = q.Next() {
+		if seen[next.ID] {
+			t.Fatalf("%s played twice before the queue was exhausted", next.ID)
+		}
+		seen[next.ID] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("visited %d tracks, want 6", len(seen))
+	}
+}
+
+func TestQueue_UnshuffleResumesFromCurrent(t *testing.T) {
+	q := NewQueue()
+	q.SetShuffleSeed(3)
+	q.Set(queueTracks("a", "b", "c", "d", "e"))
+	q.Shuffle()
+	q.Next()
+	current := q.Current().ID
+
+	q.Unshuffle()
+	if q.IsShuffled() || q.Current().ID != current {
+		t.Fatalf("after unshuffle: shuffled %v, current %s, want %s", q.IsShuffled(), q.Current().ID, current)
+	}
+	want := []string{"a", "b", "c", "d", "e"}
+	if !equalIDs(queueIDs(q), want) {
+		t.Fatalf("order = %v, want %v", queueIDs(q), want)
+	}
+	if i := q.Index(); i < len(want)-1 && q.PeekNext().ID != want[i+1] {
+		t.Errorf("next after %s = %s, want %s", current, q.PeekNext().ID, want[i+1])
+	}
+}
+
+func TestQueue_SetClearsShuffle(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c"))
+	q.Shuffle()
+
+	q.Set(queueTracks("x", "y"))
+	if q.IsShuffled() {
+		t.Error("a new queue should not report being shuffled")
+	}
+	q.Shuffle()
+	if !q.IsShuffled() {
+		t.Error("shuffling the new queue should work")
+	}
+}
+
+func TestQueue_ShuffleShortQueue(t *testing.T) {
+	q := NewQueue()
+	q.Shuffle()
+	if !q.IsShuffled() {
+		t.Fatal("an empty queue should still switch to shuffle mode")
+	}
+	q.Set(queueTracks("a"))
+	q.Shuffle()
+	if !q.IsShuffled() {
+		t.Error("a one-track queue should still switch to shuffle mode")
+	}
+	q.Unshuffle()
+	if q.IsShuffled() || q.Current().ID != "a" {
+		t.Errorf("after unshuffle: shuffled %v, current %s", q.IsShuffled(), q.Current().ID)
+	}
+}
+
+func TestQueue_RepeatOneSkip(t *testing.T) {
+	q := NewQueue()
+	q.Set(queueTracks("a", "b", "c"))
+	q.SetRepeatMode(api.RepeatOne)
+
+	// Finishing a track replays it
+	if next := q.Next(); next.ID != "a" {
+		t.Fatalf("Next under repeat one = %s, want a", next.ID)
+	}
+	// A manual skip still moves on, wrapping at either end
+	if next := q.Skip(); next.ID != "b" {
+		t.Errorf("Skip = %s, want b", next.ID)
+	}
+	q.JumpTo(2)
+	if next := q.Skip(); next.ID != "a" {
+		t.Errorf("Skip at the end = %s, want a", next.ID)
+	}
+	if prev := q.SkipBack(); prev.ID != "c" {
+		t.Errorf("SkipBack at the start = %s, want c", prev.ID)
+	}
+	if q.GetRepeatMode() != api.RepeatOne {
+		t.Error("skipping should not change the repeat mode")
+	}
+}
+
+func TestQueue_RepeatModesAtEnd(t *testing.T) {
+	tests := []struct {
+		mode api.RepeatMode
+		want string // "" for end of queue
+	}{
+		{api.RepeatNone, ""},
+		{api.RepeatAll, "a"},
+		{api.RepeatOne, "c"},
+	}
+	for _, tt := range tests {
+		q := NewQueue()
+		q.Set(queueTracks("a", "b", "c"))
+		q.JumpTo(2)
+		q.SetRepeatMode(tt.mode)
+
+		got := ""
+		if next := q.Next(); next != nil {
+			got = next.ID
+		}
+		if got != tt.want {
+			t.Errorf("mode %d: Next at the end = %q, want %q", tt.mode, got, tt.want)
+		}
+		if skip := q.Skip(); tt.mode == api.RepeatNone && skip != nil {
+			t.Errorf("mode %d: Skip at the end = %s, want none", tt.mode, skip.ID)
+		}
+	}
+}
+
+func TestQueue_SnapshotRestore(t *testing.T) {
+	q := NewQueue()
+	q.SetShuffleSeed(1)
+	q.Set(queueTracks("a", "b", "c", "d"))
+	q.JumpTo(1)
+	q.Shuffle()
+	shuffled := queueIDs(q)
+	saved := q.Snapshot()
+
+	q.Clear()
+	q.Restore(saved)
+	if got := queueIDs(q); !equalIDs(got, shuffled) || !q.IsShuffled() || q.Current().ID != "b" {
+		t.Fatalf("restored %v (shuffled %v, current %v), want %v shuffled on b", got, q.IsShuffled(), q.Current(), shuffled)
+	}
+	// The order from before the shuffle comes back with it
+	q.Unshuffle()
+	if got := queueIDs(q); !equalIDs(got, []string{"a", "b", "c", "d"}) || q.Current().ID != "b" {
+		t.Errorf("unshuffled to %v on %v, want a b c d on b", got, q.Current())
+	}
+
+	// Changing the queue after a snapshot leaves the snapshot alone
+	saved = q.Snapshot()
+	q.Move(0, 3)
+	q.Restore(saved)
+	if got := queueIDs(q); !equalIDs(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("restored %v after a move, want a b c d", got)
+	}
+}
@@ -0,0 +1,373 @@
+package playlist
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// SmartPlaylist is a playlist whose tracks are whichever of the library's
+// match its rule, worked out afresh each time they're asked for
+type SmartPlaylist struct {
+	Name  string `json:"name"`
+	Rule  Rule   `json:"rule"`
+	Sort  string `json:"sort,omitempty"`  // Field to order by; library order if empty
+	Desc  bool   `json:"desc,omitempty"`  // Order by Sort from highest or latest
+	Limit int    `json:"limit,omitempty"` // Most tracks to include; 0 for all
+}
+
+// Rule is a node of a smart playlist's expression tree. A rule with All
+// matches tracks every one of them matches, one with Any tracks at least
+// one matches and one with Not tracks it doesn't. Otherwise it is a
+// condition comparing Field to Value with Op.
+type Rule struct {
+	All []Rule `json:"all,omitempty"`
+	Any []Rule `json:"any,omitempty"`
+	Not *Rule  `json:"not,omitempty"`
+
+	Field string    `json:"field,omitempty"`
+	Op    string    `json:"op,omitempty"`
+	Value RuleValue `json:"value,omitempty"`
+}
+
+// RuleValue is a condition's operand. It is written in JSON as a string or
+// a number, and kept as text.
+type RuleValue string
+
+// UnmarshalJSON accepts a string, a number or a boolean
+func (v *RuleValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = RuleValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = RuleValue(n.String())
+		return nil
+	}
+	var b bool
+	if err := json.Unmarshal(data, &b); err != nil {
+		return fmt.Errorf("rule value must be a string or a number, not %s", data)
+	}
+	*v = RuleValue(strconv.FormatBool(b))
+	return nil
+}
+
+// Rule operators
+const (
+	OpEquals      = "equals"
+	OpNotEquals   = "not_equals"
+	OpContains    = "contains"
+	OpNotContains = "not_contains"
+	OpGreaterThan = "greater_than"
+	OpLessThan    = "less_than"
+	OpInLast      = "in_last" // Within the last Value days, for dates
+)
+
+// fieldKind is what sort of value a field holds, which decides the
+// operators it takes
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	numberField
+	dateField
+)
+
+// TrackStats gives the play count of a track and when it was last played,
+// for rules on plays and last_played. A nil TrackStats counts no plays.
+type TrackStats func(track *api.Track) (plays int, lastPlayed time.Time)
+
+// ruleField is a field rules can test, and how to read it from a track
+type ruleField struct {
+	kind   fieldKind
+	text   func(t *api.Track) string
+	number func(t *api.Track, plays int) float64
+	date   func(t *api.Track, lastPlayed time.Time) time.Time
+}
+
+// ruleFields are the fields rules and sorting can name
+var ruleFields = map[string]ruleField{
+	"title":  {kind: textField, text: func(t *api.Track) string { return t.Title }},
+	"artist": {kind: textField, text: func(t *api.Track) string { return t.Artist }},
+	"album":  {kind: textField, text: func(t *api.Track) string { return t.Album }},
+	"genre":  {kind: textField, text: func(t *api.Track) string { return t.Genre }},
+	"path":   {kind: textField, text: func(t *api.Track) string { return t.FilePath }},
+
+	"year":     {kind: numberField, number: func(t *api.Track, _ int) float64 { return float64(t.Year) }},
+	"track":    {kind: numberField, number: func(t *api.Track, _ int) float64 { return float64(t.TrackNum) }},
+	"disc":     {kind: numberField, number: func(t *api.Track, _ int) float64 { return float64(t.DiscNum) }},
+	"bpm":      {kind: numberField, number: func(t *api.Track, _ int) float64 { return t.BPM }},
+	"duration": {kind: numberField, number: func(t *api.Track, _ int) float64 { return t.Duration.Seconds() }},
+	"plays":    {kind: numberField, number: func(_ *api.Track, plays int) float64 { return float64(plays) }},
+
+	"added":       {kind: dateField, date: func(t *api.Track, _ time.Time) time.Time { return t.CreatedAt }},
+	"last_played": {kind: dateField, date: func(_ *api.Track, at time.Time) time.Time { return at }},
+}
+
+// kindOps are the operators each kind of field takes
+var kindOps = map[fieldKind][]string{
+	textField:   {OpEquals, OpNotEquals, OpContains, OpNotContains},
+	numberField: {OpEquals, OpNotEquals, OpGreaterThan, OpLessThan},
+	dateField:   {OpInLast, OpGreaterThan, OpLessThan},
+}
+
+// Validate reports the first rule naming an unknown field or none, giving
+// a field an operator it doesn't take or comparing a number or date to
+// something else. Dates compare to days ago: "added in_last 30" or
+// "last_played greater_than 365".
+func (r Rule) Validate() error {
+	if r.Field == "" && (r.Op != "" || r.Value != "") {
+		return fmt.Errorf("rule %s %q names no field", r.Op, r.Value)
+	}
+	if r.isGroup() {
+		subs := append(slices.Clone(r.All), r.Any...)
+		if r.Not != nil {
+			subs = append(subs, *r.Not)
+		}
+		for _, sub := range subs {
+			if err := sub.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	field, ok := ruleFields[r.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", r.Field)
+	}
+	if !slices.Contains(kindOps[field.kind], r.Op) {
+		return fmt.Errorf("%s: operator %q must be one of %s", r.Field, r.Op, strings.Join(kindOps[field.kind], ", "))
+	}
+	if field.kind != textField {
+		if _, err := strconv.ParseFloat(string(r.Value), 64); err != nil {
+			return fmt.Errorf("%s %s: %q is not a number", r.Field, r.Op, r.Value)
+		}
+	}
+	return nil
+}
+
+// isGroup reports whether r combines other rules rather than testing a
+// field. A rule with neither is an empty group, matching no track.
+func (r Rule) isGroup() bool {
+	return r.All != nil || r.Any != nil || r.Not != nil || r.Field == ""
+}
+
+// uses reports whether r or any rule within it tests one of fields
+func (r Rule) uses(fields ...string) bool {
+	if slices.Contains(fields, r.Field) && r.Field != "" {
+		return true
+	}
+	if r.Not != nil && r.Not.uses(fields...) {
+		return true
+	}
+	return slices.ContainsFunc(append(slices.Clone(r.All), r.Any...), func(sub Rule) bool {
+		return sub.uses(fields...)
+	})
+}
+
+// Match reports whether track satisfies r, with plays and lastPlayed its
+// play stats and now the time dates are counted back from
+func (r Rule) Match(track *api.Track, plays int, lastPlayed, now time.Time) bool {
+	if r.isGroup() {
+		if len(r.All) == 0 && len(r.Any) == 0 && r.Not == nil {
+			return false
+		}
+		for _, sub := range r.All {
+			if !sub.Match(track, plays, lastPlayed, now) {
+				return false
+			}
+		}
+		if r.Any != nil && !slices.ContainsFunc(r.Any, func(sub Rule) bool {
+			return sub.Match(track, plays, lastPlayed, now)
+		}) {
+			return false
+		}
+		return r.Not == nil || !r.Not.Match(track, plays, lastPlayed, now)
+	}
+
+	field, ok := ruleFields[r.Field]
+	if !ok {
+		return false
+	}
+	switch field.kind {
+	case textField:
+		text, value := strings.ToLower(field.text(track)), strings.ToLower(string(r.Value))
+		switch r.Op {
+		case OpEquals:
+			return text == value
+		case OpNotEquals:
+			return text != value
+		case OpContains:
+			return strings.Contains(text, value)
+		case OpNotContains:
+			return !strings.Contains(text, value)
+		}
+	case numberField:
+		value, err := strconv.ParseFloat(string(r.Value), 64)
+		if err != nil {
+			return false
+		}
+		n := field.number(track, plays)
+		switch r.Op {
+		case OpEquals:
+			return n == value
+		case OpNotEquals:
+			return n != value
+		case OpGreaterThan:
+			return n > value
+		case OpLessThan:
+			return n < value
+		}
+	case dateField:
+		days, err := strconv.ParseFloat(string(r.Value), 64)
+		date := field.date(track, lastPlayed)
+		if err != nil || date.IsZero() {
+			return false // Never played, or added before dates were kept
+		}
+		cutoff := now.Add(-time.Duration(days * float64(24*time.Hour)))
+		switch r.Op {
+		case OpInLast, OpLessThan:
+			return !date.Before(cutoff)
+		case OpGreaterThan:
+			return date.Before(cutoff)
+		}
+	}
+	return false
+}
+
+// Validate reports a smart playlist without a name, with a rule that
+// doesn't validate or sorted by an unknown field
+func (p *SmartPlaylist) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("smart playlist has no name")
+	}
+	if err := p.Rule.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	if _, ok := ruleFields[p.Sort]; p.Sort != "" && !ok {
+		return fmt.Errorf("%s: unknown sort field %q", p.Name, p.Sort)
+	}
+	return nil
+}
+
+// UsesPlays reports whether p's tracks depend on play stats, through its
+// rule or its order, so must be worked out again as plays are counted
+func (p *SmartPlaylist) UsesPlays() bool {
+	return p.Rule.uses("plays", "last_played") || p.Sort == "plays" || p.Sort == "last_played"
+}
+
+// Evaluate returns the tracks among tracks the rule matches, ordered by
+// Sort (ties, and everything without Sort, keep their order in tracks)
+// and cut to Limit
+func (p *SmartPlaylist) Evaluate(tracks []*api.Track, stats TrackStats, now time.Time) []*api.Track {
+	type match struct {
+		track      *api.Track
+		plays      int
+		lastPlayed time.Time
+	}
+	var matches []match
+	for _, track := range tracks {
+		var plays int
+		var lastPlayed time.Time
+		if stats != nil {
+			plays, lastPlayed = stats(track)
+		}
+		if p.Rule.Match(track, plays, lastPlayed, now) {
+			matches = append(matches, match{track, plays, lastPlayed})
+		}
+	}
+
+	if field, ok := ruleFields[p.Sort]; ok {
+		slices.SortStableFunc(matches, func(a, b match) int {
+			var c int
+			switch field.kind {
+			case textField:
+				c = strings.Compare(strings.ToLower(field.text(a.track)), strings.ToLower(field.text(b.track)))
+			case numberField:
+				c = cmp.Compare(field.number(a.track, a.plays), field.number(b.track, b.plays))
+			case dateField:
+				c = field.date(a.track, a.lastPlayed).Compare(field.date(b.track, b.lastPlayed))
+			}
+			if p.Desc {
+				return -c
+			}
+			return c
+		})
+	}
+	if p.Limit > 0 && len(matches) > p.Limit {
+		matches = matches[:p.Limit]
+	}
+
+	result := make([]*api.Track, len(matches))
+	for i, m := range matches {
+		result[i] = m.track
+	}
+	return result
+}
+
+// SmartPlaylistID is the playlist ID a smart playlist is shown under
+func SmartPlaylistID(name string) string {
+	return "smart:" + name
+}
+
+// IsSmartPlaylistID reports whether id is a smart playlist's, whose
+// tracks come from its rule and can't be edited
+func IsSmartPlaylistID(id string) bool {
+	return strings.HasPrefix(id, "smart:")
+}
+
+// Playlist evaluates p over tracks as a playlist to show, holding copies
+// of the matching tracks as saved playlists do
+func (p *SmartPlaylist) Playlist(tracks []*api.Track, stats TrackStats, now time.Time) *api.Playlist {
+	matched := p.Evaluate(tracks, stats, now)
+	pl := &api.Playlist{
+		ID:          SmartPlaylistID(p.Name),
+		Name:        p.Name,
+		Description: "smart",
+		Tracks:      make([]api.Track, len(matched)),
+		UpdatedAt:   now,
+	}
+	for i, track := range matched {
+		pl.Tracks[i] = *track
+	}
+	return pl
+}
+
+// LoadSmartPlaylists reads smart playlist definitions from a JSON file
+// holding an array of them. A missing file gives none. Definitions that
+// don't validate are left out and reported together; the rest load.
+func LoadSmartPlaylists(path string) ([]*SmartPlaylist, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read smart playlists: %w", err)
+	}
+	var defs []*SmartPlaylist
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("parse smart playlists %s: %w", path, err)
+	}
+
+	var valid []*SmartPlaylist
+	var errs []error
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		valid = append(valid, def)
+	}
+	return valid, errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package playlist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestSmartPlaylist_Evaluate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracks := []*api.Track{
+		{ID: "a", Title: "So What", Artist: "Miles Davis", Genre: "Jazz", Year: 1959, FilePath: "/a", CreatedAt: now.AddDate(0, 0, -3)},
+		{ID: "b", Title: "Blue in Green", Artist: "Miles Davis", Genre: "Modal Jazz", Year: 1959, FilePath: "/b", CreatedAt: now.AddDate(0, -2, 0)},
+		{ID: "c", Title: "Giant Steps", Artist: "John Coltrane", Genre: "Jazz", Year: 1960, FilePath: "/c", CreatedAt: now.AddDate(0, 0, -10)},
+		{ID: "d", Title: "Paranoid", Artist: "Black Sabbath", Genre: "Metal", Year: 1970, FilePath: "/d"},
+	}
+	plays := map[string]int{"/a": 2, "/c": 9, "/d": 5}
+	stats := func(track *api.Track) (int, time.Time) {
+		if n := plays[track.FilePath]; n > 0 {
+			return n, now.AddDate(0, 0, -n)
+		}
+		return 0, time.Time{}
+	}
+
+	tests := []struct {
+		name string
+		pl   SmartPlaylist
+		want string
+	}{
+		{"equals ignores case", SmartPlaylist{Rule: Rule{Field: "artist", Op: OpEquals, Value: "miles davis"}}, "a b"},
+		{"contains", SmartPlaylist{Rule: Rule{Field: "genre", Op: OpContains, Value: "jazz"}}, "a b c"},
+		{"all of", SmartPlaylist{Rule: Rule{All: []Rule{
+			{Field: "artist", Op: OpEquals, Value: "Miles Davis"},
+			{Field: "added", Op: OpInLast, Value: "30"},
+		}}}, "a"},
+		{"any of", SmartPlaylist{Rule: Rule{Any: []Rule{
+			{Field: "year", Op: OpGreaterThan, Value: "1965"},
+			{Field: "title", Op: OpContains, Value: "green"},
+		}}}, "b d"},
+		{"not", SmartPlaylist{Rule: Rule{Not: &Rule{Field: "genre", Op: OpContains, Value: "jazz"}}}, "d"},
+		{"never played is never recent", SmartPlaylist{Rule: Rule{Field: "last_played", Op: OpGreaterThan, Value: "4"}}, "c d"},
+		{"sorted and limited", SmartPlaylist{Rule: Rule{Field: "plays", Op: OpGreaterThan, Value: "0"}, Sort: "plays", Desc: true, Limit: 2}, "c d"},
+		{"sorted by text", SmartPlaylist{Rule: Rule{Field: "path", Op: OpContains, Value: "/"}, Sort: "title"}, "b c d a"},
+		{"empty rule matches nothing", SmartPlaylist{Rule: Rule{}}, ""},
+		{"empty group matches nothing", SmartPlaylist{Rule: Rule{All: []Rule{}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, track := range tt.pl.Evaluate(tracks, stats, now) {
+				ids = append(ids, track.ID)
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSmartPlaylists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "smart_playlists.json")
+	if pls, err := LoadSmartPlaylists(path); pls != nil || err != nil {
+		t.Fatalf("missing file: %v, %v", pls, err)
+	}
+
+	data := `[
+		{"name": "Recent Miles", "rule": {"all": [
+			{"field": "artist", "op": "equals", "value": "Miles Davis"},
+			{"field": "added", "op": "in_last", "value": 30}
+		]}, "sort": "added", "desc": true},
+		{"name": "Bad field", "rule": {"field": "mood", "op": "equals", "value": "happy"}},
+		{"name": "Bad op", "rule": {"any": [{"field": "year", "op": "contains", "value": "19"}]}},
+		{"name": "Bad number", "rule": {"field": "bpm", "op": "greater_than", "value": "fast"}},
+		{"name": "No field", "rule": {"all": [{"op": "equals", "value": "jazz"}]}}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pls, err := LoadSmartPlaylists(path)
+	if len(pls) != 1 || pls[0].Name != "Recent Miles" || pls[0].Rule.All[1].Value != "30" {
+		t.Fatalf("loaded %+v", pls)
+	}
+	if err == nil {
+		t.Fatal("invalid definitions not reported")
+	}
+	for _, name := range []string{"Bad field", "Bad op", "Bad number", "No field"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %s", err, name)
+		}
+	}
+
+	if pls[0].UsesPlays() || !(&SmartPlaylist{Rule: Rule{Not: &Rule{Field: "plays"}}}).UsesPlays() {
+		t.Error("UsesPlays wrong")
+	}
+
+	pl := pls[0].Playlist(nil, nil, time.Now())
+	if pl.ID != SmartPlaylistID("Recent Miles") || len(pl.Tracks) != 0 {
+		t.Errorf("playlist %+v", pl)
+	}
+}
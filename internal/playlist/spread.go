@@ -0,0 +1,66 @@
+package playlist
+
+import "github.com/jscyril/golang_music_player/api"
+
+// spreadWindow bounds how far ahead spreadTracks looks for a track that
+// fits, keeping reordering linear for large queues
+const spreadWindow = 64
+
+// spreadTracks reorders tracks in place so that no two tracks by the same
+// artist or from the same album are within spread positions of each other,
+// where possible. tracks[0] (the current track) stays put. Each position
+// takes a track that fits, preferring the artist with the most tracks left
+// so the big groups don't pile up at the end, and otherwise keeping the
+// existing (shuffled) order. When none fits, such as in a single-artist
+// playlist, the one whose last clash is furthest back is used, so the
+// result degrades to the plain shuffle order.
+func spreadTracks(tracks []*api.Track, spread int) {
+	if len(tracks) < 3 || spread <= 0 {
+		return
+	}
+
+	rest := make([]*api.Track, len(tracks)-1)
+	copy(rest, tracks[1:])
+	remaining := make(map[string]int)
+	for _, t := range rest {
+		remaining[t.Artist]++
+	}
+
+	for pos := 1; pos < len(tracks); pos++ {
+		pick, fits, best := 0, false, -1
+		for i := 0; i < len(rest) && i < spreadWindow; i++ {
+			gap := clashGap(tracks[:pos], rest[i], spread)
+			switch {
+			case gap > spread:
+				if !fits || remaining[rest[i].Artist] > remaining[rest[pick].Artist] {
+					pick, fits = i, true
+				}
+			case !fits && gap > best:
+				pick, best = i, gap
+			}
+		}
+
+		tracks[pos] = rest[pick]
+		remaining[rest[pick].Artist]--
+		// Remove the pick, keeping the order of the tracks before it
+		copy(rest[1:pick+1], rest[:pick])
+		rest = rest[1:]
+	}
+}
+
+// clashGap returns how many positions back from the end of placed the
+// nearest track sharing t's artist or album is, looking at most spread
+// tracks back. It returns spread+1 when there is no clash.
+func clashGap(placed []*api.Track, t *api.Track, spread int) int {
+	for gap := 1; gap <= spread && gap <= len(placed); gap++ {
+		if sameGroup(placed[len(placed)-gap], t) {
+			return gap
+		}
+	}
+	return spread + 1
+}
+
+// sameGroup reports whether two tracks share a known artist or album
+func sameGroup(a, b *api.Track) bool {
+	return (a.Artist != "" && a.Artist == b.Artist) || (a.Album != "" && a.Album == b.Album)
+}
@@ -0,0 +1,196 @@
+package scrobble
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LastFMEndpoint is the Last.fm API root
+const LastFMEndpoint = "https://ws.audioscrobbler.com/2.0/"
+
+// LastFM submits listens to Last.fm with an API account's key and secret
+// and a user's session key. Without a session key, Authenticate gets one
+// with the user's name and password.
+type LastFM struct {
+	APIKey     string
+	Secret     string
+	SessionKey string
+	Username   string
+	Password   string // Forgotten once exchanged for a session key
+
+	Endpoint string       // Defaults to LastFMEndpoint
+	Client   *http.Client // Defaults to a client with a timeout
+}
+
+// NewLastFM creates a Last.fm client for the user with sessionKey
+func NewLastFM(apiKey, secret, sessionKey string) *LastFM {
+	return &LastFM{APIKey: apiKey, Secret: secret, SessionKey: sessionKey}
+}
+
+// Name implements Service
+func (l *LastFM) Name() string { return "lastfm" }
+
+// NowPlaying implements Service
+func (l *LastFM) NowPlaying(ctx context.Context, listen Listen) error {
+	params := url.Values{"artist": {listen.Artist}, "track": {listen.Title}}
+	if listen.Album != "" {
+		params.Set("album", listen.Album)
+	}
+	if listen.Duration > 0 {
+		params.Set("duration", strconv.Itoa(int(listen.Duration.Seconds())))
+	}
+	return l.call(ctx, "track.updateNowPlaying", params, nil)
+}
+
+// Scrobble implements Service
+func (l *LastFM) Scrobble(ctx context.Context, listens []Listen) error {
+	params := url.Values{}
+	for i, listen := range listens {
+		key := func(name string) string { return fmt.Sprintf("%s[%d]", name, i) }
+		params.Set(key("artist"), listen.Artist)
+		params.Set(key("track"), listen.Title)
+		params.Set(key("timestamp"), strconv.FormatInt(listen.StartedAt.Unix(), 10))
+		if listen.Album != "" {
+			params.Set(key("album"), listen.Album)
+		}
+		if listen.Duration > 0 {
+			params.Set(key("duration"), strconv.Itoa(int(listen.Duration.Seconds())))
+		}
+	}
+	return l.call(ctx, "track.scrobble", params, nil)
+}
+
+// Login exchanges a user's name and password for a session key, which
+// is then used instead of the password
+func (l *LastFM) Login(ctx context.Context, username, password string) (string, error) {
+	var reply struct {
+		Session struct {
+			Key string `json:"key"`
+		} `json:"session"`
+	}
+	params := url.Values{"username": {username}, "password": {password}}
+	if err := l.call(ctx, "auth.getMobileSession", params, &reply); err != nil {
+		return "", err
+	}
+	if reply.Session.Key == "" {
+		return "", fmt.Errorf("last.fm login: no session key in reply")
+	}
+	l.SessionKey = reply.Session.Key
+	return reply.Session.Key, nil
+}
+
+// Authenticate implements Authenticator
+func (l *LastFM) Authenticate(ctx context.Context) (string, error) {
+	if l.SessionKey != "" {
+		return "", nil
+	}
+	if l.Username == "" || l.Password == "" {
+		return "", fmt.Errorf("last.fm: no session key, username or password: %w", ErrAuth)
+	}
+	key, err := l.Login(ctx, l.Username, l.Password)
+	if err != nil {
+		return "", err
+	}
+	l.Password = ""
+	return key, nil
+}
+
+// lastFMAuth are the error codes for bad credentials: authentication
+// failed, invalid session key, invalid API key and suspended API key
+var lastFMAuth = map[int]bool{4: true, 9: true, 10: true, 26: true}
+
+// lastFMTemporary are the error codes worth retrying: operation failed,
+// service offline, temporarily unavailable and rate limit exceeded
+var lastFMTemporary = map[int]bool{8: true, 11: true, 16: true, 29: true}
+
+// call makes a signed API call, decoding the JSON reply into out if set
+func (l *LastFM) call(ctx context.Context, method string, params url.Values, out any) error {
+	params.Set("method", method)
+	params.Set("api_key", l.APIKey)
+	if l.SessionKey != "" && method != "auth.getMobileSession" {
+		params.Set("sk", l.SessionKey)
+	}
+	params.Set("api_sig", l.sign(params))
+	params.Set("format", "json")
+
+	endpoint := l.Endpoint
+	if endpoint == "" {
+		endpoint = LastFMEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := l.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("last.fm %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("last.fm %s: %w", method, err)
+	}
+
+	var failure struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &failure) == nil && failure.Error != 0 {
+		if lastFMTemporary[failure.Error] {
+			return fmt.Errorf("last.fm %s: %s (error %d)", method, failure.Message, failure.Error)
+		}
+		if lastFMAuth[failure.Error] {
+			return fmt.Errorf("last.fm %s: %s (error %d): %w", method, failure.Message, failure.Error, ErrAuth)
+		}
+		return fmt.Errorf("last.fm %s: %s (error %d): %w", method, failure.Message, failure.Error, ErrRejected)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("last.fm %s: %s", method, resp.Status)
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("last.fm %s: %s: %w", method, resp.Status, ErrAuth)
+		}
+		return fmt.Errorf("last.fm %s: %s: %w", method, resp.Status, ErrRejected)
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("last.fm %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// sign computes api_sig: the MD5 of every parameter name and value in
+// name order, followed by the secret
+func (l *LastFM) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "format" && k != "callback" && k != "api_sig" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString(params.Get(k))
+	}
+	sb.WriteString(l.Secret)
+	sum := md5.Sum([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,133 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLastFM_Sign(t *testing.T) {
+	l := NewLastFM("key", "secret", "")
+	params := url.Values{"method": {"auth.getMobileSession"}, "api_key": {"key"}, "format": {"json"}}
+	// md5("api_keykeymethodauth.getMobileSessionsecret")
+	if got, want := l.sign(params), "018322def6bdaf0b7eba8f03ac376100"; got != want {
+		t.Errorf("sign = %q, want %q", got, want)
+	}
+}
+
+func TestLastFM_Scrobble(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`{"scrobbles":{"@attr":{"accepted":2,"ignored":0}}}`))
+	}))
+	defer srv.Close()
+
+	l := NewLastFM("key", "secret", "session")
+	l.Endpoint = srv.URL
+	started := time.Unix(1700000000, 0)
+	err := l.Scrobble(context.Background(), []Listen{
+		{Artist: "A", Title: "One", Album: "Rec", Duration: 200 * time.Second, StartedAt: started},
+		{Artist: "B", Title: "Two", StartedAt: started.Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Scrobble: %v", err)
+	}
+	checks := map[string]string{
+		"method": "track.scrobble", "api_key": "key", "sk": "session", "format": "json",
+		"artist[0]": "A", "track[0]": "One", "album[0]": "Rec", "duration[0]": "200", "timestamp[0]": "1700000000",
+		"artist[1]": "B", "track[1]": "Two", "timestamp[1]": "1700000060",
+	}
+	for k, want := range checks {
+		if got := form.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if _, ok := form["album[1]"]; ok {
+		t.Error("empty album sent")
+	}
+	signed := url.Values{}
+	for k, v := range form {
+		if k != "api_sig" {
+			signed[k] = v
+		}
+	}
+	if form.Get("api_sig") != l.sign(signed) {
+		t.Error("api_sig doesn't match the parameters")
+	}
+}
+
+func TestLastFM_Errors(t *testing.T) {
+	tests := []struct {
+		status         int
+		body           string
+		rejected, auth bool
+	}{
+		{http.StatusForbidden, `{"error":9,"message":"Invalid session key"}`, false, true},
+		{http.StatusOK, `{"error":6,"message":"Invalid parameters"}`, true, false},
+		{http.StatusOK, `{"error":11,"message":"Service Offline"}`, false, false},
+		{http.StatusServiceUnavailable, `oops`, false, false},
+		{http.StatusUnauthorized, `oops`, false, true},
+		{http.StatusBadRequest, `oops`, true, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		l := NewLastFM("key", "secret", "session")
+		l.Endpoint = srv.URL
+		err := l.NowPlaying(context.Background(), Listen{Artist: "A", Title: "T"})
+		srv.Close()
+		if err == nil {
+			t.Errorf("%d %s: no error", tt.status, tt.body)
+		} else if errors.Is(err, ErrRejected) != tt.rejected || errors.Is(err, ErrAuth) != tt.auth {
+			t.Errorf("%d %s: err = %v, want rejected %v, auth %v", tt.status, tt.body, err, tt.rejected, tt.auth)
+		}
+	}
+
+	// Unreachable: retried
+	l := NewLastFM("key", "secret", "session")
+	l.Endpoint = "http://127.0.0.1:1"
+	if err := l.NowPlaying(context.Background(), Listen{}); err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("unreachable: err = %v", err)
+	}
+}
+
+func TestLastFM_Login(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("username") != "me" || r.PostForm.Get("password") != "pw" || r.PostForm.Get("sk") != "" {
+			w.Write([]byte(`{"error":4,"message":"Authentication Failed"}`))
+			return
+		}
+		w.Write([]byte(`{"session":{"name":"me","key":"abc123","subscriber":0}}`))
+	}))
+	defer srv.Close()
+
+	l := NewLastFM("key", "secret", "")
+	l.Endpoint = srv.URL
+	key, err := l.Login(context.Background(), "me", "pw")
+	if err != nil || key != "abc123" || l.SessionKey != "abc123" {
+		t.Errorf("Login = %q, %v", key, err)
+	}
+
+	l = NewLastFM("key", "secret", "")
+	l.Endpoint = srv.URL
+	l.Username, l.Password = "me", "wrong"
+	if _, err := l.Authenticate(context.Background()); !errors.Is(err, ErrAuth) {
+		t.Errorf("Authenticate with a wrong password: err = %v", err)
+	}
+	l.Password = "pw"
+	if key, err := l.Authenticate(context.Background()); err != nil || key != "abc123" || l.Password != "" {
+		t.Errorf("Authenticate = %q, %v; password %q kept", key, err, l.Password)
+	}
+	if key, err := l.Authenticate(context.Background()); err != nil || key != "" {
+		t.Errorf("Authenticate when logged in = %q, %v", key, err)
+	}
+}
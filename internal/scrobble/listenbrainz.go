@@ -0,0 +1,114 @@
+package scrobble
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ListenBrainzEndpoint is the ListenBrainz submission URL
+const ListenBrainzEndpoint = "https://api.listenbrainz.org/1/submit-listens"
+
+// ListenBrainz submits listens to ListenBrainz with a user token
+type ListenBrainz struct {
+	Token string
+
+	Endpoint string       // Defaults to ListenBrainzEndpoint
+	Client   *http.Client // Defaults to a client with a timeout
+}
+
+// NewListenBrainz creates a ListenBrainz client for the user with token
+func NewListenBrainz(token string) *ListenBrainz {
+	return &ListenBrainz{Token: token}
+}
+
+// Name implements Service
+func (b *ListenBrainz) Name() string { return "listenbrainz" }
+
+// listenBrainzListen is one listen in a submission
+type listenBrainzListen struct {
+	ListenedAt int64 `json:"listened_at,omitempty"`
+	Metadata   struct {
+		ArtistName  string         `json:"artist_name"`
+		TrackName   string         `json:"track_name"`
+		ReleaseName string         `json:"release_name,omitempty"`
+		Info        map[string]any `json:"additional_info,omitempty"`
+	} `json:"track_metadata"`
+}
+
+// NowPlaying implements Service
+func (b *ListenBrainz) NowPlaying(ctx context.Context, listen Listen) error {
+	return b.submit(ctx, "playing_now", []Listen{listen})
+}
+
+// Scrobble implements Service
+func (b *ListenBrainz) Scrobble(ctx context.Context, listens []Listen) error {
+	listenType := "import"
+	if len(listens) == 1 {
+		listenType = "single"
+	}
+	return b.submit(ctx, listenType, listens)
+}
+
+func (b *ListenBrainz) submit(ctx context.Context, listenType string, listens []Listen) error {
+	payload := make([]listenBrainzListen, len(listens))
+	for i, listen := range listens {
+		p := &payload[i]
+		if listenType != "playing_now" {
+			p.ListenedAt = listen.StartedAt.Unix()
+		}
+		p.Metadata.ArtistName = listen.Artist
+		p.Metadata.TrackName = listen.Title
+		p.Metadata.ReleaseName = listen.Album
+		p.Metadata.Info = map[string]any{"media_player": "golang_music_player"}
+		if listen.Duration > 0 {
+			p.Metadata.Info["duration_ms"] = listen.Duration.Milliseconds()
+		}
+	}
+	data, err := json.Marshal(map[string]any{"listen_type": listenType, "payload": payload})
+	if err != nil {
+		return err
+	}
+
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = ListenBrainzEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+b.Token)
+	client := b.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("listenbrainz %s: %w", listenType, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	message := resp.Status
+	if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+		message = failure.Error
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("listenbrainz %s: %s", listenType, message)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("listenbrainz %s: %s: %w", listenType, message, ErrAuth)
+	}
+	return fmt.Errorf("listenbrainz %s: %s: %w", listenType, message, ErrRejected)
+}
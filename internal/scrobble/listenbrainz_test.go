@@ -0,0 +1,75 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListenBrainz_Submit(t *testing.T) {
+	var auth string
+	var body struct {
+		ListenType string               `json:"listen_type"`
+		Payload    []listenBrainzListen `json:"payload"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	b := NewListenBrainz("tok")
+	b.Endpoint = srv.URL
+	listen := Listen{Artist: "A", Title: "One", Album: "Rec", Duration: 200 * time.Second, StartedAt: time.Unix(1700000000, 0)}
+	if err := b.Scrobble(context.Background(), []Listen{listen}); err != nil {
+		t.Fatalf("Scrobble: %v", err)
+	}
+	if auth != "Token tok" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if body.ListenType != "single" || len(body.Payload) != 1 {
+		t.Fatalf("body = %+v", body)
+	}
+	p := body.Payload[0]
+	if p.ListenedAt != 1700000000 || p.Metadata.ArtistName != "A" || p.Metadata.TrackName != "One" || p.Metadata.ReleaseName != "Rec" {
+		t.Errorf("listen = %+v", p)
+	}
+	if p.Metadata.Info["duration_ms"] != float64(200000) {
+		t.Errorf("additional_info = %v", p.Metadata.Info)
+	}
+
+	body.Payload = nil
+	if err := b.NowPlaying(context.Background(), listen); err != nil {
+		t.Fatalf("NowPlaying: %v", err)
+	}
+	if body.ListenType != "playing_now" || body.Payload[0].ListenedAt != 0 {
+		t.Errorf("now playing body = %+v", body)
+	}
+}
+
+func TestListenBrainz_Errors(t *testing.T) {
+	for status, want := range map[int]error{
+		http.StatusUnauthorized:       ErrAuth,
+		http.StatusBadRequest:         ErrRejected,
+		http.StatusTooManyRequests:    nil,
+		http.StatusServiceUnavailable: nil,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":1,"error":"nope"}`))
+		}))
+		b := NewListenBrainz("tok")
+		b.Endpoint = srv.URL
+		err := b.Scrobble(context.Background(), []Listen{{Artist: "A", Title: "T"}})
+		srv.Close()
+		refused := errors.Is(err, ErrRejected) || errors.Is(err, ErrAuth)
+		if err == nil || refused != (want != nil) || want != nil && !errors.Is(err, want) {
+			t.Errorf("status %d: err = %v, want %v", status, err, want)
+		}
+	}
+}
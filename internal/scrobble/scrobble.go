@@ -0,0 +1,149 @@
+// Package scrobble submits listens to scrobbling services such as Last.fm
+// and ListenBrainz. Listens are queued on disk and retried until the
+// service accepts them, so plays made offline are sent once the network
+// is back.
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+const (
+	// minTrackLength is the shortest track that is scrobbled at all
+	minTrackLength = 30 * time.Second
+
+	// maxThreshold caps how long a long track must be listened to
+	maxThreshold = 4 * time.Minute
+
+	// maxTickStep is the largest position advance counted as listening;
+	// bigger jumps are seeks
+	maxTickStep = 2 * time.Second
+)
+
+// ErrRejected marks a submission the service refused for good, such as
+// one with missing fields. Retrying would only be refused again, so the
+// service is paused until the next start, keeping its listens.
+var ErrRejected = errors.New("rejected")
+
+// ErrAuth marks a failure of the user's credentials: a wrong password, or
+// a session key or token that was revoked. The service is paused as for
+// ErrRejected until the credentials are fixed and the player restarted.
+var ErrAuth = errors.New("not authorized")
+
+// defaultClient makes the services' requests unless they are given their
+// own client. Its timeout also covers reading the reply, which the
+// request context alone leaves to the caller.
+var defaultClient = &http.Client{Timeout: submitTimeout}
+
+// Listen is one play of a track
+type Listen struct {
+	Artist    string        `json:"artist"`
+	Title     string        `json:"title"`
+	Album     string        `json:"album,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+}
+
+// ListenFor describes a play of track that started at startedAt
+func ListenFor(track *api.Track, startedAt time.Time) Listen {
+	return Listen{
+		Artist:    track.Artist,
+		Title:     track.Title,
+		Album:     track.Album,
+		Duration:  track.Duration,
+		StartedAt: startedAt,
+	}
+}
+
+// Service is a scrobbling service listens are submitted to
+type Service interface {
+	// Name identifies the service in logs and in the queue file
+	Name() string
+	// NowPlaying announces the listen that has just started
+	NowPlaying(ctx context.Context, listen Listen) error
+	// Scrobble submits finished listens, at most MaxBatch at a time
+	Scrobble(ctx context.Context, listens []Listen) error
+}
+
+// Authenticator is a Service that logs in before its first submission,
+// such as Last.fm exchanging a password for a session key. The scrobbler
+// calls Authenticate in the background, so a slow login never holds up
+// the player.
+type Authenticator interface {
+	// Authenticate logs in unless already logged in. It returns the new
+	// session key, to be saved in place of the password, or "" if there
+	// was nothing to do.
+	Authenticate(ctx context.Context) (sessionKey string, err error)
+}
+
+// MaxBatch is the most listens submitted in one Scrobble call, the limit
+// of both Last.fm and ListenBrainz
+const MaxBatch = 50
+
+// Threshold returns how long a track of the given duration must be
+// listened to before it is scrobbled: half of it, or 4 minutes for long
+// tracks. Tracks of 30 seconds or less are never scrobbled and get 0.
+func Threshold(duration time.Duration) time.Duration {
+	if duration <= minTrackLength {
+		return 0
+	}
+	return min(duration/2, maxThreshold)
+}
+
+// PlayTracker follows playback of the current track, adding up the time
+// actually listened to so seeking ahead doesn't count towards a scrobble
+// and tracks skipped early are never scrobbled
+type PlayTracker struct {
+	track     *api.Track
+	startedAt time.Time
+	played    time.Duration
+	last      time.Duration
+	done      bool
+}
+
+// Update reports the current track and position, and whether it is
+// playing, at time now. It returns started the first time a newly current
+// track is heard playing, and listened once it has been played past its
+// Threshold. A track that restarts from the top after being listened to,
+// as under repeat one, counts as a new play.
+func (p *PlayTracker) Update(track *api.Track, position time.Duration, playing bool, now time.Time) (started, listened bool) {
+	if track == nil {
+		*p = PlayTracker{}
+		return false, false
+	}
+	restarted := p.done && position < p.last && position < maxTickStep
+	if p.track == nil || p.track.ID != track.ID || restarted {
+		*p = PlayTracker{track: track, last: position}
+	}
+	if !playing {
+		p.last = position
+		return false, false
+	}
+
+	if p.startedAt.IsZero() {
+		p.startedAt = now
+		started = true
+	}
+	if delta := position - p.last; delta > 0 && delta <= maxTickStep {
+		p.played += delta
+	}
+	p.last = position
+	if threshold := Threshold(track.Duration); !p.done && threshold > 0 && p.played >= threshold {
+		p.done = true
+		listened = true
+	}
+	return started, listened
+}
+
+// Listen returns the current play
+func (p *PlayTracker) Listen() Listen {
+	if p.track == nil {
+		return Listen{}
+	}
+	return ListenFor(p.track, p.startedAt)
+}
@@ -0,0 +1,99 @@
+package scrobble
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		duration, want time.Duration
+	}{
+		{0, 0},
+		{30 * time.Second, 0},
+		{31 * time.Second, 15500 * time.Millisecond},
+		{3 * time.Minute, 90 * time.Second},
+		{8 * time.Minute, 4 * time.Minute},
+		{time.Hour, 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := Threshold(tt.duration); got != tt.want {
+			t.Errorf("Threshold(%s) = %s, want %s", tt.duration, got, tt.want)
+		}
+	}
+}
+
+// play feeds the tracker a tick every half second from from to to
+func play(p *PlayTracker, track *api.Track, from, to time.Duration, now time.Time) (started, listened int) {
+	for pos := from; pos <= to; pos += 500 * time.Millisecond {
+		s, l := p.Update(track, pos, true, now.Add(pos))
+		if s {
+			started++
+		}
+		if l {
+			listened++
+		}
+	}
+	return started, listened
+}
+
+func TestPlayTracker_ScrobblesOncePastThreshold(t *testing.T) {
+	track := &api.Track{ID: "a", Title: "A", Artist: "X", Duration: 2 * time.Minute}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var p PlayTracker
+
+	started, listened := play(&p, track, 0, 59*time.Second, now)
+	if started != 1 || listened != 0 {
+		t.Fatalf("first minute: started %d, listened %d", started, listened)
+	}
+	if _, listened := play(&p, track, 59500*time.Millisecond, 2*time.Minute, now); listened != 1 {
+		t.Fatalf("rest of track: listened %d, want 1", listened)
+	}
+	if got := p.Listen(); got.Title != "A" || !got.StartedAt.Equal(now) {
+		t.Errorf("Listen() = %+v", got)
+	}
+}
+
+func TestPlayTracker_SkippedEarlyNotScrobbled(t *testing.T) {
+	one := &api.Track{ID: "1", Duration: 3 * time.Minute}
+	two := &api.Track{ID: "2", Duration: 3 * time.Minute}
+	now := time.Now()
+	var p PlayTracker
+
+	play(&p, one, 0, 20*time.Second, now)
+	started, listened := play(&p, two, 0, 10*time.Second, now)
+	if started != 1 || listened != 0 {
+		t.Errorf("after skip: started %d, listened %d", started, listened)
+	}
+}
+
+func TestPlayTracker_SeekingDoesNotCount(t *testing.T) {
+	track := &api.Track{ID: "a", Duration: 4 * time.Minute}
+	var p PlayTracker
+	now := time.Now()
+
+	play(&p, track, 0, 10*time.Second, now)
+	// Seek most of the way through and listen a little
+	if _, listened := play(&p, track, 3*time.Minute, 3*time.Minute+30*time.Second, now); listened != 0 {
+		t.Error("seeking ahead counted as listening")
+	}
+}
+
+func TestPlayTracker_PausedAndRepeat(t *testing.T) {
+	track := &api.Track{ID: "a", Duration: time.Minute}
+	var p PlayTracker
+	now := time.Now()
+
+	if started, _ := p.Update(track, 0, false, now); started {
+		t.Error("paused track reported as started")
+	}
+	play(&p, track, 0, time.Minute, now)
+
+	// Repeat one starts it again from the top: a new play
+	started, listened := play(&p, track, 0, time.Minute, now)
+	if started != 1 || listened != 1 {
+		t.Errorf("repeat: started %d, listened %d", started, listened)
+	}
+}
@@ -0,0 +1,336 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+)
+
+const (
+	// submitTimeout bounds each request to a service
+	submitTimeout = 15 * time.Second
+
+	// Failed submissions are retried after retryMin, doubling up to retryMax
+	retryMin = 30 * time.Second
+	retryMax = 10 * time.Minute
+)
+
+// Scrobbler submits listens to services in the background. NowPlaying and
+// Scrobble never block, so they can be called from the UI. Listens waiting
+// to be accepted are kept in a JSON file, so they survive restarts.
+type Scrobbler struct {
+	services []Service
+	path     string
+
+	mu      sync.Mutex
+	pending map[string][]Listen // By service name
+	dirty   bool                // pending changed since the last save
+
+	paused  map[string]bool // Services refusing our credentials or listens; run's own
+	notices chan Notice
+
+	nowPlaying chan Listen
+	wake       chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Notice tells the user about a service: that it was paused, or that it
+// logged in and its session key should be saved
+type Notice struct {
+	Service    string
+	Err        error  // Why the service was paused
+	SessionKey string // From Authenticate, to save in place of the password
+}
+
+// queueFile is the on-disk form of the pending listens
+type queueFile struct {
+	Pending map[string][]Listen `json:"pending"`
+}
+
+// NewScrobbler creates a scrobbler for services, loading listens still
+// waiting from an earlier run from the queue file at path (which need not
+// exist)
+func NewScrobbler(path string, services ...Service) (*Scrobbler, error) {
+	s := &Scrobbler{
+		services:   services,
+		path:       path,
+		pending:    make(map[string][]Listen),
+		paused:     make(map[string]bool),
+		notices:    make(chan Notice, 8),
+		nowPlaying: make(chan Listen, 1),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scrobble queue: %w", err)
+	}
+	var q queueFile
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("unmarshal scrobble queue: %w", err)
+	}
+	for name, listens := range q.Pending {
+		s.pending[name] = listens
+	}
+	return s, nil
+}
+
+// Start submits listens in the background until ctx is cancelled or Stop
+// is called, starting with any left over from an earlier run
+func (s *Scrobbler) Start(ctx context.Context) {
+	s.done = make(chan struct{})
+	go s.run(ctx)
+	s.poke()
+}
+
+// Stop stops submitting and waits for the background goroutine to exit.
+// Listens not yet accepted stay in the queue file for next time. It is
+// safe to call more than once, and before Start.
+func (s *Scrobbler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.done != nil {
+		<-s.done
+	}
+}
+
+// NowPlaying announces that listen has started. Announcements aren't
+// queued: one that fails, or is overtaken by the next, is dropped.
+func (s *Scrobbler) NowPlaying(listen Listen) {
+	select {
+	case <-s.nowPlaying: // Replace an announcement not yet sent
+	default:
+	}
+	select {
+	case s.nowPlaying <- listen:
+	default:
+	}
+}
+
+// Scrobble queues a finished listen for every service. The queue file is
+// saved in the background, before the listen is submitted.
+func (s *Scrobbler) Scrobble(listen Listen) {
+	s.mu.Lock()
+	for _, svc := range s.services {
+		s.pending[svc.Name()] = append(s.pending[svc.Name()], listen)
+	}
+	s.dirty = true
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Notices returns the channel telling about paused services and new
+// session keys. Notices not read in time are logged and dropped.
+func (s *Scrobbler) Notices() <-chan Notice {
+	return s.notices
+}
+
+// Pending returns how many listens are waiting to be accepted by the
+// service called name
+func (s *Scrobbler) Pending(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending[name])
+}
+
+func (s *Scrobbler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scrobbler) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.save() // Whatever Scrobble queued since the last flush
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := retryMin
+	retry := time.NewTimer(retryMax)
+	retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case listen := <-s.nowPlaying:
+			s.announce(ctx, listen)
+			continue
+		case <-s.wake:
+		case <-retry.C:
+		}
+
+		s.save()
+		if s.flush(ctx) {
+			backoff = retryMin
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Info("Scrobble: retrying in %s", backoff)
+		retry.Reset(backoff)
+		backoff = min(backoff*2, retryMax)
+	}
+}
+
+// announce sends a now-playing update to every service
+func (s *Scrobbler) announce(ctx context.Context, listen Listen) {
+	for _, svc := range s.services {
+		if s.paused[svc.Name()] || s.authenticate(ctx, svc) != nil {
+			continue
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, submitTimeout)
+		err := svc.NowPlaying(reqCtx, listen)
+		cancel()
+		if errors.Is(err, ErrAuth) {
+			s.pause(svc, err)
+		} else if err != nil {
+			logger.Debug("Scrobble: %s now playing: %v", svc.Name(), err)
+		}
+	}
+}
+
+// authenticate logs svc in if it needs to, pausing it if its credentials
+// are refused
+func (s *Scrobbler) authenticate(ctx context.Context, svc Service) error {
+	auth, ok := svc.(Authenticator)
+	if !ok {
+		return nil
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, submitTimeout)
+	key, err := auth.Authenticate(reqCtx)
+	cancel()
+	if err != nil {
+		if !s.refused(svc, err) {
+			logger.Warn("Scrobble: %s login: %v", svc.Name(), err)
+		}
+		return err
+	}
+	if key != "" {
+		logger.Info("Scrobble: logged in to %s", svc.Name())
+		s.notify(Notice{Service: svc.Name(), SessionKey: key})
+	}
+	return nil
+}
+
+// refused pauses svc if err says it refused our credentials or listens
+// for good, reporting whether it did
+func (s *Scrobbler) refused(svc Service, err error) bool {
+	if !errors.Is(err, ErrRejected) && !errors.Is(err, ErrAuth) {
+		return false
+	}
+	s.pause(svc, err)
+	return true
+}
+
+// pause stops submitting to svc until the next start, keeping its
+// listens, and tells the user why
+func (s *Scrobbler) pause(svc Service, err error) {
+	s.paused[svc.Name()] = true
+	logger.Warn("Scrobble: %s paused, keeping %d listens: %v", svc.Name(), s.Pending(svc.Name()), err)
+	s.notify(Notice{Service: svc.Name(), Err: err})
+}
+
+func (s *Scrobbler) notify(n Notice) {
+	select {
+	case s.notices <- n:
+	default:
+		logger.Warn("Scrobble: notice for %s dropped", n.Service)
+	}
+}
+
+// flush submits every service's pending listens, oldest first, and
+// reports whether they were all dealt with. A service that fails keeps
+// its remaining listens: for the retry, or until the next start if it
+// refused them or our credentials and was paused.
+func (s *Scrobbler) flush(ctx context.Context) bool {
+	ok := true
+	for _, svc := range s.services {
+		if s.paused[svc.Name()] {
+			continue
+		}
+		if err := s.authenticate(ctx, svc); err != nil {
+			if !s.paused[svc.Name()] {
+				ok = false
+			}
+			continue
+		}
+		for {
+			s.mu.Lock()
+			batch := s.pending[svc.Name()]
+			batch = batch[:min(len(batch), MaxBatch)]
+			s.mu.Unlock()
+			if len(batch) == 0 {
+				break
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, submitTimeout)
+			err := svc.Scrobble(reqCtx, batch)
+			cancel()
+			if err != nil {
+				if !s.refused(svc, err) {
+					logger.Warn("Scrobble: %s: %v", svc.Name(), err)
+					ok = false
+				}
+				break
+			}
+			logger.Info("Scrobbled %d listens to %s", len(batch), svc.Name())
+
+			s.mu.Lock()
+			s.pending[svc.Name()] = s.pending[svc.Name()][len(batch):]
+			s.dirty = true
+			s.mu.Unlock()
+			s.save()
+		}
+	}
+	return ok
+}
+
+// save writes the pending listens to the queue file if they changed. It
+// runs only on the background goroutine, so saves land in order; the
+// file is replaced atomically so a crash never loses the whole queue.
+func (s *Scrobbler) save() {
+	if s.path == "" {
+		return
+	}
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	q := queueFile{Pending: make(map[string][]Listen)}
+	for name, listens := range s.pending {
+		if len(listens) > 0 {
+			q.Pending[name] = listens
+		}
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	s.dirty = false
+	s.mu.Unlock()
+	if err == nil {
+		err = atomicfile.WriteData(s.path, data)
+	}
+	if err != nil {
+		logger.Warn("Scrobble: save queue: %v", err)
+	}
+}
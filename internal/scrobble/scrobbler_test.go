@@ -0,0 +1,200 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeService records submissions, failing them while err is set
+type fakeService struct {
+	mu         sync.Mutex
+	err        error
+	nowPlaying []Listen
+	scrobbled  []Listen
+	calls      chan struct{}
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{calls: make(chan struct{}, 100)}
+}
+
+func (f *fakeService) Name() string { return "fake" }
+
+func (f *fakeService) NowPlaying(ctx context.Context, listen Listen) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nowPlaying = append(f.nowPlaying, listen)
+	f.calls <- struct{}{}
+	return f.err
+}
+
+func (f *fakeService) Scrobble(ctx context.Context, listens []Listen) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer func() { f.calls <- struct{}{} }()
+	if f.err != nil {
+		return f.err
+	}
+	f.scrobbled = append(f.scrobbled, listens...)
+	return nil
+}
+
+func (f *fakeService) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeService) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-f.calls:
+	case <-time.After(2 * time.Second):
+		t.Fatal("service not called")
+	}
+}
+
+func listen(title string) Listen {
+	return Listen{Artist: "X", Title: title, StartedAt: time.Unix(1700000000, 0).UTC()}
+}
+
+func TestScrobbler_SubmitsInBackground(t *testing.T) {
+	svc := newFakeService()
+	s, err := NewScrobbler(filepath.Join(t.TempDir(), "scrobbles.json"), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start(context.Background())
+	defer s.Stop()
+
+	s.NowPlaying(listen("a"))
+	s.Scrobble(listen("a"))
+	svc.wait(t)
+	svc.wait(t)
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.nowPlaying) != 1 || len(svc.scrobbled) != 1 || svc.scrobbled[0].Title != "a" {
+		t.Errorf("now playing %v, scrobbled %v", svc.nowPlaying, svc.scrobbled)
+	}
+}
+
+func TestScrobbler_KeepsFailedListensAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrobbles.json")
+	svc := newFakeService()
+	svc.setErr(errors.New("network is unreachable"))
+
+	s, err := NewScrobbler(path, svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start(context.Background())
+	s.Scrobble(listen("a"))
+	s.Scrobble(listen("b"))
+	svc.wait(t)
+	s.Stop()
+	if n := s.Pending("fake"); n != 2 {
+		t.Fatalf("Pending = %d after failure, want 2", n)
+	}
+
+	// Back online in the next session: the saved listens go out in order
+	svc.setErr(nil)
+	s, err = NewScrobbler(path, svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start(context.Background())
+	defer s.Stop()
+	svc.wait(t)
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.scrobbled) != 2 || svc.scrobbled[0].Title != "a" || svc.scrobbled[1].Title != "b" {
+		t.Errorf("scrobbled %v", svc.scrobbled)
+	}
+	if !svc.scrobbled[0].StartedAt.Equal(listen("a").StartedAt) {
+		t.Errorf("start time not kept: %v", svc.scrobbled[0].StartedAt)
+	}
+}
+
+func TestScrobbler_PausesWhenRefused(t *testing.T) {
+	for _, refusal := range []error{ErrRejected, ErrAuth} {
+		svc := newFakeService()
+		svc.setErr(fmt.Errorf("invalid session key: %w", refusal))
+		path := filepath.Join(t.TempDir(), "scrobbles.json")
+		s, err := NewScrobbler(path, svc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Start(context.Background())
+		s.Scrobble(listen("a"))
+		svc.wait(t)
+
+		select {
+		case n := <-s.Notices():
+			if n.Service != "fake" || !errors.Is(n.Err, refusal) {
+				t.Errorf("notice = %+v", n)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%v: no notice", refusal)
+		}
+		// Paused: further listens are queued but not submitted
+		s.Scrobble(listen("b"))
+		s.Stop()
+		if len(svc.calls) != 0 {
+			t.Errorf("%v: paused service called again", refusal)
+		}
+		if n := s.Pending("fake"); n != 2 {
+			t.Errorf("%v: Pending = %d, want the 2 listens kept", refusal, n)
+		}
+
+		// Kept for the next start
+		s, err = NewScrobbler(path, svc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := s.Pending("fake"); n != 2 {
+			t.Errorf("%v: Pending = %d after restart, want 2", refusal, n)
+		}
+	}
+}
+
+// authService is a fakeService that must log in first
+type authService struct {
+	*fakeService
+	key string
+}
+
+func (a *authService) Authenticate(ctx context.Context) (string, error) {
+	if a.key != "" {
+		return "", nil
+	}
+	a.key = "session"
+	return a.key, nil
+}
+
+func TestScrobbler_LogsInInBackground(t *testing.T) {
+	svc := &authService{fakeService: newFakeService()}
+	s, err := NewScrobbler("", svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start(context.Background())
+	defer s.Stop()
+	s.Scrobble(listen("a"))
+	svc.wait(t)
+
+	select {
+	case n := <-s.Notices():
+		if n.SessionKey != "session" || n.Err != nil {
+			t.Errorf("notice = %+v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no session key notice")
+	}
+}
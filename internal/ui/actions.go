@@ -0,0 +1,328 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// runAction does what action's key does, from any view. Keys and the
+// command palette both run actions through it.
+func (m *Model) runAction(action Action) tea.Cmd {
+	var cmds []tea.Cmd
+	if m.compact && leavesCompact(action) {
+		m.compact = false
+	}
+	switch action {
+	case ActionQuit:
+		m.cancel()
+		return tea.Quit
+
+	case ActionViewPlayer:
+		m.activeView = ViewPlayer
+	case ActionViewLibrary:
+		m.activeView = ViewLibrary
+	case ActionViewPlaylist:
+		m.activeView = ViewPlaylist
+	case ActionViewQueue:
+		m.activeView = ViewQueue
+		m.refreshQueue()
+	case ActionViewHistory: // Played tracks; again switches between recent and most played
+		if m.activeView == ViewHistory {
+			m.historyView.Mode = (m.historyView.Mode + 1) % 2
+		}
+		m.activeView = ViewHistory
+		m.refreshHistory()
+	case ActionViewBrowse:
+		m.activeView = ViewBrowse
+		m.refreshBrowse()
+
+	case ActionNextView:
+		m.focusView((m.activeView + 1) % viewCount)
+	case ActionPrevView:
+		m.focusView((m.activeView + viewCount - 1) % viewCount)
+
+	case ActionSearch: // Search the library, from any view
+		m.activeView = ViewLibrary
+		m.libraryView.StartSearch()
+
+	case ActionPlayPause: // Play/pause, or collapse/expand a library group header
+		if m.activeView == ViewLibrary && m.libraryView.OnGroupHeader() {
+			m.libraryView.ToggleGroup()
+			break
+		}
+		m.togglePlayback()
+
+	case ActionStop:
+		logger.Debug("User stopped playback")
+		m.gapUntil = time.Time{}
+		m.audioEngine.Stop()
+
+	case ActionNext: // Next (also skips an inter-track delay); moves on even under repeat one
+		m.skipNext()
+
+	case ActionPrevious: // Previous (only in player view)
+		if m.activeView == ViewPlayer {
+			if prev := m.queue.SkipBack(); prev != nil {
+				m.audioEngine.Play(prev)
+			}
+		}
+
+	case ActionSeekForward, ActionSeekBack, ActionSeekForwardLong, ActionSeekBackLong: // Seek by the short step, or the long one
+		state := m.audioEngine.GetState()
+		if (state.Status == api.StatusPlaying || state.Status == api.StatusPaused) && state.CurrentTrack != nil {
+			bar := &m.playerView.ProgressBar
+			bar.SetProgress(state.Position, state.CurrentTrack.Duration)
+			m.audioEngine.Seek(bar.SeekBy(m.seekSteps[action]))
+		}
+
+	case ActionVolumeUp, ActionVolumeDown, ActionMute: // Volume up / down (unmuting), mute toggle
+		volume := &m.playerView.Volume
+		switch action {
+		case ActionVolumeDown:
+			volume.Decrease()
+		case ActionMute:
+			volume.ToggleMute()
+		default:
+			volume.Increase()
+		}
+		m.audioEngine.SetVolume(float64(volume.Level()) / 100)
+
+	case ActionRepeat: // Cycle repeat
+		cmds = append(cmds, m.setRepeat((m.queue.GetRepeatMode()+1)%3))
+
+	case ActionResume: // Resume the playing track where it was last left off
+		cmds = append(cmds, m.resume())
+
+	case ActionTimeMode: // Cycle the progress bar time display
+		mode := m.playerView.ProgressBar.TimeMode.Next()
+		m.playerView.ProgressBar.TimeMode = mode
+		if m.onTimeModeChange != nil {
+			m.onTimeModeChange(mode)
+		}
+
+	case ActionEqualizer: // Open the equalizer panel
+		m.eqOpen = true
+
+	case ActionTheme: // Switch to the next color theme
+		cmds = append(cmds, m.cycleTheme())
+
+	case ActionSleepTimer: // Cycle the sleep timer
+		cmds = append(cmds, m.cycleSleep())
+
+	case ActionOutputDevice: // Pick the audio output device
+		cmds = append(cmds, m.listOutputs())
+
+	case ActionReplayGain: // Cycle ReplayGain normalization: off, track, album
+		mode := m.audioEngine.ReplayGain().Next()
+		m.audioEngine.SetReplayGain(mode)
+		if m.opts.OnReplayGainChange != nil {
+			m.opts.OnReplayGainChange(mode)
+		}
+		cmds = append(cmds, m.toast.Notify("ReplayGain: "+mode.String(), components.LevelInfo))
+
+	case ActionSkipSilence: // Toggle skipping silence at track boundaries
+		state := m.audioEngine.GetState()
+		m.audioEngine.SetSkipSilence(!state.SkipSilence)
+		if state.SkipSilence {
+			cmds = append(cmds, m.toast.Notify("Skip silence off", components.LevelInfo))
+		} else {
+			cmds = append(cmds, m.toast.Notify("Skip silence on (from next track)", components.LevelInfo))
+		}
+
+	case ActionTrimStart, ActionTrimEnd, ActionTrimClear: // Set trim start / end at the current position, or clear
+		state := m.audioEngine.GetState()
+		track := state.CurrentTrack
+		if track == nil {
+			break
+		}
+		start, end := track.TrimStart, track.TrimEnd
+		switch action {
+		case ActionTrimStart:
+			start = state.Position
+		case ActionTrimEnd:
+			end = state.Position
+		default:
+			start, end = 0, 0
+		}
+		if err := m.library.SetTrim(track, start, end); err != nil {
+			m.err = err
+			break
+		}
+		m.err = nil
+		cmds = append(cmds, m.toast.Notify(formatTrimStatus(start, end), components.LevelInfo))
+
+	case ActionBookmark: // Bookmark the current position, asking for a label
+		m.promptBookmark()
+
+	case ActionRemoveBookmark: // Remove the bookmark nearest the current position
+		cmds = append(cmds, m.removeBookmark())
+
+	case ActionPrevChapter, ActionNextChapter: // Jump to the previous / next chapter
+		state := m.audioEngine.GetState()
+		if (state.Status == api.StatusPlaying || state.Status == api.StatusPaused) && state.CurrentTrack != nil {
+			bar := &m.playerView.ProgressBar
+			bar.SetProgress(state.Position, state.CurrentTrack.Duration)
+			delta := 1
+			if action == ActionPrevChapter {
+				delta = -1
+			}
+			if at, ok := bar.JumpToChapter(delta); ok {
+				m.audioEngine.Seek(at)
+				bar.Current = at
+			}
+		}
+
+	case ActionPrevBookmark, ActionNextBookmark: // Jump to the previous / next bookmark
+		cmds = append(cmds, m.jumpBookmark(action == ActionPrevBookmark))
+
+	case ActionShuffle: // Toggle shuffle; turning it off continues in order from the current track
+		m.undoable("shuffle change")
+		if m.queue.IsShuffled() {
+			m.queue.Unshuffle()
+			cmds = append(cmds, m.toast.Notify("Shuffle off", components.LevelInfo))
+		} else {
+			m.queue.Shuffle()
+			cmds = append(cmds, m.toast.Notify("Shuffle on", components.LevelInfo))
+		}
+		m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+		m.playerView.UpNext = m.queue.PeekNext()
+
+	case ActionPreview: // Preview the selected track, or stop previewing it
+		track, _ := m.selectionContext()
+		if track == nil {
+			break
+		}
+		if preview := m.audioEngine.GetState().PreviewTrack; preview != nil && preview.ID == track.ID {
+			m.audioEngine.StopPreview()
+		} else {
+			m.audioEngine.Preview(track, m.previewOffset, m.previewLength, 1)
+		}
+
+	case ActionABLoop: // Set loop point A, then B, then clear the loop
+		state := m.audioEngine.GetState()
+		if state.CurrentTrack == nil {
+			break
+		}
+		if state.CurrentTrack.IsStream() {
+			cmds = append(cmds, m.toast.Notify("Streams can't loop", components.LevelWarning))
+			break
+		}
+		switch loop := state.Loop; {
+		case !loop.HasA:
+			m.audioEngine.SetLoopA(state.Position)
+			cmds = append(cmds, m.toast.Notify("Loop A at "+formatCountdown(state.Position.Round(time.Second))+" · [l] Set B", components.LevelInfo))
+		case !loop.HasB:
+			m.audioEngine.SetLoopB(state.Position)
+			loop = m.audioEngine.GetState().Loop
+			cmds = append(cmds, m.toast.Notify(fmt.Sprintf("Looping %s → %s · [l] Clear", formatCountdown(loop.A.Round(time.Second)), formatCountdown(loop.B.Round(time.Second))), components.LevelInfo))
+		default:
+			m.audioEngine.ClearLoop()
+			cmds = append(cmds, m.toast.Notify("Loop cleared", components.LevelInfo))
+		}
+		m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+
+	case ActionVisualizer: // Show or hide the spectrum in the player view
+		state := m.audioEngine.GetState()
+		m.playerView.Visualizer.Animate(state.Status == api.StatusPlaying)
+		cmds = append(cmds, m.playerView.Visualizer.Toggle())
+		if m.playerView.Visualizer.Enabled {
+			cmds = append(cmds, m.toast.Notify("Visualizer on", components.LevelInfo))
+		} else {
+			cmds = append(cmds, m.toast.Notify("Visualizer off", components.LevelInfo))
+		}
+
+	case ActionCompact: // Collapse the screen to the player's line, or restore it
+		m.compact = !m.compact
+		m.playerView.ProgressBar.ClearHover()
+
+	case ActionRate1, ActionRate2, ActionRate3, ActionRate4, ActionRate5, ActionRateClear: // Rate the selected track, or clear its rating
+		cmds = append(cmds, m.rate(m.selectedFile(), ratings[action]))
+
+	case ActionGainUp, ActionGainDown, ActionGainReset: // Offset the playing track's level, remembered for next time
+		cmds = append(cmds, m.nudgeGain(gainSteps[action]))
+
+	case ActionUndo: // Take back the latest queue, shuffle or sort change
+		cmds = append(cmds, m.undoLast())
+
+	case ActionLibraryFolder: // Scan another folder in place of the library's
+		cmds = append(cmds, m.promptLibraryFolder())
+
+	case ActionRescan: // Scan the library's folders again, rereading every file's tags
+		cmds = append(cmds, m.rescanLibraryCmd())
+
+	case ActionExportStats: // Write play counts by track, artist and album to a CSV or JSON file
+		cmds = append(cmds, m.promptExportStats())
+
+	case ActionRevealFolder, ActionCopyPath: // Show the selected track's file in the file manager, or copy its path
+		track := m.selectedFile()
+		if track == nil {
+			break
+		}
+		if track.IsStream() || track.FilePath == "" {
+			cmds = append(cmds, m.toast.Notify("Streams have no file to show", components.LevelWarning))
+			break
+		}
+		if action == ActionRevealFolder {
+			cmds = append(cmds, revealCmd(track.FilePath))
+		} else {
+			cmds = append(cmds, copyPathCmd(track.FilePath))
+		}
+
+	case ActionPalette: // Search for a command to run
+		m.openPalette()
+
+	case ActionPlaySelected:
+		// Play selected track, or collapse/expand a library group header
+		if m.activeView == ViewLibrary && m.libraryView.OnGroupHeader() {
+			m.libraryView.ToggleGroup()
+			break
+		}
+		if m.activeView == ViewBrowse && m.browseView.Descend() {
+			break
+		}
+		if m.activeView == ViewQueue {
+			if i := m.queueView.Selected(); i >= 0 {
+				m.playQueued(i)
+			}
+			break
+		}
+		if track, context := m.selectionContext(); track != nil {
+			cmds = append(cmds, m.playSelection(track, context))
+		}
+
+	case ActionPlayTrack: // Play just the selected track, whatever Enter does
+		if track, _ := m.selectionContext(); track != nil {
+			logger.Info("User selected track: %q by %s", track.Title, track.Artist)
+			m.playNow(track, nil)
+		}
+
+	}
+	return tea.Batch(cmds...)
+}
+
+// setRepeat sets the queue's repeat mode and says so
+func (m *Model) setRepeat(mode api.RepeatMode) tea.Cmd {
+	m.queue.SetRepeatMode(mode)
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	m.playerView.UpNext = m.queue.PeekNext()
+	return m.toast.Notify("Repeat: "+repeatModeName(mode), components.LevelInfo)
+}
+
+// focusView makes view the active one, bringing the lists that are only
+// kept up to date while shown up to date
+func (m *Model) focusView(view ViewType) {
+	m.activeView = view
+	switch view {
+	case ViewQueue:
+		m.refreshQueue()
+	case ViewHistory:
+		m.refreshHistory()
+	case ViewBrowse:
+		m.refreshBrowse()
+	}
+}
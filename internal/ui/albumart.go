@@ -0,0 +1,43 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// CoverArtMsg carries the embedded artwork read for a track's file; Data is
+// nil if it has none
+type CoverArtMsg struct {
+	Path string
+	Data []byte
+}
+
+// loadAlbumArt starts reading the playing track's artwork when the track
+// changes. Tracks already carrying CoverArt use it directly.
+func (m *Model) loadAlbumArt(state *api.PlaybackState) tea.Cmd {
+	art := &m.playerView.Art
+	track := state.CurrentTrack
+	if art.Protocol == components.ImageOff || track == nil || track.FilePath == art.Key() {
+		return nil
+	}
+	// Show the placeholder until the new artwork arrives
+	art.SetImage(track.FilePath, nil)
+
+	path, data := track.FilePath, track.CoverArt
+	return func() tea.Msg {
+		return CoverArtMsg{Path: path, Data: coverArt(path, data)}
+	}
+}
+
+// coverArt returns a track's artwork: embedded, if it was kept with the
+// track, or else what the file at path has. It reads the file, so is for
+// use off the UI goroutine.
+func coverArt(path string, embedded []byte) []byte {
+	if embedded != nil {
+		return embedded
+	}
+	data, _ := library.NewMetadataReader().ReadCoverArt(path)
+	return data
+}
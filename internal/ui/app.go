@@ -3,6 +3,9 @@ package ui
 import (
 	"context"
 	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,8 +14,12 @@ import (
 	"github.com/jscyril/golang_music_player/internal/audio"
 	"github.com/jscyril/golang_music_player/internal/library"
 	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/mpris"
 	"github.com/jscyril/golang_music_player/internal/playlist"
+	"github.com/jscyril/golang_music_player/internal/scrobble"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
 	"github.com/jscyril/golang_music_player/internal/ui/views"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
 )
 
 // ViewType represents the current active view
@@ -22,6 +29,11 @@ const (
 	ViewPlayer ViewType = iota
 	ViewLibrary
 	ViewPlaylist
+	ViewQueue
+	ViewHistory
+	ViewBrowse
+
+	viewCount // Number of views, for cycling through them
 )
 
 // Model is the main bubbletea model
@@ -37,82 +49,467 @@ type Model struct {
 	playerView   views.PlayerView
 	libraryView  views.LibraryView
 	playlistView views.PlaylistView
+	queueView    views.QueueView
+
+	historyView views.HistoryView
+	browseView  views.BrowseView
 
 	// Components
-	audioEngine     *audio.AudioEngine
+	audioEngine     Engine
 	library         *library.Library
 	playlistManager *playlist.Manager
+	smartPlaylists  []*playlist.SmartPlaylist
+	smartLists      []*api.Playlist // Last evaluation of each of smartPlaylists
 	queue           *playlist.Queue
 
 	// State
 	ctx    context.Context
 	cancel context.CancelFunc
 	err    error
+	status string // Transient notice shown below the views
+
+	// Inter-track gap: when set, the next track starts at gapUntil
+	trackDelay time.Duration
+
+	opts Options // As given to NewModel, for the settings read where they're used
+
+	onTimeModeChange func(components.TimeMode)
+
+	// Equalizer panel, shown over the active view while open
+	eqOpen    bool
+	eqBand    int
+	eqChanged bool // Since the panel opened, to be saved when it closes
+
+	outputs *outputPanel // Audio output selector, shown over the active view while set
+
+	compact bool // Show only the player, on one line
+
+	// palette is the open command palette; while set, keys go to it
+	palette *commandPalette
+
+	autoPlay bool
+	startAt  time.Duration
+
+	availabilityGen int // Current periodic availability check
+	gapUntil        time.Time
+	gapID           int
+
+	enterAction    EnterAction
+	confirmReplace bool
+
+	previewOffset float64 // Fraction into the track previews start at
+	previewLength time.Duration
+
+	seekSteps map[Action]time.Duration // How far each seek action moves
+
+	positions   *library.Positions
+	watcher     *library.Watcher
+	nextSent    *api.Track   // Track last given to the engine as the next one
+	nextJoined  bool         // Whether nextSent was to be joined on
+	playingPath string       // Track whose position is being remembered
+	resumeOffer *resumeOffer // Where the playing track was last left off
+
+	plays scrobble.PlayTracker // How long the playing track has been heard, for scrobbling
+
+	bookmarks *library.Bookmarks
+	naming    *bookmarkPrompt // Bookmark being labelled; while set, keys edit its label
+
+	asking *pathPrompt // Path being entered; while set, keys edit it
+
+	prelisten prelistenState // The library selection being prelistened
+
+	playStats *library.PlayStats
+
+	playFailures map[string]int // Times each track, by ID, has failed to play
+	failedInRow  int            // Queued tracks skipped for failing since one played
 
-	// Styles
+	sessionSaved time.Time // When the session was last saved
+
+	notice trackNotice // Desktop notification for the playing track
+	stream streamMeta  // What the radio stream last played says it's playing
+
+	// Tracks whose duration is being decoded in the background, the head
+	// of it first
+	durationQueue []*api.Track
+
+	sleep sleepTimer
+
+	// Pending yes/no question; while set, keys answer it instead of acting
+	confirm *confirmPrompt
+	toast   components.Toast // Transient notices, shown in the footer corner
+
+	undo undoStack // Queue and sort changes u can take back
+
+	keys Keymap // Global keys' actions
+
+	// Styles, in the colors of theme
+	theme          components.Theme
 	tabStyle       lipgloss.Style
 	activeTabStyle lipgloss.Style
 	headerStyle    lipgloss.Style
 }
 
+// confirmPrompt asks the user to confirm a destructive action
+type confirmPrompt struct {
+	details  []string // Optional preview lines shown above the question
+	question string
+	onYes    func(m *Model) tea.Cmd
+}
+
+// Options holds UI settings taken from the configuration
+type Options struct {
+	// TrackDelay is the pause before auto-advancing to the next track
+	TrackDelay time.Duration
+
+	// OrganizeRoot and OrganizePattern control where "organize" moves files
+	OrganizeRoot    string
+	OrganizePattern string
+
+	// RowTint colors library rows by an attribute
+	RowTint views.RowTint
+
+	// LibraryColumns, if set, lays library rows out in these columns
+	LibraryColumns []components.Column
+
+	// ExactAccents makes the library search tell accented letters from
+	// plain ones
+	ExactAccents bool
+
+	// TimeMode is the initial progress bar time display; OnTimeModeChange,
+	// if set, is called when the user switches it so it can be persisted
+	TimeMode         components.TimeMode
+	OnTimeModeChange func(components.TimeMode)
+
+	// SnapSeek rounds seeks from the progress bar and seek keys to whole
+	// seconds
+	SnapSeek bool
+
+	// SeekStep and SeekStepLong are how far the seek keys and their shifted
+	// versions move; 0 means 5 and 30 seconds
+	SeekStep     time.Duration
+	SeekStepLong time.Duration
+
+	// OnEqualizerChange, if set, is called with the band gains and bypass
+	// state when the user closes the equalizer panel after changing it, or
+	// quits with it open
+	OnEqualizerChange func(gains []float64, bypass bool)
+
+	// SearchHistory is the library's past search queries, most recent
+	// first; OnSearchHistoryChange, if set, is called when a query is
+	// added so the history can be saved
+	SearchHistory         []string
+	OnSearchHistoryChange func([]string)
+
+	// OnReplayGainChange, if set, is called when the user switches the
+	// ReplayGain mode so it can be persisted
+	OnReplayGainChange func(audio.ReplayGainMode)
+
+	// Theme is the palette at startup, the default if unset; OnThemeChange,
+	// if set, is called when the user switches it so it can be persisted
+	Theme         components.Theme
+	OnThemeChange func(components.Theme)
+
+	// EnterAction chooses what Enter does; with ConfirmReplaceQueue,
+	// replacing a non-empty queue asks first
+	EnterAction         EnterAction
+	ConfirmReplaceQueue bool
+
+	// PreviewOffset (0-1) and PreviewLength set where previews start and
+	// how long they play
+	PreviewOffset float64
+	PreviewLength time.Duration
+
+	// Prelisten previews the library's selected track, at PrelistenVolume
+	// (0-1) of the playback volume, once the selection has rested on it
+	// for PrelistenDelay; 0 means a second
+	Prelisten       bool
+	PrelistenDelay  time.Duration
+	PrelistenVolume float64
+
+	// ShuffleSpread keeps tracks by the same artist or album this many
+	// tracks apart when shuffling; 0 is plain shuffle
+	ShuffleSpread int
+
+	// PlaylistExportDir is where W saves the listed tracks as M3U
+	PlaylistExportDir string
+
+	// AlbumArt is how the player view shows the playing track's artwork
+	AlbumArt components.ImageProtocol
+
+	// Watcher, if set and started, reports files added to or removed from
+	// the music folders so the library view updates without a restart.
+	// The caller stops it.
+	Watcher *library.Watcher
+
+	// Positions remembers where each track was left off so it can be
+	// resumed with R; nil disables it
+	Positions *library.Positions
+
+	// Bookmarks holds named positions within tracks, added with b and
+	// jumped between with , and .; nil disables them
+	Bookmarks *library.Bookmarks
+
+	// SleepQuit quits once the sleep timer has paused playback, rather
+	// than leaving the player open
+	SleepQuit bool
+
+	// PlayStats counts the plays of each track, for the Recently Played
+	// and Most Played views; nil leaves them empty
+	PlayStats *library.PlayStats
+
+	// SmartPlaylists are listed after the saved playlists, holding the
+	// library's tracks their rules match as the library and play counts
+	// change
+	SmartPlaylists []*playlist.SmartPlaylist
+
+	// MPRIS, if set, is told what's playing and its clients' commands
+	// control playback. The caller closes it.
+	MPRIS *mpris.Server
+
+	// Scrobbler, if set, is sent the tracks played for scrobbling. The
+	// caller starts and stops it. OnScrobbleLogin, if set, is called with
+	// the session key a service logged in with, to be saved in place of
+	// the password.
+	Scrobbler       *scrobble.Scrobbler
+	OnScrobbleLogin func(service, sessionKey string)
+
+	// Notifications shows a desktop notification as each track starts.
+	// The track's artwork is written to NotifyCover, if set, for the
+	// notification's icon.
+	Notifications bool
+	NotifyCover   string
+
+	// InitialQueue is loaded into the queue at startup. With AutoPlay its
+	// first track starts playing right away, at StartAt if set (clamped to
+	// the track's duration).
+	InitialQueue []*api.Track
+	AutoPlay     bool
+	StartAt      time.Duration
+
+	// ScanDirectories, if set, are scanned into the library in the
+	// background once the UI is up, the tracks showing as they're found.
+	// Dedup then hides the duplicates among them, as it does for a
+	// library loaded from its file or moved to another folder.
+	ScanDirectories []string
+	Dedup           library.DedupMode
+
+	// OnLibraryFolderChange, if set, is called with the folder the library
+	// has been moved to, so it can be scanned on the next launch
+	OnLibraryFolderChange func(dir string)
+
+	// Session, if set, is the last session to pick up from: its view,
+	// volume and modes, and its queue unless InitialQueue is given.
+	// OnSessionSave, if set, is called with the session to save every so
+	// often and on exit.
+	Session       *Session
+	OnSessionSave func(Session)
+
+	// InputTTY reads keys from the terminal rather than stdin, for when
+	// stdin was used to pipe in data
+	InputTTY bool
+
+	// Keys resolves global keys to their actions; the zero Keymap uses the
+	// default keys
+	Keys Keymap
+
+	// DefaultSort is the library's sort order at startup; Shuffle and
+	// Repeat are the queue's modes at startup
+	DefaultSort views.SortField
+	Shuffle     bool
+	Repeat      api.RepeatMode
+
+	// Volume (0-1) is the playback volume the engine was started at, for
+	// the volume bar to show
+	Volume float64
+}
+
 // TickMsg is sent periodically to update the UI
 type TickMsg time.Time
 
 // StateUpdateMsg is sent when playback state changes
 type StateUpdateMsg struct {
-	State *api.PlaybackState
+	State   *api.PlaybackState
+	Started bool // A track started playing, possibly the same one again
+}
+
+// TrackEndedMsg is sent once when a track plays to its end, without a next
+// track taking over. Track is the track that ended.
+type TrackEndedMsg struct {
+	Track *api.Track
+}
+
+// GapElapsedMsg is sent when an inter-track delay has run out
+type GapElapsedMsg struct {
+	id int
+}
+
+// AvailabilityMsg carries the result of a background availability check
+type AvailabilityMsg struct {
+	Unavailable map[string]library.Availability
 }
 
-// TrackEndedMsg is sent when a track finishes playing
-type TrackEndedMsg struct{}
+// availabilityTickMsg triggers a periodic re-check while offline tracks are
+// hidden, so plugging a drive back in shows its tracks again. Ticks from an
+// older generation are dropped so toggling doesn't stack up timers.
+type availabilityTickMsg struct {
+	gen int
+}
+
+// availabilityInterval is how often availability is re-checked
+const availabilityInterval = 30 * time.Second
+
+// Seek steps of the seek keys unless configured otherwise
+const (
+	defaultSeekStep     = 5 * time.Second
+	defaultSeekStepLong = 30 * time.Second
+)
+
+// newSeekSteps maps the seek actions to how far they move the playback
+// position, short and long forward and back; a step of 0 is the default
+func newSeekSteps(short, long time.Duration) map[Action]time.Duration {
+	if short <= 0 {
+		short = defaultSeekStep
+	}
+	if long <= 0 {
+		long = defaultSeekStepLong
+	}
+	return map[Action]time.Duration{
+		ActionSeekForward:     short,
+		ActionSeekBack:        -short,
+		ActionSeekForwardLong: long,
+		ActionSeekBackLong:    -long,
+	}
+}
+
+// OrganizedMsg is sent when organize moves have been applied
+type OrganizedMsg struct {
+	Moves []library.Move
+}
+
+// PlaylistSavedMsg is sent when tracks have been saved to a playlist file
+type PlaylistSavedMsg struct {
+	Path  string
+	Count int
+	Err   error
+}
+
+// FolderQueuedMsg is sent when a background folder scan for the queue completes
+type FolderQueuedMsg struct {
+	Path    string
+	Tracks  []*api.Track
+	Skipped []*playerrors.ScanError // Files and folders that couldn't be read
+	Err     error
+}
 
 // NewModel creates a new application model
-func NewModel(engine *audio.AudioEngine, lib *library.Library, plManager *playlist.Manager) Model {
+func NewModel(engine Engine, lib *library.Library, plManager *playlist.Manager, opts Options) Model {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := Model{
-		width:           80,
-		height:          24,
-		activeView:      ViewLibrary,
-		audioEngine:     engine,
-		library:         lib,
-		playlistManager: plManager,
-		queue:           playlist.NewQueue(),
-		ctx:             ctx,
-		cancel:          cancel,
-		tabStyle: lipgloss.NewStyle().
-			Padding(0, 2).
-			Foreground(lipgloss.Color("240")),
-		activeTabStyle: lipgloss.NewStyle().
-			Padding(0, 2).
-			Bold(true).
-			Foreground(lipgloss.Color("212")).
-			Background(lipgloss.Color("236")),
-		headerStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("212")).
-			MarginBottom(1),
+		width:            80,
+		height:           24,
+		activeView:       ViewLibrary,
+		audioEngine:      engine,
+		library:          lib,
+		playlistManager:  plManager,
+		smartPlaylists:   opts.SmartPlaylists,
+		queue:            playlist.NewQueue(),
+		toast:            components.NewToast(),
+		trackDelay:       opts.TrackDelay,
+		opts:             opts,
+		onTimeModeChange: opts.OnTimeModeChange,
+		autoPlay:         opts.AutoPlay,
+		startAt:          opts.StartAt,
+		enterAction:      opts.EnterAction,
+		previewOffset:    opts.PreviewOffset,
+		seekSteps:        newSeekSteps(opts.SeekStep, opts.SeekStepLong),
+		previewLength:    opts.PreviewLength,
+		positions:        opts.Positions,
+		bookmarks:        opts.Bookmarks,
+		playStats:        opts.PlayStats,
+		playFailures:     make(map[string]int),
+		sessionSaved:     time.Now(),
+		watcher:          opts.Watcher,
+		confirmReplace:   opts.ConfirmReplaceQueue,
+		keys:             opts.Keys,
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 
 	// Initialize views
-	m.playerView = views.NewPlayerView(m.width, m.height/3)
-	m.libraryView = views.NewLibraryView(m.width, m.height-10)
-	m.playlistView = views.NewPlaylistView(m.width, m.height-10)
+	m.playerView = views.NewPlayerView(m.width, contentHeight(m.height))
+	m.playerView.ProgressBar.TimeMode = opts.TimeMode
+	m.playerView.ProgressBar.SnapSeconds = opts.SnapSeek
+	m.playerView.Art = components.NewAlbumArt(opts.AlbumArt)
+	m.playerView.Volume.SetVolume(int(math.Round(opts.Volume * 100)))
+	m.playerView.Visualizer.Source = m.audioEngine.Spectrum
+	m.libraryView = views.NewLibraryView(m.width, contentHeight(m.height))
+	m.playlistView = views.NewPlaylistView(m.width, contentHeight(m.height))
+	m.queueView = views.NewQueueView(m.width, contentHeight(m.height))
+	m.historyView = views.NewHistoryView(m.width, contentHeight(m.height), views.HistoryRecent)
+	m.browseView = views.NewBrowseView(m.width, contentHeight(m.height))
+	if opts.Theme.Name == "" {
+		opts.Theme = components.DefaultTheme
+	}
+	m.setTheme(opts.Theme)
+
+	if m.keys.actions == nil {
+		m.keys = DefaultKeymap()
+	}
 
 	// Load library tracks into view
+	m.libraryView.SortField = opts.DefaultSort
+	m.libraryView.ExactAccents = opts.ExactAccents
+	m.libraryView.ListDir = lib.ListDir
 	m.libraryView.SetTracks(lib.GetAllTracks())
+	for _, track := range lib.GetAllTracks() {
+		if library.NeedsDuration(track) {
+			m.durationQueue = append(m.durationQueue, track)
+		}
+	}
+	m.libraryView.Loading = len(opts.ScanDirectories) > 0
+	m.libraryView.SetRowTint(opts.RowTint)
+	m.libraryView.TrackList.SetColumns(opts.LibraryColumns)
+	m.libraryView.SearchBar.SetHistory(opts.SearchHistory)
+	m.refreshHistory()
+
+	m.queue.SetSmartShuffle(opts.ShuffleSpread)
+	m.queue.SetRepeatMode(opts.Repeat)
+	if len(opts.InitialQueue) > 0 {
+		m.queue.Set(opts.InitialQueue)
+		if opts.AutoPlay {
+			m.activeView = ViewPlayer
+		} else {
+			m.status = fmt.Sprintf("Queued %d tracks. Press Space to play.", len(opts.InitialQueue))
+		}
+	}
+	if opts.Shuffle {
+		m.queue.Shuffle()
+	}
+	if opts.Session != nil {
+		m.restoreSession(opts.Session, len(opts.InitialQueue) > 0)
+	}
 
 	// Load playlists
-	m.playlistView.SetPlaylists(plManager.GetAll())
+	m.refreshPlaylists()
 
 	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
+	if m.autoPlay {
+		m.startInitialTrack()
+	}
 	return tea.Batch(
 		tickCmd(),
 		m.listenForEvents(),
+		m.watchLibrary(),
+		m.listenMPRIS(),
+		m.listenScrobbler(),
+		m.scanDurationCmd(),
+		m.loadLibraryCmd(m.opts.ScanDirectories),
+		m.startupDedupCmd(),
 	)
 }
 
@@ -129,11 +526,23 @@ func (m Model) listenForEvents() tea.Cmd {
 		select {
 		case event := <-m.audioEngine.Events():
 			switch event.Type {
-			case api.EventStateChange, api.EventTrackStarted, api.EventPositionUpdate:
+			case api.EventStateChange, api.EventPositionUpdate:
 				return StateUpdateMsg{State: m.audioEngine.GetState()}
+			case api.EventTrackStarted:
+				return StateUpdateMsg{State: m.audioEngine.GetState(), Started: true}
 			case api.EventTrackEnded:
-				return TrackEndedMsg{}
+				track, _ := event.Payload.(*api.Track)
+				return TrackEndedMsg{Track: track}
+			case api.EventTrackAdvanced:
+				track, _ := event.Payload.(*api.Track)
+				return TrackAdvancedMsg{Track: track}
+			case api.EventStreamInfo:
+				info, _ := event.Payload.(audio.StreamInfo)
+				return StreamInfoMsg{Info: info}
 			case api.EventError:
+				if pe, ok := event.Payload.(*audio.PlaybackError); ok {
+					return PlaybackErrorMsg{Track: pe.Track, Err: pe.Err, Preview: pe.Preview}
+				}
 				return StateUpdateMsg{State: m.audioEngine.GetState()}
 			}
 		case <-m.ctx.Done():
@@ -155,27 +564,101 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case TickMsg:
 		// Update playback state
-		state := m.audioEngine.GetState()
+		state := m.playbackState(m.audioEngine.GetState())
 		m.playerView.SetState(state)
-		cmds = append(cmds, tickCmd())
+		if state.Status == api.StatusPlaying {
+			m.failedInRow = 0
+			if track := state.CurrentTrack; track != nil && m.playFailures[track.ID] > 0 {
+				// It plays after all, e.g. once its file was replaced
+				delete(m.playFailures, track.ID)
+				m.libraryView.SetUnplayable(track, false)
+			}
+		}
+		m.playerView.UpNext = m.queue.PeekNext()
+		m.playerView.UpNextPinned = m.queue.Pinned() != nil
+		m.syncNextTrack()
+		m.publishMPRIS(state)
+		cmds = append(cmds, m.trackPlay(state))
+		m.showBookmarks(state.CurrentTrack)
+		m.libraryView.SetPlaying(state.CurrentTrack)
+		if m.activeView == ViewQueue {
+			m.queueView.SetQueue(m.queue.GetAll(), m.queue.Index())
+		}
+		cmds = append(cmds, m.playerView.ProgressBar.Animate(state.Status == api.StatusPlaying))
+		cmds = append(cmds, m.playerView.Visualizer.Animate(state.Status == api.StatusPlaying))
+		if state.CurrentTrack != nil {
+			m.playerView.Cued = nil
+		}
+		m.prelistenSelected(time.Time(msg))
+		cmds = append(cmds, m.trackPosition(state), m.loadAlbumArt(state), m.notifyTrack(state, time.Time(msg)), m.saveSessionCmd(time.Time(msg)), tickCmd())
+
+	case NotifyFailedMsg:
+		m.notifyFailed(msg)
 
 	case StateUpdateMsg:
-		m.playerView.SetState(msg.State)
+		if msg.Started {
+			// Even a track played again is announced again
+			m.notice = trackNotice{}
+		}
+		m.playerView.SetState(m.playbackState(msg.State))
+		cmds = append(cmds, m.listenForEvents())
+
+	case TracksBatchMsg:
+		cmds = append(cmds, m.tracksLoaded(msg))
+
+	case LoadDoneMsg:
+		cmds = append(cmds, m.loadDone(msg))
+
+	case DuplicatesHiddenMsg:
+		cmds = append(cmds, m.duplicatesHidden(msg))
+
+	case TrackAdvancedMsg:
+		m.trackAdvanced(msg)
 		cmds = append(cmds, m.listenForEvents())
 
+	case StreamInfoMsg:
+		cmds = append(cmds, m.streamInfo(msg.Info), m.listenForEvents())
+
+	case PlaybackErrorMsg:
+		cmds = append(cmds, m.playbackFailed(msg), m.listenForEvents())
+
 	case TrackEndedMsg:
 		// Auto-advance to next track (handled inside Update for thread safety)
 		logger.Debug("TrackEndedMsg received, advancing to next track")
-		if next := m.queue.Next(); next != nil {
+		if current := m.queue.Current(); msg.Track != nil && current != nil && current.ID != msg.Track.ID {
+			// Another track was started before the end was handled, e.g.
+			// by skipping just as it finished; advancing would skip that too
+			logger.Debug("Ignoring end of %q, no longer current", msg.Track.Title)
+		} else if m.sleep.afterTrack {
+			// The sleep timer was waiting for this track to finish
+			cmds = append(cmds, m.sleepExpired(0))
+		} else if m.trackDelay > 0 && m.queue.PeekNext() != nil {
+			// Wait before advancing; GapElapsedMsg starts the next track
+			m.gapID++
+			m.gapUntil = time.Now().Add(m.trackDelay)
+			id := m.gapID
+			cmds = append(cmds, tea.Tick(m.trackDelay, func(time.Time) tea.Msg {
+				return GapElapsedMsg{id: id}
+			}))
+		} else if next := m.queue.Next(); next != nil {
 			logger.Info("Auto-advancing to next track: %q", next.Title)
 			m.audioEngine.Play(next)
 		} else {
 			logger.Info("Queue exhausted, no next track")
 		}
-		state := m.audioEngine.GetState()
-		m.playerView.SetState(state)
+		m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
 		cmds = append(cmds, m.listenForEvents())
 
+	case GapElapsedMsg:
+		// Ignore stale gaps that were skipped or cancelled
+		if msg.id == m.gapID && m.gapPending() {
+			m.gapUntil = time.Time{}
+			if next := m.queue.Next(); next != nil {
+				logger.Info("Advancing to next track after delay: %q", next.Title)
+				m.audioEngine.Play(next)
+			}
+		}
+
 	case views.FileAddedMsg:
 		// Add file to library
 		logger.Info("Adding file to library: %s", msg.Path)
@@ -187,226 +670,706 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			logger.Info("Added track: %q by %s", track.Title, track.Artist)
 			// Update the library view with the new track
 			m.libraryView.AddTrack(track)
+			m.refreshPlaylists()
 		}
 
-	case tea.KeyMsg:
-		// If library view is in search mode, pass keys directly to it
-		// (except for critical global keys like quit)
-		if m.activeView == ViewLibrary && (m.libraryView.Searching || m.libraryView.Browsing) {
-			switch msg.String() {
-			case "ctrl+c":
-				m.cancel()
-				return m, tea.Quit
-			default:
-				m.libraryView, _ = m.libraryView.Update(msg)
-				return m, tea.Batch(cmds...)
+	case views.QueueFolderMsg:
+		logger.Info("Queueing folder: %s", msg.Path)
+		m.status = "Scanning " + msg.Path + "..."
+		cmds = append(cmds, m.queueFolderCmd(msg.Path))
+
+	case FolderQueuedMsg:
+		if msg.Err != nil {
+			logger.Error("Failed to queue folder %s: %v", msg.Path, msg.Err)
+			m.err = msg.Err
+		} else {
+			m.queue.Add(msg.Tracks...)
+			m.libraryView.SetTracks(m.library.GetAllTracks())
+			m.refreshPlaylists()
+			cmds = append(cmds, m.queueDurations(msg.Tracks))
+			if m.libraryView.HideOffline {
+				cmds = append(cmds, m.checkAvailabilityCmd())
+			}
+			logger.Info("Queued %d tracks from %s", len(msg.Tracks), msg.Path)
+			for _, s := range msg.Skipped {
+				logger.Warn("Skipped while queueing folder: %v", s)
+			}
+			if len(msg.Skipped) > 0 {
+				cmds = append(cmds, m.toast.Notify(fmt.Sprintf("Added %d tracks, %d skipped", len(msg.Tracks), len(msg.Skipped)), components.LevelWarning))
+			} else {
+				cmds = append(cmds, m.toast.Notify(fmt.Sprintf("Added %d tracks", len(msg.Tracks)), components.LevelSuccess))
 			}
 		}
+		m.status = ""
 
-		// Global keybindings (only active when not searching)
-		switch msg.String() {
-		case "q", "ctrl+c":
-			m.cancel()
-			return m, tea.Quit
+	case views.PlayNextMsg:
+		m.undoable("play next")
+		m.queue.InsertNext(msg.Track)
+		m.playerView.UpNext = m.queue.PeekNext()
+		cmds = append(cmds, m.toast.Notify("Playing next: "+msg.Track.Title, components.LevelInfo))
 
-		case "1":
-			m.activeView = ViewPlayer
-		case "2":
-			m.activeView = ViewLibrary
-		case "3":
-			m.activeView = ViewPlaylist
+	case views.EnqueueMsg:
+		cmds = append(cmds, m.enqueue(msg.Tracks))
 
-		case "tab":
-			m.activeView = (m.activeView + 1) % 3
+	case views.QueueMoveMsg:
+		m.undoable("move in the queue")
+		if m.queue.Move(msg.From, msg.To) != nil {
+			m.undo.pop() // Nothing moved
+			break
+		}
+		m.refreshQueue()
 
-		case " ": // Space - play/pause
-			state := m.audioEngine.GetState()
-			if state.Status == api.StatusPlaying {
-				logger.Debug("User paused playback")
-				m.audioEngine.Pause()
-			} else if state.Status == api.StatusPaused {
-				logger.Debug("User resumed playback")
-				m.audioEngine.Resume()
-			} else if m.queue.Current() != nil {
-				logger.Debug("User started playback from stopped state")
-				m.audioEngine.Play(m.queue.Current())
-			}
+	case views.QueueRemoveMsg:
+		cmds = append(cmds, m.removeQueued(msg))
 
-		case "s": // Stop
-			logger.Debug("User stopped playback")
-			m.audioEngine.Stop()
+	case views.AddToPlaylistMsg:
+		cmds = append(cmds, m.addToPlaylist(msg.Tracks))
 
-		case "n": // Next
-			if next := m.queue.Next(); next != nil {
-				logger.Info("User skipped to next track: %q", next.Title)
-				m.audioEngine.Play(next)
-			}
+	case views.PlaylistMoveMsg:
+		cmds = append(cmds, m.editPlaylist(msg.PlaylistID, "move in the playlist", func() error {
+			return m.playlistManager.MoveTrack(msg.PlaylistID, msg.From, msg.To)
+		}))
 
-		case "p": // Previous (only in player view)
-			if m.activeView == ViewPlayer {
-				if prev := m.queue.Previous(); prev != nil {
-					m.audioEngine.Play(prev)
-				}
+	case views.PlaylistRemoveMsg:
+		cmds = append(cmds, m.editPlaylist(msg.PlaylistID, "removal from the playlist", func() error {
+			return m.playlistManager.RemoveTrackAt(msg.PlaylistID, msg.Index)
+		}))
+
+	case views.HistoryClearMsg:
+		m.confirm = &confirmPrompt{
+			question: "Forget every play counted so far?",
+			onYes: func(m *Model) tea.Cmd {
+				m.playStats.Clear()
+				m.refreshHistory()
+				m.refreshPlaylists()
+				return tea.Batch(m.toast.Notify("Play history cleared", components.LevelInfo), saveCmd("play stats", m.playStats.Save))
+			},
+		}
+
+	case views.QueueClearMsg:
+		m.confirm = &confirmPrompt{
+			question: fmt.Sprintf("Clear the queue (%d tracks)?", m.queue.Len()),
+			onYes: func(m *Model) tea.Cmd {
+				m.undoable("clearing the queue")
+				m.queue.Clear()
+				m.refreshQueue()
+				return m.toast.Notify("Queue cleared", components.LevelInfo)
+			},
+		}
+
+	case views.PinMsg:
+		if pinned := m.queue.Pinned(); pinned != nil && pinned.ID == msg.Track.ID {
+			m.queue.Unpin()
+			cmds = append(cmds, m.toast.Notify("Unpinned "+msg.Track.Title, components.LevelInfo))
+		} else {
+			m.queue.Pin(msg.Track)
+			cmds = append(cmds, m.toast.Notify("Pinned as up next: "+msg.Track.Title, components.LevelInfo))
+		}
+		m.playerView.UpNext = m.queue.PeekNext()
+		m.playerView.UpNextPinned = m.queue.Pinned() != nil
+
+	case views.CheckAvailabilityMsg:
+		m.availabilityGen++
+		cmds = append(cmds, m.checkAvailabilityCmd(), availabilityTick(m.availabilityGen))
+
+	case availabilityTickMsg:
+		if m.libraryView.HideOffline && msg.gen == m.availabilityGen {
+			cmds = append(cmds, m.checkAvailabilityCmd(), availabilityTick(msg.gen))
+		}
+
+	case AvailabilityMsg:
+		m.libraryView.SetAvailability(msg.Unavailable)
+
+	case views.QueueTracksMsg:
+		tracks := msg.Tracks
+		if !msg.Replace {
+			m.undoable("queueing")
+			m.queue.Add(tracks...)
+			cmds = append(cmds, m.toast.Notify(fmt.Sprintf("Queued %d tracks", len(tracks)), components.LevelSuccess))
+			break
+		}
+		replace := func(m *Model) tea.Cmd {
+			m.undoable("replacing the queue")
+			shuffled := m.queue.IsShuffled()
+			m.queue.Set(tracks)
+			if shuffled {
+				m.queue.Shuffle()
 			}
+			m.audioEngine.Play(tracks[0])
+			return m.toast.Notify(fmt.Sprintf("Replaced queue with %d tracks", len(tracks)), components.LevelSuccess)
+		}
+		if m.queue.Len() == 0 {
+			cmds = append(cmds, replace(&m))
+			break
+		}
+		m.confirm = &confirmPrompt{
+			question: fmt.Sprintf("Replace the queue (%d tracks) with %d tracks?", m.queue.Len(), len(tracks)),
+			onYes:    replace,
+		}
 
-		case "right": // Seek forward 5 seconds
-			state := m.audioEngine.GetState()
-			if state.Status == api.StatusPlaying || state.Status == api.StatusPaused {
-				newPos := state.Position + 5*time.Second
-				if state.CurrentTrack != nil && newPos > state.CurrentTrack.Duration {
-					newPos = state.CurrentTrack.Duration
-				}
-				m.audioEngine.Seek(newPos)
+	case views.OrganizeMsg:
+		if m.opts.OrganizeRoot == "" {
+			m.err = fmt.Errorf("organize: no organize_root or music directory configured")
+			break
+		}
+		moves := library.PlanOrganize(msg.Tracks, m.opts.OrganizeRoot, m.opts.OrganizePattern)
+		details, pending := previewMoves(moves)
+		if pending == 0 {
+			cmds = append(cmds, m.toast.Notify("Nothing to organize", components.LevelInfo))
+			break
+		}
+		m.confirm = &confirmPrompt{
+			details:  details,
+			question: fmt.Sprintf("Move %d files?", pending),
+			onYes: func(m *Model) tea.Cmd {
+				m.status = fmt.Sprintf("Moving %d files...", pending)
+				return applyMovesCmd(moves)
+			},
+		}
+
+	case OrganizedMsg:
+		cmds = append(cmds, m.organized(msg))
+
+	case views.SearchHistoryMsg:
+		if m.opts.OnSearchHistoryChange != nil {
+			m.opts.OnSearchHistoryChange(msg.History)
+		}
+
+	case views.SavePlaylistMsg:
+		if m.opts.PlaylistExportDir == "" {
+			m.err = fmt.Errorf("save playlist: no playlist_export_dir configured")
+			break
+		}
+		name := "library-" + time.Now().Format("20060102-150405") + ".m3u8"
+		cmds = append(cmds, savePlaylistCmd(filepath.Join(m.opts.PlaylistExportDir, name), msg.Tracks))
+
+	case FolderRevealedMsg:
+		if msg.Err != nil {
+			logger.Warn("Reveal %s: %v", msg.Path, msg.Err)
+			cmds = append(cmds, m.toast.Notify("Couldn't open folder: "+msg.Err.Error(), components.LevelError))
+			break
+		}
+		cmds = append(cmds, m.toast.Notify("Opened "+folderName(msg.Path), components.LevelSuccess))
+
+	case PathCopiedMsg:
+		if msg.Err != nil {
+			logger.Warn("Copy %s: %v", msg.Path, msg.Err)
+			cmds = append(cmds, m.toast.Notify("Couldn't copy path: "+msg.Err.Error(), components.LevelError))
+			break
+		}
+		cmds = append(cmds, m.toast.Notify("Copied "+msg.Path, components.LevelSuccess))
+
+	case PlaylistSavedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			break
+		}
+		cmds = append(cmds, m.toast.Notify(fmt.Sprintf("Saved %d tracks to %s", msg.Count, msg.Path), components.LevelSuccess))
+
+	case views.AnalyzeBPMMsg:
+		cmds = append(cmds, m.analyzeBPM(msg))
+
+	case BPMAnalyzedMsg:
+		cmds = append(cmds, m.bpmAnalyzed(msg))
+
+	case components.ToastDismissMsg:
+		cmds = append(cmds, m.toast.Update(msg))
+
+	case components.LiveFrameMsg:
+		var cmd tea.Cmd
+		m.playerView.ProgressBar, cmd = m.playerView.ProgressBar.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case components.VisualizerFrameMsg:
+		var cmd tea.Cmd
+		m.playerView.Visualizer, cmd = m.playerView.Visualizer.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case views.SeekMsg:
+		m.audioEngine.Seek(msg.Position)
+
+	case MPRISMsg:
+		cmds = append(cmds, m.mprisCommand(msg.Command), m.listenMPRIS())
+
+	case ScrobbleNoticeMsg:
+		cmds = append(cmds, m.scrobbleNotice(msg.Notice), m.listenScrobbler())
+
+	case sleepTickMsg:
+		cmds = append(cmds, m.sleepTicked(msg))
+
+	case sleepQuitMsg:
+		m.cancel()
+		return m, tea.Quit
+
+	case OutputDevicesMsg:
+		cmds = append(cmds, m.outputsListed(msg))
+
+	case OutputSwitchedMsg:
+		cmds = append(cmds, m.outputSwitched(msg))
+
+	case LibraryMovedMsg:
+		cmds = append(cmds, m.libraryMoved(msg))
+
+	case LibraryRescannedMsg:
+		cmds = append(cmds, m.libraryRescanned(msg))
+
+	case SaveFailedMsg:
+		cmds = append(cmds, m.saveFailed(msg))
+
+	case StatsExportedMsg:
+		cmds = append(cmds, m.statsExported(msg))
+
+	case LibraryChangedMsg:
+		cmds = append(cmds, m.libraryChanged(msg), m.queueDurations(msg.Added), m.watchLibrary())
+
+	case DurationScannedMsg:
+		cmds = append(cmds, m.durationScanned(msg))
+
+	case CoverArtMsg:
+		// Ignore artwork for a track that is no longer playing
+		if msg.Path == m.playerView.Art.Key() {
+			m.playerView.Art.SetImage(msg.Path, msg.Data)
+		}
+
+	case views.SearchDebounceMsg:
+		var cmd tea.Cmd
+		m.libraryView, cmd = m.libraryView.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case tea.KeyMsg:
+		// A play action ends a prelisten before it plays, as does moving
+		// the selection off its track; other keys leave it playing
+		if action, ok := m.keys.Action(msg.String()); ok && playsTrack(action) {
+			m.stopPrelisten()
+		}
+
+		// A pending confirmation takes every key: y accepts, anything else cancels
+		if m.confirm != nil {
+			prompt := m.confirm
+			m.confirm = nil
+			switch msg.String() {
+			case "ctrl+c":
+				m.cancel()
+				return m, tea.Quit
+			case "y", "Y":
+				cmds = append(cmds, prompt.onYes(&m))
+			default:
+				cmds = append(cmds, m.toast.Notify("Cancelled", components.LevelInfo))
 			}
+			return m, tea.Batch(cmds...)
+		}
 
-		case "left": // Seek backward 5 seconds
-			state := m.audioEngine.GetState()
-			if state.Status == api.StatusPlaying || state.Status == api.StatusPaused {
-				newPos := state.Position - 5*time.Second
-				if newPos < 0 {
-					newPos = 0
-				}
-				m.audioEngine.Seek(newPos)
+		if m.naming != nil {
+			if msg.String() == "ctrl+c" {
+				m.cancel()
+				return m, tea.Quit
 			}
+			cmds = append(cmds, m.updateBookmarkPrompt(msg))
+			return m, tea.Batch(cmds...)
+		}
 
-		case "+", "=": // Volume up
-			state := m.audioEngine.GetState()
-			newVol := state.Volume + 0.1
-			if newVol > 1 {
-				newVol = 1
+		if m.asking != nil {
+			if msg.String() == "ctrl+c" {
+				m.cancel()
+				return m, tea.Quit
 			}
-			m.audioEngine.SetVolume(newVol)
+			cmds = append(cmds, m.updatePathPrompt(msg))
+			return m, tea.Batch(cmds...)
+		}
 
-		case "-": // Volume down
-			state := m.audioEngine.GetState()
-			newVol := state.Volume - 0.1
-			if newVol < 0 {
-				newVol = 0
+		if m.palette != nil {
+			if msg.String() == "ctrl+c" {
+				m.cancel()
+				return m, tea.Quit
 			}
-			m.audioEngine.SetVolume(newVol)
+			cmds = append(cmds, m.updatePalette(msg))
+			return m, tea.Batch(cmds...)
+		}
 
-		case "r": // Toggle repeat
-			mode := m.queue.GetRepeatMode()
-			newMode := (mode + 1) % 3
-			m.queue.SetRepeatMode(newMode)
+		if m.outputs != nil {
+			if handled, cmd := m.updateOutputs(msg); handled {
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
+			}
+		}
 
-		case "S": // Toggle shuffle
-			if m.queue.IsShuffled() {
-				m.queue.Unshuffle()
-			} else {
-				m.queue.Shuffle()
+		if m.eqOpen {
+			if handled, cmd := m.updateEqualizer(msg); handled {
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
 			}
+		}
 
-		case "enter":
-			// Play selected track
-			var track *api.Track
-			switch m.activeView {
-			case ViewLibrary:
-				track = m.libraryView.SelectedTrack()
-				if track != nil {
-					// Set queue to all library tracks starting from selected
-					tracks := m.library.GetAllTracks()
-					m.queue.Set(tracks)
-					for i, t := range tracks {
-						if t.ID == track.ID {
-							m.queue.JumpTo(i)
-							break
-						}
-					}
-				}
-			case ViewPlaylist:
-				track = m.playlistView.SelectedTrack()
-				if track != nil {
-					// Set queue to playlist tracks
-					pl := m.playlistView.SelectedPlaylist()
-					if pl != nil {
-						tracks := make([]*api.Track, len(pl.Tracks))
-						for i := range pl.Tracks {
-							tracks[i] = &pl.Tracks[i]
-						}
-						m.queue.Set(tracks)
-						for i, t := range tracks {
-							if t.ID == track.ID {
-								m.queue.JumpTo(i)
-								break
-							}
-						}
-					}
-				}
+		// The compact layout shows no view, so only global keys act
+		if m.compact {
+			if action, ok := m.keys.Action(msg.String()); ok {
+				cmds = append(cmds, m.runAction(action))
 			}
-			if track != nil {
-				logger.Info("User selected track: %q by %s", track.Title, track.Artist)
-				m.audioEngine.Play(track)
+			break
+		}
+
+		// If library view is in search or jump mode, pass keys directly to it
+		// (except for critical global keys like quit)
+		if m.activeView == ViewLibrary && (m.libraryView.Searching || m.libraryView.Browsing || m.libraryView.Jumping) {
+			switch msg.String() {
+			case "ctrl+c":
+				m.cancel()
+				return m, tea.Quit
+			default:
+				var cmd tea.Cmd
+				m.libraryView, cmd = m.libraryView.Update(msg)
+				m.prelistenMoved()
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
 			}
+		}
 
-		default:
-			// Pass to active view
-			switch m.activeView {
-			case ViewLibrary:
-				m.libraryView, _ = m.libraryView.Update(msg)
-			case ViewPlaylist:
-				m.playlistView, _ = m.playlistView.Update(msg)
+		// The player view's seek keys go ahead of the digits' view switching
+		if viewMsg, ok := m.playerKey(msg); ok {
+			if msg.String() == "ctrl+c" {
+				m.cancel()
+				return m, tea.Quit
 			}
+			var cmd tea.Cmd
+			m.playerView, cmd = m.playerView.Update(viewMsg)
+			cmds = append(cmds, cmd)
+			break
+		}
+
+		// Global keybindings (only active when not searching)
+		if action, ok := m.keys.Action(msg.String()); ok {
+			cmds = append(cmds, m.runAction(action))
+			break
+		}
+
+		// Pass to active view, as the key it acts on
+		msg, ok := m.viewKey(msg)
+		if !ok {
+			break
 		}
+		var cmd tea.Cmd
+		switch m.activeView {
+		case ViewLibrary:
+			field, desc := m.libraryView.SortField, m.libraryView.SortDesc
+			m.libraryView, cmd = m.libraryView.Update(msg)
+			m.sortChanged(field, desc)
+			m.prelistenMoved()
+		case ViewPlaylist:
+			m.playlistView, cmd = m.playlistView.Update(msg)
+		case ViewQueue:
+			m.queueView, cmd = m.queueView.Update(msg)
+		case ViewHistory:
+			m.historyView, cmd = m.historyView.Update(msg)
+		case ViewBrowse:
+			m.browseView, cmd = m.browseView.Update(msg)
+		}
+		cmds = append(cmds, cmd)
 
 	case tea.MouseMsg:
-		// Handle click-to-seek on progress bar
-		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
-			state := m.audioEngine.GetState()
-			if state.Status == api.StatusPlaying || state.Status == api.StatusPaused {
-				// The progress bar row is at a fixed offset from the top:
-				// tab bar (1) + newline gap (1) + player border top (1) + padding (1)
-				// + status/title (1) + artist (1) + album (1) + blank (1) = row 8 (0-indexed: 7)
-				progressRow := 1 + m.playerView.ProgressBarRow() // tab + player offset
-				if msg.Y == progressRow {
-					// Border left (1) + padding left (2) = 3 chars offset
-					barOffsetX := 3
-					seekPos := m.playerView.ProgressBarClickSeek(msg.X, barOffsetX)
-					m.audioEngine.Seek(seekPos)
+		// Click or press-and-drag on the progress bar to seek. The head
+		// follows the mouse while dragging and the seek happens on release.
+		// The progress bar sits in the footer pinned to the bottom of the
+		// screen; rendering it also lays out the bar.
+		bar := &m.playerView.ProgressBar
+		if m.showCompact() {
+			// Only a click on the compact line's bar does anything; drawing
+			// the line lays it out
+			m.compactView()
+			if msg.Y == 0 && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+				if pos, ok := m.playerView.CompactSeek(msg.X); ok {
+					m.audioEngine.Seek(pos)
 				}
 			}
+			break
 		}
+		top := footerTop(m.height, m.renderFooter())
+		switch {
+		case bar.Dragging && msg.Action == tea.MouseActionMotion:
+			bar.UpdateDrag(msg.X)
+		case bar.Dragging && msg.Action == tea.MouseActionRelease:
+			bar.UpdateDrag(msg.X)
+			m.audioEngine.Seek(bar.EndDrag())
+		case m.height > 0 && msg.Y >= top:
+			// Only the progress bar in the footer takes the mouse. The
+			// footer has no border, so the bar's view starts at column 0.
+			state := m.audioEngine.GetState()
+			playing := state.Status == api.StatusPlaying || state.Status == api.StatusPaused
+			onBar := playing && msg.Y == top+footerProgressRow
+			switch {
+			case onBar && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+				bar.ClearHover()
+				bar.BeginDrag(msg.X)
+			case onBar && msg.Action == tea.MouseActionMotion:
+				bar.HoverAt(msg.X, 0)
+			default:
+				bar.ClearHover()
+			}
+		default:
+			bar.ClearHover()
+			cmds = append(cmds, m.contentMouse(msg))
+		}
+
+	case components.RowActivatedMsg:
+		cmds = append(cmds, m.runAction(ActionPlaySelected))
 	}
 
+	// The visualizer only samples the spectrum while it is on screen
+	cmds = append(cmds, m.playerView.Visualizer.SetHidden(m.activeView != ViewPlayer || m.showCompact()))
 	return m, tea.Batch(cmds...)
 }
 
+// togglePlayback pauses or resumes, or starts the current track when
+// stopped. During a preview it pauses or resumes the preview.
+func (m *Model) togglePlayback() {
+	state := m.audioEngine.GetState()
+	if state.PreviewTrack != nil {
+		// Main playback stays paused under a preview; pause that instead
+		m.audioEngine.TogglePreview()
+	} else if state.Status == api.StatusPlaying {
+		logger.Debug("User paused playback")
+		m.audioEngine.Pause()
+	} else if state.Status == api.StatusPaused {
+		logger.Debug("User resumed playback")
+		m.audioEngine.Resume()
+	} else if current := m.queue.Current(); current != nil {
+		logger.Debug("User started playback from stopped state")
+		if !m.resumeCued(current) {
+			m.audioEngine.Play(current)
+		}
+	}
+}
+
+// skipNext plays the next track, skipping an inter-track delay; it moves
+// on even under repeat one
+func (m *Model) skipNext() {
+	m.gapUntil = time.Time{}
+	if next := m.queue.Skip(); next != nil {
+		logger.Info("User skipped to next track: %q", next.Title)
+		m.audioEngine.Play(next)
+	}
+}
+
+// repeatModeName names a repeat mode for notices
+func repeatModeName(mode api.RepeatMode) string {
+	switch mode {
+	case api.RepeatOne:
+		return "One"
+	case api.RepeatAll:
+		return "All"
+	}
+	return "Off"
+}
+
+// ParseRepeatMode parses a repeat mode by name: "off", "one" or "all"
+func ParseRepeatMode(s string) (api.RepeatMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off", "none":
+		return api.RepeatNone, true
+	case "one", "track":
+		return api.RepeatOne, true
+	case "all":
+		return api.RepeatAll, true
+	}
+	return api.RepeatNone, false
+}
+
+// playbackState fills in the modes the queue owns, shuffle and repeat, on a
+// state snapshot reported by the engine
+func (m Model) playbackState(state *api.PlaybackState) *api.PlaybackState {
+	shown := *state
+	shown.Shuffle = m.queue.IsShuffled()
+	shown.Repeat = m.queue.GetRepeatMode()
+	shown.CurrentTrack = m.stream.show(state.CurrentTrack)
+	return &shown
+}
+
+// startInitialTrack plays the first queued track for a launch-time -play,
+// seeking to the requested position. A position past the end is clamped to
+// the last second so the track is still heard.
+func (m Model) startInitialTrack() {
+	track := m.queue.Current()
+	if track == nil {
+		return
+	}
+	m.audioEngine.Play(track)
+
+	at := m.startAt
+	if track.Duration > 0 && at >= track.Duration {
+		at = max(track.Duration-time.Second, 0)
+		logger.Warn("Start position %v is past the end of %q, clamping to %v", m.startAt, track.Title, at)
+	}
+	if at > 0 {
+		m.audioEngine.Seek(at)
+	}
+}
+
+// gapPending reports whether an inter-track delay is counting down. Any
+// manual playback started meanwhile cancels it.
+func (m Model) gapPending() bool {
+	return !m.gapUntil.IsZero() && m.audioEngine.GetState().Status == api.StatusStopped
+}
+
+// previewMoves summarises planned organize moves for the confirm prompt and
+// returns how many files would actually move
+func previewMoves(moves []library.Move) ([]string, int) {
+	const maxLines = 5
+
+	var lines []string
+	pending, unchanged, conflicts := 0, 0, 0
+	for _, move := range moves {
+		switch {
+		case move.Err != nil:
+			conflicts++
+			lines = append(lines, fmt.Sprintf("✗ %s: %v", move.To, move.Err))
+		case move.Unchanged():
+			unchanged++
+		default:
+			pending++
+			lines = append(lines, fmt.Sprintf("%s → %s", move.From, move.To))
+		}
+	}
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], fmt.Sprintf("…and %d more", len(lines)-maxLines))
+	}
+	lines = append(lines, fmt.Sprintf("%d to move, %d already in place, %d skipped (target exists)", pending, unchanged, conflicts))
+	return lines, pending
+}
+
+// availabilityTick schedules the next periodic availability check
+func availabilityTick(gen int) tea.Cmd {
+	return tea.Tick(availabilityInterval, func(time.Time) tea.Msg {
+		return availabilityTickMsg{gen: gen}
+	})
+}
+
+// checkAvailabilityCmd stats library files off the UI goroutine
+func (m Model) checkAvailabilityCmd() tea.Cmd {
+	return func() tea.Msg {
+		return AvailabilityMsg{Unavailable: m.library.CheckAvailability()}
+	}
+}
+
+// formatTrimStatus describes the trim region after it has been changed
+func formatTrimStatus(start, end time.Duration) string {
+	if start == 0 && end == 0 {
+		return "Trim cleared"
+	}
+	endLabel := "end"
+	if end > 0 {
+		endLabel = end.Round(time.Second).String()
+	}
+	return fmt.Sprintf("Trim %s → %s (applies from the next play)", start.Round(time.Second), endLabel)
+}
+
+// savePlaylistCmd writes tracks to an M3U file off the UI goroutine
+func savePlaylistCmd(path string, tracks []*api.Track) tea.Cmd {
+	return func() tea.Msg {
+		err := library.SavePlaylist(path, tracks)
+		return PlaylistSavedMsg{Path: path, Count: len(tracks), Err: err}
+	}
+}
+
+// queueFolderCmd scans a directory in the background so large folders
+// don't block the UI while their metadata is read
+func (m Model) queueFolderCmd(dir string) tea.Cmd {
+	return func() tea.Msg {
+		tracks, skipped, err := m.library.ScanFolder(m.ctx, dir)
+		return FolderQueuedMsg{Path: dir, Tracks: tracks, Skipped: skipped, Err: err}
+	}
+}
+
 // updateViewSizes updates view dimensions
 func (m *Model) updateViewSizes() {
-	m.playerView.Width = m.width
-	m.playerView.Height = 10
-	m.libraryView.Width = m.width
-	m.libraryView.Height = m.height - 12
-	m.playlistView.Width = m.width
-	m.playlistView.Height = m.height - 12
+	height := contentHeight(m.height)
+	m.playerView.SetSize(m.width, height)
+	m.libraryView.SetSize(m.width, height)
+	m.playlistView.SetSize(m.width, height)
+	m.queueView.SetSize(m.width, height)
+	m.historyView.SetSize(m.width, height)
+	m.browseView.SetSize(m.width, height)
 }
 
 // View renders the UI
 func (m Model) View() string {
-	var sb string
-
-	// Header with tabs
-	sb += m.renderTabs()
-	sb += "\n"
-
-	// Main content
+	if m.showCompact() {
+		return m.compactView()
+	}
+	var content string
 	switch m.activeView {
 	case ViewPlayer:
-		sb += m.playerView.View()
+		content = m.playerView.View()
 	case ViewLibrary:
-		sb += m.playerView.View()
-		sb += "\n"
-		sb += m.libraryView.View()
+		content = m.libraryView.View()
 	case ViewPlaylist:
-		sb += m.playerView.View()
-		sb += "\n"
-		sb += m.playlistView.View()
+		content = m.playlistView.View()
+	case ViewQueue:
+		content = m.queueView.View()
+	case ViewHistory:
+		content = m.historyView.View()
+	case ViewBrowse:
+		content = m.browseView.View()
+	}
+	if m.eqOpen {
+		content = m.equalizerView()
+	}
+	if m.outputs != nil {
+		content = m.outputsView()
+	}
+	if m.palette != nil {
+		content = overlayCenter(content, m.paletteView(), m.width, contentHeight(m.height))
+	}
+	if m.activeView != ViewPlayer || m.eqOpen || m.outputs != nil || m.palette != nil {
+		content = m.playerView.Art.Clear() + content
+	}
+
+	return composeScreen(m.height, m.renderTabs(), content, m.renderFooter())
+}
+
+// renderFooter renders the fixed bottom bar: now playing and progress,
+// then the gap countdown, a pending confirmation or the status line, and
+// any error
+func (m *Model) renderFooter() string {
+	// The toast sits at the right end of the divider
+	toast := m.toast.ViewWidth(m.width - 1)
+	dividerWidth := max(m.width-lipgloss.Width(toast), 1)
+	divider := lipgloss.NewStyle().
+		Foreground(m.theme.Border).
+		Render(strings.Repeat("─", dividerWidth))
+	sb := divider + toast + "\n" + m.playerView.FooterView()
+
+	if m.gapPending() {
+		gapStyle := lipgloss.NewStyle().
+			Foreground(m.theme.Success)
+		remaining := time.Until(m.gapUntil).Round(time.Second)
+		sb += "\n" + gapStyle.Render(fmt.Sprintf("Next track in %v · [n] Skip wait", remaining))
+	}
+	if sleep := m.sleepStatus(); sleep != "" {
+		sb += "\n" + lipgloss.NewStyle().Foreground(m.theme.Special).Render(sleep)
+	}
+	// Confirmation, bookmark and folder prompts replace the status line
+	// while pending
+	if m.naming != nil {
+		sb += "\n" + m.naming.input.View()
+	} else if m.asking != nil {
+		sb += "\n" + m.asking.input.View()
+	} else if m.confirm != nil {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(m.theme.Warning).
+			Bold(true)
+		for _, line := range m.confirm.details {
+			sb += "\n" + line
+		}
+		sb += "\n" + promptStyle.Render(m.confirm.question+" [y/N]")
+	} else if m.status != "" {
+		statusStyle := lipgloss.NewStyle().
+			Foreground(m.theme.Success)
+		sb += "\n" + statusStyle.Render(m.status)
 	}
 
 	// Error display
 	if m.err != nil {
 		errorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
+			Foreground(m.theme.Error).
 			Bold(true)
 		sb += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
 	}
@@ -414,12 +1377,13 @@ func (m Model) View() string {
 	return sb
 }
 
+// tabLabels are the tab bar's tabs, one per view in order
+var tabLabels = [...]string{"[1] Player", "[2] Library", "[3] Playlist", "[4] Queue", "[5] History", "[6] Browse"}
+
 // renderTabs renders the tab bar
 func (m Model) renderTabs() string {
-	tabs := []string{"[1] Player", "[2] Library", "[3] Playlist"}
-
 	var rendered []string
-	for i, tab := range tabs {
+	for i, tab := range tabLabels {
 		if ViewType(i) == m.activeView {
 			rendered = append(rendered, m.activeTabStyle.Render(tab))
 		} else {
@@ -431,11 +1395,21 @@ func (m Model) renderTabs() string {
 }
 
 // Run starts the bubbletea program
-func Run(engine *audio.AudioEngine, lib *library.Library, plManager *playlist.Manager) error {
+func Run(engine Engine, lib *library.Library, plManager *playlist.Manager, opts Options) error {
 	logger.Info("Starting UI")
-	model := NewModel(engine, lib, plManager)
-	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	_, err := p.Run()
+	model := NewModel(engine, lib, plManager, opts)
+	progOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseAllMotion()}
+	if opts.InputTTY {
+		progOpts = append(progOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model, progOpts...)
+	final, err := p.Run()
+	if final, ok := final.(Model); ok {
+		final.closeEqualizer()
+		if opts.OnSessionSave != nil {
+			opts.OnSessionSave(final.session())
+		}
+	}
 	if err != nil {
 		logger.Error("UI exited with error: %v", err)
 	} else {
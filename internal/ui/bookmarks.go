@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// bookmarkPrompt asks for the label of a bookmark about to be added at a
+// position in the playing track
+type bookmarkPrompt struct {
+	filePath string
+	at       time.Duration
+	input    components.SearchInput
+}
+
+// playingFile returns the playing or paused track and its position, or nil
+// if nothing is loaded
+func (m *Model) playingFile() (*api.Track, time.Duration) {
+	state := m.audioEngine.GetState()
+	if state.CurrentTrack == nil || (state.Status != api.StatusPlaying && state.Status != api.StatusPaused) {
+		return nil, 0
+	}
+	return state.CurrentTrack, state.Position
+}
+
+// promptBookmark starts naming a bookmark at the current position
+func (m *Model) promptBookmark() {
+	track, at := m.playingFile()
+	if m.bookmarks == nil || track == nil {
+		return
+	}
+	input := components.NewSearchInput(max(m.width-2, 20))
+	input.SetTheme(m.theme)
+	input.Prompt = fmt.Sprintf("Bookmark at %s: ", at.Round(time.Second))
+	input.Placeholder = at.Round(time.Second).String()
+	input.Style, input.FocusStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
+	input.Focus()
+	m.naming = &bookmarkPrompt{filePath: track.FilePath, at: at, input: input}
+}
+
+// updateBookmarkPrompt edits the pending bookmark's label. Enter adds it,
+// labelled with its position if left blank; Esc drops it.
+func (m *Model) updateBookmarkPrompt(msg tea.KeyMsg) tea.Cmd {
+	prompt := m.naming
+	switch msg.String() {
+	case "esc":
+		m.naming = nil
+		return m.toast.Notify("Cancelled", components.LevelInfo)
+	case "enter":
+		m.naming = nil
+		label := prompt.input.Value
+		if label == "" {
+			label = prompt.input.Placeholder
+		}
+		mark := m.bookmarks.Add(prompt.filePath, label, prompt.at)
+		return m.saveBookmarks(fmt.Sprintf("Bookmarked %q at %s", mark.Label, mark.Position.Round(time.Second)))
+	}
+	prompt.input, _ = prompt.input.Update(msg)
+	return nil
+}
+
+// removeBookmark deletes the playing track's bookmark nearest the current
+// position
+func (m *Model) removeBookmark() tea.Cmd {
+	track, at := m.playingFile()
+	if m.bookmarks == nil || track == nil {
+		return nil
+	}
+	mark, ok := m.bookmarks.Remove(track.FilePath, at)
+	if !ok {
+		return m.toast.Notify("No bookmarks in this track", components.LevelInfo)
+	}
+	return m.saveBookmarks(fmt.Sprintf("Removed bookmark %q", mark.Label))
+}
+
+// jumpBookmark seeks to the playing track's next bookmark, or with back
+// the previous one
+func (m *Model) jumpBookmark(back bool) tea.Cmd {
+	track, at := m.playingFile()
+	if m.bookmarks == nil || track == nil {
+		return nil
+	}
+	step := m.bookmarks.Next
+	if back {
+		step = m.bookmarks.Prev
+	}
+	mark, ok := step(track.FilePath, at)
+	if !ok {
+		return m.toast.Notify("No more bookmarks", components.LevelInfo)
+	}
+	m.audioEngine.Seek(mark.Position)
+	m.playerView.ProgressBar.Current = mark.Position
+	return m.toast.Notify(fmt.Sprintf("%s · %s", mark.Label, mark.Position.Round(time.Second)), components.LevelInfo)
+}
+
+// saveBookmarks persists the bookmarks in the background after a change,
+// confirming it with done
+func (m *Model) saveBookmarks(done string) tea.Cmd {
+	track, _ := m.playingFile()
+	m.showBookmarks(track)
+	return tea.Batch(m.toast.Notify(done, components.LevelSuccess), saveCmd("bookmarks", m.bookmarks.Save))
+}
+
+// showBookmarks ticks track's bookmarks on the progress bar
+func (m *Model) showBookmarks(track *api.Track) {
+	bar := &m.playerView.ProgressBar
+	bar.Marks = nil
+	if m.bookmarks == nil || track == nil {
+		return
+	}
+	for _, mark := range m.bookmarks.Get(track.FilePath) {
+		bar.Marks = append(bar.Marks, mark.Position)
+	}
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+// BPMAnalyzedMsg is sent when background tempo detection completes
+type BPMAnalyzedMsg struct {
+	Track *api.Track
+	BPM   float64
+	Err   error
+}
+
+// analyzeBPM starts tempo detection for the requested tracks. Tracks with
+// a BPM already are skipped, unless it was detected and msg.Force asks to
+// detect it again; a tagged BPM is never replaced.
+func (m *Model) analyzeBPM(msg views.AnalyzeBPMMsg) tea.Cmd {
+	var cmds []tea.Cmd
+	var started []*api.Track
+	for _, track := range msg.Tracks {
+		if track.BPM > 0 && (!msg.Force || !track.BPMDetected) {
+			continue
+		}
+		m.libraryView.SetAnalyzing(track, true)
+		cmds = append(cmds, m.analyzeBPMCmd(track, msg.Force))
+		started = append(started, track)
+	}
+	switch {
+	case len(started) == 0 && len(msg.Tracks) == 1:
+		track := msg.Tracks[0]
+		return m.toast.Notify(fmt.Sprintf("%s is tagged %.1f BPM", track.Title, track.BPM), components.LevelInfo)
+	case len(started) == 1:
+		m.status = "Detecting BPM for " + started[0].Title + "..."
+	case len(started) > 1:
+		m.status = fmt.Sprintf("Detecting BPM for %d tracks...", m.libraryView.AnalyzingCount())
+	}
+	return tea.Batch(cmds...)
+}
+
+// analyzeBPMCmd runs tempo detection off the UI goroutine, skipping the
+// cached tempo when force. The result is applied to the track in Update to
+// avoid racing with rendering.
+func (m Model) analyzeBPMCmd(track *api.Track, force bool) tea.Cmd {
+	return func() tea.Msg {
+		bpm, err := m.library.AnalyzeBPM(track, force)
+		return BPMAnalyzedMsg{Track: track, BPM: bpm, Err: err}
+	}
+}
+
+// bpmAnalyzed applies a detected tempo to its track, unless the track
+// was tagged with one meanwhile, and saves the sidecar it was cached in
+func (m *Model) bpmAnalyzed(msg BPMAnalyzedMsg) tea.Cmd {
+	var cmd tea.Cmd
+	m.libraryView.SetAnalyzing(msg.Track, false)
+	remaining := m.libraryView.AnalyzingCount()
+	if msg.Err != nil {
+		logger.Warn("BPM detection failed for %s: %v", msg.Track.FilePath, msg.Err)
+		cmd = m.toast.Notify(fmt.Sprintf("BPM detection failed for %s: %v", msg.Track.Title, msg.Err), components.LevelError)
+	} else if msg.Track.BPM > 0 && !msg.Track.BPMDetected {
+		// Tagged while detecting, by a rescan: the tag wins
+		logger.Info("Detected BPM %.1f for %s ignored for its tag's %.1f", msg.BPM, msg.Track.FilePath, msg.Track.BPM)
+	} else {
+		msg.Track.BPM, msg.Track.BPMDetected = msg.BPM, true
+		cmd = m.toast.Notify(fmt.Sprintf("%s: %.1f BPM", msg.Track.Title, msg.BPM), components.LevelSuccess)
+		if m.libraryView.SortField == views.SortBPM {
+			m.libraryView.Refresh()
+		}
+		if sc := m.library.Sidecar(); sc != nil {
+			cmd = tea.Batch(cmd, saveCmd("sidecar", sc.Save))
+		}
+	}
+	m.status = ""
+	if remaining > 0 {
+		m.status = fmt.Sprintf("Detecting BPM for %d tracks...", remaining)
+	}
+	return cmd
+}
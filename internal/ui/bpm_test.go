@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+func TestAnalyzeBPM_KeepsTaggedTempos(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	tagged := &api.Track{ID: "t", Title: "Tagged", BPM: 120}
+	detected := &api.Track{ID: "d", Title: "Detected", BPM: 98, BPMDetected: true}
+	none := &api.Track{ID: "n", Title: "None"}
+
+	m.analyzeBPM(views.AnalyzeBPMMsg{Tracks: []*api.Track{tagged, detected, none}})
+	if got := m.libraryView.AnalyzingCount(); got != 1 {
+		t.Errorf("analyzing %d tracks unforced, want only the one without a BPM", got)
+	}
+	m.analyzeBPM(views.AnalyzeBPMMsg{Tracks: []*api.Track{tagged}, Force: true})
+	m.analyzeBPM(views.AnalyzeBPMMsg{Tracks: []*api.Track{detected}, Force: true})
+	if got := m.libraryView.AnalyzingCount(); got != 2 {
+		t.Errorf("analyzing %d tracks after forcing, want the detected one added", got)
+	}
+
+	// A tag read while detecting wins over the detected tempo
+	none.BPM = 140
+	m.bpmAnalyzed(BPMAnalyzedMsg{Track: none, BPM: 70})
+	m.bpmAnalyzed(BPMAnalyzedMsg{Track: detected, BPM: 96})
+	if none.BPM != 140 || none.BPMDetected {
+		t.Errorf("tagged BPM replaced: %v (detected %v)", none.BPM, none.BPMDetected)
+	}
+	if detected.BPM != 96 || !detected.BPMDetected {
+		t.Errorf("detected BPM = %v, want 96", detected.BPM)
+	}
+}
@@ -0,0 +1,6 @@
+package ui
+
+// refreshBrowse gives the browse view the library as it is now
+func (m *Model) refreshBrowse() {
+	m.browseView.SetTracks(m.library.GetAllTracks())
+}
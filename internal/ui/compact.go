@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// viewActions are the actions that go to a view, leaving the compact
+// layout to show it
+var viewActions = []Action{
+	ActionViewPlayer, ActionViewLibrary, ActionViewPlaylist, ActionViewQueue,
+	ActionViewHistory, ActionViewBrowse, ActionNextView, ActionPrevView,
+	ActionSearch,
+}
+
+// leavesCompact reports whether action ends the compact layout
+func leavesCompact(action Action) bool {
+	return slices.Contains(viewActions, action)
+}
+
+// showCompact reports whether the screen is the compact line. Panels and
+// the palette need the full layout, and get it while they're open.
+func (m *Model) showCompact() bool {
+	return m.compact && !m.eqOpen && m.outputs == nil && m.palette == nil
+}
+
+// compactView renders the compact layout: the player on one line, with
+// the toast at its end and a pending prompt below it
+func (m *Model) compactView() string {
+	toast := m.toast.ViewWidth(m.width / 2)
+	line := m.playerView.Art.Clear() + m.playerView.CompactView(m.width-lipgloss.Width(toast)) + toast
+	switch {
+	case m.naming != nil:
+		line += "\n" + m.naming.input.View()
+	case m.asking != nil:
+		line += "\n" + m.asking.input.View()
+	case m.confirm != nil:
+		line += "\n" + lipgloss.NewStyle().Foreground(m.theme.Warning).Bold(true).Render(m.confirm.question+" [y/N]")
+	}
+	return line
+}
@@ -0,0 +1,312 @@
+package components
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Embedded artwork is usually JPEG or PNG
+	"image/png"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ImageProtocol is how a terminal can be sent inline images
+type ImageProtocol int
+
+const (
+	ImageOff         ImageProtocol = iota // Don't show album art
+	ImagePlaceholder                      // No image support: draw a text box instead
+	ImageKitty                            // Kitty graphics protocol (also WezTerm, Ghostty)
+	ImageITerm2                           // iTerm2 inline images (also WezTerm)
+	ImageSixel                            // DEC Sixel graphics
+)
+
+var imageProtocolNames = [...]string{"off", "placeholder", "kitty", "iterm2", "sixel"}
+
+func (p ImageProtocol) String() string {
+	if int(p) < len(imageProtocolNames) {
+		return imageProtocolNames[p]
+	}
+	return "unknown"
+}
+
+// ParseImageProtocol parses a protocol name as written by String
+func ParseImageProtocol(s string) (ImageProtocol, bool) {
+	for i, name := range imageProtocolNames {
+		if s == name {
+			return ImageProtocol(i), true
+		}
+	}
+	return ImageOff, false
+}
+
+// DetectImageProtocol guesses the terminal's image support from its
+// environment, as read by getenv. Terminals can't be asked without reading
+// their reply from stdin, so unknown terminals get the placeholder.
+func DetectImageProtocol(getenv func(string) string) ImageProtocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || term == "xterm-ghostty" ||
+		program == "ghostty" || program == "WezTerm":
+		return ImageKitty
+	case program == "iTerm.app" || getenv("LC_TERMINAL") == "iTerm2":
+		return ImageITerm2
+	case strings.Contains(term, "sixel") || term == "foot" || strings.HasPrefix(term, "foot-") ||
+		term == "mlterm" || term == "yaft-256color" || program == "mlterm":
+		return ImageSixel
+	}
+	return ImagePlaceholder
+}
+
+// Approximate cell size in pixels. Images are scaled to fit their cells
+// before sending; Sixel is drawn at that pixel size, the others are fitted
+// to the cells by the terminal.
+const (
+	cellPixelWidth  = 10
+	cellPixelHeight = 20
+)
+
+// kittyImageID identifies our image so it can be replaced and deleted
+const kittyImageID = 7301
+
+// AlbumArt shows a track's embedded artwork in a box Cols cells wide and
+// Rows tall. The encoded image is cached per key, so it is only decoded
+// and encoded when the track changes.
+type AlbumArt struct {
+	Protocol         ImageProtocol
+	Cols             int
+	Rows             int
+	PlaceholderStyle lipgloss.Style
+
+	key     string
+	encoded string // Escape sequence drawing the image; empty if there is none
+}
+
+// NewAlbumArt creates an album art box using protocol
+func NewAlbumArt(protocol ImageProtocol) AlbumArt {
+	a := AlbumArt{
+		Protocol: protocol,
+		Cols:     16,
+		Rows:     8,
+	}
+	a.SetTheme(DefaultTheme)
+	return a
+}
+
+// SetTheme styles the placeholder box in t's colors
+func (a *AlbumArt) SetTheme(t Theme) {
+	a.PlaceholderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Muted).
+		Foreground(t.Muted).
+		Align(lipgloss.Center, lipgloss.Center)
+}
+
+// Key returns the key of the image last set, e.g. the track's file path
+func (a AlbumArt) Key() string {
+	return a.key
+}
+
+// SetImage sets the artwork for key from encoded image data. Data that is
+// empty or can't be decoded shows the placeholder.
+func (a *AlbumArt) SetImage(key string, data []byte) {
+	a.key, a.encoded = key, ""
+	if len(data) == 0 || a.Protocol <= ImagePlaceholder {
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	switch a.Protocol {
+	case ImageKitty:
+		a.encoded = kittyImage(img, a.Cols, a.Rows)
+	case ImageITerm2:
+		a.encoded = itermImage(img, a.Cols, a.Rows)
+	case ImageSixel:
+		a.encoded = sixelImage(scaleImage(img, a.Cols*cellPixelWidth, a.Rows*cellPixelHeight))
+	}
+}
+
+// View renders the artwork as Rows lines of Cols cells. The cells under
+// the image are blank and the escape sequence has no width, so the block
+// lays out like plain text. Kitty draws images on their own layer, so the
+// image is sent with the first line. Sixel and iTerm2 images are drawn
+// into the cells and would be erased by the blank lines printed after
+// them, so they are sent with the last line, moving the cursor up to the
+// top of the block first.
+func (a AlbumArt) View() string {
+	if a.Protocol == ImageOff {
+		return ""
+	}
+	if a.encoded == "" {
+		return a.PlaceholderStyle.
+			Width(a.Cols - 2).
+			Height(a.Rows - 2).
+			Render("♪")
+	}
+
+	blank := strings.Repeat(" ", a.Cols)
+	lines := make([]string, a.Rows)
+	for i := range lines {
+		lines[i] = blank
+	}
+	if a.Protocol == ImageKitty {
+		lines[0] = a.encoded + blank
+	} else {
+		up := ""
+		if a.Rows > 1 {
+			up = fmt.Sprintf("\x1b[%dA", a.Rows-1)
+		}
+		lines[a.Rows-1] = "\x1b7" + up + a.encoded + "\x1b8" + blank
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Clear returns an escape sequence removing the image from the screen when
+// it stops being rendered. Only Kitty keeps images apart from the text
+// cells; the others are erased by drawing over them.
+func (a AlbumArt) Clear() string {
+	if a.Protocol != ImageKitty || a.encoded == "" {
+		return ""
+	}
+	return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+}
+
+// kittyImage transmits img as PNG and places it over cols x rows cells
+// without moving the cursor. q=2 stops the terminal replying on stdin.
+func kittyImage(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleImage(img, cols*cellPixelWidth, rows*cellPixelHeight)); err != nil {
+		return ""
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// The payload is sent in chunks of at most 4096 bytes
+	const chunkSize = 4096
+	var sb strings.Builder
+	for i := 0; i < len(payload); i += chunkSize {
+		end := min(i+chunkSize, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,i=%d,c=%d,r=%d,C=1,q=2,m=%d;", kittyImageID, cols, rows, more)
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;", more)
+		}
+		sb.WriteString(payload[i:end])
+		sb.WriteString("\x1b\\")
+	}
+	return sb.String()
+}
+
+// itermImage sends img with the iTerm2 inline image protocol, sized in
+// cells
+func itermImage(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleImage(img, cols*cellPixelWidth, rows*cellPixelHeight)); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		buf.Len(), cols, rows, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// sixelImage encodes img as Sixel using a 6x6x6 color cube palette
+func sixelImage(img *image.RGBA) string {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Quantize every pixel to a palette index once
+	index := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+			index[y*w+x] = int(c.R)*6/256*36 + int(c.G)*6/256*6 + int(c.B)*6/256
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1bPq")
+	fmt.Fprintf(&sb, "\"1;1;%d;%d", w, h)
+	for i := 0; i < 216; i++ {
+		// Registers take RGB as percentages
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	row := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		// Each color used in the band gets one pass over it
+		var used [216]bool
+		for y := band; y < min(band+6, h); y++ {
+			for x := 0; x < w; x++ {
+				used[index[y*w+x]] = true
+			}
+		}
+		for color, ok := range used {
+			if !ok {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				bits := byte(0)
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if index[(band+dy)*w+x] == color {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			fmt.Fprintf(&sb, "#%d", color)
+			writeSixelRuns(&sb, row)
+			sb.WriteByte('$')
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+// writeSixelRuns writes sixel characters, run-length encoding repeats
+func writeSixelRuns(sb *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i + 1
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(sb, "!%d%c", n, row[i])
+		} else {
+			sb.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// scaleImage resizes img to fit within w x h, keeping its aspect ratio,
+// with nearest-neighbor sampling
+func scaleImage(img image.Image, w, h int) *image.RGBA {
+	src := img.Bounds()
+	if src.Dx() == 0 || src.Dy() == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 1, 1))
+	}
+	if src.Dx()*h > src.Dy()*w {
+		h = max(src.Dy()*w/src.Dx(), 1)
+	} else {
+		w = max(src.Dx()*h/src.Dy(), 1)
+	}
+
+	rgba := image.NewRGBA(src)
+	draw.Draw(rgba, src, img, src.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetRGBA(x, y, rgba.RGBAAt(src.Min.X+x*src.Dx()/w, src.Min.Y+y*src.Dy()/h))
+		}
+	}
+	return dst
+}
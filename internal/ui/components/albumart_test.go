@@ -0,0 +1,123 @@
+package components
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func samplePNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 6), uint8(y * 6), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectImageProtocol(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want ImageProtocol
+	}{
+		{map[string]string{"KITTY_WINDOW_ID": "1", "TERM": "xterm-kitty"}, ImageKitty},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, ImageKitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, ImageITerm2},
+		{map[string]string{"TERM": "foot"}, ImageSixel},
+		{map[string]string{"TERM": "xterm-256color"}, ImagePlaceholder},
+	}
+	for _, tt := range tests {
+		got := DetectImageProtocol(func(k string) string { return tt.env[k] })
+		if got != tt.want {
+			t.Errorf("DetectImageProtocol(%v) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestParseImageProtocol(t *testing.T) {
+	for _, p := range []ImageProtocol{ImageOff, ImagePlaceholder, ImageKitty, ImageITerm2, ImageSixel} {
+		if got, ok := ParseImageProtocol(p.String()); !ok || got != p {
+			t.Errorf("ParseImageProtocol(%q) = %v, %v", p.String(), got, ok)
+		}
+	}
+	if _, ok := ParseImageProtocol("braille"); ok {
+		t.Error("unknown protocol should not parse")
+	}
+}
+
+func TestAlbumArt_OffRendersNothing(t *testing.T) {
+	a := NewAlbumArt(ImageOff)
+	a.SetImage("a.mp3", samplePNG(t))
+	if a.View() != "" {
+		t.Errorf("ImageOff View = %q, want empty", a.View())
+	}
+}
+
+func TestAlbumArt_PlaceholderWithoutImage(t *testing.T) {
+	for _, p := range []ImageProtocol{ImagePlaceholder, ImageKitty} {
+		a := NewAlbumArt(p)
+		a.SetImage("a.mp3", []byte("not an image"))
+		out := a.View()
+		if strings.Contains(out, "\x1b_G") || strings.Contains(out, "\x1bP") {
+			t.Errorf("%v: invalid data should render the placeholder, got %q", p, out)
+		}
+		if !strings.Contains(out, "♪") || lipgloss.Width(out) != a.Cols || lipgloss.Height(out) != a.Rows {
+			t.Errorf("%v: placeholder = %q, want a %dx%d box", p, out, a.Cols, a.Rows)
+		}
+		if a.Clear() != "" {
+			t.Errorf("%v: Clear without an image = %q, want empty", p, a.Clear())
+		}
+	}
+}
+
+func TestAlbumArt_Kitty(t *testing.T) {
+	a := NewAlbumArt(ImageKitty)
+	a.SetImage("a.mp3", samplePNG(t))
+	out := a.View()
+	if !strings.HasPrefix(out, "\x1b_Ga=T,f=100") {
+		t.Fatalf("kitty View should start with a transmit command, got %.40q", out)
+	}
+	if lipgloss.Height(out) != a.Rows {
+		t.Errorf("height = %d, want %d", lipgloss.Height(out), a.Rows)
+	}
+	if a.Key() != "a.mp3" {
+		t.Errorf("Key = %q", a.Key())
+	}
+	if !strings.HasPrefix(a.Clear(), "\x1b_Ga=d") {
+		t.Errorf("Clear = %q, want a delete command", a.Clear())
+	}
+}
+
+func TestAlbumArt_SixelAndITerm2(t *testing.T) {
+	for p, prefix := range map[ImageProtocol]string{ImageSixel: "\x1bPq", ImageITerm2: "\x1b]1337;File="} {
+		a := NewAlbumArt(p)
+		a.SetImage("a.mp3", samplePNG(t))
+		lines := strings.Split(a.View(), "\n")
+		if len(lines) != a.Rows {
+			t.Fatalf("%v: %d lines, want %d", p, len(lines), a.Rows)
+		}
+		// Drawn from the last line after moving back to the top
+		if last := lines[a.Rows-1]; !strings.Contains(last, prefix) {
+			t.Errorf("%v: last line should carry the image, got %.40q", p, last)
+		}
+	}
+}
+
+func TestScaleImage_KeepsAspect(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	got := scaleImage(img, 160, 160).Bounds()
+	if got.Dx() != 160 || got.Dy() != 80 {
+		t.Errorf("scaled to %v, want 160x80", got)
+	}
+}
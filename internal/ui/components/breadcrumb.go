@@ -0,0 +1,149 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Crumb is one level of a breadcrumb path
+type Crumb struct {
+	Name string
+	Path string
+}
+
+// Breadcrumb renders a path as "root › Jazz › Miles Davis" relative to a
+// root directory, with an optional focused crumb for keyboard navigation
+type Breadcrumb struct {
+	RootPath  string // Crumbs are relative to this directory when Path is inside it
+	RootLabel string // Name shown for the root crumb
+	Path      string
+	Labels    []string // Crumbs to show instead of Path's, for levels that aren't directories
+	Focused   int      // Index of the focused crumb, or -1 for none
+	Width     int
+
+	Separator    string
+	CrumbStyle   lipgloss.Style
+	CurrentStyle lipgloss.Style
+	FocusedStyle lipgloss.Style
+	SepStyle     lipgloss.Style
+}
+
+// NewBreadcrumb creates a breadcrumb rooted at the home directory, falling
+// back to the filesystem root for paths outside it
+func NewBreadcrumb(width int) Breadcrumb {
+	b := Breadcrumb{
+		RootPath:  string(filepath.Separator),
+		RootLabel: string(filepath.Separator),
+		Focused:   -1,
+		Width:     width,
+		Separator: " › ",
+	}
+	b.SetTheme(DefaultTheme)
+	if home, err := os.UserHomeDir(); err == nil {
+		b.RootPath, b.RootLabel = home, "~"
+	}
+	return b
+}
+
+// SetTheme styles the crumbs in t's colors
+func (b *Breadcrumb) SetTheme(t Theme) {
+	b.CrumbStyle = lipgloss.NewStyle().Foreground(t.Subtle)
+	b.CurrentStyle = lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+	b.FocusedStyle = lipgloss.NewStyle().Background(t.SelectedBg).Foreground(t.Bright).Bold(true)
+	b.SepStyle = lipgloss.NewStyle().Foreground(t.Muted)
+}
+
+// SetPath changes the displayed path and clears the focus
+func (b *Breadcrumb) SetPath(path string) {
+	b.Path = filepath.Clean(path)
+	b.Focused = -1
+}
+
+// Crumbs returns the levels from the root down to Path, or Labels when set
+func (b Breadcrumb) Crumbs() []Crumb {
+	if b.Labels != nil {
+		crumbs := make([]Crumb, len(b.Labels))
+		for i, label := range b.Labels {
+			crumbs[i] = Crumb{Name: label}
+		}
+		return crumbs
+	}
+	root, label := b.RootPath, b.RootLabel
+	rel, err := filepath.Rel(root, b.Path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		root, label = string(filepath.Separator), string(filepath.Separator)
+		rel, _ = filepath.Rel(root, b.Path)
+	}
+
+	crumbs := []Crumb{{Name: label, Path: root}}
+	if rel == "." || rel == "" {
+		return crumbs
+	}
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		crumbs = append(crumbs, Crumb{Name: part, Path: current})
+	}
+	return crumbs
+}
+
+// FocusPrev moves the focus one level up, starting from the current level
+func (b *Breadcrumb) FocusPrev() {
+	n := len(b.Crumbs())
+	if b.Focused < 0 {
+		b.Focused = n - 1
+	}
+	if b.Focused > 0 {
+		b.Focused--
+	}
+}
+
+// FocusNext moves the focus one level down; moving past the current level
+// clears the focus
+func (b *Breadcrumb) FocusNext() {
+	if b.Focused < 0 {
+		return
+	}
+	b.Focused++
+	if b.Focused >= len(b.Crumbs())-1 {
+		b.Focused = -1
+	}
+}
+
+// FocusedCrumb returns the focused crumb, if any
+func (b Breadcrumb) FocusedCrumb() (Crumb, bool) {
+	crumbs := b.Crumbs()
+	if b.Focused < 0 || b.Focused >= len(crumbs) {
+		return Crumb{}, false
+	}
+	return crumbs[b.Focused], true
+}
+
+// View renders the breadcrumb. When it doesn't fit in Width, crumbs after
+// the root are replaced by "…" until it does, keeping the deepest levels.
+func (b Breadcrumb) View() string {
+	crumbs := b.Crumbs()
+	rendered := make([]string, len(crumbs))
+	for i, c := range crumbs {
+		switch {
+		case i == b.Focused:
+			rendered[i] = b.FocusedStyle.Render(c.Name)
+		case i == len(crumbs)-1:
+			rendered[i] = b.CurrentStyle.Render(c.Name)
+		default:
+			rendered[i] = b.CrumbStyle.Render(c.Name)
+		}
+	}
+
+	sep := b.SepStyle.Render(b.Separator)
+	ellipsis := b.CrumbStyle.Render("…")
+	line := strings.Join(rendered, sep)
+	for skip := 1; b.Width > 0 && lipgloss.Width(line) > b.Width && skip < len(rendered)-1; skip++ {
+		parts := append([]string{rendered[0], ellipsis}, rendered[skip+1:]...)
+		line = strings.Join(parts, sep)
+	}
+	return line
+}
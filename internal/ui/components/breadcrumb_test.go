@@ -0,0 +1,76 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func plainBreadcrumb(width int) Breadcrumb {
+	b := NewBreadcrumb(width)
+	b.RootPath, b.RootLabel = "/music", "root"
+	for _, style := range []*lipgloss.Style{&b.CrumbStyle, &b.CurrentStyle, &b.FocusedStyle, &b.SepStyle} {
+		*style = lipgloss.NewStyle()
+	}
+	return b
+}
+
+func TestBreadcrumb_View(t *testing.T) {
+	b := plainBreadcrumb(0)
+	b.SetPath("/music/Jazz/Miles Davis/Kind of Blue")
+
+	if got, want := b.View(), "root › Jazz › Miles Davis › Kind of Blue"; got != want {
+		t.Errorf("View = %q, want %q", got, want)
+	}
+}
+
+func TestBreadcrumb_TruncatesMiddle(t *testing.T) {
+	b := plainBreadcrumb(30)
+	b.SetPath("/music/Jazz/Miles Davis/Kind of Blue")
+
+	got := b.View()
+	if !strings.HasPrefix(got, "root › … › ") || !strings.HasSuffix(got, "Kind of Blue") {
+		t.Errorf("View = %q, want root, ellipsis and the last level", got)
+	}
+	if lipgloss.Width(got) > 30 {
+		t.Errorf("View is %d wide, want at most 30", lipgloss.Width(got))
+	}
+}
+
+func TestBreadcrumb_Focus(t *testing.T) {
+	b := plainBreadcrumb(0)
+	b.SetPath("/music/Jazz/Miles Davis")
+
+	b.FocusPrev()
+	b.FocusPrev()
+	crumb, ok := b.FocusedCrumb()
+	if !ok || crumb.Path != "/music" {
+		t.Errorf("focused = %+v, %v; want /music", crumb, ok)
+	}
+
+	b.FocusNext()
+	b.FocusNext()
+	if _, ok := b.FocusedCrumb(); ok {
+		t.Error("moving past the current level should clear the focus")
+	}
+}
+
+func TestBreadcrumb_OutsideRoot(t *testing.T) {
+	b := plainBreadcrumb(0)
+	b.SetPath("/srv/audio")
+
+	if got, want := b.View(), "/ › srv › audio"; got != want {
+		t.Errorf("View = %q, want %q", got, want)
+	}
+}
+
+func TestBreadcrumb_Labels(t *testing.T) {
+	b := plainBreadcrumb(0)
+	b.SetPath("/music/Jazz")
+	b.Labels = []string{"Artists", "AC/DC", "Back in Black"}
+
+	if got, want := b.View(), "Artists › AC/DC › Back in Black"; got != want {
+		t.Errorf("View = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package components
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// ColumnField is the track attribute a TrackList column shows
+type ColumnField int
+
+const (
+	ColumnTitle ColumnField = iota
+	ColumnArtist
+	ColumnAlbum
+	ColumnDuration
+	ColumnGenre
+	ColumnYear
+	ColumnTrackNumber
+	ColumnRating
+)
+
+var columnFieldNames = [...]string{"title", "artist", "album", "duration", "genre", "year", "track", "rating"}
+
+func (f ColumnField) String() string {
+	if int(f) < len(columnFieldNames) {
+		return columnFieldNames[f]
+	}
+	return "unknown"
+}
+
+// ColumnAlign is how a column's text sits within its width
+type ColumnAlign int
+
+const (
+	AlignLeft ColumnAlign = iota
+	AlignRight
+)
+
+// Column is one column of a TrackList's column layout
+type Column struct {
+	Field ColumnField
+	Width int // In cells; 0 shares out the width the fixed columns leave
+	Align ColumnAlign
+}
+
+// columnSeparator goes between columns
+const columnSeparator = "  "
+
+// ellipsis ends text cut to fit its column
+const ellipsis = "…"
+
+// ParseColumns parses a column layout such as "title,artist:20,album,
+// duration:>5": field names, each optionally followed by a width, which a
+// leading "<" or ">" aligns left or right. Numbers and durations are
+// right-aligned unless "<" says otherwise.
+func ParseColumns(spec string) ([]Column, error) {
+	var columns []Column
+	for _, part := range strings.Split(spec, ",") {
+		name, width, _ := strings.Cut(strings.TrimSpace(part), ":")
+		col := Column{Field: -1}
+		for i, fieldName := range columnFieldNames {
+			if name == fieldName {
+				col.Field = ColumnField(i)
+			}
+		}
+		if col.Field < 0 {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		switch col.Field {
+		case ColumnDuration, ColumnYear, ColumnTrackNumber:
+			col.Align = AlignRight
+		}
+		switch {
+		case strings.HasPrefix(width, ">"):
+			col.Align, width = AlignRight, width[1:]
+		case strings.HasPrefix(width, "<"):
+			col.Align, width = AlignLeft, width[1:]
+		}
+		if width != "" {
+			n, err := strconv.Atoi(width)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("column %s: bad width %q", name, width)
+			}
+			col.Width = n
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// SetColumns lays rows out in columns, truncating each field to its
+// column. With no columns rows are drawn the classic way, as
+// "artist - title".
+func (l *TrackList) SetColumns(columns []Column) {
+	if len(columns) == 0 {
+		columns = nil
+	}
+	l.columns = columns
+}
+
+// Columns returns the column layout, nil for the classic rows
+func (l *TrackList) Columns() []Column {
+	return l.columns
+}
+
+// columnText returns the text of track's field
+func columnText(field ColumnField, track *api.Track) string {
+	switch field {
+	case ColumnTitle:
+		return track.Title
+	case ColumnArtist:
+		return track.Artist
+	case ColumnAlbum:
+		return track.Album
+	case ColumnDuration:
+		if track.Duration > 0 {
+			return formatDuration(track.Duration)
+		}
+	case ColumnGenre:
+		return track.Genre
+	case ColumnYear:
+		if track.Year > 0 {
+			return strconv.Itoa(track.Year)
+		}
+	case ColumnTrackNumber:
+		if track.TrackNum > 0 {
+			return strconv.Itoa(track.TrackNum)
+		}
+	case ColumnRating:
+		return Stars(track.Rating)
+	}
+	return ""
+}
+
+// Stars draws a rating as filled stars out of five, e.g. "★★★☆☆", or ""
+// for an unrated track
+func Stars(rating int) string {
+	if rating <= 0 {
+		return ""
+	}
+	rating = min(rating, api.MaxRating)
+	return strings.Repeat("★", rating) + strings.Repeat("☆", api.MaxRating-rating)
+}
+
+// highlightField names the Highlight field of a column, if it has one
+func highlightField(field ColumnField) string {
+	switch field {
+	case ColumnTitle:
+		return FieldTitle
+	case ColumnArtist:
+		return FieldArtist
+	}
+	return ""
+}
+
+// columnWidths fits the columns into width cells, separators included.
+// Flexible columns share what the fixed ones leave; if the fixed ones
+// don't fit, the widest are narrowed first so short columns like the
+// duration stay readable.
+func columnWidths(columns []Column, width int) []int {
+	widths := make([]int, len(columns))
+	avail := width - len(columnSeparator)*max(len(columns)-1, 0)
+	flex, fixed := 0, 0
+	for i, col := range columns {
+		if col.Width > 0 {
+			widths[i] = col.Width
+			fixed += col.Width
+		} else {
+			flex++
+		}
+	}
+
+	if spare := avail - fixed; spare > 0 && flex > 0 {
+		share, extra := spare/flex, spare%flex
+		for i, col := range columns {
+			if col.Width == 0 {
+				widths[i] = share
+				if extra > 0 {
+					widths[i]++
+					extra--
+				}
+			}
+		}
+		return widths
+	}
+
+	for excess := fixed - max(avail, 0); excess > 0; {
+		widest, next := 0, 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		for i, w := range widths {
+			if i != widest && w > next {
+				next = w
+			}
+		}
+		cut := min(excess, max(widths[widest]-next, 1))
+		widths[widest] -= cut
+		excess -= cut
+	}
+	return widths
+}
+
+// fitText cuts s to width cells, ending it with an ellipsis when shortened
+func fitText(s string, width int) string {
+	if ansi.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(s, width, ellipsis)
+}
+
+// columnsText builds a row laid out in columns within width cells and,
+// when Highlight is set, which of its runes to emphasise. Columns left
+// with no room are dropped along with their separator.
+func (l TrackList) columnsText(prefix string, track *api.Track, width int) (string, []bool) {
+	widths := columnWidths(l.columns, width-ansi.StringWidth(prefix))
+
+	var sb strings.Builder
+	var marks []bool
+	write := func(s string, fieldMarks []bool) {
+		sb.WriteString(s)
+		if l.Highlight != nil {
+			if fieldMarks == nil {
+				fieldMarks = make([]bool, utf8.RuneCountInString(s))
+			}
+			marks = append(marks, fieldMarks...)
+		}
+	}
+
+	write(prefix, nil)
+	first := true
+	for i, col := range l.columns {
+		w := widths[i]
+		if w <= 0 {
+			continue
+		}
+		if !first {
+			write(columnSeparator, nil)
+		}
+		first = false
+
+		text := columnText(col.Field, track)
+		shown := fitText(text, w)
+		pad := strings.Repeat(" ", w-ansi.StringWidth(shown))
+		if col.Align == AlignRight {
+			write(pad, nil)
+		}
+		write(shown, l.columnMarks(col.Field, text, shown))
+		if col.Align == AlignLeft {
+			write(pad, nil)
+		}
+	}
+	return sb.String(), marks
+}
+
+// columnMarks returns which runes of a field's shown text to emphasise,
+// nil when Highlight isn't set. Matches cut off by the ellipsis stay
+// unmarked.
+func (l TrackList) columnMarks(field ColumnField, text, shown string) []bool {
+	if l.Highlight == nil {
+		return nil
+	}
+	marks := make([]bool, utf8.RuneCountInString(shown))
+	name := highlightField(field)
+	if name == "" {
+		return marks
+	}
+	visible := len(marks)
+	if shown != text {
+		visible--
+	}
+	for _, pos := range l.Highlight(name, text) {
+		if pos >= 0 && pos < visible {
+			marks[pos] = true
+		}
+	}
+	return marks
+}
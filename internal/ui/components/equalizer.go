@@ -0,0 +1,111 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// EQBars draws equalizer band gains as vertical bars around a 0 dB line,
+// with each band's label underneath and the selected band highlighted
+type EQBars struct {
+	Gains    []float64 // Band gains in dB
+	Labels   []string  // Band labels, e.g. "1k"
+	Selected int
+	MaxGain  float64 // Gain at the top and bottom of the bars
+	Rows     int     // Rows above and below the 0 dB line
+	Bypassed bool
+
+	BarStyle      lipgloss.Style
+	SelectedStyle lipgloss.Style
+	AxisStyle     lipgloss.Style
+	BypassStyle   lipgloss.Style
+}
+
+// eqColumnWidth is the width of each band's column
+const eqColumnWidth = 5
+
+// NewEQBars creates bars for gains ranging over ±maxGain dB
+func NewEQBars(labels []string, maxGain float64) EQBars {
+	b := EQBars{
+		Labels:  labels,
+		MaxGain: maxGain,
+		Rows:    4,
+	}
+	b.SetTheme(DefaultTheme)
+	return b
+}
+
+// SetTheme styles the bars in t's colors
+func (b *EQBars) SetTheme(t Theme) {
+	b.BarStyle = lipgloss.NewStyle().Foreground(t.Border)
+	b.SelectedStyle = lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+	b.AxisStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	b.BypassStyle = lipgloss.NewStyle().Foreground(t.Muted)
+}
+
+// View renders the bars, 2*Rows+1 lines of them, then the labels and the
+// selected band's gain
+func (b EQBars) View() string {
+	rows := max(b.Rows, 1)
+	var lines []string
+	for r := rows; r >= -rows; r-- {
+		var sb strings.Builder
+		for i, gain := range b.Gains {
+			sb.WriteString(b.style(i).Render(b.cell(gain, r, rows)))
+		}
+		lines = append(lines, sb.String())
+	}
+
+	var labels strings.Builder
+	for i := range b.Gains {
+		label := ""
+		if i < len(b.Labels) {
+			label = b.Labels[i]
+		}
+		cell := lipgloss.PlaceHorizontal(eqColumnWidth, lipgloss.Center, label)
+		if i == b.Selected {
+			labels.WriteString(b.SelectedStyle.Render(cell))
+		} else {
+			labels.WriteString(b.AxisStyle.Render(cell))
+		}
+	}
+	lines = append(lines, labels.String())
+
+	if b.Selected >= 0 && b.Selected < len(b.Gains) {
+		label := ""
+		if b.Selected < len(b.Labels) {
+			label = b.Labels[b.Selected] + " "
+		}
+		lines = append(lines, b.SelectedStyle.Render(fmt.Sprintf("%s%+.1f dB", label, b.Gains[b.Selected])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cell draws row r (rows above the 0 dB line are positive) of a bar
+func (b EQBars) cell(gain float64, r, rows int) string {
+	level := 0
+	if b.MaxGain > 0 {
+		level = int(math.Round(gain / b.MaxGain * float64(rows)))
+	}
+	mark := " "
+	switch {
+	case r == 0:
+		mark = "─"
+	case r > 0 && level >= r, r < 0 && level <= r:
+		mark = "█"
+	}
+	return lipgloss.PlaceHorizontal(eqColumnWidth, lipgloss.Center, strings.Repeat(mark, 3))
+}
+
+func (b EQBars) style(i int) lipgloss.Style {
+	switch {
+	case b.Bypassed:
+		return b.BypassStyle
+	case i == b.Selected:
+		return b.SelectedStyle
+	}
+	return b.BarStyle
+}
@@ -0,0 +1,36 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestEQBars_View(t *testing.T) {
+	bars := NewEQBars([]string{"lo", "mid", "hi"}, 12)
+	bars.Gains = []float64{12, 0, -6}
+	bars.Selected = 2
+
+	lines := strings.Split(bars.View(), "\n")
+	// 4 rows above the axis, the axis, 4 below, labels, then the gain
+	if len(lines) != 11 {
+		t.Fatalf("got %d lines, want 11:\n%s", len(lines), bars.View())
+	}
+	if w := lipgloss.Width(lines[0]); w != 3*eqColumnWidth {
+		t.Errorf("bar rows are %d wide, want %d", w, 3*eqColumnWidth)
+	}
+
+	column := func(line string, i int) string {
+		return strings.TrimSpace(string([]rune(line)[i*eqColumnWidth : (i+1)*eqColumnWidth]))
+	}
+	if column(lines[0], 0) != "███" || column(lines[0], 2) != "" {
+		t.Errorf("top row = %q, want only the +12 dB band filled", lines[0])
+	}
+	if column(lines[6], 2) != "███" || column(lines[7], 2) != "" {
+		t.Errorf("-6 dB band should fill two rows below the axis:\n%s", bars.View())
+	}
+	if got := lines[10]; got != "hi -6.0 dB" {
+		t.Errorf("gain line = %q, want %q", got, "hi -6.0 dB")
+	}
+}
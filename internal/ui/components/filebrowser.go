@@ -17,6 +17,10 @@ type FileEntry struct {
 	IsDir bool
 }
 
+// ListDirFunc lists the subfolders and files directly in a folder. err
+// says what couldn't be read, alongside whatever was listed.
+type ListDirFunc func(path string) (subdirs, files []string, err error)
+
 // FileBrowser is a component for navigating the filesystem
 type FileBrowser struct {
 	Width       int
@@ -25,8 +29,10 @@ type FileBrowser struct {
 	Entries     []FileEntry
 	Selected    int
 	Offset      int
-	Extensions  []string // Supported file extensions
+	Extensions  []string    // Supported file extensions, matched case-insensitively
+	ListDir     ListDirFunc // Lists folders instead of reading them itself, if set
 	Err         error
+	Breadcrumb  Breadcrumb
 
 	// Styles
 	DirStyle      lipgloss.Style
@@ -34,6 +40,8 @@ type FileBrowser struct {
 	SelectedStyle lipgloss.Style
 	PathStyle     lipgloss.Style
 	BorderStyle   lipgloss.Style
+	ErrorStyle    lipgloss.Style
+	HelpStyle     lipgloss.Style
 }
 
 // NewFileBrowser creates a new file browser starting at the given path
@@ -42,23 +50,9 @@ func NewFileBrowser(startPath string, width, height int) FileBrowser {
 		Width:      width,
 		Height:     height,
 		Extensions: []string{".mp3", ".wav", ".flac"},
-		DirStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("33")).
-			Bold(true),
-		FileStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")),
-		SelectedStyle: lipgloss.NewStyle().
-			Background(lipgloss.Color("62")).
-			Foreground(lipgloss.Color("255")).
-			Bold(true),
-		PathStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("212")).
-			Bold(true),
-		BorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Padding(1, 2),
+		Breadcrumb: NewBreadcrumb(width - 10),
 	}
+	fb.SetTheme(DefaultTheme)
 
 	// If startPath is empty, use home directory
 	if startPath == "" {
@@ -74,21 +68,45 @@ func NewFileBrowser(startPath string, width, height int) FileBrowser {
 	return fb
 }
 
-// Navigate changes to the specified directory
+// SetTheme styles the browser and its breadcrumb in t's colors
+func (fb *FileBrowser) SetTheme(t Theme) {
+	fb.DirStyle = lipgloss.NewStyle().
+		Foreground(t.Directory).
+		Bold(true)
+	fb.FileStyle = lipgloss.NewStyle().
+		Foreground(t.Bright)
+	fb.SelectedStyle = lipgloss.NewStyle().
+		Background(t.SelectedBg).
+		Foreground(t.Bright).
+		Bold(true)
+	fb.PathStyle = lipgloss.NewStyle().
+		Foreground(t.Accent).
+		Bold(true)
+	fb.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	fb.ErrorStyle = lipgloss.NewStyle().Foreground(t.Error)
+	fb.HelpStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	fb.Breadcrumb.SetTheme(t)
+}
+
+// Navigate changes to the specified directory. A folder that can't be
+// fully read still shows what was listed, with the error above it.
 func (fb *FileBrowser) Navigate(path string) {
 	fb.CurrentPath = path
 	fb.Selected = 0
 	fb.Offset = 0
-	fb.Err = nil
+	fb.Breadcrumb.SetPath(path)
 
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		fb.Err = err
-		fb.Entries = nil
-		return
+	list := fb.ListDir
+	if list == nil {
+		list = fb.readDir
 	}
+	subdirs, files, err := list(path)
+	fb.Err = err
 
-	fb.Entries = make([]FileEntry, 0)
+	fb.Entries = make([]FileEntry, 0, len(subdirs)+len(files)+1)
 
 	// Add parent directory entry (unless at root)
 	if path != "/" {
@@ -99,9 +117,27 @@ func (fb *FileBrowser) Navigate(path string) {
 		})
 	}
 
-	// Separate dirs and files
-	var dirs, files []FileEntry
+	// Add directories first, then files, each sorted alphabetically
+	fb.Entries = append(fb.Entries, sortedEntries(subdirs, true)...)
+	fb.Entries = append(fb.Entries, sortedEntries(files, false)...)
+}
 
+// sortedEntries makes entries of paths, sorted by name ignoring case
+func sortedEntries(paths []string, isDir bool) []FileEntry {
+	entries := make([]FileEntry, len(paths))
+	for i, path := range paths {
+		entries[i] = FileEntry{Name: filepath.Base(path), Path: path, IsDir: isDir}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries
+}
+
+// readDir lists path when no ListDir is set: every folder and each file
+// with one of the Extensions, leaving hidden ones out
+func (fb *FileBrowser) readDir(path string) (subdirs, files []string, err error) {
+	entries, err := os.ReadDir(path)
 	for _, entry := range entries {
 		// Skip hidden files
 		if strings.HasPrefix(entry.Name(), ".") {
@@ -109,40 +145,20 @@ func (fb *FileBrowser) Navigate(path string) {
 		}
 
 		fullPath := filepath.Join(path, entry.Name())
-
 		if entry.IsDir() {
-			dirs = append(dirs, FileEntry{
-				Name:  entry.Name(),
-				Path:  fullPath,
-				IsDir: true,
-			})
-		} else {
-			// Only show supported audio files
-			ext := strings.ToLower(filepath.Ext(entry.Name()))
-			for _, supportedExt := range fb.Extensions {
-				if ext == supportedExt {
-					files = append(files, FileEntry{
-						Name:  entry.Name(),
-						Path:  fullPath,
-						IsDir: false,
-					})
-					break
-				}
+			subdirs = append(subdirs, fullPath)
+			continue
+		}
+		// Only show supported audio files
+		ext := filepath.Ext(entry.Name())
+		for _, supportedExt := range fb.Extensions {
+			if strings.EqualFold(ext, supportedExt) {
+				files = append(files, fullPath)
+				break
 			}
 		}
 	}
-
-	// Sort directories and files alphabetically
-	sort.Slice(dirs, func(i, j int) bool {
-		return strings.ToLower(dirs[i].Name) < strings.ToLower(dirs[j].Name)
-	})
-	sort.Slice(files, func(i, j int) bool {
-		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
-	})
-
-	// Add directories first, then files
-	fb.Entries = append(fb.Entries, dirs...)
-	fb.Entries = append(fb.Entries, files...)
+	return subdirs, files, err
 }
 
 // Update handles input messages
@@ -178,6 +194,11 @@ func (fb FileBrowser) Update(msg tea.Msg) (FileBrowser, tea.Cmd) {
 		case "end":
 			fb.Selected = len(fb.Entries) - 1
 			fb.ensureVisible()
+		case "left", "h":
+			// Focus a parent level in the breadcrumb
+			fb.Breadcrumb.FocusPrev()
+		case "right", "l":
+			fb.Breadcrumb.FocusNext()
 		case "backspace":
 			// Go to parent directory
 			if fb.CurrentPath != "/" {
@@ -204,6 +225,12 @@ func (fb *FileBrowser) SelectedEntry() *FileEntry {
 // EnterSelected handles Enter on the selected entry
 // Returns the file path if a file was selected, empty string if navigated to dir
 func (fb *FileBrowser) EnterSelected() string {
+	// A focused breadcrumb takes precedence: jump to that level
+	if crumb, ok := fb.Breadcrumb.FocusedCrumb(); ok {
+		fb.Navigate(crumb.Path)
+		return ""
+	}
+
 	entry := fb.SelectedEntry()
 	if entry == nil {
 		return ""
@@ -241,14 +268,14 @@ func (fb *FileBrowser) ensureVisible() {
 func (fb FileBrowser) View() string {
 	var sb strings.Builder
 
-	// Current path
-	sb.WriteString(fb.PathStyle.Render("📁 " + fb.CurrentPath))
+	// Current path as a breadcrumb
+	sb.WriteString(fb.PathStyle.Render("📁 "))
+	sb.WriteString(fb.Breadcrumb.View())
 	sb.WriteString("\n\n")
 
 	// Error display
 	if fb.Err != nil {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-		sb.WriteString(errorStyle.Render("Error: " + fb.Err.Error()))
+		sb.WriteString(fb.ErrorStyle.Render("Error: " + fb.Err.Error()))
 		sb.WriteString("\n")
 	}
 
@@ -270,10 +297,8 @@ func (fb FileBrowser) View() string {
 		}
 
 		// Truncate if too long
-		maxWidth := fb.Width - 10
-		if len(line) > maxWidth {
-			line = line[:maxWidth-3] + "..."
-		}
+		maxWidth := max(fb.Width-10, 3)
+		line = truncate(line, maxWidth)
 
 		if i == fb.Selected {
 			sb.WriteString(fb.SelectedStyle.Render(line))
@@ -297,15 +322,13 @@ func (fb FileBrowser) View() string {
 			fileCount++
 		}
 	}
-	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	sb.WriteString(countStyle.Render(
+	sb.WriteString(fb.HelpStyle.Render(
 		strings.Repeat("─", 20) + "\n" +
 			"Files: " + string(rune('0'+fileCount/100%10)) + string(rune('0'+fileCount/10%10)) + string(rune('0'+fileCount%10))))
 
 	// Help text
 	sb.WriteString("\n\n")
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	sb.WriteString(helpStyle.Render("[Enter] Open/Add  [Backspace] Up  [~] Home  [Esc] Cancel"))
+	sb.WriteString(fb.HelpStyle.Render("[Enter] Open/Add  [A] Queue Folder  [←/→] Path  [Backspace] Up  [~] Home  [Esc] Cancel"))
 
 	return fb.BorderStyle.Width(fb.Width - 4).Render(sb.String())
 }
@@ -0,0 +1,51 @@
+package components
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func entryNames(fb FileBrowser) []string {
+	var names []string
+	for _, entry := range fb.Entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+func TestFileBrowser_NavigateReadsDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.MP3", "a.flac", "notes.txt", ".hidden.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "Zed"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fb := NewFileBrowser(dir, 80, 20)
+	fb.Extensions = []string{".mp3", ".flac"}
+	fb.Navigate(dir)
+	if got, want := entryNames(fb), []string{"..", "Zed", "a.flac", "b.MP3"}; !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+func TestFileBrowser_NavigateWithListDir(t *testing.T) {
+	fb := NewFileBrowser("/", 80, 20)
+	failed := errors.New("permission denied")
+	fb.ListDir = func(path string) ([]string, []string, error) {
+		return []string{path + "/Live"}, []string{path + "/b.mp3", path + "/A.mp3"}, failed
+	}
+	fb.Navigate("/music")
+	if got, want := entryNames(fb), []string{"..", "Live", "A.mp3", "b.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+	if fb.Err != failed {
+		t.Errorf("Err = %v, want the listing's error kept alongside the entries", fb.Err)
+	}
+}
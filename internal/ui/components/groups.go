@@ -0,0 +1,130 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// TrackGroup is a run of tracks listed under a collapsible header, such as
+// an album
+type TrackGroup struct {
+	Key       string // Identifies the group across refreshes, e.g. to remember collapse state
+	Title     string
+	Tracks    []*api.Track
+	Collapsed bool
+}
+
+// listRow is one row of a grouped list: a group header, or a track with
+// its index into Items
+type listRow struct {
+	group int
+	track int // -1 for the group header
+	num   int // Number shown before the track
+}
+
+// SetGroups lists tracks under group headers. Items holds every grouped
+// track, collapsed or not, in group order. SetItems returns to a flat list.
+func (l *TrackList) SetGroups(groups []TrackGroup) {
+	l.groups = groups
+	l.Items = make([]*api.Track, 0, len(l.Items))
+	for _, g := range groups {
+		l.Items = append(l.Items, g.Tracks...)
+	}
+	l.buildRows()
+	l.Selected = 0
+	l.offset = 0
+}
+
+// Grouped reports whether the list is showing group headers
+func (l *TrackList) Grouped() bool {
+	return l.groups != nil
+}
+
+// buildRows lays out headers and the tracks of expanded groups
+func (l *TrackList) buildRows() {
+	l.rows = l.rows[:0]
+	base := 0
+	for gi, g := range l.groups {
+		l.rows = append(l.rows, listRow{group: gi, track: -1})
+		if !g.Collapsed {
+			for j, track := range g.Tracks {
+				num := j + 1
+				if track.TrackNum > 0 {
+					num = track.TrackNum
+				}
+				l.rows = append(l.rows, listRow{group: gi, track: base + j, num: num})
+			}
+		}
+		base += len(g.Tracks)
+	}
+}
+
+// ToggleGroup collapses or expands the group of the selected row, leaving
+// its header selected, and returns the group's new state. It does nothing
+// on an ungrouped list.
+func (l *TrackList) ToggleGroup() (TrackGroup, bool) {
+	if l.groups == nil || l.Selected < 0 || l.Selected >= len(l.rows) {
+		return TrackGroup{}, false
+	}
+	gi := l.rows[l.Selected].group
+	l.groups[gi].Collapsed = !l.groups[gi].Collapsed
+	l.buildRows()
+	for i, row := range l.rows {
+		if row.group == gi && row.track < 0 {
+			l.Selected = i
+			break
+		}
+	}
+	l.ensureVisible()
+	return l.groups[gi], true
+}
+
+// OnGroupHeader reports whether a group header is selected
+func (l *TrackList) OnGroupHeader() bool {
+	return l.groups != nil && l.Selected >= 0 && l.Selected < len(l.rows) && l.rows[l.Selected].track < 0
+}
+
+// RowCount returns the number of rows shown, including group headers
+func (l *TrackList) RowCount() int {
+	if l.groups != nil {
+		return len(l.rows)
+	}
+	return len(l.Items)
+}
+
+// RowTrack returns the track on row i, or nil for a group header
+func (l *TrackList) RowTrack(i int) *api.Track {
+	if i < 0 || i >= l.RowCount() {
+		return nil
+	}
+	if l.groups != nil {
+		if l.rows[i].track < 0 {
+			return nil
+		}
+		return l.Items[l.rows[i].track]
+	}
+	return l.Items[i]
+}
+
+// SelectTrack moves the selection to the row showing track, or another
+// track for the same file, scrolling it into view. It reports whether the
+// track is listed and not inside a collapsed group.
+func (l *TrackList) SelectTrack(track *api.Track) bool {
+	for i := 0; i < l.RowCount(); i++ {
+		if row := l.RowTrack(i); row == track || (row != nil && track.FilePath != "" && row.FilePath == track.FilePath) {
+			l.SetSelected(i)
+			return true
+		}
+	}
+	return false
+}
+
+// headerText builds the text of a group header row
+func (l TrackList) headerText(g TrackGroup) string {
+	arrow := "▾"
+	if g.Collapsed {
+		arrow = "▸"
+	}
+	return fmt.Sprintf("%s %s (%d)", arrow, truncate(g.Title, max(l.Width-16, 10)), len(g.Tracks))
+}
@@ -2,10 +2,14 @@ package components
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/jscyril/golang_music_player/api"
 )
 
@@ -15,47 +19,197 @@ type TrackList struct {
 	Selected      int
 	Height        int
 	Width         int
-	Offset        int
+	offset        int
 	Title         string
 	ShowNumbers   bool
+	RowSuffix     func(*api.Track) string                 // Optional extra text after each row
+	RowColor      func(*api.Track) (lipgloss.Color, bool) // Optional tint for unselected rows
+	Highlight     func(field, text string) []int          // Optional rune indices of a field's text to emphasise
 	SelectedStyle lipgloss.Style
+	MatchStyle    lipgloss.Style
 	NormalStyle   lipgloss.Style
+	HeaderStyle   lipgloss.Style
 	TitleStyle    lipgloss.Style
+
+	// Marked tracks by ID, in the order they were marked. Marks follow the
+	// track rather than the row, so they survive scrolling, sorting and
+	// filtering; tracks filtered out of Items stay marked.
+	marked    map[string]bool
+	markOrder []*api.Track
+
+	// Column layout set with SetColumns; nil draws "artist - title" rows
+	columns []Column
+
+	// Group headers and the rows they lay out, nil for a flat list. When
+	// grouped, Selected and Offset index rows rather than Items.
+	groups []TrackGroup
+	rows   []listRow
+
+	// The row last clicked and when, to tell a double-click
+	clickRow int
+	clickAt  time.Time
 }
 
+// Field names passed to TrackList.Highlight
+const (
+	FieldArtist = "artist"
+	FieldTitle  = "title"
+)
+
 // NewTrackList creates a new track list
 func NewTrackList(height, width int) TrackList {
-	return TrackList{
+	l := TrackList{
 		Items:    make([]*api.Track, 0),
 		Selected: 0,
 		Height:   height,
 		Width:    width,
-		Offset:   0,
-		SelectedStyle: lipgloss.NewStyle().
-			Background(lipgloss.Color("62")).
-			Foreground(lipgloss.Color("230")).
-			Bold(true).
-			Padding(0, 1),
 		NormalStyle: lipgloss.NewStyle().
 			Padding(0, 1),
-		TitleStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("212")).
-			MarginBottom(1),
 		ShowNumbers: true,
 	}
+	l.SetTheme(DefaultTheme)
+	return l
 }
 
-// SetItems sets the list items
+// SetTheme styles the list in t's colors
+func (l *TrackList) SetTheme(t Theme) {
+	l.SelectedStyle = lipgloss.NewStyle().
+		Background(t.SelectedBg).
+		Foreground(t.SelectedFg).
+		Bold(true).
+		Padding(0, 1)
+	l.HeaderStyle = lipgloss.NewStyle().
+		Foreground(t.Header).
+		Bold(true).
+		Padding(0, 1)
+	l.MatchStyle = lipgloss.NewStyle().
+		Foreground(t.Accent).
+		Bold(true).
+		Underline(true)
+	l.TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent).
+		MarginBottom(1)
+}
+
+// SetItems sets the list items, without group headers
 func (l *TrackList) SetItems(items []*api.Track) {
 	l.Items = items
+	l.groups, l.rows = nil, nil
 	l.Selected = 0
-	l.Offset = 0
+	l.offset = 0
+}
+
+// ToggleMark marks the selected track, or unmarks it if already marked
+func (l *TrackList) ToggleMark() {
+	track := l.SelectedItem()
+	if track == nil {
+		return
+	}
+	if l.marked[track.ID] {
+		l.unmark(track.ID)
+		return
+	}
+	l.mark(track)
+}
+
+// MarkAll marks every track in Items. If they are all marked already it
+// unmarks them instead.
+func (l *TrackList) MarkAll() {
+	all := true
+	for _, track := range l.Items {
+		if !l.marked[track.ID] {
+			all = false
+			l.mark(track)
+		}
+	}
+	if all {
+		for _, track := range l.Items {
+			l.unmark(track.ID)
+		}
+	}
+}
+
+// ClearMarks unmarks every track, including ones not currently listed
+func (l *TrackList) ClearMarks() {
+	l.marked = nil
+	l.markOrder = nil
+}
+
+// IsMarked reports whether a track is marked
+func (l *TrackList) IsMarked(track *api.Track) bool {
+	return track != nil && l.marked[track.ID]
+}
+
+// MarkedCount returns the number of marked tracks, listed or not
+func (l *TrackList) MarkedCount() int {
+	return len(l.marked)
+}
+
+// SelectedItems returns the marked tracks: those in Items in list order,
+// then any that are filtered out in the order they were marked. With no
+// marks it returns nil.
+func (l *TrackList) SelectedItems() []*api.Track {
+	if len(l.marked) == 0 {
+		return nil
+	}
+	items := make([]*api.Track, 0, len(l.marked))
+	listed := make(map[string]bool, len(l.marked))
+	for _, track := range l.Items {
+		if l.marked[track.ID] && !listed[track.ID] {
+			items = append(items, track)
+			listed[track.ID] = true
+		}
+	}
+	for _, track := range l.markOrder {
+		if !listed[track.ID] {
+			items = append(items, track)
+		}
+	}
+	return items
+}
+
+func (l *TrackList) mark(track *api.Track) {
+	if l.marked == nil {
+		l.marked = make(map[string]bool)
+	}
+	l.marked[track.ID] = true
+	l.markOrder = append(l.markOrder, track)
+}
+
+func (l *TrackList) unmark(id string) {
+	delete(l.marked, id)
+	for i, track := range l.markOrder {
+		if track.ID == id {
+			l.markOrder = append(l.markOrder[:i], l.markOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetSelected moves the selection to index, clamped to the list
+func (l *TrackList) SetSelected(index int) {
+	if index >= l.RowCount() {
+		index = l.RowCount() - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	l.Selected = index
+	l.ensureVisible()
+}
+
+// SetHeight resizes the list, scrolling so the selection stays visible
+func (l *TrackList) SetHeight(height int) {
+	l.Height = height
+	l.ensureVisible()
 }
 
 // Update handles messages for the track list
 func (l TrackList) Update(msg tea.Msg) (TrackList, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		return l.updateMouse(msg)
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k":
@@ -64,10 +218,10 @@ func (l TrackList) Update(msg tea.Msg) (TrackList, tea.Cmd) {
 			l.MoveDown()
 		case "home":
 			l.Selected = 0
-			l.Offset = 0
+			l.offset = 0
 		case "end":
-			if len(l.Items) > 0 {
-				l.Selected = len(l.Items) - 1
+			if l.RowCount() > 0 {
+				l.Selected = l.RowCount() - 1
 				l.ensureVisible()
 			}
 		case "pgup":
@@ -89,7 +243,7 @@ func (l *TrackList) MoveUp() {
 
 // MoveDown moves selection down
 func (l *TrackList) MoveDown() {
-	if l.Selected < len(l.Items)-1 {
+	if l.Selected < l.RowCount()-1 {
 		l.Selected++
 		l.ensureVisible()
 	}
@@ -107,32 +261,59 @@ func (l *TrackList) PageUp() {
 // PageDown moves selection down by a page
 func (l *TrackList) PageDown() {
 	l.Selected += l.Height - 2
-	if l.Selected >= len(l.Items) {
-		l.Selected = len(l.Items) - 1
+	if l.Selected >= l.RowCount() {
+		// An empty list keeps row 0, so nothing indexes row -1
+		l.Selected = max(l.RowCount()-1, 0)
 	}
 	l.ensureVisible()
 }
 
+// Cursor returns the selected row
+func (l *TrackList) Cursor() int {
+	return l.Selected
+}
+
+// SetCursor selects row i, clamped to the list, scrolling it into view
+func (l *TrackList) SetCursor(i int) {
+	l.SetSelected(i)
+}
+
+// Offset returns the first row shown
+func (l *TrackList) Offset() int {
+	return l.offset
+}
+
+// SetOffset scrolls so row i is the first shown, clamped so the window
+// stays on the list. The cursor moves into the window if it scrolled out.
+func (l *TrackList) SetOffset(i int) {
+	visibleHeight := l.visibleRows()
+	l.offset = min(max(i, 0), max(l.RowCount()-visibleHeight, 0))
+	if l.RowCount() == 0 {
+		return
+	}
+	l.Selected = min(max(l.Selected, l.offset), l.offset+visibleHeight-1, l.RowCount()-1)
+}
+
+// visibleRows returns how many rows fit in the list
+func (l *TrackList) visibleRows() int {
+	return max(l.Height-2, 1) // Account for title and border
+}
+
 // ensureVisible ensures the selected item is visible
 func (l *TrackList) ensureVisible() {
-	visibleHeight := l.Height - 2 // Account for title and border
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
+	visibleHeight := l.visibleRows()
 
-	if l.Selected < l.Offset {
-		l.Offset = l.Selected
-	} else if l.Selected >= l.Offset+visibleHeight {
-		l.Offset = l.Selected - visibleHeight + 1
+	if l.Selected < l.offset {
+		l.offset = l.Selected
+	} else if l.Selected >= l.offset+visibleHeight {
+		l.offset = l.Selected - visibleHeight + 1
 	}
 }
 
-// SelectedItem returns the currently selected track
+// SelectedItem returns the currently selected track, or nil on a group
+// header or when the list is empty, such as a search matching nothing
 func (l *TrackList) SelectedItem() *api.Track {
-	if l.Selected >= 0 && l.Selected < len(l.Items) {
-		return l.Items[l.Selected]
-	}
-	return nil
+	return l.RowTrack(l.Selected)
 }
 
 // View renders the track list
@@ -145,42 +326,70 @@ func (l TrackList) View() string {
 		sb.WriteString("\n")
 	}
 
-	if len(l.Items) == 0 {
+	rowCount := l.RowCount()
+	if rowCount == 0 {
 		sb.WriteString(l.NormalStyle.Render("No tracks"))
 		return sb.String()
 	}
 
 	// Calculate visible range
-	visibleHeight := l.Height - 2
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
+	visibleHeight := l.visibleRows()
 
-	end := l.Offset + visibleHeight
-	if end > len(l.Items) {
-		end = len(l.Items)
-	}
+	// Only the visible window is rendered, so the cost is independent of
+	// the library size. Items may have shrunk since the offset was last
+	// set, so keep the window on the list.
+	start := l.windowStart()
+	end := min(start+visibleHeight, rowCount)
 
 	// Render visible items
-	for i := l.Offset; i < end; i++ {
-		track := l.Items[i]
-		var line string
+	for i := start; i < end; i++ {
+		track, num := l.RowTrack(i), i+1
+		if l.groups != nil {
+			row := l.rows[i]
+			if track == nil {
+				style := l.HeaderStyle
+				if i == l.Selected {
+					style = l.SelectedStyle
+				}
+				sb.WriteString(style.Render(l.headerText(l.groups[row.group])))
+				if i < end-1 {
+					sb.WriteString("\n")
+				}
+				continue
+			}
+			num = row.num
+		}
+		var suffix string
+		if l.RowSuffix != nil {
+			suffix = l.RowSuffix(track)
+		}
 
-		if l.ShowNumbers {
-			line = fmt.Sprintf("%3d. %s - %s", i+1, truncate(track.Artist, 20), truncate(track.Title, 30))
+		var line string
+		var marks []bool
+		if l.columns != nil {
+			// The columns make room for the suffix, and the padding
+			line, marks = l.columnsText(l.rowPrefix(num, track), track, l.Width-2-ansi.StringWidth(suffix))
+			line = fitText(line+suffix, max(l.Width-2, 0))
 		} else {
-			line = fmt.Sprintf("%s - %s", truncate(track.Artist, 20), truncate(track.Title, 35))
-		}
+			line, marks = l.rowText(num, track)
+			line += suffix
 
-		// Truncate to width
-		if len(line) > l.Width-2 {
-			line = line[:l.Width-5] + "..."
+			// Truncate to width
+			if ansi.StringWidth(line) > l.Width-2 {
+				line = truncate(line, max(l.Width-2, 3))
+				if kept := utf8.RuneCountInString(line) - 3; len(marks) > kept {
+					marks = marks[:max(kept, 0)]
+				}
+			}
 		}
 
 		if i == l.Selected {
-			sb.WriteString(l.SelectedStyle.Render(line))
+			// The selection highlight always wins so it stays readable
+			sb.WriteString(l.renderRow(l.SelectedStyle, line, marks))
+		} else if color, ok := l.rowColor(track); ok {
+			sb.WriteString(l.renderRow(l.NormalStyle.Foreground(color), line, marks))
 		} else {
-			sb.WriteString(l.NormalStyle.Render(line))
+			sb.WriteString(l.renderRow(l.NormalStyle, line, marks))
 		}
 
 		if i < end-1 {
@@ -189,18 +398,111 @@ func (l TrackList) View() string {
 	}
 
 	// Scrollbar indicator
-	if len(l.Items) > visibleHeight {
+	if rowCount > visibleHeight {
 		sb.WriteString("\n")
-		sb.WriteString(l.NormalStyle.Render(fmt.Sprintf("  [%d/%d]", l.Selected+1, len(l.Items))))
+		sb.WriteString(l.NormalStyle.Render(fmt.Sprintf("  [%d/%d]", l.Selected+1, rowCount)))
+	}
+
+	return sb.String()
+}
+
+// rowText builds the plain text of a track row numbered num and, when
+// Highlight is set, which of its runes to emphasise
+func (l TrackList) rowText(num int, track *api.Track) (string, []bool) {
+	prefix, titleLen := l.rowPrefix(num, track), 35
+	if l.ShowNumbers {
+		titleLen = 30
+	}
+	artist, title := truncate(track.Artist, 20), truncate(track.Title, titleLen)
+	line := prefix + artist + " - " + title
+	if stars := Stars(track.Rating); stars != "" {
+		line += "  " + stars
 	}
+	if l.Highlight == nil {
+		return line, nil
+	}
+
+	marks := make([]bool, utf8.RuneCountInString(line))
+	mark := func(name, field, shown string, offset int) {
+		// Matches hidden by truncation stay unmarked rather than
+		// lighting up the ellipsis
+		visible := utf8.RuneCountInString(shown)
+		if shown != field {
+			visible -= 3
+		}
+		for _, pos := range l.Highlight(name, field) {
+			if pos < visible {
+				marks[offset+pos] = true
+			}
+		}
+	}
+	artistAt := utf8.RuneCountInString(prefix)
+	mark(FieldArtist, track.Artist, artist, artistAt)
+	mark(FieldTitle, track.Title, title, artistAt+utf8.RuneCountInString(artist)+3)
+	return line, marks
+}
+
+// rowPrefix returns the mark and number shown before a row's fields
+func (l TrackList) rowPrefix(num int, track *api.Track) string {
+	var prefix string
+	if l.ShowNumbers {
+		prefix = fmt.Sprintf("%3d. ", num)
+	}
+	// Reserve the marker column only while something is marked
+	if len(l.marked) > 0 {
+		if l.marked[track.ID] {
+			prefix = "✓ " + prefix
+		} else {
+			prefix = "  " + prefix
+		}
+	}
+	return prefix
+}
 
+// renderRow renders a row in style, emphasising marked runes with
+// MatchStyle. Each run is styled separately so the row's background carries
+// through highlighted text, and the padding is kept so widths don't change.
+func (l TrackList) renderRow(style lipgloss.Style, line string, marks []bool) string {
+	if !slices.Contains(marks, true) {
+		return style.Render(line)
+	}
+
+	inner := style.UnsetPadding()
+	match := l.MatchStyle.Inherit(inner)
+	var sb strings.Builder
+	sb.WriteString(inner.Render(strings.Repeat(" ", style.GetPaddingLeft())))
+
+	runes := []rune(line)
+	marked := func(i int) bool { return i < len(marks) && marks[i] }
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && marked(end) == marked(start) {
+			end++
+		}
+		if marked(start) {
+			sb.WriteString(match.Render(string(runes[start:end])))
+		} else {
+			sb.WriteString(inner.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+
+	sb.WriteString(inner.Render(strings.Repeat(" ", style.GetPaddingRight())))
 	return sb.String()
 }
 
-// truncate truncates a string to the specified length
+func (l TrackList) rowColor(track *api.Track) (lipgloss.Color, bool) {
+	if l.RowColor == nil {
+		return "", false
+	}
+	return l.RowColor(track)
+}
+
+// truncate cuts s to maxLen cells, ending it with "..." when shortened.
+// Wide characters such as CJK count as two cells and are never split.
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if ansi.StringWidth(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return ansi.Truncate(s, maxLen, "...")
 }
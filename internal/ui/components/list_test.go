@@ -0,0 +1,585 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestTrackList_HighlightMarks(t *testing.T) {
+	l := NewTrackList(10, 80)
+	l.Highlight = func(_, text string) []int {
+		if i := strings.Index(text, "Rhap"); i >= 0 {
+			return []int{i, i + 1, i + 2, i + 3}
+		}
+		return nil
+	}
+
+	line, marks := l.rowText(1, &api.Track{Artist: "Queen", Title: "Bohemian Rhapsody"})
+	var got strings.Builder
+	for i, r := range []rune(line) {
+		if marks[i] {
+			got.WriteRune(r)
+		}
+	}
+	if got.String() != "Rhap" {
+		t.Errorf("marked %q in %q, want \"Rhap\"", got.String(), line)
+	}
+}
+
+func TestTrackList_HighlightHiddenByTruncation(t *testing.T) {
+	l := NewTrackList(10, 80)
+	l.Highlight = func(_, text string) []int { return []int{len([]rune(text)) - 1} }
+
+	title := strings.Repeat("a", 40) + "z"
+	_, marks := l.rowText(1, &api.Track{Artist: "X", Title: title})
+	for i, m := range marks {
+		if m && i != 5 { // The artist's only rune, after "  1. "
+			t.Errorf("rune %d marked; the title match is truncated away", i)
+		}
+	}
+}
+
+func TestTrackList_HighlightKeepsWidth(t *testing.T) {
+	l := NewTrackList(10, 60)
+	l.Items = []*api.Track{
+		{Artist: "Queen", Title: "Bohemian Rhapsody"},
+		{Artist: "Blur", Title: "Song 2"},
+	}
+	plain := l.View()
+
+	l.Highlight = func(_, _ string) []int { return []int{0, 2} }
+	highlighted := l.View()
+
+	plainLines, lines := strings.Split(plain, "\n"), strings.Split(highlighted, "\n")
+	if len(plainLines) != len(lines) {
+		t.Fatalf("line count changed: %d -> %d", len(plainLines), len(lines))
+	}
+	for i := range lines {
+		if a, b := lipgloss.Width(plainLines[i]), lipgloss.Width(lines[i]); a != b {
+			t.Errorf("line %d width %d, want %d", i, b, a)
+		}
+	}
+}
+
+func markTracks() []*api.Track {
+	return []*api.Track{
+		{ID: "a", Artist: "A", Title: "One"},
+		{ID: "b", Artist: "B", Title: "Two"},
+		{ID: "c", Artist: "C", Title: "Three"},
+	}
+}
+
+func TestTrackList_ToggleMark(t *testing.T) {
+	l := NewTrackList(10, 80)
+	l.SetItems(markTracks())
+
+	if l.SelectedItems() != nil {
+		t.Fatal("SelectedItems should be nil with nothing marked")
+	}
+	l.ToggleMark()
+	if !l.IsMarked(l.Items[0]) || l.MarkedCount() != 1 {
+		t.Fatalf("selected track not marked, count %d", l.MarkedCount())
+	}
+	l.ToggleMark()
+	if l.IsMarked(l.Items[0]) || l.MarkedCount() != 0 {
+		t.Fatalf("second toggle should unmark, count %d", l.MarkedCount())
+	}
+}
+
+func TestTrackList_MarkAll(t *testing.T) {
+	l := NewTrackList(10, 80)
+	l.SetItems(markTracks())
+
+	l.MarkAll()
+	if l.MarkedCount() != 3 {
+		t.Fatalf("MarkedCount() = %d after MarkAll, want 3", l.MarkedCount())
+	}
+	l.MarkAll()
+	if l.MarkedCount() != 0 {
+		t.Fatalf("MarkedCount() = %d after second MarkAll, want 0", l.MarkedCount())
+	}
+}
+
+func TestTrackList_MarksSurviveFiltering(t *testing.T) {
+	l := NewTrackList(10, 80)
+	tracks := markTracks()
+	l.SetItems(tracks)
+
+	l.SetSelected(2)
+	l.ToggleMark()
+	l.SetSelected(0)
+	l.ToggleMark()
+
+	// Filter the first track out; it stays marked and sorts after listed ones
+	l.SetItems(tracks[1:])
+	got := l.SelectedItems()
+	if len(got) != 2 || got[0].ID != "c" || got[1].ID != "a" {
+		t.Fatalf("SelectedItems() = %v, want [c a]", trackIDs(got))
+	}
+
+	l.SetItems(tracks)
+	got = l.SelectedItems()
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Fatalf("SelectedItems() = %v, want [a c] in list order", trackIDs(got))
+	}
+
+	l.ClearMarks()
+	if l.SelectedItems() != nil {
+		t.Error("ClearMarks should unmark everything")
+	}
+}
+
+func TestTrackList_MarkerColumn(t *testing.T) {
+	l := NewTrackList(10, 80)
+	l.SetItems(markTracks())
+
+	plain, _ := l.rowText(1, l.Items[0])
+	if strings.Contains(plain, "✓") {
+		t.Errorf("marker shown with nothing marked: %q", plain)
+	}
+
+	l.ToggleMark()
+	marked, _ := l.rowText(1, l.Items[0])
+	unmarked, _ := l.rowText(2, l.Items[1])
+	if !strings.HasPrefix(marked, "✓ ") || !strings.HasPrefix(unmarked, "  ") {
+		t.Errorf("rows %q / %q, want a marker column", marked, unmarked)
+	}
+}
+
+func trackIDs(tracks []*api.Track) []string {
+	ids := make([]string, len(tracks))
+	for i, track := range tracks {
+		ids[i] = track.ID
+	}
+	return ids
+}
+
+func manyTracks(n int) []*api.Track {
+	tracks := make([]*api.Track, n)
+	for i := range tracks {
+		tracks[i] = &api.Track{ID: fmt.Sprint(i), Artist: "Artist", Title: fmt.Sprintf("Track %d", i)}
+	}
+	return tracks
+}
+
+func TestTrackList_RendersVisibleWindowOnly(t *testing.T) {
+	l := NewTrackList(12, 80)
+	l.SetItems(manyTracks(50000))
+	l.SetSelected(25000)
+
+	lines := strings.Split(l.View(), "\n")
+	// Visible rows plus the position indicator
+	if want := l.Height - 2 + 1; len(lines) != want {
+		t.Fatalf("View() rendered %d lines, want %d", len(lines), want)
+	}
+	if !strings.Contains(l.View(), "Track 25000") {
+		t.Error("selected row not rendered")
+	}
+}
+
+func TestTrackList_SetItemsShrinkResetsScroll(t *testing.T) {
+	l := NewTrackList(12, 80)
+	l.SetItems(manyTracks(1000))
+	l.SetSelected(900)
+
+	l.SetItems(manyTracks(5))
+	if l.offset != 0 || l.Selected != 0 {
+		t.Fatalf("Offset, Selected = %d, %d after shrinking, want 0, 0", l.offset, l.Selected)
+	}
+	if !strings.Contains(l.View(), "Track 4") {
+		t.Error("shrunk list should show its rows")
+	}
+}
+
+func TestTrackList_ViewClampsStaleOffset(t *testing.T) {
+	l := NewTrackList(12, 80)
+	l.SetItems(manyTracks(1000))
+	l.SetSelected(900)
+
+	// Replacing Items directly leaves Offset past the end of the list
+	l.Items = manyTracks(5)
+	view := l.View()
+	for i := 0; i < 5; i++ {
+		if !strings.Contains(view, fmt.Sprintf("Track %d", i)) {
+			t.Errorf("row %d not rendered with a stale offset", i)
+		}
+	}
+}
+
+func BenchmarkTrackListView(b *testing.B) {
+	for _, n := range []int{100, 50000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			l := NewTrackList(40, 120)
+			l.SetItems(manyTracks(n))
+			l.SetSelected(n / 2)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = l.View()
+			}
+		})
+	}
+}
+
+func BenchmarkTrackListSetItems(b *testing.B) {
+	tracks := manyTracks(50000)
+	l := NewTrackList(40, 120)
+	for i := 0; i < b.N; i++ {
+		l.SetItems(tracks)
+	}
+}
+
+func TestTrackList_Groups(t *testing.T) {
+	tracks := markTracks()
+	l := NewTrackList(12, 80)
+	l.SetGroups([]TrackGroup{
+		{Key: "x", Title: "X", Tracks: tracks[:2]},
+		{Key: "y", Title: "Y", Tracks: tracks[2:]},
+	})
+
+	if len(l.Items) != 3 || l.RowCount() != 5 {
+		t.Fatalf("Items %d, rows %d; want 3 tracks in 5 rows", len(l.Items), l.RowCount())
+	}
+	if !l.OnGroupHeader() || l.SelectedItem() != nil {
+		t.Fatal("first row should be a header with no selected track")
+	}
+	l.MoveDown()
+	if l.SelectedItem() != tracks[0] {
+		t.Fatalf("row 1 = %v, want the first track", l.SelectedItem())
+	}
+
+	// Collapsing from a track row selects its header and hides its tracks
+	if g, ok := l.ToggleGroup(); !ok || !g.Collapsed || g.Key != "x" {
+		t.Fatalf("ToggleGroup() = %+v, %v", g, ok)
+	}
+	if l.Selected != 0 || l.RowCount() != 3 {
+		t.Fatalf("Selected %d, rows %d after collapsing; want 0, 3", l.Selected, l.RowCount())
+	}
+	if l.SelectTrack(tracks[1]) {
+		t.Error("SelectTrack should not find a track in a collapsed group")
+	}
+	if !l.SelectTrack(tracks[2]) || l.Selected != 2 {
+		t.Errorf("SelectTrack on the expanded group selected row %d, want 2", l.Selected)
+	}
+
+	view := l.View()
+	if !strings.Contains(view, "▸ X (2)") || !strings.Contains(view, "▾ Y (1)") {
+		t.Errorf("headers missing from view:\n%s", view)
+	}
+
+	l.SetItems(tracks)
+	if l.Grouped() || l.RowCount() != 3 {
+		t.Error("SetItems should return to a flat list")
+	}
+}
+
+func TestTrackList_EmptyNavigation(t *testing.T) {
+	for _, grouped := range []bool{false, true} {
+		l := NewTrackList(12, 80)
+		if grouped {
+			l.SetGroups(nil)
+		} else {
+			l.SetItems(nil)
+		}
+		for _, key := range []tea.KeyType{tea.KeyDown, tea.KeyPgDown, tea.KeyEnd, tea.KeyUp, tea.KeyPgUp, tea.KeyHome, tea.KeyPgDown} {
+			l, _ = l.Update(tea.KeyMsg{Type: key})
+			if l.Selected != 0 {
+				t.Fatalf("grouped %v: %v left the selection on row %d, want 0", grouped, key, l.Selected)
+			}
+		}
+		if l.SelectedItem() != nil || l.OnGroupHeader() {
+			t.Errorf("grouped %v: an empty list has a selection", grouped)
+		}
+		if _, ok := l.ToggleGroup(); ok {
+			t.Errorf("grouped %v: toggled a group of an empty list", grouped)
+		}
+		if !strings.Contains(l.View(), "No tracks") {
+			t.Errorf("grouped %v: view = %q", grouped, l.View())
+		}
+	}
+}
+
+func TestTrackList_CursorAndOffset(t *testing.T) {
+	l := NewTrackList(12, 80) // 10 visible rows
+	l.SetItems(manyTracks(100))
+
+	l.SetCursor(50)
+	if l.Cursor() != 50 || l.Offset() != 41 {
+		t.Fatalf("SetCursor(50): cursor %d, offset %d; want 50, 41", l.Cursor(), l.Offset())
+	}
+	l.SetCursor(500)
+	if l.Cursor() != 99 {
+		t.Errorf("SetCursor past the end = %d, want 99", l.Cursor())
+	}
+	l.SetCursor(-3)
+	if l.Cursor() != 0 || l.Offset() != 0 {
+		t.Errorf("SetCursor(-3): cursor %d, offset %d; want 0, 0", l.Cursor(), l.Offset())
+	}
+
+	// Scrolling drags the cursor into the window
+	l.SetOffset(20)
+	if l.Offset() != 20 || l.Cursor() != 20 {
+		t.Errorf("SetOffset(20): offset %d, cursor %d; want 20, 20", l.Offset(), l.Cursor())
+	}
+	l.SetOffset(1000)
+	if l.Offset() != 90 {
+		t.Errorf("SetOffset past the end = %d, want 90", l.Offset())
+	}
+	l.SetOffset(-1)
+	if l.Offset() != 0 || l.Cursor() != 9 {
+		t.Errorf("SetOffset(-1): offset %d, cursor %d; want 0, 9", l.Offset(), l.Cursor())
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	got, err := ParseColumns("title, artist:20,album:<12,duration,year:>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Column{
+		{Field: ColumnTitle},
+		{Field: ColumnArtist, Width: 20},
+		{Field: ColumnAlbum, Width: 12},
+		{Field: ColumnDuration, Align: AlignRight},
+		{Field: ColumnYear, Align: AlignRight},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ParseColumns = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"", "title,bogus", "artist:x", "artist:-3"} {
+		if _, err := ParseColumns(bad); err == nil {
+			t.Errorf("ParseColumns(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestTrackList_Columns(t *testing.T) {
+	l := NewTrackList(10, 40)
+	l.ShowNumbers = false
+	l.SetColumns([]Column{
+		{Field: ColumnTitle},
+		{Field: ColumnArtist, Width: 8},
+		{Field: ColumnDuration, Width: 5, Align: AlignRight},
+	})
+	track := &api.Track{Title: "A Very Long Song Title Indeed", Artist: "Somebody Else", Duration: 185 * time.Second}
+
+	line, _ := l.columnsText("", track, l.Width-2)
+	// 38 cells: 21 title, 2, 8 artist, 2, 5 duration
+	want := "A Very Long Song Tit…  Somebod…  03:05"
+	if line != want {
+		t.Errorf("row = %q, want %q", line, want)
+	}
+
+	short := &api.Track{Title: "Hi", Artist: "Me", Duration: 5 * time.Second}
+	if line, _ := l.columnsText("", short, l.Width-2); line != "Hi                     Me        00:05" {
+		t.Errorf("short row = %q", line)
+	}
+}
+
+func TestTrackList_ColumnsFitWidth(t *testing.T) {
+	track := &api.Track{Title: "日本語のタイトル", Artist: "Artist", Album: "Album", Duration: time.Minute}
+	for _, width := range []int{0, 3, 10, 25, 60, 200} {
+		l := NewTrackList(10, width)
+		l.SetColumns([]Column{
+			{Field: ColumnTitle},
+			{Field: ColumnArtist, Width: 15},
+			{Field: ColumnAlbum, Width: 15},
+			{Field: ColumnDuration, Width: 5, Align: AlignRight},
+		})
+		l.Items = []*api.Track{track}
+		l.RowSuffix = func(*api.Track) string { return "  [offline]" }
+		for _, line := range strings.Split(l.View(), "\n") {
+			if w := lipgloss.Width(line); w > max(width, 2) {
+				t.Errorf("width %d: row is %d cells: %q", width, w, line)
+			}
+		}
+		if width >= 60 && !strings.Contains(l.View(), "01:00  [offline]") {
+			t.Errorf("width %d: duration or suffix missing:\n%s", width, l.View())
+		}
+	}
+}
+
+func TestTruncate_Width(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"Queen", 20, "Queen"},
+		{"Bohemian Rhapsody", 10, "Bohemia..."},
+		{"宇多田ヒカル", 12, "宇多田ヒカル"},
+		{"宇多田ヒカル", 10, "宇多田..."},
+		{"宇多田ヒカル", 8, "宇多..."}, // A wide rune is never split
+		{"🎵 Song Title", 8, "🎵 So..."},
+		{"Bohemian 日本語", 12, "Bohemian ..."},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if w := lipgloss.Width(got); w > tt.width {
+			t.Errorf("truncate(%q, %d) is %d cells", tt.s, tt.width, w)
+		}
+	}
+}
+
+func TestTrackList_WideRowsFitWidth(t *testing.T) {
+	tracks := []*api.Track{
+		{Artist: "宇多田ヒカル", Title: "First Love"},
+		{Artist: "Queen", Title: "Bohemian Rhapsody"},
+		{Artist: "米津玄師", Title: "Lemon 🍋 (Live at 日本武道館, Remastered Edition)"},
+		{Artist: "Björk", Title: "Jóga"},
+	}
+	for _, width := range []int{20, 30, 45, 80} {
+		l := NewTrackList(10, width)
+		l.Items = tracks
+		l.Highlight = func(_, _ string) []int { return []int{0, 1, 2} }
+		for _, line := range strings.Split(l.View(), "\n") {
+			if w := lipgloss.Width(line); w > width {
+				t.Errorf("width %d: row is %d cells: %q", width, w, line)
+			}
+		}
+	}
+}
+
+func TestTrackList_ColumnsAlignWideText(t *testing.T) {
+	l := NewTrackList(10, 40)
+	l.ShowNumbers = false
+	l.SetColumns([]Column{{Field: ColumnArtist, Width: 10}, {Field: ColumnTitle}})
+	var starts []int
+	for _, track := range []*api.Track{
+		{Artist: "宇多田ヒカル", Title: "First Love"},
+		{Artist: "Queen", Title: "Bohemian Rhapsody"},
+		{Artist: "🎵 DJ", Title: "Mix"},
+	} {
+		line, _ := l.columnsText("", track, 38)
+		if w := lipgloss.Width(line); w != 38 {
+			t.Errorf("%s: row is %d cells, want 38: %q", track.Artist, w, line)
+		}
+		before, _, _ := strings.Cut(line, track.Title)
+		starts = append(starts, lipgloss.Width(before))
+	}
+	for i, start := range starts {
+		if start != 12 {
+			t.Errorf("row %d: title starts at cell %d, want 12", i, start)
+		}
+	}
+}
+
+func TestTrackList_ColumnsHighlight(t *testing.T) {
+	l := NewTrackList(10, 40)
+	l.ShowNumbers = false
+	l.SetColumns([]Column{{Field: ColumnArtist, Width: 6}, {Field: ColumnTitle}})
+	l.Highlight = func(field, text string) []int {
+		if field == FieldTitle {
+			return []int{0, 1}
+		}
+		return nil
+	}
+	line, marks := l.columnsText("", &api.Track{Artist: "Queen", Title: "Bohemian"}, 38)
+	var got strings.Builder
+	for i, r := range []rune(line) {
+		if marks[i] {
+			got.WriteRune(r)
+		}
+	}
+	if got.String() != "Bo" {
+		t.Errorf("marked %q in %q, want \"Bo\"", got.String(), line)
+	}
+}
+
+func TestTrackList_Mouse(t *testing.T) {
+	var tracks []*api.Track
+	for i := range 30 {
+		tracks = append(tracks, &api.Track{ID: fmt.Sprint(i), Title: fmt.Sprint("Track ", i)})
+	}
+	l := NewTrackList(12, 80) // 10 rows shown below the title
+	l.Title = "Library"
+	l.SetItems(tracks)
+
+	wheel := func(button tea.MouseButton) {
+		l, _ = l.Update(tea.MouseMsg{Button: button, Action: tea.MouseActionPress})
+	}
+	wheel(tea.MouseButtonWheelDown)
+	wheel(tea.MouseButtonWheelDown)
+	if l.Offset() != 6 || l.Selected != 0 {
+		t.Errorf("after two wheel ticks offset = %d, selected = %d; want 6 and 0", l.Offset(), l.Selected)
+	}
+	for range 10 {
+		wheel(tea.MouseButtonWheelDown)
+	}
+	if l.Offset() != 20 {
+		t.Errorf("wheeling past the end: offset = %d, want 20", l.Offset())
+	}
+	wheel(tea.MouseButtonWheelUp)
+	if l.Offset() != 17 {
+		t.Errorf("wheeling back up: offset = %d, want 17", l.Offset())
+	}
+
+	click := func(y int) tea.Cmd {
+		var cmd tea.Cmd
+		l, cmd = l.Update(tea.MouseMsg{Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+		return cmd
+	}
+	if cmd := click(0); cmd != nil || l.Selected != 0 {
+		t.Errorf("clicking the title should do nothing, selected = %d", l.Selected)
+	}
+	first := lipgloss.Height(l.TitleStyle.Render(l.Title)) // Line of the first row shown
+	if cmd := click(first + 2); cmd != nil || l.Selected != 19 {
+		t.Errorf("clicking the third row selected %d, want 19", l.Selected)
+	}
+	if l.Offset() != 17 {
+		t.Errorf("clicking a row scrolled the list to %d", l.Offset())
+	}
+	cmd := click(first + 2)
+	if cmd == nil {
+		t.Fatal("a second click on the row should activate it")
+	}
+	if _, ok := cmd().(RowActivatedMsg); !ok {
+		t.Errorf("double-click sent %T, want RowActivatedMsg", cmd())
+	}
+	if cmd := click(first + 2); cmd != nil {
+		t.Error("a third click should start over rather than activate again")
+	}
+
+	// Moving the selection brings it back into view
+	l.ScrollBy(-20)
+	l.MoveDown()
+	if l.Offset() != 11 {
+		t.Errorf("moving the selection: offset = %d, want 11 to show row 20", l.Offset())
+	}
+}
+
+func TestTrackList_ShowsRating(t *testing.T) {
+	if got := Stars(3); got != "★★★☆☆" {
+		t.Errorf("Stars(3) = %q", got)
+	}
+	if got := Stars(0); got != "" {
+		t.Errorf("Stars(0) = %q, want none for unrated", got)
+	}
+
+	l := NewTrackList(5, 60)
+	l.SetItems([]*api.Track{{ID: "1", Artist: "Queen", Title: "Bicycle", Rating: 4}, {ID: "2", Artist: "Blur", Title: "Tender"}})
+	for _, row := range strings.Split(ansi.Strip(l.View()), "\n") {
+		rated := strings.Contains(row, "Bicycle")
+		if rated != strings.Contains(row, "★★★★☆") || (!rated && strings.Contains(row, "★")) {
+			t.Errorf("row %q, want stars on the rated track only", row)
+		}
+	}
+
+	columns, err := ParseColumns("title,rating:5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetColumns(columns)
+	if view := ansi.Strip(l.View()); strings.Count(view, "★★★★☆") != 1 {
+		t.Errorf("rating column view = %q", view)
+	}
+}
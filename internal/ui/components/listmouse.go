@@ -0,0 +1,70 @@
+package components
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// wheelRows is how many rows a tick of the mouse wheel scrolls a list by
+const wheelRows = 3
+
+// doubleClickTime is the longest gap between two clicks on the same row
+// for them to count as a double-click
+const doubleClickTime = 400 * time.Millisecond
+
+// RowActivatedMsg is sent when a list row is double-clicked, to act on the
+// selected row as Enter does
+type RowActivatedMsg struct{}
+
+// updateMouse handles a mouse event whose position is relative to the
+// list's first line. The wheel scrolls without moving the selection, a
+// click selects the row under it and a second click on it activates it.
+func (l TrackList) updateMouse(msg tea.MouseMsg) (TrackList, tea.Cmd) {
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		l.ScrollBy(-wheelRows)
+	case msg.Button == tea.MouseButtonWheelDown:
+		l.ScrollBy(wheelRows)
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		row, ok := l.RowAt(msg.Y)
+		if !ok {
+			break
+		}
+		now := time.Now()
+		double := row == l.clickRow && now.Sub(l.clickAt) <= doubleClickTime
+		l.Selected, l.clickRow, l.clickAt = row, row, now
+		if double {
+			l.clickAt = time.Time{} // A third click starts over
+			return l, func() tea.Msg { return RowActivatedMsg{} }
+		}
+	}
+	return l, nil
+}
+
+// ScrollBy moves the window of rows shown by n, clamped to the list. The
+// selection stays where it is, even out of view; moving it scrolls back.
+func (l *TrackList) ScrollBy(n int) {
+	l.offset = min(max(l.windowStart()+n, 0), max(l.RowCount()-l.visibleRows(), 0))
+}
+
+// RowAt returns the row drawn y lines below the list's first line, if
+// there is one there
+func (l *TrackList) RowAt(y int) (int, bool) {
+	if l.Title != "" {
+		y -= lipgloss.Height(l.TitleStyle.Render(l.Title))
+	}
+	start := l.windowStart()
+	end := min(start+l.visibleRows(), l.RowCount())
+	if y < 0 || start+y >= end {
+		return 0, false
+	}
+	return start + y, true
+}
+
+// windowStart returns the first row shown, keeping the window on the list
+// as View does
+func (l *TrackList) windowStart() int {
+	return min(l.offset, max(l.RowCount()-l.visibleRows(), 0))
+}
@@ -2,25 +2,128 @@ package components
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// TimeMode selects how the progress bar labels the playback position
+type TimeMode int
+
+const (
+	TimeElapsed   TimeMode = iota // "01:23/04:56"
+	TimeRemaining                 // "-03:33/04:56"
+	TimePercent                   // "28%"
+)
+
+// timeModeCount is the number of time modes, used for cycling
+const timeModeCount = 3
+
+var timeModeNames = [...]string{"elapsed", "remaining", "percent"}
+
+func (m TimeMode) String() string {
+	if int(m) < len(timeModeNames) {
+		return timeModeNames[m]
+	}
+	return "unknown"
+}
+
+// Next returns the following time mode, wrapping around
+func (m TimeMode) Next() TimeMode {
+	return (m + 1) % timeModeCount
+}
+
+// ParseTimeMode parses a time mode name as written by String
+func ParseTimeMode(s string) (TimeMode, bool) {
+	for i, name := range timeModeNames {
+		if s == name {
+			return TimeMode(i), true
+		}
+	}
+	return TimeElapsed, false
+}
+
+// The bar is never drawn narrower than minBarWidth cells, nor wider than
+// maxBarWidth however wide it is asked to be, which keeps the per-render
+// work bounded
+const (
+	minBarWidth = 10
+	maxBarWidth = 2000
 )
 
 // ProgressBar represents a progress bar component
 type ProgressBar struct {
-	Width       int
-	Current     time.Duration
-	Total       time.Duration
-	BarChar     string
-	EmptyChar   string
-	ShowTime    bool
-	Style       lipgloss.Style
-	FilledStyle lipgloss.Style
-	EmptyStyle  lipgloss.Style
-	HeadStyle   lipgloss.Style
+	Width         int
+	Current       time.Duration
+	Total         time.Duration
+	BarChar       string
+	EmptyChar     string
+	ShowTime      bool
+	ShowPercent   bool // Append a percentage after the time, e.g. "01:23/04:56 28%"
+	TimeMode      TimeMode
+	SnapSeconds   bool // Round seek targets to the nearest whole second
+	Style         lipgloss.Style
+	FilledStyle   lipgloss.Style
+	EmptyStyle    lipgloss.Style
+	HeadStyle     lipgloss.Style
+	TrimStyle     lipgloss.Style // Used for the parts outside the trim region
+	BufferedStyle lipgloss.Style // Used between the head and Buffered
+
+	// Optional position loaded so far when streaming; zero, or anything
+	// not ahead of Current, shows no buffered region
+	Buffered time.Duration
+
+	// Optional playable region; a zero TrimEnd means the end of the track
+	TrimStart time.Duration
+	TrimEnd   time.Duration
+
+	// Optional bookmark positions, each drawn as a tick where it falls;
+	// the head is drawn over a tick it reaches
+	Marks     []time.Duration
+	MarkChar  string
+	MarkStyle lipgloss.Style
+
+	// Optional A-B loop. While it is active the cells from A to B are
+	// drawn in LoopStyle; with A alone set, A is ticked in it.
+	Loop      api.ABLoop
+	LoopStyle lipgloss.Style
+
+	// Optional chapters of the track, in start order. Each one after the
+	// first is ticked where it starts, and the current one's title is
+	// shown after the time.
+	Chapters     []api.Chapter
+	ChapterStyle lipgloss.Style
+
+	// Optional position in the whole file when the track is one part of
+	// it, such as a virtual track in a continuous mix. A zero GlobalTotal
+	// shows the local position only.
+	GlobalCurrent time.Duration
+	GlobalTotal   time.Duration
+	GlobalStyle   lipgloss.Style
+
+	// Drag-to-seek state: while Dragging the head and time follow
+	// DragPosition instead of Current
+	Dragging     bool
+	DragPosition time.Duration
+
+	// Hover preview: while Hovering a faint head and the time at
+	// HoverPosition are drawn where the mouse is, over the bar's cells but
+	// never over the head at Current
+	Hovering      bool
+	HoverPosition time.Duration
+	HoverStyle    lipgloss.Style
+
+	// Live marks a stream without an end, such as internet radio. Its bar
+	// shows a pulse sweeping to and fro instead of a head, one step per
+	// LiveFrameMsg while animating, and can't be seeked.
+	Live      bool
+	frame     int
+	animating bool // A LiveFrameMsg is due
+	animate   bool // Keep animating while Live
 
 	// Layout info for click-to-seek (set during View)
 	barWidth  int
@@ -29,27 +132,133 @@ type ProgressBar struct {
 
 // NewProgressBar creates a new progress bar
 func NewProgressBar(width int) ProgressBar {
-	return ProgressBar{
-		Width:       width,
-		BarChar:     "━",
-		EmptyChar:   "─",
-		ShowTime:    true,
-		Style:       lipgloss.NewStyle(),
-		FilledStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("212")),
-		EmptyStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
-		HeadStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true),
+	p := ProgressBar{
+		Width:     width,
+		BarChar:   "━",
+		EmptyChar: "─",
+		ShowTime:  true,
+		Style:     lipgloss.NewStyle(),
+		MarkChar:  "┃",
 	}
+	p.SetTheme(DefaultTheme)
+	return p
+}
+
+// SetTheme styles the bar in t's colors
+func (p *ProgressBar) SetTheme(t Theme) {
+	p.FilledStyle = lipgloss.NewStyle().Foreground(t.Accent)
+	p.EmptyStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	p.HeadStyle = lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+	p.TrimStyle = lipgloss.NewStyle().Foreground(t.Surface)
+	p.BufferedStyle = lipgloss.NewStyle().Foreground(t.Buffered)
+	p.GlobalStyle = lipgloss.NewStyle().Foreground(t.Subtle)
+	p.ChapterStyle = lipgloss.NewStyle().Foreground(t.Subtle)
+	p.MarkStyle = lipgloss.NewStyle().Foreground(t.Warning)
+	p.LoopStyle = lipgloss.NewStyle().Foreground(t.Success).Background(t.Surface)
+	p.HoverStyle = lipgloss.NewStyle().Foreground(t.Accent).Faint(true)
 }
 
+// liveFrameInterval is how often a live bar's pulse moves on a cell
+const liveFrameInterval = 120 * time.Millisecond
+
+// LiveFrameMsg moves a live bar's pulse on
+type LiveFrameMsg struct{}
+
 // Update handles messages for the progress bar
 func (p ProgressBar) Update(msg tea.Msg) (ProgressBar, tea.Cmd) {
+	if _, ok := msg.(LiveFrameMsg); ok {
+		if !p.Live || !p.animate {
+			p.animating = false
+			return p, nil
+		}
+		p.frame++
+		return p, liveFrame()
+	}
 	return p, nil
 }
 
-// SetProgress sets the current position
+// Animate starts or stops moving a live bar's pulse, as playback starts
+// and stops. It returns the command starting the frames, if they aren't
+// already running; a bar that isn't live never animates.
+func (p *ProgressBar) Animate(on bool) tea.Cmd {
+	p.animate = on
+	if !on || !p.Live || p.animating {
+		return nil
+	}
+	p.animating = true
+	return liveFrame()
+}
+
+// liveFrame schedules the next frame of a live bar
+func liveFrame() tea.Cmd {
+	return tea.Tick(liveFrameInterval, func(time.Time) tea.Msg { return LiveFrameMsg{} })
+}
+
+// SetProgress sets the current position, kept within the track. A total
+// that isn't positive is an unknown length, of which only the start bounds
+// the position.
 func (p *ProgressBar) SetProgress(current, total time.Duration) {
-	p.Current = current
-	p.Total = total
+	p.Total = max(total, 0)
+	p.Current = p.clamp(current)
+}
+
+// clamp keeps pos within [0, Total], or at least 0 with an unknown Total
+func (p *ProgressBar) clamp(pos time.Duration) time.Duration {
+	pos = max(pos, 0)
+	if p.Total > 0 {
+		pos = min(pos, p.Total)
+	}
+	return pos
+}
+
+// SeekBy moves Current by delta, clamped to the track, and returns the new
+// position to seek to. With an unknown Total only the start is clamped. A
+// live bar stays where it is.
+func (p *ProgressBar) SeekBy(delta time.Duration) time.Duration {
+	if p.Live {
+		return p.Current
+	}
+	target := p.Current + delta
+	if p.Total > 0 && target > p.Total {
+		target = p.Total
+	}
+	if target < 0 {
+		target = 0
+	}
+	target = p.snap(target)
+	p.Current = target
+	return target
+}
+
+// snap rounds a seek target to the nearest second when SnapSeconds is set,
+// never past Total
+func (p *ProgressBar) snap(target time.Duration) time.Duration {
+	if !p.SnapSeconds {
+		return target
+	}
+	rounded := target.Round(time.Second)
+	if p.Total > 0 && rounded > p.Total {
+		rounded = p.Total.Truncate(time.Second)
+	}
+	return rounded
+}
+
+// SetTrim sets the playable region shown on the bar. Zero values clear it.
+func (p *ProgressBar) SetTrim(start, end time.Duration) {
+	p.TrimStart = start
+	p.TrimEnd = end
+}
+
+// SetBuffered sets how far ahead the track is loaded. Zero clears it.
+func (p *ProgressBar) SetBuffered(buffered time.Duration) {
+	p.Buffered = buffered
+}
+
+// SetGlobal sets the position within the whole file for tracks that are
+// part of a longer file. Zero values go back to a single label.
+func (p *ProgressBar) SetGlobal(current, total time.Duration) {
+	p.GlobalCurrent = current
+	p.GlobalTotal = total
 }
 
 // BarWidth returns the computed bar width (available after View is called)
@@ -57,22 +266,95 @@ func (p ProgressBar) BarWidth() int {
 	return p.barWidth
 }
 
+// ClickPercent converts a click X position, measured as for HandleClick,
+// into the fraction of the bar it falls at, from 0 to 1. It reports false
+// when the bar can't be seeked: before the first View, with an unknown
+// Total or live.
+func (p ProgressBar) ClickPercent(clickX, barOffsetX int) (float64, bool) {
+	if p.barWidth <= 0 || p.Total <= 0 || p.Live {
+		return 0, false
+	}
+	relX := min(max(clickX-barOffsetX, 0), p.barWidth)
+	return float64(relX) / float64(p.barWidth), true
+}
+
 // HandleClick converts a click X position (relative to the start of the bar)
 // into a seek position. barOffsetX is the X offset of the bar within the
-// parent container (e.g. border padding). Returns the target duration.
+// parent container (e.g. border padding). Returns the target duration,
+// snapped with SnapSeconds, or 0 for a live bar.
 func (p ProgressBar) HandleClick(clickX, barOffsetX int) time.Duration {
+	percent, ok := p.ClickPercent(clickX, barOffsetX)
+	if !ok {
+		return 0
+	}
+	return p.snap(time.Duration(float64(p.Total) * percent))
+}
+
+// HoverAt shows the hover preview for the mouse at X position clickX,
+// measured as for HandleClick, and returns the position it previews. Off
+// the bar's cells, or on a bar that can't be seeked, it clears the preview
+// and reports false.
+func (p *ProgressBar) HoverAt(clickX, barOffsetX int) (time.Duration, bool) {
 	relX := clickX - barOffsetX
-	if relX < 0 {
-		relX = 0
+	percent, ok := p.ClickPercent(clickX, barOffsetX)
+	if !ok || relX < 0 || relX >= p.barWidth {
+		p.ClearHover()
+		return 0, false
 	}
-	if p.barWidth <= 0 || p.Total <= 0 {
-		return 0
+	p.Hovering = true
+	p.HoverPosition = p.snap(time.Duration(float64(p.Total) * percent))
+	return p.HoverPosition, true
+}
+
+// ClearHover removes the hover preview, as when the mouse leaves the bar
+func (p *ProgressBar) ClearHover() {
+	p.Hovering = false
+	p.HoverPosition = 0
+}
+
+// HandleClickAbsolute converts a click X position, measured from where the
+// rendered View starts, into a seek position. Unlike HandleClick it skips
+// the margin, border and padding of Style itself.
+func (p ProgressBar) HandleClickAbsolute(clickX int) time.Duration {
+	return p.HandleClick(clickX, p.barOffset())
+}
+
+// BeginDrag starts scrubbing at clickX, measured as for HandleClickAbsolute.
+// A live bar can't be scrubbed.
+func (p *ProgressBar) BeginDrag(clickX int) {
+	if p.Live {
+		return
 	}
-	if relX > p.barWidth {
-		relX = p.barWidth
+	p.Dragging = true
+	p.DragPosition = p.HandleClickAbsolute(clickX)
+}
+
+// UpdateDrag moves the scrub position to clickX while dragging
+func (p *ProgressBar) UpdateDrag(clickX int) {
+	if p.Dragging {
+		p.DragPosition = p.HandleClickAbsolute(clickX)
 	}
-	percent := float64(relX) / float64(p.barWidth)
-	return time.Duration(float64(p.Total) * percent)
+}
+
+// EndDrag stops scrubbing and returns the position to seek to
+func (p *ProgressBar) EndDrag() time.Duration {
+	p.Dragging = false
+	return p.DragPosition
+}
+
+// position returns the position to show: the scrub position while
+// dragging, otherwise Current, kept within the track even if Current was
+// set past it directly
+func (p *ProgressBar) position() time.Duration {
+	if p.Dragging {
+		return p.clamp(p.DragPosition)
+	}
+	return p.clamp(p.Current)
+}
+
+// barOffset returns the columns Style renders before the first bar cell
+func (p ProgressBar) barOffset() int {
+	return p.Style.GetMarginLeft() + p.Style.GetBorderLeftSize() + p.Style.GetPaddingLeft()
 }
 
 // View renders the progress bar
@@ -80,54 +362,328 @@ func (p *ProgressBar) View() string {
 	var sb strings.Builder
 
 	// Calculate progress percentage
+	current := p.position()
 	var percent float64
 	if p.Total > 0 {
-		percent = float64(p.Current) / float64(p.Total)
+		percent = float64(current) / float64(p.Total)
+	}
+	percent = min(max(percent, 0), 1)
+
+	// Calculate bar segments; the label's width depends on the time mode,
+	// plus a separating space and a spare column. Without room for both,
+	// the label is dropped so the bar keeps its minimum width.
+	label := p.label()
+	p.timeWidth = 0
+	if label != "" {
+		p.timeWidth = lipgloss.Width(label) + 2
 	}
-	if percent > 1 {
-		percent = 1
+	if p.Width-p.timeWidth < minBarWidth {
+		label, p.timeWidth = "", 0
 	}
+	p.barWidth = min(max(p.Width-p.timeWidth, minBarWidth), maxBarWidth)
+
+	// Wide bar characters such as CJK or emoji take more than one cell
+	// each, so the bar is laid out in slots as wide as the widest one
+	slotWidth := max(lipgloss.Width(p.BarChar), lipgloss.Width(p.EmptyChar), lipgloss.Width(p.MarkChar), 1)
+	cells := p.barWidth / slotWidth
 
-	// Calculate bar segments
-	// Time display takes "MM:SS/MM:SS " = 12 chars + 2 spaces = 14
-	p.timeWidth = 14
-	p.barWidth = p.Width - p.timeWidth
-	if p.barWidth < 10 {
-		p.barWidth = 10
+	if p.Live {
+		sb.WriteString(p.livePulse(cells, slotWidth))
+		sb.WriteString(strings.Repeat(" ", p.barWidth-cells*slotWidth))
+		if label != "" {
+			sb.WriteString(" ")
+			sb.WriteString(label)
+		}
+		return p.Style.Render(sb.String())
 	}
 
-	headPos := int(float64(p.barWidth) * percent)
-	if headPos >= p.barWidth {
-		headPos = p.barWidth - 1
+	// A finished track fills the whole bar; otherwise the head marks the
+	// position and never sits past the last cell. Without a length, such
+	// as for a live stream, there's no position on the bar to mark.
+	headPos := int(float64(cells) * percent)
+	if p.Total <= 0 {
+		headPos = -1
+	} else if percent >= 1 {
+		headPos = cells
+	} else if headPos >= cells {
+		headPos = cells - 1
 	}
 
-	filled := headPos
-	empty := p.barWidth - headPos - 1
+	// Cells outside [trimStart, trimEnd) are dimmed
+	trimStart, trimEnd := 0, cells
+	if p.Total > 0 {
+		if p.TrimStart > 0 {
+			trimStart = int(float64(cells) * float64(p.TrimStart) / float64(p.Total))
+		}
+		if p.TrimEnd > 0 && p.TrimEnd < p.Total {
+			trimEnd = int(float64(cells) * float64(p.TrimEnd) / float64(p.Total))
+		}
+	}
 
-	// Build progress bar with seek head
-	filledBar := p.FilledStyle.Render(strings.Repeat(p.BarChar, filled))
-	head := p.HeadStyle.Render("●")
-	emptyBar := p.EmptyStyle.Render(strings.Repeat(p.EmptyChar, empty))
+	// Cells after the head up to bufferedEnd show what's loaded ahead
+	bufferedEnd := 0
+	if p.Total > 0 && p.Buffered > current {
+		bufferedEnd = int(float64(cells) * min(float64(p.Buffered)/float64(p.Total), 1))
+	}
 
-	sb.WriteString(filledBar)
-	sb.WriteString(head)
-	sb.WriteString(emptyBar)
+	// Cells in the A-B loop, or the one where A alone was set
+	loopStart, loopEnd, loopTick := 0, 0, -1
+	if p.Total > 0 && p.Loop.Active() {
+		loopStart = min(int(float64(cells)*float64(p.Loop.A)/float64(p.Total)), cells-1)
+		loopEnd = max(min(int(float64(cells)*float64(p.Loop.B)/float64(p.Total)), cells), loopStart+1)
+	} else if p.Total > 0 && p.Loop.HasA && !p.Loop.HasB && p.Loop.A <= p.Total {
+		loopTick = min(int(float64(cells)*float64(p.Loop.A)/float64(p.Total)), cells-1)
+	}
+
+	// Cells holding a bookmark tick
+	var marked map[int]bool
+	if p.Total > 0 && p.MarkChar != "" {
+		for _, mark := range p.Marks {
+			if mark < 0 || mark > p.Total {
+				continue
+			}
+			if marked == nil {
+				marked = make(map[int]bool, len(p.Marks))
+			}
+			marked[min(int(float64(cells)*float64(mark)/float64(p.Total)), cells-1)] = true
+		}
+	}
+
+	// Cells where a chapter starts
+	var chapterAt map[int]bool
+	if p.Total > 0 {
+		for _, ch := range p.Chapters {
+			if ch.Start <= 0 || ch.Start >= p.Total {
+				continue
+			}
+			if chapterAt == nil {
+				chapterAt = make(map[int]bool, len(p.Chapters))
+			}
+			chapterAt[min(int(float64(cells)*float64(ch.Start)/float64(p.Total)), cells-1)] = true
+		}
+	}
+
+	// The hover preview's head, and its time beside it on whichever side
+	// has room without covering the real head
+	hoverPos, hoverLabel, hoverLabelAt := -1, []rune(nil), -1
+	if p.Hovering && !p.Dragging && p.Total > 0 && cells > 0 {
+		hoverPos = min(int(float64(cells)*float64(p.HoverPosition)/float64(p.Total)), cells-1)
+		hoverLabel = []rune(formatDuration(p.HoverPosition))
+		n := len(hoverLabel)
+		fits := func(at int) bool {
+			return at >= 0 && at+n <= cells && (headPos < at || headPos >= at+n)
+		}
+		if at := hoverPos + 1; fits(at) {
+			hoverLabelAt = at
+		} else if at := hoverPos - n; fits(at) {
+			hoverLabelAt = at
+		}
+	}
+
+	// Build progress bar with seek head, rendering runs of equally styled cells
+	var run strings.Builder
+	var runStyle *lipgloss.Style
+	flush := func() {
+		if runStyle != nil {
+			sb.WriteString(runStyle.Render(run.String()))
+		}
+		run.Reset()
+	}
+	for i := 0; i < cells; i++ {
+		style, char := &p.EmptyStyle, p.EmptyChar
+		switch {
+		case i == headPos:
+			style, char = &p.HeadStyle, "●"
+		case marked[i]:
+			style, char = &p.MarkStyle, p.MarkChar
+		case i < headPos:
+			style, char = &p.FilledStyle, p.BarChar
+		case i < bufferedEnd:
+			style, char = &p.BufferedStyle, p.BarChar
+		}
+		if chapterAt[i] && i != headPos && !marked[i] {
+			char = chapterTick(char == p.BarChar)
+		}
+		if i != headPos && !marked[i] && (i < trimStart || i >= trimEnd) {
+			style = &p.TrimStyle
+		}
+		if i != headPos && !marked[i] && (i >= loopStart && i < loopEnd || i == loopTick) {
+			style = &p.LoopStyle
+			if i == loopTick {
+				char = p.MarkChar
+			}
+		}
+		if hoverLabelAt >= 0 && i >= hoverLabelAt && i < hoverLabelAt+len(hoverLabel) {
+			style, char = &p.HoverStyle, string(hoverLabel[i-hoverLabelAt])
+		} else if i == hoverPos && i != headPos {
+			style, char = &p.HoverStyle, "●"
+		}
+		if style != runStyle {
+			flush()
+			runStyle = style
+		}
+		run.WriteString(char)
+		run.WriteString(strings.Repeat(" ", max(slotWidth-lipgloss.Width(char), 0)))
+	}
+	flush()
+	sb.WriteString(strings.Repeat(" ", p.barWidth-cells*slotWidth))
 
 	// Add time display
-	if p.ShowTime {
+	if label != "" {
 		sb.WriteString(" ")
-		sb.WriteString(formatDuration(p.Current))
-		sb.WriteString("/")
-		sb.WriteString(formatDuration(p.Total))
+		sb.WriteString(label)
 	}
 
 	return p.Style.Render(sb.String())
 }
 
-// formatDuration formats a duration as MM:SS
+// livePulse renders a live bar of cells slots: a short run of filled cells
+// at the place the frame has swept it to, bouncing off each end
+func (p *ProgressBar) livePulse(cells, slotWidth int) string {
+	width := min(max(cells/8, 3), cells)
+	at := 0
+	if span := cells - width; span > 0 {
+		at = p.frame % (2 * span)
+		if at > span {
+			at = 2*span - at
+		}
+	}
+	slot := func(char string, n int) string {
+		return strings.Repeat(char+strings.Repeat(" ", max(slotWidth-lipgloss.Width(char), 0)), n)
+	}
+	return p.EmptyStyle.Render(slot(p.EmptyChar, at)) +
+		p.FilledStyle.Render(slot(p.BarChar, width)) +
+		p.EmptyStyle.Render(slot(p.EmptyChar, cells-at-width))
+}
+
+// label renders everything shown after the bar: the time (with the global
+// position, if any) and the percentage, each when enabled
+func (p *ProgressBar) label() string {
+	var parts []string
+	if p.ShowTime {
+		label := p.timeLabel()
+		if p.GlobalTotal > 0 {
+			label += p.GlobalStyle.Render(" · " + formatDuration(p.GlobalCurrent) + "/" + formatDuration(p.GlobalTotal))
+		}
+		parts = append(parts, label)
+	}
+	if title := p.chapterLabel(); title != "" {
+		parts = append(parts, p.ChapterStyle.Render(title))
+	}
+	// The percent time mode already shows it
+	if p.ShowPercent && p.Total > 0 && !p.Live && !(p.ShowTime && p.TimeMode == TimePercent) {
+		parts = append(parts, p.percentLabel())
+	}
+	return strings.Join(parts, " ")
+}
+
+// chapterLimit caps how many cells of a chapter title are shown
+const chapterLimit = 24
+
+// chapterRestart is how far into a chapter going back restarts it rather
+// than going to the previous one
+const chapterRestart = 3 * time.Second
+
+// chapterTick is the character marking a chapter start, heavy within the
+// filled part of the bar to match it
+func chapterTick(filled bool) string {
+	if filled {
+		return "╋"
+	}
+	return "┼"
+}
+
+// currentChapter returns the index of the chapter the position is in, -1
+// before the first or without chapters
+func (p *ProgressBar) currentChapter() int {
+	pos := p.position()
+	current := -1
+	for i, ch := range p.Chapters {
+		if ch.Start <= pos {
+			current = i
+		}
+	}
+	return current
+}
+
+// chapterLabel names the current chapter, or "Chapter n" when untitled.
+// It's padded to the longest title so the bar doesn't change width from
+// chapter to chapter.
+func (p *ProgressBar) chapterLabel() string {
+	current := p.currentChapter()
+	if current < 0 {
+		return ""
+	}
+	title := func(i int) string {
+		if t := strings.TrimSpace(p.Chapters[i].Title); t != "" {
+			return truncate(t, chapterLimit)
+		}
+		return fmt.Sprintf("Chapter %d", i+1)
+	}
+	width := 0
+	for i := range p.Chapters {
+		width = max(width, lipgloss.Width(title(i)))
+	}
+	label := title(current)
+	return label + strings.Repeat(" ", width-lipgloss.Width(label))
+}
+
+// JumpToChapter returns where the chapter delta away from the current one
+// starts: 1 for the next, -1 for the previous. More than a few seconds
+// into a chapter, -1 goes back to its start instead, as going to the
+// previous track does. ok is false without chapters or past the last one.
+func (p *ProgressBar) JumpToChapter(delta int) (target time.Duration, ok bool) {
+	if len(p.Chapters) == 0 {
+		return 0, false
+	}
+	current := p.currentChapter()
+	if delta < 0 && current >= 0 && p.position()-p.Chapters[current].Start > chapterRestart {
+		delta++
+	}
+	i := max(current+delta, 0)
+	if i >= len(p.Chapters) {
+		return 0, false
+	}
+	return p.Chapters[i].Start, true
+}
+
+// timeLabel renders the position according to the time mode. Without a
+// length, or live, only the elapsed time is shown.
+func (p *ProgressBar) timeLabel() string {
+	if p.Total <= 0 || p.Live {
+		return formatDuration(p.position())
+	}
+	switch p.TimeMode {
+	case TimeRemaining:
+		remaining := p.Total - p.position()
+		if remaining < 0 {
+			remaining = 0
+		}
+		return "-" + formatDuration(remaining) + "/" + formatDuration(p.Total)
+	case TimePercent:
+		return p.percentLabel()
+	}
+	return formatDuration(p.position()) + "/" + formatDuration(p.Total)
+}
+
+// percentLabel renders the rounded percentage played, padded to a fixed
+// width so the bar doesn't jump as it grows
+func (p *ProgressBar) percentLabel() string {
+	percent := 0
+	if p.Total > 0 {
+		percent = int(math.Round(float64(p.position()) / float64(p.Total) * 100))
+	}
+	return fmt.Sprintf("%3d%%", min(max(percent, 0), 100))
+}
+
+// formatDuration formats a duration as MM:SS, or H:MM:SS from an hour up
 func formatDuration(d time.Duration) string {
 	d = d.Round(time.Second)
-	m := d / time.Minute
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
 	s := (d % time.Minute) / time.Second
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
 	return fmt.Sprintf("%02d:%02d", m, s)
 }
@@ -0,0 +1,622 @@
+package components
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestProgressBar_TimeModes(t *testing.T) {
+	tests := []struct {
+		mode TimeMode
+		want string
+	}{
+		{TimeElapsed, "01:00/04:00"},
+		{TimeRemaining, "-03:00/04:00"},
+		{TimePercent, " 25%"},
+	}
+	for _, tt := range tests {
+		p := NewProgressBar(60)
+		p.TimeMode = tt.mode
+		p.SetProgress(time.Minute, 4*time.Minute)
+
+		view := p.View()
+		if !strings.HasSuffix(view, tt.want) {
+			t.Errorf("%v: view %q does not end with %q", tt.mode, view, tt.want)
+		}
+		if w := lipgloss.Width(view); w > p.Width {
+			t.Errorf("%v: view is %d wide, want at most %d", tt.mode, w, p.Width)
+		}
+	}
+}
+
+func TestTimeMode_Cycle(t *testing.T) {
+	mode := TimeElapsed
+	for i := 0; i < timeModeCount; i++ {
+		parsed, ok := ParseTimeMode(mode.String())
+		if !ok || parsed != mode {
+			t.Errorf("ParseTimeMode(%q) = %v, %v", mode, parsed, ok)
+		}
+		mode = mode.Next()
+	}
+	if mode != TimeElapsed {
+		t.Errorf("cycling %d times ended at %v, want elapsed", timeModeCount, mode)
+	}
+}
+
+func TestProgressBar_GlobalLabel(t *testing.T) {
+	p := NewProgressBar(60)
+	p.SetProgress(time.Minute, 4*time.Minute)
+	if view := p.View(); strings.Contains(view, "·") {
+		t.Errorf("single track view %q should not show a global position", view)
+	}
+
+	p.SetGlobal(31*time.Minute, 62*time.Minute)
+	view := p.View()
+	if !strings.Contains(view, "01:00/04:00") || !strings.Contains(view, "31:00/1:02:00") {
+		t.Errorf("view %q should show local and global positions", view)
+	}
+	if w := lipgloss.Width(view); w > p.Width {
+		t.Errorf("view is %d wide, want at most %d", w, p.Width)
+	}
+}
+
+func TestProgressBar_ShowPercent(t *testing.T) {
+	tests := []struct {
+		showTime bool
+		mode     TimeMode
+		want     string
+	}{
+		{false, TimeElapsed, "─  47%"},
+		{true, TimeElapsed, "─ 01:53/04:00  47%"},
+		{true, TimePercent, "─  47%"}, // Not shown twice
+	}
+	for _, tt := range tests {
+		p := NewProgressBar(50)
+		p.ShowTime, p.ShowPercent, p.TimeMode = tt.showTime, true, tt.mode
+		p.SetProgress(113*time.Second, 4*time.Minute) // 47.08%
+
+		view := p.View()
+		if !strings.HasSuffix(view, tt.want) {
+			t.Errorf("time %v %v: view %q does not end with %q", tt.showTime, tt.mode, view, tt.want)
+		}
+		if w := lipgloss.Width(view); w > p.Width {
+			t.Errorf("time %v %v: view is %d wide, want at most %d", tt.showTime, tt.mode, w, p.Width)
+		}
+		if w := lipgloss.Width(view); p.BarWidth()+p.timeWidth != p.Width || w != p.Width-1 {
+			t.Errorf("time %v %v: bar %d + label %d, rendered %d, for width %d",
+				tt.showTime, tt.mode, p.BarWidth(), p.timeWidth, w, p.Width)
+		}
+	}
+}
+
+func TestProgressBar_Fill(t *testing.T) {
+	tests := []struct {
+		current time.Duration
+		filled  int
+		head    bool
+	}{
+		{0, 0, true},
+		{2 * time.Minute, 20, true},
+		{4 * time.Minute, 40, false},
+		{5 * time.Minute, 40, false}, // Overshoot clamps to full
+	}
+	for _, tt := range tests {
+		p := NewProgressBar(40)
+		p.ShowTime = false
+		p.SetProgress(tt.current, 4*time.Minute)
+
+		view := p.View()
+		if w := lipgloss.Width(view); w != 40 {
+			t.Errorf("%v: view is %d wide, want 40", tt.current, w)
+		}
+		if got := strings.Count(view, p.BarChar); got != tt.filled {
+			t.Errorf("%v: %d filled cells, want %d", tt.current, got, tt.filled)
+		}
+		if got := strings.Contains(view, "●"); got != tt.head {
+			t.Errorf("%v: head shown = %v, want %v", tt.current, got, tt.head)
+		}
+		if !tt.head && strings.HasSuffix(view, p.EmptyChar) {
+			t.Errorf("%v: full bar %q ends with an empty cell", tt.current, view)
+		}
+	}
+}
+
+func TestProgressBar_OutOfRangeProgress(t *testing.T) {
+	total := 4 * time.Minute
+	tests := []struct {
+		name           string
+		current, total time.Duration
+		wantCurrent    time.Duration
+		label          string
+		filled         int
+	}{
+		{"negative", -time.Second, total, 0, "00:00/04:00", 0},
+		{"at the end", total, total, total, "04:00/04:00", 40},
+		{"past the end", total + 3*time.Second, total, total, "04:00/04:00", 40},
+		{"negative, unknown length", -time.Second, 0, 0, "00:00", 0},
+		{"negative length", time.Minute, -time.Second, time.Minute, "01:00", 0},
+	}
+	for _, tt := range tests {
+		for _, mode := range []TimeMode{TimeElapsed, TimeRemaining, TimePercent} {
+			p := NewProgressBar(60)
+			p.TimeMode = mode
+			p.ShowPercent = true
+			p.SetProgress(tt.current, tt.total)
+			if p.Current != tt.wantCurrent || p.Total < 0 {
+				t.Errorf("%s: SetProgress(%v, %v) left %v of %v", tt.name, tt.current, tt.total, p.Current, p.Total)
+			}
+			view := ansi.Strip(p.View())
+			if w := lipgloss.Width(view); w > p.Width {
+				t.Errorf("%s, %v: view is %d wide, want at most %d", tt.name, mode, w, p.Width)
+			}
+			if strings.Contains(view, ":-") || strings.Contains(view, "-0:00/") {
+				t.Errorf("%s, %v: view %q shows a negative time", tt.name, mode, view)
+			}
+			if mode == TimeElapsed && !strings.Contains(view, " "+tt.label) {
+				t.Errorf("%s: view %q does not show %q", tt.name, view, tt.label)
+			}
+		}
+
+		p := NewProgressBar(40)
+		p.ShowTime = false
+		p.SetProgress(tt.current, tt.total)
+		if got := strings.Count(p.View(), p.BarChar); got != tt.filled {
+			t.Errorf("%s: %d filled cells, want %d", tt.name, got, tt.filled)
+		}
+	}
+
+	// Current set directly below zero still shows as the start
+	p := NewProgressBar(60)
+	p.SetProgress(0, total)
+	p.Current = -time.Minute
+	if view := ansi.Strip(p.View()); !strings.HasSuffix(view, "00:00/04:00") {
+		t.Errorf("negative Current shows as %q", view)
+	}
+}
+
+func TestProgressBar_WideChars(t *testing.T) {
+	for _, chars := range [][2]string{{"━", "─"}, {"█", "░"}, {"🟩", "⬜"}, {"＝", "－"}} {
+		for _, width := range []int{15, 40, 41} {
+			p := NewProgressBar(width)
+			p.ShowTime = false
+			p.BarChar, p.EmptyChar = chars[0], chars[1]
+			p.SetProgress(time.Minute, 4*time.Minute)
+			if w := lipgloss.Width(p.View()); w != width {
+				t.Errorf("%q, width %d: view is %d wide", chars, width, w)
+			}
+		}
+	}
+}
+
+func TestProgressBar_Marks(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.Marks = []time.Duration{0, time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	p.SetProgress(time.Minute, 4*time.Minute)
+
+	bar := []rune(ansi.Strip(p.View()))
+	if len(bar) != 40 {
+		t.Fatalf("bar is %d cells, want 40", len(bar))
+	}
+	// Cells 0, 30 and 39 (the end, clamped) are ticked; the head covers
+	// the tick at cell 10 and the mark past the end is dropped
+	for i, r := range bar {
+		want := i == 0 || i == 30 || i == 39
+		if got := r == '┃'; got != want {
+			t.Errorf("cell %d is %q, tick wanted %v", i, r, want)
+		}
+	}
+	if bar[10] != '●' {
+		t.Errorf("head cell is %q", bar[10])
+	}
+}
+
+func TestProgressBar_Chapters(t *testing.T) {
+	plain := NewProgressBar(60)
+	plain.SetProgress(90*time.Second, 10*time.Minute)
+	want := plain.View()
+
+	p := NewProgressBar(60)
+	p.SetProgress(90*time.Second, 10*time.Minute)
+	p.Chapters = []api.Chapter{}
+	if got := p.View(); got != want {
+		t.Errorf("empty chapters changed the bar:\n%q\n%q", got, want)
+	}
+
+	p.Chapters = []api.Chapter{{Title: "Intro", Start: 0}, {Title: "The Long Middle", Start: 5 * time.Minute}, {Start: 8 * time.Minute}}
+	view := ansi.Strip(p.View())
+	if lipgloss.Width(view) > 60 {
+		t.Errorf("view is %d wide: %q", lipgloss.Width(view), view)
+	}
+	if !strings.Contains(view, "01:30/10:00 Intro") {
+		t.Errorf("current chapter not shown: %q", view)
+	}
+	if n := strings.Count(view, "┼"); n != 2 {
+		t.Errorf("%d chapter ticks, want 2 (none for the one at the start): %q", n, view)
+	}
+
+	// Untitled chapters are numbered; the label width doesn't change
+	p.SetProgress(9*time.Minute, 10*time.Minute)
+	later := ansi.Strip(p.View())
+	if !strings.Contains(later, "Chapter 3") || lipgloss.Width(later) != lipgloss.Width(view) {
+		t.Errorf("untitled chapter: %q (width %d, was %d)", later, lipgloss.Width(later), lipgloss.Width(view))
+	}
+}
+
+func TestProgressBar_JumpToChapter(t *testing.T) {
+	p := NewProgressBar(40)
+	if _, ok := p.JumpToChapter(1); ok {
+		t.Error("jump without chapters")
+	}
+	p.Chapters = []api.Chapter{{Start: 10 * time.Second}, {Start: time.Minute}, {Start: 2 * time.Minute}}
+
+	tests := []struct {
+		at    time.Duration
+		delta int
+		want  time.Duration
+		ok    bool
+	}{
+		{0, 1, 10 * time.Second, true},
+		{0, -1, 10 * time.Second, true},
+		{30 * time.Second, 1, time.Minute, true},
+		{90 * time.Second, -1, time.Minute, true}, // Restart the chapter
+		{61 * time.Second, -1, 10 * time.Second, true},
+		{61 * time.Second, 2, 0, false},
+		{3 * time.Minute, 1, 0, false},
+	}
+	for _, tt := range tests {
+		p.SetProgress(tt.at, 3*time.Minute)
+		got, ok := p.JumpToChapter(tt.delta)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("at %v, %+d: got %v, %v; want %v, %v", tt.at, tt.delta, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestProgressBar_Widths(t *testing.T) {
+	// "01:00/04:00" plus its two spacing columns needs 13 cells
+	tests := []struct {
+		width    int
+		bar      int
+		showTime bool
+	}{
+		{width: -3, bar: 10},
+		{width: 0, bar: 10},
+		{width: 5, bar: 10},
+		{width: 14, bar: 14},
+		{width: 15, bar: 15},
+		{width: 23, bar: 10, showTime: true},
+		{width: 200, bar: 187, showTime: true},
+		{width: 1 << 30, bar: maxBarWidth, showTime: true},
+	}
+	for _, tt := range tests {
+		p := NewProgressBar(tt.width)
+		p.SetProgress(time.Minute, 4*time.Minute)
+		view := p.View()
+
+		if p.BarWidth() != tt.bar {
+			t.Errorf("width %d: bar is %d cells, want %d", tt.width, p.BarWidth(), tt.bar)
+		}
+		if got := strings.Contains(view, "01:00/04:00"); got != tt.showTime {
+			t.Errorf("width %d: time shown = %v, want %v", tt.width, got, tt.showTime)
+		}
+		if want := max(tt.width, 10); tt.width < maxBarWidth && lipgloss.Width(view) > want {
+			t.Errorf("width %d: view is %d wide, want at most %d", tt.width, lipgloss.Width(view), want)
+		}
+	}
+
+	// A position outside the track still draws a full-width bar
+	p := NewProgressBar(30)
+	p.ShowTime = false
+	p.SetProgress(-time.Minute, 4*time.Minute)
+	if w := lipgloss.Width(p.View()); w != 30 {
+		t.Errorf("negative position: view is %d wide, want 30", w)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{4*time.Minute + 5*time.Second, "04:05"},
+		{59*time.Minute + 59*time.Second, "59:59"},
+		{59*time.Minute + 59*time.Second + 600*time.Millisecond, "1:00:00"}, // Rounds up
+		{time.Hour, "1:00:00"},
+		{80*time.Minute + 5*time.Second, "1:20:05"},
+		{12*time.Hour + 3*time.Second, "12:00:03"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestProgressBar_HourLabelWidth(t *testing.T) {
+	p := NewProgressBar(60)
+	p.SetProgress(59*time.Minute+59*time.Second, 80*time.Minute)
+	short := p.View()
+	shortBar := p.BarWidth()
+	if !strings.HasSuffix(short, "59:59/1:20:00") {
+		t.Errorf("view %q should end with 59:59/1:20:00", short)
+	}
+
+	p.SetProgress(time.Hour, 80*time.Minute)
+	long := p.View()
+	if !strings.HasSuffix(long, "1:00:00/1:20:00") {
+		t.Errorf("view %q should end with 1:00:00/1:20:00", long)
+	}
+	if p.BarWidth() != shortBar-2 {
+		t.Errorf("bar width %d after the hour, want %d", p.BarWidth(), shortBar-2)
+	}
+	for _, view := range []string{short, long} {
+		if w := lipgloss.Width(view); w != p.Width-1 {
+			t.Errorf("view %q is %d wide, want %d", view, w, p.Width-1)
+		}
+	}
+}
+
+func TestProgressBar_Buffered(t *testing.T) {
+	tests := []struct {
+		buffered time.Duration
+		bar      int // Cells drawn with BarChar: filled plus buffered
+	}{
+		{0, 10},
+		{30 * time.Second, 10}, // Behind the position
+		{2 * time.Minute, 19},
+		{10 * time.Minute, 39}, // Clamped to the end
+	}
+	for _, tt := range tests {
+		p := NewProgressBar(40)
+		p.ShowTime = false
+		p.SetProgress(time.Minute, 4*time.Minute)
+		p.SetBuffered(tt.buffered)
+
+		view := p.View()
+		if got := strings.Count(view, p.BarChar); got != tt.bar {
+			t.Errorf("buffered %v: %d bar cells, want %d", tt.buffered, got, tt.bar)
+		}
+		if !strings.Contains(view, "●") || lipgloss.Width(view) != 40 {
+			t.Errorf("buffered %v: view %q should keep the head and width", tt.buffered, view)
+		}
+	}
+}
+
+func TestProgressBar_HandleClickAbsolute(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.Style = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1).MarginLeft(2)
+	p.SetProgress(0, 40*time.Second)
+	p.View()
+
+	// Margin 2, border 1 and padding 1 put the first cell at column 4
+	tests := []struct {
+		x    int
+		want time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{14, 10 * time.Second},
+		{44, 40 * time.Second},
+		{100, 40 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.HandleClickAbsolute(tt.x); got != tt.want {
+			t.Errorf("HandleClickAbsolute(%d) = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+	if got := p.HandleClick(14, 4); got != 10*time.Second {
+		t.Errorf("HandleClick(14, 4) = %v, want 10s", got)
+	}
+}
+
+func TestProgressBar_SnapSeconds(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.SetProgress(1500*time.Millisecond, 100500*time.Millisecond)
+	p.View()
+
+	// Column 13 of 40 is 32.6625s into the track
+	if percent, ok := p.ClickPercent(13, 0); !ok || percent != 0.325 {
+		t.Errorf("ClickPercent(13, 0) = %v, %v; want 0.325", percent, ok)
+	}
+	if got := p.HandleClick(13, 0); got != 32662500*time.Microsecond {
+		t.Errorf("unsnapped click = %v, want 32.6625s", got)
+	}
+
+	p.SnapSeconds = true
+	if got := p.HandleClick(13, 0); got != 33*time.Second {
+		t.Errorf("snapped click = %v, want 33s", got)
+	}
+	// Rounding never goes past the end
+	if got := p.HandleClick(40, 0); got != 100*time.Second {
+		t.Errorf("snapped click at the end = %v, want 100s", got)
+	}
+	if got := p.SeekBy(5 * time.Second); got != 7*time.Second {
+		t.Errorf("snapped SeekBy from 1.5s = %v, want 7s", got)
+	}
+
+	p.Live = true
+	if _, ok := p.ClickPercent(13, 0); ok {
+		t.Error("a live bar has no click percent")
+	}
+}
+
+func TestProgressBar_Hover(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.SetProgress(10*time.Second, 40*time.Second)
+	plain := ansi.Strip(p.View())
+
+	if at, ok := p.HoverAt(37, 0); !ok || at != 37*time.Second {
+		t.Fatalf("HoverAt(37, 0) = %v, %v; want 37s", at, ok)
+	}
+	got := ansi.Strip(p.View())
+	want := []rune(plain)
+	copy(want[32:], []rune("00:37●"))
+	if got != string(want) {
+		t.Errorf("hover near the end should put the time before the preview head:\n%s\nwant\n%s", got, string(want))
+	}
+
+	// The time goes after the preview head when there's room, and the real
+	// head at Current stays as it was
+	p.HoverAt(2, 0)
+	got = ansi.Strip(p.View())
+	if !strings.HasPrefix(got, "━━●00:02") || []rune(got)[10] != '●' {
+		t.Errorf("hover near the start: %q", got)
+	}
+
+	if _, ok := p.HoverAt(40, 0); ok || p.Hovering {
+		t.Error("hovering past the bar's cells should clear the preview")
+	}
+	p.HoverAt(20, 0)
+	p.ClearHover()
+	if got := ansi.Strip(p.View()); got != plain {
+		t.Errorf("cleared hover still drawn: %q", got)
+	}
+}
+
+func TestProgressBar_Drag(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.SetProgress(10*time.Second, 40*time.Second)
+	p.View()
+
+	p.UpdateDrag(30) // Not dragging yet: ignored
+	if p.Dragging || p.DragPosition != 0 {
+		t.Fatalf("UpdateDrag before BeginDrag changed state: %v %v", p.Dragging, p.DragPosition)
+	}
+
+	p.BeginDrag(20)
+	p.UpdateDrag(30)
+	p.SetProgress(11*time.Second, 40*time.Second) // Playback keeps ticking
+	view := p.View()
+	if head := strings.Index(view, "●"); strings.Count(view[:head], p.BarChar) != 30 {
+		t.Errorf("head in %q should follow the drag to cell 30", view)
+	}
+
+	if got := p.EndDrag(); got != 30*time.Second || p.Dragging {
+		t.Errorf("EndDrag = %v (dragging %v), want 30s", got, p.Dragging)
+	}
+	view = p.View()
+	if head := strings.Index(view, "●"); strings.Count(view[:head], p.BarChar) != 11 {
+		t.Errorf("head in %q should return to Current after the drag", view)
+	}
+}
+
+func TestProgressBar_SeekBy(t *testing.T) {
+	p := NewProgressBar(40)
+	p.SetProgress(time.Minute, 3*time.Minute)
+
+	tests := []struct {
+		delta, want time.Duration
+	}{
+		{5 * time.Second, 65 * time.Second},
+		{-30 * time.Second, 35 * time.Second},
+		{-time.Minute, 0},
+		{10 * time.Minute, 3 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.SeekBy(tt.delta); got != tt.want || p.Current != tt.want {
+			t.Errorf("SeekBy(%v) = %v (Current %v), want %v", tt.delta, got, p.Current, tt.want)
+		}
+	}
+
+	// Without a known length only the start is clamped
+	p.SetProgress(time.Minute, 0)
+	if got := p.SeekBy(time.Hour); got != time.Hour+time.Minute {
+		t.Errorf("SeekBy with unknown Total = %v", got)
+	}
+}
+
+func TestProgressBar_UnknownTotal(t *testing.T) {
+	for _, mode := range []TimeMode{TimeElapsed, TimeRemaining, TimePercent} {
+		p := NewProgressBar(40)
+		p.TimeMode = mode
+		p.ShowPercent = true
+		p.SetProgress(83*time.Second, 0)
+
+		view := ansi.Strip(p.View())
+		if !strings.HasSuffix(view, " 01:23") {
+			t.Errorf("%v: view %q should end with the elapsed time alone", mode, view)
+		}
+		if strings.Contains(view, "●") {
+			t.Errorf("%v: view %q has a head without a length", mode, view)
+		}
+	}
+}
+
+func TestProgressBar_Live(t *testing.T) {
+	p := NewProgressBar(40)
+	p.Live = true
+	p.SetProgress(83*time.Second, 4*time.Minute)
+
+	first := ansi.Strip(p.View())
+	if !strings.HasSuffix(first, " 01:23") || strings.Contains(first, "●") {
+		t.Errorf("live view %q should show a pulse and the elapsed time alone", first)
+	}
+	if p.HandleClickAbsolute(20) != 0 {
+		t.Error("clicking a live bar should not seek")
+	}
+	if p.BeginDrag(20); p.Dragging {
+		t.Error("a live bar should not start dragging")
+	}
+	if got := p.SeekBy(time.Minute); got != 83*time.Second {
+		t.Errorf("SeekBy on a live bar = %v, want the current position", got)
+	}
+
+	if p.Animate(true) == nil {
+		t.Fatal("Animate should start the frames")
+	}
+	if p.Animate(true) != nil {
+		t.Error("Animate should not start a second run of frames")
+	}
+	p, cmd := p.Update(LiveFrameMsg{})
+	if cmd == nil {
+		t.Error("a frame should schedule the next while animating")
+	}
+	if next := ansi.Strip(p.View()); next == first {
+		t.Errorf("the pulse did not move: %q", next)
+	}
+
+	p.Animate(false)
+	if _, cmd := p.Update(LiveFrameMsg{}); cmd != nil {
+		t.Error("frames should stop once animation is off")
+	}
+}
+
+func TestProgressBar_Loop(t *testing.T) {
+	p := NewProgressBar(40)
+	p.ShowTime = false
+	p.SetProgress(time.Minute, 4*time.Minute)
+	// Show the loop's cells whatever the terminal's colors
+	p.LoopStyle = lipgloss.NewStyle().Transform(func(s string) string {
+		return strings.Repeat("~", utf8.RuneCountInString(s))
+	})
+
+	p.Loop = api.ABLoop{A: 2 * time.Minute, HasA: true}
+	bar := []rune(ansi.Strip(p.View()))
+	if bar[20] != '~' || strings.Count(string(bar), "~") != 1 {
+		t.Errorf("A alone should tick its cell, got %q", string(bar))
+	}
+
+	p.Loop = api.ABLoop{A: 30 * time.Second, B: 2 * time.Minute, HasA: true, HasB: true}
+	bar = []rune(ansi.Strip(p.View()))
+	for i, r := range bar {
+		want := i >= 5 && i < 20 && i != 10
+		if got := r == '~'; got != want {
+			t.Errorf("cell %d is %q, in loop wanted %v", i, r, want)
+		}
+	}
+	if bar[10] != '●' {
+		t.Errorf("head cell is %q, want the head drawn over the loop", bar[10])
+	}
+}
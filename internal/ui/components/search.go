@@ -1,8 +1,12 @@
 package components
 
 import (
+	"strings"
+	"unicode/utf8"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // SearchInput represents a search input component
@@ -15,28 +19,50 @@ type SearchInput struct {
 	Style       lipgloss.Style
 	FocusStyle  lipgloss.Style
 	Prompt      string
+
+	PlaceholderStyle lipgloss.Style
+	CursorStyle      lipgloss.Style
+
+	// Past queries, most recent first, recalled with up and down. recall
+	// counts how far back the shown query is, 0 while editing draft, the
+	// query in progress.
+	history []string
+	recall  int
+	draft   string
 }
 
+// searchHistorySize is how many past queries a SearchInput keeps
+const searchHistorySize = 50
+
 // NewSearchInput creates a new search input
 func NewSearchInput(width int) SearchInput {
-	return SearchInput{
+	s := SearchInput{
 		Placeholder: "Search...",
 		Width:       width,
 		Prompt:      "🔍 ",
-		Style: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("240")).
-			Padding(0, 1),
-		FocusStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("212")).
-			Padding(0, 1),
 	}
+	s.SetTheme(DefaultTheme)
+	return s
+}
+
+// SetTheme styles the input in t's colors
+func (s *SearchInput) SetTheme(t Theme) {
+	s.Style = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Muted).
+		Padding(0, 1)
+	s.FocusStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(0, 1)
+	s.PlaceholderStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	s.CursorStyle = lipgloss.NewStyle().Background(t.Accent)
 }
 
 // Focus sets focus on the input
 func (s *SearchInput) Focus() {
 	s.Focused = true
+	s.recall = 0
 }
 
 // Blur removes focus from the input
@@ -56,6 +82,59 @@ func (s *SearchInput) Clear() {
 	s.CursorPos = 0
 }
 
+// SetHistory replaces the past queries, most recent first, e.g. with
+// those saved by an earlier session
+func (s *SearchInput) SetHistory(history []string) {
+	s.history = nil
+	for i := len(history) - 1; i >= 0; i-- {
+		s.Remember(history[i])
+	}
+}
+
+// History returns the past queries, most recent first
+func (s *SearchInput) History() []string {
+	return s.history
+}
+
+// Remember adds query to the front of the history, moving it there if it
+// was already in it, and reports whether the history changed. Blank
+// queries aren't kept.
+func (s *SearchInput) Remember(query string) bool {
+	s.recall = 0
+	query = strings.TrimSpace(query)
+	if query == "" || (len(s.history) > 0 && s.history[0] == query) {
+		return false
+	}
+	history := make([]string, 0, min(len(s.history)+1, searchHistorySize))
+	history = append(history, query)
+	for _, q := range s.history {
+		if q != query && len(history) < searchHistorySize {
+			history = append(history, q)
+		}
+	}
+	s.history = history
+	return true
+}
+
+// recallHistory steps through the history, older for step 1 and newer
+// for -1. Stepping newer than the newest entry brings back the query
+// that was being typed.
+func (s *SearchInput) recallHistory(step int) {
+	i := s.recall + step
+	if i < 0 || i > len(s.history) {
+		return
+	}
+	if s.recall == 0 {
+		s.draft = s.Value
+	}
+	s.recall = i
+	if i == 0 {
+		s.SetValue(s.draft)
+	} else {
+		s.SetValue(s.history[i-1])
+	}
+}
+
 // Update handles messages for the search input
 func (s SearchInput) Update(msg tea.Msg) (SearchInput, tea.Cmd) {
 	if !s.Focused {
@@ -64,23 +143,40 @@ func (s SearchInput) Update(msg tea.Msg) (SearchInput, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyUp:
+			s.recallHistory(1)
+			return s, nil
+		case tea.KeyDown:
+			s.recallHistory(-1)
+			return s, nil
+		}
+		// Editing a recalled query makes it the one in progress
+		switch msg.Type {
+		case tea.KeyBackspace, tea.KeyDelete, tea.KeyRunes:
+			s.recall = 0
+		}
 		switch msg.Type {
 		case tea.KeyBackspace:
 			if len(s.Value) > 0 && s.CursorPos > 0 {
-				s.Value = s.Value[:s.CursorPos-1] + s.Value[s.CursorPos:]
-				s.CursorPos--
+				_, size := utf8.DecodeLastRuneInString(s.Value[:s.CursorPos])
+				s.Value = s.Value[:s.CursorPos-size] + s.Value[s.CursorPos:]
+				s.CursorPos -= size
 			}
 		case tea.KeyDelete:
 			if s.CursorPos < len(s.Value) {
-				s.Value = s.Value[:s.CursorPos] + s.Value[s.CursorPos+1:]
+				_, size := utf8.DecodeRuneInString(s.Value[s.CursorPos:])
+				s.Value = s.Value[:s.CursorPos] + s.Value[s.CursorPos+size:]
 			}
 		case tea.KeyLeft:
 			if s.CursorPos > 0 {
-				s.CursorPos--
+				_, size := utf8.DecodeLastRuneInString(s.Value[:s.CursorPos])
+				s.CursorPos -= size
 			}
 		case tea.KeyRight:
 			if s.CursorPos < len(s.Value) {
-				s.CursorPos++
+				_, size := utf8.DecodeRuneInString(s.Value[s.CursorPos:])
+				s.CursorPos += size
 			}
 		case tea.KeyHome:
 			s.CursorPos = 0
@@ -102,23 +198,24 @@ func (s SearchInput) View() string {
 	var content string
 
 	if s.Value == "" && !s.Focused {
-		content = s.Prompt + lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(s.Placeholder)
+		content = s.Prompt + s.PlaceholderStyle.Render(s.Placeholder)
 	} else {
 		// Show value with cursor
 		if s.Focused {
 			before := s.Value[:s.CursorPos]
 			after := s.Value[s.CursorPos:]
-			cursor := lipgloss.NewStyle().Background(lipgloss.Color("212")).Render(" ")
+			cursor := s.CursorStyle.Render(" ")
 			content = s.Prompt + before + cursor + after
 		} else {
 			content = s.Prompt + s.Value
 		}
 	}
 
-	// Truncate if too long
-	maxWidth := s.Width - 4
-	if len(content) > maxWidth {
-		content = content[:maxWidth]
+	// Truncate if too long, by cells so wide characters and the
+	// cursor's styling are never cut in half
+	maxWidth := max(s.Width-4, 0)
+	if ansi.StringWidth(content) > maxWidth {
+		content = ansi.Truncate(content, maxWidth, "")
 	}
 
 	if s.Focused {
@@ -0,0 +1,87 @@
+package components
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeKeys(s SearchInput, keys ...tea.KeyMsg) SearchInput {
+	for _, key := range keys {
+		s, _ = s.Update(key)
+	}
+	return s
+}
+
+var (
+	keyUp   = tea.KeyMsg{Type: tea.KeyUp}
+	keyDown = tea.KeyMsg{Type: tea.KeyDown}
+)
+
+func TestSearchInput_Remember(t *testing.T) {
+	s := NewSearchInput(40)
+	for _, q := range []string{"queen", "blur", " queen ", "", "  ", "oasis"} {
+		s.Remember(q)
+	}
+	if want := []string{"oasis", "queen", "blur"}; !slices.Equal(s.History(), want) {
+		t.Errorf("History() = %q, want %q", s.History(), want)
+	}
+	if s.Remember("oasis") {
+		t.Error("repeating the latest query changed the history")
+	}
+
+	for i := range searchHistorySize + 10 {
+		s.Remember(string(rune('a' + i)))
+	}
+	if n := len(s.History()); n != searchHistorySize {
+		t.Errorf("history holds %d queries, want %d", n, searchHistorySize)
+	}
+}
+
+func TestSearchInput_Recall(t *testing.T) {
+	s := NewSearchInput(40)
+	s.SetHistory([]string{"newest", "middle", "oldest"})
+	s.Focus()
+	s = typeKeys(s, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("dra")})
+
+	tests := []struct {
+		key  tea.KeyMsg
+		want string
+	}{
+		{keyUp, "newest"},
+		{keyUp, "middle"},
+		{keyUp, "oldest"},
+		{keyUp, "oldest"}, // Nothing older
+		{keyDown, "middle"},
+		{keyDown, "newest"},
+		{keyDown, "dra"}, // Back to the query in progress
+		{keyDown, "dra"},
+	}
+	for i, tt := range tests {
+		s = typeKeys(s, tt.key)
+		if s.Value != tt.want || s.CursorPos != len(tt.want) {
+			t.Fatalf("step %d: value %q (cursor %d), want %q", i, s.Value, s.CursorPos, tt.want)
+		}
+	}
+
+	// Editing a recalled query makes it the one in progress
+	s = typeKeys(s, keyUp, keyUp, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")}, keyUp, keyDown)
+	if s.Value != "middle!" {
+		t.Errorf("value %q after editing a recalled query, want \"middle!\"", s.Value)
+	}
+}
+
+func TestSearchInput_WideRunes(t *testing.T) {
+	s := NewSearchInput(40)
+	s.Focus()
+	s = typeKeys(s, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("宇多田")},
+		tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyBackspace})
+	if s.Value != "宇田" || s.CursorPos != len("宇") {
+		t.Errorf("value %q, cursor %d", s.Value, s.CursorPos)
+	}
+	s = typeKeys(s, tea.KeyMsg{Type: tea.KeyDelete})
+	if s.Value != "宇" {
+		t.Errorf("value %q after delete", s.Value)
+	}
+}
@@ -0,0 +1,188 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the palette the UI is drawn in. Components and views take their
+// colors from it by role, and restyle themselves when given another.
+type Theme struct {
+	Name string
+
+	Accent lipgloss.Color // Titles, filled bars, the current item
+	Border lipgloss.Color // Panel borders and informational highlights
+	Muted  lipgloss.Color // Help text, empty bars, inactive borders
+	Subtle lipgloss.Color // Secondary text such as album names
+	Text   lipgloss.Color // Body text shown on its own, such as details
+	Bright lipgloss.Color // Text on colored backgrounds and file names
+	Dark   lipgloss.Color // Text on light backgrounds
+
+	SelectedFg lipgloss.Color // The selected row
+	SelectedBg lipgloss.Color
+
+	Header    lipgloss.Color // Group headers in lists
+	Directory lipgloss.Color // Folders in the file browser
+	Buffered  lipgloss.Color // Buffered part of a streaming progress bar
+	Surface   lipgloss.Color // Trimmed-off bar parts and the active tab
+	Special   lipgloss.Color // The sleep timer
+
+	TintFLAC lipgloss.Color // Library rows of FLAC files, when tinted by format
+	TintWAV  lipgloss.Color // Library rows of WAV files, when tinted by format
+
+	Success lipgloss.Color
+	Warning lipgloss.Color
+	Error   lipgloss.Color
+}
+
+// DefaultTheme is the palette the player has always had
+var DefaultTheme = Theme{
+	Name:       "Default",
+	Accent:     "212",
+	Border:     "62",
+	Muted:      "240",
+	Subtle:     "244",
+	Text:       "252",
+	Bright:     "255",
+	Dark:       "0",
+	SelectedFg: "230",
+	SelectedBg: "62",
+	Header:     "110",
+	Directory:  "33",
+	Buffered:   "246",
+	Surface:    "236",
+	Special:    "141",
+	TintFLAC:   "114",
+	TintWAV:    "117",
+	Success:    "86",
+	Warning:    "214",
+	Error:      "196",
+}
+
+// Themes are the palettes to choose from, in the order the theme switcher
+// cycles through them
+var Themes = []Theme{
+	DefaultTheme,
+	{
+		Name:       "Dracula",
+		Accent:     "#ff79c6",
+		Border:     "#bd93f9",
+		Muted:      "#6272a4",
+		Subtle:     "#9ea3c0",
+		Text:       "#f8f8f2",
+		Bright:     "#f8f8f2",
+		Dark:       "#282a36",
+		SelectedFg: "#f8f8f2",
+		SelectedBg: "#44475a",
+		Header:     "#8be9fd",
+		Directory:  "#8be9fd",
+		Buffered:   "#7a80a8",
+		Surface:    "#44475a",
+		Special:    "#bd93f9",
+		TintFLAC:   "#50fa7b",
+		TintWAV:    "#8be9fd",
+		Success:    "#50fa7b",
+		Warning:    "#ffb86c",
+		Error:      "#ff5555",
+	},
+	{
+		Name:       "Gruvbox",
+		Accent:     "#fabd2f",
+		Border:     "#83a598",
+		Muted:      "#928374",
+		Subtle:     "#a89984",
+		Text:       "#ebdbb2",
+		Bright:     "#fbf1c7",
+		Dark:       "#282828",
+		SelectedFg: "#fbf1c7",
+		SelectedBg: "#504945",
+		Header:     "#8ec07c",
+		Directory:  "#83a598",
+		Buffered:   "#7c6f64",
+		Surface:    "#3c3836",
+		Special:    "#d3869b",
+		TintFLAC:   "#b8bb26",
+		TintWAV:    "#83a598",
+		Success:    "#b8bb26",
+		Warning:    "#fe8019",
+		Error:      "#fb4934",
+	},
+	{
+		// Shades of grey only, for terminals or eyes that do without color
+		Name:       "Mono",
+		Accent:     "255",
+		Border:     "240",
+		Muted:      "242",
+		Subtle:     "247",
+		Text:       "252",
+		Bright:     "255",
+		Dark:       "0",
+		SelectedFg: "0",
+		SelectedBg: "255",
+		Header:     "250",
+		Directory:  "253",
+		Buffered:   "245",
+		Surface:    "238",
+		Special:    "250",
+		TintFLAC:   "253",
+		TintWAV:    "249",
+		Success:    "252",
+		Warning:    "250",
+		Error:      "255",
+	},
+	{
+		// The terminal's own bright colors, with no dim text
+		Name:       "High Contrast",
+		Accent:     "11",
+		Border:     "12",
+		Muted:      "252",
+		Subtle:     "15",
+		Text:       "15",
+		Bright:     "15",
+		Dark:       "0",
+		SelectedFg: "0",
+		SelectedBg: "11",
+		Header:     "14",
+		Directory:  "14",
+		Buffered:   "250",
+		Surface:    "240",
+		Special:    "13",
+		TintFLAC:   "10",
+		TintWAV:    "14",
+		Success:    "10",
+		Warning:    "11",
+		Error:      "9",
+	},
+}
+
+// ThemeByName finds a theme by name, ignoring case, spaces and dashes, so
+// "high-contrast" finds "High Contrast". "" and "dark", the name older
+// config files used, are the default theme.
+func ThemeByName(name string) (Theme, bool) {
+	key := themeKey(name)
+	if key == "" || key == "dark" {
+		return DefaultTheme, true
+	}
+	for _, t := range Themes {
+		if themeKey(t.Name) == key {
+			return t, true
+		}
+	}
+	return DefaultTheme, false
+}
+
+// themeKey normalizes a theme name for matching
+func themeKey(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// NextTheme returns the theme after t in Themes, wrapping around
+func NextTheme(t Theme) Theme {
+	for i, theme := range Themes {
+		if theme.Name == t.Name {
+			return Themes[(i+1)%len(Themes)]
+		}
+	}
+	return DefaultTheme
+}
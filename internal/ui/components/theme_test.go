@@ -0,0 +1,53 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestThemes_SetEveryColor(t *testing.T) {
+	for _, theme := range Themes {
+		v := reflect.ValueOf(theme)
+		for i := range v.NumField() {
+			if v.Field(i).String() == "" {
+				t.Errorf("%s: %s is not set", theme.Name, v.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestThemeByName(t *testing.T) {
+	for name, want := range map[string]string{
+		"":                "Default",
+		"dark":            "Default",
+		"dracula":         "Dracula",
+		"high-contrast":   "High Contrast",
+		" High Contrast ": "High Contrast",
+	} {
+		got, ok := ThemeByName(name)
+		if !ok || got.Name != want {
+			t.Errorf("ThemeByName(%q) = %q, %v; want %q", name, got.Name, ok, want)
+		}
+	}
+	if _, ok := ThemeByName("solarized"); ok {
+		t.Error("unknown theme found")
+	}
+
+	if got := NextTheme(Themes[len(Themes)-1]); got.Name != DefaultTheme.Name {
+		t.Errorf("NextTheme wraps to %q", got.Name)
+	}
+}
+
+func TestTrackList_SetTheme(t *testing.T) {
+	l := NewTrackList(10, 40)
+	mono, _ := ThemeByName("mono")
+	l.SetTheme(mono)
+	if got := l.SelectedStyle.GetBackground(); got != lipgloss.TerminalColor(mono.SelectedBg) {
+		t.Errorf("selected background = %v, want %v", got, mono.SelectedBg)
+	}
+	if got := l.TitleStyle.GetForeground(); got != lipgloss.TerminalColor(mono.Accent) {
+		t.Errorf("title color = %v, want %v", got, mono.Accent)
+	}
+}
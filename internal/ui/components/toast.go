@@ -0,0 +1,144 @@
+package components
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Level is the severity of a toast message
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelSuccess
+	LevelWarning
+	LevelError
+)
+
+// DefaultToastDuration is how long a toast stays up on its own
+const DefaultToastDuration = 3 * time.Second
+
+// maxQueuedToasts bounds the backlog; the oldest waiting notice is dropped
+// once it fills
+const maxQueuedToasts = 5
+
+// ToastDismissMsg dismisses the toast with the given ID when its time is up
+type ToastDismissMsg struct {
+	ID int
+}
+
+type toastItem struct {
+	id    int
+	text  string
+	level Level
+}
+
+// Toast shows transient messages one at a time. Notices that arrive while
+// one is showing wait their turn and are shown for half as long, so a burst
+// of them plays through quickly without any being lost.
+type Toast struct {
+	Duration time.Duration
+	Styles   map[Level]lipgloss.Style
+
+	queue  []toastItem // queue[0] is showing
+	nextID int
+}
+
+// NewToast creates a toast with the default duration and styles
+func NewToast() Toast {
+	t := Toast{Duration: DefaultToastDuration}
+	t.SetTheme(DefaultTheme)
+	return t
+}
+
+// SetTheme styles each level's notices in theme's colors
+func (t *Toast) SetTheme(theme Theme) {
+	base := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	t.Styles = map[Level]lipgloss.Style{
+		LevelInfo:    base.Foreground(theme.Bright).Background(theme.Border),
+		LevelSuccess: base.Foreground(theme.Dark).Background(theme.Success),
+		LevelWarning: base.Foreground(theme.Dark).Background(theme.Warning),
+		LevelError:   base.Foreground(theme.Bright).Background(theme.Error),
+	}
+}
+
+// Notify queues a message. The returned command schedules its dismissal if
+// it is shown right away; otherwise it is scheduled when its turn comes.
+func (t *Toast) Notify(text string, level Level) tea.Cmd {
+	t.nextID++
+	t.queue = append(t.queue, toastItem{id: t.nextID, text: text, level: level})
+	if len(t.queue) > maxQueuedToasts {
+		// Keep the one showing, drop the oldest waiting
+		t.queue = append(t.queue[:1], t.queue[2:]...)
+	}
+	if len(t.queue) == 1 {
+		return t.dismissCmd()
+	}
+	return nil
+}
+
+// Update handles dismissal, returning the command that times the next toast
+func (t *Toast) Update(msg tea.Msg) tea.Cmd {
+	dismiss, ok := msg.(ToastDismissMsg)
+	if !ok || len(t.queue) == 0 || t.queue[0].id != dismiss.ID {
+		return nil
+	}
+	t.queue = t.queue[1:]
+	if len(t.queue) == 0 {
+		return nil
+	}
+	return t.dismissCmd()
+}
+
+// dismissCmd times the toast now showing
+func (t *Toast) dismissCmd() tea.Cmd {
+	d := t.Duration
+	if len(t.queue) > 1 {
+		d /= 2
+	}
+	id := t.queue[0].id
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return ToastDismissMsg{ID: id}
+	})
+}
+
+// Visible reports whether a toast is showing
+func (t Toast) Visible() bool {
+	return len(t.queue) > 0
+}
+
+// Text returns the message showing, or ""
+func (t Toast) Text() string {
+	if len(t.queue) == 0 {
+		return ""
+	}
+	return t.queue[0].text
+}
+
+// View renders the toast showing, or "" if none
+func (t Toast) View() string {
+	if len(t.queue) == 0 {
+		return ""
+	}
+	item := t.queue[0]
+	return t.Styles[item.level].Render(item.text)
+}
+
+// ViewWidth renders the toast showing cut to at most width cells, so a
+// long notice keeps to its line rather than wrapping and pushing the
+// layout up. It renders "" if there's no room for any of the text.
+func (t Toast) ViewWidth(width int) string {
+	if len(t.queue) == 0 || width < 3 {
+		return ""
+	}
+	item := t.queue[0]
+	style := t.Styles[item.level]
+	text := ansi.Truncate(item.text, width-style.GetHorizontalFrameSize(), "…")
+	if text == "" {
+		return ""
+	}
+	return style.Render(text)
+}
@@ -0,0 +1,79 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestToast_ShowsInSequence(t *testing.T) {
+	toast := NewToast()
+
+	if cmd := toast.Notify("first", LevelInfo); cmd == nil {
+		t.Fatal("first notice should schedule its dismissal")
+	}
+	if cmd := toast.Notify("second", LevelSuccess); cmd != nil {
+		t.Error("a queued notice should wait for its turn")
+	}
+	if toast.Text() != "first" {
+		t.Fatalf("showing %q, want first", toast.Text())
+	}
+
+	// A stale dismissal for another toast is ignored
+	toast.Update(ToastDismissMsg{ID: 99})
+	if toast.Text() != "first" {
+		t.Fatalf("stale dismiss changed toast to %q", toast.Text())
+	}
+
+	if cmd := toast.Update(ToastDismissMsg{ID: 1}); cmd == nil {
+		t.Error("the next toast should be scheduled once shown")
+	}
+	if toast.Text() != "second" || !strings.Contains(toast.View(), "second") {
+		t.Fatalf("showing %q, want second", toast.Text())
+	}
+
+	toast.Update(ToastDismissMsg{ID: 2})
+	if toast.Visible() {
+		t.Errorf("toast still visible: %q", toast.Text())
+	}
+}
+
+func TestToast_QueueBounded(t *testing.T) {
+	toast := NewToast()
+	for i := 0; i < 10; i++ {
+		toast.Notify(fmt.Sprint(i), LevelInfo)
+	}
+	if len(toast.queue) != maxQueuedToasts {
+		t.Fatalf("queue has %d toasts, want %d", len(toast.queue), maxQueuedToasts)
+	}
+	if toast.Text() != "0" {
+		t.Errorf("showing %q, want the first notice kept", toast.Text())
+	}
+	if last := toast.queue[len(toast.queue)-1].text; last != "9" {
+		t.Errorf("newest queued = %q, want 9", last)
+	}
+}
+
+func TestToast_ViewWidth(t *testing.T) {
+	toast := NewToast()
+	if got := toast.ViewWidth(40); got != "" {
+		t.Errorf("no toast renders %q", got)
+	}
+
+	toast.Notify("Can't play A Very Long Title Indeed: file not found", LevelError)
+	if got := toast.ViewWidth(80); got != toast.View() {
+		t.Errorf("toast that fits = %q, want %q", got, toast.View())
+	}
+	got := toast.ViewWidth(20)
+	if w := lipgloss.Width(got); w > 20 || strings.Contains(got, "\n") {
+		t.Errorf("toast cut to 20 is %d cells: %q", w, got)
+	}
+	if !strings.Contains(got, "Can't play") || !strings.Contains(got, "…") {
+		t.Errorf("cut toast = %q, want its start and an ellipsis", got)
+	}
+	if got := toast.ViewWidth(2); got != "" {
+		t.Errorf("toast with no room renders %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package components
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// visualizerFrameInterval is how often the visualizer samples the audio
+// and redraws
+const visualizerFrameInterval = 80 * time.Millisecond
+
+// visualizerFall is how much of its height a bar keeps each frame when the
+// sound under it drops, so bars fall smoothly rather than flicker
+const visualizerFall = 0.7
+
+// visualizerBlocks are the eighths of a cell a bar's top is drawn with
+var visualizerBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// VisualizerFrameMsg carries the levels sampled for the visualizer's next
+// frame
+type VisualizerFrameMsg struct {
+	Levels []float64
+}
+
+// Visualizer draws a spectrum of what is playing as a row of bars, Height
+// rows tall. Each frame it asks Source for the level of each bar, from 0
+// to 1. Stopped or paused, its bars fall to nothing and the frames stop.
+// They also stop while it is hidden, so Source isn't sampled for bars
+// nobody sees.
+type Visualizer struct {
+	Width   int
+	Height  int
+	Enabled bool
+
+	// Source samples the levels of bands bars. It is called off the UI
+	// goroutine, between frames.
+	Source func(bands int) []float64
+
+	BarStyle  lipgloss.Style
+	PeakStyle lipgloss.Style
+
+	levels    []float64
+	animating bool // A VisualizerFrameMsg is due
+	animate   bool // Keep sampling; otherwise settle
+	hidden    bool // Not on screen; no frames are sampled
+}
+
+// NewVisualizer creates a visualizer, off until enabled
+func NewVisualizer(width, height int) Visualizer {
+	v := Visualizer{Width: width, Height: height}
+	v.SetTheme(DefaultTheme)
+	return v
+}
+
+// SetTheme styles the bars in t's colors
+func (v *Visualizer) SetTheme(t Theme) {
+	v.BarStyle = lipgloss.NewStyle().Foreground(t.Accent)
+	v.PeakStyle = lipgloss.NewStyle().Foreground(t.Warning)
+}
+
+// Bands is how many bars fit the width, each a cell with a gap after it
+func (v *Visualizer) Bands() int {
+	return max((v.Width+1)/2, 0)
+}
+
+// Animate starts or stops sampling, as playback starts and stops. It
+// returns the command for the next frame if none is due; a disabled
+// visualizer never animates. Stopped, the bars fall before the frames end.
+func (v *Visualizer) Animate(on bool) tea.Cmd {
+	v.animate = on
+	if !v.Enabled || v.hidden || v.animating || (!on && v.settled()) {
+		return nil
+	}
+	v.animating = true
+	return v.frame()
+}
+
+// Toggle turns the visualizer on or off, returning the command for its
+// frames if it is turned on while playing
+func (v *Visualizer) Toggle() tea.Cmd {
+	v.Enabled = !v.Enabled
+	if !v.Enabled {
+		v.levels = nil
+		return nil
+	}
+	return v.Animate(v.animate)
+}
+
+// SetHidden notes whether the visualizer is off screen, as when another
+// view is showing. Hidden, its frames stop and its bars are dropped; shown
+// again, it returns the command to pick the frames back up.
+func (v *Visualizer) SetHidden(hidden bool) tea.Cmd {
+	if hidden == v.hidden {
+		return nil
+	}
+	v.hidden = hidden
+	if hidden {
+		v.levels = nil
+		return nil
+	}
+	return v.Animate(v.animate)
+}
+
+// Update handles messages for the visualizer
+func (v Visualizer) Update(msg tea.Msg) (Visualizer, tea.Cmd) {
+	frame, ok := msg.(VisualizerFrameMsg)
+	if !ok {
+		return v, nil
+	}
+	v.animating = false
+	if !v.Enabled || v.hidden {
+		return v, nil
+	}
+	levels := frame.Levels
+	if !v.animate {
+		levels = nil // Fall to nothing whatever is left in the buffer
+	}
+	v.push(levels)
+	if !v.animate && v.settled() {
+		return v, nil
+	}
+	v.animating = true
+	return v, v.frame()
+}
+
+// push sets the bars to levels, letting any that drop fall gradually
+func (v *Visualizer) push(levels []float64) {
+	bands := v.Bands()
+	if len(v.levels) != bands {
+		v.levels = make([]float64, bands)
+	}
+	for i := range v.levels {
+		level := 0.0
+		if i < len(levels) {
+			level = min(max(levels[i], 0), 1)
+		}
+		if fallen := v.levels[i] * visualizerFall; level < fallen {
+			level = fallen
+		}
+		if level < 0.01 {
+			level = 0
+		}
+		v.levels[i] = level
+	}
+}
+
+// settled reports whether every bar has fallen to nothing
+func (v *Visualizer) settled() bool {
+	for _, level := range v.levels {
+		if level > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// frame schedules the next frame, sampling the source when it is due
+func (v *Visualizer) frame() tea.Cmd {
+	source, bands := v.Source, v.Bands()
+	return tea.Tick(visualizerFrameInterval, func(time.Time) tea.Msg {
+		var levels []float64
+		if source != nil {
+			levels = source(bands)
+		}
+		return VisualizerFrameMsg{Levels: levels}
+	})
+}
+
+// View renders the bars, Height lines of them, or nothing while disabled.
+// Bars reaching the top row are drawn in PeakStyle; settled, only a
+// baseline shows.
+func (v Visualizer) View() string {
+	if !v.Enabled || v.Height <= 0 || v.Bands() == 0 {
+		return ""
+	}
+	eighths := v.Height * 8
+	lines := make([]string, v.Height)
+	for row := range v.Height {
+		base := (v.Height - 1 - row) * 8 // Eighths below this row
+		var sb strings.Builder
+		for i := range v.Bands() {
+			level := 0.0
+			if i < len(v.levels) {
+				level = v.levels[i]
+			}
+			fill := min(max(int(level*float64(eighths)+0.5)-base, 0), 8)
+			if fill == 0 && row == v.Height-1 {
+				fill = 1 // Keep a baseline when silent
+			}
+			style := v.BarStyle
+			if row == 0 && fill > 0 {
+				style = v.PeakStyle
+			}
+			sb.WriteString(style.Render(string(visualizerBlocks[fill])))
+			if i < v.Bands()-1 {
+				sb.WriteByte(' ')
+			}
+		}
+		lines[row] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
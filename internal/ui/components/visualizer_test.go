@@ -0,0 +1,80 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestVisualizer_BarsAndSettling(t *testing.T) {
+	v := NewVisualizer(7, 2)
+	if v.Bands() != 4 {
+		t.Fatalf("bands = %d, want 4", v.Bands())
+	}
+	if v.Animate(true) != nil || v.View() != "" {
+		t.Fatal("a disabled visualizer should neither animate nor draw")
+	}
+	if v.Toggle() == nil {
+		t.Fatal("enabling while playing should start the frames")
+	}
+
+	v, cmd := v.Update(VisualizerFrameMsg{Levels: []float64{1, 0.5, 0, 0.25}})
+	if cmd == nil {
+		t.Fatal("a frame should schedule the next while playing")
+	}
+	want := "█      \n█ █ ▁ ▄"
+	if got := ansi.Strip(v.View()); got != want {
+		t.Errorf("view:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Paused, the bars fall rather than freeze, then the frames stop
+	v.Animate(false)
+	frames := 0
+	for cmd != nil {
+		v, cmd = v.Update(VisualizerFrameMsg{Levels: []float64{1, 1, 1, 1}})
+		if frames++; frames > 50 {
+			t.Fatal("the bars never settled")
+		}
+	}
+	if frames < 3 {
+		t.Errorf("settled after %d frames, want a gradual fall", frames)
+	}
+	if got := ansi.Strip(v.View()); got != "       \n▁ ▁ ▁ ▁" {
+		t.Errorf("settled view:\n%s", got)
+	}
+	if v.Animate(false) != nil {
+		t.Error("a settled, stopped visualizer should not animate")
+	}
+
+	v.Toggle()
+	if strings.TrimSpace(v.View()) != "" {
+		t.Error("a disabled visualizer should draw nothing")
+	}
+}
+
+func TestVisualizer_HiddenStopsSampling(t *testing.T) {
+	samples := 0
+	v := NewVisualizer(7, 2)
+	v.Source = func(bands int) []float64 {
+		samples++
+		return make([]float64, bands)
+	}
+	v.Toggle()
+	cmd := v.Animate(true)
+	if cmd == nil {
+		t.Fatal("no frames while playing")
+	}
+
+	v.SetHidden(true)
+	v, cmd = v.Update(cmd())
+	if cmd != nil || v.Animate(true) != nil {
+		t.Error("a hidden visualizer kept sampling")
+	}
+	sampled := samples
+	if cmd := v.SetHidden(false); cmd == nil {
+		t.Error("shown again while playing, the frames didn't resume")
+	} else if cmd(); samples != sampled+1 {
+		t.Errorf("sampled %d times on resuming, want 1", samples-sampled)
+	}
+}
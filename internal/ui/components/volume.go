@@ -0,0 +1,93 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// VolumeBar is a small volume gauge with a mute toggle. Muting keeps the
+// level so unmuting restores it.
+type VolumeBar struct {
+	Percent     int // Level, 0-100, kept while muted
+	Step        int // Amount Increase and Decrease change the level by
+	Muted       bool
+	Width       int // Gauge cells
+	BarChar     string
+	EmptyChar   string
+	ShowPercent bool
+	Style       lipgloss.Style
+	FilledStyle lipgloss.Style
+	EmptyStyle  lipgloss.Style
+	MutedStyle  lipgloss.Style
+}
+
+// NewVolumeBar creates a volume bar at percent, styled like ProgressBar
+func NewVolumeBar(percent int) VolumeBar {
+	v := VolumeBar{
+		Step:        10,
+		Width:       10,
+		BarChar:     "●",
+		EmptyChar:   "○",
+		ShowPercent: true,
+		Style:       lipgloss.NewStyle(),
+	}
+	v.SetTheme(DefaultTheme)
+	v.SetVolume(percent)
+	return v
+}
+
+// SetTheme styles the gauge in t's colors
+func (v *VolumeBar) SetTheme(t Theme) {
+	v.FilledStyle = lipgloss.NewStyle().Foreground(t.Accent)
+	v.EmptyStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	v.MutedStyle = lipgloss.NewStyle().Foreground(t.Subtle).Italic(true)
+}
+
+// SetVolume sets the level, clamped to [0, 100]. It doesn't unmute.
+func (v *VolumeBar) SetVolume(percent int) {
+	v.Percent = min(max(percent, 0), 100)
+}
+
+// Increase raises the level by Step, unmuting first
+func (v *VolumeBar) Increase() {
+	v.Muted = false
+	v.SetVolume(v.Percent + v.Step)
+}
+
+// Decrease lowers the level by Step, unmuting first
+func (v *VolumeBar) Decrease() {
+	v.Muted = false
+	v.SetVolume(v.Percent - v.Step)
+}
+
+// ToggleMute mutes, or unmutes back to the level before muting
+func (v *VolumeBar) ToggleMute() {
+	v.Muted = !v.Muted
+}
+
+// Level returns the level to play at: 0 while muted, otherwise Percent
+func (v VolumeBar) Level() int {
+	if v.Muted {
+		return 0
+	}
+	return v.Percent
+}
+
+// View renders the gauge, e.g. "●●●●●○○○○○ 50%"
+func (v VolumeBar) View() string {
+	if v.Muted {
+		gauge := strings.Repeat(v.EmptyChar, v.Width)
+		return v.Style.Render(v.EmptyStyle.Render(gauge) + v.MutedStyle.Render(fmt.Sprintf(" muted (%d%%)", v.Percent)))
+	}
+
+	// Round so every Step of 10 lights one more cell of a 10-cell gauge
+	filled := (v.Percent*v.Width + 50) / 100
+	gauge := v.FilledStyle.Render(strings.Repeat(v.BarChar, filled)) +
+		v.EmptyStyle.Render(strings.Repeat(v.EmptyChar, v.Width-filled))
+	if v.ShowPercent {
+		gauge += fmt.Sprintf(" %d%%", v.Percent)
+	}
+	return v.Style.Render(gauge)
+}
@@ -0,0 +1,52 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolumeBar_Clamp(t *testing.T) {
+	v := NewVolumeBar(95)
+	v.Increase()
+	if v.Percent != 100 {
+		t.Errorf("Increase from 95 = %d, want 100", v.Percent)
+	}
+	v.SetVolume(5)
+	v.Decrease()
+	if v.Percent != 0 {
+		t.Errorf("Decrease from 5 = %d, want 0", v.Percent)
+	}
+	v.SetVolume(150)
+	if v.Percent != 100 {
+		t.Errorf("SetVolume(150) = %d, want 100", v.Percent)
+	}
+}
+
+func TestVolumeBar_Mute(t *testing.T) {
+	v := NewVolumeBar(60)
+	v.ToggleMute()
+	if v.Level() != 0 || v.Percent != 60 {
+		t.Fatalf("muted Level() = %d, Percent = %d; want 0, 60", v.Level(), v.Percent)
+	}
+	if !strings.Contains(v.View(), "muted") {
+		t.Errorf("muted view %q should say so", v.View())
+	}
+	v.ToggleMute()
+	if v.Level() != 60 {
+		t.Errorf("unmuted Level() = %d, want 60", v.Level())
+	}
+
+	// Changing the level unmutes from the remembered level
+	v.ToggleMute()
+	v.Increase()
+	if v.Muted || v.Level() != 70 {
+		t.Errorf("Increase while muted: Muted %v, Level %d; want false, 70", v.Muted, v.Level())
+	}
+}
+
+func TestVolumeBar_View(t *testing.T) {
+	v := NewVolumeBar(50)
+	if got := v.View(); strings.Count(got, "●") != 5 || strings.Count(got, "○") != 5 || !strings.HasSuffix(got, " 50%") {
+		t.Errorf("View() at 50%% = %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// DurationScannedMsg is sent when decoding a track whose headers didn't
+// give its duration has found it
+type DurationScannedMsg struct {
+	Track    *api.Track
+	Duration time.Duration
+	Err      error
+}
+
+// queueDurations adds the tracks among tracks whose duration is unknown to
+// those being decoded in the background, starting on them if none were
+func (m *Model) queueDurations(tracks []*api.Track) tea.Cmd {
+	idle := len(m.durationQueue) == 0
+	for _, track := range tracks {
+		if library.NeedsDuration(track) {
+			m.durationQueue = append(m.durationQueue, track)
+		}
+	}
+	if !idle {
+		return nil
+	}
+	return m.scanDurationCmd()
+}
+
+// scanDurationCmd decodes the track at the head of the duration queue off
+// the UI goroutine. One file is decoded at a time, so a large library
+// fills in gradually without competing with playback.
+func (m Model) scanDurationCmd() tea.Cmd {
+	if len(m.durationQueue) == 0 {
+		return nil
+	}
+	track, lib := m.durationQueue[0], m.library
+	return func() tea.Msg {
+		d, err := lib.ScanDuration(track)
+		return DurationScannedMsg{Track: track, Duration: d, Err: err}
+	}
+}
+
+// durationScanned sets a decoded track's duration, which its rows show
+// next time they're drawn, and moves on to the next track
+func (m *Model) durationScanned(msg DurationScannedMsg) tea.Cmd {
+	if len(m.durationQueue) > 0 && m.durationQueue[0] == msg.Track {
+		m.durationQueue = m.durationQueue[1:]
+	}
+	if msg.Err != nil {
+		logger.Warn("Duration scan failed for %s: %v", msg.Track.FilePath, msg.Err)
+	} else {
+		m.library.SetDuration(msg.Track, msg.Duration)
+	}
+	return m.scanDurationCmd()
+}
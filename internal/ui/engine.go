@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio"
+)
+
+// Engine is the playback the UI drives: the core Player, plus the
+// engine's preview, A-B loop, gapless and sound settings. audio's
+// AudioEngine is the real one; audiotest's Player stands in for it in
+// tests.
+type Engine interface {
+	api.Player
+
+	FadeOutAndPause(d time.Duration) error
+	Preview(track *api.Track, offset float64, length time.Duration, volume float64) error
+	StopPreview() error
+	TogglePreview() error
+	SetNext(track *api.Track, join bool)
+
+	SetLoopA(pos time.Duration)
+	SetLoopB(pos time.Duration)
+	ClearLoop()
+
+	SetSkipSilence(enabled bool)
+	SetReplayGain(mode audio.ReplayGainMode)
+	ReplayGain() audio.ReplayGainMode
+	SetGain(filePath string, db float64)
+	Equalizer() *audio.Equalizer
+	Spectrum(bands int) []float64
+}
+
+var _ Engine = (*audio.AudioEngine)(nil)
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio/audiotest"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/playlist"
+)
+
+var _ Engine = (*audiotest.Player)(nil)
+
+// newTestModel returns a model over lib playing through a fake engine
+func newTestModel(t *testing.T, lib *library.Library, opts Options) (*Model, *audiotest.Player) {
+	t.Helper()
+	engine := audiotest.NewPlayer()
+	m := NewModel(engine, lib, playlist.NewManager(t.TempDir()), opts)
+	t.Cleanup(m.cancel)
+	return &m, engine
+}
+
+func TestTogglePlayback_ResumesRestoredSession(t *testing.T) {
+	lib := library.NewLibrary()
+	a := &api.Track{ID: "a", Title: "A", FilePath: "/music/a.mp3", Duration: 3 * time.Minute}
+	b := &api.Track{ID: "b", Title: "B", FilePath: "/music/b.mp3", Duration: 4 * time.Minute}
+	lib.AddTrack(a)
+	lib.AddTrack(b)
+
+	m, engine := newTestModel(t, lib, Options{Session: &Session{
+		View:     "queue",
+		Volume:   40,
+		Repeat:   "All",
+		Queue:    []string{"/music/gone.mp3", a.FilePath, b.FilePath},
+		Index:    2,
+		Position: 90 * time.Second,
+	}})
+	if m.activeView != ViewQueue || m.queue.Len() != 2 || m.queue.GetRepeatMode() != api.RepeatAll {
+		t.Fatalf("restored view %v, %d queued, repeat %v", m.activeView, m.queue.Len(), m.queue.GetRepeatMode())
+	}
+	if v := engine.GetState().Volume; v != 0.4 {
+		t.Errorf("engine volume = %v, want 0.4", v)
+	}
+
+	m.togglePlayback()
+	state := engine.GetState()
+	if state.CurrentTrack != b || state.Status != api.StatusPlaying || state.Position != 90*time.Second {
+		t.Errorf("Space played %v (%v) at %v, want b at 1m30s", state.CurrentTrack, state.Status, state.Position)
+	}
+	if s := m.session(); s.Index != 1 || s.Position != 90*time.Second || s.Volume != 40 {
+		t.Errorf("session now %+v", s)
+	}
+}
+
+func TestNewModel_ShowsStartingVolume(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{Volume: 0.8})
+	if got := m.playerView.Volume.Level(); got != 80 {
+		t.Errorf("volume bar at %d, want 80", got)
+	}
+}
+
+func TestTrackAdvanced_FollowsTheEngine(t *testing.T) {
+	a := &api.Track{ID: "a", FilePath: "/m/a.mp3"}
+	b := &api.Track{ID: "b", FilePath: "/m/b.mp3"}
+	c := &api.Track{ID: "c", FilePath: "/m/c.mp3"}
+	gone := &api.Track{ID: "gone", FilePath: "/m/gone.mp3"}
+	m, engine := newTestModel(t, library.NewLibrary(), Options{})
+	m.queue.Set([]*api.Track{a, b, c})
+
+	// The engine joined c, queued next before the queue was reordered
+	m.trackAdvanced(TrackAdvancedMsg{Track: c})
+	if m.queue.Current() != c || len(engine.Played) != 0 {
+		t.Errorf("queue at %v, played %v; want the queue to follow to c", m.queue.Current(), engine.Played)
+	}
+
+	// The track joined was taken out of the queue: the next one plays
+	m.queue.JumpTo(0)
+	m.trackAdvanced(TrackAdvancedMsg{Track: gone})
+	if m.queue.Current() != b || len(engine.Played) != 1 || engine.Played[0] != b {
+		t.Errorf("queue at %v, played %v; want b started", m.queue.Current(), engine.Played)
+	}
+}
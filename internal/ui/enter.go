@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// EnterAction selects what Enter does with the selected track
+type EnterAction int
+
+const (
+	// EnterReplaceQueue replaces the queue with the list the track was
+	// chosen from (the filtered library or the playlist) and plays from it
+	EnterReplaceQueue EnterAction = iota
+	// EnterPlayTrack plays just that track now, keeping the rest of the queue
+	EnterPlayTrack
+	// EnterPlayAlbum plays that track now with the rest of its album queued
+	// after it, in album order, ahead of what was already queued
+	EnterPlayAlbum
+)
+
+// ParseEnterAction parses an enter_action config value
+func ParseEnterAction(s string) (EnterAction, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "replace_queue":
+		return EnterReplaceQueue, true
+	case "play_track":
+		return EnterPlayTrack, true
+	case "play_album":
+		return EnterPlayAlbum, true
+	}
+	return EnterReplaceQueue, false
+}
+
+// selectionContext returns the selected track in the active view and the
+// list it was chosen from, in display order
+func (m *Model) selectionContext() (*api.Track, []*api.Track) {
+	switch m.activeView {
+	case ViewLibrary:
+		return m.libraryView.SelectedTrack(), m.libraryView.VisibleTracks()
+	case ViewPlaylist:
+		track := m.playlistView.SelectedTrack()
+		pl := m.playlistView.SelectedPlaylist()
+		if track == nil || pl == nil {
+			return nil, nil
+		}
+		tracks := make([]*api.Track, len(pl.Tracks))
+		for i := range pl.Tracks {
+			tracks[i] = &pl.Tracks[i]
+			if pl.Tracks[i].ID == track.ID {
+				// Play the queued copy so the queue and engine agree
+				track = tracks[i]
+			}
+		}
+		return track, tracks
+	case ViewHistory:
+		return m.historyView.SelectedTrack(), m.historyView.TrackList.Items
+	case ViewBrowse:
+		return m.browseView.SelectedTrack(), m.browseView.VisibleTracks()
+	}
+	return nil, nil
+}
+
+// playSelection starts the selected track according to the Enter action,
+// asking first before replacing a non-empty queue if configured to
+func (m *Model) playSelection(track *api.Track, context []*api.Track) tea.Cmd {
+	logger.Info("User selected track: %q by %s", track.Title, track.Artist)
+
+	switch m.enterAction {
+	case EnterPlayTrack:
+		m.playNow(track, nil)
+		return nil
+	case EnterPlayAlbum:
+		m.playNow(track, m.restOfAlbum(track))
+		return nil
+	}
+
+	replace := func(m *Model) tea.Cmd {
+		m.undoable("replacing the queue")
+		shuffled := m.queue.IsShuffled()
+		m.queue.Set(context)
+		for i, t := range context {
+			if t == track {
+				m.queue.JumpTo(i)
+				break
+			}
+		}
+		// Stay in shuffle mode; the selected track still plays first
+		if shuffled {
+			m.queue.Shuffle()
+		}
+		m.audioEngine.Play(track)
+		return nil
+	}
+	if m.confirmReplace && m.queue.Len() > 0 {
+		m.confirm = &confirmPrompt{
+			question: fmt.Sprintf("Replace the queue (%d tracks) with %d tracks?", m.queue.Len(), len(context)),
+			onYes:    replace,
+		}
+		return nil
+	}
+	return replace(m)
+}
+
+// playNow plays track straight away with then queued after it, keeping
+// the rest of the queue to follow
+func (m *Model) playNow(track *api.Track, then []*api.Track) {
+	m.queue.PlayNow(track)
+	for _, t := range then {
+		m.queue.InsertNext(t)
+	}
+	m.audioEngine.Play(track)
+	m.refreshQueue()
+}
+
+// restOfAlbum returns the library's tracks that come after track on its
+// album, in disc and track number order with unnumbered tracks last by
+// title, as the library groups them. An album is the tracks with the
+// same album name by the same artist, or in the same folder so that
+// compilations hold together.
+func (m *Model) restOfAlbum(track *api.Track) []*api.Track {
+	if track.Album == "" {
+		return nil
+	}
+	var album []*api.Track
+	for _, t := range m.library.GetTracksByAlbum(track.Album) {
+		if t.ID == track.ID || strings.EqualFold(t.Artist, track.Artist) || filepath.Dir(t.FilePath) == filepath.Dir(track.FilePath) {
+			album = append(album, t)
+		}
+	}
+	slices.SortStableFunc(album, func(a, b *api.Track) int {
+		unnumbered := func(t *api.Track) int {
+			if t.TrackNum > 0 {
+				return 0
+			}
+			return 1
+		}
+		return cmp.Or(
+			cmp.Compare(a.DiscNum, b.DiscNum),
+			cmp.Compare(unnumbered(a), unnumbered(b)),
+			cmp.Compare(a.TrackNum, b.TrackNum),
+			cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+		)
+	})
+	for i, t := range album {
+		if t.ID == track.ID {
+			return album[i+1:]
+		}
+	}
+	return nil
+}
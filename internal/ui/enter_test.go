@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestPlaySelection_PlayAlbum(t *testing.T) {
+	lib := library.NewLibrary()
+	track := func(id, album, artist string, disc, num int) *api.Track {
+		tr := &api.Track{ID: id, Title: id, Album: album, Artist: artist, DiscNum: disc, TrackNum: num, FilePath: "/music/" + artist + "/" + album + "/" + id + ".mp3"}
+		lib.AddTrack(tr)
+		return tr
+	}
+	// Added out of order, across two discs, with a same-named album by
+	// someone else that isn't part of it
+	track("d2t1", "Live", "Band", 2, 1)
+	d1t3 := track("d1t3", "Live", "Band", 1, 3)
+	d1t1 := track("d1t1", "Live", "Band", 1, 1)
+	d1t2 := track("d1t2", "Live", "Band", 1, 2)
+	track("other", "Live", "Someone Else", 1, 4)
+	queued := &api.Track{ID: "queued", Title: "queued"}
+
+	m, engine := newTestModel(t, lib, Options{EnterAction: EnterPlayAlbum})
+	m.queue.Set([]*api.Track{queued})
+
+	m.playSelection(d1t2, nil)
+	want := []string{"queued", "d1t2", "d1t3", "d2t1"}
+	if got := queuedIDs(m); !slices.Equal(got, want) {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+	if current := m.queue.Current(); current != d1t2 || engine.GetState().CurrentTrack != d1t2 {
+		t.Errorf("playing %v (queue on %v), want d1t2", engine.GetState().CurrentTrack, current)
+	}
+	if m.queue.PeekNext() != d1t3 {
+		t.Errorf("up next = %v, want d1t3", m.queue.PeekNext())
+	}
+
+	// Alt+Enter's action plays just the one, ahead of the album's rest
+	m.libraryView.SelectTrack(d1t1)
+	m.runAction(ActionPlayTrack)
+	want = []string{"queued", "d1t2", "d1t1", "d1t3", "d2t1"}
+	if got := queuedIDs(m); !slices.Equal(got, want) {
+		t.Errorf("after play_track queue = %v, want %v", got, want)
+	}
+}
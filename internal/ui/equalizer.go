@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// eqLabels are the band labels shown under the equalizer bars
+var eqLabels = func() []string {
+	labels := make([]string, audio.EQBands)
+	for i, freq := range audio.EQBandFrequencies {
+		if freq >= 1000 {
+			labels[i] = fmt.Sprintf("%gk", freq/1000)
+		} else {
+			labels[i] = fmt.Sprintf("%g", freq)
+		}
+	}
+	return labels
+}()
+
+// eqStep is how far one key press moves a band, in dB
+const eqStep = 1.0
+
+// updateEqualizer handles a key while the equalizer panel is open and
+// reports whether it was an equalizer key. Changes are heard at once and
+// passed to OnEqualizerChange when the panel closes, so they are kept for
+// the next session without saving on every key.
+func (m *Model) updateEqualizer(msg tea.KeyMsg) (bool, tea.Cmd) {
+	eq := m.audioEngine.Equalizer()
+	gains := eq.Gains()
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "E":
+		m.closeEqualizer()
+		return true, nil
+	case "left", "h":
+		m.eqBand = (m.eqBand + audio.EQBands - 1) % audio.EQBands
+		return true, nil
+	case "right", "l":
+		m.eqBand = (m.eqBand + 1) % audio.EQBands
+		return true, nil
+	case "up", "k":
+		eq.SetBand(m.eqBand, gains[m.eqBand]+eqStep)
+	case "down", "j":
+		eq.SetBand(m.eqBand, gains[m.eqBand]-eqStep)
+	case "0":
+		eq.SetBand(m.eqBand, 0)
+	case "P": // Next preset
+		next := audio.EQPresets[0]
+		for i, preset := range audio.EQPresets {
+			if preset.Name == eq.Preset() {
+				next = audio.EQPresets[(i+1)%len(audio.EQPresets)]
+			}
+		}
+		eq.ApplyPreset(next.Name)
+		cmd = m.toast.Notify("EQ preset: "+next.Name, components.LevelInfo)
+	case "b":
+		eq.SetBypass(!eq.Bypassed())
+		state := "on"
+		if eq.Bypassed() {
+			state = "bypassed"
+		}
+		cmd = m.toast.Notify("Equalizer "+state, components.LevelInfo)
+	default:
+		return false, nil
+	}
+
+	m.eqChanged = true
+	return true, cmd
+}
+
+// closeEqualizer closes the equalizer panel, passing on any changes made
+// while it was open
+func (m *Model) closeEqualizer() {
+	m.eqOpen = false
+	if !m.eqChanged {
+		return
+	}
+	m.eqChanged = false
+	if m.opts.OnEqualizerChange != nil {
+		eq := m.audioEngine.Equalizer()
+		gains := eq.Gains()
+		m.opts.OnEqualizerChange(gains[:], eq.Bypassed())
+	}
+}
+
+// equalizerView renders the equalizer panel
+func (m *Model) equalizerView() string {
+	eq := m.audioEngine.Equalizer()
+	gains := eq.Gains()
+	bars := components.NewEQBars(eqLabels, audio.MaxEQGain)
+	bars.SetTheme(m.theme)
+	bars.Gains = gains[:]
+	bars.Selected = m.eqBand
+	bars.Bypassed = eq.Bypassed()
+
+	title := "🎚 Equalizer · " + eq.Preset()
+	if bars.Bypassed {
+		title += " (bypassed)"
+	}
+	help := lipgloss.NewStyle().Foreground(m.theme.Muted).
+		Render("[←→] Band  [↑↓] Gain  [0] Reset band  [P] Preset  [b] Bypass  [Esc] Close")
+
+	var sb strings.Builder
+	sb.WriteString(m.headerStyle.Render(title))
+	sb.WriteString("\n")
+	sb.WriteString(bars.View())
+	sb.WriteString("\n\n")
+	sb.WriteString(help)
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Border).
+		Padding(1, 2).
+		Render(sb.String())
+}
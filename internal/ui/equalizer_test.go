@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestEqualizer_SavedWhenThePanelCloses(t *testing.T) {
+	var saved [][]float64
+	m, engine := newTestModel(t, library.NewLibrary(), Options{
+		OnEqualizerChange: func(gains []float64, bypass bool) { saved = append(saved, gains) },
+	})
+	m.eqOpen = true
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	m.updateEqualizer(up)
+	m.updateEqualizer(up)
+	if len(saved) != 0 {
+		t.Fatalf("saved %d times while the panel is open", len(saved))
+	}
+	if gains := engine.Equalizer().Gains(); gains[0] != 2 {
+		t.Errorf("band 0 at %v dB, want the change heard at once", gains[0])
+	}
+
+	m.updateEqualizer(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.eqOpen || len(saved) != 1 || saved[0][0] != 2 {
+		t.Errorf("after closing: open %v, saved %v", m.eqOpen, saved)
+	}
+
+	// Closing again without changes saves nothing
+	m.eqOpen = true
+	m.closeEqualizer()
+	if len(saved) != 1 {
+		t.Errorf("saved %d times, want 1", len(saved))
+	}
+}
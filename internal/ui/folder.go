@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// LibraryMovedMsg is sent when the library has been scanned afresh from
+// Dir, or, with Err set, when that failed and the library was kept as it
+// was
+type LibraryMovedMsg struct {
+	Dir        string
+	Skipped    []*playerrors.ScanError
+	Duplicates int
+	Err        error
+}
+
+// LibraryRescannedMsg is sent when the library's folders have been scanned
+// again with every file's tags reread, or, with Err set, when that failed
+// and the library was kept as it was
+type LibraryRescannedMsg struct {
+	Skipped    []*playerrors.ScanError
+	Duplicates int
+	Err        error
+}
+
+// promptLibraryFolder starts asking for a new library folder, filled in
+// with the current one
+func (m *Model) promptLibraryFolder() tea.Cmd {
+	if m.libraryView.Loading {
+		return m.toast.Notify("The library is still being scanned", components.LevelWarning)
+	}
+	var current string
+	if roots := m.library.ScanRoots(); len(roots) > 0 {
+		current = roots[0]
+	}
+	// Enter checks the folder and starts scanning it
+	m.promptPath("Library folder: ", current, func(m *Model, typed string) tea.Cmd {
+		dir, err := libraryFolder(typed)
+		if err != nil {
+			return m.toast.Notify(err.Error(), components.LevelError)
+		}
+		return m.moveLibraryCmd(dir)
+	})
+	return nil
+}
+
+// libraryFolder resolves a folder as typed, with ~ for the home folder,
+// to an absolute path, checking that it is a folder
+func libraryFolder(typed string) (string, error) {
+	if strings.TrimSpace(typed) == "" {
+		return "", playerrors.ErrNoMusicFolder
+	}
+	dir, err := resolvePath(typed)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("can't open %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a folder", dir)
+	}
+	return dir, nil
+}
+
+// moveLibraryCmd scans dir off the UI goroutine, replacing the library's
+// tracks with what it finds once the scan is done
+func (m *Model) moveLibraryCmd(dir string) tea.Cmd {
+	m.libraryView.Loading = true
+	ctx, lib, dedup := m.ctx, m.library, m.opts.Dedup
+	return tea.Batch(
+		m.toast.Notify(fmt.Sprintf("Scanning %s…", dir), components.LevelInfo),
+		func() tea.Msg {
+			skipped, err := lib.Rescan(ctx, []string{dir})
+			if err != nil {
+				return LibraryMovedMsg{Dir: dir, Skipped: skipped, Err: err}
+			}
+			dups := 0
+			for _, g := range lib.HideDuplicates(dedup) {
+				dups += len(g.Duplicates)
+			}
+			return LibraryMovedMsg{Dir: dir, Skipped: skipped, Duplicates: dups}
+		},
+	)
+}
+
+// libraryMoved shows the library scanned from its new folder, with the
+// search cleared and the list back at the top, and remembers the folder
+func (m *Model) libraryMoved(msg LibraryMovedMsg) tea.Cmd {
+	m.libraryView.Loading = false
+	for _, s := range msg.Skipped {
+		logger.Warn("Skipped during scan: %v", s)
+	}
+	if msg.Err != nil {
+		logger.Warn("Scan of %s failed, keeping the library: %v", msg.Dir, msg.Err)
+		return m.toast.Notify(fmt.Sprintf("Couldn't scan %s: %v", msg.Dir, msg.Err), components.LevelError)
+	}
+
+	tracks := m.library.GetAllTracks()
+	m.libraryView.ReplaceTracks(tracks)
+	m.refreshPlaylists()
+	m.refreshBrowse()
+	if m.watcher != nil {
+		m.watcher.SetRoots([]string{msg.Dir})
+	}
+	if m.opts.OnLibraryFolderChange != nil {
+		m.opts.OnLibraryFolderChange(msg.Dir)
+	}
+
+	text := fmt.Sprintf("Library moved to %s: %d tracks", msg.Dir, len(tracks))
+	if len(msg.Skipped) > 0 {
+		text += fmt.Sprintf(", %d skipped (see log)", len(msg.Skipped))
+	}
+	if msg.Duplicates > 0 {
+		text += fmt.Sprintf(", %d duplicates", msg.Duplicates)
+	}
+	logger.Info("%s", text)
+	return tea.Batch(m.toast.Notify(text, components.LevelSuccess), m.queueDurations(tracks))
+}
+
+// rescanLibraryCmd scans the library's folders again off the UI goroutine,
+// rereading the tags of every file rather than taking them from the
+// metadata cache, and replaces the library's tracks once it is done
+func (m *Model) rescanLibraryCmd() tea.Cmd {
+	if m.libraryView.Loading {
+		return m.toast.Notify("The library is still being scanned", components.LevelWarning)
+	}
+	m.libraryView.Loading = true
+	ctx, lib, dedup := m.ctx, m.library, m.opts.Dedup
+	return tea.Batch(
+		m.toast.Notify("Rescanning the library…", components.LevelInfo),
+		func() tea.Msg {
+			skipped, err := lib.Reread(ctx)
+			if err != nil {
+				return LibraryRescannedMsg{Skipped: skipped, Err: err}
+			}
+			dups := 0
+			for _, g := range lib.HideDuplicates(dedup) {
+				dups += len(g.Duplicates)
+			}
+			return LibraryRescannedMsg{Skipped: skipped, Duplicates: dups}
+		},
+	)
+}
+
+// libraryRescanned shows the rescanned library's tracks, keeping the
+// search and selection
+func (m *Model) libraryRescanned(msg LibraryRescannedMsg) tea.Cmd {
+	m.libraryView.Loading = false
+	for _, s := range msg.Skipped {
+		logger.Warn("Skipped during scan: %v", s)
+	}
+	if msg.Err != nil {
+		logger.Warn("Rescan failed, keeping the library: %v", msg.Err)
+		return m.toast.Notify(fmt.Sprintf("Couldn't rescan the library: %v", msg.Err), components.LevelError)
+	}
+
+	tracks := m.library.GetAllTracks()
+	m.libraryView.SetTracks(tracks)
+	m.refreshPlaylists()
+	m.refreshBrowse()
+
+	text := fmt.Sprintf("Library rescanned: %d tracks", len(tracks))
+	if len(msg.Skipped) > 0 {
+		text += fmt.Sprintf(", %d skipped (see log)", len(msg.Skipped))
+	}
+	if msg.Duplicates > 0 {
+		text += fmt.Sprintf(", %d duplicates", msg.Duplicates)
+	}
+	logger.Info("%s", text)
+	return tea.Batch(m.toast.Notify(text, components.LevelSuccess), m.queueDurations(tracks))
+}
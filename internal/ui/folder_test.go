@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestLibraryFolder_Checks(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := libraryFolder(" " + dir + " "); err != nil || got != dir {
+		t.Errorf("libraryFolder(%q) = %q, %v; want %q", dir, got, err, dir)
+	}
+	for _, bad := range []string{"", file, filepath.Join(dir, "missing")} {
+		if _, err := libraryFolder(bad); err == nil {
+			t.Errorf("libraryFolder(%q) accepted", bad)
+		}
+	}
+}
+
+func TestLibraryMoved_ReplacesTracksOrKeepsThem(t *testing.T) {
+	lib := library.NewLibrary()
+	lib.AddTrack(&api.Track{ID: "a", Title: "Alpha", FilePath: "/old/a.mp3"})
+	var saved string
+	m, _ := newTestModel(t, lib, Options{OnLibraryFolderChange: func(dir string) { saved = dir }})
+	m.libraryView.SetTracks(lib.GetAllTracks())
+	m.libraryView.SearchBar.SetValue("alpha")
+	m.libraryView.Refresh()
+
+	m.libraryMoved(LibraryMovedMsg{Dir: "/none", Err: errors.New("cancelled")})
+	if len(m.libraryView.AllTracks) != 1 || saved != "" {
+		t.Fatalf("failed move left %d tracks and saved %q", len(m.libraryView.AllTracks), saved)
+	}
+
+	dir := t.TempDir()
+	if _, err := lib.Rescan(context.Background(), []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	m.libraryMoved(LibraryMovedMsg{Dir: dir})
+	if len(m.libraryView.AllTracks) != 0 || m.libraryView.SearchBar.Value != "" {
+		t.Errorf("after the move: %d tracks, search %q; want none and no search", len(m.libraryView.AllTracks), m.libraryView.SearchBar.Value)
+	}
+	if saved != dir {
+		t.Errorf("saved folder %q, want %q", saved, dir)
+	}
+}
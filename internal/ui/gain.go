@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// gainStep is how far, in dB, one press of the gain keys moves the
+// playing track's level
+const gainStep = 1
+
+// gainSteps are how far each gain action moves the level; reset's 0
+// clears it
+var gainSteps = map[Action]float64{
+	ActionGainUp:    gainStep,
+	ActionGainDown:  -gainStep,
+	ActionGainReset: 0,
+}
+
+// nudgeGain moves the playing track's level offset db up or down, within
+// library.MaxGain, and says where it now is. With db zero it resets it.
+func (m *Model) nudgeGain(db float64) tea.Cmd {
+	track, _ := m.playingFile()
+	if track == nil {
+		return nil
+	}
+	gain := 0.0
+	if db != 0 {
+		gain = min(max(track.Gain+db, -library.MaxGain), library.MaxGain)
+	}
+	if err := m.library.SetGain(track, gain); err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	m.audioEngine.SetGain(track.FilePath, gain)
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	if gain == 0 {
+		return m.toast.Notify("Gain reset: "+track.Title, components.LevelInfo)
+	}
+	return m.toast.Notify(fmt.Sprintf("Gain %+g dB: %s", gain, track.Title), components.LevelInfo)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestNudgeGain_PlayingTrack(t *testing.T) {
+	lib := library.NewLibrary()
+	track := &api.Track{ID: "a", Title: "A", FilePath: "/music/a.mp3"}
+	lib.AddTrack(track)
+	m, engine := newTestModel(t, lib, Options{})
+
+	m.runAction(ActionGainUp)
+	if track.Gain != 0 {
+		t.Fatalf("gain moved to %v with nothing playing", track.Gain)
+	}
+	engine.Play(track)
+	m.runAction(ActionGainDown)
+	m.runAction(ActionGainDown)
+	if track.Gain != -2 {
+		t.Errorf("two steps down = %v dB, want -2", track.Gain)
+	}
+	m.playerView.SetState(m.playbackState(engine.GetState()))
+	if view := m.playerView.View(); !strings.Contains(view, "Gain -2 dB") {
+		t.Errorf("player view doesn't show the offset:\n%s", view)
+	}
+
+	for range 20 {
+		m.runAction(ActionGainUp)
+	}
+	if track.Gain != library.MaxGain {
+		t.Errorf("gain went to %v dB, want it held at %v", track.Gain, library.MaxGain)
+	}
+	m.runAction(ActionGainReset)
+	if track.Gain != 0 {
+		t.Errorf("reset left %v dB", track.Gain)
+	}
+}
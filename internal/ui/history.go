@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+// historyLimit is how many tracks the history view lists
+const historyLimit = 100
+
+// recordPlay counts a play of track once it has played long enough to
+// count as listened to, moves it up the history and saves the stats in
+// the background
+func (m *Model) recordPlay(track *api.Track) tea.Cmd {
+	if m.playStats == nil || track == nil || track.FilePath == "" {
+		return nil
+	}
+	stat := m.playStats.Record(track.FilePath, time.Now())
+	m.historyView.Played(track, views.HistoryPlays{Count: stat.Count, LastPlayed: stat.LastPlayed}, historyLimit)
+	m.refreshPlayedPlaylists()
+	return saveCmd("play stats", m.playStats.Save)
+}
+
+// refreshHistory rebuilds the history view from the play stats, listing
+// the library's tracks of the paths played, recent or most played first
+// as the view's mode says
+func (m *Model) refreshHistory() {
+	if m.playStats == nil {
+		m.historyView.SetTracks(nil, nil, time.Time{})
+		return
+	}
+	byPath := make(map[string]*api.Track)
+	for _, track := range m.library.GetAllTracks() {
+		byPath[track.FilePath] = track
+	}
+
+	paths := m.playStats.Recent(0)
+	if m.historyView.Mode == views.HistoryMostPlayed {
+		paths = m.playStats.MostPlayed(0)
+	}
+	tracks := make([]*api.Track, 0, historyLimit)
+	plays := make(map[string]views.HistoryPlays)
+	for _, path := range paths {
+		track, ok := byPath[path]
+		if !ok {
+			continue // Played, but no longer in the library
+		}
+		stat := m.playStats.Get(path)
+		tracks = append(tracks, track)
+		plays[path] = views.HistoryPlays{Count: stat.Count, LastPlayed: stat.LastPlayed}
+		if len(tracks) == historyLimit {
+			break
+		}
+	}
+	m.historyView.SetTracks(tracks, plays, time.Now())
+}
@@ -0,0 +1,457 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action names something a global key does, as it is called in the
+// config file's key bindings
+type Action string
+
+const (
+	ActionQuit            Action = "quit"
+	ActionViewPlayer      Action = "view_player"
+	ActionViewLibrary     Action = "view_library"
+	ActionViewPlaylist    Action = "view_playlist"
+	ActionViewQueue       Action = "view_queue"
+	ActionViewHistory     Action = "view_history"
+	ActionViewBrowse      Action = "view_browse"
+	ActionNextView        Action = "next_view"
+	ActionPrevView        Action = "prev_view"
+	ActionSearch          Action = "search"
+	ActionPlayPause       Action = "play_pause"
+	ActionPlaySelected    Action = "play_selected"
+	ActionPlayTrack       Action = "play_track"
+	ActionStop            Action = "stop"
+	ActionNext            Action = "next"
+	ActionPrevious        Action = "previous"
+	ActionSeekForward     Action = "seek_forward"
+	ActionSeekBack        Action = "seek_back"
+	ActionSeekForwardLong Action = "seek_forward_long"
+	ActionSeekBackLong    Action = "seek_back_long"
+	ActionVolumeUp        Action = "volume_up"
+	ActionVolumeDown      Action = "volume_down"
+	ActionMute            Action = "mute"
+	ActionRepeat          Action = "repeat"
+	ActionShuffle         Action = "shuffle"
+	ActionResume          Action = "resume"
+	ActionTimeMode        Action = "time_mode"
+	ActionEqualizer       Action = "equalizer"
+	ActionTheme           Action = "theme"
+	ActionSleepTimer      Action = "sleep_timer"
+	ActionOutputDevice    Action = "output_device"
+	ActionReplayGain      Action = "replay_gain"
+	ActionSkipSilence     Action = "skip_silence"
+	ActionTrimStart       Action = "trim_start"
+	ActionTrimEnd         Action = "trim_end"
+	ActionTrimClear       Action = "trim_clear"
+	ActionBookmark        Action = "bookmark"
+	ActionRemoveBookmark  Action = "remove_bookmark"
+	ActionPrevBookmark    Action = "prev_bookmark"
+	ActionNextBookmark    Action = "next_bookmark"
+	ActionPrevChapter     Action = "prev_chapter"
+	ActionNextChapter     Action = "next_chapter"
+	ActionPreview         Action = "preview"
+	ActionABLoop          Action = "ab_loop"
+	ActionVisualizer      Action = "visualizer"
+	ActionRevealFolder    Action = "reveal_folder"
+	ActionCopyPath        Action = "copy_path"
+	ActionCompact         Action = "compact"
+	ActionRate1           Action = "rate_1"
+	ActionRate2           Action = "rate_2"
+	ActionRate3           Action = "rate_3"
+	ActionRate4           Action = "rate_4"
+	ActionRate5           Action = "rate_5"
+	ActionRateClear       Action = "rate_clear"
+	ActionGainUp          Action = "gain_up"
+	ActionGainDown        Action = "gain_down"
+	ActionGainReset       Action = "gain_reset"
+	ActionUndo            Action = "undo"
+	ActionLibraryFolder   Action = "library_folder"
+	ActionRescan          Action = "rescan"
+	ActionExportStats     Action = "export_stats"
+	ActionPalette         Action = "command_palette"
+)
+
+// defaultBindings are the keys of each action unless configured otherwise
+var defaultBindings = []struct {
+	action Action
+	keys   []string
+}{
+	{ActionQuit, []string{"q"}},
+	{ActionViewPlayer, []string{"1"}},
+	{ActionViewLibrary, []string{"2"}},
+	{ActionViewPlaylist, []string{"3"}},
+	{ActionViewQueue, []string{"4"}},
+	{ActionViewHistory, []string{"5"}},
+	{ActionViewBrowse, []string{"6"}},
+	{ActionNextView, []string{"tab"}},
+	{ActionPrevView, []string{"shift+tab"}},
+	{ActionSearch, []string{"/"}},
+	{ActionPlayPause, []string{" "}},
+	{ActionPlaySelected, []string{"enter"}},
+	{ActionPlayTrack, []string{"alt+enter"}},
+	{ActionStop, []string{"s"}},
+	{ActionNext, []string{"n"}},
+	{ActionPrevious, []string{"p"}},
+	{ActionSeekForward, []string{"right"}},
+	{ActionSeekBack, []string{"left"}},
+	{ActionSeekForwardLong, []string{"shift+right"}},
+	{ActionSeekBackLong, []string{"shift+left"}},
+	{ActionVolumeUp, []string{"+", "="}},
+	{ActionVolumeDown, []string{"-"}},
+	{ActionMute, []string{"m"}},
+	{ActionRepeat, []string{"r"}},
+	{ActionShuffle, []string{"S"}},
+	{ActionResume, []string{"R"}},
+	{ActionTimeMode, []string{"t"}},
+	{ActionEqualizer, []string{"E"}},
+	{ActionTheme, []string{"c"}},
+	{ActionSleepTimer, []string{"T"}},
+	{ActionOutputDevice, []string{"D"}},
+	{ActionReplayGain, []string{"L"}},
+	{ActionSkipSilence, []string{"Z"}},
+	{ActionTrimStart, []string{"["}},
+	{ActionTrimEnd, []string{"]"}},
+	{ActionTrimClear, []string{"\\"}},
+	{ActionBookmark, []string{"b"}},
+	{ActionRemoveBookmark, []string{"alt+b"}},
+	{ActionPrevBookmark, []string{","}},
+	{ActionNextBookmark, []string{"."}},
+	{ActionPrevChapter, []string{"<"}},
+	{ActionNextChapter, []string{">"}},
+	{ActionPreview, []string{"v"}},
+	{ActionABLoop, []string{"l"}},
+	{ActionVisualizer, []string{"V"}},
+	{ActionRevealFolder, []string{"ctrl+o"}},
+	{ActionCopyPath, []string{"ctrl+y"}},
+	{ActionCompact, []string{"alt+m"}},
+	{ActionRate1, []string{"alt+1"}},
+	{ActionRate2, []string{"alt+2"}},
+	{ActionRate3, []string{"alt+3"}},
+	{ActionRate4, []string{"alt+4"}},
+	{ActionRate5, []string{"alt+5"}},
+	{ActionRateClear, []string{"alt+0"}},
+	{ActionGainUp, []string{"alt+=", "alt++"}},
+	{ActionGainDown, []string{"alt+-"}},
+	{ActionGainReset, []string{"alt+backspace"}},
+	{ActionUndo, []string{"u"}},
+	{ActionLibraryFolder, []string{"alt+o"}},
+	{ActionRescan, []string{"alt+r"}},
+	{ActionExportStats, []string{"alt+s"}},
+	{ActionPalette, []string{":"}},
+}
+
+// viewBindings are the keys of the actions each view handles itself,
+// named after the view in the config file, e.g. "queue.remove". The view
+// acts on its first key, which the others are passed on as.
+var viewBindings = []viewBinding{
+	{ViewPlayer, "player.go_to", []string{"g"}},
+	{ViewLibrary, "library.jump", []string{"g"}},
+	{ViewLibrary, "library.add_files", []string{"a"}},
+	{ViewLibrary, "library.sort", []string{"o"}},
+	{ViewLibrary, "library.sort_order", []string{"O"}},
+	{ViewLibrary, "library.group", []string{"G"}},
+	{ViewLibrary, "library.mark", []string{"x"}},
+	{ViewLibrary, "library.mark_all", []string{"X"}},
+	{ViewLibrary, "library.clear_marks", []string{"esc"}},
+	{ViewLibrary, "library.play_next", []string{"N"}},
+	{ViewLibrary, "library.enqueue", []string{"e"}},
+	{ViewLibrary, "library.pin", []string{"ctrl+p"}},
+	{ViewLibrary, "library.queue", []string{"Q"}},
+	{ViewLibrary, "library.replace_queue", []string{"ctrl+q"}},
+	{ViewLibrary, "library.organize", []string{"M"}},
+	{ViewLibrary, "library.save_playlist", []string{"W"}},
+	{ViewLibrary, "library.add_to_playlist", []string{"P"}},
+	{ViewLibrary, "library.hide_offline", []string{"H"}},
+	{ViewLibrary, "library.find_playing", []string{"f"}},
+	{ViewLibrary, "library.follow", []string{"F"}},
+	{ViewLibrary, "library.details", []string{"i"}},
+	{ViewLibrary, "library.detect_bpm", []string{"B"}},
+	{ViewLibrary, "library.detect_bpm_all", []string{"ctrl+b"}},
+	{ViewPlaylist, "playlist.back", []string{"backspace", "esc"}},
+	{ViewPlaylist, "playlist.move_up", []string{"K", "shift+up"}},
+	{ViewPlaylist, "playlist.move_down", []string{"J", "shift+down"}},
+	{ViewPlaylist, "playlist.remove", []string{"d", "delete"}},
+	{ViewQueue, "queue.move_up", []string{"K", "shift+up"}},
+	{ViewQueue, "queue.move_down", []string{"J", "shift+down"}},
+	{ViewQueue, "queue.remove", []string{"d", "delete"}},
+	{ViewQueue, "queue.clear", []string{"C"}},
+	{ViewHistory, "history.clear", []string{"C"}},
+	{ViewBrowse, "browse.back", []string{"esc", "backspace"}},
+}
+
+// Keymap resolves the keys pressed outside of text entry and panels to
+// the actions they trigger. Keys it doesn't bind go to the active view,
+// as the view's own remapped keys say.
+type Keymap struct {
+	actions map[string]Action
+
+	// views maps keys configured for a view's own actions to the key the
+	// view acts on; "" drops a default key whose action was moved away
+	views map[ViewType]map[string]string
+}
+
+// DefaultKeymap returns the keymap with every action on its default keys
+func DefaultKeymap() Keymap {
+	km := Keymap{actions: make(map[string]Action)}
+	for _, b := range defaultBindings {
+		for _, key := range b.keys {
+			km.actions[key] = b.action
+		}
+	}
+	km.actions["ctrl+c"] = ActionQuit
+	return km
+}
+
+// NewKeymap returns the default keymap with bindings, from action names to
+// space-separated keys such as "n ctrl+n", applied over it. Keys are named
+// as bubbletea names them ("enter", "shift+right", "alt+b"); "space" is
+// the space bar. A configured action loses its default keys, and a default
+// action loses any key configured for another. ctrl+c always quits. A
+// view's own actions, such as "queue.remove", are bound the same way
+// within the view, and can't take a global action's keys.
+//
+// Unknown actions, empty bindings and keys given to two actions are
+// reported together; the keymap returned still applies the rest.
+func NewKeymap(bindings map[string]string) (Keymap, error) {
+	km := DefaultKeymap()
+	known := make(map[Action]bool, len(defaultBindings))
+	for _, b := range defaultBindings {
+		known[b.action] = true
+	}
+
+	names := make([]string, 0, len(bindings))
+	var viewNames []string
+	for name := range bindings {
+		if _, ok := lookupViewBinding(name); ok {
+			viewNames = append(viewNames, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	slices.Sort(viewNames)
+
+	var errs []error
+	claimed := make(map[string]Action)
+	for _, name := range names {
+		action := Action(name)
+		if !known[action] {
+			errs = append(errs, fmt.Errorf("unknown action %q", name))
+			continue
+		}
+		keys := parseKeys(bindings[name])
+		if len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no keys given", name))
+			continue
+		}
+		var accepted []string
+		for _, key := range keys {
+			if other, ok := claimed[key]; ok && other != action {
+				errs = append(errs, fmt.Errorf("%s: key %q is already bound to %s", name, keyName(key), other))
+				continue
+			}
+			claimed[key] = action
+			accepted = append(accepted, key)
+		}
+		// An action none of whose keys could be used keeps its defaults
+		if len(accepted) == 0 {
+			continue
+		}
+		for key, bound := range km.actions {
+			if bound == action && key != "ctrl+c" {
+				delete(km.actions, key)
+			}
+		}
+		for _, key := range accepted {
+			km.actions[key] = action
+		}
+	}
+	km.actions["ctrl+c"] = ActionQuit
+	errs = append(errs, km.bindViews(bindings, viewNames)...)
+	return km, errors.Join(errs...)
+}
+
+// viewBinding is a view's own action and its default keys
+type viewBinding struct {
+	view   ViewType
+	action string
+	keys   []string
+}
+
+// lookupViewBinding returns the view action called name, if there is one
+func lookupViewBinding(name string) (viewBinding, bool) {
+	for _, b := range viewBindings {
+		if b.action == name {
+			return b, true
+		}
+	}
+	return viewBinding{}, false
+}
+
+// bindViews applies the bindings of the view actions names, once the
+// global keys are settled, and returns the problems found
+func (km *Keymap) bindViews(bindings map[string]string, names []string) []error {
+	var errs []error
+	km.views = make(map[ViewType]map[string]string)
+	parsed := make(map[string][]string, len(names))
+	for _, name := range names {
+		b, _ := lookupViewBinding(name)
+		keys := parseKeys(bindings[name])
+		if len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no keys given", name))
+			continue
+		}
+		parsed[name] = keys
+		if km.views[b.view] == nil {
+			km.views[b.view] = make(map[string]string)
+		}
+		for _, key := range b.keys {
+			km.views[b.view][key] = ""
+		}
+	}
+
+	claimed := make(map[ViewType]map[string]string)
+	for _, name := range names {
+		b, _ := lookupViewBinding(name)
+		if claimed[b.view] == nil {
+			claimed[b.view] = make(map[string]string)
+		}
+		for _, key := range parsed[name] {
+			if global, ok := km.actions[key]; ok {
+				errs = append(errs, fmt.Errorf("%s: key %q is already bound to %s", name, keyName(key), global))
+				continue
+			}
+			if other, ok := claimed[b.view][key]; ok && other != name {
+				errs = append(errs, fmt.Errorf("%s: key %q is already bound to %s", name, keyName(key), other))
+				continue
+			}
+			claimed[b.view][key] = name
+			km.views[b.view][key] = b.keys[0]
+		}
+	}
+	return errs
+}
+
+// parseKeys splits a binding into its keys. A binding of a lone space is
+// the space bar, as older config files wrote it.
+func parseKeys(s string) []string {
+	if s == " " {
+		return []string{" "}
+	}
+	keys := strings.Fields(s)
+	for i, key := range keys {
+		if key == "space" {
+			keys[i] = " "
+		}
+	}
+	return keys
+}
+
+// keyName is how a key is written in a binding
+func keyName(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+// Action returns the action key triggers, if any
+func (km Keymap) Action(key string) (Action, bool) {
+	action, ok := km.actions[key]
+	return action, ok
+}
+
+// ViewKey returns the key view acts on when key is pressed in it, and
+// false if the view should ignore it because its action was moved to
+// other keys
+func (km Keymap) ViewKey(view ViewType, key string) (string, bool) {
+	if mapped, ok := km.views[view][key]; ok {
+		return mapped, mapped != ""
+	}
+	return key, true
+}
+
+// keyTypes are bubbletea's key types by the names their keys go by
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-256); t < 256; t++ {
+		if name := t.String(); name != "" && t != tea.KeyRunes {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// keyMsg returns the key press bubbletea names key, so a remapped key can
+// be passed on to a view as the key it acts on
+func keyMsg(key string) tea.KeyMsg {
+	alt := false
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok && rest != "" {
+		alt, key = true, rest
+	}
+	if t, ok := keyTypes[key]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key), Alt: alt}
+}
+
+// Keys returns the keys bound to action, named as in a binding and sorted
+func (km Keymap) Keys(action Action) []string {
+	var keys []string
+	for key, bound := range km.actions {
+		if bound == action {
+			keys = append(keys, keyName(key))
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// viewKey returns msg as the active view acts on it, with the view's own
+// keys remapped, and false if the view should ignore it. Typing a time to
+// go to takes keys as they are.
+func (m *Model) viewKey(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	if m.activeView == ViewPlayer && m.playerView.GoingTo {
+		return msg, true
+	}
+	key, ok := m.keys.ViewKey(m.activeView, msg.String())
+	if !ok {
+		return msg, false
+	}
+	if key != msg.String() {
+		return keyMsg(key), true
+	}
+	return msg, true
+}
+
+// playerKey returns msg as the player view acts on it, and true if the
+// view takes it ahead of the global keys: every key while its time prompt
+// is open, and otherwise its seek keys, unless the keymap gives the key
+// to a global action. Digits that only switch views are left to seek, as
+// they do while a track can be seeked.
+func (m *Model) playerKey(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	if m.activeView != ViewPlayer {
+		return msg, false
+	}
+	if m.playerView.GoingTo {
+		return msg, true
+	}
+	if action, ok := m.keys.Action(msg.String()); ok && !slices.Contains(directViewActions, action) {
+		return msg, false
+	}
+	viewMsg, ok := m.viewKey(msg)
+	return viewMsg, ok && m.playerView.TakesKey(viewMsg.String())
+}
+
+// directViewActions are the actions that switch straight to one view
+var directViewActions = []Action{
+	ActionViewPlayer, ActionViewLibrary, ActionViewPlaylist,
+	ActionViewQueue, ActionViewHistory, ActionViewBrowse,
+}
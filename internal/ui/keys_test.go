@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestNewKeymap_Rebinds(t *testing.T) {
+	km, err := NewKeymap(map[string]string{
+		"next":       "N ctrl+n",
+		"play_pause": "space",
+		"shuffle":    "s", // Taken from stop
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]Action{
+		"N":      ActionNext,
+		"ctrl+n": ActionNext,
+		" ":      ActionPlayPause,
+		"s":      ActionShuffle,
+		"q":      ActionQuit,
+		"ctrl+c": ActionQuit,
+	} {
+		if got, _ := km.Action(key); got != want {
+			t.Errorf("Action(%q) = %q, want %q", key, got, want)
+		}
+	}
+	for _, key := range []string{"n", "S"} {
+		if got, ok := km.Action(key); ok {
+			t.Errorf("default key %q still bound to %q", key, got)
+		}
+	}
+}
+
+func TestNewKeymap_Errors(t *testing.T) {
+	km, err := NewKeymap(map[string]string{
+		"dance":  "d",
+		"next":   "x",
+		"stop":   "x",
+		"repeat": "  ",
+		"quit":   "ctrl+q",
+	})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	msg := err.Error()
+	for _, want := range []string{`unknown action "dance"`, "repeat: no keys given", `stop: key "x" is already bound to next`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %q", msg, want)
+		}
+	}
+	// The valid bindings still apply, and an action given no usable keys,
+	// none at all or only ones already taken, keeps its defaults
+	if got, _ := km.Action("ctrl+q"); got != ActionQuit {
+		t.Errorf("ctrl+q = %q, want quit", got)
+	}
+	if got, _ := km.Action("r"); got != ActionRepeat {
+		t.Errorf("r = %q, want repeat", got)
+	}
+	if got, _ := km.Action("x"); got != ActionNext {
+		t.Errorf("x = %q, want next", got)
+	}
+	if got, _ := km.Action("s"); got != ActionStop {
+		t.Errorf("s = %q, want stop to keep its default key", got)
+	}
+}
+
+func TestNewKeymap_ViewKeys(t *testing.T) {
+	km, err := NewKeymap(map[string]string{
+		"queue.remove":  "x",
+		"queue.clear":   "d", // Taken from remove
+		"library.sort":  "s", // Global stop's key
+		"player.go_to":  "ctrl+g",
+		"library.bogus": "z",
+	})
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	for _, want := range []string{`library.sort: key "s" is already bound to stop`, `unknown action "library.bogus"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %q", msg, want)
+		}
+	}
+
+	tests := []struct {
+		view ViewType
+		key  string
+		want string
+		ok   bool
+	}{
+		{ViewQueue, "x", "d", true},
+		{ViewQueue, "d", "C", true},
+		{ViewQueue, "delete", "", false},
+		{ViewQueue, "C", "", false},
+		{ViewQueue, "K", "K", true},
+		{ViewLibrary, "o", "", false},
+		{ViewLibrary, "x", "x", true}, // Other views keep their keys
+		{ViewPlayer, "ctrl+g", "g", true},
+	}
+	for _, tt := range tests {
+		got, ok := km.ViewKey(tt.view, tt.key)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("ViewKey(%v, %q) = %q, %v; want %q, %v", tt.view, tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeyMsg(t *testing.T) {
+	for _, key := range []string{"a", "C", "enter", "esc", "shift+up", "ctrl+q", "delete", "alt+b", " "} {
+		if got := keyMsg(key).String(); got != key {
+			t.Errorf("keyMsg(%q) is %q", key, got)
+		}
+	}
+}
+
+func TestPlayerKey_KeymapFirst(t *testing.T) {
+	km, err := NewKeymap(map[string]string{"shuffle": "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := newTestModel(t, library.NewLibrary(), Options{Keys: km})
+	m.activeView = ViewPlayer
+	m.playerView.SetState(&api.PlaybackState{
+		Status:       api.StatusPlaying,
+		CurrentTrack: &api.Track{ID: "a", Title: "A", Duration: 200 * time.Second},
+	})
+
+	for key, want := range map[string]bool{"5": true, "8": true, "g": true, "7": false, "n": false} {
+		if _, got := m.playerKey(keyMsg(key)); got != want {
+			t.Errorf("player takes %q: %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// footerBaseHeight is the footer's height without prompt or status
+	// lines: a divider, the now-playing line and the progress bar
+	footerBaseHeight = 3
+
+	// footerProgressRow is the progress bar's row within the footer
+	footerProgressRow = 2
+
+	// footerReserve is the footer height views are sized for; one line
+	// is kept for the status message
+	footerReserve = footerBaseHeight + 1
+)
+
+// composeScreen stacks the header, content and footer so that the footer
+// always occupies the last rows of a height-row screen. The content is
+// clipped or padded to fill the rows in between, so it scrolls under a
+// fixed footer. With an unknown height the parts are simply joined.
+func composeScreen(height int, header, content, footer string) string {
+	if height <= 0 {
+		return header + "\n" + content + "\n" + footer
+	}
+
+	avail := height - lipgloss.Height(header) - lipgloss.Height(footer)
+	if avail < 0 {
+		avail = 0
+	}
+	lines := strings.Split(content, "\n")
+	if len(lines) > avail {
+		lines = lines[:avail]
+	}
+	for len(lines) < avail {
+		lines = append(lines, "")
+	}
+
+	parts := []string{header}
+	if avail > 0 {
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	parts = append(parts, footer)
+	return strings.Join(parts, "\n")
+}
+
+// footerTop returns the screen row the footer starts at
+func footerTop(height int, footer string) int {
+	return height - lipgloss.Height(footer)
+}
+
+// contentHeight returns the height content views are sized to
+func contentHeight(height int) int {
+	// Tab bar above, footer below
+	return max(height-1-footerReserve, 0)
+}
+
+// overlayCenter draws box over content, width by height, centered across
+// it and a third of the way down, with content showing around it
+func overlayCenter(content, box string, width, height int) string {
+	lines := strings.Split(content, "\n")
+	boxLines := strings.Split(box, "\n")
+	boxWidth := lipgloss.Width(box)
+	x := max((width-boxWidth)/2, 0)
+	y := max((height-len(boxLines))/3, 0)
+	for len(lines) < max(height, y+len(boxLines)) {
+		lines = append(lines, "")
+	}
+	for i, b := range boxLines {
+		line := lines[y+i]
+		left := ansi.Truncate(line, x, "")
+		left += strings.Repeat(" ", x-lipgloss.Width(left))
+		right := ansi.TruncateLeft(line, x+boxWidth, "")
+		// Reset styles either side so the content's don't run into the box
+		lines[y+i] = left + "\x1b[m" + b + "\x1b[m" + right
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestViews_TabNavigationAndClicks(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	m.runAction(ActionPrevView)
+	if m.activeView != ViewPlayer {
+		t.Fatalf("Shift+Tab from the library went to %v, want player", m.activeView)
+	}
+	m.runAction(ActionPrevView)
+	if m.activeView != ViewBrowse {
+		t.Fatalf("Shift+Tab from the player went to %v, want browse", m.activeView)
+	}
+	m.runAction(ActionNextView)
+	if m.activeView != ViewPlayer {
+		t.Fatalf("Tab from browse went to %v, want player", m.activeView)
+	}
+
+	// A click on the fourth tab goes to the queue
+	x := strings.Index(ansi.Strip(m.renderTabs()), "Queue")
+	m.contentMouse(tea.MouseMsg{X: x, Y: 0, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if m.activeView != ViewQueue {
+		t.Errorf("click at column %d went to %v, want queue", x, m.activeView)
+	}
+	if _, ok := m.tabAt(-1); ok {
+		t.Error("a tab left of the tab bar")
+	}
+}
+
+func TestComposeScreen_PinsFooter(t *testing.T) {
+	footer := "divider\nnow playing\nprogress"
+
+	for _, content := range []string{"one line", strings.Repeat("row\n", 40) + "row"} {
+		screen := composeScreen(10, "tabs", content, footer)
+		lines := strings.Split(screen, "\n")
+		if len(lines) != 10 {
+			t.Fatalf("screen has %d lines, want 10", len(lines))
+		}
+		if lines[0] != "tabs" {
+			t.Errorf("first line = %q, want header", lines[0])
+		}
+		if got := strings.Join(lines[7:], "\n"); got != footer {
+			t.Errorf("last lines = %q, want footer", got)
+		}
+		if row := footerTop(10, footer) + footerProgressRow; lines[row] != "progress" {
+			t.Errorf("progress row %d = %q", row, lines[row])
+		}
+	}
+}
+
+func TestOverlayCenter(t *testing.T) {
+	content := strings.Repeat("..........\n", 5) + ".........."
+	screen := overlayCenter(content, "+--+\n|ab|\n+--+", 10, 6)
+	lines := strings.Split(ansi.Strip(screen), "\n")
+	want := []string{"..........", "...+--+...", "...|ab|...", "...+--+...", "..........", ".........."}
+	if len(lines) != len(want) {
+		t.Fatalf("overlay has %d lines, want %d", len(lines), len(want))
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
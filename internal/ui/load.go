@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// A library scanned in at startup reaches the UI in batches of up to
+// loadBatchSize tracks, sent at least every loadBatchInterval while tracks
+// are coming in, so the list fills without a redraw per file
+const (
+	loadBatchSize     = 500
+	loadBatchInterval = 250 * time.Millisecond
+)
+
+// TracksBatchMsg carries tracks the startup scan has added to the library
+type TracksBatchMsg struct {
+	Tracks []*api.Track
+	next   <-chan tea.Msg // Where the rest of the load comes from
+}
+
+// LoadDoneMsg is sent when the startup scan has finished, with the files
+// it skipped and, if it stopped short, why
+type LoadDoneMsg struct {
+	Total   int
+	Skipped []*playerrors.ScanError
+	Err     error
+}
+
+// loadLibraryCmd scans dirs into the library off the UI goroutine,
+// sending the tracks found as TracksBatchMsgs and then a LoadDoneMsg
+func (m Model) loadLibraryCmd(dirs []string) tea.Cmd {
+	if len(dirs) == 0 {
+		return nil
+	}
+	ctx, lib := m.ctx, m.library
+	return func() tea.Msg {
+		found := make(chan *api.Track, loadBatchSize)
+		done := make(chan LoadDoneMsg, 1)
+		go func() {
+			total := 0
+			skipped, err := lib.ScanEach(ctx, dirs, func(track *api.Track) {
+				total++
+				found <- track
+			})
+			close(found)
+			done <- LoadDoneMsg{Total: total, Skipped: skipped, Err: err}
+		}()
+
+		msgs := make(chan tea.Msg)
+		go batchTracks(found, done, msgs)
+		return <-msgs
+	}
+}
+
+// batchTracks gathers the tracks from found into TracksBatchMsgs on msgs,
+// ending with the LoadDoneMsg from done
+func batchTracks(found <-chan *api.Track, done <-chan LoadDoneMsg, msgs chan tea.Msg) {
+	defer close(msgs)
+	ticker := time.NewTicker(loadBatchInterval)
+	defer ticker.Stop()
+
+	var batch []*api.Track
+	flush := func() {
+		if len(batch) > 0 {
+			msgs <- TracksBatchMsg{Tracks: batch, next: msgs}
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case track, ok := <-found:
+			if !ok {
+				flush()
+				msgs <- <-done
+				return
+			}
+			batch = append(batch, track)
+			if len(batch) >= loadBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// nextBatch waits for the load's next message
+func nextBatch(msgs <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-msgs
+	}
+}
+
+// tracksLoaded adds a batch to the library view, leaving the selection
+// and any search where they are, and listens for the next
+func (m *Model) tracksLoaded(msg TracksBatchMsg) tea.Cmd {
+	m.libraryView.UpdateTracks(msg.Tracks, nil)
+	if m.activeView == ViewBrowse {
+		m.refreshBrowse()
+	}
+	return tea.Batch(m.queueDurations(msg.Tracks), nextBatch(msg.next))
+}
+
+// loadDone finishes the startup scan: a notice says how it went, and
+// the duplicates among the tracks found are looked for in the background
+func (m *Model) loadDone(msg LoadDoneMsg) tea.Cmd {
+	m.libraryView.Loading = false
+	for _, s := range msg.Skipped {
+		logger.Warn("Skipped during scan: %v", s)
+	}
+	m.refreshPlaylists()
+	m.refreshBrowse()
+
+	if msg.Err != nil {
+		logger.Warn("Library scan stopped: %v", msg.Err)
+		return tea.Batch(
+			m.toast.Notify(fmt.Sprintf("Scan stopped: %v", msg.Err), components.LevelError),
+			m.hideDuplicatesCmd(),
+		)
+	}
+	text := fmt.Sprintf("Found %d tracks", msg.Total)
+	if len(msg.Skipped) > 0 {
+		text += fmt.Sprintf(", %d skipped (see log)", len(msg.Skipped))
+	}
+	logger.Info("Library scan finished: %s", text)
+	return tea.Batch(m.toast.Notify(text, components.LevelSuccess), m.hideDuplicatesCmd())
+}
+
+// DuplicatesHiddenMsg is sent when the library's duplicates have been
+// hidden, with the sets they were found in
+type DuplicatesHiddenMsg struct {
+	Groups []library.DuplicateGroup
+}
+
+// hideDuplicatesCmd hides the library's duplicates off the UI goroutine,
+// as finding them may mean reading every file of the same size
+func (m Model) hideDuplicatesCmd() tea.Cmd {
+	if m.opts.Dedup == library.DedupOff {
+		return nil
+	}
+	lib, mode := m.library, m.opts.Dedup
+	return func() tea.Msg {
+		return DuplicatesHiddenMsg{Groups: lib.HideDuplicates(mode)}
+	}
+}
+
+// startupDedupCmd hides the duplicates of a library loaded from its file.
+// One scanned at startup has them hidden once the scan is done.
+func (m Model) startupDedupCmd() tea.Cmd {
+	if len(m.opts.ScanDirectories) > 0 {
+		return nil
+	}
+	return m.hideDuplicatesCmd()
+}
+
+// duplicatesHidden takes the hidden duplicates out of the views and says
+// how many there were. Tracks already queued stay queued.
+func (m *Model) duplicatesHidden(msg DuplicatesHiddenMsg) tea.Cmd {
+	var dups []*api.Track
+	for _, g := range msg.Groups {
+		for _, dup := range g.Duplicates {
+			logger.Info("Duplicate of %s hidden: %s", g.Kept.FilePath, dup.FilePath)
+		}
+		dups = append(dups, g.Duplicates...)
+	}
+	if len(dups) == 0 {
+		return nil
+	}
+	m.libraryView.UpdateTracks(nil, dups)
+	m.refreshPlaylists()
+	m.refreshBrowse()
+	return m.toast.Notify(fmt.Sprintf("Hid %d duplicate tracks (see log)", len(dups)), components.LevelInfo)
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestHideDuplicates_TakesThemOutOfTheViews(t *testing.T) {
+	lib := library.NewLibrary()
+	lib.AddTrack(&api.Track{ID: "a", Title: "Song", Artist: "Band", FilePath: "/m/a.mp3", Duration: time.Minute})
+	lib.AddTrack(&api.Track{ID: "b", Title: "Song", Artist: "Band", FilePath: "/m/b.mp3", Duration: time.Minute})
+	m, _ := newTestModel(t, lib, Options{Dedup: library.DedupTags})
+	if len(m.libraryView.AllTracks) != 2 {
+		t.Fatalf("%d tracks listed before dedup", len(m.libraryView.AllTracks))
+	}
+
+	msg, ok := m.startupDedupCmd()().(DuplicatesHiddenMsg)
+	if !ok || len(msg.Groups) != 1 {
+		t.Fatalf("dedup sent %+v", msg)
+	}
+	m.duplicatesHidden(msg)
+	if len(m.libraryView.AllTracks) != 1 || m.libraryView.AllTracks[0].ID != "a" {
+		t.Errorf("listed %v after dedup", m.libraryView.AllTracks)
+	}
+	if _, err := lib.GetTrack("b"); err != nil {
+		t.Errorf("duplicate removed from the library: %v", err)
+	}
+}
+
+func TestBatchTracks_BatchesThenFinishes(t *testing.T) {
+	found := make(chan *api.Track, loadBatchSize+10)
+	for i := 0; i < loadBatchSize+10; i++ {
+		found <- &api.Track{ID: string(rune('a' + i%26))}
+	}
+	close(found)
+	done := make(chan LoadDoneMsg, 1)
+	done <- LoadDoneMsg{Total: loadBatchSize + 10}
+
+	msgs := make(chan tea.Msg)
+	go batchTracks(found, done, msgs)
+
+	total := 0
+	for msg := range msgs {
+		switch msg := msg.(type) {
+		case TracksBatchMsg:
+			if len(msg.Tracks) > loadBatchSize {
+				t.Errorf("batch of %d tracks, want at most %d", len(msg.Tracks), loadBatchSize)
+			}
+			if msg.next == nil {
+				t.Error("batch doesn't say where the next comes from")
+			}
+			total += len(msg.Tracks)
+		case LoadDoneMsg:
+			if total != msg.Total {
+				t.Errorf("batches carried %d tracks before done, want %d", total, msg.Total)
+			}
+			return
+		}
+	}
+	t.Fatal("no LoadDoneMsg")
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// contentMouse passes a mouse event over the content, between the tab bar
+// and the footer, to the active view, positioned relative to the view. A
+// click on a tab goes to its view. Panels drawn over the view don't take
+// the mouse, so while one is open the event is dropped.
+func (m *Model) contentMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.eqOpen || m.outputs != nil || m.palette != nil {
+		return nil
+	}
+	msg.Y -= lipgloss.Height(m.renderTabs())
+	if msg.Y < 0 {
+		if view, ok := m.tabAt(msg.X); ok && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.focusView(view)
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	switch m.activeView {
+	case ViewLibrary:
+		m.libraryView, cmd = m.libraryView.Update(msg)
+	case ViewQueue:
+		m.queueView, cmd = m.queueView.Update(msg)
+	case ViewHistory:
+		m.historyView, cmd = m.historyView.Update(msg)
+	case ViewBrowse:
+		m.browseView, cmd = m.browseView.Update(msg)
+	}
+	return cmd
+}
+
+// tabAt returns the view whose tab is at column x of the tab bar
+func (m *Model) tabAt(x int) (ViewType, bool) {
+	left := 0
+	for i, tab := range tabLabels {
+		style := m.tabStyle
+		if ViewType(i) == m.activeView {
+			style = m.activeTabStyle
+		}
+		left += lipgloss.Width(style.Render(tab))
+		if x < left {
+			return ViewType(i), x >= 0
+		}
+	}
+	return 0, false
+}
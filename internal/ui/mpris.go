@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/mpris"
+)
+
+// MPRISMsg is sent when an MPRIS client, such as a media key daemon or
+// desktop panel, asks for a playback action
+type MPRISMsg struct {
+	Command mpris.Command
+}
+
+// listenMPRIS waits for the next MPRIS client command
+func (m Model) listenMPRIS() tea.Cmd {
+	server := m.opts.MPRIS
+	if server == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case cmd, ok := <-server.Commands():
+			if !ok {
+				logger.Info("MPRIS: lost connection to the session bus")
+				return nil
+			}
+			return MPRISMsg{Command: cmd}
+		case <-m.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// publishMPRIS tells MPRIS clients what's playing. The length and
+// position are the progress bar's, so they match what the footer shows.
+func (m *Model) publishMPRIS(state *api.PlaybackState) {
+	server := m.opts.MPRIS
+	if server == nil {
+		return
+	}
+	status := mpris.Status{
+		Playback:    state.Status,
+		Volume:      float64(m.playerView.Volume.Level()) / 100,
+		CanNext:     m.queue.HasNext(),
+		CanPrevious: m.queue.HasPrevious(),
+	}
+	if state.CurrentTrack != nil {
+		bar := m.playerView.ProgressBar
+		status.Track = state.CurrentTrack
+		status.Position, status.Length = bar.Current, bar.Total
+	}
+	server.Update(status)
+}
+
+// mprisCommand carries out a command from an MPRIS client as the matching
+// key would
+func (m *Model) mprisCommand(cmd mpris.Command) tea.Cmd {
+	logger.Debug("MPRIS command %d", cmd.Kind)
+	state := m.audioEngine.GetState()
+	loaded := (state.Status == api.StatusPlaying || state.Status == api.StatusPaused) && state.CurrentTrack != nil
+
+	switch cmd.Kind {
+	case mpris.CommandPlay:
+		if state.Status != api.StatusPlaying {
+			m.togglePlayback()
+		}
+	case mpris.CommandPause:
+		if state.Status == api.StatusPlaying {
+			m.audioEngine.Pause()
+		}
+	case mpris.CommandPlayPause:
+		m.togglePlayback()
+	case mpris.CommandStop:
+		m.gapUntil = time.Time{}
+		m.audioEngine.Stop()
+	case mpris.CommandNext:
+		m.skipNext()
+	case mpris.CommandPrevious:
+		if prev := m.queue.SkipBack(); prev != nil {
+			m.audioEngine.Play(prev)
+		}
+	case mpris.CommandSeek:
+		if !loaded {
+			break
+		}
+		// Seeking past the end moves on, as the MPRIS spec asks
+		target := max(state.Position+cmd.Position, 0)
+		if total := m.playerView.ProgressBar.Total; total > 0 && target >= total {
+			m.skipNext()
+			break
+		}
+		m.audioEngine.Seek(target)
+	case mpris.CommandSetPosition:
+		if loaded && cmd.Position >= 0 && cmd.Position <= m.playerView.ProgressBar.Total {
+			m.audioEngine.Seek(cmd.Position)
+		}
+	case mpris.CommandVolume:
+		volume := &m.playerView.Volume
+		volume.Muted = false
+		volume.SetVolume(int(math.Round(cmd.Volume * 100)))
+		m.audioEngine.SetVolume(float64(volume.Level()) / 100)
+	case mpris.CommandQuit:
+		m.cancel()
+		return tea.Quit
+	}
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	return nil
+}
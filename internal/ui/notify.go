@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/desktop"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// notifySettle is how long a track plays before its notification goes
+// out, so skipping quickly through the queue doesn't send one per track
+const notifySettle = 2 * time.Second
+
+// NotifyFailedMsg is sent when a track change notification couldn't be
+// shown
+type NotifyFailedMsg struct {
+	Err error
+}
+
+// trackNotice is the notification for the playing track: which track, when
+// it's due, and whether it has gone
+type trackNotice struct {
+	path string
+	due  time.Time
+	sent bool
+}
+
+// notifyTrack sends a desktop notification for the playing track once it
+// has played for notifySettle, if notifications are on
+func (m *Model) notifyTrack(state *api.PlaybackState, now time.Time) tea.Cmd {
+	track := state.CurrentTrack
+	if !m.opts.Notifications || track == nil || state.Status != api.StatusPlaying {
+		return nil
+	}
+	n := &m.notice
+	if track.FilePath != n.path {
+		*n = trackNotice{path: track.FilePath, due: now.Add(notifySettle)}
+		return nil
+	}
+	if n.sent || now.Before(n.due) {
+		return nil
+	}
+	n.sent = true
+	return notifyCmd(track, m.opts.NotifyCover)
+}
+
+// notifyCmd shows track's notification off the UI goroutine. Its artwork,
+// if it has any and isn't streamed, is written to cover for the icon.
+func notifyCmd(track *api.Track, cover string) tea.Cmd {
+	title := track.Title
+	if track.Artist != "" {
+		title = track.Artist + " - " + track.Title
+	}
+	body, path, embedded, stream := track.Album, track.FilePath, track.CoverArt, track.IsStream()
+	return func() tea.Msg {
+		icon := ""
+		if cover != "" && !stream {
+			if data := coverArt(path, embedded); data != nil && os.WriteFile(cover, data, 0644) == nil {
+				icon = cover
+			}
+		}
+		if err := desktop.Notify(title, body, icon); err != nil {
+			return NotifyFailedMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+// notifyFailed logs why a notification wasn't shown. Without a way to show
+// them at all, notifications are turned off for the rest of the run.
+func (m *Model) notifyFailed(msg NotifyFailedMsg) {
+	if errors.Is(msg.Err, desktop.ErrNoNotifier) {
+		logger.Info("Track notifications off: %v", msg.Err)
+		m.opts.Notifications = false
+		return
+	}
+	logger.Warn("Track notification: %v", msg.Err)
+}
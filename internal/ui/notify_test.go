@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	"github.com/jscyril/golang_music_player/internal/desktop"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestNotifyTrack_WaitsForTheTrackToSettle(t *testing.T) {
+	m, engine := newTestModel(t, library.NewLibrary(), Options{Notifications: true})
+	a := &api.Track{ID: "a", Title: "A", FilePath: "/music/a.mp3"}
+	b := &api.Track{ID: "b", Title: "B", FilePath: "/music/b.mp3"}
+	start := time.Now()
+
+	engine.Play(a)
+	if m.notifyTrack(engine.GetState(), start) != nil {
+		t.Fatal("notified as soon as the track started")
+	}
+	// Skipped on before it settles: nothing for a
+	engine.Play(b)
+	if m.notifyTrack(engine.GetState(), start.Add(time.Second)) != nil {
+		t.Fatal("notified for a skipped track")
+	}
+	if m.notifyTrack(engine.GetState(), start.Add(2*time.Second)) != nil {
+		t.Fatal("notified before b had played long enough")
+	}
+	if m.notifyTrack(engine.GetState(), start.Add(3*time.Second)) == nil {
+		t.Fatal("no notification once b had settled")
+	}
+	if m.notifyTrack(engine.GetState(), start.Add(4*time.Second)) != nil {
+		t.Error("notified twice for the same track")
+	}
+
+	// Played again, it is announced again
+	next, _ := m.Update(StateUpdateMsg{State: engine.GetState(), Started: true})
+	*m = next.(Model)
+	m.notifyTrack(engine.GetState(), start.Add(5*time.Second))
+	if m.notifyTrack(engine.GetState(), start.Add(8*time.Second)) == nil {
+		t.Error("no notification for the track started again")
+	}
+
+	m.notifyFailed(NotifyFailedMsg{Err: desktop.ErrNoNotifier})
+	engine.Play(a)
+	m.notifyTrack(engine.GetState(), start.Add(9*time.Second))
+	if m.notifyTrack(engine.GetState(), start.Add(12*time.Second)) != nil {
+		t.Error("still notifying with no way to show notifications")
+	}
+}
+
+func TestNotifyTrack_EachStreamTitle(t *testing.T) {
+	m, engine := newTestModel(t, library.NewLibrary(), Options{Notifications: true})
+	radio := &api.Track{ID: "r", Title: "Radio", URL: "http://radio.example/live", FilePath: "http://radio.example/live"}
+	engine.Play(radio)
+	start := time.Now()
+
+	m.streamInfo(audio.StreamInfo{Track: radio, Connected: true, Title: "Band - Song"})
+	m.notifyTrack(m.playbackState(engine.GetState()), start)
+	if m.notifyTrack(m.playbackState(engine.GetState()), start.Add(3*time.Second)) == nil {
+		t.Fatal("no notification for the first title")
+	}
+	m.streamInfo(audio.StreamInfo{Track: radio, Title: "Other - Tune"})
+	m.notifyTrack(m.playbackState(engine.GetState()), start.Add(4*time.Second))
+	if m.notifyTrack(m.playbackState(engine.GetState()), start.Add(7*time.Second)) == nil {
+		t.Error("no notification for the next title")
+	}
+}
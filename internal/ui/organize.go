@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// applyMovesCmd moves organized files off the UI goroutine, leaving the
+// tracks to be pointed at them once the moves are back
+func applyMovesCmd(moves []library.Move) tea.Cmd {
+	return func() tea.Msg {
+		return OrganizedMsg{Moves: library.ApplyMoves(moves)}
+	}
+}
+
+// organized points each moved track at its new file: the library re-keys
+// it under the new path's ID, and the playlists and everything kept by
+// file path follow it. The stores changed are saved in the background.
+func (m *Model) organized(msg OrganizedMsg) tea.Cmd {
+	moved, failed := 0, 0
+	for _, move := range msg.Moves {
+		switch {
+		case move.Err != nil:
+			failed++
+			logger.Warn("Organize: cannot move %s to %s: %v", move.From, move.To, move.Err)
+		case !move.Unchanged():
+			moved++
+			oldID := move.Track.ID
+			m.library.Relocate(move.Track, move.To)
+			if err := m.playlistManager.UpdateTrackPath(oldID, move.Track); err != nil {
+				logger.Error("Organize: update playlists for %s: %v", move.To, err)
+			}
+			if m.positions != nil {
+				m.positions.Rename(move.From, move.To)
+			}
+			if m.bookmarks != nil {
+				m.bookmarks.Rename(move.From, move.To)
+			}
+			if m.playStats != nil {
+				m.playStats.Rename(move.From, move.To)
+			}
+		}
+	}
+	m.libraryView.Refresh()
+	m.refreshPlaylists()
+	m.refreshHistory()
+	m.status = ""
+
+	level := components.LevelSuccess
+	if failed > 0 {
+		level = components.LevelWarning
+	}
+	cmds := []tea.Cmd{m.toast.Notify(fmt.Sprintf("Moved %d files, %d failed", moved, failed), level)}
+	if moved > 0 {
+		if sc := m.library.Sidecar(); sc != nil {
+			cmds = append(cmds, saveCmd("sidecar", sc.Save))
+		}
+		if m.positions != nil {
+			cmds = append(cmds, saveCmd("positions", m.positions.Save))
+		}
+		if m.bookmarks != nil {
+			cmds = append(cmds, saveCmd("bookmarks", m.bookmarks.Save))
+		}
+		if m.playStats != nil {
+			cmds = append(cmds, saveCmd("play stats", m.playStats.Save))
+		}
+	}
+	return tea.Batch(cmds...)
+}
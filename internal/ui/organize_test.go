@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestOrganized_MovesEverythingKeptByTrack(t *testing.T) {
+	dir := t.TempDir()
+	lib := library.NewLibrary()
+	track := &api.Track{ID: "old", Title: "A", Artist: "Band", FilePath: "/in/a.mp3"}
+	lib.AddTrack(track)
+	marks := library.NewBookmarks(filepath.Join(dir, "bookmarks.json"))
+	marks.Add(track.FilePath, "Chorus", time.Minute)
+	stats := library.NewPlayStats(filepath.Join(dir, "plays.json"))
+	stats.Record(track.FilePath, time.Now())
+
+	m, _ := newTestModel(t, lib, Options{Bookmarks: marks, PlayStats: stats})
+	pl, err := m.playlistManager.Create("Mix", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.playlistManager.AddTrack(pl.ID, track); err != nil {
+		t.Fatal(err)
+	}
+
+	to := "/out/Band/a.mp3"
+	m.organized(OrganizedMsg{Moves: []library.Move{{Track: track, From: track.FilePath, To: to}}})
+	if track.FilePath != to || track.ID == "old" {
+		t.Fatalf("track is at %s with ID %s", track.FilePath, track.ID)
+	}
+	if got, err := lib.GetTrack(track.ID); err != nil || got != track {
+		t.Errorf("library lookup by the new ID = %v, %v", got, err)
+	}
+	pl, _ = m.playlistManager.GetByID(pl.ID)
+	if pl.Tracks[0].ID != track.ID || pl.Tracks[0].FilePath != to {
+		t.Errorf("playlist entry is %s at %s", pl.Tracks[0].ID, pl.Tracks[0].FilePath)
+	}
+	if len(marks.Get(to)) != 1 || stats.Get(to).Count != 1 {
+		t.Error("bookmarks and play counts did not follow the file")
+	}
+}
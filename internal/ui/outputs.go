@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// OutputDevicesMsg carries the audio outputs found for the output panel
+type OutputDevicesMsg struct {
+	Devices []audio.OutputDevice
+	Err     error
+}
+
+// OutputSwitchedMsg reports the result of moving playback to an output
+type OutputSwitchedMsg struct {
+	Device audio.OutputDevice
+	Err    error
+}
+
+// outputPanel lists the audio outputs to switch playback to. devices is
+// nil while they are being listed.
+type outputPanel struct {
+	devices  []audio.OutputDevice
+	selected int
+}
+
+// listOutputs opens the output panel and lists the outputs off the UI
+// goroutine, since that asks the sound server
+func (m *Model) listOutputs() tea.Cmd {
+	m.outputs = &outputPanel{}
+	return func() tea.Msg {
+		devices, err := audio.OutputDevices()
+		return OutputDevicesMsg{Devices: devices, Err: err}
+	}
+}
+
+// outputsListed fills in the output panel, selecting the output playing
+func (m *Model) outputsListed(msg OutputDevicesMsg) tea.Cmd {
+	if m.outputs == nil {
+		return nil
+	}
+	if msg.Err != nil || len(msg.Devices) == 0 {
+		m.outputs = nil
+		if msg.Err == nil {
+			return m.toast.Notify("No audio outputs found", components.LevelWarning)
+		}
+		return m.toast.Notify(msg.Err.Error(), components.LevelError)
+	}
+	m.outputs.devices = msg.Devices
+	for i, d := range msg.Devices {
+		if d.Default {
+			m.outputs.selected = i
+		}
+	}
+	for i, d := range msg.Devices {
+		if d.Current {
+			m.outputs.selected = i
+		}
+	}
+	return nil
+}
+
+// updateOutputs handles a key while the output panel is open and reports
+// whether it was a panel key. Enter switches to the selected output.
+func (m *Model) updateOutputs(msg tea.KeyMsg) (bool, tea.Cmd) {
+	panel := m.outputs
+	switch msg.String() {
+	case "esc", "D":
+		m.outputs = nil
+	case "up", "k":
+		if panel.selected > 0 {
+			panel.selected--
+		}
+	case "down", "j":
+		if panel.selected < len(panel.devices)-1 {
+			panel.selected++
+		}
+	case "enter":
+		if len(panel.devices) == 0 {
+			return true, nil
+		}
+		device := panel.devices[panel.selected]
+		m.outputs = nil
+		return true, func() tea.Msg {
+			return OutputSwitchedMsg{Device: device, Err: audio.SetOutputDevice(device.Name)}
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// outputSwitched confirms a switch of output, or why it failed
+func (m *Model) outputSwitched(msg OutputSwitchedMsg) tea.Cmd {
+	if msg.Err != nil {
+		logger.Warn("Failed to switch output to %s: %v", msg.Device.Name, msg.Err)
+		return m.toast.Notify("Couldn't switch to "+msg.Device.Label()+": "+msg.Err.Error(), components.LevelError)
+	}
+	logger.Info("Switched output to %s", msg.Device.Name)
+	return m.toast.Notify("Playing on "+msg.Device.Label(), components.LevelSuccess)
+}
+
+// outputsView renders the output panel
+func (m *Model) outputsView() string {
+	var sb strings.Builder
+	sb.WriteString(m.headerStyle.Render("🔈 Audio Output"))
+	sb.WriteString("\n")
+
+	dim := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	if m.outputs.devices == nil {
+		sb.WriteString(dim.Render("Looking for outputs..."))
+	}
+	for i, d := range m.outputs.devices {
+		line := "  " + d.Label()
+		if i == m.outputs.selected {
+			line = "▶ " + d.Label()
+		}
+		if d.Current {
+			line += dim.Render(" (playing)")
+		} else if d.Default {
+			line += dim.Render(" (default)")
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(dim.Render("[↑↓] Select  [Enter] Switch  [Esc] Close"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Border).
+		Padding(1, 2).
+		Render(sb.String())
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+// actionNames are what the command palette lists each action as
+var actionNames = map[Action]string{
+	ActionQuit:            "Quit",
+	ActionViewPlayer:      "Show player",
+	ActionViewLibrary:     "Show library",
+	ActionViewPlaylist:    "Show playlists",
+	ActionViewQueue:       "Show queue",
+	ActionViewHistory:     "Show history",
+	ActionViewBrowse:      "Browse artists and albums",
+	ActionNextView:        "Next view",
+	ActionPrevView:        "Previous view",
+	ActionSearch:          "Search library",
+	ActionPlayPause:       "Play / pause",
+	ActionPlaySelected:    "Play selected",
+	ActionPlayTrack:       "Play just the selected track",
+	ActionStop:            "Stop",
+	ActionNext:            "Next track",
+	ActionPrevious:        "Previous track",
+	ActionSeekForward:     "Seek forward 5s",
+	ActionSeekBack:        "Seek back 5s",
+	ActionSeekForwardLong: "Seek forward 30s",
+	ActionSeekBackLong:    "Seek back 30s",
+	ActionVolumeUp:        "Volume up",
+	ActionVolumeDown:      "Volume down",
+	ActionMute:            "Mute / unmute",
+	ActionRepeat:          "Cycle repeat",
+	ActionShuffle:         "Toggle shuffle",
+	ActionResume:          "Resume where left off",
+	ActionTimeMode:        "Cycle time display",
+	ActionEqualizer:       "Equalizer",
+	ActionTheme:           "Next theme",
+	ActionSleepTimer:      "Cycle sleep timer",
+	ActionOutputDevice:    "Audio output",
+	ActionReplayGain:      "Cycle ReplayGain",
+	ActionSkipSilence:     "Toggle skip silence",
+	ActionTrimStart:       "Trim start here",
+	ActionTrimEnd:         "Trim end here",
+	ActionTrimClear:       "Clear trim",
+	ActionBookmark:        "Add bookmark",
+	ActionRemoveBookmark:  "Remove bookmark",
+	ActionPrevBookmark:    "Previous bookmark",
+	ActionNextBookmark:    "Next bookmark",
+	ActionPrevChapter:     "Previous chapter",
+	ActionNextChapter:     "Next chapter",
+	ActionPreview:         "Preview selected",
+	ActionABLoop:          "A-B loop: set A, set B, clear",
+	ActionVisualizer:      "Toggle visualizer",
+	ActionRevealFolder:    "Open containing folder",
+	ActionCopyPath:        "Copy file path",
+	ActionCompact:         "Toggle compact layout",
+	ActionRate1:           "Rate selected 1 star",
+	ActionRate2:           "Rate selected 2 stars",
+	ActionRate3:           "Rate selected 3 stars",
+	ActionRate4:           "Rate selected 4 stars",
+	ActionRate5:           "Rate selected 5 stars",
+	ActionRateClear:       "Clear selected rating",
+	ActionGainUp:          "Raise playing track's gain",
+	ActionGainDown:        "Lower playing track's gain",
+	ActionGainReset:       "Reset playing track's gain",
+	ActionUndo:            "Undo last queue or sort change",
+	ActionLibraryFolder:   "Move the library to another folder",
+	ActionRescan:          "Rescan the library, rereading all tags",
+	ActionExportStats:     "Export listening stats",
+}
+
+// paletteCommand is an entry of the command palette: an action, run as its
+// key would run it, or a command only the palette offers
+type paletteCommand struct {
+	name   string
+	action Action
+	run    func(m *Model) tea.Cmd
+}
+
+// paletteCommands lists every command the palette offers, the actions in
+// keymap order followed by the palette's own
+func paletteCommands() []paletteCommand {
+	var commands []paletteCommand
+	for _, b := range defaultBindings {
+		if name, ok := actionNames[b.action]; ok {
+			commands = append(commands, paletteCommand{name: name, action: b.action})
+		}
+	}
+	for _, mode := range []api.RepeatMode{api.RepeatNone, api.RepeatOne, api.RepeatAll} {
+		commands = append(commands, paletteCommand{
+			name: "Repeat " + strings.ToLower(repeatModeName(mode)),
+			run:  func(m *Model) tea.Cmd { return m.setRepeat(mode) },
+		})
+	}
+	return append(commands,
+		paletteCommand{name: "Open folder browser", run: func(m *Model) tea.Cmd {
+			m.activeView = ViewLibrary
+			m.libraryView.OpenBrowser()
+			return nil
+		}},
+		paletteCommand{name: "Export listed tracks as playlist", run: func(m *Model) tea.Cmd {
+			tracks := m.libraryView.ActionTracks()
+			if len(tracks) == 0 {
+				return m.toast.Notify("No tracks listed to export", components.LevelWarning)
+			}
+			return func() tea.Msg { return views.SavePlaylistMsg{Tracks: tracks} }
+		}},
+	)
+}
+
+// paletteRows is how many commands the palette lists at once
+const paletteRows = 10
+
+// commandPalette is the open command palette: what's typed and the
+// commands it matches, best first
+type commandPalette struct {
+	input    components.SearchInput
+	commands []paletteCommand
+	matches  []paletteCommand
+	selected int
+}
+
+// openPalette opens the command palette with every command listed
+func (m *Model) openPalette() {
+	input := components.NewSearchInput(paletteWidth(m.width) - 4)
+	input.SetTheme(m.theme)
+	input.Prompt = ": "
+	input.Placeholder = "Type a command..."
+	input.Style, input.FocusStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
+	input.Focus()
+	m.palette = &commandPalette{input: input, commands: paletteCommands()}
+	m.palette.filter()
+}
+
+// filter lists the commands matching the query, best match first and
+// otherwise in their usual order
+func (p *commandPalette) filter() {
+	query := strings.TrimSpace(p.input.Value)
+	p.selected = 0
+	if query == "" {
+		p.matches = p.commands
+		return
+	}
+	scores := make(map[string]int)
+	p.matches = nil
+	for _, c := range p.commands {
+		if score, ok := views.FuzzyScore(query, c.name); ok {
+			scores[c.name] = score
+			p.matches = append(p.matches, c)
+		}
+	}
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		return scores[p.matches[i].name] > scores[p.matches[j].name]
+	})
+}
+
+// updatePalette handles a key while the palette is open: typing filters
+// it, up and down choose, Enter runs the chosen command and Esc closes it
+func (m *Model) updatePalette(msg tea.KeyMsg) tea.Cmd {
+	p := m.palette
+	switch msg.String() {
+	case "esc":
+		m.palette = nil
+	case "enter":
+		m.palette = nil
+		if len(p.matches) == 0 {
+			return nil
+		}
+		if c := p.matches[p.selected]; c.run != nil {
+			return c.run(m)
+		}
+		return m.runAction(p.matches[p.selected].action)
+	case "up", "ctrl+p":
+		p.selected = max(p.selected-1, 0)
+	case "down", "ctrl+n":
+		p.selected = max(min(p.selected+1, len(p.matches)-1), 0)
+	default:
+		p.input, _ = p.input.Update(msg)
+		p.filter()
+	}
+	return nil
+}
+
+// paletteWidth is how wide the palette is drawn on a screen width wide
+func paletteWidth(width int) int {
+	return max(min(60, width-4), 24)
+}
+
+// paletteView renders the palette: the query, then the matching commands
+// around the chosen one with their keys
+func (m *Model) paletteView() string {
+	p := m.palette
+	width := paletteWidth(m.width)
+	inner := width - 4 // Border and padding
+
+	lines := []string{p.input.View(), ""}
+	dim := lipgloss.NewStyle().Foreground(m.theme.Muted)
+	if len(p.matches) == 0 {
+		lines = append(lines, dim.Render("No matching commands"))
+	}
+	first := min(max(p.selected-paletteRows/2, 0), max(len(p.matches)-paletteRows, 0))
+	for i := first; i < min(first+paletteRows, len(p.matches)); i++ {
+		c := p.matches[i]
+		keys := ""
+		if c.run == nil {
+			keys = strings.Join(m.keys.Keys(c.action), " ")
+		}
+		name := ansi.Truncate(c.name, max(inner-lipgloss.Width(keys)-1, 1), "…")
+		gap := strings.Repeat(" ", max(inner-lipgloss.Width(name)-lipgloss.Width(keys), 1))
+		if i == p.selected {
+			selected := lipgloss.NewStyle().Foreground(m.theme.SelectedFg).Background(m.theme.SelectedBg)
+			lines = append(lines, selected.Render(name+gap+keys))
+			continue
+		}
+		lines = append(lines, name+gap+dim.Render(keys))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Border).
+		Padding(0, 1).
+		Width(width - 2).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,37 @@
+package ui
+
+import "testing"
+
+func TestPaletteCommands_NameEveryAction(t *testing.T) {
+	for _, b := range defaultBindings {
+		if _, ok := actionNames[b.action]; !ok && b.action != ActionPalette {
+			t.Errorf("action %s has no palette name", b.action)
+		}
+	}
+}
+
+func TestCommandPalette_Filter(t *testing.T) {
+	p := &commandPalette{commands: paletteCommands()}
+	p.filter()
+	if len(p.matches) != len(p.commands) {
+		t.Errorf("empty query lists %d of %d commands", len(p.matches), len(p.commands))
+	}
+
+	p.input.SetValue("rep one")
+	p.filter()
+	if len(p.matches) == 0 || p.matches[0].name != "Repeat one" {
+		t.Errorf("best match for %q = %v, want Repeat one", p.input.Value, p.matches)
+	}
+
+	p.input.SetValue("shufle")
+	p.filter()
+	if len(p.matches) == 0 || p.matches[0].action != ActionShuffle {
+		t.Errorf("best match for %q = %v, want the shuffle action", p.input.Value, p.matches)
+	}
+
+	p.input.SetValue("zzz")
+	p.filter()
+	if len(p.matches) != 0 {
+		t.Errorf("%q matched %v", p.input.Value, p.matches)
+	}
+}
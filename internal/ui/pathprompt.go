@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// pathPrompt asks for a path on the status line, handing what was typed
+// to enter
+type pathPrompt struct {
+	input components.SearchInput
+	enter func(m *Model, typed string) tea.Cmd
+}
+
+// promptPath starts asking for a path, filled in with value
+func (m *Model) promptPath(prompt, value string, enter func(m *Model, typed string) tea.Cmd) {
+	input := components.NewSearchInput(max(m.width-2, 20))
+	input.SetTheme(m.theme)
+	input.Prompt = prompt
+	input.SetValue(value)
+	input.Style, input.FocusStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
+	input.Focus()
+	m.asking = &pathPrompt{input: input, enter: enter}
+}
+
+// updatePathPrompt edits the path being entered. Enter hands it over; Esc
+// drops it.
+func (m *Model) updatePathPrompt(msg tea.KeyMsg) tea.Cmd {
+	prompt := m.asking
+	switch msg.String() {
+	case "esc":
+		m.asking = nil
+		return m.toast.Notify("Cancelled", components.LevelInfo)
+	case "enter":
+		m.asking = nil
+		return prompt.enter(m, prompt.input.Value)
+	}
+	prompt.input, _ = prompt.input.Update(msg)
+	return nil
+}
+
+// resolvePath turns a path as typed, with ~ for the home folder, into an
+// absolute one
+func resolvePath(typed string) (string, error) {
+	path := strings.TrimSpace(typed)
+	if path == "~" || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("find home folder: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", typed, err)
+	}
+	return abs, nil
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	playerrors "github.com/jscyril/golang_music_player/pkg/errors"
+)
+
+// unplayableAfter is how many times a track must fail to play before the
+// library marks it unplayable
+const unplayableAfter = 2
+
+// PlaybackErrorMsg is sent when a track couldn't be opened or decoded to
+// play or, with Preview, to preview
+type PlaybackErrorMsg struct {
+	Track   *api.Track
+	Err     error
+	Preview bool
+}
+
+// playbackFailed tells the user a track couldn't be played. A queued track
+// that fails is skipped, so one bad file doesn't stop the queue; once every
+// track in the queue has failed in a row, it stops there instead.
+func (m *Model) playbackFailed(msg PlaybackErrorMsg) tea.Cmd {
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	if msg.Track == nil {
+		return m.toast.Notify("Playback failed: "+failureReason(msg.Err), components.LevelError)
+	}
+	if msg.Preview {
+		return m.toast.Notify(fmt.Sprintf("Can't preview %s: %s", msg.Track.Title, failureReason(msg.Err)), components.LevelWarning)
+	}
+
+	m.playFailures[msg.Track.ID]++
+	if m.playFailures[msg.Track.ID] >= unplayableAfter {
+		m.libraryView.SetUnplayable(msg.Track, true)
+	}
+	notice := m.toast.Notify(fmt.Sprintf("Can't play %s: %s", msg.Track.Title, failureReason(msg.Err)), components.LevelError)
+
+	if current := m.queue.Current(); current == nil || current.ID != msg.Track.ID {
+		return notice
+	}
+	m.failedInRow++
+	if m.failedInRow >= m.queue.Len() {
+		logger.Warn("No track in the queue could be played")
+		m.failedInRow = 0
+		return notice
+	}
+	if next := m.queue.Skip(); next != nil {
+		logger.Info("Skipping unplayable %q for %q", msg.Track.Title, next.Title)
+		m.gapUntil = time.Time{}
+		m.audioEngine.Play(next)
+	}
+	m.refreshQueue()
+	return notice
+}
+
+// failureReason is why a track failed, without the track ID the engine's
+// errors carry
+func failureReason(err error) string {
+	var pe *playerrors.PlayerError
+	if errors.As(err, &pe) {
+		return pe.Op + ": " + pe.Err.Error()
+	}
+	if err == nil {
+		return "unknown error"
+	}
+	return err.Error()
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/playlist"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// editPlaylist makes an undoable change, labelled label, to the tracks of
+// the saved playlist id and shows the playlist as it now is
+func (m *Model) editPlaylist(id, label string, edit func() error) tea.Cmd {
+	pl, err := m.playlistManager.GetByID(id)
+	if err != nil {
+		return nil
+	}
+	saved := slices.Clone(pl.Tracks)
+	if err := edit(); err != nil {
+		logger.Warn("Edit playlist %s: %v", pl.Name, err)
+		return m.toast.Notify("Couldn't change "+pl.Name+": "+err.Error(), components.LevelError)
+	}
+	m.undo.push(label, func(m *Model) {
+		if err := m.playlistManager.SetTracks(id, saved); err != nil {
+			m.err = err
+		}
+		m.refreshPlaylists()
+	})
+	m.refreshPlaylists()
+	return nil
+}
+
+// addToPlaylist appends tracks to the saved playlist picked in the
+// playlist view: the one open there, or else the one highlighted
+func (m *Model) addToPlaylist(tracks []*api.Track) tea.Cmd {
+	pl := m.playlistView.SelectedPlaylist()
+	if pl == nil || playlist.IsSmartPlaylistID(pl.ID) {
+		return m.toast.Notify("Pick a saved playlist in the Playlist view first", components.LevelWarning)
+	}
+	cmd := m.editPlaylist(pl.ID, "adding to the playlist", func() error {
+		for _, track := range tracks {
+			if err := m.playlistManager.AddTrack(pl.ID, track); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if cmd != nil {
+		return cmd
+	}
+	if len(tracks) == 1 {
+		return m.toast.Notify("Added "+tracks[0].Title+" to "+pl.Name, components.LevelSuccess)
+	}
+	return m.toast.Notify(fmt.Sprintf("Added %d tracks to %s", len(tracks), pl.Name), components.LevelSuccess)
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// defaultPrelistenDelay is how long the selection rests on a track before
+// it is prelistened when no delay is set
+const defaultPrelistenDelay = time.Second
+
+// prelistenState follows the library's selection for prelistening: the
+// track selected, since when, and whether its preview has been started
+// and stopped. Each selection is prelistened at most once.
+type prelistenState struct {
+	track   *api.Track
+	since   time.Time
+	started bool
+	stopped bool
+}
+
+// prelistenSelected starts a quiet preview of the library's selected track
+// once the selection has rested on it long enough, and stops one whose
+// track is no longer selected. Being a preview, it leaves the queue,
+// scrobbling and play counts alone and playback resumes after it.
+func (m *Model) prelistenSelected(now time.Time) {
+	if !m.opts.Prelisten {
+		return
+	}
+	var track *api.Track
+	if m.activeView == ViewLibrary && !m.libraryView.Browsing && m.palette == nil && !m.eqOpen && m.outputs == nil {
+		track = m.libraryView.SelectedTrack()
+	}
+	p := &m.prelisten
+	if track != p.track {
+		m.stopPrelisten()
+		*p = prelistenState{track: track, since: now}
+		return
+	}
+	delay := m.opts.PrelistenDelay
+	if delay <= 0 {
+		delay = defaultPrelistenDelay
+	}
+	if track == nil || track.IsStream() || p.started || now.Sub(p.since) < delay {
+		return
+	}
+	// A preview asked for with v isn't cut short
+	if m.audioEngine.GetState().PreviewTrack != nil {
+		return
+	}
+	p.started = true
+	m.audioEngine.Preview(track, m.previewOffset, m.previewLength, m.opts.PrelistenVolume)
+}
+
+// prelistenMoved stops the prelisten as soon as the selection moves off
+// its track, without waiting for the next tick to notice
+func (m *Model) prelistenMoved() {
+	if m.prelisten.started && m.libraryView.SelectedTrack() != m.prelisten.track {
+		m.stopPrelisten()
+	}
+}
+
+// playsTrack reports whether action starts or stops main playback, which
+// a prelisten gives way to
+func playsTrack(action Action) bool {
+	switch action {
+	case ActionPlaySelected, ActionPlayTrack, ActionNext, ActionPrevious, ActionResume, ActionStop:
+		return true
+	}
+	return false
+}
+
+// stopPrelisten ends the prelisten if it is still playing, resuming
+// whatever it paused. The track isn't prelistened again until the
+// selection moves off it and back.
+func (m *Model) stopPrelisten() {
+	p := &m.prelisten
+	if !p.started || p.stopped {
+		return
+	}
+	p.stopped = true
+	if preview := m.audioEngine.GetState().PreviewTrack; preview != nil && preview.ID == p.track.ID {
+		m.audioEngine.StopPreview()
+	}
+}
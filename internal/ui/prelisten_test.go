@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestPrelisten_FollowsTheSelection(t *testing.T) {
+	lib := library.NewLibrary()
+	a := &api.Track{ID: "a", Title: "A", FilePath: "/music/a.mp3"}
+	b := &api.Track{ID: "b", Title: "B", FilePath: "/music/b.mp3"}
+	lib.AddTrack(a)
+	lib.AddTrack(b)
+	m, engine := newTestModel(t, lib, Options{Prelisten: true, PrelistenDelay: time.Second})
+	m.libraryView.SetTracks([]*api.Track{a, b})
+	previewing := func() *api.Track { return engine.GetState().PreviewTrack }
+
+	now := time.Now()
+	m.prelistenSelected(now)
+	m.prelistenSelected(now.Add(500 * time.Millisecond))
+	if previewing() != nil {
+		t.Fatal("prelistened before the selection had rested")
+	}
+	m.prelistenSelected(now.Add(time.Second))
+	if got := previewing(); got != a {
+		t.Fatalf("prelistening %v, want the selected track", got)
+	}
+
+	// Keys that neither move the selection nor play leave it playing
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	*m = next.(Model)
+	if previewing() != a {
+		t.Error("prelisten stopped by the volume key")
+	}
+
+	// Moving on stops it, and the next track gets its turn
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	*m = next.(Model)
+	if previewing() != nil {
+		t.Error("prelisten kept playing after the selection moved")
+	}
+	m.prelistenSelected(now.Add(2 * time.Second))
+	m.prelistenSelected(now.Add(3 * time.Second))
+	if got := previewing(); got != b {
+		t.Errorf("prelistening %v after moving down, want b", got)
+	}
+	m.stopPrelisten()
+	m.prelistenSelected(now.Add(5 * time.Second))
+	if previewing() != nil {
+		t.Error("a stopped prelisten started again")
+	}
+	if len(engine.Played) != 0 {
+		t.Errorf("prelistening played %d tracks", len(engine.Played))
+	}
+}
+
+func TestPrelisten_SpacePausesIt(t *testing.T) {
+	lib := library.NewLibrary()
+	a := &api.Track{ID: "a", Title: "A", FilePath: "/music/a.mp3"}
+	lib.AddTrack(a)
+	m, engine := newTestModel(t, lib, Options{Prelisten: true, PrelistenDelay: time.Second})
+	m.libraryView.SetTracks([]*api.Track{a})
+
+	now := time.Now()
+	m.prelistenSelected(now)
+	m.prelistenSelected(now.Add(time.Second))
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	*m = next.(Model)
+	state := engine.GetState()
+	if state.PreviewTrack != a || !state.Preview.Paused {
+		t.Fatalf("after Space: previewing %v, paused %v; want a paused", state.PreviewTrack, state.Preview.Paused)
+	}
+	if len(engine.Played) != 0 || state.Status != api.StatusStopped {
+		t.Errorf("Space touched main playback: played %d, status %v", len(engine.Played), state.Status)
+	}
+	m.playerView.SetState(m.playbackState(state))
+	if footer := m.playerView.FooterView(); !strings.Contains(footer, "Resume") {
+		t.Errorf("footer doesn't offer to resume the preview:\n%s", footer)
+	}
+}
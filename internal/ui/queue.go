@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+// refreshQueue shows the queue's current order in the queue view and the
+// up next line. The engine picks up a changed next track on the next tick.
+func (m *Model) refreshQueue() {
+	m.queueView.SetQueue(m.queue.GetAll(), m.queue.Index())
+	m.playerView.UpNext = m.queue.PeekNext()
+	m.playerView.UpNextPinned = m.queue.Pinned() != nil
+}
+
+// enqueue appends tracks to the queue without touching playback. With
+// nothing queued yet, the first of them becomes current, ready for Space.
+func (m *Model) enqueue(tracks []*api.Track) tea.Cmd {
+	m.undoable("queueing")
+	m.queue.Add(tracks...)
+	m.refreshQueue()
+	if len(tracks) == 1 {
+		return m.toast.Notify("Queued "+tracks[0].Title, components.LevelSuccess)
+	}
+	return m.toast.Notify(fmt.Sprintf("Queued %d tracks", len(tracks)), components.LevelSuccess)
+}
+
+// playingQueued reports whether the queue's current track is the one the
+// engine is playing or paused on
+func (m *Model) playingQueued() bool {
+	state := m.audioEngine.GetState()
+	current := m.queue.Current()
+	return current != nil && state.CurrentTrack != nil && state.CurrentTrack.ID == current.ID &&
+		(state.Status == api.StatusPlaying || state.Status == api.StatusPaused)
+}
+
+// removeQueued takes a track out of the queue. The playing track stays, as
+// removing it would make the queue lose its place.
+func (m *Model) removeQueued(msg views.QueueRemoveMsg) tea.Cmd {
+	if msg.Index == m.queue.Index() && m.playingQueued() {
+		return m.toast.Notify("Can't remove the playing track", components.LevelWarning)
+	}
+	m.undoable("removal from the queue")
+	if err := m.queue.Remove(msg.Index); err != nil {
+		m.undo.pop() // Nothing was removed
+		return nil
+	}
+	m.refreshQueue()
+	return nil
+}
+
+// playQueued jumps to the queued track at index and plays it
+func (m *Model) playQueued(index int) {
+	if m.queue.JumpTo(index) != nil {
+		return
+	}
+	m.gapUntil = time.Time{}
+	m.audioEngine.Play(m.queue.Current())
+	m.refreshQueue()
+}
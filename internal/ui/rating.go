@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// ratings are the stars each rating action gives
+var ratings = map[Action]int{
+	ActionRate1:     1,
+	ActionRate2:     2,
+	ActionRate3:     3,
+	ActionRate4:     4,
+	ActionRate5:     5,
+	ActionRateClear: 0,
+}
+
+// rate gives track stars, 0 clearing its rating, and says so
+func (m *Model) rate(track *api.Track, stars int) tea.Cmd {
+	if track == nil {
+		return nil
+	}
+	if err := m.library.SetRating(track, stars); err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	if stars == 0 {
+		return m.toast.Notify(fmt.Sprintf("Cleared the rating of %s", track.Title), components.LevelInfo)
+	}
+	return m.toast.Notify(fmt.Sprintf("Rated %s %s", track.Title, components.Stars(stars)), components.LevelSuccess)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// resumeOffer is a saved position the playing track can jump back to
+type resumeOffer struct {
+	filePath string
+	at       time.Duration
+}
+
+// trackPosition remembers how far the playing track has got and, when a
+// different track starts, offers to resume it where it was last left off
+func (m *Model) trackPosition(state *api.PlaybackState) tea.Cmd {
+	track := state.CurrentTrack
+	if m.positions == nil || track == nil || state.Status == api.StatusStopped {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	if track.FilePath != m.playingPath {
+		m.playingPath = track.FilePath
+		m.resumeOffer = nil
+		// Check before recording, so the new play doesn't replace the offer
+		if at, ok := m.positions.Get(track.FilePath); ok && at > state.Position {
+			m.resumeOffer = &resumeOffer{filePath: track.FilePath, at: at}
+			cmd = m.toast.Notify(fmt.Sprintf("Press R to resume at %s", at.Round(time.Second)), components.LevelInfo)
+		}
+	}
+	if state.Status == api.StatusPlaying {
+		m.positions.Set(track.FilePath, state.Position, track.Duration, time.Now())
+	}
+	return cmd
+}
+
+// resume seeks the playing track to its offered position, if it has one
+func (m *Model) resume() tea.Cmd {
+	offer := m.resumeOffer
+	state := m.audioEngine.GetState()
+	if offer == nil || state.CurrentTrack == nil || state.CurrentTrack.FilePath != offer.filePath {
+		return nil
+	}
+	m.resumeOffer = nil
+	m.audioEngine.Seek(offer.at)
+	return m.toast.Notify(fmt.Sprintf("Resumed at %s", offer.at.Round(time.Second)), components.LevelSuccess)
+}
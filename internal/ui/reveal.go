@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/desktop"
+)
+
+// FolderRevealedMsg is sent once the file manager has been asked to show a
+// track's file
+type FolderRevealedMsg struct {
+	Path string
+	Err  error
+}
+
+// PathCopiedMsg is sent once a track's path has been copied to the
+// clipboard
+type PathCopiedMsg struct {
+	Path string
+	Err  error
+}
+
+// selectedFile returns the track the reveal and copy actions apply to: the
+// selected one in a list view, or the playing one in the player view
+func (m *Model) selectedFile() *api.Track {
+	switch m.activeView {
+	case ViewPlayer:
+		return m.audioEngine.GetState().CurrentTrack
+	case ViewQueue:
+		return m.queueView.TrackList.SelectedItem()
+	}
+	track, _ := m.selectionContext()
+	return track
+}
+
+// revealCmd shows path in the file manager off the UI goroutine, as the
+// file manager may take a while to start
+func revealCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		return FolderRevealedMsg{Path: path, Err: desktop.Reveal(path)}
+	}
+}
+
+// copyPathCmd copies path to the clipboard off the UI goroutine
+func copyPathCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		return PathCopiedMsg{Path: path, Err: desktop.CopyToClipboard(path)}
+	}
+}
+
+// folderName is how a revealed file's folder is named in messages
+func folderName(path string) string {
+	return filepath.Base(filepath.Dir(path))
+}
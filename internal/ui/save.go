@@ -0,0 +1,31 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// SaveFailedMsg is sent when a store saved in the background couldn't be
+// written; what names it for the notice
+type SaveFailedMsg struct {
+	What string
+	Err  error
+}
+
+// saveCmd runs save off the UI goroutine, so writing a store never holds
+// up the interface; only a failure is reported back
+func saveCmd(what string, save func() error) tea.Cmd {
+	return func() tea.Msg {
+		if err := save(); err != nil {
+			return SaveFailedMsg{What: what, Err: err}
+		}
+		return nil
+	}
+}
+
+// saveFailed reports a background save that failed
+func (m *Model) saveFailed(msg SaveFailedMsg) tea.Cmd {
+	logger.Warn("Failed to save %s: %v", msg.What, msg.Err)
+	return m.toast.Notify("Couldn't save "+msg.What+": "+msg.Err.Error(), components.LevelError)
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/scrobble"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// ScrobbleNoticeMsg is sent when the scrobbler pauses a service or logs
+// in to one
+type ScrobbleNoticeMsg struct {
+	Notice scrobble.Notice
+}
+
+// trackPlay follows the playing track for play counts and scrobbling: it
+// is announced as now playing once heard, and counted and queued as a
+// scrobble once it has played long enough. The scrobbler submits both in
+// the background, as the play stats are saved.
+func (m *Model) trackPlay(state *api.PlaybackState) tea.Cmd {
+	started, listened := m.plays.Update(state.CurrentTrack, state.Position, state.Status == api.StatusPlaying, time.Now())
+	var cmd tea.Cmd
+	if listened {
+		cmd = m.recordPlay(state.CurrentTrack)
+	}
+	scrobbler := m.opts.Scrobbler
+	if scrobbler == nil {
+		return cmd
+	}
+	if started {
+		scrobbler.NowPlaying(m.plays.Listen())
+	}
+	if listened {
+		scrobbler.Scrobble(m.plays.Listen())
+	}
+	return cmd
+}
+
+// listenScrobbler waits for the scrobbler's next notice
+func (m Model) listenScrobbler() tea.Cmd {
+	scrobbler := m.opts.Scrobbler
+	if scrobbler == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case n := <-scrobbler.Notices():
+			return ScrobbleNoticeMsg{Notice: n}
+		case <-m.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// scrobbleNotice tells the user a service was paused, whose listens wait
+// for the next start, and hands on new session keys to be saved
+func (m *Model) scrobbleNotice(n scrobble.Notice) tea.Cmd {
+	if n.SessionKey != "" && m.opts.OnScrobbleLogin != nil {
+		m.opts.OnScrobbleLogin(n.Service, n.SessionKey)
+	}
+	if n.Err == nil {
+		return nil
+	}
+	return m.toast.Notify(fmt.Sprintf("Scrobbling to %s paused: %v", n.Service, n.Err), components.LevelError)
+}
@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/atomicfile"
+)
+
+// sessionSaveInterval is how often the session is saved while running, so
+// a crash loses little of it
+const sessionSaveInterval = 30 * time.Second
+
+// Session is what the player was doing when it last ran, to pick up from
+// on the next launch: the view shown, the volume and modes, the queue with
+// where it had got to, and the track selected in the library. Tracks are
+// kept by file path; a shuffled queue is kept in its shuffled order along
+// with the order it unshuffles back to.
+type Session struct {
+	View       string        `json:"view"`
+	Volume     int           `json:"volume"` // Percent, kept while muted
+	Muted      bool          `json:"muted,omitempty"`
+	Shuffle    bool          `json:"shuffle,omitempty"`
+	Repeat     string        `json:"repeat,omitempty"`
+	Queue      []string      `json:"queue,omitempty"`      // In play order
+	Unshuffled []string      `json:"unshuffled,omitempty"` // Queue's order before it was shuffled
+	Index      int           `json:"index"`                // Current track within Queue
+	Position   time.Duration `json:"position,omitempty"`   // How far into the current track
+	Selected   string        `json:"selected,omitempty"`   // Track selected in the library
+	SavedAt    time.Time     `json:"saved_at"`
+}
+
+// viewNames are how views are named in a saved session
+var viewNames = [...]string{"player", "library", "playlist", "queue", "history", "browse"}
+
+func (v ViewType) String() string {
+	if int(v) < len(viewNames) {
+		return viewNames[v]
+	}
+	return "unknown"
+}
+
+// parseView parses a view name as String writes it
+func parseView(s string) (ViewType, bool) {
+	for i, name := range viewNames {
+		if s == name {
+			return ViewType(i), true
+		}
+	}
+	return ViewLibrary, false
+}
+
+// LoadSession reads the session saved at path. A missing file gives nil;
+// one that can't be read or parsed gives nil and an error saying why, to
+// start afresh with.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse session %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the session to path
+func (s Session) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	if err := atomicfile.WriteData(path, data); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	return nil
+}
+
+// session captures the player's state to save
+func (m *Model) session() Session {
+	queue := m.queue.Snapshot()
+	s := Session{
+		View:    m.activeView.String(),
+		Volume:  m.playerView.Volume.Percent,
+		Muted:   m.playerView.Volume.Muted,
+		Shuffle: queue.Shuffled(),
+		Repeat:  repeatModeName(m.queue.GetRepeatMode()),
+		Index:   max(queue.Index(), 0),
+		SavedAt: time.Now(),
+	}
+	for _, track := range queue.Tracks() {
+		s.Queue = append(s.Queue, track.FilePath)
+	}
+	for _, track := range queue.Original() {
+		s.Unshuffled = append(s.Unshuffled, track.FilePath)
+	}
+	state := m.audioEngine.GetState()
+	switch current := m.queue.Current(); {
+	case current == nil:
+	case state.CurrentTrack != nil && state.CurrentTrack.FilePath == current.FilePath && state.Status != api.StatusStopped:
+		s.Position = state.Position
+	case m.playerView.Cued != nil && m.playerView.Cued.FilePath == current.FilePath:
+		s.Position = m.playerView.CuedAt
+	}
+	if track := m.libraryView.SelectedTrack(); track != nil {
+		s.Selected = track.FilePath
+	}
+	return s
+}
+
+// restoreSession picks up where s left off. The queue comes back in the
+// same order, shuffled or not, cued at the track and position it was on
+// for Space to resume, unless tracks were queued at launch. Tracks no
+// longer in the library are left out.
+func (m *Model) restoreSession(s *Session, queued bool) {
+	// A track started from the command line shows in the player view
+	if view, ok := parseView(s.View); ok && !(queued && m.autoPlay) {
+		m.activeView = view
+		switch view {
+		case ViewQueue:
+			m.refreshQueue()
+		case ViewBrowse:
+			m.refreshBrowse()
+		}
+	}
+	m.playerView.Volume.SetVolume(s.Volume)
+	m.playerView.Volume.Muted = s.Muted
+	m.audioEngine.SetVolume(float64(m.playerView.Volume.Level()) / 100)
+	if mode, ok := ParseRepeatMode(s.Repeat); ok {
+		m.queue.SetRepeatMode(mode)
+	}
+
+	byPath := make(map[string]*api.Track)
+	for _, track := range m.library.GetAllTracks() {
+		byPath[track.FilePath] = track
+	}
+	if track := byPath[s.Selected]; track != nil {
+		m.libraryView.SelectTrack(track)
+	}
+	if queued {
+		return
+	}
+
+	var tracks []*api.Track
+	index := 0
+	for i, path := range s.Queue {
+		track := byPath[path]
+		if track == nil {
+			continue
+		}
+		if i == s.Index {
+			index = len(tracks)
+		}
+		tracks = append(tracks, track)
+	}
+	if len(tracks) == 0 {
+		return
+	}
+	var original []*api.Track
+	for _, path := range s.Unshuffled {
+		if track := byPath[path]; track != nil {
+			original = append(original, track)
+		}
+	}
+	if s.Shuffle && original != nil {
+		m.queue.SetShuffled(tracks, original, index)
+	} else {
+		m.queue.Set(tracks)
+		m.queue.JumpTo(index)
+		// Sessions saved before the unshuffled order was kept
+		if s.Shuffle {
+			m.queue.Shuffle()
+		}
+	}
+	if current := m.queue.Current(); current != nil && s.Index >= 0 && s.Index < len(s.Queue) && current.FilePath == s.Queue[s.Index] {
+		m.playerView.Cued, m.playerView.CuedAt = current, s.Position
+	}
+	m.refreshQueue()
+	m.status = fmt.Sprintf("Restored the queue (%d tracks). Press Space to resume.", len(tracks))
+}
+
+// saveSessionCmd saves the session off the UI goroutine when it is due
+func (m *Model) saveSessionCmd(now time.Time) tea.Cmd {
+	if m.opts.OnSessionSave == nil || now.Sub(m.sessionSaved) < sessionSaveInterval {
+		return nil
+	}
+	m.sessionSaved = now
+	s, save := m.session(), m.opts.OnSessionSave
+	return func() tea.Msg {
+		save(s)
+		return nil
+	}
+}
+
+// resumeCued starts the cued track at the position it was left at, when
+// it's the one Space is starting. It reports whether it did.
+func (m *Model) resumeCued(track *api.Track) bool {
+	cued := m.playerView.Cued
+	m.playerView.Cued = nil
+	if cued == nil || cued.FilePath != track.FilePath {
+		return false
+	}
+	m.audioEngine.Play(track)
+	if m.playerView.CuedAt > 0 {
+		m.audioEngine.Seek(m.playerView.CuedAt)
+	}
+	return true
+}
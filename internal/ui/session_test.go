@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestSession_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "session.json")
+	want := Session{
+		View:     ViewBrowse.String(),
+		Volume:   35,
+		Muted:    true,
+		Shuffle:  true,
+		Repeat:   repeatModeName(api.RepeatAll),
+		Queue:    []string{"/music/a.mp3", "/music/b.mp3"},
+		Index:    1,
+		Position: 83 * time.Second,
+		Selected: "/music/a.mp3",
+	}
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := LoadSession(path)
+	if err != nil || got == nil {
+		t.Fatalf("LoadSession = %v, %v", got, err)
+	}
+	if got.View != "browse" || got.Volume != 35 || !got.Muted || !got.Shuffle || got.Index != 1 ||
+		got.Position != want.Position || got.Selected != want.Selected || len(got.Queue) != 2 {
+		t.Errorf("loaded %+v, want %+v", *got, want)
+	}
+	if mode, ok := ParseRepeatMode(got.Repeat); !ok || repeatModeName(mode) != want.Repeat {
+		t.Errorf("repeat %q doesn't parse back", got.Repeat)
+	}
+	if view, ok := parseView(got.View); !ok || view != ViewBrowse {
+		t.Errorf("view %q parses to %v, %v", got.View, view, ok)
+	}
+}
+
+func TestLoadSession_MissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if s, err := LoadSession(filepath.Join(dir, "none.json")); s != nil || err != nil {
+		t.Errorf("missing session = %v, %v; want nil, nil", s, err)
+	}
+
+	path := filepath.Join(dir, "session.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := LoadSession(path); s != nil || err == nil {
+		t.Errorf("corrupt session = %v, %v; want nil and an error", s, err)
+	}
+}
+
+func TestParseView_Unknown(t *testing.T) {
+	if _, ok := parseView("visualizer"); ok {
+		t.Error("unknown view name parsed")
+	}
+}
+
+func TestSession_KeepsShuffledOrder(t *testing.T) {
+	lib := library.NewLibrary()
+	var tracks []*api.Track
+	for i := range 8 {
+		track := &api.Track{ID: string(rune('a' + i)), FilePath: "/music/" + string(rune('a'+i)) + ".mp3"}
+		lib.AddTrack(track)
+		tracks = append(tracks, track)
+	}
+	m, _ := newTestModel(t, lib, Options{})
+	m.queue.Set(tracks)
+	m.queue.JumpTo(3)
+	m.queue.Shuffle()
+	m.queue.Next()
+	saved := m.session()
+
+	restored, _ := newTestModel(t, lib, Options{Session: &saved})
+	if got, want := queuedIDs(restored), queuedIDs(m); !slices.Equal(got, want) {
+		t.Errorf("restored order = %v, want the shuffled order %v", got, want)
+	}
+	if restored.queue.Index() != m.queue.Index() || !restored.queue.IsShuffled() {
+		t.Errorf("restored at %d, shuffled %v; want %d, shuffled", restored.queue.Index(), restored.queue.IsShuffled(), m.queue.Index())
+	}
+	restored.queue.Unshuffle()
+	if got := queuedIDs(restored); got[0] != "a" || got[7] != "h" {
+		t.Errorf("unshuffled to %v, want the original order", got)
+	}
+}
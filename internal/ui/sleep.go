@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// sleepSteps are the sleep timer's countdowns, in the order T cycles
+// through them; after the last comes stopping at the end of the track
+var sleepSteps = []time.Duration{15 * time.Minute, 30 * time.Minute, 45 * time.Minute, time.Hour, 90 * time.Minute}
+
+// sleepFade is how long playback fades out for when the sleep timer runs
+// out
+const sleepFade = 10 * time.Second
+
+// sleepTimer pauses playback, or quits, at a time or at the end of the
+// playing track
+type sleepTimer struct {
+	until      time.Time // Zero unless counting down
+	afterTrack bool      // Stop when the playing track ends instead
+	step       int       // Index into sleepSteps; len(sleepSteps) for afterTrack
+	gen        int       // Current countdown, so ticks of a cancelled one are dropped
+}
+
+// active reports whether the timer is set
+func (s sleepTimer) active() bool {
+	return s.afterTrack || !s.until.IsZero()
+}
+
+// sleepTickMsg updates the sleep timer's countdown once a second
+type sleepTickMsg struct {
+	gen int
+}
+
+// sleepQuitMsg quits once the sleep timer's fade-out has finished
+type sleepQuitMsg struct{}
+
+// sleepTick schedules the next countdown update
+func (m *Model) sleepTick() tea.Cmd {
+	gen := m.sleep.gen
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return sleepTickMsg{gen: gen}
+	})
+}
+
+// cycleSleep moves the sleep timer on to its next setting: off, then each
+// of sleepSteps, then the end of the track, then off again
+func (m *Model) cycleSleep() tea.Cmd {
+	step := 0
+	if m.sleep.active() {
+		step = m.sleep.step + 1
+	}
+	m.sleep = sleepTimer{step: step, gen: m.sleep.gen + 1}
+	defer m.syncNextTrack()
+
+	switch {
+	case step < len(sleepSteps):
+		m.sleep.until = time.Now().Add(sleepSteps[step])
+		return tea.Batch(m.sleepTick(), m.toast.Notify(fmt.Sprintf("Sleep in %d min", int(sleepSteps[step].Minutes())), components.LevelInfo))
+	case step == len(sleepSteps):
+		m.sleep.afterTrack = true
+		return m.toast.Notify("Sleep after this track", components.LevelInfo)
+	}
+	return m.toast.Notify("Sleep timer off", components.LevelInfo)
+}
+
+// sleepTicked counts down, and at zero fades out and pauses
+func (m *Model) sleepTicked(msg sleepTickMsg) tea.Cmd {
+	if msg.gen != m.sleep.gen || m.sleep.until.IsZero() {
+		return nil
+	}
+	if time.Now().Before(m.sleep.until) {
+		return m.sleepTick()
+	}
+	m.audioEngine.FadeOutAndPause(sleepFade)
+	return m.sleepExpired(sleepFade)
+}
+
+// sleepExpired turns the timer off once playback has been stopped for it,
+// quitting after wait if configured to
+func (m *Model) sleepExpired(wait time.Duration) tea.Cmd {
+	logger.Info("Sleep timer expired")
+	m.sleep = sleepTimer{gen: m.sleep.gen + 1}
+	m.syncNextTrack()
+	if !m.opts.SleepQuit {
+		return m.toast.Notify("Sleep timer: paused", components.LevelInfo)
+	}
+	return tea.Tick(wait, func(time.Time) tea.Msg { return sleepQuitMsg{} })
+}
+
+// sleepStatus describes the running timer for the footer, or "" if none
+func (m *Model) sleepStatus() string {
+	switch {
+	case m.sleep.afterTrack:
+		return "☾ Sleep after this track · [T] Change"
+	case !m.sleep.until.IsZero():
+		left := max(time.Until(m.sleep.until), 0).Round(time.Second)
+		return fmt.Sprintf("☾ Sleep in %s · [T] Change", formatCountdown(left))
+	}
+	return ""
+}
+
+// formatCountdown formats d as m:ss, or h:mm:ss from an hour
+func formatCountdown(d time.Duration) string {
+	h, mins, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mins, s)
+	}
+	return fmt.Sprintf("%d:%02d", mins, s)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/playlist"
+)
+
+// refreshPlaylists lists the saved playlists in the playlist view followed
+// by the smart playlists, evaluated afresh over the library and play stats
+func (m *Model) refreshPlaylists() {
+	m.evaluateSmart(func(*playlist.SmartPlaylist) bool { return true })
+}
+
+// refreshPlayedPlaylists is refreshPlaylists after a play is counted,
+// evaluating again only the smart playlists that depend on plays
+func (m *Model) refreshPlayedPlaylists() {
+	if slices.ContainsFunc(m.smartPlaylists, (*playlist.SmartPlaylist).UsesPlays) {
+		m.evaluateSmart((*playlist.SmartPlaylist).UsesPlays)
+	}
+}
+
+// evaluateSmart evaluates again the smart playlists which reports true
+// for, keeps the last evaluation of the rest and lists them all after the
+// saved playlists
+func (m *Model) evaluateSmart(which func(*playlist.SmartPlaylist) bool) {
+	if len(m.smartLists) != len(m.smartPlaylists) {
+		m.smartLists = make([]*api.Playlist, len(m.smartPlaylists))
+	}
+	var stats playlist.TrackStats
+	if m.playStats != nil {
+		stats = func(track *api.Track) (int, time.Time) {
+			stat := m.playStats.Get(track.FilePath)
+			return stat.Count, stat.LastPlayed
+		}
+	}
+	var tracks []*api.Track
+	now := time.Now()
+	for i, smart := range m.smartPlaylists {
+		if m.smartLists[i] != nil && !which(smart) {
+			continue
+		}
+		if tracks == nil {
+			tracks = m.library.GetAllTracks()
+		}
+		m.smartLists[i] = smart.Playlist(tracks, stats, now)
+	}
+	m.playlistView.SetPlaylists(append(m.playlistManager.GetAll(), m.smartLists...))
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// defaultStatsFile is where the stats export prompt starts out
+const defaultStatsFile = "~/listening-stats.csv"
+
+// StatsExportedMsg is sent when the listening stats have been written to
+// Path, or with Err set when that failed
+type StatsExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// promptExportStats starts asking for the file to write the listening
+// stats to; its extension picks CSV or JSON
+func (m *Model) promptExportStats() tea.Cmd {
+	if m.playStats == nil {
+		return m.toast.Notify("Play counts aren't being kept", components.LevelInfo)
+	}
+	m.promptPath("Export stats to: ", defaultStatsFile, func(m *Model, typed string) tea.Cmd {
+		path, err := resolvePath(typed)
+		if err == nil {
+			_, err = library.FormatFromPath(path)
+		}
+		if err != nil {
+			return m.toast.Notify(err.Error(), components.LevelError)
+		}
+		return m.exportStatsCmd(path)
+	})
+	return nil
+}
+
+// exportStatsCmd sums up the plays as they are now and writes them to
+// path off the UI goroutine
+func (m *Model) exportStatsCmd(path string) tea.Cmd {
+	st := m.playStats.ListeningStats(m.library.GetAllTracks())
+	return func() tea.Msg {
+		return StatsExportedMsg{Path: path, Err: st.SaveStats(path)}
+	}
+}
+
+// statsExported says how the export went
+func (m *Model) statsExported(msg StatsExportedMsg) tea.Cmd {
+	if msg.Err != nil {
+		logger.Warn("Export stats to %s: %v", msg.Path, msg.Err)
+		return m.toast.Notify("Couldn't export stats: "+msg.Err.Error(), components.LevelError)
+	}
+	return m.toast.Notify(fmt.Sprintf("Exported listening stats to %s", msg.Path), components.LevelSuccess)
+}
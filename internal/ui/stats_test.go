@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestExportStats_WritesChosenFile(t *testing.T) {
+	lib := library.NewLibrary()
+	lib.AddTrack(&api.Track{ID: "a", Title: "Alpha", Artist: "Band", FilePath: "/music/a.mp3", Duration: time.Minute})
+	dir := t.TempDir()
+	stats := library.NewPlayStats(filepath.Join(dir, "plays.json"))
+	stats.Record("/music/a.mp3", time.Now())
+	stats.Record("/music/a.mp3", time.Now())
+	m, _ := newTestModel(t, lib, Options{PlayStats: stats})
+
+	m.promptExportStats()
+	if m.asking == nil || m.asking.input.Value != defaultStatsFile {
+		t.Fatalf("prompt not started with %s", defaultStatsFile)
+	}
+	path := filepath.Join(dir, "out", "stats.json")
+	cmd := m.asking.enter(m, path)
+	msg, ok := cmd().(StatsExportedMsg)
+	if !ok || msg.Err != nil || msg.Path != path {
+		t.Fatalf("export = %+v", msg)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got library.ListeningStats
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Totals.Plays != 2 || len(got.Artists) != 1 || got.Artists[0].Artist != "Band" {
+		t.Errorf("exported %+v", got)
+	}
+
+	if cmd := m.asking.enter(m, filepath.Join(dir, "stats.txt")); cmd != nil {
+		if _, ok := cmd().(StatsExportedMsg); ok {
+			t.Error("exported to a file with no known format")
+		}
+	}
+}
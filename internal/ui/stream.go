@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// StreamInfoMsg is sent when a radio stream names its station or what it
+// is playing
+type StreamInfoMsg struct {
+	Info audio.StreamInfo
+}
+
+// streamMeta is what a radio stream says about itself, shown in place of
+// its track's own fields. The track is the queue's and the engine's, so
+// it is never changed.
+type streamMeta struct {
+	url           string // The stream's, to tell whether it is playing
+	station       string
+	artist, title string
+}
+
+// show returns track as shown: a copy with the stream's station as its
+// album and its current title, when track is the stream
+func (s streamMeta) show(track *api.Track) *api.Track {
+	if track == nil || s.url == "" || track.URL != s.url {
+		return track
+	}
+	shown := *track
+	if s.station != "" {
+		shown.Album = s.station
+	}
+	if s.title != "" {
+		shown.Artist, shown.Title = s.artist, s.title
+	}
+	return &shown
+}
+
+// streamInfo keeps what a stream says about itself: the station becomes
+// the album, and a title such as "Artist - Title" is split as playlist
+// entries are. A new title playing is announced, and gets its own desktop
+// notification.
+func (m *Model) streamInfo(info audio.StreamInfo) tea.Cmd {
+	track := info.Track
+	if track == nil {
+		return nil
+	}
+	if info.Connected || m.stream.url != track.URL {
+		m.stream = streamMeta{url: track.URL}
+	}
+	if info.Station != "" {
+		m.stream.station = info.Station
+	}
+	if info.Title != "" {
+		if artist, title, ok := strings.Cut(info.Title, " - "); ok {
+			m.stream.artist, m.stream.title = strings.TrimSpace(artist), strings.TrimSpace(title)
+		} else {
+			m.stream.artist, m.stream.title = track.Artist, info.Title
+		}
+	}
+	current := m.audioEngine.GetState().CurrentTrack
+	if current == nil || current.URL != track.URL {
+		return nil
+	}
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	if info.Title == "" {
+		return nil
+	}
+	m.notice = trackNotice{}
+	return m.toast.Notify("♪ "+info.Title, components.LevelInfo)
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/audio"
+	"github.com/jscyril/golang_music_player/internal/library"
+)
+
+func TestStreamInfo_ShownWithoutChangingTheTrack(t *testing.T) {
+	m, engine := newTestModel(t, library.NewLibrary(), Options{})
+	radio := &api.Track{ID: "r", Title: "Radio", URL: "http://radio.example/live"}
+	engine.Play(radio)
+
+	m.streamInfo(audio.StreamInfo{Track: radio, Connected: true, Station: "Example FM"})
+	m.streamInfo(audio.StreamInfo{Track: radio, Title: "Band - Song"})
+	if radio.Title != "Radio" || radio.Artist != "" || radio.Album != "" {
+		t.Errorf("stream track changed to %+v", radio)
+	}
+	shown := m.playbackState(engine.GetState()).CurrentTrack
+	if shown.Artist != "Band" || shown.Title != "Song" || shown.Album != "Example FM" {
+		t.Errorf("shown %q by %q on %q", shown.Title, shown.Artist, shown.Album)
+	}
+
+	// Reconnecting forgets what the last connection said
+	m.streamInfo(audio.StreamInfo{Track: radio, Connected: true})
+	if shown := m.playbackState(engine.GetState()).CurrentTrack; shown.Title != "Radio" || shown.Album != "" {
+		t.Errorf("after reconnecting shown %q on %q", shown.Title, shown.Album)
+	}
+}
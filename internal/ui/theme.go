@@ -0,0 +1,49 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// setTheme restyles the tabs, headers, every view and the toasts in t's
+// colors; the next render draws everything in them
+func (m *Model) setTheme(t components.Theme) {
+	m.theme = t
+	m.tabStyle = lipgloss.NewStyle().
+		Padding(0, 2).
+		Foreground(t.Muted)
+	m.activeTabStyle = lipgloss.NewStyle().
+		Padding(0, 2).
+		Bold(true).
+		Foreground(t.Accent).
+		Background(t.Surface)
+	m.headerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent).
+		MarginBottom(1)
+
+	m.playerView.SetTheme(t)
+	m.libraryView.SetTheme(t)
+	m.playlistView.SetTheme(t)
+	m.queueView.SetTheme(t)
+	m.historyView.SetTheme(t)
+	m.browseView.SetTheme(t)
+	m.toast.SetTheme(t)
+	if m.naming != nil {
+		m.naming.input.SetTheme(t)
+	}
+	if m.asking != nil {
+		m.asking.input.SetTheme(t)
+	}
+}
+
+// cycleTheme switches to the next of the themes and saves the choice
+func (m *Model) cycleTheme() tea.Cmd {
+	theme := components.NextTheme(m.theme)
+	m.setTheme(theme)
+	if m.opts.OnThemeChange != nil {
+		m.opts.OnThemeChange(theme)
+	}
+	return m.toast.Notify("Theme: "+theme.Name, components.LevelInfo)
+}
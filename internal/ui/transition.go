@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+)
+
+// TrackAdvancedMsg is sent when the engine has moved on to the next track
+// by itself, gaplessly or with a crossfade, rather than stopping
+type TrackAdvancedMsg struct {
+	Track *api.Track
+}
+
+// syncNextTrack tells the engine which track follows the current one, so
+// that with gapless playback or a crossfade on it can be joined without a
+// gap, and otherwise is preloaded to start without delay. A track delay
+// keeps the engine stopping at the end of every track, as does the sleep
+// timer waiting for the end of this one. Reordering the queue or toggling
+// shuffle changes the track sent here on the next tick.
+func (m *Model) syncNextTrack() {
+	next := m.queue.PeekNext()
+	join := m.trackDelay <= 0 && !m.sleep.afterTrack
+	if next != m.nextSent || join != m.nextJoined {
+		m.audioEngine.SetNext(next, join)
+		m.nextSent, m.nextJoined = next, join
+	}
+}
+
+// trackAdvanced moves the queue along with the engine. If the queue
+// changed while the tracks were overlapping, so the engine moved on to
+// another track than the queue's next, the queue follows the engine to it
+// when it is still queued; otherwise the queue's next track is started.
+// The track that took over is announced, even when it is the same one.
+func (m *Model) trackAdvanced(msg TrackAdvancedMsg) {
+	m.notice = trackNotice{}
+	next := m.queue.Next()
+	if msg.Track != nil && (next == nil || next.ID != msg.Track.ID) {
+		if i := m.queuedIndex(msg.Track); i >= 0 {
+			m.queue.JumpTo(i)
+		} else if next != nil {
+			logger.Info("Engine moved on to %q, which is no longer queued; playing %q", msg.Track.Title, next.Title)
+			m.gapUntil = time.Time{}
+			m.audioEngine.Play(next)
+		}
+		m.refreshQueue()
+	}
+	// The engine forgets the next track once it has moved on
+	m.nextSent = nil
+	m.syncNextTrack()
+	m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	m.playerView.UpNext = m.queue.PeekNext()
+}
+
+// queuedIndex returns where track is in the queue, looking from the
+// current track on before looking behind it, or -1 if it isn't queued
+func (m *Model) queuedIndex(track *api.Track) int {
+	tracks := m.queue.GetAll()
+	start := max(m.queue.Index(), 0)
+	for n := range tracks {
+		i := (start + n) % len(tracks)
+		if tracks[i].ID == track.ID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+// undoLimit is how many actions back undo reaches
+const undoLimit = 20
+
+// undoStep reverses one action: what was done, to say what was undone,
+// and how to put things back
+type undoStep struct {
+	label string
+	undo  func(m *Model)
+}
+
+// undoStack keeps the latest reversible actions, newest last. Only the
+// queue, shuffle, library sort and saved playlists' tracks are kept;
+// anything touching files asks for confirmation first instead.
+type undoStack struct {
+	steps []undoStep
+}
+
+// push remembers a step, forgetting the oldest past undoLimit
+func (s *undoStack) push(label string, undo func(m *Model)) {
+	if len(s.steps) == undoLimit {
+		s.steps = s.steps[1:]
+	}
+	s.steps = append(s.steps, undoStep{label: label, undo: undo})
+}
+
+// pop takes the newest step, if there is one
+func (s *undoStack) pop() (undoStep, bool) {
+	if len(s.steps) == 0 {
+		return undoStep{}, false
+	}
+	step := s.steps[len(s.steps)-1]
+	s.steps = s.steps[:len(s.steps)-1]
+	return step, true
+}
+
+// undoable remembers the queue as it is, before an action labelled label
+// changes it
+func (m *Model) undoable(label string) {
+	saved := m.queue.Snapshot()
+	m.undo.push(label, func(m *Model) {
+		m.queue.Restore(saved)
+		m.refreshQueue()
+		m.playerView.SetState(m.playbackState(m.audioEngine.GetState()))
+	})
+}
+
+// sortChanged remembers the library's sort from before a key changed it,
+// if it did
+func (m *Model) sortChanged(field views.SortField, desc bool) {
+	if m.libraryView.SortField == field && m.libraryView.SortDesc == desc {
+		return
+	}
+	m.undo.push("sort change", func(m *Model) {
+		m.libraryView.SortField, m.libraryView.SortDesc = field, desc
+		m.libraryView.Refresh()
+	})
+}
+
+// undoLast reverses the latest reversible action and says which it was
+func (m *Model) undoLast() tea.Cmd {
+	step, ok := m.undo.pop()
+	if !ok {
+		return m.toast.Notify("Nothing to undo", components.LevelInfo)
+	}
+	step.undo(m)
+	return m.toast.Notify("Undid "+step.label, components.LevelInfo)
+}
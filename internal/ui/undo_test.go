@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
+	"github.com/jscyril/golang_music_player/internal/ui/views"
+)
+
+func queuedIDs(m *Model) []string {
+	var ids []string
+	for _, track := range m.queue.GetAll() {
+		ids = append(ids, track.ID)
+	}
+	return ids
+}
+
+func TestUndo_QueueEditsInReverse(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	tracks := []*api.Track{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}, {ID: "c", Title: "C"}}
+	m.enqueue(tracks)
+
+	next, _ := m.Update(views.QueueMoveMsg{From: 0, To: 2})
+	*m = next.(Model)
+	m.removeQueued(views.QueueRemoveMsg{Index: 0})
+	if got := queuedIDs(m); len(got) != 2 || got[0] != "c" || got[1] != "a" {
+		t.Fatalf("queue after edits = %v, want c a", got)
+	}
+
+	m.undoLast()
+	if got := queuedIDs(m); len(got) != 3 || got[0] != "b" || got[2] != "a" {
+		t.Errorf("after undoing the removal = %v, want b c a", got)
+	}
+	m.undoLast()
+	if got := queuedIDs(m); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("after undoing the move = %v, want a b c", got)
+	}
+	m.undoLast()
+	if m.queue.Len() != 0 {
+		t.Errorf("after undoing the queueing = %v, want empty", queuedIDs(m))
+	}
+	if _, ok := m.undo.pop(); ok {
+		t.Error("steps left after undoing everything")
+	}
+}
+
+func TestUndo_FailedEditLeavesNoStep(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	m.removeQueued(views.QueueRemoveMsg{Index: 3})
+	if len(m.undo.steps) != 0 {
+		t.Errorf("removing from an empty queue left %d undo steps", len(m.undo.steps))
+	}
+}
+
+func TestUndo_Shuffle(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	tracks := make([]*api.Track, 20)
+	for i := range tracks {
+		tracks[i] = &api.Track{ID: string(rune('a' + i))}
+	}
+	m.queue.Set(tracks)
+	m.runAction(ActionShuffle)
+	if !m.queue.IsShuffled() {
+		t.Fatal("shuffle didn't turn on")
+	}
+	m.runAction(ActionUndo)
+	if m.queue.IsShuffled() {
+		t.Error("undo left the queue shuffled")
+	}
+	for i, id := range queuedIDs(m) {
+		if id != tracks[i].ID {
+			t.Fatalf("queue after undo = %v, want the order it was queued in", queuedIDs(m))
+		}
+	}
+}
+
+func TestUndoStack_Bounded(t *testing.T) {
+	var s undoStack
+	for i := range undoLimit + 5 {
+		s.push(string(rune('a'+i)), func(*Model) {})
+	}
+	if len(s.steps) != undoLimit {
+		t.Fatalf("kept %d steps, want %d", len(s.steps), undoLimit)
+	}
+	if s.steps[0].label != string(rune('a'+5)) {
+		t.Errorf("oldest kept step = %q, want the oldest five dropped", s.steps[0].label)
+	}
+}
+
+func playlistIDs(t *testing.T, m *Model, id string) []string {
+	t.Helper()
+	pl, err := m.playlistManager.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, track := range pl.Tracks {
+		ids = append(ids, track.ID)
+	}
+	return ids
+}
+
+func TestUndo_PlaylistEdits(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	pl, err := m.playlistManager.Create("Mix", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.refreshPlaylists()
+	m.playlistView.SetCurrentPlaylist(m.playlistView.Playlists[0])
+
+	m.addToPlaylist([]*api.Track{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+	for _, msg := range []any{views.PlaylistMoveMsg{PlaylistID: pl.ID, From: 0, To: 2}, views.PlaylistRemoveMsg{PlaylistID: pl.ID, Index: 0}} {
+		next, _ := m.Update(msg)
+		*m = next.(Model)
+	}
+	if got := playlistIDs(t, m, pl.ID); len(got) != 2 || got[0] != "c" || got[1] != "a" {
+		t.Fatalf("playlist after edits = %v, want c a", got)
+	}
+	if got := m.playlistView.TrackList.Items; len(got) != 2 {
+		t.Errorf("playlist view lists %d tracks, want 2", len(got))
+	}
+
+	m.undoLast()
+	if got := playlistIDs(t, m, pl.ID); len(got) != 3 || got[0] != "b" {
+		t.Errorf("after undoing the removal = %v, want b c a", got)
+	}
+	m.undoLast()
+	if got := playlistIDs(t, m, pl.ID); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("after undoing the move = %v, want a b c", got)
+	}
+	m.undoLast()
+	if got := playlistIDs(t, m, pl.ID); len(got) != 0 {
+		t.Errorf("after undoing the adding = %v, want empty", got)
+	}
+}
+
+func TestUndo_QueueReplace(t *testing.T) {
+	m, _ := newTestModel(t, library.NewLibrary(), Options{})
+	m.queue.Set([]*api.Track{{ID: "a"}, {ID: "b"}})
+
+	next, _ := m.Update(views.QueueTracksMsg{Tracks: []*api.Track{{ID: "c"}}, Replace: true})
+	*m = next.(Model)
+	if m.confirm == nil {
+		t.Fatal("replacing a queue didn't ask first")
+	}
+	m.confirm.onYes(m)
+	if got := queuedIDs(m); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("queue after replacing = %v, want c", got)
+	}
+
+	m.undoLast()
+	if got := queuedIDs(m); len(got) != 2 || got[0] != "a" {
+		t.Errorf("after undoing the replace = %v, want a b", got)
+	}
+}
@@ -0,0 +1,312 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// browseLevel is how deep a BrowseView has drilled into the library
+type browseLevel int
+
+const (
+	browseArtists browseLevel = iota
+	browseAlbums              // The albums of one artist
+	browseTracks              // The tracks of one album
+)
+
+// browseEntry is an artist or album listed by a BrowseView: its group key
+// as groupKey gives it, the name shown and its tracks
+type browseEntry struct {
+	key    string
+	name   string
+	tracks []*api.Track
+}
+
+// browseColumns lay out an album's tracks
+var browseColumns = []components.Column{
+	{Field: components.ColumnTrackNumber, Width: 3, Align: components.AlignRight},
+	{Field: components.ColumnTitle},
+	{Field: components.ColumnDuration, Width: 5, Align: components.AlignRight},
+}
+
+// BrowseView drills down through the library: its artists, then one
+// artist's albums, then one album's tracks. Enter goes down a level and,
+// on a track, plays it; Esc goes back up to where the selection was.
+type BrowseView struct {
+	Width       int
+	Height      int
+	TrackList   components.TrackList // The current level, artists and albums as placeholder rows
+	Breadcrumb  components.Breadcrumb
+	BorderStyle lipgloss.Style
+	HelpStyle   lipgloss.Style
+
+	tracks  []*api.Track
+	level   browseLevel
+	artist  string // Key of the artist open below the artists level
+	album   string // Key of the album open at the tracks level
+	entries []browseEntry
+	above   []int // Selection of each level above this one, restored going back
+}
+
+// NewBrowseView creates a browse view at the list of artists
+func NewBrowseView(width, height int) BrowseView {
+	v := BrowseView{
+		Width:      width,
+		Height:     height,
+		TrackList:  components.NewTrackList(height-10, width-8),
+		Breadcrumb: components.NewBreadcrumb(width - 6),
+	}
+	v.TrackList.ShowNumbers = false
+	v.SetTheme(components.DefaultTheme)
+	v.show()
+	return v
+}
+
+// SetTheme styles the view, its list and its breadcrumb in t's colors
+func (v *BrowseView) SetTheme(t components.Theme) {
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.HelpStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+	v.TrackList.SetTheme(t)
+	v.Breadcrumb.SetTheme(t)
+}
+
+// SetSize resizes the view, its list and its breadcrumb
+func (v *BrowseView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	// Rows end in their counts, so they must fit inside the padding
+	// rather than trail off under it
+	v.TrackList.Width = width - 8
+	v.TrackList.SetHeight(height - 10)
+	v.Breadcrumb.Width = width - 6
+}
+
+// SetTracks sets the library to browse. The open artist and album stay
+// open while they still have tracks, as does the selection where it can;
+// otherwise the view goes back up to the deepest level left.
+func (v *BrowseView) SetTracks(tracks []*api.Track) {
+	v.tracks = tracks
+	selected := v.TrackList.Selected
+	if v.level >= browseAlbums && v.find(v.artistEntries(), v.artist) == nil {
+		v.level, v.above = browseArtists, v.above[:0]
+	} else if v.level == browseTracks && v.find(v.albumEntries(), v.album) == nil {
+		v.level, v.above = browseAlbums, v.above[:1]
+	}
+	v.show()
+	v.TrackList.SetSelected(selected)
+}
+
+// Descend opens the selected artist or album. It reports false at the
+// tracks level, where Enter plays instead.
+func (v *BrowseView) Descend() bool {
+	if v.level == browseTracks {
+		return false
+	}
+	i := v.TrackList.Selected
+	if i < 0 || i >= len(v.entries) {
+		return true
+	}
+	if v.level == browseArtists {
+		v.artist = v.entries[i].key
+	} else {
+		v.album = v.entries[i].key
+	}
+	v.above = append(v.above, i)
+	v.level++
+	v.show()
+	return true
+}
+
+// Ascend goes back up a level, to the entry that was opened. It reports
+// false at the top.
+func (v *BrowseView) Ascend() bool {
+	if v.level == browseArtists {
+		return false
+	}
+	v.level--
+	selected := 0
+	if n := len(v.above); n > 0 {
+		selected, v.above = v.above[n-1], v.above[:n-1]
+	}
+	v.show()
+	v.TrackList.SetSelected(selected)
+	return true
+}
+
+// SelectedTrack returns the selected track at the tracks level, or nil
+// above it
+func (v *BrowseView) SelectedTrack() *api.Track {
+	if v.level != browseTracks {
+		return nil
+	}
+	return v.TrackList.SelectedItem()
+}
+
+// VisibleTracks returns the open album's tracks in album order, or nil
+// above the tracks level
+func (v *BrowseView) VisibleTracks() []*api.Track {
+	if v.level != browseTracks {
+		return nil
+	}
+	return v.TrackList.Items
+}
+
+// Update handles messages. Enter comes through the app, which plays a
+// track once there are no more levels to open.
+func (v BrowseView) Update(msg tea.Msg) (BrowseView, tea.Cmd) {
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		return v, listMouse(&v.TrackList, v.BorderStyle, 2, mouse)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc", "backspace":
+			v.Ascend()
+			return v, nil
+		}
+	}
+	v.TrackList, _ = v.TrackList.Update(msg)
+	return v, nil
+}
+
+// View renders the browse view
+func (v BrowseView) View() string {
+	var sb strings.Builder
+	sb.WriteString(v.Breadcrumb.View())
+	sb.WriteString("\n\n")
+	sb.WriteString(v.TrackList.View())
+	sb.WriteString("\n\n")
+	help := "[Enter] Open  [Esc] Back  [↑↓] Navigate"
+	switch v.level {
+	case browseArtists:
+		help = "[Enter] Open  [↑↓] Navigate"
+	case browseTracks:
+		help = "[Enter] Play  [Esc] Back  [↑↓] Navigate"
+	}
+	sb.WriteString(v.HelpStyle.Render(help))
+	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
+}
+
+// show lays out the current level in the list and the breadcrumb
+func (v *BrowseView) show() {
+	crumbs := []string{"Artists"}
+	switch v.level {
+	case browseArtists:
+		v.entries = v.artistEntries()
+		v.showEntries(fmt.Sprintf("🎤 Artists (%d)", len(v.entries)), func(e browseEntry) string {
+			return "  " + plural(len(browseEntries(e.tracks, GroupAlbum)), "album")
+		})
+	case browseAlbums:
+		artist := v.find(v.artistEntries(), v.artist)
+		v.entries = v.albumEntries()
+		crumbs = append(crumbs, artist.name)
+		v.showEntries(fmt.Sprintf("💿 %s (%d albums)", artist.name, len(v.entries)), func(e browseEntry) string {
+			count := plural(len(e.tracks), "track")
+			if year := e.tracks[0].Year; year > 0 {
+				return fmt.Sprintf("  %d · %s", year, count)
+			}
+			return "  " + count
+		})
+	case browseTracks:
+		artist := v.find(v.artistEntries(), v.artist)
+		album := v.find(v.albumEntries(), v.album)
+		v.entries = nil
+		crumbs = append(crumbs, artist.name, album.name)
+		v.TrackList.SetItems(album.tracks)
+		v.TrackList.SetColumns(browseColumns)
+		v.TrackList.RowSuffix = nil
+		v.TrackList.Title = fmt.Sprintf("🎵 %s (%s)", album.name, plural(len(album.tracks), "track"))
+	}
+	v.Breadcrumb.Labels = crumbs
+}
+
+// showEntries lists artists or albums as rows titled with their names,
+// each followed by suffix. The suffixes are worked out here, once per
+// listing, rather than each time a row is drawn.
+func (v *BrowseView) showEntries(title string, suffix func(browseEntry) string) {
+	rows := make([]*api.Track, len(v.entries))
+	suffixes := make(map[string]string, len(v.entries))
+	for i, e := range v.entries {
+		rows[i] = &api.Track{ID: "browse:" + e.key, Title: e.name}
+		suffixes[rows[i].ID] = suffix(e)
+	}
+	v.TrackList.SetItems(rows)
+	v.TrackList.SetColumns([]components.Column{{Field: components.ColumnTitle}})
+	v.TrackList.RowSuffix = func(row *api.Track) string {
+		return suffixes[row.ID]
+	}
+	v.TrackList.Title = title
+}
+
+// artistEntries groups the library by artist
+func (v *BrowseView) artistEntries() []browseEntry {
+	return browseEntries(v.tracks, GroupArtist)
+}
+
+// albumEntries groups the open artist's tracks by album
+func (v *BrowseView) albumEntries() []browseEntry {
+	artist := v.find(v.artistEntries(), v.artist)
+	if artist == nil {
+		return nil
+	}
+	return browseEntries(artist.tracks, GroupAlbum)
+}
+
+// find returns the entry with key, or nil if there is none
+func (v *BrowseView) find(entries []browseEntry, key string) *browseEntry {
+	for i := range entries {
+		if entries[i].key == key {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// browseEntries groups tracks by artist or album, named as tagged and
+// ordered by name with untagged tracks last. Each album's tracks are in
+// album order.
+func browseEntries(tracks []*api.Track, by GroupField) []browseEntry {
+	var entries []browseEntry
+	index := make(map[string]int)
+	for _, track := range tracks {
+		key, name, _ := groupKey(track, by)
+		i, seen := index[key]
+		if !seen {
+			i = len(entries)
+			index[key] = i
+			entries = append(entries, browseEntry{key: key, name: name})
+		}
+		entries[i].tracks = append(entries[i].tracks, track)
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if (entries[a].key == "") != (entries[b].key == "") {
+			return entries[b].key == ""
+		}
+		return lessFold(entries[a].name, entries[b].name)
+	})
+	if by == GroupAlbum {
+		for _, e := range entries {
+			sort.SliceStable(e.tracks, func(a, b int) bool {
+				return lessAlbumOrder(e.tracks[a], e.tracks[b])
+			})
+		}
+	}
+	return entries
+}
+
+// plural counts n things, as "1 album" or "3 albums"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
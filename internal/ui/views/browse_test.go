@@ -0,0 +1,98 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func browseLibrary() []*api.Track {
+	return []*api.Track{
+		{ID: "1", Title: "So What", Artist: "Miles Davis", Album: "Kind of Blue", TrackNum: 1, Year: 1959},
+		{ID: "2", Title: "Blue in Green", Artist: "Miles Davis", Album: "Kind of Blue", TrackNum: 3, Year: 1959},
+		{ID: "3", Title: "Freddie Freeloader", Artist: "Miles Davis", Album: "Kind of Blue", TrackNum: 2, Year: 1959},
+		{ID: "4", Title: "Walkin'", Artist: "Miles Davis", Album: "Walkin'"},
+		{ID: "5", Title: "Giant Steps", Artist: "John Coltrane", Album: "Giant Steps"},
+		{ID: "6", Title: "Mystery"},
+	}
+}
+
+// rowTitles returns the titles of the rows v lists
+func rowTitles(v BrowseView) []string {
+	var titles []string
+	for _, row := range v.TrackList.Items {
+		titles = append(titles, row.Title)
+	}
+	return titles
+}
+
+func TestBrowseView_DrillDown(t *testing.T) {
+	v := NewBrowseView(80, 30)
+	v.SetTracks(browseLibrary())
+
+	if got, want := strings.Join(rowTitles(v), ", "), "John Coltrane, Miles Davis, Unknown Artist"; got != want {
+		t.Fatalf("artists = %s, want %s", got, want)
+	}
+	if v.SelectedTrack() != nil || v.Ascend() {
+		t.Error("the artists level has no track to play and nothing above it")
+	}
+
+	v.TrackList.SetSelected(1)
+	if !v.Descend() {
+		t.Fatal("Descend on an artist reported false")
+	}
+	if got, want := strings.Join(rowTitles(v), ", "), "Kind of Blue, Walkin'"; got != want {
+		t.Fatalf("albums = %s, want %s", got, want)
+	}
+	if view := ansi.Strip(v.View()); !strings.Contains(view, "Artists › Miles Davis") || !strings.Contains(view, "1959 · 3 tracks") {
+		t.Errorf("albums view lacks the breadcrumb or album details:\n%s", view)
+	}
+
+	v.Descend()
+	if got, want := strings.Join(rowTitles(v), ", "), "So What, Freddie Freeloader, Blue in Green"; got != want {
+		t.Fatalf("tracks = %s, want %s in album order", got, want)
+	}
+	if v.Descend() {
+		t.Error("Descend on a track should leave Enter to play it")
+	}
+	if track := v.SelectedTrack(); track == nil || track.ID != "1" || len(v.VisibleTracks()) != 3 {
+		t.Errorf("selected %v of %d tracks, want the album's first of 3", track, len(v.VisibleTracks()))
+	}
+
+	// Esc goes back up to where the selection was
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if v.level != browseArtists || v.TrackList.Selected != 1 {
+		t.Errorf("after going back up: level %d, selected %d; want artists with Miles Davis", v.level, v.TrackList.Selected)
+	}
+}
+
+func TestBrowseView_SetTracksKeepsPath(t *testing.T) {
+	v := NewBrowseView(80, 30)
+	tracks := browseLibrary()
+	v.SetTracks(tracks)
+	v.TrackList.SetSelected(1)
+	v.Descend()
+	v.Descend()
+	v.TrackList.SetSelected(2)
+
+	v.SetTracks(tracks)
+	if v.level != browseTracks || v.TrackList.Selected != 2 {
+		t.Errorf("after a refresh: level %d, selected %d; want the same album and track", v.level, v.TrackList.Selected)
+	}
+
+	// With the album gone the view goes up to the artist's albums
+	v.SetTracks(tracks[3:])
+	if v.level != browseAlbums || strings.Join(rowTitles(v), ", ") != "Walkin'" {
+		t.Errorf("after the album went: level %d, rows %v", v.level, rowTitles(v))
+	}
+
+	// And with the artist gone, to the artists
+	v.SetTracks(tracks[4:])
+	if v.level != browseArtists || len(v.above) != 0 {
+		t.Errorf("after the artist went: level %d, %d levels above", v.level, len(v.above))
+	}
+}
@@ -0,0 +1,91 @@
+package views
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// The compact line's progress bar takes a third of the width, between
+// these bounds; with less than compactMinInfo cells left over for the
+// track it is dropped
+const (
+	compactBarMin  = 24
+	compactBarMax  = 40
+	compactMinInfo = 8
+)
+
+// CompactView renders the whole player as a single line width cells wide,
+// for the compact layout: the status icon, "Artist - Title" cut to fit,
+// then a short progress bar with the time. The bar shows neither chapter
+// titles nor a percentage, and gives up the time when it doesn't fit.
+func (v *PlayerView) CompactView(width int) string {
+	v.compactBarX = -1
+	width = max(width, 1)
+	if v.State == nil || (v.shownTrack() == nil && v.State.PreviewTrack == nil) {
+		return ansi.Truncate(v.AlbumStyle.Render("♪ No track playing"), width, "…")
+	}
+
+	icon, track := statusIcon(v.State.Status), v.shownTrack()
+	if v.State.PreviewTrack != nil {
+		icon, track = "🎧", v.State.PreviewTrack
+	}
+	info := track.Title
+	if track.Artist != "" {
+		info = track.Artist + " - " + info
+	}
+	prefix := v.StatusStyle.Render(icon + " ")
+
+	bar := v.ProgressBar
+	if v.State.PreviewTrack != nil {
+		bar = v.previewBar()
+	}
+	bar.ShowPercent = false
+	bar.Chapters = nil
+	bar.SetGlobal(0, 0)
+	bar.ClearHover()
+	bar.Style = lipgloss.NewStyle()
+	bar.Width = min(max(width/3, compactBarMin), compactBarMax)
+	barView := bar.View()
+
+	infoWidth := width - lipgloss.Width(prefix) - 2 - lipgloss.Width(barView)
+	if infoWidth < compactMinInfo {
+		line := prefix + v.ArtistStyle.Render(ansi.Truncate(info, max(width-lipgloss.Width(prefix), 0), "…"))
+		return ansi.Truncate(line, width, "")
+	}
+	info = ansi.Truncate(info, infoWidth, "…")
+	line := prefix + v.ArtistStyle.Render(info)
+	pad := width - lipgloss.Width(barView) - lipgloss.Width(line)
+	v.compactBar = bar
+	v.compactBarX = lipgloss.Width(line) + pad
+	return line + strings.Repeat(" ", pad) + barView
+}
+
+// CompactSeek returns the position a click at column x of the last
+// CompactView seeks to. It reports false off the bar's cells, when no bar
+// was drawn or when the track can't be seeked.
+func (v *PlayerView) CompactSeek(x int) (time.Duration, bool) {
+	rel := x - v.compactBarX
+	if v.compactBarX < 0 || rel < 0 || rel >= v.compactBar.BarWidth() {
+		return 0, false
+	}
+	if _, ok := v.compactBar.ClickPercent(x, v.compactBarX); !ok {
+		return 0, false
+	}
+	return v.compactBar.HandleClick(x, v.compactBarX), true
+}
+
+// statusIcon is the icon shown before the playing track for status
+func statusIcon(status api.PlayerStatus) string {
+	switch status {
+	case api.StatusPlaying:
+		return "▶"
+	case api.StatusPaused:
+		return "⏸"
+	default:
+		return "⏹"
+	}
+}
@@ -0,0 +1,62 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestPlayerView_CompactView(t *testing.T) {
+	v := playingView(api.StatusPlaying)
+	v.State.CurrentTrack.Artist = "Some Artist"
+
+	line := ansi.Strip(v.CompactView(80))
+	if strings.Contains(line, "\n") || lipgloss.Width(line) != 80 {
+		t.Fatalf("compact view is not one full line: %q", line)
+	}
+	if !strings.HasPrefix(line, "▶ Some Artist - A") || !strings.HasSuffix(line, "00:30/03:20") {
+		t.Errorf("compact view = %q, want the status, track, bar and time", line)
+	}
+
+	// Every width fits, dropping the bar once the track wouldn't
+	for width := 0; width <= 120; width++ {
+		line := v.CompactView(width)
+		if w := lipgloss.Width(line); w > max(width, 1) || strings.Contains(line, "\n") {
+			t.Errorf("width %d: compact view is %d wide: %q", width, w, ansi.Strip(line))
+		}
+		if _, ok := v.CompactSeek(width - 1); width < 30 && ok {
+			t.Errorf("width %d: seek on a compact line without a bar", width)
+		}
+	}
+
+	empty := NewPlayerView(80, 30)
+	empty.SetState(&api.PlaybackState{})
+	if line := ansi.Strip(empty.CompactView(80)); line != "♪ No track playing" {
+		t.Errorf("compact view with nothing playing = %q", line)
+	}
+}
+
+func TestPlayerView_CompactSeek(t *testing.T) {
+	v := playingView(api.StatusPlaying)
+	v.CompactView(80)
+
+	start := v.compactBarX
+	if pos, ok := v.CompactSeek(start); !ok || pos != 0 {
+		t.Errorf("seek at the bar's start = %v, %v; want 0", pos, ok)
+	}
+	cells := v.compactBar.BarWidth()
+	want := 200 * time.Second * time.Duration(cells/2) / time.Duration(cells)
+	if pos, ok := v.CompactSeek(start + cells/2); !ok || pos != want {
+		t.Errorf("seek at the bar's middle = %v, %v; want %v", pos, ok, want)
+	}
+	if _, ok := v.CompactSeek(start - 1); ok {
+		t.Error("seek left of the bar")
+	}
+	if _, ok := v.CompactSeek(start + v.compactBar.BarWidth()); ok {
+		t.Error("seek right of the bar")
+	}
+}
@@ -0,0 +1,99 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// detailsHeight is the number of lines the details panel occupies,
+// including its separator
+const detailsHeight = 9
+
+// renderDetails renders the selected track's details panel in theme's
+// colors. analyzing marks analysis results as still pending.
+func renderDetails(t *api.Track, analyzing bool, width int, theme components.Theme) string {
+	detailsLabelStyle := lipgloss.NewStyle().Foreground(theme.Subtle).Width(10)
+	detailsValueStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	var sb strings.Builder
+	sb.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).Render(strings.Repeat("─", max(width, 1))))
+
+	if t == nil {
+		sb.WriteString("\n")
+		sb.WriteString(detailsValueStyle.Render("No track selected"))
+		return sb.String()
+	}
+
+	position := "-"
+	if t.TrackNum > 0 {
+		position = strconv.Itoa(t.TrackNum)
+		if t.DiscNum > 0 {
+			position = fmt.Sprintf("%d-%d", t.DiscNum, t.TrackNum)
+		}
+	}
+	year := "-"
+	if t.Year > 0 {
+		year = strconv.Itoa(t.Year)
+	}
+	bpm := "-"
+	switch {
+	case analyzing:
+		bpm = "analyzing…"
+	case t.BPM > 0:
+		bpm = fmt.Sprintf("%.1f", t.BPM)
+	}
+
+	rows := [][2]string{
+		{"Title", t.Title},
+		{"Artist", t.Artist},
+		{"Album", t.Album + " (" + year + ")"},
+		{"Track", position + "   Genre: " + orDash(t.Genre)},
+		{"Duration", t.Duration.Round(time.Second).String() + "   BPM: " + bpm},
+		{"Size", formatFileSize(t.Size) + "   Rating: " + orDash(components.Stars(t.Rating))},
+		{"Location", trackBreadcrumb(t.FilePath, width-10)},
+	}
+	for _, row := range rows {
+		sb.WriteString("\n")
+		sb.WriteString(detailsLabelStyle.Render(row[0]))
+		sb.WriteString(detailsValueStyle.Render(row[1]))
+	}
+	return sb.String()
+}
+
+// trackBreadcrumb renders the file's location as a breadcrumb
+func trackBreadcrumb(path string, width int) string {
+	b := components.NewBreadcrumb(width)
+	b.SetPath(path)
+	return b.View()
+}
+
+// formatFileSize renders a byte count in binary units followed by the exact
+// size, e.g. "41.3 MiB (43315210 bytes)"
+func formatFileSize(size int64) string {
+	if size <= 0 {
+		return "unknown"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d bytes", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB (%d bytes)", float64(size)/float64(div), "KMGTPE"[exp], size)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
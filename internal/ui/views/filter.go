@@ -0,0 +1,422 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/pkg/timecode"
+)
+
+// searchQuery is a parsed library search. Each free-text term must match
+// Title, Artist or Album, or only the field named by a prefix such as
+// "artist:radiohead": fuzzily by default, so "brhap" finds "Bohemian
+// Rhapsody", or as an exact substring when prefixed with ' as in fzf. Double
+// quotes keep words together, as in album:"ok computer". A term prefixed
+// with "re:" is a regular expression, matched ignoring case unless it
+// starts with the (?-i) flag. Accents are ignored unless exactAccents, so
+// "bjork" finds "Björk". Field filters such as "bpm:120..130" or
+// "len:<2:00" narrow the results further. Everything must match.
+type searchQuery struct {
+	terms        []searchTerm
+	filters      []trackFilter
+	err          error // Why a regular expression was left out, if one was
+	exactAccents bool
+}
+
+// searchTerm is one word or quoted phrase of free text
+type searchTerm struct {
+	text  string // Lowercase and folded as the query's accents are, without the ' prefix
+	runes []rune
+	exact bool
+	re    *regexp.Regexp // Set for a "re:" term, which has no text
+	field textField
+}
+
+// textField restricts a search term to one track field
+type textField int
+
+const (
+	fieldAny textField = iota
+	fieldTitle
+	fieldArtist
+	fieldAlbum
+)
+
+// textFields maps search prefixes to the field they restrict to. The names
+// match the field names TrackList passes to its Highlight hook.
+var textFields = map[string]textField{
+	"title":  fieldTitle,
+	"artist": fieldArtist,
+	"album":  fieldAlbum,
+}
+
+// trackFilter reports whether a track satisfies one field filter
+type trackFilter func(*api.Track) bool
+
+// parseQuery parses raw as the search does by default, ignoring accents
+func parseQuery(raw string) searchQuery {
+	return parseQueryAccents(raw, false)
+}
+
+// parseQueryAccents splits a raw search string into free text and field
+// filters, matching accents exactly with exactAccents. Tokens with an
+// unrecognised prefix or an invalid value stay free text.
+func parseQueryAccents(raw string, exactAccents bool) searchQuery {
+	q := searchQuery{exactAccents: exactAccents}
+
+	for _, token := range splitQuery(raw) {
+		if filter, ok := parseFilter(token); ok {
+			q.filters = append(q.filters, filter)
+			continue
+		}
+
+		field := fieldAny
+		if prefix, value, ok := strings.Cut(token, ":"); ok && value != "" {
+			if f, known := textFields[strings.ToLower(prefix)]; known {
+				field, token = f, value
+			}
+		}
+		if pattern, ok := strings.CutPrefix(token, "re:"); ok && pattern != "" {
+			re, err := compileSearchRegexp(pattern)
+			if err != nil {
+				// Leave the pattern out until it compiles, e.g. while
+				// it's still being typed
+				q.err = err
+				continue
+			}
+			q.terms = append(q.terms, searchTerm{re: re, field: field})
+			continue
+		}
+		if term, ok := newSearchTerm(token, field, exactAccents); ok {
+			q.terms = append(q.terms, term)
+		}
+	}
+	return q
+}
+
+// compileSearchRegexp compiles a "re:" pattern, ignoring case by default.
+// Errors are reduced to what's wrong with the pattern, without the
+// regexp package's wording around it.
+func compileSearchRegexp(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("%s: %s", syntaxErr.Code, strings.TrimPrefix(syntaxErr.Expr, "(?i)"))
+		}
+		return nil, err
+	}
+	return re, nil
+}
+
+// newSearchTerm builds a term from its raw text; a leading ' makes it exact
+func newSearchTerm(raw string, field textField, exactAccents bool) (searchTerm, bool) {
+	term := searchTerm{field: field}
+	if rest, ok := strings.CutPrefix(raw, "'"); ok && rest != "" {
+		raw, term.exact = rest, true
+	}
+	term.runes, _ = foldText(raw, exactAccents)
+	term.text = string(term.runes)
+	return term, term.text != ""
+}
+
+// splitQuery splits raw on whitespace, keeping double-quoted spans together
+// (including one after a prefix, as in album:"ok computer"). The quotes
+// themselves are dropped.
+func splitQuery(raw string) []string {
+	var tokens []string
+	var token strings.Builder
+	inQuote, started := false, false
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuote, started = !inQuote, true
+		case unicode.IsSpace(r) && !inQuote:
+			if started {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				started = false
+			}
+		default:
+			token.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// parseFilter parses a single "field:value" token
+func parseFilter(token string) (trackFilter, bool) {
+	field, value, ok := strings.Cut(token, ":")
+	if !ok || value == "" {
+		return nil, false
+	}
+
+	switch strings.ToLower(field) {
+	case "bpm":
+		r, ok := parseRange(value, parseNumber, 0.5)
+		if !ok {
+			return nil, false
+		}
+		return func(t *api.Track) bool {
+			return t.BPM > 0 && r.contains(t.BPM)
+		}, true
+	case "len":
+		// Durations compare in seconds; a bare value matches to the second
+		r, ok := parseRange(value, parseTimecode, 0.5)
+		if !ok {
+			return nil, false
+		}
+		return func(t *api.Track) bool {
+			return t.Duration > 0 && r.contains(t.Duration.Seconds())
+		}, true
+	case "rating":
+		// Unrated tracks count as no stars
+		r, ok := parseRange(value, parseNumber, 0)
+		if !ok {
+			return nil, false
+		}
+		return func(t *api.Track) bool {
+			return r.contains(float64(t.Rating))
+		}, true
+	}
+	return nil, false
+}
+
+// matches reports whether a track satisfies the whole query
+func (q searchQuery) matches(t *api.Track) bool {
+	_, ok := q.score(t)
+	return ok
+}
+
+// score reports whether a track satisfies the whole query and how well its
+// text matches: the sum over terms of the best field score
+func (q searchQuery) score(t *api.Track) (int, bool) {
+	text := newTrackText(t, q.exactAccents)
+	return q.scoreText(t, &text)
+}
+
+// scoreText is score with the track's text already lowercased
+func (q searchQuery) scoreText(t *api.Track, text *trackText) (int, bool) {
+	for _, filter := range q.filters {
+		if !filter(t) {
+			return 0, false
+		}
+	}
+
+	total := 0
+	for _, term := range q.terms {
+		if term.re != nil {
+			if !term.matchesRegexp(t) {
+				return 0, false
+			}
+			continue
+		}
+		best, found := 0, false
+		for _, field := range term.fields(text) {
+			if score, ok := term.score(field); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fields returns the track fields the term is matched against
+func (term searchTerm) fields(text *trackText) []lowerText {
+	if term.field == fieldAny {
+		return text[:]
+	}
+	i := term.field - fieldTitle
+	return text[i : i+1]
+}
+
+// matchesRegexp reports whether a "re:" term matches the track's fields.
+// The original text is matched so (?-i) can make the pattern case
+// sensitive.
+func (term searchTerm) matchesRegexp(t *api.Track) bool {
+	for i, value := range []string{t.Title, t.Artist, t.Album} {
+		if (term.field == fieldAny || term.field == fieldTitle+textField(i)) && term.re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// score matches the term against one lowercased field
+func (term searchTerm) score(field lowerText) (int, bool) {
+	if !term.exact {
+		return fuzzyScoreLower(term.runes, field.runes)
+	}
+	lower := field.s
+	i := strings.Index(lower, term.text)
+	if i < 0 {
+		return 0, false
+	}
+	// Rank exact hits like a fully consecutive fuzzy match
+	score := len(term.runes)*(scoreMatch+bonusConsecutive) - bonusConsecutive
+	if prev, _ := utf8.DecodeLastRuneInString(lower[:i]); i == 0 || isWordBoundary(prev) {
+		score += bonusWordStart + bonusFirstRune
+	}
+	return score, true
+}
+
+// highlight returns the rune indices of text matched by any term that
+// applies to the named field ("title", "artist" or "album"), for
+// emphasising why a track matched. Overlapping matches are merged.
+func (q searchQuery) highlight(field, text string) []int {
+	f := textFields[field]
+	var marked map[int]bool
+	for _, term := range q.terms {
+		if term.field != fieldAny && term.field != f {
+			continue
+		}
+		for _, i := range term.positions(text, q.exactAccents) {
+			if marked == nil {
+				marked = make(map[int]bool)
+			}
+			marked[i] = true
+		}
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	positions := make([]int, 0, len(marked))
+	for i := range marked {
+		positions = append(positions, i)
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// positions returns the rune indices of field the term matches, folding
+// its accents as the term's were unless exactAccents
+func (term searchTerm) positions(field string, exactAccents bool) []int {
+	if term.re != nil {
+		loc := term.re.FindStringIndex(field)
+		if loc == nil {
+			return nil
+		}
+		start := utf8.RuneCountInString(field[:loc[0]])
+		positions := make([]int, utf8.RuneCountInString(field[loc[0]:loc[1]]))
+		for j := range positions {
+			positions[j] = start + j
+		}
+		return positions
+	}
+	text, from := foldText(field, exactAccents)
+	var positions []int
+	if !term.exact {
+		positions = fuzzyPositions(term.runes, text)
+	} else {
+		n := len(term.runes)
+		for i := 0; i+n <= len(text); i++ {
+			if string(text[i:i+n]) == term.text {
+				positions = make([]int, n)
+				for j := range positions {
+					positions[j] = i + j
+				}
+				break
+			}
+		}
+	}
+	// Point back past any marks folding dropped
+	if from != nil {
+		for j, i := range positions {
+			positions[j] = from[i]
+		}
+	}
+	return positions
+}
+
+// isEmpty reports whether the query has neither text nor filters
+func (q searchQuery) isEmpty() bool {
+	return len(q.terms) == 0 && len(q.filters) == 0
+}
+
+// numericRange is an inclusive [min, max] interval; either end may be infinite
+type numericRange struct {
+	min, max float64
+}
+
+func (r numericRange) contains(v float64) bool {
+	return v >= r.min && v <= r.max
+}
+
+// parseRange parses "a..b", ">a", ">=a", "<b", "<=b" or a bare value. A bare
+// value matches within ±tolerance so "bpm:128" finds a track at 127.8.
+func parseRange(s string, parse func(string) (float64, bool), tolerance float64) (numericRange, bool) {
+	if lo, hi, ok := strings.Cut(s, ".."); ok {
+		min, okMin := parse(lo)
+		max, okMax := parse(hi)
+		if !okMin || !okMax {
+			return numericRange{}, false
+		}
+		if min > max {
+			min, max = max, min
+		}
+		return numericRange{min: min, max: max}, true
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		rest, ok := strings.CutPrefix(s, op)
+		if !ok {
+			continue
+		}
+		v, ok := parse(rest)
+		if !ok {
+			return numericRange{}, false
+		}
+		switch op {
+		case ">=":
+			return numericRange{min: v, max: math.Inf(1)}, true
+		case "<=":
+			return numericRange{min: math.Inf(-1), max: v}, true
+		case ">":
+			return numericRange{min: math.Nextafter(v, math.Inf(1)), max: math.Inf(1)}, true
+		default:
+			return numericRange{min: math.Inf(-1), max: math.Nextafter(v, math.Inf(-1))}, true
+		}
+	}
+
+	v, ok := parse(s)
+	if !ok {
+		return numericRange{}, false
+	}
+	return numericRange{min: v - tolerance, max: v + tolerance}, true
+}
+
+// parseNumber parses a plain decimal number
+func parseNumber(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseTimecode parses a timecode like "2:30" into seconds
+func parseTimecode(s string) (float64, bool) {
+	d, err := timecode.Parse(s)
+	if err != nil {
+		return 0, false
+	}
+	return d.Seconds(), true
+}
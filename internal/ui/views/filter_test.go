@@ -0,0 +1,452 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestParseQuery_Filters(t *testing.T) {
+	short := &api.Track{Title: "Interlude", Duration: 90 * time.Second, BPM: 120}
+	epic := &api.Track{Title: "Epic", Duration: 12 * time.Minute}
+	unknown := &api.Track{Title: "Unknown"}
+
+	tests := []struct {
+		query string
+		want  []bool // short, epic, unknown
+	}{
+		{"len:<2:00", []bool{true, false, false}},
+		{"len:>10:00", []bool{false, true, false}},
+		{"len:1:00..5:00", []bool{true, false, false}},
+		{"len:1:30", []bool{true, false, false}},
+		{"bpm:115..125", []bool{true, false, false}},
+		{"epic len:>10:00", []bool{false, true, false}},
+		{"len:bogus", []bool{false, false, false}}, // kept as free text
+		{"rating:>=4", []bool{false, true, false}},
+		{"rating:3", []bool{true, false, false}},
+		{"rating:0", []bool{false, false, true}},
+		{"rating:<4", []bool{true, false, true}},
+	}
+	short.Rating, epic.Rating = 3, 5
+	for _, tt := range tests {
+		q := parseQuery(tt.query)
+		for i, track := range []*api.Track{short, epic, unknown} {
+			if got := q.matches(track); got != tt.want[i] {
+				t.Errorf("%q matches %s = %v, want %v", tt.query, track.Title, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseQuery_Fuzzy(t *testing.T) {
+	queen := &api.Track{Title: "Bohemian Rhapsody", Artist: "Queen", Album: "A Night at the Opera"}
+	other := &api.Track{Title: "Harbour Lights", Artist: "Blur", Album: "Think Tank"}
+
+	tests := []struct {
+		query string
+		want  []bool // queen, other
+	}{
+		{"brhap", []bool{true, false}},
+		{"BohRh", []bool{true, false}},
+		{"queen opera", []bool{true, false}},
+		{"hrb", []bool{false, true}},
+		{"ha", []bool{true, true}},
+		{"'harbour", []bool{false, true}},
+		{"'brhap", []bool{false, false}},
+		{"pahr", []bool{false, false}}, // out of order
+	}
+	for _, tt := range tests {
+		q := parseQuery(tt.query)
+		for i, track := range []*api.Track{queen, other} {
+			if got := q.matches(track); got != tt.want[i] {
+				t.Errorf("%q matches %s = %v, want %v", tt.query, track.Title, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestFuzzyScore_Ranking(t *testing.T) {
+	pattern := []rune("rhap")
+	tight, _ := fuzzyScore(pattern, "Bohemian Rhapsody")
+	loose, _ := fuzzyScore(pattern, "Red House After Party")
+	if tight <= loose {
+		t.Errorf("consecutive match scored %d, scattered match %d", tight, loose)
+	}
+
+	start, _ := fuzzyScore([]rune("lig"), "Harbour Lights")
+	middle, _ := fuzzyScore([]rune("lig"), "Delight")
+	if start <= middle {
+		t.Errorf("word-start match scored %d, mid-word match %d", start, middle)
+	}
+}
+
+func TestLibraryView_FuzzyResultsSortedByScore(t *testing.T) {
+	v := NewLibraryView(80, 20)
+	v.SetTracks([]*api.Track{
+		{ID: "1", Title: "Red House After Party"},
+		{ID: "2", Title: "Bohemian Rhapsody"},
+		{ID: "3", Title: "Unrelated"},
+	})
+
+	v.filterTracks("rhap")
+	got := v.VisibleTracks()
+	if len(got) != 2 || got[0].ID != "2" || got[1].ID != "1" {
+		t.Fatalf("visible = %v, want tracks 2 then 1", trackIDs(got))
+	}
+
+	v.filterTracks("")
+	if got := v.VisibleTracks(); len(got) != 3 || got[0].ID != "1" {
+		t.Errorf("empty query visible = %v, want library order", trackIDs(got))
+	}
+}
+
+func trackIDs(tracks []*api.Track) []string {
+	ids := make([]string, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestSearchQuery_Highlight(t *testing.T) {
+	tests := []struct {
+		query, field string
+		want         []int
+	}{
+		{"brhap", "Bohemian Rhapsody", []int{0, 9, 10, 11, 12}},
+		{"'rhap", "Bohemian Rhapsody", []int{9, 10, 11, 12}},
+		{"bo rh", "Bohemian Rhapsody", []int{0, 1, 9, 10}},
+		{"zz", "Bohemian Rhapsody", nil},
+		{"bpm:120", "Bohemian Rhapsody", nil},
+	}
+	for _, tt := range tests {
+		got := parseQuery(tt.query).highlight("title", tt.field)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q highlight %q = %v, want %v", tt.query, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuery_FieldPrefixes(t *testing.T) {
+	revolver := &api.Track{Title: "Taxman", Artist: "The Beatles", Album: "Revolver"}
+	okc := &api.Track{Title: "Airbag", Artist: "Radiohead", Album: "OK Computer"}
+	named := &api.Track{Title: "Radiohead Tribute", Artist: "Various", Album: "Covers"}
+
+	tests := []struct {
+		query string
+		want  []bool // revolver, okc, named
+	}{
+		{"radiohead", []bool{false, true, true}},
+		{"artist:radiohead", []bool{false, true, false}},
+		{"title:radiohead", []bool{false, false, true}},
+		{`album:"ok computer"`, []bool{false, true, false}},
+		{"album:ok computer", []bool{false, true, false}},
+		{"artist:beatles album:revolver", []bool{true, false, false}},
+		{"artist:beatles album:okc", []bool{false, false, false}},
+		{"ARTIST:'radio", []bool{false, true, false}},
+		{"genre:rock", []bool{false, false, false}}, // unknown prefix stays literal
+		{"artist:", []bool{false, false, false}},    // no value, literal text
+	}
+	for _, tt := range tests {
+		q := parseQuery(tt.query)
+		for i, track := range []*api.Track{revolver, okc, named} {
+			if got := q.matches(track); got != tt.want[i] {
+				t.Errorf("%q matches %s = %v, want %v", tt.query, track.Title, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSearchQuery_HighlightRespectsField(t *testing.T) {
+	q := parseQuery("artist:queen")
+	if got := q.highlight("title", "Queen of Hearts"); got != nil {
+		t.Errorf("title highlight = %v, want none for an artist: term", got)
+	}
+	if got := q.highlight("artist", "Queen"); len(got) != 5 {
+		t.Errorf("artist highlight = %v, want all five runes", got)
+	}
+}
+
+func TestParseQuery_Regexp(t *testing.T) {
+	queen := &api.Track{Title: "Bohemian Rhapsody", Artist: "Queen", Album: "A Night at the Opera"}
+	other := &api.Track{Title: "Harbour Lights", Artist: "Blur", Album: "Think Tank"}
+
+	tests := []struct {
+		query string
+		want  []bool // queen, other
+	}{
+		{"re:^boh", []bool{true, false}},
+		{"re:(?-i)^boh", []bool{false, false}}, // case sensitive
+		{"re:(?-i)^Boh", []bool{true, false}},
+		{"re:t(ank|ower)$", []bool{false, true}},
+		{`re:"night at"`, []bool{true, false}},
+		{"artist:re:^b", []bool{false, true}},
+		{"title:re:^b", []bool{true, false}},
+		{"re:ts$ blur", []bool{false, true}},
+		{"re:(unclosed", []bool{true, true}}, // left out until it compiles
+		{"re:(unclosed queen", []bool{true, false}},
+	}
+	for _, tt := range tests {
+		q := parseQuery(tt.query)
+		for i, track := range []*api.Track{queen, other} {
+			if got := q.matches(track); got != tt.want[i] {
+				t.Errorf("%q matches %s = %v, want %v", tt.query, track.Title, got, tt.want[i])
+			}
+		}
+	}
+
+	if q := parseQuery("re:(unclosed"); q.err == nil || !q.isEmpty() {
+		t.Errorf("invalid pattern: err %v, empty %v", q.err, q.isEmpty())
+	} else if got, want := q.err.Error(), "missing closing ): (unclosed"; got != want {
+		t.Errorf("error %q, want %q", got, want)
+	}
+	if got := parseQuery("re:rhap").highlight("title", "Bohemian Rhapsody"); !slices.Equal(got, []int{9, 10, 11, 12}) {
+		t.Errorf("highlight = %v", got)
+	}
+	if got := parseQuery("re:ヒカ").highlight("artist", "宇多田ヒカル"); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("highlight wide runes = %v", got)
+	}
+}
+
+func TestLibraryView_RegexpError(t *testing.T) {
+	v := NewLibraryView(120, 30)
+	v.SetTracks([]*api.Track{{ID: "1", Title: "River"}, {ID: "2", Title: "Ocean"}})
+	v.filterTracks("re:[a-")
+	if len(v.TrackList.Items) != 2 || !strings.Contains(v.View(), "✗ regexp: missing closing ]") {
+		t.Errorf("invalid pattern listed %v; error shown in view = %v", trackIDs(v.TrackList.Items), strings.Contains(v.View(), "✗ regexp"))
+	}
+	v.filterTracks("re:[a-r]iver")
+	if got := trackIDs(v.TrackList.Items); len(got) != 1 || got[0] != "1" || strings.Contains(v.View(), "✗") {
+		t.Errorf("once the pattern compiles listed %v", got)
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	got := splitQuery(`  artist:"the beatles"  help "" x `)
+	want := []string{"artist:the beatles", "help", "", "x"}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitQuery = %q, want %q", got, want)
+	}
+}
+
+func TestLibraryView_SearchDebounce(t *testing.T) {
+	v := NewLibraryView(80, 40)
+	v.SetTracks([]*api.Track{
+		{ID: "1", Title: "River"},
+		{ID: "2", Title: "Ocean"},
+	})
+	v.Searching = true
+	v.SearchBar.Focus()
+
+	typeRune := func(r rune) tea.Cmd {
+		var cmd tea.Cmd
+		v, cmd = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		return cmd
+	}
+	if cmd := typeRune('r'); cmd == nil {
+		t.Fatal("typing should schedule a debounced filter")
+	}
+	stale := SearchDebounceMsg{seq: v.searchSeq}
+	typeRune('i')
+	if len(v.TrackList.Items) != 2 {
+		t.Fatal("filter applied before typing paused")
+	}
+
+	v, _ = v.Update(stale)
+	if len(v.TrackList.Items) != 2 {
+		t.Fatal("a superseded tick should not filter")
+	}
+	v, _ = v.Update(SearchDebounceMsg{seq: v.searchSeq})
+	if got := trackIDs(v.TrackList.Items); len(got) != 1 || got[0] != "1" {
+		t.Fatalf("after the latest tick listed %v, want [1]", got)
+	}
+
+	// Clearing the query restores the library immediately
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if len(v.TrackList.Items) != 2 {
+		t.Fatalf("empty query listed %d tracks, want 2", len(v.TrackList.Items))
+	}
+
+	// Enter applies at once
+	typeRune('o')
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := trackIDs(v.TrackList.Items); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("Enter listed %v, want [2]", got)
+	}
+}
+
+func TestLibraryView_ClearedSearchRestoresPosition(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	v.SetTracks(syntheticLibrary(200))
+	v.TrackList.SetOffset(100)
+	v.TrackList.SetCursor(105)
+
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	v.SearchBar.Value = "'no such track"
+	v.filterTracks(v.SearchBar.Value)
+
+	v.SearchBar.Value = ""
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if v.TrackList.Cursor() != 105 || v.TrackList.Offset() != 100 {
+		t.Errorf("after clearing search: cursor %d, offset %d; want 105, 100", v.TrackList.Cursor(), v.TrackList.Offset())
+	}
+}
+
+func TestLibraryView_ClearedSearchKeepsHighlightedTrack(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	tracks := syntheticLibrary(200)
+	for _, track := range tracks {
+		track.FilePath = "/music/" + track.ID + ".mp3"
+	}
+	v.SetTracks(tracks)
+
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	v.SearchBar.Value = "'150"
+	v.filterTracks(v.SearchBar.Value)
+	v.TrackList.SelectTrack(tracks[150])
+
+	v.SearchBar.Value = ""
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := v.SelectedTrack(); got != tracks[150] {
+		t.Fatalf("after clearing search selected %v, want track 150", got)
+	}
+	if c, o := v.TrackList.Cursor(), v.TrackList.Offset(); c < o || c >= o+v.TrackList.Height-2 {
+		t.Errorf("cursor %d not in view at offset %d", c, o)
+	}
+
+	// A reload keeps the same file selected even though the tracks are new
+	reloaded := make([]*api.Track, len(tracks))
+	for i, track := range tracks {
+		copied := *track
+		reloaded[i] = &copied
+	}
+	v.SetTracks(reloaded)
+	if got := v.SelectedTrack(); got != reloaded[150] {
+		t.Errorf("after reload selected %v, want the reloaded track 150", got)
+	}
+
+	// A track that isn't listed any more falls back to the top
+	v.SetTracks(reloaded[:100])
+	if v.TrackList.Cursor() != 0 {
+		t.Errorf("cursor %d after the selection left the list, want 0", v.TrackList.Cursor())
+	}
+}
+
+func TestLibraryView_SearchKeepsUpWithLoading(t *testing.T) {
+	named := func(from, to int, title string) []*api.Track {
+		var tracks []*api.Track
+		for i := from; i < to; i++ {
+			tracks = append(tracks, &api.Track{ID: fmt.Sprint(i), Title: fmt.Sprintf("%s %03d", title, i)})
+		}
+		return tracks
+	}
+	v := NewLibraryView(80, 30)
+	v.SortField = SortTitle
+	v.SetTracks(append(named(50, 100, "River"), named(100, 120, "Ocean")...))
+
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	v.SearchBar.Value = "river"
+	v.filterTracks(v.SearchBar.Value)
+	v.TrackList.SetOffset(10)
+	v.TrackList.SetCursor(15)
+	selected, onScreen := v.SelectedTrack(), v.TrackList.Cursor()-v.TrackList.Offset()
+
+	// Matches sorting ahead of the selection arrive while searching
+	v.UpdateTracks(append(named(0, 50, "River"), named(120, 140, "Ocean")...), nil)
+	if len(v.TrackList.Items) != 100 {
+		t.Fatalf("search lists %d tracks after loading more, want 100", len(v.TrackList.Items))
+	}
+	if v.SelectedTrack() != selected || v.TrackList.Cursor()-v.TrackList.Offset() != onScreen {
+		t.Errorf("selection moved to %v on row %d, want %v on row %d",
+			v.SelectedTrack(), v.TrackList.Cursor()-v.TrackList.Offset(), selected, onScreen)
+	}
+
+	v.AddTrack(&api.Track{ID: "late", Title: "River late"})
+	v.AddTrack(&api.Track{ID: "sea", Title: "Sea"})
+	if len(v.TrackList.Items) != 101 {
+		t.Errorf("search lists %d tracks after one more match, want 101", len(v.TrackList.Items))
+	}
+
+	v.SearchBar.Value = ""
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(v.TrackList.Items) != 142 {
+		t.Errorf("cleared search lists %d tracks, want all 142", len(v.TrackList.Items))
+	}
+
+	// A scan picked up again sends tracks already listed
+	v.UpdateTracks(named(0, 10, "River"), nil)
+	if len(v.AllTracks) != 142 || len(v.TrackList.Items) != 142 {
+		t.Errorf("rescanned tracks listed twice: %d tracks, %d rows", len(v.AllTracks), len(v.TrackList.Items))
+	}
+}
+
+func TestLibraryView_SearchHistory(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	v.SetTracks([]*api.Track{{ID: "1", Title: "River"}, {ID: "2", Title: "Ocean"}})
+	search := func(query string) tea.Cmd {
+		v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+		v.SearchBar.SetValue(query)
+		var cmd tea.Cmd
+		v, cmd = v.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		return cmd
+	}
+
+	search("river")
+	cmd := search("ocean")
+	if cmd == nil {
+		t.Fatal("a new query should be reported for saving")
+	}
+	if msg, ok := cmd().(SearchHistoryMsg); !ok || !slices.Equal(msg.History, []string{"ocean", "river"}) {
+		t.Errorf("got %#v, want the history newest first", cmd())
+	}
+	if cmd := search("ocean"); cmd != nil {
+		t.Error("repeating the last query reported a change")
+	}
+
+	// Up recalls the previous query and filters by it, rather than moving
+	// the list selection
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	v.SearchBar.SetValue("")
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyUp})
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if v.SearchBar.Value != "river" {
+		t.Errorf("Up twice recalled %q, want \"river\"", v.SearchBar.Value)
+	}
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := trackIDs(v.TrackList.Items); len(got) != 1 || got[0] != "1" {
+		t.Errorf("recalled query listed %v, want [1]", got)
+	}
+}
+
+func TestLibraryView_EnterOnEmptyResults(t *testing.T) {
+	for _, group := range []GroupField{GroupNone, GroupArtist} {
+		v := NewLibraryView(80, 30)
+		v.GroupBy = group
+		v.SetTracks([]*api.Track{{ID: "1", Title: "River", Artist: "A"}, {ID: "2", Title: "Ocean", Artist: "B"}})
+
+		v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+		v.SearchBar.SetValue("zzzz")
+		v, _ = v.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if got := v.VisibleTracks(); len(got) != 0 {
+			t.Fatalf("group %v: search matched %v, want nothing", group, trackIDs(got))
+		}
+
+		// Paging and Enter on the empty results select nothing to play
+		for _, key := range []tea.KeyType{tea.KeyPgDown, tea.KeyDown, tea.KeyEnter} {
+			v, _ = v.Update(tea.KeyMsg{Type: key})
+		}
+		if track := v.SelectedTrack(); track != nil {
+			t.Errorf("group %v: selected %q in empty results", group, track.ID)
+		}
+		if v.OnGroupHeader() {
+			t.Errorf("group %v: a header is selected in empty results", group)
+		}
+		_ = v.View()
+	}
+}
@@ -0,0 +1,45 @@
+package views
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldText returns s as search matches it: lowercased and, unless
+// exactAccents, decomposed under NFD with the combining marks dropped, so
+// "bjork" finds "Björk". from holds the index in s of the rune each
+// returned rune came from, for highlighting; it is nil when every rune
+// folded to exactly one and they line up.
+func foldText(s string, exactAccents bool) (runes []rune, from []int) {
+	runes = lowerRunes(s)
+	if exactAccents {
+		return runes, nil
+	}
+	folded := make([]rune, 0, len(runes))
+	for i, r := range runes {
+		start := len(folded)
+		if r < utf8.RuneSelf {
+			folded = append(folded, r)
+		} else {
+			for _, d := range norm.NFD.String(string(r)) {
+				if !unicode.Is(unicode.Mn, d) {
+					folded = append(folded, d)
+				}
+			}
+		}
+		if from == nil && len(folded) != i+1 {
+			from = make([]int, start, len(runes))
+			for j := range from {
+				from[j] = j
+			}
+		}
+		if from != nil {
+			for range folded[start:] {
+				from = append(from, i)
+			}
+		}
+	}
+	return folded, from
+}
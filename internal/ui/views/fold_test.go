@@ -0,0 +1,85 @@
+package views
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func accentedLibrary() []*api.Track {
+	return []*api.Track{
+		{ID: "1", Title: "Hyperballad", Artist: "Björk"},
+		{ID: "2", Title: "Ace of Spades", Artist: "Motörhead"},
+		{ID: "3", Title: "Hoppípolla", Artist: "Sigur Rós"},
+		{ID: "4", Title: "Halo", Artist: "Beyoncé"},
+		{ID: "5", Title: "Plain", Artist: "Bjork Tribute"},
+	}
+}
+
+func TestLibraryView_SearchIgnoresAccents(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	v.SetTracks(accentedLibrary())
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"bjork", []string{"1", "5"}},
+		{"'BJORK", []string{"1", "5"}},
+		{"motorhead", []string{"2"}},
+		{"'sigur ros", []string{"3"}},
+		{"hoppipolla", []string{"3"}},
+		{"beyonce", []string{"4"}},
+		{"artist:beyonce", []string{"4"}},
+		{"Björk", []string{"1", "5"}}, // Accents in the query are folded too
+	}
+	for _, tt := range tests {
+		v.filterTracks(tt.query)
+		got := trackIDs(v.VisibleTracks())
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q listed %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestLibraryView_SearchExactAccents(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	v.SetTracks(accentedLibrary())
+	v.ExactAccents = true
+	v.filterTracks("'bjork")
+	if got := trackIDs(v.VisibleTracks()); !slices.Equal(got, []string{"5"}) {
+		t.Errorf("exact accents: 'bjork listed %v, want [5]", got)
+	}
+	v.filterTracks("'björk")
+	if got := trackIDs(v.VisibleTracks()); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("exact accents: 'björk listed %v, want [1]", got)
+	}
+}
+
+func TestFoldText_DecomposedMarks(t *testing.T) {
+	// "Beyonce" followed by a combining acute accent, as NFD spells it
+	runes, from := foldText("Beyonce\u0301 Live", false)
+	if string(runes) != "beyonce live" {
+		t.Errorf("folded to %q, want %q", string(runes), "beyonce live")
+	}
+	if len(from) != len(runes) || from[7] != 8 {
+		t.Errorf("from = %v, want the space to point at rune 8", from)
+	}
+	if runes, from := foldText("Björk", false); string(runes) != "bjork" || from != nil {
+		t.Errorf("precomposed folded to %q, %v; want bjork with no index map", string(runes), from)
+	}
+	// Any letter NFD decomposes folds, not only the common Latin ones
+	if runes, from := foldText("Đỗ Ṣǘ", false); string(runes) != "đo su" || from != nil {
+		t.Errorf("other accents folded to %q, %v; want đo su with no index map", string(runes), from)
+	}
+	if runes, _ := foldText("Björk", true); string(runes) != "björk" {
+		t.Errorf("exact accents folded to %q, want björk", string(runes))
+	}
+
+	// Highlights land on the original runes, past the dropped mark
+	q := parseQuery("'live")
+	if got := q.highlight("title", "Beyonce\u0301 Live"); !slices.Equal(got, []int{9, 10, 11, 12}) {
+		t.Errorf("highlight = %v, want [9 10 11 12]", got)
+	}
+}
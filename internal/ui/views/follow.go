@@ -0,0 +1,65 @@
+package views
+
+import "github.com/jscyril/golang_music_player/api"
+
+// SetPlaying tells the view which track is playing, nil for none. In
+// follow mode the selection moves to each track as it starts, if the
+// search lists it; a search being typed is left alone.
+func (v *LibraryView) SetPlaying(track *api.Track) {
+	if sameFile(track, v.playing) {
+		return
+	}
+	v.playing = track
+	if v.Follow && track != nil && !v.Searching {
+		v.revealTrack(track, false)
+	}
+}
+
+// SelectTrack selects track and scrolls it into view, as revealing it
+// does, leaving any search alone. It reports whether the track is now
+// selected.
+func (v *LibraryView) SelectTrack(track *api.Track) bool {
+	return v.revealTrack(track, false)
+}
+
+// revealTrack selects track and scrolls it into view, expanding its group
+// if that is collapsed. With clearSearch, a search that leaves the track
+// out is cleared so it can be shown. It reports whether the track is now
+// selected; tracks hidden as offline, or not in the library, aren't.
+func (v *LibraryView) revealTrack(track *api.Track, clearSearch bool) bool {
+	if v.TrackList.SelectTrack(track) {
+		return true
+	}
+	if key, _, _ := groupKey(track, v.GroupBy); v.GroupBy != GroupNone && v.collapsed[key] {
+		delete(v.collapsed, key)
+		v.Refresh()
+		if v.TrackList.SelectTrack(track) {
+			return true
+		}
+	}
+	if !clearSearch || parseQuery(v.SearchBar.Value).isEmpty() {
+		return false
+	}
+	v.SearchBar.Clear()
+	v.searchSeq++ // Drop a debounced search still pending
+	v.browsePos = nil
+	v.filterTracks("")
+	return v.TrackList.SelectTrack(track)
+}
+
+// followLabel notes that follow mode is on
+func (v *LibraryView) followLabel() string {
+	if !v.Follow {
+		return ""
+	}
+	return " · following"
+}
+
+// sameFile reports whether a and b are the same track, or tracks for the
+// same file
+func sameFile(a, b *api.Track) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a == b || (a.FilePath != "" && a.FilePath == b.FilePath)
+}
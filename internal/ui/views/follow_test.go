@@ -0,0 +1,64 @@
+package views
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLibraryView_GoToPlaying(t *testing.T) {
+	v := NewLibraryView(80, 40)
+	tracks := groupLibrary()
+	v.SetTracks(tracks)
+	playing := tracks[3] // u1
+	v.SetPlaying(playing)
+	if got := v.SelectedTrack(); got != tracks[0] {
+		t.Fatalf("selection moved without follow mode: %v", got.ID)
+	}
+
+	// A search that leaves the playing track out is cleared by f
+	v.SearchBar.SetValue("alpha")
+	v.filterTracks(v.SearchBar.Value)
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if got := v.SelectedTrack(); got != playing || v.SearchBar.Value != "" {
+		t.Fatalf("after f: selected %v, search %q", got.ID, v.SearchBar.Value)
+	}
+
+	// A collapsed group is expanded to show it
+	v.GroupBy = GroupArtist
+	v.Refresh()
+	v.TrackList.SetSelected(0)
+	v.ToggleGroup() // Blur, which holds u1
+	v.TrackList.SetSelected(0)
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if got := v.SelectedTrack(); got != playing {
+		t.Fatalf("after f on a collapsed group: selected %v", got)
+	}
+}
+
+func TestLibraryView_FollowPlayback(t *testing.T) {
+	v := NewLibraryView(80, 40)
+	tracks := groupLibrary()
+	v.SetTracks(tracks)
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F")})
+	if !v.Follow {
+		t.Fatal("F should turn follow mode on")
+	}
+
+	v.SetPlaying(tracks[2])
+	if got := v.SelectedTrack(); got != tracks[2] {
+		t.Fatalf("follow selected %v, want %s", got.ID, tracks[2].ID)
+	}
+
+	// Following leaves a search alone when the new track isn't in it
+	v.SearchBar.SetValue("alpha")
+	v.filterTracks(v.SearchBar.Value)
+	v.SetPlaying(tracks[0])
+	if got := v.SelectedTrack(); v.SearchBar.Value != "alpha" || got != tracks[4] {
+		t.Errorf("follow disturbed the search: search %q, selected %v", v.SearchBar.Value, got.ID)
+	}
+	v.SetPlaying(tracks[4])
+	if got := v.SelectedTrack(); got != tracks[4] {
+		t.Errorf("follow within the search selected %v", got.ID)
+	}
+}
@@ -0,0 +1,152 @@
+package views
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fuzzy match scoring, loosely after fzf: every pattern rune must appear in
+// order, and matches score higher when runs are consecutive and start at
+// word boundaries.
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 8
+	bonusWordStart   = 10
+	bonusFirstRune   = 6
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// fuzzyScore reports whether pattern (lowercase) is a subsequence of the
+// lowercased s, and how well it matches; higher is better.
+func fuzzyScore(pattern []rune, s string) (int, bool) {
+	return fuzzyScoreLower(pattern, lowerRunes(s))
+}
+
+// FuzzyScore reports whether query fuzzily matches s, ignoring case, as the
+// library's fuzzy search does, and how well; higher is better
+func FuzzyScore(query, s string) (int, bool) {
+	return fuzzyScore(lowerRunes(query), s)
+}
+
+// fuzzyScoreLower is fuzzyScore on text that is already lowercased
+func fuzzyScoreLower(pattern, text []rune) (int, bool) {
+	if len(pattern) == 0 {
+		return 0, true
+	}
+	start, end, ok := fuzzyWindow(pattern, text)
+	if !ok {
+		return 0, false
+	}
+
+	// Score the match within text[start:end+1]
+	score, pi, prevMatch, inGap := 0, 0, false, false
+	for i := start; i <= end && pi < len(pattern); i++ {
+		if text[i] != pattern[pi] {
+			if inGap {
+				score -= penaltyGapExtend
+			} else {
+				score -= penaltyGapStart
+				inGap = true
+			}
+			prevMatch = false
+			continue
+		}
+		score += scoreMatch
+		if prevMatch {
+			score += bonusConsecutive
+		}
+		if i == 0 || isWordBoundary(text[i-1]) {
+			score += bonusWordStart
+			if pi == 0 {
+				score += bonusFirstRune
+			}
+		}
+		prevMatch, inGap = true, false
+		pi++
+	}
+	return score, true
+}
+
+// fuzzyPositions returns the indices of the lowercased text matched by
+// pattern, the same runes fuzzyScore scores, or nil when pattern doesn't
+// match
+func fuzzyPositions(pattern, text []rune) []int {
+	if len(pattern) == 0 {
+		return nil
+	}
+	start, end, ok := fuzzyWindow(pattern, text)
+	if !ok {
+		return nil
+	}
+
+	positions := make([]int, 0, len(pattern))
+	for i, pi := start, 0; i <= end && pi < len(pattern); i++ {
+		if text[i] == pattern[pi] {
+			positions = append(positions, i)
+			pi++
+		}
+	}
+	return positions
+}
+
+// fuzzyWindow finds the shortest-ending match of pattern in text: the match
+// is first found left to right, then tightened by scanning back from where
+// it ended so "rhap" in "r... rhapsody" uses the compact occurrence.
+func fuzzyWindow(pattern, text []rune) (start, end int, ok bool) {
+	// Forward pass: find where the leftmost full match ends
+	pi, end := 0, -1
+	for i, r := range text {
+		if r == pattern[pi] {
+			pi++
+			if pi == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, 0, false
+	}
+
+	// Backward pass: the latest start that still matches before end
+	pi, start = len(pattern)-1, end
+	for i := end; i >= 0; i-- {
+		if text[i] == pattern[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+	return start, end, true
+}
+
+// lowerRunes returns the lowercased runes of s, one per rune of s so
+// indices line up with the original text
+func lowerRunes(s string) []rune {
+	text := []rune(s)
+	for i, r := range text {
+		text[i] = unicode.ToLower(r)
+	}
+	return text
+}
+
+// isWordBoundary reports whether r separates words
+func isWordBoundary(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// distinctRunes returns the distinct runes of s, ignoring spaces
+func distinctRunes(s string) []rune {
+	seen := make(map[rune]bool, utf8.RuneCountInString(s))
+	var out []rune
+	for _, r := range s {
+		if r != ' ' && !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package views
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+	"github.com/jscyril/golang_music_player/pkg/timecode"
+)
+
+// SeekMsg asks for playback to jump to Position
+type SeekMsg struct {
+	Position time.Duration
+}
+
+// The player view's own keys: a digit jumps to that tenth of the track,
+// g asks for a time to jump to. They only apply while a track that can be
+// seeked is loaded, so otherwise the digits still switch views.
+
+// seekable reports whether the loaded track can be jumped around in
+func (v *PlayerView) seekable() bool {
+	if v.State == nil || v.State.CurrentTrack == nil || v.ProgressBar.Live || v.ProgressBar.Total <= 0 {
+		return false
+	}
+	return v.State.Status == api.StatusPlaying || v.State.Status == api.StatusPaused
+}
+
+// TakesKey reports whether the player view handles key itself, ahead of
+// the global keys: every key while the time prompt is open, and the digits
+// and g while the track can be seeked
+func (v *PlayerView) TakesKey(key string) bool {
+	if v.GoingTo {
+		return true
+	}
+	if !v.seekable() {
+		return false
+	}
+	return key == "g" || len(key) == 1 && key[0] >= '0' && key[0] <= '9'
+}
+
+// openGoTo opens the prompt for a time to jump to
+func (v *PlayerView) openGoTo() {
+	v.GoTo = components.NewSearchInput(max(v.Width-8, 20))
+	v.GoTo.SetTheme(v.theme)
+	v.GoTo.Prompt = "Go to: "
+	v.GoTo.Placeholder = "M:SS or H:MM:SS"
+	v.GoTo.Style, v.GoTo.FocusStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
+	v.GoTo.Focus()
+	v.GoingTo = true
+	v.goToErr = ""
+}
+
+// updateKey handles the player view's own keys
+func (v PlayerView) updateKey(msg tea.KeyMsg) (PlayerView, tea.Cmd) {
+	if v.GoingTo {
+		switch msg.String() {
+		case "esc":
+			v.GoingTo = false
+			return v, nil
+		case "enter":
+			at, err := v.parseGoTo(v.GoTo.Value)
+			if err != nil {
+				v.goToErr = err.Error() // Stay open to correct it
+				return v, nil
+			}
+			v.GoingTo = false
+			return v, seekTo(at)
+		}
+		v.GoTo, _ = v.GoTo.Update(msg)
+		v.goToErr = ""
+		return v, nil
+	}
+	if !v.seekable() {
+		return v, nil
+	}
+
+	key := msg.String()
+	if key == "g" {
+		v.openGoTo()
+		return v, nil
+	}
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+		total := v.ProgressBar.Total
+		at := min(total*time.Duration(key[0]-'0')/10, total)
+		return v, seekTo(at)
+	}
+	return v, nil
+}
+
+// parseGoTo parses a time typed into the prompt, which must fall within
+// the track
+func (v *PlayerView) parseGoTo(s string) (time.Duration, error) {
+	at, err := timecode.Parse(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time; use M:SS or H:MM:SS", s)
+	}
+	if total := v.ProgressBar.Total; at > total {
+		return 0, fmt.Errorf("%s is past the end (%s)", at.Round(time.Second), total.Round(time.Second))
+	}
+	return at, nil
+}
+
+// seekTo returns the command asking for playback to jump to at
+func seekTo(at time.Duration) tea.Cmd {
+	return func() tea.Msg { return SeekMsg{Position: at} }
+}
@@ -0,0 +1,95 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func playingView(status api.PlayerStatus) PlayerView {
+	v := NewPlayerView(80, 30)
+	v.SetState(&api.PlaybackState{
+		Status:       status,
+		Position:     30 * time.Second,
+		CurrentTrack: &api.Track{ID: "a", Title: "A", Duration: 200 * time.Second},
+	})
+	return v
+}
+
+// typeKeys sends each key to v, returning where the last one asked to seek
+func typeKeys(v PlayerView, keys ...string) (PlayerView, *time.Duration) {
+	var seek *time.Duration
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		var cmd tea.Cmd
+		v, cmd = v.Update(msg)
+		seek = nil
+		if cmd != nil {
+			if m, ok := cmd().(SeekMsg); ok {
+				seek = &m.Position
+			}
+		}
+	}
+	return v, seek
+}
+
+func TestPlayerView_DigitJumpsToPercent(t *testing.T) {
+	v := playingView(api.StatusPaused)
+	for key, want := range map[string]time.Duration{"0": 0, "5": 100 * time.Second, "9": 180 * time.Second} {
+		if !v.TakesKey(key) {
+			t.Fatalf("%s not taken while paused", key)
+		}
+		if _, at := typeKeys(v, key); at == nil || *at != want {
+			t.Errorf("%s seeks to %v, want %v", key, at, want)
+		}
+	}
+
+	stopped := playingView(api.StatusStopped)
+	if stopped.TakesKey("5") || stopped.TakesKey("g") {
+		t.Error("with nothing to seek the digits should switch views as usual")
+	}
+	live := playingView(api.StatusPlaying)
+	live.ProgressBar.Live = true
+	if live.TakesKey("5") {
+		t.Error("a live stream can't be jumped around in")
+	}
+}
+
+func TestPlayerView_GoToTime(t *testing.T) {
+	v, at := typeKeys(playingView(api.StatusPlaying), "g", "1", ":", "2", "3", "enter")
+	if at == nil || *at != 83*time.Second || v.GoingTo {
+		t.Errorf("g 1:23 seeks to %v, going to %v; want 1m23s and closed", at, v.GoingTo)
+	}
+
+	for _, input := range []string{"1:2x", "4:00"} {
+		keys := append([]string{"g"}, splitKeys(input)...)
+		v, at := typeKeys(playingView(api.StatusPlaying), append(keys, "enter")...)
+		if at != nil || !v.GoingTo || v.goToErr == "" {
+			t.Errorf("%q: seek %v, open %v, error %q; want rejected and left open", input, at, v.GoingTo, v.goToErr)
+		}
+	}
+
+	v, at = typeKeys(playingView(api.StatusPlaying), "g", "1", "esc")
+	if at != nil || v.GoingTo {
+		t.Error("Esc should close the prompt without seeking")
+	}
+	if v := playingView(api.StatusPlaying); !v.TakesKey("g") {
+		t.Error("g not taken while playing")
+	}
+}
+
+func splitKeys(s string) []string {
+	var keys []string
+	for _, r := range s {
+		keys = append(keys, string(r))
+	}
+	return keys
+}
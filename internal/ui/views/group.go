@@ -0,0 +1,164 @@
+package views
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// GroupField identifies what the library list is grouped by
+type GroupField int
+
+const (
+	GroupNone GroupField = iota
+	GroupAlbum
+	GroupArtist
+)
+
+// groupFieldCount is the number of group fields, used for cycling
+const groupFieldCount = 3
+
+var groupFieldNames = [...]string{"None", "Album", "Artist"}
+
+func (f GroupField) String() string {
+	if int(f) < len(groupFieldNames) {
+		return groupFieldNames[f]
+	}
+	return "Unknown"
+}
+
+// Next returns the following group field, wrapping around
+func (f GroupField) Next() GroupField {
+	return (f + 1) % groupFieldCount
+}
+
+// groupTracks splits tracks into groups in order of each group's first
+// track, with untagged tracks in a final group. With inAlbumOrder each
+// group's tracks are ordered by album, disc and track number, falling back
+// to title; otherwise they keep the order given.
+func groupTracks(tracks []*api.Track, by GroupField, inAlbumOrder bool, collapsed map[string]bool) []components.TrackGroup {
+	var groups []components.TrackGroup
+	index := make(map[string]int)
+	var unknown *components.TrackGroup
+
+	for _, track := range tracks {
+		key, title, ok := groupKey(track, by)
+		if !ok {
+			if unknown == nil {
+				unknown = &components.TrackGroup{Key: key, Title: title}
+			}
+			unknown.Tracks = append(unknown.Tracks, track)
+			continue
+		}
+		i, seen := index[key]
+		if !seen {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, components.TrackGroup{Key: key, Title: title})
+		}
+		groups[i].Tracks = append(groups[i].Tracks, track)
+	}
+	if unknown != nil {
+		groups = append(groups, *unknown)
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		if by == GroupAlbum && g.Key != "" {
+			g.Title += " — " + albumArtist(g.Tracks)
+		}
+		if inAlbumOrder {
+			sort.SliceStable(g.Tracks, func(a, b int) bool {
+				return lessAlbumOrder(g.Tracks[a], g.Tracks[b])
+			})
+		}
+		g.Collapsed = collapsed[g.Key]
+	}
+	return groups
+}
+
+// groupKey returns the group a track belongs to and its header title. ok is
+// false for tracks without the field, which share an "Unknown" group.
+// Albums are keyed on their album artist as well, falling back to the
+// track's artist, so albums of the same name by different artists stay
+// apart while a compilation tagged with one album artist stays together.
+func groupKey(t *api.Track, by GroupField) (key, title string, ok bool) {
+	field := SortAlbum
+	if by == GroupArtist {
+		field = SortArtist
+	}
+	if !hasSortValue(t, field) {
+		return "", "Unknown " + by.String(), false
+	}
+	if by == GroupArtist {
+		return strings.ToLower(t.Artist), t.Artist, true
+	}
+	artist := t.AlbumArtist
+	if artist == "" {
+		artist = t.Artist
+	}
+	return strings.ToLower(artist) + "\x00" + strings.ToLower(t.Album), t.Album, true
+}
+
+// albumArtist names the artist of an album's tracks: their album artist
+// if tagged, else the tracks' artist
+func albumArtist(tracks []*api.Track) string {
+	if tracks[0].AlbumArtist != "" {
+		return tracks[0].AlbumArtist
+	}
+	artist := tracks[0].Artist
+	for _, t := range tracks[1:] {
+		if !strings.EqualFold(t.Artist, artist) {
+			return "Various Artists"
+		}
+	}
+	return artist
+}
+
+// lessAlbumOrder orders tracks as they appear on their albums. Numbered
+// tracks come first; the rest are ordered by title.
+func lessAlbumOrder(a, b *api.Track) bool {
+	if !strings.EqualFold(a.Album, b.Album) {
+		return lessFold(a.Album, b.Album)
+	}
+	if a.DiscNum != b.DiscNum {
+		return a.DiscNum < b.DiscNum
+	}
+	if (a.TrackNum > 0) != (b.TrackNum > 0) {
+		return a.TrackNum > 0
+	}
+	if a.TrackNum != b.TrackNum {
+		return a.TrackNum < b.TrackNum
+	}
+	return lessFold(a.Title, b.Title)
+}
+
+// OnGroupHeader reports whether a group header is selected
+func (v *LibraryView) OnGroupHeader() bool {
+	return v.TrackList.OnGroupHeader()
+}
+
+// ToggleGroup collapses or expands the selected row's group, remembering
+// the choice across refreshes. It reports whether the list is grouped.
+func (v *LibraryView) ToggleGroup() bool {
+	g, ok := v.TrackList.ToggleGroup()
+	if !ok {
+		return false
+	}
+	if g.Collapsed {
+		v.collapsed[g.Key] = true
+	} else {
+		delete(v.collapsed, g.Key)
+	}
+	return true
+}
+
+// groupLabel describes the active grouping for the list title
+func (v *LibraryView) groupLabel() string {
+	if v.GroupBy == GroupNone {
+		return ""
+	}
+	return " · by " + strings.ToLower(v.GroupBy.String())
+}
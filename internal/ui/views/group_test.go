@@ -0,0 +1,97 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func groupLibrary() []*api.Track {
+	return []*api.Track{
+		{ID: "b2", Title: "Second", Artist: "Blur", Album: "Blur", TrackNum: 2},
+		{ID: "a1", Title: "Zeta", Artist: "Abba", Album: "Arrival"},
+		{ID: "b1", Title: "First", Artist: "Blur", Album: "blur", TrackNum: 1},
+		{ID: "u1", Title: "Loose", Artist: "Blur", Album: "Unknown Album"},
+		{ID: "a2", Title: "Alpha", Artist: "Abba", Album: "Arrival"},
+	}
+}
+
+func TestGroupTracks_Album(t *testing.T) {
+	groups := groupTracks(groupLibrary(), GroupAlbum, true, nil)
+
+	want := []struct {
+		title string
+		ids   []string
+	}{
+		{"Blur — Blur", []string{"b1", "b2"}},
+		{"Arrival — Abba", []string{"a2", "a1"}},
+		{"Unknown Album", []string{"u1"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		got := trackIDs(groups[i].Tracks)
+		if groups[i].Title != w.title || len(got) != len(w.ids) {
+			t.Fatalf("group %d = %q %v, want %q %v", i, groups[i].Title, got, w.title, w.ids)
+		}
+		for j := range got {
+			if got[j] != w.ids[j] {
+				t.Errorf("group %q = %v, want %v", w.title, got, w.ids)
+				break
+			}
+		}
+	}
+}
+
+func TestGroupTracks_AlbumsKeyedOnAlbumArtist(t *testing.T) {
+	tracks := []*api.Track{
+		{ID: "q", Title: "One", Artist: "Queen", Album: "Greatest Hits"},
+		{ID: "a", Title: "Two", Artist: "ABBA", Album: "Greatest Hits"},
+		{ID: "c1", Title: "Three", Artist: "Blur", Album: "Now 30", AlbumArtist: "Various Artists"},
+		{ID: "c2", Title: "Four", Artist: "Pulp", Album: "Now 30", AlbumArtist: "Various Artists"},
+	}
+	groups := groupTracks(tracks, GroupAlbum, false, nil)
+	want := []string{"Greatest Hits — Queen", "Greatest Hits — ABBA", "Now 30 — Various Artists"}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		if groups[i].Title != w {
+			t.Errorf("group %d = %q, want %q", i, groups[i].Title, w)
+		}
+	}
+	if len(groups[2].Tracks) != 2 {
+		t.Errorf("compilation split: %v", trackIDs(groups[2].Tracks))
+	}
+}
+
+func TestLibraryView_GroupCollapseAndSearch(t *testing.T) {
+	v := NewLibraryView(80, 40)
+	v.SetTracks(groupLibrary())
+	v.GroupBy = GroupArtist
+	v.Refresh()
+
+	// Collapse the first group (Blur) from its header
+	v.TrackList.SetSelected(0)
+	if !v.OnGroupHeader() || !v.ToggleGroup() {
+		t.Fatal("first row should be a toggleable header")
+	}
+	if got := v.TrackList.RowCount(); got != 4 {
+		t.Fatalf("rows after collapsing = %d, want 4", got)
+	}
+
+	// Stays collapsed across a refresh, but a search expands it
+	v.Refresh()
+	if got := v.TrackList.RowCount(); got != 4 {
+		t.Fatalf("rows after refresh = %d, want 4", got)
+	}
+	v.filterTracks("first")
+	if got := v.TrackList.RowCount(); got != 2 || v.TrackList.RowTrack(1).ID != "b1" {
+		t.Fatalf("search rows = %d, want the Blur header and its match", got)
+	}
+	v.filterTracks("")
+	if got := v.TrackList.RowCount(); got != 4 {
+		t.Errorf("rows after clearing search = %d, want 4 with Blur collapsed again", got)
+	}
+}
@@ -0,0 +1,185 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// HistoryClearMsg is sent to forget every play counted so far
+type HistoryClearMsg struct{}
+
+// HistoryMode is which ranking of played tracks a HistoryView shows
+type HistoryMode int
+
+const (
+	HistoryRecent     HistoryMode = iota // Last played first
+	HistoryMostPlayed                    // Most played first
+)
+
+// HistoryPlays is how a track ranks in a HistoryView
+type HistoryPlays struct {
+	Count      int
+	LastPlayed time.Time
+}
+
+// HistoryView lists the tracks played most recently or most often
+type HistoryView struct {
+	Width       int
+	Height      int
+	TrackList   components.TrackList
+	Mode        HistoryMode
+	BorderStyle lipgloss.Style
+	HelpStyle   lipgloss.Style
+
+	ranks *historyRanks // Shared with the row suffix, so Played can update it
+}
+
+// historyRanks is what the listed tracks' rows show: their plays by file
+// path, and the time their ages are counted back from
+type historyRanks struct {
+	plays map[string]HistoryPlays
+	now   time.Time
+}
+
+// NewHistoryView creates a new view of played tracks ranked by mode
+func NewHistoryView(width, height int, mode HistoryMode) HistoryView {
+	v := HistoryView{
+		Width:     width,
+		Height:    height,
+		TrackList: components.NewTrackList(height-8, width-6),
+		Mode:      mode,
+	}
+	v.SetTheme(components.DefaultTheme)
+	v.SetTracks(nil, nil, time.Time{})
+	return v
+}
+
+// SetTheme styles the view and its track list in t's colors
+func (v *HistoryView) SetTheme(t components.Theme) {
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.HelpStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+	v.TrackList.SetTheme(t)
+}
+
+// SetSize resizes the view and its track list
+func (v *HistoryView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	v.TrackList.Width = width - 6
+	v.TrackList.SetHeight(height - 8)
+}
+
+// SetTracks shows tracks, already ranked, with how often each was played
+// by its file path and how long before now it last was
+func (v *HistoryView) SetTracks(tracks []*api.Track, plays map[string]HistoryPlays, now time.Time) {
+	if plays == nil {
+		plays = make(map[string]HistoryPlays)
+	}
+	ranks := &historyRanks{plays: plays, now: now}
+	mode := v.Mode
+	v.ranks = ranks
+	v.TrackList.RowSuffix = func(track *api.Track) string {
+		p := ranks.plays[track.FilePath]
+		if mode == HistoryMostPlayed {
+			return fmt.Sprintf("  ×%d", p.Count)
+		}
+		return "  " + timeAgo(ranks.now.Sub(p.LastPlayed))
+	}
+	v.show(tracks)
+}
+
+// Played moves track to where its latest play, p, ranks it, keeping at
+// most limit tracks listed, without going back over every play
+func (v *HistoryView) Played(track *api.Track, p HistoryPlays, limit int) {
+	v.ranks.plays[track.FilePath] = p
+	v.ranks.now = p.LastPlayed
+
+	tracks := slices.DeleteFunc(slices.Clone(v.TrackList.Items), func(t *api.Track) bool {
+		return t.FilePath == track.FilePath
+	})
+	at := 0 // The latest play is the most recent, and first of its count
+	if v.Mode == HistoryMostPlayed {
+		at = len(tracks)
+		for i, t := range tracks {
+			if v.ranks.plays[t.FilePath].Count <= p.Count {
+				at = i
+				break
+			}
+		}
+	}
+	tracks = slices.Insert(tracks, at, track)
+	if limit > 0 && len(tracks) > limit {
+		tracks = tracks[:limit]
+	}
+	v.show(tracks)
+}
+
+// show lists tracks, keeping the selection where it was
+func (v *HistoryView) show(tracks []*api.Track) {
+	selected := v.TrackList.Selected
+	v.TrackList.SetItems(tracks)
+	v.TrackList.SetSelected(min(selected, max(len(tracks)-1, 0)))
+	title := "🕘 Recently Played"
+	if v.Mode == HistoryMostPlayed {
+		title = "🏆 Most Played"
+	}
+	v.TrackList.Title = fmt.Sprintf("%s (%d tracks)", title, len(tracks))
+}
+
+// timeAgo describes an age the way "5m ago" or "3d ago" does
+func timeAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
+
+// SelectedTrack returns the selected track, or nil if there are none
+func (v *HistoryView) SelectedTrack() *api.Track {
+	return v.TrackList.SelectedItem()
+}
+
+// Update handles messages. Clearing is sent to the app, which owns the
+// play stats.
+func (v HistoryView) Update(msg tea.Msg) (HistoryView, tea.Cmd) {
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		return v, listMouse(&v.TrackList, v.BorderStyle, 0, mouse)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "C" {
+		if len(v.TrackList.Items) == 0 {
+			return v, nil
+		}
+		return v, func() tea.Msg { return HistoryClearMsg{} }
+	}
+	v.TrackList, _ = v.TrackList.Update(msg)
+	return v, nil
+}
+
+// View renders the history view
+func (v HistoryView) View() string {
+	var sb strings.Builder
+	sb.WriteString(v.TrackList.View())
+	sb.WriteString("\n\n")
+	other := "Most played"
+	if v.Mode == HistoryMostPlayed {
+		other = "Recently played"
+	}
+	sb.WriteString(v.HelpStyle.Render("[Enter] Play  [5] " + other + "  [C] Clear play history  [↑↓] Navigate"))
+	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
+}
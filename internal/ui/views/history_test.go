@@ -0,0 +1,77 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestHistoryView_Modes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracks := []*api.Track{{ID: "a", Title: "A", FilePath: "/a.mp3"}, {ID: "b", Title: "B", FilePath: "/b.mp3"}}
+	plays := map[string]HistoryPlays{
+		"/a.mp3": {Count: 7, LastPlayed: now.Add(-3 * time.Hour)},
+		"/b.mp3": {Count: 1, LastPlayed: now.Add(-30 * time.Second)},
+	}
+
+	v := NewHistoryView(80, 30, HistoryRecent)
+	v.SetTracks(tracks, plays, now)
+	out := v.View()
+	if !strings.Contains(out, "Recently Played (2 tracks)") || !strings.Contains(out, "A  3h ago") || !strings.Contains(out, "B  just now") {
+		t.Errorf("recent view:\n%s", out)
+	}
+
+	v.Mode = HistoryMostPlayed
+	v.SetTracks(tracks, plays, now)
+	if out := v.View(); !strings.Contains(out, "Most Played") || !strings.Contains(out, "A  ×7") {
+		t.Errorf("most played view:\n%s", out)
+	}
+
+	if _, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")}); cmd == nil {
+		t.Error("C sent nothing")
+	} else if _, ok := cmd().(HistoryClearMsg); !ok {
+		t.Errorf("C sent %#v", cmd())
+	}
+}
+
+func TestHistoryView_PlayedMovesTrackUp(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := &api.Track{ID: "a", Title: "A", FilePath: "/a.mp3"}
+	b := &api.Track{ID: "b", Title: "B", FilePath: "/b.mp3"}
+	c := &api.Track{ID: "c", Title: "C", FilePath: "/c.mp3"}
+	plays := map[string]HistoryPlays{
+		"/a.mp3": {Count: 5, LastPlayed: now.Add(-time.Hour)},
+		"/b.mp3": {Count: 2, LastPlayed: now.Add(-2 * time.Hour)},
+	}
+	order := func(v HistoryView) string {
+		var titles []string
+		for _, t := range v.TrackList.Items {
+			titles = append(titles, t.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	v := NewHistoryView(80, 30, HistoryMostPlayed)
+	v.SetTracks([]*api.Track{a, b}, plays, now)
+	v.Played(b, HistoryPlays{Count: 5, LastPlayed: now}, 0)
+	if got := order(v); got != "B,A" {
+		t.Errorf("most played after a tying play = %s, want B,A", got)
+	}
+	v.Played(c, HistoryPlays{Count: 1, LastPlayed: now}, 0)
+	if got := order(v); got != "B,A,C" {
+		t.Errorf("most played after a first play = %s, want B,A,C", got)
+	}
+
+	v = NewHistoryView(80, 30, HistoryRecent)
+	v.SetTracks([]*api.Track{a, b}, plays, now)
+	v.Played(c, HistoryPlays{Count: 1, LastPlayed: now}, 2)
+	if got := order(v); got != "C,A" {
+		t.Errorf("recent after a play = %s, want C,A", got)
+	}
+	if out := v.View(); !strings.Contains(out, "C  just now") || !strings.Contains(out, "(2 tracks)") {
+		t.Errorf("recent view:\n%s", out)
+	}
+}
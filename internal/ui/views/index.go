@@ -0,0 +1,166 @@
+package views
+
+import (
+	"sort"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// indexMinTracks is the library size below which a plain linear scan is
+// fast enough and the index isn't consulted
+const indexMinTracks = 1000
+
+// searchIndex indexes the lowercased Title, Artist and Album of each track,
+// without their accents unless exactAccents, by trigram and by rune. A
+// query is narrowed to the tracks containing all trigrams of its exact
+// terms and all runes of its fuzzy terms; callers still run the full match
+// on those candidates, against the lowercased text kept here so it isn't
+// recomputed on every keystroke.
+type searchIndex struct {
+	exactAccents bool
+	tracks       []*api.Track
+	text         []trackText      // Lowercased fields, parallel to tracks
+	postings     map[string][]int // trigram -> ascending positions in tracks
+	runes        map[rune][]int   // rune -> ascending positions in tracks
+}
+
+// trackText holds a track's Title, Artist and Album, lowercased
+type trackText [3]lowerText
+
+// lowerText is one lowercased field, as a string for exact matching and as
+// runes for fuzzy matching
+type lowerText struct {
+	s     string
+	runes []rune
+}
+
+// newTrackText lowercases the searchable fields of t, folding their
+// accents away unless exactAccents
+func newTrackText(t *api.Track, exactAccents bool) trackText {
+	var text trackText
+	for i, field := range []string{t.Title, t.Artist, t.Album} {
+		runes, _ := foldText(field, exactAccents)
+		text[i] = lowerText{s: string(runes), runes: runes}
+	}
+	return text
+}
+
+// newSearchIndex builds an index over tracks, matching accents exactly
+// with exactAccents
+func newSearchIndex(tracks []*api.Track, exactAccents bool) *searchIndex {
+	idx := &searchIndex{
+		exactAccents: exactAccents,
+		postings:     make(map[string][]int),
+		runes:        make(map[rune][]int),
+	}
+	for _, t := range tracks {
+		idx.add(t)
+	}
+	return idx
+}
+
+// add appends a track to the index
+func (idx *searchIndex) add(t *api.Track) {
+	pos := len(idx.tracks)
+	text := newTrackText(t, idx.exactAccents)
+	idx.tracks = append(idx.tracks, t)
+	idx.text = append(idx.text, text)
+	for _, field := range text {
+		lower := field.s
+		// A trigram or rune can repeat within a track; record the track once
+		for _, tri := range trigrams(lower) {
+			if list := idx.postings[tri]; len(list) == 0 || list[len(list)-1] != pos {
+				idx.postings[tri] = append(list, pos)
+			}
+		}
+		for _, r := range lower {
+			if list := idx.runes[r]; len(list) == 0 || list[len(list)-1] != pos {
+				idx.runes[r] = append(list, pos)
+			}
+		}
+	}
+}
+
+// candidates returns the positions of the tracks that may match the query
+// terms, in library order. ok is false when the index can't narrow the
+// search (no terms, or a library too small to bother), in which case the
+// caller should scan every track.
+func (idx *searchIndex) candidates(terms []searchTerm) (positions []int, ok bool) {
+	if idx == nil || len(idx.tracks) < indexMinTracks || len(terms) == 0 {
+		return nil, false
+	}
+
+	var lists [][]int
+	for _, term := range terms {
+		if term.re != nil {
+			// Regular expressions are matched against every candidate
+			continue
+		}
+		if term.exact && len(term.runes) >= 3 {
+			for _, tri := range trigrams(term.text) {
+				list, found := idx.postings[tri]
+				if !found {
+					return nil, true
+				}
+				lists = append(lists, list)
+			}
+			continue
+		}
+		for _, r := range distinctRunes(term.text) {
+			list, found := idx.runes[r]
+			if !found {
+				return nil, true
+			}
+			lists = append(lists, list)
+		}
+	}
+	if len(lists) == 0 {
+		return nil, false
+	}
+	// Intersect starting from the rarest trigram to keep the work small
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	positions = lists[0]
+	for _, list := range lists[1:] {
+		positions = intersect(positions, list)
+		if len(positions) == 0 {
+			return nil, true
+		}
+	}
+	return positions, true
+}
+
+// trigrams returns the distinct three-rune substrings of s
+func trigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < 3 {
+		return nil
+	}
+	seen := make(map[string]bool, len(runes)-2)
+	grams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		tri := string(runes[i : i+3])
+		if !seen[tri] {
+			seen[tri] = true
+			grams = append(grams, tri)
+		}
+	}
+	return grams
+}
+
+// intersect returns the values present in both ascending slices
+func intersect(a, b []int) []int {
+	out := make([]int, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
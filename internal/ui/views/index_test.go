@@ -0,0 +1,139 @@
+package views
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+var indexWords = []string{
+	"love", "night", "blue", "river", "fire", "dream", "heart", "shadow",
+	"summer", "rain", "golden", "echo", "city", "wild", "ocean", "stone",
+}
+
+// syntheticLibrary builds n tracks with pseudo-random word titles
+func syntheticLibrary(n int) []*api.Track {
+	r := rand.New(rand.NewSource(1))
+	word := func() string { return indexWords[r.Intn(len(indexWords))] }
+	tracks := make([]*api.Track, n)
+	for i := range tracks {
+		tracks[i] = &api.Track{
+			ID:     fmt.Sprint(i),
+			Title:  fmt.Sprintf("%s %s %d", word(), word(), i),
+			Artist: fmt.Sprintf("The %s %ss", word(), word()),
+			Album:  fmt.Sprintf("%s of %s", word(), word()),
+		}
+	}
+	return tracks
+}
+
+func linearSearch(tracks []*api.Track, q searchQuery) []*api.Track {
+	var out []*api.Track
+	for _, t := range tracks {
+		if q.matches(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func TestSearchIndex_MatchesLinearScan(t *testing.T) {
+	tracks := syntheticLibrary(5000)
+	v := NewLibraryView(80, 24)
+	v.SetTracks(tracks[:4000])
+	for _, tr := range tracks[4000:] {
+		v.AddTrack(tr)
+	}
+
+	for _, raw := range []string{"river", "golden echo", "wild oc", "4321", "zzz", "lo", "shadow bpm:>0", "'river", "'golden 'ech", "rvr", "'zzz"} {
+		q := parseQuery(raw)
+		got, _ := v.searchTracks(q)
+		want := linearSearch(tracks, q)
+		if len(got) != len(want) {
+			t.Errorf("%q: %d results, want %d", raw, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%q: result %d = %s, want %s", raw, i, got[i].ID, want[i].ID)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchIndex_SmallLibraryScans(t *testing.T) {
+	idx := newSearchIndex(syntheticLibrary(10), false)
+	if _, ok := idx.candidates(parseQuery("river").terms); ok {
+		t.Error("index should not be used below indexMinTracks")
+	}
+}
+
+func BenchmarkSearch100k(b *testing.B) {
+	tracks := syntheticLibrary(100000)
+	v := NewLibraryView(80, 24)
+	v.SetTracks(tracks)
+	q := parseQuery("'99999")
+
+	b.Run("linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			linearSearch(tracks, q)
+		}
+	})
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v.searchTracks(q)
+		}
+	})
+	b.Run("fuzzy", func(b *testing.B) {
+		fq := parseQuery("rvr99")
+		for i := 0; i < b.N; i++ {
+			v.searchTracks(fq)
+		}
+	})
+}
+
+// BenchmarkFilterKeystroke40k times one live-filter pass per keystroke of a
+// fuzzy query, scoring every track as the small-library path does.
+// "rescan" lowercases each track's fields per pass, "prebuilt" uses the
+// text lowercased when the index was built.
+func BenchmarkFilterKeystroke40k(b *testing.B) {
+	tracks := syntheticLibrary(40000)
+	v := NewLibraryView(80, 24)
+	v.SetTracks(tracks)
+	var queries []searchQuery
+	for _, prefix := range []string{"r", "ri", "riv", "rive", "river"} {
+		queries = append(queries, parseQuery(prefix))
+	}
+
+	b.Run("rescan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			linearSearch(tracks, queries[i%len(queries)])
+		}
+	})
+	b.Run("prebuilt", func(b *testing.B) {
+		idx := v.index
+		for i := 0; i < b.N; i++ {
+			q := queries[i%len(queries)]
+			for pos, track := range idx.tracks {
+				q.scoreText(track, &idx.text[pos])
+			}
+		}
+	})
+}
+
+func TestSearchIndex_StaysInSync(t *testing.T) {
+	v := NewLibraryView(80, 24)
+	v.SetTracks(syntheticLibrary(indexMinTracks))
+	v.AddTrack(&api.Track{ID: "new", Title: "Zzyzx Road"})
+
+	got, _ := v.searchTracks(parseQuery("zzyzx"))
+	if len(got) != 1 || got[0].ID != "new" {
+		t.Fatalf("search after AddTrack = %v, want [new]", trackIDs(got))
+	}
+	if len(v.index.text) != len(v.AllTracks) {
+		t.Errorf("index has text for %d tracks, want %d", len(v.index.text), len(v.AllTracks))
+	}
+}
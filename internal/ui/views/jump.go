@@ -0,0 +1,107 @@
+package views
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// jumpTimeout is how soon the next letter must follow to extend the prefix
+// rather than start a new one
+const jumpTimeout = time.Second
+
+// typeAhead is the state of jump-to-letter navigation: the prefix typed so
+// far and when its last letter was typed
+type typeAhead struct {
+	prefix  string
+	last    time.Time
+	noMatch bool
+}
+
+// updateJump handles a key in jump mode. Letters and digits jump; any other
+// key leaves jump mode, navigation keys still moving the list.
+func (v LibraryView) updateJump(msg tea.KeyMsg, now time.Time) LibraryView {
+	switch {
+	case msg.Type == tea.KeyRunes && len(msg.Runes) == 1:
+		v.typeAheadKey(string(msg.Runes), now)
+	case msg.Type == tea.KeyBackspace && v.jump.prefix != "":
+		runes := []rune(v.jump.prefix)
+		v.jump.prefix = string(runes[:len(runes)-1])
+		v.jump.last = now
+		if v.jump.prefix != "" {
+			v.jumpToPrefix(0)
+		}
+	default:
+		v.Jumping = false
+		v.jump = typeAhead{}
+		if s := msg.String(); s != "esc" && s != "enter" {
+			v.TrackList, _ = v.TrackList.Update(msg)
+		}
+	}
+	return v
+}
+
+// typeAheadKey applies one typed letter. Repeating a single letter cycles
+// through its matches; a different letter within jumpTimeout extends the
+// prefix, and otherwise starts a new one from the top of the list.
+func (v *LibraryView) typeAheadKey(key string, now time.Time) {
+	key = strings.ToLower(key)
+	from := 0
+	switch {
+	case v.jump.prefix == key:
+		from = v.TrackList.Selected + 1
+	case v.jump.prefix != "" && now.Sub(v.jump.last) < jumpTimeout:
+		// The current match may still match the longer prefix
+		key = v.jump.prefix + key
+		from = v.TrackList.Selected
+	}
+	v.jump.prefix, v.jump.last = key, now
+	v.jumpToPrefix(from)
+}
+
+// jumpToPrefix selects the first listed track at or after from, wrapping
+// around, whose jump text starts with the current prefix. The listed tracks
+// are the filtered and sorted ones, not AllTracks; group headers and
+// collapsed groups are skipped.
+func (v *LibraryView) jumpToPrefix(from int) {
+	rows := v.TrackList.RowCount()
+	for i := 0; i < rows; i++ {
+		idx := (from + i) % rows
+		track := v.TrackList.RowTrack(idx)
+		if track != nil && strings.HasPrefix(strings.ToLower(v.jumpText(track)), v.jump.prefix) {
+			v.TrackList.SetSelected(idx)
+			v.jump.noMatch = false
+			return
+		}
+	}
+	v.jump.noMatch = true
+}
+
+// jumpText is what a track is matched by: the sort field's value when
+// sorted by artist, album or BPM, otherwise its title
+func (v *LibraryView) jumpText(t *api.Track) string {
+	switch v.SortField {
+	case SortArtist:
+		return t.Artist
+	case SortAlbum:
+		return t.Album
+	case SortBPM:
+		if t.BPM > 0 {
+			return strconv.FormatFloat(math.Round(t.BPM), 'f', 0, 64)
+		}
+	}
+	return t.Title
+}
+
+// jumpHelp describes jump mode for the help line
+func (v *LibraryView) jumpHelp() string {
+	help := "Jump: " + v.jump.prefix + "_"
+	if v.jump.noMatch {
+		help += " (no match)"
+	}
+	return help + "  [letter again] Next match  [Esc] Done"
+}
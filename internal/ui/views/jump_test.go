@@ -0,0 +1,98 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func jumpView() LibraryView {
+	v := NewLibraryView(80, 40)
+	v.SetTracks([]*api.Track{
+		{ID: "1", Title: "Alpha"},
+		{ID: "2", Title: "Bravo", BPM: 128},
+		{ID: "3", Title: "beta", BPM: 90},
+		{ID: "4", Title: "Charlie"},
+		{ID: "5", Title: "Bells"},
+	})
+	v.Jumping = true
+	return v
+}
+
+func typeKey(v LibraryView, key string, at time.Time) LibraryView {
+	return v.updateJump(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}, at)
+}
+
+func TestJump_CyclesSameLetter(t *testing.T) {
+	v := jumpView()
+	now := time.Now()
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		v = typeKey(v, "b", now.Add(time.Duration(i)*2*time.Second))
+		got = append(got, v.SelectedTrack().Title)
+	}
+	want := []string{"Bravo", "beta", "Bells", "Bravo"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("b presses selected %v, want %v", got, want)
+		}
+	}
+}
+
+func TestJump_MultiLetterPrefix(t *testing.T) {
+	v := jumpView()
+	now := time.Now()
+
+	v = typeKey(v, "b", now)
+	v = typeKey(v, "e", now.Add(300*time.Millisecond))
+	if got := v.SelectedTrack().Title; got != "beta" {
+		t.Fatalf("\"be\" selected %q, want beta", got)
+	}
+	v = typeKey(v, "l", now.Add(600*time.Millisecond))
+	if got := v.SelectedTrack().Title; got != "Bells" {
+		t.Fatalf("\"bel\" selected %q, want Bells", got)
+	}
+
+	// After the timeout a letter starts a new prefix
+	v = typeKey(v, "c", now.Add(3*time.Second))
+	if got := v.SelectedTrack().Title; got != "Charlie" {
+		t.Fatalf("\"c\" after timeout selected %q, want Charlie", got)
+	}
+}
+
+func TestJump_UsesListedTracks(t *testing.T) {
+	v := jumpView()
+	v.filterTracks("bpm:>100")
+
+	v = typeKey(v, "b", time.Now())
+	if got := v.SelectedTrack().Title; got != "Bravo" {
+		t.Fatalf("selected %q, want the only listed b track", got)
+	}
+	v = typeKey(v, "a", time.Now().Add(2*time.Second))
+	if !v.jump.noMatch || v.SelectedTrack().Title != "Bravo" {
+		t.Error("a filtered-out match should leave the selection alone")
+	}
+}
+
+func TestJump_BySortField(t *testing.T) {
+	v := jumpView()
+	v.SortField = SortBPM
+	v.Refresh()
+
+	v = typeKey(v, "9", time.Now())
+	if got := v.SelectedTrack().Title; got != "beta" {
+		t.Fatalf("\"9\" sorted by BPM selected %q, want beta", got)
+	}
+}
+
+func TestJump_EscLeaves(t *testing.T) {
+	v := jumpView()
+	v = typeKey(v, "c", time.Now())
+	v = v.updateJump(tea.KeyMsg{Type: tea.KeyEsc}, time.Now())
+	if v.Jumping || v.SelectedTrack().Title != "Charlie" {
+		t.Error("Esc should leave jump mode keeping the selection")
+	}
+}
@@ -1,11 +1,15 @@
 package views
 
 import (
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/library"
 	"github.com/jscyril/golang_music_player/internal/ui/components"
 )
 
@@ -14,57 +18,308 @@ type FileAddedMsg struct {
 	Path string
 }
 
+// QueueFolderMsg is sent when a directory in the file browser should be
+// recursively scanned and appended to the playback queue
+type QueueFolderMsg struct {
+	Path string
+}
+
+// PlayNextMsg is sent when a track should be queued right after the
+// current one
+type PlayNextMsg struct {
+	Track *api.Track
+}
+
+// EnqueueMsg is sent when tracks should be added to the end of the queue
+// without interrupting what is playing
+type EnqueueMsg struct {
+	Tracks []*api.Track
+}
+
+// PinMsg is sent to pin a track as "up next", or unpin it if it already is
+type PinMsg struct {
+	Track *api.Track
+}
+
+// CheckAvailabilityMsg is sent when track availability should be re-checked,
+// e.g. after hiding offline tracks was switched on
+type CheckAvailabilityMsg struct{}
+
+// QueueTracksMsg is sent to queue a batch of tracks, such as the current
+// search results. Replace swaps out the whole queue instead of appending.
+type QueueTracksMsg struct {
+	Tracks  []*api.Track
+	Replace bool
+}
+
+// AddToPlaylistMsg is sent to append tracks to the saved playlist last
+// picked in the playlist view
+type AddToPlaylistMsg struct {
+	Tracks []*api.Track
+}
+
+// OrganizeMsg is sent to preview moving tracks' files into the configured
+// tag-based layout
+type OrganizeMsg struct {
+	Tracks []*api.Track
+}
+
+// SavePlaylistMsg is sent to save tracks, such as the current search
+// results, to a playlist file
+type SavePlaylistMsg struct {
+	Tracks []*api.Track
+}
+
+// AnalyzeBPMMsg is sent when the user requests tempo detection for one or
+// more tracks
+type AnalyzeBPMMsg struct {
+	Tracks []*api.Track
+	Force  bool // Detect again over a BPM detected earlier; tagged ones are kept regardless
+}
+
+// SearchHistoryMsg is sent when a query has been added to the search
+// history, so it can be saved. History is most recent first.
+type SearchHistoryMsg struct {
+	History []string
+}
+
+// SearchDebounceMsg is delivered once typing in the search bar has paused,
+// to apply the query. Only the latest one takes effect.
+type SearchDebounceMsg struct {
+	seq int
+}
+
+// searchDebounce is how long typing must pause before the query is applied
+const searchDebounce = 100 * time.Millisecond
+
+// listPosition is a saved cursor and scroll offset of the track list
+type listPosition struct {
+	cursor, offset int
+}
+
 // LibraryView displays the music library
 type LibraryView struct {
-	Width       int
-	Height      int
-	TrackList   components.TrackList
-	SearchBar   components.SearchInput
-	FileBrowser components.FileBrowser
-	Searching   bool
-	Browsing    bool // True when file browser is open
-	AllTracks   []*api.Track
-	BorderStyle lipgloss.Style
-	TitleStyle  lipgloss.Style
+	Width        int
+	Height       int
+	TrackList    components.TrackList
+	SearchBar    components.SearchInput
+	FileBrowser  components.FileBrowser
+	ListDir      components.ListDirFunc // Lists folders for the file browser, with the library's scan options
+	Searching    bool
+	Browsing     bool // True when file browser is open
+	Jumping      bool // True while typing a jump-to-letter prefix
+	jump         typeAhead
+	AllTracks    []*api.Track
+	index        *searchIndex // Search index and lowercased text of AllTracks
+	SortField    SortField
+	SortDesc     bool
+	GroupBy      GroupField
+	collapsed    map[string]bool // Keys of collapsed groups
+	searchSeq    int             // Bumped on every search edit; stale debounce ticks are ignored
+	browsePos    *listPosition   // Where the unfiltered list was when a search began
+	searchErr    error           // Why part of the search was left out, e.g. a bad regexp
+	ShowDetails  bool            // Details panel for the selected track
+	analyzing    map[string]bool // IDs of tracks with analysis still pending
+	HideOffline  bool            // Hide tracks whose volume isn't mounted
+	ExactAccents bool            // Search matches accents as typed, so "bjork" doesn't find "Björk"
+	Follow       bool            // Select each track as it starts playing
+	Loading      bool            // The library is still being scanned in
+	playing      *api.Track      // Track playing, set with SetPlaying
+	unavailable  map[string]library.Availability
+	unplayable   map[string]bool // Tracks that have failed to play, by ID
+	rowTint      RowTint         // What list rows are colored by
+	BorderStyle  lipgloss.Style
+	TitleStyle   lipgloss.Style
+	theme        components.Theme
 }
 
 // NewLibraryView creates a new library view
 func NewLibraryView(width, height int) LibraryView {
 	trackList := components.NewTrackList(height-8, width-6)
 	trackList.Title = "🎵 Library"
+	analyzing := make(map[string]bool)
+	unplayable := make(map[string]bool)
+	unavailable := make(map[string]library.Availability)
+	trackList.RowSuffix = func(t *api.Track) string {
+		var suffix string
+		if analyzing[t.ID] {
+			suffix += "  analyzing..."
+		}
+		if a, ok := unavailable[t.ID]; ok {
+			suffix += "  [" + a.String() + "]"
+		} else if unplayable[t.ID] {
+			suffix += "  [unplayable]"
+		}
+		return suffix
+	}
 
-	return LibraryView{
+	v := LibraryView{
 		Width:       width,
 		Height:      height,
 		TrackList:   trackList,
 		SearchBar:   components.NewSearchInput(width - 6),
-		FileBrowser: components.NewFileBrowser("", width, height),
+		FileBrowser: newFileBrowser(width, height, nil),
 		AllTracks:   make([]*api.Track, 0),
-		BorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Padding(1, 2),
-		TitleStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("212")),
+		analyzing:   analyzing,
+		unavailable: unavailable,
+		unplayable:  unplayable,
+		collapsed:   make(map[string]bool),
 	}
+	v.SetTheme(components.DefaultTheme)
+	return v
+}
+
+// SetTheme styles the view and everything in it in t's colors
+func (v *LibraryView) SetTheme(t components.Theme) {
+	v.theme = t
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent)
+	v.TrackList.SetTheme(t)
+	v.SearchBar.SetTheme(t)
+	v.FileBrowser.SetTheme(t)
+	v.applyRowTint()
 }
 
-// SetTracks sets the library tracks
+// newFileBrowser creates a file browser showing the files the library
+// scans, listing folders with listDir if it is set
+func newFileBrowser(width, height int, listDir components.ListDirFunc) components.FileBrowser {
+	fb := components.NewFileBrowser("", width, height)
+	fb.Extensions = library.SupportedExtensions
+	fb.ListDir = listDir
+	fb.Navigate(fb.CurrentPath)
+	return fb
+}
+
+// SetTracks sets the library tracks. A search in progress is re-run over
+// them.
 func (v *LibraryView) SetTracks(tracks []*api.Track) {
 	v.AllTracks = tracks
-	v.TrackList.SetItems(tracks)
+	v.index = newSearchIndex(tracks, v.ExactAccents)
+	v.Refresh()
+}
+
+// ReplaceTracks swaps in a whole new set of library tracks, as when the
+// library moves to another folder: the search and marks are cleared and
+// the list starts again from the top
+func (v *LibraryView) ReplaceTracks(tracks []*api.Track) {
+	v.Searching = false
+	v.SearchBar.Blur()
+	v.SearchBar.Clear()
+	v.searchSeq++
+	v.browsePos = nil
+	v.TrackList.ClearMarks()
+	v.SetTracks(tracks)
+	v.TrackList.SetCursor(0)
+	v.TrackList.SetOffset(0)
 }
 
-// AddTrack adds a track to the view
+// AddTrack adds a track to the view, listing it among the results if a
+// search in progress matches it
 func (v *LibraryView) AddTrack(track *api.Track) {
 	v.AllTracks = append(v.AllTracks, track)
-	v.TrackList.SetItems(v.AllTracks)
+	if v.index == nil {
+		v.index = newSearchIndex(nil, v.ExactAccents)
+	}
+	v.index.add(track)
+	v.Refresh()
+}
+
+// UpdateTracks adds and removes library tracks in place, e.g. as files
+// appear in or vanish from the music folders, keeping the current search,
+// sort and selection
+func (v *LibraryView) UpdateTracks(added, removed []*api.Track) {
+	// Added tracks already listed, such as those of a scan picked up
+	// again, replace them rather than being listed twice
+	if len(added) > 0 && len(v.AllTracks) > 0 {
+		adding := make(map[string]bool, len(added))
+		for _, t := range added {
+			adding[t.ID] = true
+		}
+		for _, t := range v.AllTracks {
+			if adding[t.ID] {
+				removed = append(slices.Clip(removed), t)
+			}
+		}
+	}
+	if len(removed) > 0 {
+		gone := make(map[string]bool, len(removed))
+		for _, t := range removed {
+			gone[t.ID] = true
+		}
+		kept := make([]*api.Track, 0, len(v.AllTracks))
+		for _, t := range v.AllTracks {
+			if !gone[t.ID] {
+				kept = append(kept, t)
+			}
+		}
+		v.AllTracks = append(kept, added...)
+		v.index = newSearchIndex(v.AllTracks, v.ExactAccents)
+	} else {
+		v.AllTracks = append(v.AllTracks, added...)
+		if v.index == nil {
+			v.index = newSearchIndex(nil, v.ExactAccents)
+		}
+		for _, t := range added {
+			v.index.add(t)
+		}
+	}
+	v.Refresh()
+}
+
+// SetSize resizes the view and its track list and file browser
+func (v *LibraryView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	listHeight := height - 8
+	if v.ShowDetails {
+		listHeight -= detailsHeight
+	}
+	v.TrackList.Width = width - 6
+	v.TrackList.SetHeight(listHeight)
+	v.SearchBar.Width = width - 6
+	v.FileBrowser.Width = width
+	v.FileBrowser.Height = height
+	v.FileBrowser.Breadcrumb.Width = width - 10
+}
+
+// listTop returns the line of the border's content the track list starts
+// on: below the search bar, laid out as View lays it out, and a blank line
+func (v LibraryView) listTop() int {
+	width := max(v.Width-4-v.BorderStyle.GetHorizontalPadding(), 1)
+	return lipgloss.Height(lipgloss.NewStyle().Width(width).Render(v.SearchBar.View())) + 1
+}
+
+// OpenBrowser opens the file browser, to add files or queue folders
+func (v *LibraryView) OpenBrowser() {
+	v.Browsing = true
+	v.FileBrowser = newFileBrowser(v.Width, v.Height, v.ListDir)
+	v.FileBrowser.SetTheme(v.theme)
+}
+
+// Refresh re-applies the current search and sort, e.g. after track data
+// such as BPM has changed
+func (v *LibraryView) Refresh() {
+	v.filterTracks(v.SearchBar.Value)
 }
 
 // Update handles messages
 func (v LibraryView) Update(msg tea.Msg) (LibraryView, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if v.Browsing {
+			return v, nil
+		}
+		return v, listMouse(&v.TrackList, v.BorderStyle, v.listTop(), msg)
+	case SearchDebounceMsg:
+		if msg.seq == v.searchSeq {
+			v.filterTracks(v.SearchBar.Value)
+		}
+		return v, nil
 	case tea.KeyMsg:
 		// Handle file browser mode
 		if v.Browsing {
@@ -84,42 +339,196 @@ func (v LibraryView) Update(msg tea.Msg) (LibraryView, tea.Cmd) {
 				}
 				// Otherwise it was a directory navigation, stay in browser
 				return v, nil
+			case "A":
+				// Queue the whole folder subtree, staying in the browser
+				entry := v.FileBrowser.SelectedEntry()
+				if entry != nil && entry.IsDir && entry.Name != ".." {
+					dir := entry.Path
+					return v, func() tea.Msg {
+						return QueueFolderMsg{Path: dir}
+					}
+				}
+				return v, nil
 			default:
 				v.FileBrowser, _ = v.FileBrowser.Update(msg)
 			}
 			return v, nil
 		}
 
+		if v.Jumping {
+			return v.updateJump(msg, time.Now()), nil
+		}
+
 		// Handle search mode
 		if v.Searching {
 			switch msg.String() {
 			case "enter", "esc":
 				v.Searching = false
 				v.SearchBar.Blur()
-				// Filter tracks based on search
-				if v.SearchBar.Value != "" {
-					v.filterTracks(v.SearchBar.Value)
-				} else {
-					v.TrackList.SetItems(v.AllTracks)
+				// Filter tracks based on search, superseding any pending tick
+				v.searchSeq++
+				v.filterTracks(v.SearchBar.Value)
+				if msg.String() == "enter" && v.SearchBar.Remember(v.SearchBar.Value) {
+					history := v.SearchBar.History()
+					return v, func() tea.Msg {
+						return SearchHistoryMsg{History: history}
+					}
 				}
 				return v, nil
 			default:
+				v.saveBrowsePos()
 				v.SearchBar, _ = v.SearchBar.Update(msg)
-				// Live filtering
-				v.filterTracks(v.SearchBar.Value)
+				return v, v.debounceSearch()
 			}
 		} else {
 			// Normal mode
 			switch msg.String() {
 			case "/":
-				v.Searching = true
-				v.SearchBar.Focus()
+				v.StartSearch()
+				return v, nil
+			case "g":
+				// Jump to a track by typing the start of its title
+				v.Jumping = true
+				v.jump = typeAhead{}
 				return v, nil
 			case "a":
-				// Open file browser
-				v.Browsing = true
-				v.FileBrowser = components.NewFileBrowser("", v.Width, v.Height)
+				v.OpenBrowser()
 				return v, nil
+			case "o":
+				v.SortField = v.SortField.Next()
+				v.Refresh()
+				return v, nil
+			case "O":
+				v.SortDesc = !v.SortDesc
+				v.Refresh()
+				return v, nil
+			case "G":
+				v.GroupBy = v.GroupBy.Next()
+				v.Refresh()
+				return v, nil
+			case "x":
+				v.TrackList.ToggleMark()
+				v.TrackList.MoveDown()
+				return v, nil
+			case "X":
+				v.TrackList.MarkAll()
+				return v, nil
+			case "esc":
+				v.TrackList.ClearMarks()
+				return v, nil
+			case "N":
+				if track := v.SelectedTrack(); track != nil {
+					return v, func() tea.Msg {
+						return PlayNextMsg{Track: track}
+					}
+				}
+				return v, nil
+			case "e":
+				// Enqueue the marked tracks, or the selected one
+				tracks := v.TrackList.SelectedItems()
+				if track := v.SelectedTrack(); len(tracks) == 0 && track != nil {
+					tracks = []*api.Track{track}
+				}
+				if len(tracks) == 0 {
+					return v, nil
+				}
+				return v, func() tea.Msg {
+					return EnqueueMsg{Tracks: tracks}
+				}
+			case "P":
+				// Add the marked tracks, or the selected one, to a playlist
+				tracks := v.TrackList.SelectedItems()
+				if track := v.SelectedTrack(); len(tracks) == 0 && track != nil {
+					tracks = []*api.Track{track}
+				}
+				if len(tracks) == 0 {
+					return v, nil
+				}
+				return v, func() tea.Msg {
+					return AddToPlaylistMsg{Tracks: tracks}
+				}
+			case "ctrl+p":
+				if track := v.SelectedTrack(); track != nil {
+					return v, func() tea.Msg {
+						return PinMsg{Track: track}
+					}
+				}
+				return v, nil
+			case "Q", "ctrl+q":
+				tracks := v.ActionTracks()
+				if len(tracks) == 0 {
+					return v, nil
+				}
+				replace := msg.String() == "ctrl+q"
+				return v, func() tea.Msg {
+					return QueueTracksMsg{Tracks: tracks, Replace: replace}
+				}
+			case "M":
+				tracks := v.ActionTracks()
+				if len(tracks) == 0 {
+					return v, nil
+				}
+				return v, func() tea.Msg {
+					return OrganizeMsg{Tracks: tracks}
+				}
+			case "W":
+				tracks := v.ActionTracks()
+				if len(tracks) == 0 {
+					return v, nil
+				}
+				return v, func() tea.Msg {
+					return SavePlaylistMsg{Tracks: tracks}
+				}
+			case "H":
+				v.HideOffline = !v.HideOffline
+				v.Refresh()
+				if v.HideOffline {
+					return v, func() tea.Msg {
+						return CheckAvailabilityMsg{}
+					}
+				}
+				return v, nil
+			case "f":
+				// Go to the playing track, clearing a search that hides it
+				if v.playing != nil {
+					v.revealTrack(v.playing, true)
+				}
+				return v, nil
+			case "F":
+				v.Follow = !v.Follow
+				if v.Follow && v.playing != nil {
+					v.revealTrack(v.playing, false)
+				}
+				return v, nil
+			case "i":
+				v.ShowDetails = !v.ShowDetails
+				if v.ShowDetails {
+					v.TrackList.SetHeight(v.TrackList.Height - detailsHeight)
+				} else {
+					v.TrackList.SetHeight(v.TrackList.Height + detailsHeight)
+				}
+				return v, nil
+			case "B":
+				if track := v.SelectedTrack(); track != nil && !v.analyzing[track.ID] {
+					return v, func() tea.Msg {
+						return AnalyzeBPMMsg{Tracks: []*api.Track{track}, Force: true}
+					}
+				}
+				return v, nil
+			case "ctrl+b":
+				// Detect BPM for every listed track that doesn't have one yet
+				var pending []*api.Track
+				for _, track := range v.TrackList.Items {
+					if track.BPM == 0 && !v.analyzing[track.ID] {
+						pending = append(pending, track)
+					}
+				}
+				if len(pending) == 0 {
+					return v, nil
+				}
+				return v, func() tea.Msg {
+					return AnalyzeBPMMsg{Tracks: pending}
+				}
 			default:
 				v.TrackList, _ = v.TrackList.Update(msg)
 			}
@@ -128,26 +537,263 @@ func (v LibraryView) Update(msg tea.Msg) (LibraryView, tea.Cmd) {
 	return v, nil
 }
 
-// filterTracks filters tracks based on search query
+// filterTracks filters tracks based on search query. Besides free text the
+// query may contain field filters such as "bpm:120..130". Without a sort
+// field the best text matches are listed first.
 func (v *LibraryView) filterTracks(query string) {
-	if query == "" {
-		v.TrackList.SetItems(v.AllTracks)
+	q := parseQueryAccents(query, v.ExactAccents)
+	v.searchErr = q.err
+	v.TrackList.Highlight = nil
+	if len(q.terms) > 0 {
+		v.TrackList.Highlight = q.highlight
+	}
+	if q.isEmpty() {
+		defer v.restoreBrowsePos(v.SelectedTrack())
+	}
+	if q.isEmpty() && !v.HideOffline {
+		v.showTracks(v.AllTracks, false)
+		return
+	}
+
+	tracks, scores := v.searchTracks(q)
+	if len(q.terms) > 0 && v.SortField == SortNone {
+		sortByScore(tracks, scores)
+	}
+	// Expand every group so all matches are listed
+	v.showTracks(tracks, !q.isEmpty())
+}
+
+// saveBrowsePos remembers the place in the full list, while it is shown, to
+// return to once the search is cleared
+func (v *LibraryView) saveBrowsePos() {
+	if v.browsePos == nil && parseQuery(v.SearchBar.Value).isEmpty() {
+		v.browsePos = &listPosition{cursor: v.TrackList.Cursor(), offset: v.TrackList.Offset()}
+	}
+}
+
+// restoreBrowsePos returns the list to where it was before the search once
+// the search is cleared. The track highlighted in the results stays
+// selected, keeping the old scroll position if it is in view; with no
+// highlighted track the old cursor comes back.
+func (v *LibraryView) restoreBrowsePos(highlighted *api.Track) {
+	if v.browsePos == nil {
 		return
 	}
+	pos := *v.browsePos
+	v.browsePos = nil
+	v.TrackList.SetOffset(pos.offset)
+	if highlighted == nil || !v.TrackList.SelectTrack(highlighted) {
+		v.TrackList.SetCursor(pos.cursor)
+	}
+}
+
+// debounceSearch schedules the live filter for when typing pauses. An empty
+// query restores the full library straight away.
+func (v *LibraryView) debounceSearch() tea.Cmd {
+	v.searchSeq++
+	if strings.TrimSpace(v.SearchBar.Value) == "" {
+		v.filterTracks(v.SearchBar.Value)
+		return nil
+	}
+	seq := v.searchSeq
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return SearchDebounceMsg{seq: seq}
+	})
+}
+
+// searchTracks returns the tracks matching q in library order, with their
+// match scores. Large libraries are narrowed through the search index
+// before matching.
+func (v *LibraryView) searchTracks(q searchQuery) ([]*api.Track, []int) {
+	// Rebuild if AllTracks was replaced without SetTracks, or accents are
+	// to be matched differently
+	if v.index == nil || len(v.index.tracks) != len(v.AllTracks) || v.index.exactAccents != v.ExactAccents {
+		v.index = newSearchIndex(v.AllTracks, v.ExactAccents)
+	}
+	idx := v.index
+	positions, narrowed := idx.candidates(q.terms)
+	n := len(idx.tracks)
+	if narrowed {
+		n = len(positions)
+	}
 
-	query = strings.ToLower(query)
 	filtered := make([]*api.Track, 0)
-	for _, track := range v.AllTracks {
-		if strings.Contains(strings.ToLower(track.Title), query) ||
-			strings.Contains(strings.ToLower(track.Artist), query) ||
-			strings.Contains(strings.ToLower(track.Album), query) {
+	var scores []int
+	for i := 0; i < n; i++ {
+		pos := i
+		if narrowed {
+			pos = positions[i]
+		}
+		track := idx.tracks[pos]
+		if v.HideOffline && v.unavailable[track.ID] == library.Offline {
+			continue
+		}
+		if score, ok := q.scoreText(track, &idx.text[pos]); ok {
 			filtered = append(filtered, track)
+			scores = append(scores, score)
 		}
 	}
-	v.TrackList.SetItems(filtered)
+	return filtered, scores
+}
+
+// SetAvailability records which tracks are currently unavailable, keyed by
+// track ID, and re-applies the filter
+func (v *LibraryView) SetAvailability(unavailable map[string]library.Availability) {
+	for id := range v.unavailable {
+		delete(v.unavailable, id)
+	}
+	for id, a := range unavailable {
+		v.unavailable[id] = a
+	}
+	v.Refresh()
 }
 
-// SelectedTrack returns the currently selected track
+// offlineLabel notes how many tracks the offline filter is hiding
+func (v *LibraryView) offlineLabel() string {
+	if !v.HideOffline {
+		return ""
+	}
+	hidden := 0
+	for _, track := range v.AllTracks {
+		if v.unavailable[track.ID] == library.Offline {
+			hidden++
+		}
+	}
+	if hidden == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" · %d tracks hidden (offline)", hidden)
+}
+
+// showTracks applies the active sort and displays the tracks. The slice is
+// copied before sorting so AllTracks keeps its library order. The selected
+// track stays selected if it, or a track for the same file, is still
+// listed, so re-sorting as analysis results arrive or a live filter
+// changing the set doesn't lose the user's place; otherwise the list starts
+// at the top. A selection that stays keeps its row on screen, so results
+// growing as tracks load don't scroll the list.
+func (v *LibraryView) showTracks(tracks []*api.Track, expand bool) {
+	if v.SortField != SortNone {
+		sorted := make([]*api.Track, len(tracks))
+		copy(sorted, tracks)
+		sortTracks(sorted, v.SortField, v.SortDesc)
+		tracks = sorted
+	}
+
+	selected := v.SelectedTrack()
+	onScreen := v.TrackList.Cursor() - v.TrackList.Offset()
+	if v.GroupBy == GroupNone {
+		v.TrackList.SetItems(tracks)
+	} else {
+		collapsed := v.collapsed
+		if expand {
+			collapsed = nil
+		}
+		// An explicit sort orders tracks within each group; otherwise
+		// they are listed in album order
+		v.TrackList.SetGroups(groupTracks(tracks, v.GroupBy, v.SortField == SortNone, collapsed))
+	}
+	if selected != nil && v.TrackList.SelectTrack(selected) {
+		v.TrackList.SetOffset(v.TrackList.Cursor() - onScreen)
+	}
+}
+
+// SetRowTint colors list rows by the given attribute
+func (v *LibraryView) SetRowTint(tint RowTint) {
+	v.rowTint = tint
+	v.applyRowTint()
+}
+
+// applyRowTint has the list color rows by the row tint, in the theme's
+// colors
+func (v *LibraryView) applyRowTint() {
+	if v.rowTint == TintNone {
+		v.TrackList.RowColor = nil
+		return
+	}
+	tint, theme := v.rowTint, v.theme
+	v.TrackList.RowColor = func(track *api.Track) (lipgloss.Color, bool) {
+		return tint.rowColor(theme, track)
+	}
+}
+
+// SetAnalyzing marks a track's analysis as pending or finished
+func (v *LibraryView) SetAnalyzing(track *api.Track, pending bool) {
+	if pending {
+		v.analyzing[track.ID] = true
+	} else {
+		delete(v.analyzing, track.ID)
+	}
+}
+
+// SetUnplayable marks a track as one that fails to play, or clears it
+func (v *LibraryView) SetUnplayable(track *api.Track, unplayable bool) {
+	if unplayable {
+		v.unplayable[track.ID] = true
+	} else {
+		delete(v.unplayable, track.ID)
+	}
+}
+
+// AnalyzingCount returns the number of tracks with analysis pending
+func (v *LibraryView) AnalyzingCount() int {
+	return len(v.analyzing)
+}
+
+// sortLabel describes the active sort for the list title
+func (v *LibraryView) sortLabel() string {
+	if v.SortField == SortNone {
+		return ""
+	}
+	arrow := "↑"
+	if v.SortDesc {
+		arrow = "↓"
+	}
+	return " (" + v.SortField.String() + " " + arrow + ")"
+}
+
+// VisibleTracks returns a copy of the tracks currently listed, after search
+// filtering and sorting, in display order
+func (v *LibraryView) VisibleTracks() []*api.Track {
+	tracks := make([]*api.Track, len(v.TrackList.Items))
+	copy(tracks, v.TrackList.Items)
+	return tracks
+}
+
+// ActionTracks returns the tracks batch actions such as queueing apply to:
+// the marked tracks if there are any, otherwise every listed track
+func (v *LibraryView) ActionTracks() []*api.Track {
+	if marked := v.TrackList.SelectedItems(); len(marked) > 0 {
+		return marked
+	}
+	return v.VisibleTracks()
+}
+
+// markedLabel counts marked tracks for the list title
+func (v *LibraryView) markedLabel() string {
+	if n := v.TrackList.MarkedCount(); n > 0 {
+		return fmt.Sprintf(" [%d marked]", n)
+	}
+	return ""
+}
+
+// loadingLabel counts the tracks in so far while the library loads
+func (v *LibraryView) loadingLabel() string {
+	if !v.Loading {
+		return ""
+	}
+	return fmt.Sprintf(" · loading… %d tracks", len(v.AllTracks))
+}
+
+// StartSearch focuses the search bar so keys type the query
+func (v *LibraryView) StartSearch() {
+	v.Searching = true
+	v.SearchBar.Focus()
+	v.saveBrowsePos()
+}
+
+// SelectedTrack returns the currently selected track, or nil when there is
+// none, as when a search matches nothing or a group header is selected
 func (v *LibraryView) SelectedTrack() *api.Track {
 	return v.TrackList.SelectedItem()
 }
@@ -166,15 +812,28 @@ func (v LibraryView) View() string {
 	sb.WriteString("\n\n")
 
 	// Track list
+	v.TrackList.Title = "🎵 Library" + v.sortLabel() + v.groupLabel() + v.offlineLabel() + v.markedLabel() + v.followLabel() + v.loadingLabel()
 	sb.WriteString(v.TrackList.View())
 
+	if v.ShowDetails {
+		sb.WriteString("\n")
+		track := v.SelectedTrack()
+		sb.WriteString(renderDetails(track, track != nil && v.analyzing[track.ID], v.Width-10, v.theme))
+	}
+
 	// Help
 	sb.WriteString("\n\n")
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	helpStyle := lipgloss.NewStyle().Foreground(v.theme.Muted)
+	if v.searchErr != nil {
+		errStyle := lipgloss.NewStyle().Foreground(v.theme.Error)
+		sb.WriteString(errStyle.Render("✗ regexp: "+v.searchErr.Error()) + "  ")
+	}
 	if v.Searching {
 		sb.WriteString(helpStyle.Render("[Enter] Confirm  [Esc] Cancel"))
+	} else if v.Jumping {
+		sb.WriteString(helpStyle.Render(v.jumpHelp()))
 	} else {
-		sb.WriteString(helpStyle.Render("[/] Search  [a] Add Files  [Enter] Play  [↑↓] Navigate"))
+		sb.WriteString(helpStyle.Render("[/] Search  [g] Jump  [a] Add Files  [x/X] Mark  [N] Play Next  [^P] Pin  [Q] Queue All  [f/F] Go to/Follow Playing  [i] Details  [H] Hide Offline  [o/O] Sort  [G] Group  [B/^B] Detect BPM  [Enter] Play  [↑↓] Navigate"))
 	}
 
 	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
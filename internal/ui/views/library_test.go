@@ -0,0 +1,110 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+func TestLibraryView_SetSize(t *testing.T) {
+	v := NewLibraryView(80, 30)
+	v.SetSize(120, 40)
+	if v.TrackList.Width != 114 || v.TrackList.Height != 32 || v.SearchBar.Width != 114 {
+		t.Errorf("after resize: list %dx%d, search %d; want 114x32, 114",
+			v.TrackList.Width, v.TrackList.Height, v.SearchBar.Width)
+	}
+
+	// The details panel keeps its share of the height through a resize
+	v.ShowDetails = true
+	v.SetSize(100, 30)
+	if want := 30 - 8 - detailsHeight; v.TrackList.Height != want {
+		t.Errorf("list height with details = %d, want %d", v.TrackList.Height, want)
+	}
+}
+
+func TestLibraryView_RendersWhenTiny(t *testing.T) {
+	tracks := []*api.Track{{ID: "a", Title: "A title long enough to need truncating", Artist: "Some Artist"}}
+	for width := 0; width < 16; width++ {
+		for height := 0; height < 10; height++ {
+			v := NewLibraryView(80, 30)
+			v.SetTracks(tracks)
+			v.SetSize(width, height)
+			v.View()
+
+			v.ShowDetails = true
+			v.View()
+
+			v.Browsing = true
+			v.FileBrowser = newFileBrowser(width, height, nil)
+			v.View()
+		}
+	}
+}
+
+func TestLibraryView_ClickSelectsRowUnderMouse(t *testing.T) {
+	var tracks []*api.Track
+	for i := range 5 {
+		tracks = append(tracks, &api.Track{ID: fmt.Sprint(i), Artist: "Artist", Title: fmt.Sprint("Song ", i)})
+	}
+	v := NewLibraryView(80, 30)
+	v.SetSize(80, 30)
+	v.SetTracks(tracks)
+
+	lines := strings.Split(ansi.Strip(v.View()), "\n")
+	y := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(line, "Song 3") })
+	if y < 0 {
+		t.Fatal("row not drawn")
+	}
+	x := ansi.StringWidth(lines[y][:strings.Index(lines[y], "Song 3")])
+
+	v, _ = v.Update(tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	if got := v.SelectedTrack(); got != tracks[3] {
+		t.Errorf("clicked on Song 3, selected %v", got)
+	}
+
+	// Clicks on the border are ignored
+	v, _ = v.Update(tea.MouseMsg{X: 0, Y: y - 1, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	if got := v.SelectedTrack(); got != tracks[3] {
+		t.Errorf("clicking the border selected %v", got)
+	}
+}
+
+func TestLibraryView_MarksUnplayableTracks(t *testing.T) {
+	tracks := []*api.Track{{ID: "a", Title: "Broken"}, {ID: "b", Title: "Fine"}}
+	v := NewLibraryView(80, 30)
+	v.SetTracks(tracks)
+
+	v.SetUnplayable(tracks[0], true)
+	view := ansi.Strip(v.View())
+	if strings.Count(view, "[unplayable]") != 1 {
+		t.Errorf("want one track marked unplayable:\n%s", view)
+	}
+
+	v.SetUnplayable(tracks[0], false)
+	if view := ansi.Strip(v.View()); strings.Contains(view, "[unplayable]") {
+		t.Errorf("mark not cleared:\n%s", view)
+	}
+}
+
+func TestLibraryView_RowTintFollowsTheme(t *testing.T) {
+	v := NewLibraryView(80, 24)
+	v.SetRowTint(TintFormat)
+	flac := &api.Track{FilePath: "/m/a.flac"}
+	if c, ok := v.TrackList.RowColor(flac); !ok || c != components.DefaultTheme.TintFLAC {
+		t.Errorf("default theme tint = %q, %v", c, ok)
+	}
+	gruvbox, _ := components.ThemeByName("gruvbox")
+	v.SetTheme(gruvbox)
+	if c, ok := v.TrackList.RowColor(flac); !ok || c != gruvbox.TintFLAC {
+		t.Errorf("gruvbox tint = %q, %v", c, ok)
+	}
+	if _, ok := v.TrackList.RowColor(&api.Track{FilePath: "/m/a.mp3"}); ok {
+		t.Error("lossy file tinted")
+	}
+}
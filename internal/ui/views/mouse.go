@@ -0,0 +1,22 @@
+package views
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// listMouse passes a mouse event, positioned relative to a view drawn in
+// border, on to the view's track list, whose first line is top lines into
+// the border's content. Clicks beside the list are dropped; the wheel
+// scrolls it from anywhere in the view.
+func listMouse(list *components.TrackList, border lipgloss.Style, top int, msg tea.MouseMsg) tea.Cmd {
+	msg.X -= border.GetBorderLeftSize() + border.GetPaddingLeft()
+	msg.Y -= border.GetBorderTopSize() + border.GetPaddingTop() + top
+	if !tea.MouseEvent(msg).IsWheel() && (msg.X < 0 || msg.X >= list.Width) {
+		return nil
+	}
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
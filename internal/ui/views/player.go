@@ -2,6 +2,7 @@ package views
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -13,10 +14,29 @@ import (
 
 // PlayerView displays the current playback state
 type PlayerView struct {
-	Width       int
-	Height      int
-	State       *api.PlaybackState
-	ProgressBar components.ProgressBar
+	Width        int
+	Height       int
+	State        *api.PlaybackState
+	UpNext       *api.Track // Track the queue will advance to, if any
+	UpNextPinned bool       // UpNext is pinned in place
+	ProgressBar  components.ProgressBar
+	Volume       components.VolumeBar
+	Art          components.AlbumArt
+	Visualizer   components.Visualizer
+
+	// GoTo asks for a time to jump to while GoingTo, opened with g
+	GoTo    components.SearchInput
+	GoingTo bool
+	goToErr string // Why the time entered was rejected
+
+	// The bar as last drawn by CompactView, and the column it starts at
+	compactBar  components.ProgressBar
+	compactBarX int
+
+	// Cued is the track Space will resume at CuedAt while nothing is
+	// loaded, as when the last session is restored
+	Cued   *api.Track
+	CuedAt time.Duration
 
 	// Styles
 	TitleStyle    lipgloss.Style
@@ -25,61 +45,100 @@ type PlayerView struct {
 	StatusStyle   lipgloss.Style
 	ControlsStyle lipgloss.Style
 	BorderStyle   lipgloss.Style
+	ModesStyle    lipgloss.Style
+	theme         components.Theme
 }
 
 // NewPlayerView creates a new player view
 func NewPlayerView(width, height int) PlayerView {
-	return PlayerView{
+	v := PlayerView{
 		Width:       width,
 		Height:      height,
 		ProgressBar: components.NewProgressBar(width - 4),
-		TitleStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("212")).
-			MarginBottom(1),
-		ArtistStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("86")),
-		AlbumStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244")).
-			Italic(true),
-		StatusStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("214")).
-			Bold(true),
-		ControlsStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			MarginTop(1),
-		BorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Padding(1, 2),
+		Volume:      components.NewVolumeBar(50),
+		Visualizer:  components.NewVisualizer(width-8, 4),
 	}
+	v.SetTheme(components.DefaultTheme)
+	return v
+}
+
+// SetTheme styles the view, its bars and its album art in t's colors
+func (v *PlayerView) SetTheme(t components.Theme) {
+	v.theme = t
+	v.TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent).
+		MarginBottom(1)
+	v.ArtistStyle = lipgloss.NewStyle().
+		Foreground(t.Success)
+	v.AlbumStyle = lipgloss.NewStyle().
+		Foreground(t.Subtle).
+		Italic(true)
+	v.StatusStyle = lipgloss.NewStyle().
+		Foreground(t.Warning).
+		Bold(true)
+	v.ControlsStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		MarginTop(1)
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.ModesStyle = lipgloss.NewStyle().Foreground(t.Subtle)
+	v.ProgressBar.SetTheme(t)
+	v.Volume.SetTheme(t)
+	v.Art.SetTheme(t)
+	v.Visualizer.SetTheme(t)
+	v.GoTo.SetTheme(t)
+	v.GoTo.Style, v.GoTo.FocusStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
 }
 
 // SetState updates the playback state
 func (v *PlayerView) SetState(state *api.PlaybackState) {
 	v.State = state
+	// While muted the engine plays at zero; keep the level to restore
+	if state != nil && !v.Volume.Muted {
+		v.Volume.SetVolume(int(math.Round(state.Volume * 100)))
+	}
 	if state != nil && state.CurrentTrack != nil {
 		v.ProgressBar.SetProgress(state.Position, state.CurrentTrack.Duration)
+		v.ProgressBar.SetTrim(state.CurrentTrack.TrimStart, state.CurrentTrack.TrimEnd)
+		v.ProgressBar.Chapters = state.CurrentTrack.Chapters
+		v.ProgressBar.Loop = state.Loop
+		// Streams of unknown length, such as radio, play on indefinitely
+		v.ProgressBar.Live = state.CurrentTrack.IsStream() && state.CurrentTrack.Duration <= 0
+	} else if state != nil && v.Cued != nil {
+		v.ProgressBar.SetProgress(v.CuedAt, v.Cued.Duration)
+		v.ProgressBar.SetTrim(v.Cued.TrimStart, v.Cued.TrimEnd)
+		v.ProgressBar.Chapters = v.Cued.Chapters
+		v.ProgressBar.Live = false
+		v.ProgressBar.Loop = api.ABLoop{}
+	} else if state != nil {
+		// Nothing loaded, as when a track failed to open: don't leave the
+		// last track's position showing
+		v.ProgressBar.SetProgress(0, 0)
+		v.ProgressBar.SetTrim(0, 0)
+		v.ProgressBar.Chapters = nil
+		v.ProgressBar.Live = false
+		v.ProgressBar.Loop = api.ABLoop{}
 	}
 }
 
-// Update handles messages
+// Update handles messages: the keys TakesKey reports
 func (v PlayerView) Update(msg tea.Msg) (PlayerView, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		return v.updateKey(msg)
+	}
 	return v, nil
 }
 
-// ProgressBarRow returns the screen row offset of the progress bar
-// within the player view (relative to the top of the player view content).
-// Layout: status+title (1) + artist (1) + album (1) + blank (1) + progress (row 4)
-// Plus border top (1) + padding (1) = 6 rows from the top of the rendered box.
-func (v *PlayerView) ProgressBarRow() int {
-	return 6
-}
-
-// ProgressBarClickSeek converts a mouse click X position to a seek duration.
-// barOffsetX is the X offset of the bar within the terminal (border + padding).
-func (v *PlayerView) ProgressBarClickSeek(clickX, barOffsetX int) time.Duration {
-	return v.ProgressBar.HandleClick(clickX, barOffsetX)
+// SetSize resizes the view; the progress bar spans the full width since
+// it is drawn in the footer
+func (v *PlayerView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	v.ProgressBar.Width = width
+	v.Visualizer.Width = width - 8
 }
 
 // View renders the player view
@@ -91,68 +150,148 @@ func (v *PlayerView) View() string {
 		sb.WriteString("\n\n")
 		sb.WriteString(v.ControlsStyle.Render("Press Enter on a track to play"))
 	} else {
-		track := v.State.CurrentTrack
-
-		// Status icon
-		var statusIcon string
-		switch v.State.Status {
-		case api.StatusPlaying:
-			statusIcon = "▶"
-		case api.StatusPaused:
-			statusIcon = "⏸"
-		default:
-			statusIcon = "⏹"
-		}
-
-		// Track info
-		sb.WriteString(v.StatusStyle.Render(statusIcon + " "))
-		sb.WriteString(v.TitleStyle.Render(track.Title))
-		sb.WriteString("\n")
-		sb.WriteString(v.ArtistStyle.Render(track.Artist))
-		sb.WriteString("\n")
-		sb.WriteString(v.AlbumStyle.Render(track.Album))
-		sb.WriteString("\n\n")
-
-		// Progress bar
-		sb.WriteString(v.ProgressBar.View())
-		sb.WriteString("\n\n")
-
-		// Volume
-		volumeBar := renderVolumeBar(v.State.Volume)
-		sb.WriteString(fmt.Sprintf("Volume: %s %d%%", volumeBar, int(v.State.Volume*100)))
-		sb.WriteString("\n")
-
-		// Repeat/Shuffle status
-		var modes []string
-		switch v.State.Repeat {
-		case api.RepeatOne:
-			modes = append(modes, "🔂 Repeat One")
-		case api.RepeatAll:
-			modes = append(modes, "🔁 Repeat All")
+		info := v.trackInfo(v.State.CurrentTrack)
+		if art := v.Art.View(); art != "" {
+			info = lipgloss.JoinHorizontal(lipgloss.Top, art, "  ", info)
 		}
-		if v.State.Shuffle {
-			modes = append(modes, "🔀 Shuffle")
+		sb.WriteString(info)
+		if bars := v.Visualizer.View(); bars != "" {
+			sb.WriteString("\n\n" + bars)
 		}
-		if len(modes) > 0 {
-			sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(strings.Join(modes, " | ")))
+	}
+
+	if v.GoingTo {
+		sb.WriteString("\n\n" + v.GoTo.View())
+		if v.goToErr != "" {
+			sb.WriteString("\n" + v.StatusStyle.Render(v.goToErr))
 		}
+		sb.WriteString("\n" + v.ModesStyle.Render("[Enter] Jump  [Esc] Cancel"))
 	}
 
 	sb.WriteString("\n\n")
 	sb.WriteString(v.ControlsStyle.Render(
-		"[Space] Play/Pause  [s] Stop  [n] Next  [p] Prev  [←/→] Seek ±5s  [+/-] Volume  [q] Quit",
+		"[Space] Play/Pause  [s] Stop  [n] Next  [p] Prev  [←/→] Seek  [0-9] Jump  [g] Go to  [+/-] Volume  [m] Mute  [q] Quit",
 	))
 
 	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
 }
 
-// renderVolumeBar renders a volume bar
-func renderVolumeBar(volume float64) string {
-	filled := int(volume * 10)
-	empty := 10 - filled
+// trackInfo renders the playing track's details, volume and modes
+func (v *PlayerView) trackInfo(track *api.Track) string {
+	var info strings.Builder
+
+	// Track info; the progress bar is in the footer
+	info.WriteString(v.StatusStyle.Render(statusIcon(v.State.Status) + " "))
+	info.WriteString(v.TitleStyle.Render(track.Title))
+	info.WriteString("\n")
+	info.WriteString(v.ArtistStyle.Render(track.Artist))
+	info.WriteString("\n")
+	album := track.Album
+	if track.BPM > 0 {
+		album += fmt.Sprintf(" · %.0f BPM", track.BPM)
+	}
+	info.WriteString(v.AlbumStyle.Render(album))
+	info.WriteString("\n\n")
+
+	// Volume
+	info.WriteString("Volume: " + v.Volume.View())
+	info.WriteString("\n")
+
+	// Repeat/Shuffle status
+	var modes []string
+	switch v.State.Repeat {
+	case api.RepeatOne:
+		modes = append(modes, "🔂 Repeat One")
+	case api.RepeatAll:
+		modes = append(modes, "🔁 Repeat All")
+	}
+	if v.State.Shuffle {
+		modes = append(modes, "🔀 Shuffle")
+	}
+	if v.State.SkipSilence {
+		modes = append(modes, "✂ Skip Silence")
+	}
+	if track.Gain != 0 {
+		modes = append(modes, fmt.Sprintf("🎚 Gain %+g dB", track.Gain))
+	}
+	if len(modes) > 0 {
+		info.WriteString(v.ModesStyle.Render(strings.Join(modes, " | ")))
+	}
+
+	// Up next
+	if v.UpNext != nil {
+		info.WriteString("\n")
+		label := "Up next: "
+		if v.UpNextPinned {
+			label = "📌 Up next: "
+		}
+		info.WriteString(v.AlbumStyle.Render(label + v.UpNext.Title + " — " + v.UpNext.Artist))
+	}
+	return info.String()
+}
+
+// shownTrack is the track the footer shows: the one loaded, or else the
+// one cued
+func (v *PlayerView) shownTrack() *api.Track {
+	if v.State != nil && v.State.CurrentTrack != nil {
+		return v.State.CurrentTrack
+	}
+	return v.Cued
+}
+
+// FooterView renders the two-line now-playing bar shown at the bottom of
+// every view: status and track, then the progress bar
+func (v *PlayerView) FooterView() string {
+	if v.State != nil && v.State.PreviewTrack != nil {
+		preview := v.State.PreviewTrack
+		icon, hint := "🎧", "  [Space] Pause [v] Stop"
+		if v.State.Preview.Paused {
+			icon, hint = "⏸", "  [Space] Resume [v] Stop"
+		}
+		line := v.StatusStyle.Render(icon+" Preview: ") + v.ArtistStyle.Render(preview.Title+" — "+preview.Artist)
+		bar := v.previewBar()
+		return lipgloss.NewStyle().MaxWidth(max(v.Width, 1)).Render(line+hint) + "\n" + bar.View()
+	}
+	track := v.shownTrack()
+	if v.State == nil || track == nil {
+		return v.AlbumStyle.Render("♪ No track playing") + "\n"
+	}
+
+	info := track.Title
+	if track.Artist != "" {
+		info += " — " + track.Artist
+	}
 
-	filledStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
-	emptyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	// Repeat and shuffle icons follow the track so they show on every tab
+	modes := ""
+	switch v.State.Repeat {
+	case api.RepeatOne:
+		modes += " 🔂"
+	case api.RepeatAll:
+		modes += " 🔁"
+	}
+	if v.State.Shuffle {
+		modes += " 🔀"
+	}
+
+	infoStyle := v.ArtistStyle
+	if w := v.Width - 2 - lipgloss.Width(modes); w > 0 {
+		infoStyle = infoStyle.MaxWidth(w)
+	}
+	line := v.StatusStyle.Render(statusIcon(v.State.Status)+" ") + infoStyle.Render(info) + v.AlbumStyle.Render(modes)
+	return line + "\n" + v.ProgressBar.View()
+}
 
-	return filledStyle.Render(strings.Repeat("●", filled)) + emptyStyle.Render(strings.Repeat("○", empty))
+// previewBar is the progress bar with the preview's progress in place of
+// the track's, and none of the track's marks
+func (v *PlayerView) previewBar() components.ProgressBar {
+	bar := v.ProgressBar
+	bar.SetProgress(v.State.Preview.Position, v.State.Preview.Length)
+	bar.SetTrim(0, 0)
+	bar.SetBuffered(0)
+	bar.SetGlobal(0, 0)
+	bar.Marks, bar.Chapters, bar.Loop = nil, nil, api.ABLoop{}
+	bar.Live, bar.Dragging = false, false
+	bar.ClearHover()
+	return bar
 }
@@ -1,14 +1,30 @@
 package views
 
 import (
+	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/playlist"
 	"github.com/jscyril/golang_music_player/internal/ui/components"
 )
 
+// PlaylistMoveMsg is sent to move the track at From in a saved playlist
+// to position To
+type PlaylistMoveMsg struct {
+	PlaylistID string
+	From, To   int
+}
+
+// PlaylistRemoveMsg is sent to take the track at Index out of a saved
+// playlist
+type PlaylistRemoveMsg struct {
+	PlaylistID string
+	Index      int
+}
+
 // PlaylistView displays playlist management
 type PlaylistView struct {
 	Width       int
@@ -20,6 +36,7 @@ type PlaylistView struct {
 	Selected    int
 	BorderStyle lipgloss.Style
 	TitleStyle  lipgloss.Style
+	theme       components.Theme
 }
 
 // NewPlaylistView creates a new playlist view
@@ -27,25 +44,55 @@ func NewPlaylistView(width, height int) PlaylistView {
 	trackList := components.NewTrackList(height-8, width-6)
 	trackList.Title = "📋 Playlist"
 
-	return PlaylistView{
+	v := PlaylistView{
 		Width:       width,
 		Height:      height,
 		TrackList:   trackList,
 		Playlists:   make([]*api.Playlist, 0),
 		ShowingList: true,
-		BorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Padding(1, 2),
-		TitleStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("212")),
 	}
+	v.SetTheme(components.DefaultTheme)
+	return v
 }
 
-// SetPlaylists sets the available playlists
+// SetTheme styles the view and its track list in t's colors
+func (v *PlaylistView) SetTheme(t components.Theme) {
+	v.theme = t
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent)
+	v.TrackList.SetTheme(t)
+}
+
+// SetSize resizes the view and its track list
+func (v *PlaylistView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	v.TrackList.Width = width - 6
+	v.TrackList.SetHeight(height - 8)
+}
+
+// SetPlaylists sets the available playlists. An open playlist that is
+// still among them shows its new tracks, keeping the selection where it
+// can.
 func (v *PlaylistView) SetPlaylists(playlists []*api.Playlist) {
 	v.Playlists = playlists
+	v.Selected = max(min(v.Selected, len(playlists)-1), 0)
+	if v.Current == nil || v.ShowingList {
+		return
+	}
+	for _, pl := range playlists {
+		if pl.ID == v.Current.ID {
+			selected := v.TrackList.Selected
+			v.SetCurrentPlaylist(pl)
+			v.TrackList.SetSelected(selected)
+			return
+		}
+	}
 }
 
 // SetCurrentPlaylist sets the current playlist to display
@@ -87,6 +134,8 @@ func (v PlaylistView) Update(msg tea.Msg) (PlaylistView, tea.Cmd) {
 				v.ShowingList = true
 				v.Current = nil
 				return v, nil
+			case "K", "shift+up", "J", "shift+down", "d", "delete":
+				return v, v.editKey(msg.String())
 			default:
 				v.TrackList, _ = v.TrackList.Update(msg)
 			}
@@ -95,6 +144,32 @@ func (v PlaylistView) Update(msg tea.Msg) (PlaylistView, tea.Cmd) {
 	return v, nil
 }
 
+// editKey asks for the open playlist's selected track to be moved up or
+// down or removed, unless it is a smart playlist. The cursor follows a
+// moved track.
+func (v *PlaylistView) editKey(key string) tea.Cmd {
+	selected := v.TrackList.Cursor()
+	if v.Current == nil || playlist.IsSmartPlaylistID(v.Current.ID) || selected < 0 || selected >= len(v.TrackList.Items) {
+		return nil
+	}
+	id := v.Current.ID
+	switch key {
+	case "K", "shift+up":
+		if selected > 0 {
+			v.TrackList.MoveUp()
+			return func() tea.Msg { return PlaylistMoveMsg{PlaylistID: id, From: selected, To: selected - 1} }
+		}
+	case "J", "shift+down":
+		if selected < len(v.TrackList.Items)-1 {
+			v.TrackList.MoveDown()
+			return func() tea.Msg { return PlaylistMoveMsg{PlaylistID: id, From: selected, To: selected + 1} }
+		}
+	default:
+		return func() tea.Msg { return PlaylistRemoveMsg{PlaylistID: id, Index: selected} }
+	}
+	return nil
+}
+
 // SelectedTrack returns the currently selected track
 func (v *PlaylistView) SelectedTrack() *api.Track {
 	if v.ShowingList {
@@ -115,17 +190,18 @@ func (v *PlaylistView) SelectedPlaylist() *api.Playlist {
 func (v PlaylistView) View() string {
 	var sb strings.Builder
 
+	muted := lipgloss.NewStyle().Foreground(v.theme.Muted)
 	if v.ShowingList {
 		// Show playlist list
 		sb.WriteString(v.TitleStyle.Render("📋 Playlists"))
 		sb.WriteString("\n\n")
 
 		if len(v.Playlists) == 0 {
-			sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("No playlists yet"))
+			sb.WriteString(muted.Render("No playlists yet"))
 		} else {
 			selectedStyle := lipgloss.NewStyle().
-				Background(lipgloss.Color("62")).
-				Foreground(lipgloss.Color("230")).
+				Background(v.theme.SelectedBg).
+				Foreground(v.theme.SelectedFg).
 				Bold(true).
 				Padding(0, 1)
 			normalStyle := lipgloss.NewStyle().Padding(0, 1)
@@ -135,8 +211,8 @@ func (v PlaylistView) View() string {
 				if pl.Description != "" {
 					line += " - " + pl.Description
 				}
-				line += lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(
-					" (" + string(rune('0'+len(pl.Tracks))) + " tracks)")
+				line += muted.Render(
+					fmt.Sprintf(" (%d tracks)", len(pl.Tracks)))
 
 				if i == v.Selected {
 					sb.WriteString(selectedStyle.Render(line))
@@ -148,14 +224,14 @@ func (v PlaylistView) View() string {
 		}
 
 		sb.WriteString("\n")
-		sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(
+		sb.WriteString(muted.Render(
 			"[Enter] Open  [↑↓] Navigate"))
 	} else {
 		// Show playlist tracks
 		sb.WriteString(v.TrackList.View())
 		sb.WriteString("\n\n")
-		sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(
-			"[Backspace/Esc] Back  [Enter] Play  [↑↓] Navigate"))
+		sb.WriteString(muted.Render(
+			"[Backspace/Esc] Back  [Enter] Play  [K/J] Move up/down  [d] Remove  [↑↓] Navigate"))
 	}
 
 	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
@@ -0,0 +1,147 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// QueueMoveMsg is sent to move the queued track at From to position To
+type QueueMoveMsg struct {
+	From, To int
+}
+
+// QueueRemoveMsg is sent to take the track at Index out of the queue
+type QueueRemoveMsg struct {
+	Index int
+}
+
+// QueueClearMsg is sent to empty the queue
+type QueueClearMsg struct{}
+
+// QueueView lists the playback queue in play order, marking the current
+// track, and lets it be reordered
+type QueueView struct {
+	Width       int
+	Height      int
+	TrackList   components.TrackList
+	Current     int // Index of the current track; -1 if none
+	BorderStyle lipgloss.Style
+	HelpStyle   lipgloss.Style
+}
+
+// NewQueueView creates a new queue view
+func NewQueueView(width, height int) QueueView {
+	v := QueueView{
+		Width:     width,
+		Height:    height,
+		TrackList: components.NewTrackList(height-8, width-6),
+		Current:   -1,
+	}
+	v.SetTheme(components.DefaultTheme)
+	v.TrackList.Title = "⏭ Queue"
+	return v
+}
+
+// SetTheme styles the view and its track list in t's colors
+func (v *QueueView) SetTheme(t components.Theme) {
+	v.BorderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(1, 2)
+	v.HelpStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+	v.TrackList.SetTheme(t)
+}
+
+// SetSize resizes the view and its track list
+func (v *QueueView) SetSize(width, height int) {
+	v.Width = width
+	v.Height = height
+	v.TrackList.Width = width - 6
+	v.TrackList.SetHeight(height - 8)
+}
+
+// SetQueue shows tracks, in play order, with the track at current playing.
+// The cursor stays on the same row so the list doesn't jump as the queue
+// advances.
+func (v *QueueView) SetQueue(tracks []*api.Track, current int) {
+	selected := v.TrackList.Selected
+	v.TrackList.SetItems(tracks)
+	v.TrackList.SetSelected(min(selected, max(len(tracks)-1, 0)))
+	v.Current = -1
+	if current >= 0 && current < len(tracks) {
+		v.Current = current
+		playing := tracks[current]
+		v.TrackList.RowSuffix = func(track *api.Track) string {
+			if track == playing {
+				return "  ◀ playing"
+			}
+			return ""
+		}
+	} else {
+		v.TrackList.RowSuffix = nil
+	}
+	v.TrackList.Title = fmt.Sprintf("⏭ Queue (%d tracks)", len(tracks))
+}
+
+// Selected returns the index of the selected track, or -1 if the queue is
+// empty
+func (v *QueueView) Selected() int {
+	if len(v.TrackList.Items) == 0 {
+		return -1
+	}
+	return v.TrackList.Selected
+}
+
+// Update handles messages. Moves and removals are sent to the app, which
+// owns the queue; the cursor follows a moved track.
+func (v QueueView) Update(msg tea.Msg) (QueueView, tea.Cmd) {
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		return v, listMouse(&v.TrackList, v.BorderStyle, 0, mouse)
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return v, nil
+	}
+	selected := v.Selected()
+	switch key.String() {
+	case "K", "shift+up":
+		if selected > 0 {
+			v.TrackList.MoveUp()
+			return v, func() tea.Msg { return QueueMoveMsg{From: selected, To: selected - 1} }
+		}
+		return v, nil
+	case "J", "shift+down":
+		if selected >= 0 && selected < len(v.TrackList.Items)-1 {
+			v.TrackList.MoveDown()
+			return v, func() tea.Msg { return QueueMoveMsg{From: selected, To: selected + 1} }
+		}
+		return v, nil
+	case "d", "delete":
+		if selected >= 0 {
+			return v, func() tea.Msg { return QueueRemoveMsg{Index: selected} }
+		}
+		return v, nil
+	case "C":
+		if selected >= 0 {
+			return v, func() tea.Msg { return QueueClearMsg{} }
+		}
+		return v, nil
+	}
+	v.TrackList, _ = v.TrackList.Update(msg)
+	return v, nil
+}
+
+// View renders the queue view
+func (v QueueView) View() string {
+	var sb strings.Builder
+	sb.WriteString(v.TrackList.View())
+	sb.WriteString("\n\n")
+	sb.WriteString(v.HelpStyle.Render("[Enter] Play  [K/J] Move up/down  [d] Remove  [C] Clear  [↑↓] Navigate"))
+	return v.BorderStyle.Width(v.Width - 4).Render(sb.String())
+}
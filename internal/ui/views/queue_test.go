@@ -0,0 +1,48 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestQueueView_MoveFollowsCursor(t *testing.T) {
+	tracks := []*api.Track{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}, {ID: "c", Title: "C"}}
+	v := NewQueueView(80, 30)
+	v.SetQueue(tracks, 0)
+	v.TrackList.SetSelected(1)
+
+	v, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("J")})
+	if cmd == nil {
+		t.Fatal("moving down sent nothing")
+	}
+	if msg, ok := cmd().(QueueMoveMsg); !ok || msg.From != 1 || msg.To != 2 {
+		t.Errorf("move message = %#v, want From 1 To 2", cmd())
+	}
+	if v.Selected() != 2 {
+		t.Errorf("cursor = %d, want 2 to follow the track", v.Selected())
+	}
+
+	// Already last: nothing to move
+	if _, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("J")}); cmd != nil {
+		t.Errorf("moving the last track down sent %#v", cmd())
+	}
+}
+
+func TestQueueView_MarksPlaying(t *testing.T) {
+	tracks := []*api.Track{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}}
+	v := NewQueueView(80, 30)
+	v.SetQueue(tracks, 1)
+
+	out := v.View()
+	if strings.Count(out, "◀ playing") != 1 || !strings.Contains(out, "B  ◀ playing") {
+		t.Errorf("only the current track should be marked playing:\n%s", out)
+	}
+
+	v.SetQueue(nil, 0)
+	if v.Selected() != -1 || strings.Contains(v.View(), "playing") {
+		t.Errorf("empty queue: selected %d, view:\n%s", v.Selected(), v.View())
+	}
+}
@@ -0,0 +1,139 @@
+package views
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+// SortField identifies the track attribute the library is sorted by
+type SortField int
+
+const (
+	SortNone SortField = iota // Library order (artist, album, track)
+	SortTitle
+	SortArtist
+	SortAlbum
+	SortBPM
+	SortSize
+	SortRating
+)
+
+// sortFieldCount is the number of sort fields, used for cycling
+const sortFieldCount = 7
+
+var sortFieldNames = [...]string{"Default", "Title", "Artist", "Album", "BPM", "Size", "Rating"}
+
+func (f SortField) String() string {
+	if int(f) < len(sortFieldNames) {
+		return sortFieldNames[f]
+	}
+	return "Unknown"
+}
+
+// ParseSortField parses a sort field by name, as String returns it, in any
+// case; "" and "none" are the default order
+func ParseSortField(s string) (SortField, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "none") {
+		return SortNone, true
+	}
+	for i, name := range sortFieldNames {
+		if strings.EqualFold(s, name) {
+			return SortField(i), true
+		}
+	}
+	return SortNone, false
+}
+
+// Next returns the following sort field, wrapping around
+func (f SortField) Next() SortField {
+	return (f + 1) % sortFieldCount
+}
+
+// sortTracks sorts tracks in place by field. The sort is stable, and tracks
+// with an unknown value for the field always sort last regardless of direction.
+func sortTracks(tracks []*api.Track, field SortField, desc bool) {
+	if field == SortNone {
+		return
+	}
+
+	sort.SliceStable(tracks, func(i, j int) bool {
+		a, b := tracks[i], tracks[j]
+		aKnown, bKnown := hasSortValue(a, field), hasSortValue(b, field)
+		if aKnown != bKnown {
+			return aKnown
+		}
+		if !aKnown {
+			return false
+		}
+		if desc {
+			return lessTrack(b, a, field)
+		}
+		return lessTrack(a, b, field)
+	})
+}
+
+// hasSortValue reports whether the track has a known value for field. Tags
+// the scanner filled with a placeholder count as unknown.
+func hasSortValue(t *api.Track, field SortField) bool {
+	switch field {
+	case SortTitle:
+		return t.Title != ""
+	case SortArtist:
+		return t.Artist != "" && t.Artist != "Unknown Artist"
+	case SortAlbum:
+		return t.Album != "" && t.Album != "Unknown Album"
+	case SortBPM:
+		return t.BPM > 0
+	case SortSize:
+		return t.Size > 0
+	case SortRating:
+		return t.Rating > 0
+	}
+	return true
+}
+
+// lessTrack compares two tracks by a single field. Text compares
+// case-insensitively.
+func lessTrack(a, b *api.Track, field SortField) bool {
+	switch field {
+	case SortTitle:
+		return lessFold(a.Title, b.Title)
+	case SortArtist:
+		return lessFold(a.Artist, b.Artist)
+	case SortAlbum:
+		return lessFold(a.Album, b.Album)
+	case SortBPM:
+		return a.BPM < b.BPM
+	case SortSize:
+		return a.Size < b.Size
+	case SortRating:
+		return a.Rating < b.Rating
+	}
+	return false
+}
+
+// lessFold reports whether a sorts before b, ignoring case
+func lessFold(a, b string) bool {
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// sortByScore orders tracks by descending match score, keeping library
+// order between equal scores. scores is reordered along with tracks.
+func sortByScore(tracks []*api.Track, scores []int) {
+	sort.Stable(byScore{tracks, scores})
+}
+
+type byScore struct {
+	tracks []*api.Track
+	scores []int
+}
+
+func (s byScore) Len() int           { return len(s.tracks) }
+func (s byScore) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+func (s byScore) Swap(i, j int) {
+	s.tracks[i], s.tracks[j] = s.tracks[j], s.tracks[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
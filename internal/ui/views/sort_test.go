@@ -0,0 +1,80 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/jscyril/golang_music_player/api"
+)
+
+func TestSortTracks_Text(t *testing.T) {
+	tracks := []*api.Track{
+		{ID: "1", Title: "beta", Artist: "Unknown Artist", Album: "Zed"},
+		{ID: "2", Title: "Alpha", Artist: "queen", Album: ""},
+		{ID: "3", Title: "Gamma", Artist: "Blur", Album: "alpha"},
+		{ID: "4", Title: "alpha", Artist: "Queen", Album: "Unknown Album"},
+	}
+
+	tests := []struct {
+		field SortField
+		desc  bool
+		want  []string
+	}{
+		{SortTitle, false, []string{"2", "4", "1", "3"}},
+		{SortTitle, true, []string{"3", "1", "2", "4"}},
+		// Equal artists keep their order; the placeholder artist sorts last
+		{SortArtist, false, []string{"3", "2", "4", "1"}},
+		{SortArtist, true, []string{"2", "4", "3", "1"}},
+		// Empty and placeholder albums sort last in either direction
+		{SortAlbum, false, []string{"3", "1", "2", "4"}},
+		{SortAlbum, true, []string{"1", "3", "2", "4"}},
+	}
+	for _, tt := range tests {
+		sorted := append([]*api.Track(nil), tracks...)
+		sortTracks(sorted, tt.field, tt.desc)
+		for i, id := range tt.want {
+			if sorted[i].ID != id {
+				t.Errorf("%s desc=%v = %v, want %v", tt.field, tt.desc, trackIDs(sorted), tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSortTracks_Rating(t *testing.T) {
+	tracks := []*api.Track{
+		{ID: "1", Rating: 2},
+		{ID: "2"},
+		{ID: "3", Rating: 5},
+		{ID: "4", Rating: 4},
+	}
+	for _, desc := range []bool{false, true} {
+		sortTracks(tracks, SortRating, desc)
+		want := []string{"1", "4", "3", "2"}
+		if desc {
+			want = []string{"3", "4", "1", "2"} // Unrated still last
+		}
+		for i, id := range want {
+			if tracks[i].ID != id {
+				t.Errorf("desc=%v = %v, want %v", desc, trackIDs(tracks), want)
+				break
+			}
+		}
+	}
+}
+
+func TestLibraryView_SortKeepsSearch(t *testing.T) {
+	v := NewLibraryView(80, 40)
+	v.SetTracks([]*api.Track{
+		{ID: "1", Title: "Song B", Artist: "Blur"},
+		{ID: "2", Title: "Other", Artist: "Abba"},
+		{ID: "3", Title: "Song A", Artist: "Cure"},
+	})
+	v.SearchBar.Value = "song"
+	v.Refresh()
+
+	v.SortField = SortTitle
+	v.Refresh()
+	if got := trackIDs(v.TrackList.Items); len(got) != 2 || got[0] != "3" || got[1] != "1" {
+		t.Fatalf("sorted search results = %v, want [3 1]", got)
+	}
+}
@@ -0,0 +1,44 @@
+package views
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// RowTint selects the track attribute library rows are colored by
+type RowTint int
+
+const (
+	TintNone RowTint = iota
+	TintFormat
+)
+
+// ParseRowTint parses a row_tint config value. Unknown values return false.
+func ParseRowTint(s string) (RowTint, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return TintNone, true
+	case "format":
+		return TintFormat, true
+	}
+	return TintNone, false
+}
+
+// rowColor returns the tint for a track in theme's colors, if it has one.
+// Format tints mark lossless files; lossy ones keep the normal color.
+func (t RowTint) rowColor(theme components.Theme, track *api.Track) (lipgloss.Color, bool) {
+	switch t {
+	case TintFormat:
+		switch strings.ToLower(filepath.Ext(track.FilePath)) {
+		case ".flac":
+			return theme.TintFLAC, true
+		case ".wav":
+			return theme.TintWAV, true
+		}
+	}
+	return "", false
+}
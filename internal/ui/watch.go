@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jscyril/golang_music_player/api"
+	"github.com/jscyril/golang_music_player/internal/logger"
+	"github.com/jscyril/golang_music_player/internal/ui/components"
+)
+
+// LibraryChangedMsg is sent when the library watcher has added tracks for
+// new files and removed those whose files are gone
+type LibraryChangedMsg struct {
+	Added   []*api.Track
+	Removed []*api.Track
+	Errs    []error
+}
+
+// watchLibrary waits for the next watcher event and applies it to the
+// library off the UI goroutine, since new files' metadata must be read
+func (m Model) watchLibrary() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	watcher, lib := m.watcher, m.library
+	return func() tea.Msg {
+		select {
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			added, removed, errs := lib.ApplyWatchEvent(event)
+			return LibraryChangedMsg{Added: added, Removed: removed, Errs: errs}
+		case <-m.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// libraryChanged shows watched changes in the library view
+func (m *Model) libraryChanged(msg LibraryChangedMsg) tea.Cmd {
+	for _, err := range msg.Errs {
+		logger.Warn("Skipped new file: %v", err)
+	}
+	if len(msg.Added) == 0 && len(msg.Removed) == 0 {
+		return nil
+	}
+	m.libraryView.UpdateTracks(msg.Added, msg.Removed)
+	m.refreshPlaylists()
+	m.refreshBrowse()
+	logger.Info("Library changed: %d added, %d removed", len(msg.Added), len(msg.Removed))
+
+	var text string
+	switch {
+	case len(msg.Removed) == 0:
+		text = fmt.Sprintf("Library: %d tracks added", len(msg.Added))
+	case len(msg.Added) == 0:
+		text = fmt.Sprintf("Library: %d tracks removed", len(msg.Removed))
+	default:
+		text = fmt.Sprintf("Library: %d tracks added, %d removed", len(msg.Added), len(msg.Removed))
+	}
+	return m.toast.Notify(text, components.LevelInfo)
+}
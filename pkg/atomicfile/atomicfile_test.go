@@ -0,0 +1,31 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "data.json")
+	if err := WriteData(path, []byte("old")); err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+
+	failed := errors.New("disk full")
+	err := WriteFile(path, func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("WriteFile error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("after a failed write the file holds %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -13,6 +13,10 @@ var (
 	ErrPlaybackFailed   = errors.New("playback failed")
 	ErrEmptyQueue       = errors.New("playback queue is empty")
 	ErrInvalidVolume    = errors.New("volume must be between 0.0 and 1.0")
+	ErrInvalidTrim      = errors.New("trim start must be before trim end")
+	ErrInvalidRating    = errors.New("rating must be between 0 and 5 stars")
+	ErrInvalidGain      = errors.New("gain offset must be between -12 and +12 dB")
+	ErrNoMusicFolder    = errors.New("no music folder given")
 )
 
 // PlayerError wraps errors with additional context
@@ -0,0 +1,60 @@
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned for strings that aren't a recognised timecode
+var ErrInvalid = errors.New("invalid timecode")
+
+// Parse parses a timecode such as "45", "2:30", "1:02:03" or "2:30.5".
+// Components are separated by colons, the last one may have a fraction,
+// and all but the first must be below 60. Go duration strings like "90s"
+// or "1m30s" are accepted too.
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalid
+	}
+	if !strings.Contains(s, ":") && strings.ContainsAny(s, "hms") {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+		return d, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+
+	var total float64
+	for i, part := range parts {
+		last := i == len(parts)-1
+		var v float64
+		if last {
+			f, err := strconv.ParseFloat(part, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || strings.ContainsAny(part, "eE+-") {
+				return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+			}
+			v = f
+		} else {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || strings.ContainsAny(part, "+-") {
+				return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+			}
+			v = float64(n)
+		}
+		if i > 0 && v >= 60 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
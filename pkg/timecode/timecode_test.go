@@ -0,0 +1,39 @@
+package timecode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"45", 45 * time.Second},
+		{"2:00", 2 * time.Minute},
+		{"10:00", 10 * time.Minute},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"2:30.5", 2*time.Minute + 30*time.Second + 500*time.Millisecond},
+		{"90s", 90 * time.Second},
+		{"1m30s", 90 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{"", "abc", "1:60", "1:2:3:4", "-5", "1:-2", ":30", "1e3", "-1m"} {
+		if d, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", in, d)
+		}
+	}
+}